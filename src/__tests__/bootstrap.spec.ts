@@ -0,0 +1,130 @@
+import { installProcessSupervision } from '../bootstrap';
+
+// 只测试进程监管，不加载整个应用
+jest.mock('../app.module', () => ({ AppModule: class {} }));
+jest.mock('../modules/monitoring/services/startup-check.service', () => ({ StartupCheckService: class {} }));
+jest.mock('../common/services/schema-version.service', () => ({ SchemaVersionService: class {} }));
+
+describe('installProcessSupervision', () => {
+  let handlers: Record<string, (...args: any[]) => unknown>;
+  let calls: string[];
+  let spies: jest.SpyInstance[];
+
+  const errorReporter = {
+    flush: jest.fn(async () => {
+      calls.push('flush');
+    }),
+    captureException: jest.fn(),
+  };
+
+  const httpServer = {
+    listening: true,
+    close: jest.fn((callback: () => void) => {
+      calls.push('http');
+      callback();
+    }),
+  };
+
+  const app = {
+    get: jest.fn(() => errorReporter),
+    getHttpServer: jest.fn(() => httpServer),
+    close: jest.fn(async () => {
+      calls.push('app');
+    }),
+  };
+
+  // 等待异步的关闭流程执行完
+  const settle = () => new Promise((resolve) => setImmediate(resolve));
+
+  beforeEach(() => {
+    handlers = {};
+    calls = [];
+    spies = [
+      jest.spyOn(process, 'on').mockImplementation(((event: string, handler: (...args: any[]) => unknown) => {
+        handlers[event] = handler;
+        return process;
+      }) as any),
+      jest.spyOn(process, 'exit').mockImplementation((() => undefined) as any),
+    ];
+  });
+
+  afterEach(() => {
+    spies.forEach((spy) => spy.mockRestore());
+    jest.clearAllMocks();
+    jest.useRealTimers();
+    delete process.env.SHUTDOWN_TIMEOUT_MS;
+  });
+
+  it('收到 SIGTERM 时先停止 HTTP，再关闭应用（等待队列任务和连接），最后上报并退出', async () => {
+    installProcessSupervision(app as any);
+
+    handlers.SIGTERM();
+    await settle();
+
+    expect(calls).toEqual(['http', 'app', 'flush']);
+    expect(process.exit).toHaveBeenCalledWith(0);
+  });
+
+  it('重复的退出信号只关闭一次', async () => {
+    installProcessSupervision(app as any);
+
+    handlers.SIGTERM();
+    handlers.SIGINT();
+    await settle();
+
+    expect(app.close).toHaveBeenCalledTimes(1);
+    expect(process.exit).toHaveBeenCalledTimes(1);
+  });
+
+  it('只消费队列的进程没有 HTTP 服务，直接关闭应用', async () => {
+    const { getHttpServer: _getHttpServer, ...context } = app;
+    const shutdown = installProcessSupervision(context as any);
+
+    await shutdown('test');
+
+    expect(httpServer.close).not.toHaveBeenCalled();
+    expect(calls).toEqual(['app', 'flush']);
+  });
+
+  it('关闭出错时仍然上报并以非零状态退出', async () => {
+    app.close.mockRejectedValueOnce(new Error('redis quit failed'));
+    const shutdown = installProcessSupervision(app as any);
+
+    await shutdown('SIGTERM');
+
+    expect(errorReporter.flush).toHaveBeenCalled();
+    expect(process.exit).toHaveBeenCalledWith(1);
+  });
+
+  it('超过 SHUTDOWN_TIMEOUT_MS 仍未关闭完成时强制退出', async () => {
+    jest.useFakeTimers();
+    process.env.SHUTDOWN_TIMEOUT_MS = '5000';
+    app.close.mockImplementationOnce(() => new Promise(() => undefined));
+    const shutdown = installProcessSupervision(app as any);
+
+    void shutdown('SIGTERM');
+    jest.advanceTimersByTime(5000);
+
+    expect(process.exit).toHaveBeenCalledWith(1);
+  });
+
+  it('未捕获的异常上报后退出，未处理的 Promise 拒绝只上报', async () => {
+    installProcessSupervision(app as any);
+
+    handlers.unhandledRejection(new Error('rejected'));
+    expect(errorReporter.captureException).toHaveBeenCalledWith(expect.any(Error), {
+      source: 'process',
+      tags: { event: 'unhandledRejection' },
+    });
+    expect(app.close).not.toHaveBeenCalled();
+
+    handlers.uncaughtException(new Error('boom'));
+    await settle();
+
+    expect(errorReporter.captureException).toHaveBeenLastCalledWith(expect.any(Error), {
+      source: 'process',
+      tags: { event: 'uncaughtException' },
+    });
+    expect(process.exit).toHaveBeenCalledWith(1);
+  });
+});
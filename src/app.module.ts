@@ -1,5 +1,6 @@
-import { Module } from '@nestjs/common';
-import { ConfigModule, ConfigService } from '@nestjs/config';
+import { MiddlewareConsumer, Module, NestModule } from '@nestjs/common';
+import { ConfigModule } from '@nestjs/config';
+import { APP_FILTER, APP_GUARD } from '@nestjs/core';
 import { MikroOrmModule } from '@mikro-orm/nestjs';
 import { BullModule } from '@nestjs/bull';
 import { HttpModule } from '@nestjs/axios';
@@ -13,35 +14,30 @@ import { PaymentModule } from './modules/payment/payment.module';
 import { PaymentEnhancedModule } from './modules/payment/payment-enhanced.module';
 import { AuditModule } from './modules/audit/audit.module';
 import { MonitoringModule } from './modules/monitoring/monitoring.module';
+import { TenantModule } from './modules/tenant/tenant.module';
+import { GithubModule } from './modules/github/github.module';
+import { LatencyBudgetMiddleware } from './modules/monitoring/middleware/latency-budget.middleware';
+import { RequestIdMiddleware } from './common/middleware/request-id.middleware';
+import { AllExceptionsFilter } from './common/filters/all-exceptions.filter';
+import { ReadOnlyModeGuard } from './common/guards/read-only-mode.guard';
+import { TenantMiddleware } from './modules/tenant/middleware/tenant.middleware';
 import { CommonModule } from './common/common.module';
 import { CustomLogger } from './common/utils/logger.service';
 import { CircuitBreakerService } from './common/utils/circuit-breaker.service';
-import { Options } from '@mikro-orm/core';
+import { buildDatabaseOptions } from './config/database.config';
+import { buildRedisOptions } from './config/redis.config';
+import { resolveProcessRole, runsWorkers } from './config/process-role';
 
 @Module({
   imports: [
     ConfigModule.forRoot({
       isGlobal: true,
     }),
-    MikroOrmModule.forRoot({
-      entities: ['./dist/**/*.entity.js'],
-      entitiesTs: ['./src/**/*.entity.ts'],
-      dbName: process.env.DB_NAME,
-      type: 'postgresql',
-      host: process.env.DB_HOST,
-      port: parseInt(process.env.DB_PORT, 10),
-      user: process.env.DB_USERNAME,
-      password: process.env.DB_PASSWORD,
-      debug: process.env.NODE_ENV === 'development',
-    } as Options),
+    MikroOrmModule.forRoot(buildDatabaseOptions()),
     BullModule.forRootAsync({
-      useFactory: (configService: ConfigService) => ({
-        redis: {
-          host: configService.get('REDIS_HOST', 'localhost'),
-          port: configService.get('REDIS_PORT', 6379),
-        },
+      useFactory: () => ({
+        redis: buildRedisOptions(),
       }),
-      inject: [ConfigService],
     }),
     HttpModule,
     UserModule,
@@ -49,13 +45,25 @@ import { Options } from '@mikro-orm/core';
     SubscriptionModule,
     TranslationModule,
     WebhookModule,
-    WorkerModule,
+    // api 角色不注册队列消费者
+    ...(runsWorkers(resolveProcessRole(process.env.PROCESS_ROLE)) ? [WorkerModule] : []),
     PaymentModule,
     PaymentEnhancedModule,
     AuditModule,
     MonitoringModule,
+    TenantModule,
+    GithubModule,
     CommonModule,
   ],
-  providers: [CustomLogger, CircuitBreakerService],
+  providers: [
+    CustomLogger,
+    CircuitBreakerService,
+    { provide: APP_FILTER, useClass: AllExceptionsFilter },
+    { provide: APP_GUARD, useClass: ReadOnlyModeGuard },
+  ],
 })
-export class AppModule {} 
\ No newline at end of file
+export class AppModule implements NestModule {
+  configure(consumer: MiddlewareConsumer) {
+    consumer.apply(RequestIdMiddleware, LatencyBudgetMiddleware, TenantMiddleware).forRoutes('*');
+  }
+} 
\ No newline at end of file
@@ -0,0 +1,169 @@
+import { NestFactory } from '@nestjs/core';
+import { INestApplication, INestApplicationContext, Logger, ValidationPipe } from '@nestjs/common';
+import { SchedulerRegistry } from '@nestjs/schedule';
+import { NestExpressApplication } from '@nestjs/platform-express';
+import { DocumentBuilder, SwaggerModule } from '@nestjs/swagger';
+import { AppModule } from './app.module';
+import { CustomLogger } from './common/utils/logger.service';
+import { ErrorReporterService } from './common/services/error-reporter.service';
+import { StartupCheckService } from './modules/monitoring/services/startup-check.service';
+import { SchemaVersionService } from './common/services/schema-version.service';
+import { resolveTrustProxy } from './common/utils/client-ip';
+import {
+  ProcessRole,
+  isReadOnlyMode,
+  resolveWorkerQueues,
+  runsWorkers,
+  servesHttp,
+} from './config/process-role';
+
+const logger = new Logger('Process');
+
+/**
+ * 按进程角色启动应用
+ * api / all 启动 HTTP 服务，worker 只创建应用上下文消费队列
+ */
+export async function bootstrap(role: ProcessRole): Promise<void> {
+  const readOnly = isReadOnlyMode();
+  if (readOnly && role !== ProcessRole.API) {
+    logger.error('READ_ONLY_MODE requires PROCESS_ROLE=api');
+    process.exit(1);
+  }
+
+  const app = servesHttp(role)
+    ? await NestFactory.create(AppModule, { logger: new CustomLogger(), rawBody: true })
+    : await NestFactory.createApplicationContext(AppModule, { logger: new CustomLogger() });
+
+  const shutdown = installProcessSupervision(app);
+
+  // 数据库结构版本检查：构建需要的迁移未执行、或库超出兼容窗口时拒绝启动
+  try {
+    await app.get(SchemaVersionService).assertCompatible();
+  } catch (error) {
+    logger.error(error.message);
+    await shutdown('schema-version', 1);
+    return;
+  }
+
+  // 启动自检：必需依赖不可用时打印修复提示并退出，STARTUP_CHECK_STRICT=false 时只告警
+  if (process.env.STARTUP_CHECK !== 'false') {
+    const report = await app.get(StartupCheckService).run();
+    if (!report.ok && process.env.STARTUP_CHECK_STRICT !== 'false') {
+      logger.error('Startup self-test failed, exiting');
+      await shutdown('startup-check', 1);
+      return;
+    }
+  }
+
+  // 只读副本不运行定时任务（对账、计费、统计等都会写库），这些任务由主 API 和 worker 负责
+  if (readOnly) {
+    stopScheduledJobs(app);
+  }
+
+  if (servesHttp(role)) {
+    await listen(app as NestExpressApplication);
+  }
+  const queues = runsWorkers(role) ? `, consuming ${resolveWorkerQueues(process.env.WORKER_QUEUES).join(', ')}` : '';
+  logger.log(`Started in ${role} mode${readOnly ? ' (read-only)' : ''}${queues}`);
+}
+
+function stopScheduledJobs(app: INestApplicationContext): void {
+  const registry = app.get(SchedulerRegistry);
+  for (const job of registry.getCronJobs().values()) {
+    job.stop();
+  }
+  for (const name of registry.getIntervals()) {
+    registry.deleteInterval(name);
+  }
+  for (const name of registry.getTimeouts()) {
+    registry.deleteTimeout(name);
+  }
+}
+
+async function listen(app: NestExpressApplication): Promise<void> {
+  // 客户端 IP 用于试用密钥的签发限制、密钥的 IP 绑定和审计日志，只信任 TRUST_PROXY 配置的反向代理写入的 X-Forwarded-For
+  app.set('trust proxy', resolveTrustProxy(process.env.TRUST_PROXY));
+
+  // 请求体上限跟随 JSON_MAX_BYTES（默认 10MB）：文档以转义后的字符串放在请求体中，按两倍加上其他字段的余量；
+  // 超过时 body-parser 直接返回 413，不读入整个请求体
+  const jsonMaxBytes = Number(process.env.JSON_MAX_BYTES || 10 * 1024 * 1024);
+  const bodyLimit = jsonMaxBytes * 2 + 64 * 1024;
+  app.useBodyParser('json', { limit: bodyLimit });
+  app.useBodyParser('urlencoded', { limit: bodyLimit, extended: true });
+
+  // 全局验证管道
+  app.useGlobalPipes(new ValidationPipe());
+
+  // Swagger 配置
+  const config = new DocumentBuilder()
+    .setTitle('JSON Translation API')
+    .setDescription('API for JSON translation with Stripe payment integration')
+    .setVersion('1.0')
+    .addBearerAuth()
+    .addApiKey({ type: 'apiKey', name: 'X-API-Key', in: 'header' }, 'api-key')
+    .build();
+  const document = SwaggerModule.createDocument(app, config);
+  SwaggerModule.setup('api', app, document);
+
+  // 全局前缀
+  app.setGlobalPrefix('api/v1');
+
+  await app.listen(Number(process.env.PORT || 3000));
+}
+
+/**
+ * 进程监管：统一处理退出信号和未捕获异常
+ * 关闭顺序：先停止接收 HTTP 请求，再等待正在执行的队列任务结束，最后关闭共享的 Redis / 数据库连接；
+ * 超过 SHUTDOWN_TIMEOUT_MS 仍未完成时强制退出
+ */
+export function installProcessSupervision(
+  app: INestApplicationContext,
+): (reason: string, exitCode?: number) => Promise<void> {
+  const errorReporter = app.get(ErrorReporterService);
+  const timeoutMs = Number(process.env.SHUTDOWN_TIMEOUT_MS || 30000);
+  let shuttingDown = false;
+
+  const shutdown = async (reason: string, exitCode = 0) => {
+    if (shuttingDown) {
+      return;
+    }
+    shuttingDown = true;
+    logger.log(`Shutting down (${reason})`);
+
+    const timer = setTimeout(() => {
+      logger.error(`Graceful shutdown timed out after ${timeoutMs}ms, forcing exit`);
+      process.exit(1);
+    }, timeoutMs);
+    timer.unref();
+
+    try {
+      const httpServer = 'getHttpServer' in app ? (app as INestApplication).getHttpServer() : undefined;
+      if (httpServer?.listening) {
+        await new Promise<void>((resolve) => httpServer.close(() => resolve()));
+      }
+      // 触发各模块的关闭钩子：Bull 队列等待活动任务完成，随后断开共享连接
+      await app.close();
+    } catch (error) {
+      logger.error(`Error during shutdown: ${error.stack ?? error}`);
+      exitCode = exitCode || 1;
+    }
+    await errorReporter.flush();
+    process.exit(exitCode);
+  };
+
+  process.on('SIGTERM', () => shutdown('SIGTERM'));
+  process.on('SIGINT', () => shutdown('SIGINT'));
+
+  // 未捕获的异常：上报后退出，避免进程在未知状态下继续运行
+  process.on('unhandledRejection', (reason) => {
+    logger.error(`Unhandled rejection: ${reason instanceof Error ? reason.stack : reason}`);
+    errorReporter.captureException(reason, { source: 'process', tags: { event: 'unhandledRejection' } });
+  });
+  process.on('uncaughtException', (error) => {
+    logger.error(`Uncaught exception: ${error.stack}`);
+    errorReporter.captureException(error, { source: 'process', tags: { event: 'uncaughtException' } });
+    void shutdown('uncaughtException', 1);
+  });
+
+  return shutdown;
+}
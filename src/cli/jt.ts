@@ -0,0 +1,32 @@
+import 'dotenv/config';
+import { ProcessRole } from '../config/process-role';
+
+/**
+ * jt 命令
+ *
+ *   jt api      只运行 HTTP 接口
+ *   jt api --read-only
+ *               只读维护副本：只响应查询类接口，不写库、不入队
+ *   jt worker   只运行队列消费者
+ *   jt all      在同一进程内运行接口和消费者，共享连接并协调关闭
+ */
+async function main() {
+  const [command, ...flags] = process.argv.slice(2);
+  if (!Object.values(ProcessRole).includes(command as ProcessRole)) {
+    console.log('Usage: jt <api|worker|all> [--read-only]');
+    process.exit(command ? 1 : 0);
+  }
+  if (flags.includes('--read-only')) {
+    process.env.READ_ONLY_MODE = 'true';
+  }
+
+  // AppModule 在导入时读取 PROCESS_ROLE 决定是否注册队列消费者，因此必须先设置再加载
+  process.env.PROCESS_ROLE = command;
+  const { bootstrap } = await import('../bootstrap');
+  await bootstrap(command as ProcessRole);
+}
+
+main().catch((error) => {
+  console.error(error);
+  process.exit(1);
+});
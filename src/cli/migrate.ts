@@ -0,0 +1,60 @@
+import 'dotenv/config';
+import { MikroORM } from '@mikro-orm/core';
+import { buildDatabaseOptions } from '../config/database.config';
+
+/**
+ * 数据库迁移命令
+ *
+ *   npm run migrate -- up            执行所有未执行的迁移
+ *   npm run migrate -- up --to <name>
+ *   npm run migrate -- down          回滚最近一次迁移
+ *   npm run migrate -- status        查看已执行 / 待执行的迁移
+ *   npm run migrate -- create <name> 生成空白迁移文件
+ */
+async function main() {
+  const [command = 'status', ...args] = process.argv.slice(2);
+  const orm = await MikroORM.init({
+    ...buildDatabaseOptions(),
+    debug: false,
+    discovery: { warnWhenNoEntities: false },
+  });
+  const migrator = orm.getMigrator();
+
+  try {
+    switch (command) {
+      case 'up': {
+        const toIndex = args.indexOf('--to');
+        const migrations = await migrator.up(toIndex >= 0 ? { to: args[toIndex + 1] } : undefined);
+        console.log(migrations.length ? `Applied: ${migrations.map((m) => m.name).join(', ')}` : 'Nothing to migrate');
+        break;
+      }
+      case 'down': {
+        const migrations = await migrator.down();
+        console.log(migrations.length ? `Reverted: ${migrations.map((m) => m.name).join(', ')}` : 'Nothing to revert');
+        break;
+      }
+      case 'status': {
+        const executed = await migrator.getExecutedMigrations();
+        const pending = await migrator.getPendingMigrations();
+        executed.forEach((m) => console.log(`  [x] ${m.name}  (${m.executed_at.toISOString()})`));
+        pending.forEach((m) => console.log(`  [ ] ${m.name}`));
+        console.log(`${executed.length} executed, ${pending.length} pending`);
+        break;
+      }
+      case 'create': {
+        const result = await migrator.createMigration(undefined, true, false, args[0]);
+        console.log(`Created ${result.fileName}`);
+        break;
+      }
+      default:
+        throw new Error(`Unknown command "${command}", expected one of: up, down, status, create`);
+    }
+  } finally {
+    await orm.close(true);
+  }
+}
+
+main().catch((error) => {
+  console.error(error.message);
+  process.exit(1);
+});
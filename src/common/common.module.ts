@@ -4,6 +4,14 @@ import { MikroOrmModule } from '@mikro-orm/nestjs';
 // 共享服务
 import { IdempotencyService } from './services/idempotency.service';
 import { PartitionManagerService } from './services/partition-manager.service';
+import { RedisService } from './services/redis.service';
+import { StorageDriverService } from './services/storage-driver.service';
+import { MailService } from './services/mail.service';
+import { ErrorReporterService } from './services/error-reporter.service';
+import { SchemaVersionService } from './services/schema-version.service';
+import { ObjectStorageService } from './services/object-storage.service';
+import { SecretBoxService } from './services/secret-box.service';
+import { RequestIdMiddleware } from './middleware/request-id.middleware';
 
 /**
  * 通用模块
@@ -18,10 +26,26 @@ import { PartitionManagerService } from './services/partition-manager.service';
   providers: [
     IdempotencyService,
     PartitionManagerService,
+    RedisService,
+    StorageDriverService,
+    MailService,
+    ErrorReporterService,
+    SchemaVersionService,
+    ObjectStorageService,
+    SecretBoxService,
+    RequestIdMiddleware,
   ],
   exports: [
     IdempotencyService,
     PartitionManagerService,
+    RedisService,
+    StorageDriverService,
+    MailService,
+    ErrorReporterService,
+    SchemaVersionService,
+    ObjectStorageService,
+    SecretBoxService,
+    RequestIdMiddleware,
   ],
 })
-export class CommonModule {}
\ No newline at end of file
+export class CommonModule {}
@@ -0,0 +1,8 @@
+import { SetMetadata } from '@nestjs/common';
+
+export const PLAYGROUND_ALLOWED_KEY = 'playgroundAllowed';
+
+/**
+ * 标记文档站试运行密钥可以调用的接口（创建任务、查询自己任务的状态和结果），其余接口拒绝试运行密钥
+ */
+export const PlaygroundAllowed = () => SetMetadata(PLAYGROUND_ALLOWED_KEY, true);
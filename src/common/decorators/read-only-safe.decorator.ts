@@ -0,0 +1,8 @@
+import { SetMetadata } from '@nestjs/common';
+
+export const READ_ONLY_SAFE_KEY = 'readOnlySafe';
+
+/**
+ * 标记不写数据的 POST 接口（估算、检测、合并等），只读维护模式下仍然可用
+ */
+export const ReadOnlySafe = () => SetMetadata(READ_ONLY_SAFE_KEY, true);
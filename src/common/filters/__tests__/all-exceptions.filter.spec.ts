@@ -0,0 +1,111 @@
+import { ArgumentsHost, BadRequestException, ConflictException, NotFoundException } from '@nestjs/common';
+import { AllExceptionsFilter } from '../all-exceptions.filter';
+import { ErrorReporterService } from '../../services/error-reporter.service';
+
+describe('AllExceptionsFilter', () => {
+  const errorReporter = { captureException: jest.fn() } as unknown as ErrorReporterService;
+  const filter = new AllExceptionsFilter(errorReporter);
+
+  const createHost = (acceptLanguage?: string) => {
+    const res = {
+      headersSent: false,
+      status: jest.fn().mockReturnThis(),
+      json: jest.fn().mockReturnThis(),
+      setHeader: jest.fn(),
+    };
+    const req = {
+      method: 'POST',
+      originalUrl: '/api/v1/translation/task',
+      requestId: 'req-1',
+      user: { id: 'user1' },
+      headers: acceptLanguage ? { 'accept-language': acceptLanguage } : {},
+    };
+    const host = {
+      getType: () => 'http',
+      switchToHttp: () => ({ getRequest: () => req, getResponse: () => res }),
+    } as unknown as ArgumentsHost;
+    return { host, res };
+  };
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('HttpException 应保留状态码并附带请求 ID，不上报', () => {
+    const { host, res } = createHost();
+
+    filter.catch(new NotFoundException('Tenant not found'), host);
+
+    expect(res.status).toHaveBeenCalledWith(404);
+    expect(res.json).toHaveBeenCalledWith(expect.objectContaining({ message: 'Tenant not found', requestId: 'req-1' }));
+    expect(errorReporter.captureException).not.toHaveBeenCalled();
+  });
+
+  it('未处理的异常应转换为 500 并上报', () => {
+    const { host, res } = createHost();
+    const error = new TypeError("Cannot read properties of undefined (reading 'id')");
+
+    filter.catch(error, host);
+
+    expect(res.status).toHaveBeenCalledWith(500);
+    expect(res.json).toHaveBeenCalledWith({
+      statusCode: 500,
+      code: 'INTERNAL_ERROR',
+      message: 'Internal server error',
+      requestId: 'req-1',
+    });
+    expect(errorReporter.captureException).toHaveBeenCalledWith(
+      error,
+      expect.objectContaining({ requestId: 'req-1', userId: 'user1' }),
+    );
+  });
+
+  it('应按 Accept-Language 渲染目录中的消息并返回错误码', () => {
+    const { host, res } = createHost('zh-CN,zh;q=0.9,en;q=0.8');
+
+    filter.catch(new NotFoundException('Translation not found'), host);
+
+    expect(res.json).toHaveBeenCalledWith(
+      expect.objectContaining({ statusCode: 404, code: 'TRANSLATION_NOT_FOUND', message: '翻译不存在' }),
+    );
+    expect(res.setHeader).toHaveBeenCalledWith('Content-Language', 'zh');
+  });
+
+  it('目录中没有的消息应按状态码归类，并保留英文原文', () => {
+    const { host, res } = createHost('ja');
+
+    filter.catch(new ConflictException('Legal hold has already been released'), host);
+
+    expect(res.json).toHaveBeenCalledWith(
+      expect.objectContaining({
+        code: 'CONFLICT',
+        message: '現在の状態と競合しています',
+        detail: 'Legal hold has already been released',
+      }),
+    );
+  });
+
+  it('参数校验错误应返回 VALIDATION_FAILED 并保留各条原文', () => {
+    const { host, res } = createHost('zh');
+
+    filter.catch(new BadRequestException(['targetLang must be a string']), host);
+
+    expect(res.json).toHaveBeenCalledWith(
+      expect.objectContaining({
+        code: 'VALIDATION_FAILED',
+        message: '请求参数校验失败',
+        details: ['targetLang must be a string'],
+      }),
+    );
+  });
+
+  it('500 响应也应本地化', () => {
+    const { host, res } = createHost('ja-JP');
+
+    filter.catch(new Error('boom'), host);
+
+    expect(res.json).toHaveBeenCalledWith(
+      expect.objectContaining({ code: 'INTERNAL_ERROR', message: 'サーバー内部エラーが発生しました' }),
+    );
+  });
+});
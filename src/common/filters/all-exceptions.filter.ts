@@ -0,0 +1,90 @@
+import { ArgumentsHost, Catch, ExceptionFilter, HttpException, HttpStatus, Logger } from '@nestjs/common';
+import { Request, Response } from 'express';
+import { ErrorReporterService } from '../services/error-reporter.service';
+import { ErrorLocale, negotiateErrorLocale, renderError, resolveError } from '../i18n/error-catalog';
+
+/**
+ * 全局异常过滤器
+ * HttpException 按原样返回并附带 requestId；其他未处理的异常记录日志、上报错误追踪，
+ * 并统一转换为带 requestId 的 500 响应
+ * 响应都带稳定的错误码 code，message 按 Accept-Language 从错误消息目录渲染（支持 en/zh/ja）
+ */
+@Catch()
+export class AllExceptionsFilter implements ExceptionFilter {
+  private readonly logger = new Logger(AllExceptionsFilter.name);
+
+  constructor(private readonly errorReporter: ErrorReporterService) {}
+
+  catch(exception: unknown, host: ArgumentsHost) {
+    if (host.getType() !== 'http') {
+      throw exception;
+    }
+
+    const ctx = host.switchToHttp();
+    const req = ctx.getRequest<Request & { requestId?: string; tenantSlug?: string; user?: { id: string } }>();
+    const res = ctx.getResponse<Response>();
+    const requestId = req.requestId;
+    const locale = negotiateErrorLocale(req.headers?.['accept-language']);
+
+    if (exception instanceof HttpException) {
+      const status = exception.getStatus();
+      const body = exception.getResponse();
+      if (!res.headersSent) {
+        const fields = typeof body === 'string' ? { statusCode: status, message: body } : (body as Record<string, any>);
+        this.setLocaleHeaders(res, locale);
+        res.status(status).json({ ...fields, ...this.localize(fields, status, locale), requestId });
+      }
+      return;
+    }
+
+    const error = exception instanceof Error ? exception : new Error(String(exception));
+    this.logger.error(`Unhandled error on ${req.method} ${req.originalUrl} [${requestId}]: ${error.message}`, error.stack);
+    this.errorReporter.captureException(error, {
+      requestId,
+      userId: req.user?.id,
+      source: 'api',
+      tags: req.tenantSlug ? { tenant: req.tenantSlug } : undefined,
+      extra: { method: req.method, url: req.originalUrl, params: req.params },
+    });
+
+    if (!res.headersSent) {
+      this.setLocaleHeaders(res, locale);
+      res.status(HttpStatus.INTERNAL_SERVER_ERROR).json({
+        statusCode: HttpStatus.INTERNAL_SERVER_ERROR,
+        code: 'INTERNAL_ERROR',
+        message: renderError('INTERNAL_ERROR', locale),
+        requestId,
+      });
+    }
+  }
+
+  /**
+   * 目录中没有的消息：英文保持原文，其他语言使用状态码对应的通用消息并把原文放在 detail 中；
+   * 参数校验错误（消息数组）的各条原文放在 details 中
+   */
+  private localize(fields: Record<string, any>, status: number, locale: ErrorLocale) {
+    if (Array.isArray(fields.message)) {
+      const code = status === HttpStatus.BAD_REQUEST ? 'VALIDATION_FAILED' : resolveError(undefined, status).code;
+      return {
+        code,
+        message: locale === 'en' ? fields.message : renderError(code, locale),
+        details: fields.message,
+      };
+    }
+
+    const original = typeof fields.message === 'string' ? fields.message : undefined;
+    const { code, params, matched } = resolveError(original, status);
+    if (matched || !original) {
+      return { code, message: renderError(code, locale, params) };
+    }
+    if (locale === 'en') {
+      return { code, message: original };
+    }
+    return { code, message: renderError(code, locale), detail: original };
+  }
+
+  private setLocaleHeaders(res: Response, locale: ErrorLocale): void {
+    res.setHeader('Content-Language', locale);
+    res.setHeader('Vary', 'Accept-Language');
+  }
+}
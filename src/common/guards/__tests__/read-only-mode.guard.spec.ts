@@ -0,0 +1,52 @@
+import { ExecutionContext, ServiceUnavailableException } from '@nestjs/common';
+import { Reflector } from '@nestjs/core';
+import { ReadOnlyModeGuard } from '../read-only-mode.guard';
+import { ReadOnlySafe } from '../../decorators/read-only-safe.decorator';
+
+class SampleController {
+  update() {}
+
+  @ReadOnlySafe()
+  estimate() {}
+}
+
+describe('ReadOnlyModeGuard', () => {
+  const response = { setHeader: jest.fn() };
+
+  const contextFor = (method: string, handler: () => void): ExecutionContext =>
+    ({
+      getType: () => 'http',
+      getHandler: () => handler,
+      getClass: () => SampleController,
+      switchToHttp: () => ({ getRequest: () => ({ method }), getResponse: () => response }),
+    }) as any;
+
+  afterEach(() => {
+    delete process.env.READ_ONLY_MODE;
+    jest.clearAllMocks();
+  });
+
+  it('未开启只读模式时不拦截任何请求', () => {
+    const guard = new ReadOnlyModeGuard(new Reflector());
+
+    expect(guard.canActivate(contextFor('POST', SampleController.prototype.update))).toBe(true);
+  });
+
+  it('只读模式下放行查询请求和标记为 @ReadOnlySafe() 的接口', () => {
+    process.env.READ_ONLY_MODE = 'true';
+    const guard = new ReadOnlyModeGuard(new Reflector());
+
+    expect(guard.canActivate(contextFor('GET', SampleController.prototype.update))).toBe(true);
+    expect(guard.canActivate(contextFor('POST', SampleController.prototype.estimate))).toBe(true);
+  });
+
+  it('只读模式下修改数据的请求应返回 503', () => {
+    process.env.READ_ONLY_MODE = 'true';
+    const guard = new ReadOnlyModeGuard(new Reflector());
+
+    expect(() => guard.canActivate(contextFor('DELETE', SampleController.prototype.update))).toThrow(
+      ServiceUnavailableException,
+    );
+    expect(response.setHeader).toHaveBeenCalledWith('Retry-After', '60');
+  });
+});
@@ -0,0 +1,35 @@
+import { CanActivate, ExecutionContext, Injectable, ServiceUnavailableException } from '@nestjs/common';
+import { Reflector } from '@nestjs/core';
+import { isReadOnlyMode } from '../../config/process-role';
+import { READ_ONLY_SAFE_KEY } from '../decorators/read-only-safe.decorator';
+
+const READ_METHODS = new Set(['GET', 'HEAD', 'OPTIONS']);
+
+/**
+ * 只读维护模式下拒绝会修改数据的请求（503），查询类接口和标记为 @ReadOnlySafe() 的接口照常处理
+ */
+@Injectable()
+export class ReadOnlyModeGuard implements CanActivate {
+  private readonly enabled = isReadOnlyMode();
+
+  constructor(private readonly reflector: Reflector) {}
+
+  canActivate(context: ExecutionContext): boolean {
+    if (!this.enabled || context.getType() !== 'http') {
+      return true;
+    }
+    const request = context.switchToHttp().getRequest();
+    if (READ_METHODS.has(request.method)) {
+      return true;
+    }
+    const safe = this.reflector.getAllAndOverride<boolean>(READ_ONLY_SAFE_KEY, [
+      context.getHandler(),
+      context.getClass(),
+    ]);
+    if (safe) {
+      return true;
+    }
+    context.switchToHttp().getResponse().setHeader('Retry-After', '60');
+    throw new ServiceUnavailableException('This API replica is read-only; send write requests to the primary API');
+  }
+}
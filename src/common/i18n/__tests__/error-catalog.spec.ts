@@ -0,0 +1,34 @@
+import { ERROR_CATALOG, negotiateErrorLocale, renderError, resolveError } from '../error-catalog';
+
+describe('error-catalog', () => {
+  it('每个错误码都应有 en/zh/ja 三种消息，且占位参数一致', () => {
+    for (const [code, entry] of Object.entries(ERROR_CATALOG)) {
+      const params = (template: string) => (template.match(/\{\w+\}/g) ?? []).sort();
+      expect([code, params(entry.zh)]).toEqual([code, params(entry.en)]);
+      expect([code, params(entry.ja)]).toEqual([code, params(entry.en)]);
+    }
+  });
+
+  it('应从英文消息中解析出错误码和参数', () => {
+    expect(resolveError('Translation provider deepl rate limit reached', 429)).toEqual({
+      code: 'PROVIDER_RATE_LIMITED',
+      params: { provider: 'deepl' },
+      matched: true,
+    });
+    expect(renderError('PROVIDER_RATE_LIMITED', 'zh', { provider: 'deepl' })).toBe('翻译服务 deepl 已达到速率限制');
+  });
+
+  it('未登记的消息应按状态码归类', () => {
+    expect(resolveError('Something odd', 404)).toEqual({ code: 'NOT_FOUND', params: {}, matched: false });
+    expect(resolveError('Something odd', 502).code).toBe('INTERNAL_ERROR');
+    expect(resolveError('Something odd', 418).code).toBe('BAD_REQUEST');
+  });
+
+  it('应按 q 权重协商语言，不支持时回退到英文', () => {
+    expect(negotiateErrorLocale('fr-FR,ja;q=0.5,zh;q=0.8')).toBe('zh');
+    expect(negotiateErrorLocale('ja-JP')).toBe('ja');
+    expect(negotiateErrorLocale('fr, de;q=0.9')).toBe('en');
+    expect(negotiateErrorLocale('zh;q=0, ja;q=0.1')).toBe('ja');
+    expect(negotiateErrorLocale(undefined)).toBe('en');
+  });
+});
@@ -0,0 +1,496 @@
+import { HttpStatus } from '@nestjs/common';
+
+export type ErrorLocale = 'en' | 'zh' | 'ja';
+
+export const SUPPORTED_ERROR_LOCALES: ErrorLocale[] = ['en', 'zh', 'ja'];
+
+interface ErrorCatalogEntry {
+  /** 英文模板，与代码中抛出的异常消息一致，{name} 为参数占位 */
+  en: string;
+  zh: string;
+  ja: string;
+}
+
+/**
+ * 错误消息目录：稳定的错误码 → 各语言模板
+ * 异常仍然用英文消息抛出，由全局过滤器按英文模板匹配出错误码和参数，再按 Accept-Language 渲染
+ */
+export const ERROR_CATALOG: Record<string, ErrorCatalogEntry> = {
+  TRANSLATION_NOT_FOUND: { en: 'Translation not found', zh: '翻译不存在', ja: '翻訳が見つかりません' },
+  TRANSLATION_NOT_FINISHED: {
+    en: 'Translation has not finished yet',
+    zh: '翻译尚未完成',
+    ja: '翻訳はまだ完了していません',
+  },
+  TRANSLATION_IN_PROGRESS: {
+    en: 'Translation is still in progress',
+    zh: '翻译仍在进行中',
+    ja: '翻訳はまだ処理中です',
+  },
+  TRANSLATION_CANCELED: {
+    en: 'Translation task has been canceled',
+    zh: '翻译任务已取消',
+    ja: '翻訳タスクはキャンセルされました',
+  },
+  TRANSLATION_NO_FAILED_CHUNKS: {
+    en: 'Translation has no failed chunks',
+    zh: '翻译没有失败的分片',
+    ja: '失敗したチャンクはありません',
+  },
+  TRANSLATION_NO_FAILED_KEYS: {
+    en: 'Translation has no failed keys',
+    zh: '翻译没有失败的键',
+    ja: '翻訳に失敗したキーはありません',
+  },
+  TRANSLATION_ARCHIVED: {
+    en: 'Archived translations cannot be edited',
+    zh: '已归档的翻译不能编辑',
+    ja: 'アーカイブ済みの翻訳は編集できません',
+  },
+  TRANSLATION_KEY_NOT_FOUND: {
+    en: 'Key "{key}" does not exist or is not a translated value',
+    zh: '键 "{key}" 不存在或不是已翻译的值',
+    ja: 'キー "{key}" は存在しないか、翻訳済みの値ではありません',
+  },
+  DOCUMENT_LOCKED: {
+    en: 'Document is locked for editing',
+    zh: '文档正在人工编辑，已被锁定',
+    ja: 'ドキュメントは編集のためロックされています',
+  },
+  INVALID_JSON: { en: 'Invalid JSON content', zh: 'JSON 内容无效', ja: 'JSON の内容が不正です' },
+  MISSING_REQUEST_BODY: { en: 'Missing request body', zh: '缺少请求体', ja: 'リクエストボディがありません' },
+  SOURCE_LANGUAGE_UNDETECTED: {
+    en: 'Source language could not be detected: no translatable text found',
+    zh: '无法识别源语言：没有可翻译的文本',
+    ja: '原文の言語を判定できません：翻訳対象のテキストがありません',
+  },
+  QUOTA_EXCEEDED: {
+    en: 'Monthly character quota exceeded',
+    zh: '已超出本月字符额度',
+    ja: '今月の文字数クォータを超えました',
+  },
+  QUOTA_CAP_REACHED: {
+    en: 'Monthly character cap reached',
+    zh: '已达到本月字符上限',
+    ja: '今月の文字数上限に達しました',
+  },
+  QUEUE_CONGESTED: {
+    en: 'Translation queue is congested, retry later',
+    zh: '翻译队列繁忙，请稍后重试',
+    ja: '翻訳キューが混雑しています。しばらくしてから再試行してください',
+  },
+  PROVIDER_RATE_LIMITED: {
+    en: 'Translation provider {provider} rate limit reached',
+    zh: '翻译服务 {provider} 已达到速率限制',
+    ja: '翻訳プロバイダー {provider} のレート制限に達しました',
+  },
+  PROVIDER_UNAVAILABLE: {
+    en: 'Translation provider {provider} is unavailable (circuit open)',
+    zh: '翻译服务 {provider} 暂不可用（已熔断）',
+    ja: '翻訳プロバイダー {provider} は現在利用できません（サーキットオープン）',
+  },
+  STORAGE_DOCUMENT_LIMIT: {
+    en: 'Stored document limit reached ({limit} documents); delete old documents or upgrade your plan',
+    zh: '已达到文档存储上限（{limit} 个文档），请删除旧文档或升级套餐',
+    ja: '保存できるドキュメント数の上限（{limit} 件）に達しました。古いドキュメントを削除するかプランをアップグレードしてください',
+  },
+  STORAGE_BYTES_LIMIT: {
+    en: 'Storage limit exceeded ({limit} bytes); delete old documents or upgrade your plan',
+    zh: '已超出存储空间上限（{limit} 字节），请删除旧文档或升级套餐',
+    ja: 'ストレージの上限（{limit} バイト）を超えました。古いドキュメントを削除するかプランをアップグレードしてください',
+  },
+  USER_NOT_FOUND: { en: 'User not found', zh: '用户不存在', ja: 'ユーザーが見つかりません' },
+  EMAIL_EXISTS: { en: 'Email already exists', zh: '邮箱已被注册', ja: 'このメールアドレスは既に登録されています' },
+  INVALID_CREDENTIALS: {
+    en: 'Invalid credentials',
+    zh: '用户名或密码错误',
+    ja: '認証情報が正しくありません',
+  },
+  ADMIN_REQUIRED: { en: 'Admin privileges required', zh: '需要管理员权限', ja: '管理者権限が必要です' },
+  API_KEY_NOT_FOUND: { en: 'API Key not found', zh: 'API Key 不存在', ja: 'API キーが見つかりません' },
+  API_KEY_INVALID: {
+    en: 'Invalid or expired API key',
+    zh: 'API Key 无效或已过期',
+    ja: 'API キーが無効か、有効期限が切れています',
+  },
+  API_KEY_SIGNED_ONLY: {
+    en: 'This API key only accepts signed requests',
+    zh: '该 API Key 只接受签名请求',
+    ja: 'この API キーは署名付きリクエストのみ受け付けます',
+  },
+  API_KEY_IP_NOT_ALLOWED: {
+    en: 'This API key cannot be used from this IP address',
+    zh: '该 API Key 不能在当前 IP 地址使用',
+    ja: 'この API キーはこの IP アドレスからは使用できません',
+  },
+  API_KEY_CHARACTER_LIMIT: {
+    en: 'This API key is limited to {limit} characters',
+    zh: '该 API Key 最多可翻译 {limit} 个字符',
+    ja: 'この API キーで翻訳できるのは {limit} 文字までです',
+  },
+  SIGNATURE_INVALID: { en: 'Invalid request signature', zh: '请求签名无效', ja: 'リクエスト署名が無効です' },
+  SIGNATURE_REPLAYED: {
+    en: 'Request signature has already been used',
+    zh: '请求签名已被使用',
+    ja: 'このリクエスト署名は既に使用されています',
+  },
+  ACCOUNT_LOCKED: {
+    en: 'API access for this account is locked',
+    zh: '该账号的 API 访问已被锁定',
+    ja: 'このアカウントの API アクセスはロックされています',
+  },
+  PLAYGROUND_DISABLED: {
+    en: 'The API playground is not enabled',
+    zh: 'API 试用未开放',
+    ja: 'API プレイグラウンドは有効になっていません',
+  },
+  PLAYGROUND_KEY_LIMIT: {
+    en: 'Too many playground keys requested from this IP address',
+    zh: '该 IP 地址申请的试用 Key 过多',
+    ja: 'この IP アドレスからのプレイグラウンドキーの発行が多すぎます',
+  },
+  WEBHOOK_PAID_ONLY: {
+    en: 'Webhook functionality is only available for paid users',
+    zh: 'Webhook 功能仅对付费用户开放',
+    ja: 'Webhook 機能は有料ユーザーのみ利用できます',
+  },
+  WEBHOOK_CONFIG_NOT_FOUND: {
+    en: 'Webhook config not found',
+    zh: 'Webhook 配置不存在',
+    ja: 'Webhook 設定が見つかりません',
+  },
+  INVALID_TIMESTAMP: {
+    en: 'Invalid {field} timestamp',
+    zh: '{field} 时间格式无效',
+    ja: '{field} の日時形式が不正です',
+  },
+  INVALID_TIME_RANGE: {
+    en: 'create_time_min must not be after create_time_max',
+    zh: 'create_time_min 不能晚于 create_time_max',
+    ja: 'create_time_min は create_time_max より後にできません',
+  },
+  WEBHOOK_HEADER_INVALID: {
+    en: 'Invalid webhook header {name}',
+    zh: 'Webhook 请求头 {name} 无效',
+    ja: 'Webhook ヘッダー {name} が不正です',
+  },
+  WEBHOOK_HEADER_RESERVED: {
+    en: 'Webhook header {name} is set by the delivery worker',
+    zh: 'Webhook 请求头 {name} 由推送服务设置，不能覆盖',
+    ja: 'Webhook ヘッダー {name} は配信側で設定されるため指定できません',
+  },
+  WEBHOOK_AUTH_CONFLICT: {
+    en: 'Use either basicAuth or an Authorization header, not both',
+    zh: 'basicAuth 和 Authorization 请求头只能选择一种',
+    ja: 'basicAuth と Authorization ヘッダーはどちらか一方のみ指定できます',
+  },
+  WEBHOOK_HEADER_LIMIT: {
+    en: 'At most {limit} webhook headers are allowed',
+    zh: 'Webhook 请求头最多 {limit} 个',
+    ja: 'Webhook ヘッダーは最大 {limit} 個までです',
+  },
+  WEBHOOK_TLS_PLAN_REQUIRED: {
+    en: 'Webhook TLS settings require a plan with custom integrations',
+    zh: 'Webhook TLS 设置需要支持自定义集成的计划',
+    ja: 'Webhook の TLS 設定にはカスタム連携に対応したプランが必要です',
+  },
+  WEBHOOK_TLS_HTTPS_REQUIRED: {
+    en: 'Webhook TLS settings require an https webhook URL',
+    zh: 'Webhook TLS 设置要求 webhook 地址使用 https',
+    ja: 'Webhook の TLS 設定には https の Webhook URL が必要です',
+  },
+  WEBHOOK_TLS_CERT_INVALID: {
+    en: '{field} is not a valid PEM certificate',
+    zh: '{field} 不是有效的 PEM 证书',
+    ja: '{field} は有効な PEM 証明書ではありません',
+  },
+  WEBHOOK_TLS_CERT_EXPIRED: {
+    en: '{field} contains an expired certificate',
+    zh: '{field} 中包含已过期的证书',
+    ja: '{field} に有効期限切れの証明書が含まれています',
+  },
+  WEBHOOK_TLS_KEY_INVALID: {
+    en: 'clientKey is not a valid PEM private key or the passphrase is wrong',
+    zh: 'clientKey 不是有效的 PEM 私钥，或口令错误',
+    ja: 'clientKey が有効な PEM 秘密鍵ではないか、パスフレーズが誤っています',
+  },
+  WEBHOOK_TLS_KEY_MISMATCH: {
+    en: 'clientKey does not match clientCert',
+    zh: 'clientKey 与 clientCert 不匹配',
+    ja: 'clientKey が clientCert と一致しません',
+  },
+  WEBHOOK_TLS_INCOMPLETE: {
+    en: 'clientCert and clientKey must be set together',
+    zh: 'clientCert 和 clientKey 必须同时设置',
+    ja: 'clientCert と clientKey は同時に指定する必要があります',
+  },
+  NOTIFICATION_CHANNEL_LIMIT: {
+    en: 'At most {limit} notification channels are allowed per account',
+    zh: '每个账户最多只能配置 {limit} 个通知渠道',
+    ja: '通知チャネルは 1 アカウントあたり最大 {limit} 件までです',
+  },
+  NOTIFICATION_EMAIL_RECIPIENTS_REQUIRED: {
+    en: 'Email channels require recipients',
+    zh: '邮件渠道必须指定收件人',
+    ja: 'メールチャネルには宛先が必要です',
+  },
+  NOTIFICATION_EMAIL_NOT_CONFIGURED: {
+    en: 'Email delivery is not configured',
+    zh: '服务端未配置邮件发送',
+    ja: 'メール送信が構成されていません',
+  },
+  NOTIFICATION_SLACK_URL_INVALID: {
+    en: 'Slack channels require a https://hooks.slack.com/services/ webhook URL',
+    zh: 'Slack 渠道必须使用 https://hooks.slack.com/services/ 开头的 webhook 地址',
+    ja: 'Slack チャネルには https://hooks.slack.com/services/ の Webhook URL が必要です',
+  },
+  NOTIFICATION_CHANNEL_NOT_FOUND: {
+    en: 'Notification channel not found',
+    zh: '通知渠道不存在',
+    ja: '通知チャネルが見つかりません',
+  },
+  PERIOD_INVALID: {
+    en: 'period must be formatted as YYYY-MM',
+    zh: 'period 的格式必须为 YYYY-MM',
+    ja: 'period は YYYY-MM 形式で指定してください',
+  },
+  QUEUE_NOT_FOUND: { en: 'Queue not found', zh: '队列不存在', ja: 'キューが見つかりません' },
+  QUEUE_JOB_NOT_FOUND: { en: 'Job not found', zh: '任务不存在', ja: 'ジョブが見つかりません' },
+  QUEUE_JOB_NOT_FAILED: {
+    en: 'Only failed jobs can be requeued',
+    zh: '只能重新入队失败的任务',
+    ja: '再投入できるのは失敗したジョブのみです',
+  },
+  READ_ONLY_MODE: {
+    en: 'This API replica is read-only; send write requests to the primary API',
+    zh: '当前 API 副本为只读模式，请将写操作发送到主 API',
+    ja: 'この API レプリカは読み取り専用です。書き込みリクエストはプライマリ API に送信してください',
+  },
+  IGNORE_PROFILE_LIMIT: {
+    en: 'At most {max} ignore profiles are allowed per account',
+    zh: '每个账户最多创建 {max} 个忽略配置',
+    ja: '無視プロファイルは 1 アカウントにつき最大 {max} 個までです',
+  },
+  IGNORE_PROFILE_NOT_FOUND: {
+    en: 'Ignore profile not found',
+    zh: '忽略配置不存在',
+    ja: '無視プロファイルが見つかりません',
+  },
+  IGNORE_PROFILE_EMPTY: {
+    en: 'An ignore profile needs at least one rule',
+    zh: '忽略配置至少需要一条规则',
+    ja: '無視プロファイルには少なくとも 1 つのルールが必要です',
+  },
+  IGNORE_PROFILE_NAME_TAKEN: {
+    en: 'An ignore profile named {name} already exists',
+    zh: '名为 {name} 的忽略配置已存在',
+    ja: '{name} という名前の無視プロファイルは既に存在します',
+  },
+  IGNORE_PROFILE_PATTERN_INVALID: {
+    en: 'Invalid pattern {pattern}: {reason}',
+    zh: '正则表达式 {pattern} 无效：{reason}',
+    ja: '正規表現 {pattern} が不正です: {reason}',
+  },
+  TRANSLATION_DUPLICATE: {
+    en: 'Duplicate of translation {id} submitted in the last {seconds} seconds; pass force=true to translate it again',
+    zh: '{seconds} 秒内已提交过相同的翻译（{id}），如需再次翻译请传 force=true',
+    ja: '同じ翻訳 {id} が直近 {seconds} 秒以内に送信されています。再度翻訳するには force=true を指定してください',
+  },
+  INVALID_CURSOR: {
+    en: 'Invalid cursor',
+    zh: '游标无效',
+    ja: 'カーソルが無効です',
+  },
+  CURSOR_SORT_MISMATCH: {
+    en: 'Cursor was issued for a different sort order',
+    zh: '游标与当前的排序方式不一致',
+    ja: 'カーソルは別の並び順で発行されたものです',
+  },
+  USAGE_REPORT_NOT_FOUND: {
+    en: 'Usage report subscription not found',
+    zh: '未订阅用量报告',
+    ja: '利用状況レポートの購読が見つかりません',
+  },
+  CUSTOM_MT_ENGINE_NOT_FOUND: {
+    en: 'Custom translation engine not found',
+    zh: '未配置自定义翻译引擎',
+    ja: 'カスタム翻訳エンジンが見つかりません',
+  },
+  CUSTOM_MT_ENGINE_NOT_CONFIGURED: {
+    en: 'No custom translation engine is configured',
+    zh: '使用 custom 翻译服务前需要先配置自定义翻译引擎',
+    ja: 'カスタム翻訳エンジンが設定されていません',
+  },
+  CUSTOM_MT_URL_NOT_ALLOWED: {
+    en: 'Engine URL is not allowed: {reason}',
+    zh: '不允许使用该引擎地址：{reason}',
+    ja: 'このエンジン URL は使用できません: {reason}',
+  },
+  CUSTOM_MT_TEMPLATE_MISSING_TEXT: {
+    en: 'Request template must contain {{text}}',
+    zh: '请求模板必须包含 {{text}}',
+    ja: 'リクエストテンプレートには {{text}} が必要です',
+  },
+  CUSTOM_MT_TEMPLATE_INVALID: {
+    en: 'Request template is not valid JSON: {reason}',
+    zh: '请求模板不是有效的 JSON：{reason}',
+    ja: 'リクエストテンプレートが有効な JSON ではありません: {reason}',
+  },
+  DOCUMENT_PURGED: {
+    en: 'Document content was purged under the retention policy',
+    zh: '文档内容已按保留策略清除',
+    ja: 'ドキュメントの内容は保持ポリシーにより削除されました',
+  },
+  PROJECT_SETTINGS_NOT_FOUND: {
+    en: 'Project settings not found',
+    zh: '项目没有设置',
+    ja: 'プロジェクト設定が見つかりません',
+  },
+  PROJECT_JOB_NOT_FOUND: { en: 'Project job not found', zh: '项目任务不存在', ja: 'プロジェクトジョブが見つかりません' },
+  PROJECT_NO_TARGET_LANGUAGES: {
+    en: 'Project has no target languages other than the source language',
+    zh: '项目除源语言外没有其他目标语言',
+    ja: 'プロジェクトにソース言語以外のターゲット言語がありません',
+  },
+  DATA_ENCRYPTION_NOT_CONFIGURED: {
+    en: 'Data encryption is not configured',
+    zh: '服务端未配置文档内容加密',
+    ja: 'データ暗号化が構成されていません',
+  },
+  DATA_ENCRYPTION_MASTER_KEY_MISSING: {
+    en: 'Data encryption master key {masterKeyId} is not configured',
+    zh: '服务端未配置文档加密主密钥 {masterKeyId}',
+    ja: 'データ暗号化マスターキー {masterKeyId} が構成されていません',
+  },
+  SECRETS_NOT_CONFIGURED: {
+    en: 'Secret encryption is not configured',
+    zh: '服务端未配置凭据加密',
+    ja: '認証情報の暗号化が構成されていません',
+  },
+  SUBSCRIPTION_PLAN_NOT_FOUND: {
+    en: 'Subscription plan not found',
+    zh: '订阅套餐不存在',
+    ja: 'サブスクリプションプランが見つかりません',
+  },
+  NO_ACTIVE_SUBSCRIPTION: {
+    en: 'No active subscription found',
+    zh: '没有有效的订阅',
+    ja: '有効なサブスクリプションがありません',
+  },
+  TENANT_NOT_FOUND: { en: 'Tenant not found', zh: '租户不存在', ja: 'テナントが見つかりません' },
+  LINT_RULE_NOT_FOUND: { en: 'Lint rule not found', zh: '检查规则不存在', ja: 'チェックルールが見つかりません' },
+  LINT_RULE_FIELD_REQUIRED: {
+    en: 'Lint rule of type {type} requires {field}',
+    zh: '{type} 类型的检查规则必须提供 {field}',
+    ja: '{type} タイプのチェックルールには {field} が必要です',
+  },
+  LINT_RULE_LIMIT: {
+    en: 'At most {limit} lint rules are allowed per account',
+    zh: '每个账户最多 {limit} 条检查规则',
+    ja: 'チェックルールは 1 アカウントあたり最大 {limit} 件です',
+  },
+  SOURCE_SYNC_NOT_FOUND: { en: 'Source sync not found', zh: '源同步不存在', ja: 'ソース同期が見つかりません' },
+  SCHEDULED_TRANSLATION_NOT_FOUND: {
+    en: 'Scheduled translation not found',
+    zh: '定时翻译不存在',
+    ja: 'スケジュールされた翻訳が見つかりません',
+  },
+  EXPORT_NOT_FOUND: { en: 'Export not found', zh: '导出不存在', ja: 'エクスポートが見つかりません' },
+  STORAGE_UNAVAILABLE: {
+    en: 'Storage backend unavailable',
+    zh: '存储服务暂不可用',
+    ja: 'ストレージが利用できません',
+  },
+
+  // 未登记的消息按 HTTP 状态码归类
+  VALIDATION_FAILED: { en: 'Request validation failed', zh: '请求参数校验失败', ja: 'リクエストの検証に失敗しました' },
+  BAD_REQUEST: { en: 'Bad request', zh: '请求无效', ja: '不正なリクエストです' },
+  UNAUTHORIZED: { en: 'Unauthorized', zh: '未认证', ja: '認証されていません' },
+  FORBIDDEN: { en: 'Forbidden', zh: '没有权限', ja: 'アクセスが拒否されました' },
+  NOT_FOUND: { en: 'Not found', zh: '资源不存在', ja: '見つかりません' },
+  CONFLICT: { en: 'Conflict', zh: '请求与当前状态冲突', ja: '現在の状態と競合しています' },
+  PAYLOAD_TOO_LARGE: { en: 'Payload too large', zh: '请求体过大', ja: 'リクエストが大きすぎます' },
+  RATE_LIMITED: { en: 'Too many requests', zh: '请求过于频繁', ja: 'リクエストが多すぎます' },
+  SERVICE_UNAVAILABLE: { en: 'Service unavailable', zh: '服务暂不可用', ja: 'サービスは現在利用できません' },
+  INTERNAL_ERROR: { en: 'Internal server error', zh: '服务器内部错误', ja: 'サーバー内部エラーが発生しました' },
+};
+
+const STATUS_CODES: Partial<Record<number, string>> = {
+  [HttpStatus.BAD_REQUEST]: 'BAD_REQUEST',
+  [HttpStatus.UNAUTHORIZED]: 'UNAUTHORIZED',
+  [HttpStatus.FORBIDDEN]: 'FORBIDDEN',
+  [HttpStatus.NOT_FOUND]: 'NOT_FOUND',
+  [HttpStatus.CONFLICT]: 'CONFLICT',
+  [HttpStatus.PAYLOAD_TOO_LARGE]: 'PAYLOAD_TOO_LARGE',
+  [HttpStatus.TOO_MANY_REQUESTS]: 'RATE_LIMITED',
+  [HttpStatus.SERVICE_UNAVAILABLE]: 'SERVICE_UNAVAILABLE',
+};
+
+const escapeRegExp = (value: string) => value.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
+
+const TEMPLATE_MATCHERS = Object.entries(ERROR_CATALOG).map(([code, entry]) => {
+  const names: string[] = [];
+  const pattern = entry.en
+    .split(/(\{\w+\})/)
+    .map((part) => {
+      const param = /^\{(\w+)\}$/.exec(part);
+      if (!param) {
+        return escapeRegExp(part);
+      }
+      names.push(param[1]);
+      return '(.+?)';
+    })
+    .join('');
+  return { code, names, regex: new RegExp(`^${pattern}$`) };
+});
+
+export interface ResolvedError {
+  code: string;
+  params: Record<string, string>;
+  /** 消息是否来自目录模板（否则只是按状态码归类） */
+  matched: boolean;
+}
+
+/**
+ * 由英文异常消息和状态码得到错误码和模板参数
+ */
+export function resolveError(message: string | undefined, status: number): ResolvedError {
+  if (message) {
+    for (const { code, names, regex } of TEMPLATE_MATCHERS) {
+      const match = regex.exec(message);
+      if (match) {
+        return { code, params: Object.fromEntries(names.map((name, i) => [name, match[i + 1]])), matched: true };
+      }
+    }
+  }
+  const code = STATUS_CODES[status] ?? (status >= 500 ? 'INTERNAL_ERROR' : 'BAD_REQUEST');
+  return { code, params: {}, matched: false };
+}
+
+export function renderError(code: string, locale: ErrorLocale, params: Record<string, string> = {}): string {
+  const entry = ERROR_CATALOG[code] ?? ERROR_CATALOG.INTERNAL_ERROR;
+  return entry[locale].replace(/\{(\w+)\}/g, (placeholder, name) => params[name] ?? placeholder);
+}
+
+/**
+ * 按 Accept-Language（含 q 权重）选择错误消息语言，不支持的语言回退到英文
+ */
+export function negotiateErrorLocale(header: string | string[] | undefined): ErrorLocale {
+  const value = Array.isArray(header) ? header.join(',') : header;
+  if (!value) {
+    return 'en';
+  }
+
+  const candidates = value
+    .split(',')
+    .map((part, index) => {
+      const [tag, ...attributes] = part.trim().split(';');
+      const q = attributes.map((attr) => /^\s*q=([\d.]+)\s*$/.exec(attr)).find(Boolean);
+      return { language: tag.trim().toLowerCase().split('-')[0], q: q ? Number(q[1]) : 1, index };
+    })
+    .filter((candidate) => candidate.language && candidate.q > 0)
+    .sort((a, b) => b.q - a.q || a.index - b.index);
+
+  const supported = candidates.find((candidate) => SUPPORTED_ERROR_LOCALES.includes(candidate.language as ErrorLocale));
+  return (supported?.language as ErrorLocale) ?? 'en';
+}
@@ -0,0 +1,84 @@
+import { ConflictException, NotFoundException, ServiceUnavailableException } from '@nestjs/common';
+import { ConnectionException, EntityManager, UniqueConstraintViolationException } from '@mikro-orm/core';
+import { DataRepository } from '../data.repository';
+
+class Note {
+  id!: string;
+  userId!: string;
+  body!: string;
+}
+
+class NoteRepository extends DataRepository<Note> {
+  constructor(em: EntityManager) {
+    super(em, Note, 'userId');
+  }
+}
+
+describe('DataRepository', () => {
+  let repository: NoteRepository;
+
+  const mockEntityManager = {
+    findOne: jest.fn(),
+    find: jest.fn(),
+    count: jest.fn(),
+    create: jest.fn((_entity, data) => ({ ...data })),
+    assign: jest.fn((entity, changes) => Object.assign(entity, changes)),
+    persistAndFlush: jest.fn(),
+    removeAndFlush: jest.fn(),
+  };
+
+  beforeEach(() => {
+    repository = new NoteRepository(mockEntityManager as unknown as EntityManager);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('getOrFail 在记录不存在时应抛出 404', async () => {
+    mockEntityManager.findOne.mockResolvedValue(null);
+
+    await expect(repository.getOrFail({ id: 'n1' })).rejects.toThrow(NotFoundException);
+    await expect(repository.getOrFail({ id: 'n1' }, 'Note missing')).rejects.toThrow('Note missing');
+  });
+
+  it('insert 应创建实体并写库', async () => {
+    const note = await repository.insert({ id: 'n1', userId: 'u1', body: 'hi' });
+
+    expect(note).toEqual({ id: 'n1', userId: 'u1', body: 'hi' });
+    expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith(note);
+  });
+
+  it('update 应合并变更后写库', async () => {
+    const note = { id: 'n1', userId: 'u1', body: 'hi' };
+
+    await repository.update(note, { body: 'hello' });
+
+    expect(note.body).toBe('hello');
+    expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith(note);
+  });
+
+  it('唯一约束冲突应映射为 409', async () => {
+    mockEntityManager.persistAndFlush.mockRejectedValueOnce(
+      new UniqueConstraintViolationException(new Error('duplicate key')),
+    );
+
+    await expect(repository.insert({ id: 'n1', userId: 'u1', body: 'hi' })).rejects.toThrow(ConflictException);
+  });
+
+  it('连接异常应映射为 503', async () => {
+    mockEntityManager.find.mockRejectedValueOnce(new ConnectionException(new Error('ECONNREFUSED')));
+
+    await expect(repository.list({})).rejects.toThrow(ServiceUnavailableException);
+  });
+
+  it('forUser 应自动附加归属字段', async () => {
+    const scoped = repository.forUser('u1');
+
+    await scoped.get({ id: 'n1' });
+    await scoped.insert({ id: 'n2', userId: 'someone-else', body: 'x' });
+
+    expect(mockEntityManager.findOne).toHaveBeenCalledWith(Note, { id: 'n1', userId: 'u1' });
+    expect(mockEntityManager.create).toHaveBeenCalledWith(Note, { id: 'n2', userId: 'u1', body: 'x' });
+  });
+});
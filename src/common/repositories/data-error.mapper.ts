@@ -0,0 +1,35 @@
+import {
+  BadRequestException,
+  ConflictException,
+  HttpException,
+  ServiceUnavailableException,
+} from '@nestjs/common';
+import {
+  ConnectionException,
+  ForeignKeyConstraintViolationException,
+  NotNullConstraintViolationException,
+  UniqueConstraintViolationException,
+} from '@mikro-orm/core';
+
+/**
+ * 将 ORM / 驱动层异常映射为 HTTP 异常
+ * 未识别的异常原样返回，交给全局异常处理
+ */
+export function mapDataError(error: unknown, entityName: string): unknown {
+  if (error instanceof HttpException) {
+    return error;
+  }
+  if (error instanceof UniqueConstraintViolationException) {
+    return new ConflictException(`${entityName} already exists`);
+  }
+  if (error instanceof ForeignKeyConstraintViolationException) {
+    return new BadRequestException(`${entityName} references a missing record`);
+  }
+  if (error instanceof NotNullConstraintViolationException) {
+    return new BadRequestException(`${entityName} is missing required fields`);
+  }
+  if (error instanceof ConnectionException) {
+    return new ServiceUnavailableException('Storage backend unavailable');
+  }
+  return error;
+}
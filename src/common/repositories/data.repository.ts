@@ -0,0 +1,153 @@
+import { Logger, NotFoundException } from '@nestjs/common';
+import {
+  EntityData,
+  EntityManager,
+  EntityName,
+  FilterQuery,
+  FindOptions,
+  RequiredEntityData,
+  Utils,
+} from '@mikro-orm/core';
+import { mapDataError } from './data-error.mapper';
+import { measurePhase } from '../utils/request-timing';
+
+export interface ListOptions<T extends object> {
+  limit?: number;
+  offset?: number;
+  orderBy?: FindOptions<T>['orderBy'];
+}
+
+/**
+ * 通用数据访问层
+ * 封装 Get / Insert / Update / Delete 和错误映射，业务服务不再直接拼装 EntityManager 调用
+ */
+export abstract class DataRepository<T extends object> {
+  protected readonly logger: Logger;
+  protected readonly entityLabel: string;
+
+  protected constructor(
+    protected readonly em: EntityManager,
+    protected readonly entityName: EntityName<T>,
+    protected readonly ownerField?: keyof T & string,
+  ) {
+    this.entityLabel = Utils.className(entityName);
+    this.logger = new Logger(`${this.entityLabel}Repository`);
+  }
+
+  async get(filter: FilterQuery<T>): Promise<T | null> {
+    return this.run('get', () => this.em.findOne(this.entityName, filter));
+  }
+
+  /**
+   * 查询单条记录，不存在时抛出 404
+   */
+  async getOrFail(filter: FilterQuery<T>, message?: string): Promise<T> {
+    const entity = await this.get(filter);
+    if (!entity) {
+      throw new NotFoundException(message ?? `${this.entityLabel} not found`);
+    }
+    return entity;
+  }
+
+  async list(filter: FilterQuery<T>, options: ListOptions<T> = {}): Promise<T[]> {
+    return this.run('list', () => this.em.find(this.entityName, filter, options as FindOptions<T>));
+  }
+
+  async listAndCount(filter: FilterQuery<T>, options: ListOptions<T> = {}): Promise<[T[], number]> {
+    return this.run('listAndCount', () =>
+      this.em.findAndCount(this.entityName, filter, options as FindOptions<T>),
+    );
+  }
+
+  async count(filter: FilterQuery<T>): Promise<number> {
+    return this.run('count', () => this.em.count(this.entityName, filter));
+  }
+
+  /**
+   * 创建实体但不立即写库，可与其他实体一起 flush
+   */
+  build(data: RequiredEntityData<T>): T {
+    return this.em.create(this.entityName, data);
+  }
+
+  async insert(data: RequiredEntityData<T>): Promise<T> {
+    const entity = this.build(data);
+    await this.save(entity);
+    return entity;
+  }
+
+  async update(entity: T, changes: EntityData<T>): Promise<T> {
+    this.em.assign(entity, changes);
+    await this.save(entity);
+    return entity;
+  }
+
+  async delete(entity: T): Promise<void> {
+    await this.run('delete', () => this.em.removeAndFlush(entity));
+  }
+
+  /**
+   * 持久化一个或多个实体（可以是不同类型，在同一次 flush 中提交）
+   */
+  async save(entities: object | object[]): Promise<void> {
+    await this.run('save', () => this.em.persistAndFlush(entities));
+  }
+
+  /**
+   * 返回限定到某个用户的视图，所有查询和写入都会自动带上归属字段
+   */
+  forUser(userId: string): ScopedDataRepository<T> {
+    if (!this.ownerField) {
+      throw new Error(`${this.entityLabel} is not owned by a user`);
+    }
+    return new ScopedDataRepository<T>(this, this.ownerField, userId);
+  }
+
+  protected async run<R>(operation: string, fn: () => Promise<R>): Promise<R> {
+    try {
+      return await measurePhase('storage', fn);
+    } catch (error) {
+      this.logger.error(`${operation} failed: ${error.message}`);
+      throw mapDataError(error, this.entityLabel);
+    }
+  }
+}
+
+/**
+ * 按用户限定的数据视图
+ */
+export class ScopedDataRepository<T extends object> {
+  constructor(
+    private readonly repository: DataRepository<T>,
+    private readonly ownerField: keyof T & string,
+    private readonly userId: string,
+  ) {}
+
+  get(filter: FilterQuery<T> = {} as FilterQuery<T>): Promise<T | null> {
+    return this.repository.get(this.scope(filter));
+  }
+
+  getOrFail(filter: FilterQuery<T> = {} as FilterQuery<T>, message?: string): Promise<T> {
+    return this.repository.getOrFail(this.scope(filter), message);
+  }
+
+  list(filter: FilterQuery<T> = {} as FilterQuery<T>, options?: ListOptions<T>): Promise<T[]> {
+    return this.repository.list(this.scope(filter), options);
+  }
+
+  listAndCount(filter: FilterQuery<T> = {} as FilterQuery<T>, options?: ListOptions<T>): Promise<[T[], number]> {
+    return this.repository.listAndCount(this.scope(filter), options);
+  }
+
+  count(filter: FilterQuery<T> = {} as FilterQuery<T>): Promise<number> {
+    return this.repository.count(this.scope(filter));
+  }
+
+  insert(data: RequiredEntityData<T>): Promise<T> {
+    return this.repository.insert({ ...data, [this.ownerField]: this.userId } as RequiredEntityData<T>);
+  }
+
+  private scope(filter: FilterQuery<T>): FilterQuery<T> {
+    return { ...(filter as object), [this.ownerField]: this.userId } as FilterQuery<T>;
+  }
+}
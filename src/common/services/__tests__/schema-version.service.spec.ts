@@ -0,0 +1,85 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { MikroORM } from '@mikro-orm/core';
+import { mkdtempSync, writeFileSync } from 'fs';
+import { tmpdir } from 'os';
+import { join } from 'path';
+import { SchemaVersionService } from '../schema-version.service';
+
+describe('SchemaVersionService', () => {
+  let service: SchemaVersionService;
+  let config: Record<string, string>;
+  const getExecutedMigrations = jest.fn();
+
+  beforeEach(async () => {
+    const dir = mkdtempSync(join(tmpdir(), 'migrations-'));
+    ['Migration001_a.ts', 'Migration002_b.ts', 'Migration002_b.d.ts'].forEach((file) =>
+      writeFileSync(join(dir, file), ''),
+    );
+    config = {};
+
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        SchemaVersionService,
+        {
+          provide: MikroORM,
+          useValue: {
+            config: { get: () => ({ path: dir, pathTs: dir }) },
+            getMigrator: () => ({ getExecutedMigrations }),
+          },
+        },
+        {
+          provide: ConfigService,
+          useValue: { get: jest.fn((key: string, defaultValue?: any) => config[key] ?? defaultValue) },
+        },
+      ],
+    }).compile();
+
+    service = module.get<SchemaVersionService>(SchemaVersionService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('迁移全部执行时应兼容', async () => {
+    getExecutedMigrations.mockResolvedValue([{ name: 'Migration001_a' }, { name: 'Migration002_b' }]);
+
+    const report = await service.check();
+    expect(report.compatible).toBe(true);
+    expect(report.latestKnown).toBe('Migration002_b');
+  });
+
+  it('构建需要的迁移未执行时应拒绝启动', async () => {
+    getExecutedMigrations.mockResolvedValue([{ name: 'Migration001_a' }]);
+
+    await expect(service.assertCompatible()).rejects.toThrow('Migration002_b');
+  });
+
+  it('数据库在兼容窗口内领先时应允许启动', async () => {
+    getExecutedMigrations.mockResolvedValue([
+      { name: 'Migration001_a' },
+      { name: 'Migration002_b' },
+      { name: 'Migration003_c' },
+    ]);
+
+    const report = await service.assertCompatible();
+    expect(report.compatible).toBe(true);
+    expect(report.unknownApplied).toEqual(['Migration003_c']);
+  });
+
+  it('数据库超出兼容窗口时应拒绝启动，warn 模式下只告警', async () => {
+    getExecutedMigrations.mockResolvedValue([
+      { name: 'Migration001_a' },
+      { name: 'Migration002_b' },
+      { name: 'Migration003_c' },
+      { name: 'Migration004_d' },
+    ]);
+
+    await expect(service.assertCompatible()).rejects.toThrow('compatibility window of 1');
+
+    config.SCHEMA_GUARD = 'warn';
+    const report = await service.assertCompatible();
+    expect(report.compatible).toBe(false);
+  });
+});
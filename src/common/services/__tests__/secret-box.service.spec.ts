@@ -0,0 +1,32 @@
+import { ServiceUnavailableException } from '@nestjs/common';
+import { SecretBoxService } from '../secret-box.service';
+
+describe('SecretBoxService', () => {
+  const key = Buffer.alloc(32, 7).toString('base64');
+  const create = (value?: string) => new SecretBoxService({ get: jest.fn(() => value) } as any);
+
+  it('加密后应能解密回原文，且每次密文不同', () => {
+    const service = create(key);
+
+    const first = service.encryptJson({ Authorization: 'Bearer secret' });
+    const second = service.encryptJson({ Authorization: 'Bearer secret' });
+
+    expect(first).toMatch(/^v1:/);
+    expect(first).not.toContain('secret');
+    expect(first).not.toBe(second);
+    expect(service.decryptJson(first)).toEqual({ Authorization: 'Bearer secret' });
+  });
+
+  it('密文被篡改或换了密钥时应解密失败', () => {
+    const encrypted = create(key).encrypt('value');
+    const [version, iv, tag] = encrypted.split(':');
+
+    expect(() => create(key).decrypt(`${version}:${iv}:${tag}:AAAA`)).toThrow();
+    expect(() => create(Buffer.alloc(32, 8).toString('base64')).decrypt(encrypted)).toThrow();
+  });
+
+  it('未配置密钥时拒绝加密，密钥长度错误时阻止启动', () => {
+    expect(() => create().encrypt('value')).toThrow(ServiceUnavailableException);
+    expect(() => create(Buffer.alloc(16).toString('base64'))).toThrow('SECRETS_ENCRYPTION_KEY must be 32 bytes');
+  });
+});
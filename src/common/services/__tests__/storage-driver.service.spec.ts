@@ -0,0 +1,56 @@
+import { EntityManager, RawQueryFragment } from '@mikro-orm/core';
+import { StorageDriverService } from '../storage-driver.service';
+import { StorageDriver } from '../../../config/database.config';
+
+describe('StorageDriverService', () => {
+  const execute = jest.fn();
+  const create = (value?: string) =>
+    new StorageDriverService({ get: jest.fn(() => value) } as any, {
+      getConnection: () => ({ execute }),
+    } as unknown as EntityManager);
+
+  // 把条件里的原生 SQL 片段还原为 SQL、参数和比较值
+  const fragment = (condition: Record<string, unknown>) => {
+    const [key] = Object.keys(condition);
+    const { sql, params } = RawQueryFragment.getKnownFragment(key);
+    return { sql, params, value: condition[key] };
+  };
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('默认使用 PostgreSQL，mariadb 按 MySQL 处理，MySQL 不支持表分区', () => {
+    expect(create().driver).toBe(StorageDriver.POSTGRESQL);
+    expect(create().supports('partitioning')).toBe(true);
+    expect(create('mariadb').driver).toBe(StorageDriver.MYSQL);
+    expect(create('mysql').supports('partitioning')).toBe(false);
+    expect(() => create('sqlite')).toThrow('Unsupported DB_DRIVER "sqlite"');
+  });
+
+  it('PostgreSQL 下 JSON 数组条件使用 jsonb_exists_any', () => {
+    expect(fragment(create('postgresql').jsonArrayOverlap('tags', ['web', 'mobile']))).toEqual({
+      sql: 'jsonb_exists_any(tags, array[?, ?]::text[])',
+      params: ['web', 'mobile'],
+      value: true,
+    });
+  });
+
+  it('MySQL 下 JSON 数组条件逐个使用 JSON_CONTAINS，不依赖 PostgreSQL 的数组运算符', () => {
+    const condition = fragment(create('mysql').jsonArrayOverlap('tags', ['web', 'mobile']));
+
+    expect(condition).toEqual({
+      sql: '(JSON_CONTAINS(tags, JSON_QUOTE(?)) OR JSON_CONTAINS(tags, JSON_QUOTE(?)))',
+      params: ['web', 'mobile'],
+      value: 1,
+    });
+    expect(condition.sql).not.toMatch(/jsonb|&&|\?\|/);
+  });
+
+  it('数据库不可用时 ping 返回 false', async () => {
+    execute.mockRejectedValueOnce(new Error('ECONNREFUSED'));
+
+    await expect(create('mysql').ping()).resolves.toBe(false);
+    expect(execute).toHaveBeenCalledWith('SELECT 1');
+  });
+});
@@ -0,0 +1,90 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import * as Sentry from '@sentry/node';
+
+export interface ErrorContext {
+  requestId?: string;
+  userId?: string;
+  tenantId?: string;
+  /** 翻译任务 / 文档 ID */
+  documentId?: string;
+  /** 错误来源：api、worker、webhook 等 */
+  source?: string;
+  tags?: Record<string, string>;
+  extra?: Record<string, any>;
+}
+
+/**
+ * 错误上报
+ * 配置 SENTRY_DSN 后把未处理的异常发送到 Sentry（或兼容的错误追踪服务），未配置时只记录日志
+ */
+@Injectable()
+export class ErrorReporterService {
+  private readonly logger = new Logger(ErrorReporterService.name);
+  private static initialized = false;
+  readonly enabled: boolean;
+
+  constructor(private readonly configService: ConfigService) {
+    const dsn = this.configService.get<string>('SENTRY_DSN');
+    this.enabled = !!dsn;
+    if (dsn && !ErrorReporterService.initialized) {
+      Sentry.init({
+        dsn,
+        environment: this.configService.get('SENTRY_ENVIRONMENT', this.configService.get('NODE_ENV', 'development')),
+        release: this.configService.get('SENTRY_RELEASE'),
+        sampleRate: Number(this.configService.get('SENTRY_SAMPLE_RATE', 1)),
+      });
+      ErrorReporterService.initialized = true;
+      this.logger.log('Error reporting enabled');
+    }
+  }
+
+  /**
+   * 上报异常，返回事件 ID（未启用时返回 undefined）
+   */
+  captureException(error: unknown, context: ErrorContext = {}): string | undefined {
+    if (!this.enabled) {
+      return undefined;
+    }
+    try {
+      let eventId: string | undefined;
+      Sentry.withScope((scope) => {
+        if (context.userId) {
+          scope.setUser({ id: context.userId });
+        }
+        if (context.requestId) {
+          scope.setTag('request_id', context.requestId);
+        }
+        if (context.tenantId) {
+          scope.setTag('tenant_id', context.tenantId);
+        }
+        if (context.documentId) {
+          scope.setTag('document_id', context.documentId);
+        }
+        if (context.source) {
+          scope.setTag('source', context.source);
+        }
+        for (const [key, value] of Object.entries(context.tags ?? {})) {
+          scope.setTag(key, value);
+        }
+        if (context.extra) {
+          scope.setExtras(context.extra);
+        }
+        eventId = Sentry.captureException(error);
+      });
+      return eventId;
+    } catch (reportError) {
+      this.logger.error(`Failed to report error: ${reportError.message}`);
+      return undefined;
+    }
+  }
+
+  /**
+   * 等待待发送的事件发送完毕，进程退出前调用
+   */
+  async flush(timeoutMs = 2000): Promise<void> {
+    if (this.enabled) {
+      await Sentry.flush(timeoutMs);
+    }
+  }
+}
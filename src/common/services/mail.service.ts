@@ -0,0 +1,86 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import * as nodemailer from 'nodemailer';
+
+export interface MailMessage {
+  to: string | string[];
+  subject: string;
+  text: string;
+  html?: string;
+  /** 发件人显示名，默认 MAIL_FROM_NAME */
+  fromName?: string;
+  replyTo?: string;
+}
+
+/**
+ * 邮件发送服务
+ * 配置了 SMTP_HOST 时通过 SMTP 发送，否则只记录日志，便于本地开发
+ */
+@Injectable()
+export class MailService {
+  private readonly logger = new Logger(MailService.name);
+  private readonly transporter?: nodemailer.Transporter;
+  private readonly fromAddress: string;
+  private readonly fromName: string;
+
+  constructor(private readonly configService: ConfigService) {
+    this.fromAddress = this.configService.get('MAIL_FROM', 'no-reply@example.com');
+    this.fromName = this.configService.get('MAIL_FROM_NAME', 'JSON Translation API');
+
+    const host = this.configService.get('SMTP_HOST');
+    if (host) {
+      this.transporter = nodemailer.createTransport({
+        host,
+        port: Number(this.configService.get('SMTP_PORT', 587)),
+        secure: this.configService.get('SMTP_SECURE', 'false') === 'true',
+        auth: this.configService.get('SMTP_USER')
+          ? {
+              user: this.configService.get('SMTP_USER'),
+              pass: this.configService.get('SMTP_PASSWORD'),
+            }
+          : undefined,
+      });
+    }
+  }
+
+  get enabled(): boolean {
+    return !!this.transporter;
+  }
+
+  /**
+   * 校验 SMTP 连接和认证，失败时抛出错误；未配置 SMTP 时直接返回 true
+   */
+  async verify(): Promise<boolean> {
+    if (!this.transporter) {
+      return true;
+    }
+    await this.transporter.verify();
+    return true;
+  }
+
+  /**
+   * 发送邮件，失败时记录错误并返回 false，不向调用方抛出
+   */
+  async send(message: MailMessage): Promise<boolean> {
+    const recipients = Array.isArray(message.to) ? message.to.join(', ') : message.to;
+    if (!this.transporter) {
+      this.logger.log(`[EMAIL] Would send to ${recipients}: ${message.subject}`);
+      return false;
+    }
+
+    try {
+      await this.transporter.sendMail({
+        from: `"${message.fromName ?? this.fromName}" <${this.fromAddress}>`,
+        to: recipients,
+        replyTo: message.replyTo,
+        subject: message.subject,
+        text: message.text,
+        html: message.html,
+      });
+      return true;
+    } catch (error) {
+      this.logger.error(`Failed to send email to ${recipients}: ${error.message}`);
+      return false;
+    }
+  }
+}
@@ -0,0 +1,73 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { DeleteObjectCommand, GetObjectCommand, PutObjectCommand, S3Client } from '@aws-sdk/client-s3';
+import { promises as fs } from 'fs';
+import * as path from 'path';
+
+export type ObjectStorageDriver = 'filesystem' | 's3';
+
+/**
+ * 对象存储
+ * 用于归档等不常访问的大对象。s3 驱动兼容 S3 协议（AWS S3、MinIO、阿里云 OSS 等），
+ * 未配置时写入本地目录，适合开发环境和单机部署
+ */
+@Injectable()
+export class ObjectStorageService {
+  private readonly logger = new Logger(ObjectStorageService.name);
+  readonly driver: ObjectStorageDriver;
+  private readonly baseDir: string;
+  private readonly bucket?: string;
+  private readonly prefix: string;
+  private readonly s3?: S3Client;
+
+  constructor(private readonly configService: ConfigService) {
+    this.driver = this.configService.get('OBJECT_STORAGE_DRIVER', 'filesystem') === 's3' ? 's3' : 'filesystem';
+    this.baseDir = this.configService.get('OBJECT_STORAGE_DIR', 'storage/objects');
+    this.prefix = this.configService.get('OBJECT_STORAGE_PREFIX', '');
+    if (this.driver === 's3') {
+      this.bucket = this.configService.get('OBJECT_STORAGE_BUCKET');
+      const endpoint = this.configService.get('OBJECT_STORAGE_ENDPOINT');
+      this.s3 = new S3Client({
+        region: this.configService.get('OBJECT_STORAGE_REGION', 'us-east-1'),
+        ...(endpoint ? { endpoint, forcePathStyle: true } : {}),
+      });
+    }
+  }
+
+  async put(key: string, body: Buffer, contentType = 'application/octet-stream'): Promise<void> {
+    if (this.s3) {
+      await this.s3.send(
+        new PutObjectCommand({ Bucket: this.bucket, Key: this.prefix + key, Body: body, ContentType: contentType }),
+      );
+      return;
+    }
+    const file = this.resolvePath(key);
+    await fs.mkdir(path.dirname(file), { recursive: true });
+    await fs.writeFile(file, body);
+  }
+
+  async get(key: string): Promise<Buffer> {
+    if (this.s3) {
+      const response = await this.s3.send(new GetObjectCommand({ Bucket: this.bucket, Key: this.prefix + key }));
+      return Buffer.from(await response.Body.transformToByteArray());
+    }
+    return fs.readFile(this.resolvePath(key));
+  }
+
+  async delete(key: string): Promise<void> {
+    if (this.s3) {
+      await this.s3.send(new DeleteObjectCommand({ Bucket: this.bucket, Key: this.prefix + key }));
+      return;
+    }
+    await fs.rm(this.resolvePath(key), { force: true });
+  }
+
+  private resolvePath(key: string): string {
+    const file = path.resolve(this.baseDir, this.prefix + key);
+    if (!file.startsWith(path.resolve(this.baseDir) + path.sep)) {
+      this.logger.error(`Rejected object key outside storage directory: ${key}`);
+      throw new Error('Invalid object key');
+    }
+    return file;
+  }
+}
@@ -1,6 +1,7 @@
 import { Injectable, Logger } from '@nestjs/common';
 import { Cron, CronExpression } from '@nestjs/schedule';
 import { EntityManager } from '@mikro-orm/core';
+import { StorageDriverService } from './storage-driver.service';
 
 interface PartitionConfig {
   tableName: string;
@@ -57,13 +58,19 @@ export class PartitionManagerService {
     },
   ];
 
-  constructor(private readonly em: EntityManager) {}
+  constructor(
+    private readonly em: EntityManager,
+    private readonly storageDriverService: StorageDriverService,
+  ) {}
 
   /**
    * 每天凌晨2点创建未来分区
    */
   @Cron(CronExpression.EVERY_DAY_AT_2AM)
   async createFuturePartitions() {
+    if (!this.partitioningSupported()) {
+      return;
+    }
     this.logger.log('开始创建未来分区...');
 
     for (const config of this.partitionConfigs) {
@@ -82,6 +89,9 @@ export class PartitionManagerService {
    */
   @Cron(CronExpression.EVERY_WEEK)
   async cleanupOldPartitions() {
+    if (!this.partitioningSupported()) {
+      return;
+    }
     this.logger.log('开始清理旧分区...');
 
     for (const config of this.partitionConfigs) {
@@ -308,6 +318,9 @@ export class PartitionManagerService {
    * 手动创建分区（用于初始化或紧急情况）
    */
   async createPartitionManually(tableName: string, startDate: Date, partitionType: string) {
+    if (!this.partitioningSupported()) {
+      throw new Error(`当前存储驱动 ${this.storageDriverService.driver} 不支持表分区`);
+    }
     const config = this.partitionConfigs.find(c => c.tableName === tableName);
     if (!config) {
       throw new Error(`未找到表 ${tableName} 的分区配置`);
@@ -330,6 +343,9 @@ export class PartitionManagerService {
    * 获取分区统计信息
    */
   async getPartitionStats(): Promise<any[]> {
+    if (!this.partitioningSupported()) {
+      return [];
+    }
     const query = `
       SELECT 
         schemaname,
@@ -351,6 +367,9 @@ export class PartitionManagerService {
    * 获取索引使用情况
    */
   async getIndexUsageStats(): Promise<any[]> {
+    if (!this.partitioningSupported()) {
+      return [];
+    }
     const query = `
       SELECT 
         indexrelname as index_name,
@@ -365,4 +384,15 @@ export class PartitionManagerService {
 
     return await this.em.getConnection().execute(query);
   }
-}
\ No newline at end of file
+
+  /**
+   * 分区依赖 PostgreSQL 声明式分区，其他驱动下跳过
+   */
+  private partitioningSupported(): boolean {
+    if (this.storageDriverService.supports('partitioning')) {
+      return true;
+    }
+    this.logger.debug(`存储驱动 ${this.storageDriverService.driver} 不支持分区，跳过分区维护`);
+    return false;
+  }
+}
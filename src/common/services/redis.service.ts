@@ -0,0 +1,98 @@
+import { Injectable, Logger, OnModuleDestroy } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import Redis from 'ioredis';
+import { buildRedisOptions } from '../../config/redis.config';
+
+/**
+ * 共享 Redis 客户端
+ * 为缓存、计数器等轻量场景提供统一的连接和 JSON 读写方法
+ */
+@Injectable()
+export class RedisService implements OnModuleDestroy {
+  private readonly logger = new Logger(RedisService.name);
+  readonly client: Redis;
+
+  constructor(private readonly configService: ConfigService) {
+    this.client = new Redis({
+      ...buildRedisOptions(),
+      retryDelayOnFailover: 100,
+      maxRetriesPerRequest: 3,
+      lazyConnect: true,
+    } as any);
+
+    this.client.on('error', (error) => {
+      this.logger.error('Redis connection error:', error);
+    });
+  }
+
+  /**
+   * 读取 JSON 值，不存在或解析失败时返回 null
+   */
+  async getJson<T>(key: string): Promise<T | null> {
+    try {
+      const raw = await this.client.get(key);
+      return raw ? (JSON.parse(raw) as T) : null;
+    } catch (error) {
+      this.logger.error(`Error reading cache key ${key}:`, error);
+      return null;
+    }
+  }
+
+  /**
+   * 写入 JSON 值并设置过期时间（秒）
+   */
+  async setJson<T>(key: string, value: T, ttlSeconds: number): Promise<void> {
+    try {
+      await this.client.setex(key, ttlSeconds, JSON.stringify(value));
+    } catch (error) {
+      this.logger.error(`Error writing cache key ${key}:`, error);
+    }
+  }
+
+  /**
+   * 键不存在时写入并设置过期时间，返回是否写入成功；用于一次性标记（去重）
+   */
+  async setIfAbsent(key: string, value: string, ttlSeconds: number): Promise<boolean> {
+    try {
+      return (await this.client.set(key, value, 'EX', ttlSeconds, 'NX')) === 'OK';
+    } catch (error) {
+      this.logger.error(`Error writing cache key ${key}:`, error);
+      return false;
+    }
+  }
+
+  /**
+   * 计数器加 by，首次创建时设置过期时间（秒），返回累加后的值；Redis 不可用时返回 null
+   */
+  async increment(key: string, by: number, ttlSeconds: number): Promise<number | null> {
+    try {
+      const value = await this.client.incrby(key, by);
+      if (value === by) {
+        await this.client.expire(key, ttlSeconds);
+      }
+      return value;
+    } catch (error) {
+      this.logger.error(`Error incrementing counter ${key}:`, error);
+      return null;
+    }
+  }
+
+  /**
+   * 删除一个或多个键
+   */
+  async del(...keys: string[]): Promise<number> {
+    if (keys.length === 0) {
+      return 0;
+    }
+    try {
+      return await this.client.del(...keys);
+    } catch (error) {
+      this.logger.error(`Error deleting cache keys ${keys.join(',')}:`, error);
+      return 0;
+    }
+  }
+
+  onModuleDestroy() {
+    this.client.disconnect();
+  }
+}
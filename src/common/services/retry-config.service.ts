@@ -32,4 +32,16 @@ export class RetryConfigService {
       backoffFactor: this.configService.get('API_RETRY_BACKOFF_FACTOR', 2),
     };
   }
-}
\ No newline at end of file
+
+  /**
+   * 机器翻译服务调用的重试策略
+   */
+  getProviderConfig(): RetryConfig {
+    return {
+      maxAttempts: Math.max(Number(this.configService.get('PROVIDER_RETRY_MAX_ATTEMPTS', 3)), 1),
+      delay: Number(this.configService.get('PROVIDER_RETRY_DELAY', 500)),
+      backoff: this.configService.get('PROVIDER_RETRY_BACKOFF', 'true') !== 'false',
+      backoffFactor: Number(this.configService.get('PROVIDER_RETRY_BACKOFF_FACTOR', 2)),
+    };
+  }
+}
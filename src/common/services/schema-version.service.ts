@@ -0,0 +1,115 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { MikroORM } from '@mikro-orm/core';
+import { existsSync, readdirSync } from 'fs';
+
+export interface SchemaVersionReport {
+  compatible: boolean;
+  /** 当前构建包含的最新迁移 */
+  latestKnown?: string;
+  /** 数据库已执行的最新迁移 */
+  latestApplied?: string;
+  /** 当前构建需要但数据库尚未执行的迁移 */
+  pending: string[];
+  /** 数据库已执行但当前构建不认识的迁移（数据库比构建新） */
+  unknownApplied: string[];
+  compatWindow: number;
+  reason?: string;
+}
+
+export function migrationName(fileOrName: string): string {
+  return fileOrName.replace(/\.(js|ts)$/, '');
+}
+
+/**
+ * 数据库结构版本检查
+ * 启动时对比迁移表和当前构建自带的迁移：
+ * - 构建需要的迁移尚未执行（库比构建旧）时拒绝启动，避免运行时出现列不匹配
+ * - 库比构建新时，只允许落后 SCHEMA_COMPAT_WINDOW 个迁移（滚动升级期间的旧实例），
+ *   因此每个迁移必须对上一个版本保持向后兼容（先加列、后删列）
+ */
+@Injectable()
+export class SchemaVersionService {
+  private readonly logger = new Logger(SchemaVersionService.name);
+  private readonly compatWindow: number;
+
+  constructor(
+    private readonly orm: MikroORM,
+    private readonly configService: ConfigService,
+  ) {
+    this.compatWindow = Math.max(Number(this.configService.get('SCHEMA_COMPAT_WINDOW', 1)), 0);
+  }
+
+  async check(): Promise<SchemaVersionReport> {
+    const known = this.listKnownMigrations();
+    const executed = (await this.orm.getMigrator().getExecutedMigrations()).map((row) => migrationName(row.name));
+    const executedSet = new Set(executed);
+    const knownSet = new Set(known);
+
+    const report: SchemaVersionReport = {
+      compatible: true,
+      latestKnown: known[known.length - 1],
+      latestApplied: [...executed].sort().pop(),
+      pending: known.filter((name) => !executedSet.has(name)),
+      unknownApplied: executed.filter((name) => !knownSet.has(name)).sort(),
+      compatWindow: this.compatWindow,
+    };
+
+    if (report.pending.length > 0) {
+      report.compatible = false;
+      report.reason =
+        `This build requires ${report.pending.length} migration(s) not yet applied (${report.pending.join(', ')}); ` +
+        'run "npm run migrate:prod -- up" before starting it';
+    } else if (report.unknownApplied.length > this.compatWindow) {
+      report.compatible = false;
+      report.reason =
+        `Database schema is ${report.unknownApplied.length} migration(s) ahead of this build, ` +
+        `beyond the compatibility window of ${this.compatWindow}; deploy a newer build`;
+    }
+
+    return report;
+  }
+
+  /**
+   * 检查并在不兼容时抛出异常；SCHEMA_GUARD=warn 时只记录日志，off 时跳过
+   */
+  async assertCompatible(): Promise<SchemaVersionReport | null> {
+    const mode = this.configService.get('SCHEMA_GUARD', 'enforce');
+    if (mode === 'off') {
+      return null;
+    }
+
+    const report = await this.check();
+    if (report.compatible) {
+      if (report.unknownApplied.length > 0) {
+        this.logger.warn(
+          `Database schema is ${report.unknownApplied.length} migration(s) ahead of this build (within compatibility window)`,
+        );
+      }
+      return report;
+    }
+
+    if (mode === 'warn') {
+      this.logger.warn(`Schema version mismatch: ${report.reason}`);
+      return report;
+    }
+    throw new Error(`Schema version mismatch: ${report.reason}`);
+  }
+
+  /**
+   * 当前构建自带的迁移（编译后读取 dist/migrations，ts-node 下读取 src/migrations）
+   */
+  private listKnownMigrations(): string[] {
+    const options = this.orm.config.get('migrations');
+    const runningTs = __filename.endsWith('.ts');
+    const dir = runningTs ? options.pathTs ?? options.path : options.path;
+    if (!dir || !existsSync(dir)) {
+      return [];
+    }
+    return readdirSync(dir)
+      .filter((file) => /\.(js|ts)$/.test(file) && !file.endsWith('.d.ts'))
+      .filter((file) => file.endsWith(runningTs ? '.ts' : '.js'))
+      .map(migrationName)
+      .sort();
+  }
+}
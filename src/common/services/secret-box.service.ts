@@ -0,0 +1,62 @@
+import { Injectable, ServiceUnavailableException } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { createCipheriv, createDecipheriv, randomBytes } from 'crypto';
+
+const ALGORITHM = 'aes-256-gcm';
+const VERSION = 'v1';
+
+/**
+ * 用户提供的凭据（如 webhook 的认证头）的加密存储
+ * AES-256-GCM，密钥来自 SECRETS_ENCRYPTION_KEY（base64 编码的 32 字节）；
+ * 密文格式为 v1:<iv>:<tag>:<ciphertext>，未配置密钥时拒绝保存凭据
+ */
+@Injectable()
+export class SecretBoxService {
+  private readonly key?: Buffer;
+
+  constructor(private readonly configService: ConfigService) {
+    const encoded = this.configService.get<string>('SECRETS_ENCRYPTION_KEY');
+    if (encoded) {
+      const key = Buffer.from(encoded, 'base64');
+      if (key.length !== 32) {
+        throw new Error('SECRETS_ENCRYPTION_KEY must be 32 bytes encoded as base64');
+      }
+      this.key = key;
+    }
+  }
+
+  encrypt(plaintext: string): string {
+    const key = this.requireKey();
+    const iv = randomBytes(12);
+    const cipher = createCipheriv(ALGORITHM, key, iv);
+    const ciphertext = Buffer.concat([cipher.update(plaintext, 'utf8'), cipher.final()]);
+    const parts = [iv, cipher.getAuthTag(), ciphertext].map((part) => part.toString('base64'));
+    return [VERSION, ...parts].join(':');
+  }
+
+  decrypt(value: string): string {
+    const key = this.requireKey();
+    const [version, iv, tag, ciphertext] = value.split(':');
+    if (version !== VERSION || !ciphertext) {
+      throw new Error('Unsupported secret format');
+    }
+    const decipher = createDecipheriv(ALGORITHM, key, Buffer.from(iv, 'base64'));
+    decipher.setAuthTag(Buffer.from(tag, 'base64'));
+    return Buffer.concat([decipher.update(Buffer.from(ciphertext, 'base64')), decipher.final()]).toString('utf8');
+  }
+
+  encryptJson(value: unknown): string {
+    return this.encrypt(JSON.stringify(value));
+  }
+
+  decryptJson<T>(value: string): T {
+    return JSON.parse(this.decrypt(value));
+  }
+
+  private requireKey(): Buffer {
+    if (!this.key) {
+      throw new ServiceUnavailableException('Secret encryption is not configured');
+    }
+    return this.key;
+  }
+}
@@ -0,0 +1,59 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager, raw } from '@mikro-orm/core';
+import {
+  StorageDriver,
+  StorageDriverCapabilities,
+  getStorageCapabilities,
+  resolveStorageDriver,
+} from '../../config/database.config';
+
+/**
+ * 存储驱动服务
+ * 暴露当前使用的数据库驱动及其能力，依赖特定数据库特性的功能据此降级
+ */
+@Injectable()
+export class StorageDriverService {
+  private readonly logger = new Logger(StorageDriverService.name);
+  readonly driver: StorageDriver;
+  readonly capabilities: StorageDriverCapabilities;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly em: EntityManager,
+  ) {
+    this.driver = resolveStorageDriver(this.configService.get('DB_DRIVER'));
+    this.capabilities = getStorageCapabilities(this.driver);
+  }
+
+  supports(capability: Exclude<keyof StorageDriverCapabilities, 'driver'>): boolean {
+    return this.capabilities[capability];
+  }
+
+  /**
+   * JSON 数组列包含任一给定值的过滤条件
+   * PostgreSQL 的 jsonb 列用 jsonb_exists_any，MySQL 用 JSON_CONTAINS 逐个判断（兼容 MariaDB）。
+   * 条件里的原生 SQL 片段只能用于一次查询，每次查询都要重新生成
+   */
+  jsonArrayOverlap(column: string, values: string[]): Record<string, unknown> {
+    const placeholders = values.map(() => '?');
+    if (this.driver === StorageDriver.MYSQL) {
+      const checks = placeholders.map((placeholder) => `JSON_CONTAINS(${column}, JSON_QUOTE(${placeholder}))`);
+      return { [raw(`(${checks.join(' OR ')})`, values)]: 1 };
+    }
+    return { [raw(`jsonb_exists_any(${column}, array[${placeholders.join(', ')}]::text[])`, values)]: true };
+  }
+
+  /**
+   * 检查数据库连接是否可用
+   */
+  async ping(): Promise<boolean> {
+    try {
+      await this.em.getConnection().execute('SELECT 1');
+      return true;
+    } catch (error) {
+      this.logger.error(`Storage ping failed (${this.driver}): ${error.message}`);
+      return false;
+    }
+  }
+}
@@ -0,0 +1,47 @@
+import express from 'express';
+import request from 'supertest';
+import { getClientIp, resolveTrustProxy } from '../client-ip';
+
+describe('client-ip', () => {
+  describe('resolveTrustProxy', () => {
+    it('未配置时不信任代理头', () => {
+      expect(resolveTrustProxy(undefined)).toBe(false);
+      expect(resolveTrustProxy(' false ')).toBe(false);
+    });
+
+    it('数字按代理层数，其余按逗号分隔的地址处理', () => {
+      expect(resolveTrustProxy('2')).toBe(2);
+      expect(resolveTrustProxy('loopback, 10.0.0.0/8')).toEqual(['loopback', '10.0.0.0/8']);
+    });
+
+    it('拒绝信任所有代理', () => {
+      expect(() => resolveTrustProxy('true')).toThrow('TRUST_PROXY=true');
+    });
+  });
+
+  describe('getClientIp', () => {
+    // 测试请求从 127.0.0.1 连接，X-Forwarded-For 最左侧是客户端伪造的地址
+    const clientIp = async (trustProxy: string | undefined) => {
+      const app = express();
+      app.set('trust proxy', resolveTrustProxy(trustProxy));
+      app.get('/', (req, res) => res.send(getClientIp(req)));
+      const response = await request(app)
+        .get('/')
+        .set('X-Forwarded-For', '6.6.6.6, 203.0.113.7')
+        .set('X-Real-IP', '6.6.6.6');
+      return response.text;
+    };
+
+    it('未配置代理时忽略 X-Forwarded-For 和 X-Real-IP，使用连接地址', async () => {
+      await expect(clientIp(undefined)).resolves.toBe('127.0.0.1');
+    });
+
+    it('按代理层数从右侧取第一个不受信任的地址', async () => {
+      await expect(clientIp('1')).resolves.toBe('203.0.113.7');
+    });
+
+    it('按受信任的代理地址从右侧取第一个不受信任的地址', async () => {
+      await expect(clientIp('loopback')).resolves.toBe('203.0.113.7');
+    });
+  });
+});
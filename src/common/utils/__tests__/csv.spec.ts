@@ -0,0 +1,38 @@
+import { formatCsv, parseCsv } from '../csv';
+
+describe('parseCsv', () => {
+  it('应按表头解析每一行', () => {
+    expect(parseCsv('userId,characters\nu1,100\r\nu2,200\n')).toEqual([
+      { userId: 'u1', characters: '100' },
+      { userId: 'u2', characters: '200' },
+    ]);
+  });
+
+  it('应支持引号内的逗号、换行和转义引号', () => {
+    const [row] = parseCsv('id,sourceJson\n1,"{""a"":""x, y""}\n"');
+
+    expect(row.sourceJson).toBe('{"a":"x, y"}\n');
+  });
+
+  it('应跳过空行，缺失的列为空字符串', () => {
+    expect(parseCsv('a,b\n\n1\n')).toEqual([{ a: '1', b: '' }]);
+  });
+
+  it('引号未闭合时应报错', () => {
+    expect(() => parseCsv('a\n"oops')).toThrow('Unterminated quoted field');
+  });
+});
+
+describe('formatCsv', () => {
+  it('应转义特殊字符，生成的内容可以被 parseCsv 读回', () => {
+    const csv = formatCsv(['id', 'note', 'createdAt'], [
+      { id: 1, note: 'a, "b"\nc', createdAt: new Date('2026-10-01T00:00:00Z') },
+      { id: 2, note: null },
+    ]);
+
+    expect(parseCsv(csv)).toEqual([
+      { id: '1', note: 'a, "b"\nc', createdAt: '2026-10-01T00:00:00.000Z' },
+      { id: '2', note: '', createdAt: '' },
+    ]);
+  });
+});
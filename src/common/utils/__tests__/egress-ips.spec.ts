@@ -0,0 +1,22 @@
+import { parseEgressIps } from '../egress-ips';
+
+describe('parseEgressIps', () => {
+  it('应解析 IP 和 CIDR 并去重', () => {
+    expect(parseEgressIps(' 203.0.113.10, 198.51.100.0/28,2001:db8::1,203.0.113.10 ')).toEqual([
+      '203.0.113.10',
+      '198.51.100.0/28',
+      '2001:db8::1',
+    ]);
+  });
+
+  it('未配置时返回空列表', () => {
+    expect(parseEgressIps(undefined)).toEqual([]);
+  });
+
+  it('格式错误时应抛出并指出变量名', () => {
+    expect(() => parseEgressIps('203.0.113.300', 'WEBHOOK_EGRESS_IPS')).toThrow(
+      'WEBHOOK_EGRESS_IPS contains an invalid IP address or CIDR range: 203.0.113.300',
+    );
+    expect(() => parseEgressIps('203.0.113.0/33')).toThrow('invalid IP address or CIDR range');
+  });
+});
@@ -0,0 +1,41 @@
+import { REDACTED, loadRedactionRules, redactValue, redactionFormat } from '../log-redaction';
+
+describe('log redaction', () => {
+  const rules = loadRedactionRules({ API_KEY_PREFIX: 'jt_' });
+
+  it('应该整体替换敏感字段', () => {
+    expect(
+      redactValue({ userId: 'u1', apiKey: 'jt_abc', nested: { WebhookUrl: 'https://hooks.example.com/x' } }, rules),
+    ).toEqual({ userId: 'u1', apiKey: REDACTED, nested: { WebhookUrl: REDACTED } });
+  });
+
+  it('应该脱敏消息中的 URL 路径、令牌和 JSON 内容', () => {
+    expect(redactValue('Sending to https://hooks.example.com/services/T000/secret?sig=1', rules)).toBe(
+      `Sending to https://hooks.example.com/${REDACTED}`,
+    );
+    expect(redactValue('Authorization: Bearer abc.def', rules)).toBe(`Authorization: Bearer ${REDACTED}`);
+    expect(redactValue('key jt_0123456789abcdef used', rules)).toBe(`key ${REDACTED} used`);
+    expect(redactValue('Parsed {"greeting":"hello"}', rules)).toBe('Parsed [REDACTED_JSON]');
+  });
+
+  it('应该支持自定义字段和正则', () => {
+    const custom = loadRedactionRules({ LOG_REDACT_KEYS: 'email', LOG_REDACT_PATTERNS: '["sk_live_[a-z0-9]+"]' });
+    expect(redactValue({ email: 'a@b.c', note: 'sk_live_abc123' }, custom)).toEqual({ email: REDACTED, note: REDACTED });
+  });
+
+  it('关闭后不应修改日志', () => {
+    const disabled = loadRedactionRules({ LOG_REDACTION: 'false' });
+    const info = { level: 'info', message: 'Bearer abc', token: 'x' } as any;
+    expect(redactionFormat(disabled).transform(info)).toEqual({ level: 'info', message: 'Bearer abc', token: 'x' });
+  });
+
+  it('format 应作用于消息和日志属性', () => {
+    const info = { level: 'info', message: 'Bearer abc', context: 'Test', token: 'x' } as any;
+    expect(redactionFormat(rules).transform(info)).toEqual({
+      level: 'info',
+      message: `Bearer ${REDACTED}`,
+      context: 'Test',
+      token: REDACTED,
+    });
+  });
+});
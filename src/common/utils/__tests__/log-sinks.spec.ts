@@ -0,0 +1,32 @@
+import { buildLogTransports, parseLogSinks, parseLokiLabels } from '../log-sinks';
+
+describe('log sinks', () => {
+  it('未配置时应默认输出到 console 和 file', () => {
+    expect(parseLogSinks(undefined)).toEqual(['console', 'file']);
+    expect(parseLogSinks('')).toEqual(['console', 'file']);
+  });
+
+  it('应该忽略未知的输出目标并去重', () => {
+    expect(parseLogSinks('Console, loki,unknown,loki')).toEqual(['console', 'loki']);
+  });
+
+  it('应该解析 Loki 标签', () => {
+    expect(parseLokiLabels('env=prod, region = eu,invalid,query=a=b')).toEqual({
+      env: 'prod',
+      region: 'eu',
+      query: 'a=b',
+    });
+  });
+
+  it('未配置 LOKI_URL 时应跳过 loki 输出', () => {
+    const warn = jest.spyOn(console, 'warn').mockImplementation(() => undefined);
+    expect(buildLogTransports({ LOG_SINKS: 'loki' })).toHaveLength(0);
+    warn.mockRestore();
+  });
+
+  it('file 输出应分别创建错误日志和完整日志两个轮转文件', () => {
+    const result = buildLogTransports({ LOG_SINKS: 'file', LOG_DIR: '/tmp/json-translation-api-logs' });
+    expect(result).toHaveLength(2);
+    expect(result[0].level).toBe('error');
+  });
+});
@@ -0,0 +1,26 @@
+import { assertPublicUrl, isPrivateAddress } from '../url-safety';
+
+describe('url-safety', () => {
+  it('应识别内网、回环和链路本地地址', () => {
+    expect(isPrivateAddress('10.1.2.3')).toBe(true);
+    expect(isPrivateAddress('172.20.0.1')).toBe(true);
+    expect(isPrivateAddress('192.168.1.1')).toBe(true);
+    expect(isPrivateAddress('127.0.0.1')).toBe(true);
+    expect(isPrivateAddress('169.254.169.254')).toBe(true);
+    expect(isPrivateAddress('::1')).toBe(true);
+    expect(isPrivateAddress('::ffff:10.0.0.1')).toBe(true);
+    expect(isPrivateAddress('fd00::1')).toBe(true);
+  });
+
+  it('公网地址不应被拦截', () => {
+    expect(isPrivateAddress('8.8.8.8')).toBe(false);
+    expect(isPrivateAddress('172.32.0.1')).toBe(false);
+    expect(isPrivateAddress('2606:4700::1111')).toBe(false);
+  });
+
+  it('应拒绝指向内网的地址和非 http 协议', async () => {
+    await expect(assertPublicUrl('http://127.0.0.1/export.json')).rejects.toThrow('private or reserved');
+    await expect(assertPublicUrl('http://[::1]:8080/')).rejects.toThrow('private or reserved');
+    await expect(assertPublicUrl('file:///etc/passwd')).rejects.toThrow('Unsupported protocol');
+  });
+});
@@ -0,0 +1,25 @@
+import { crc32, createZip } from '../zip';
+
+describe('createZip', () => {
+  it('CRC32 应与标准值一致', () => {
+    expect(crc32(Buffer.from('123456789'))).toBe(0xcbf43926);
+  });
+
+  it('应写入本地文件头、中央目录和结束记录', () => {
+    const zip = createZip([
+      { name: 'en.json', content: '{"a":"Hello"}' },
+      { name: 'de.json', content: '{"a":"Hallo"}' },
+    ]);
+
+    expect(zip.readUInt32LE(0)).toBe(0x04034b50);
+    expect(zip.subarray(30, 37).toString()).toBe('en.json');
+    expect(zip.subarray(37, 50).toString()).toBe('{"a":"Hello"}');
+
+    const end = zip.subarray(zip.length - 22);
+    expect(end.readUInt32LE(0)).toBe(0x06054b50);
+    expect(end.readUInt16LE(10)).toBe(2);
+    const centralOffset = end.readUInt32LE(16);
+    expect(zip.readUInt32LE(centralOffset)).toBe(0x02014b50);
+    expect(centralOffset + end.readUInt32LE(12)).toBe(zip.length - 22);
+  });
+});
@@ -0,0 +1,32 @@
+/**
+ * 解析 TRUST_PROXY，作为 Express 的 trust proxy 设置
+ * 未设置时不信任任何代理头，直接使用连接地址；数字表示应用前面的反向代理层数，
+ * 其余按逗号分隔的 IP / CIDR 或 Express 预设名称（loopback、linklocal、uniquelocal）处理。
+ * 不接受 true：信任所有代理时客户端 IP 取 X-Forwarded-For 最左侧的值，而这个值由客户端自己填写
+ */
+export function resolveTrustProxy(raw: string | undefined): number | string[] | false {
+  const value = (raw ?? '').trim().toLowerCase();
+  if (!value || value === 'false') {
+    return false;
+  }
+  if (value === 'true') {
+    throw new Error('TRUST_PROXY=true would trust client-supplied X-Forwarded-For; set the proxy hop count or CIDRs');
+  }
+  if (/^\d+$/.test(value)) {
+    return Number(value);
+  }
+  return value
+    .split(',')
+    .map((entry) => entry.trim())
+    .filter(Boolean);
+}
+
+/**
+ * 取请求的客户端 IP
+ * 使用 Express 按 trust proxy 计算的 request.ip：从 X-Forwarded-For 右侧跳过受信任的代理，取第一个不受信任的地址；
+ * 不直接读取 X-Forwarded-For / X-Real-IP，这两个头客户端可以任意设置
+ */
+export function getClientIp(request: any): string {
+  const ip: string = request.ip || request.socket?.remoteAddress || 'unknown';
+  return ip.replace(/^::ffff:/, '');
+}
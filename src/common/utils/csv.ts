@@ -0,0 +1,75 @@
+/**
+ * 解析 RFC 4180 风格的 CSV：首行为表头，支持双引号包裹的字段（含逗号、换行和 "" 转义）
+ */
+export function parseCsv(text: string): Record<string, string>[] {
+  const rows = parseRows(text.replace(/^\uFEFF/, ''));
+  if (rows.length === 0) {
+    return [];
+  }
+  const header = rows[0].map((name) => name.trim());
+  return rows
+    .slice(1)
+    .filter((row) => row.some((cell) => cell !== ''))
+    .map((row) => Object.fromEntries(header.map((name, index) => [name, row[index] ?? ''])));
+}
+
+/**
+ * 按列顺序生成 CSV：首行为表头，含逗号、引号或换行的字段用双引号包裹，空值输出为空字段
+ */
+export function formatCsv(columns: string[], rows: Record<string, unknown>[]): string {
+  const escape = (value: unknown) => {
+    if (value === undefined || value === null) {
+      return '';
+    }
+    const text = value instanceof Date ? value.toISOString() : String(value);
+    return /[",\r\n]/.test(text) ? `"${text.replace(/"/g, '""')}"` : text;
+  };
+  return [columns, ...rows.map((row) => columns.map((column) => row[column]))]
+    .map((cells) => cells.map(escape).join(','))
+    .join('\n')
+    .concat('\n');
+}
+
+function parseRows(text: string): string[][] {
+  const rows: string[][] = [];
+  let row: string[] = [];
+  let cell = '';
+  let quoted = false;
+
+  for (let i = 0; i < text.length; i++) {
+    const char = text[i];
+    if (quoted) {
+      if (char === '"' && text[i + 1] === '"') {
+        cell += '"';
+        i++;
+      } else if (char === '"') {
+        quoted = false;
+      } else {
+        cell += char;
+      }
+    } else if (char === '"') {
+      quoted = true;
+    } else if (char === ',') {
+      row.push(cell);
+      cell = '';
+    } else if (char === '\n' || char === '\r') {
+      if (char === '\r' && text[i + 1] === '\n') {
+        i++;
+      }
+      row.push(cell);
+      rows.push(row);
+      row = [];
+      cell = '';
+    } else {
+      cell += char;
+    }
+  }
+  if (quoted) {
+    throw new Error('Unterminated quoted field');
+  }
+  if (cell !== '' || row.length > 0) {
+    row.push(cell);
+    rows.push(row);
+  }
+  return rows;
+}
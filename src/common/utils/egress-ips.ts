@@ -0,0 +1,22 @@
+import { isIP } from 'net';
+
+/**
+ * 解析出口 IP 列表（逗号分隔的 IP 或 CIDR），去重；格式错误时抛出，避免对外公布错误的地址
+ */
+export function parseEgressIps(raw: string | undefined, name = 'EGRESS_IPS'): string[] {
+  const entries = (raw ?? '')
+    .split(',')
+    .map((entry) => entry.trim())
+    .filter(Boolean);
+
+  for (const entry of entries) {
+    const [address, prefix, ...rest] = entry.split('/');
+    const version = isIP(address);
+    const maxPrefix = version === 6 ? 128 : 32;
+    const validPrefix = prefix === undefined || (/^\d+$/.test(prefix) && Number(prefix) <= maxPrefix);
+    if (!version || !validPrefix || rest.length > 0) {
+      throw new Error(`${name} contains an invalid IP address or CIDR range: ${entry}`);
+    }
+  }
+  return Array.from(new Set(entries));
+}
@@ -0,0 +1,125 @@
+import { format } from 'winston';
+
+export const REDACTED = '[REDACTED]';
+
+const MAX_DEPTH = 8;
+
+/**
+ * 默认脱敏字段（不区分大小写）：翻译内容、凭据和 webhook 地址
+ */
+const DEFAULT_REDACT_KEYS = [
+  'content',
+  'sourceJson',
+  'translatedContent',
+  'originalContent',
+  'json',
+  'payload',
+  'password',
+  'token',
+  'accessToken',
+  'refreshToken',
+  'apiKey',
+  'secret',
+  'authorization',
+  'cookie',
+  'webhookUrl',
+  'callbackUrl',
+];
+
+export interface RedactionPattern {
+  regex: RegExp;
+  replacement: string;
+}
+
+export interface RedactionRules {
+  enabled: boolean;
+  keys: Set<string>;
+  patterns: RedactionPattern[];
+}
+
+function escapeRegExp(value: string): string {
+  return value.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
+}
+
+/**
+ * 从环境变量加载脱敏规则
+ * LOG_REDACT_KEYS 追加字段名，LOG_REDACT_PATTERNS 追加正则（JSON 数组），LOG_REDACTION=false 关闭
+ */
+export function loadRedactionRules(env: NodeJS.ProcessEnv = process.env): RedactionRules {
+  const keys = new Set(DEFAULT_REDACT_KEYS.map((key) => key.toLowerCase()));
+  for (const key of (env.LOG_REDACT_KEYS ?? '').split(',')) {
+    if (key.trim()) {
+      keys.add(key.trim().toLowerCase());
+    }
+  }
+
+  const patterns: RedactionPattern[] = [
+    // 消息中内嵌的 JSON 文档
+    { regex: /\{\s*"[\s\S]*\}/g, replacement: '[REDACTED_JSON]' },
+    { regex: /\bBearer\s+[A-Za-z0-9\-._~+/]+=*/gi, replacement: `Bearer ${REDACTED}` },
+    // JWT
+    { regex: /\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+/g, replacement: REDACTED },
+    // URL 只保留协议和主机，路径和查询参数里常带有密钥（如 webhook 地址）
+    { regex: /\b(https?:\/\/[^/\s"']+)[^\s"']*/gi, replacement: `$1/${REDACTED}` },
+  ];
+  if (env.API_KEY_PREFIX) {
+    patterns.push({ regex: new RegExp(`${escapeRegExp(env.API_KEY_PREFIX)}[A-Za-z0-9_-]{8,}`, 'g'), replacement: REDACTED });
+  }
+
+  if (env.LOG_REDACT_PATTERNS) {
+    try {
+      for (const source of JSON.parse(env.LOG_REDACT_PATTERNS) as string[]) {
+        patterns.push({ regex: new RegExp(source, 'g'), replacement: REDACTED });
+      }
+    } catch (error) {
+      // eslint-disable-next-line no-console
+      console.warn(`Ignoring invalid LOG_REDACT_PATTERNS: ${error.message}`);
+    }
+  }
+
+  return { enabled: env.LOG_REDACTION !== 'false', keys, patterns };
+}
+
+export function redactString(value: string, rules: RedactionRules): string {
+  return rules.patterns.reduce((result, pattern) => result.replace(pattern.regex, pattern.replacement), value);
+}
+
+/**
+ * 递归脱敏：命中字段名的值整体替换，其余字符串按正则替换
+ */
+export function redactValue(value: any, rules: RedactionRules, depth = 0): any {
+  if (typeof value === 'string') {
+    return redactString(value, rules);
+  }
+  if (value === null || typeof value !== 'object' || value instanceof Date) {
+    return value;
+  }
+  if (depth >= MAX_DEPTH) {
+    return REDACTED;
+  }
+  if (Array.isArray(value)) {
+    return value.map((item) => redactValue(item, rules, depth + 1));
+  }
+
+  const result: Record<string, any> = {};
+  for (const [key, item] of Object.entries(value)) {
+    result[key] = rules.keys.has(key.toLowerCase()) ? REDACTED : redactValue(item, rules, depth + 1);
+  }
+  return result;
+}
+
+/**
+ * winston 脱敏 format，作用于消息和所有日志属性（level 等内部字段除外）
+ */
+export const redactionFormat = format((info, rules: RedactionRules) => {
+  if (!rules?.enabled) {
+    return info;
+  }
+  for (const key of Object.keys(info)) {
+    if (key === 'level' || key === 'timestamp') {
+      continue;
+    }
+    info[key] = rules.keys.has(key.toLowerCase()) ? REDACTED : redactValue(info[key], rules);
+  }
+  return info;
+});
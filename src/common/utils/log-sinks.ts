@@ -0,0 +1,99 @@
+import * as path from 'path';
+import { transports } from 'winston';
+import * as Transport from 'winston-transport';
+import DailyRotateFile from 'winston-daily-rotate-file';
+import LokiTransport from 'winston-loki';
+import { Syslog } from 'winston-syslog';
+
+export type LogSink = 'console' | 'file' | 'syslog' | 'loki';
+
+const KNOWN_SINKS: LogSink[] = ['console', 'file', 'syslog', 'loki'];
+
+/**
+ * 解析 LOG_SINKS（逗号分隔），忽略未知值；为空时回退到 console + file
+ */
+export function parseLogSinks(raw: string | undefined): LogSink[] {
+  const sinks = (raw ?? '')
+    .split(',')
+    .map((sink) => sink.trim().toLowerCase())
+    .filter((sink): sink is LogSink => KNOWN_SINKS.includes(sink as LogSink));
+  return sinks.length > 0 ? Array.from(new Set(sinks)) : ['console', 'file'];
+}
+
+/**
+ * 解析 Loki 标签，格式 key=value,key2=value2
+ */
+export function parseLokiLabels(raw: string | undefined): Record<string, string> {
+  const labels: Record<string, string> = {};
+  for (const pair of (raw ?? '').split(',')) {
+    const [key, ...rest] = pair.split('=');
+    if (key?.trim() && rest.length > 0) {
+      labels[key.trim()] = rest.join('=').trim();
+    }
+  }
+  return labels;
+}
+
+/**
+ * 按环境变量构建 winston 输出目标
+ * 日志器在 Nest 容器之前创建，因此直接读取 process.env
+ */
+export function buildLogTransports(env: NodeJS.ProcessEnv = process.env): Transport[] {
+  const appName = env.LOG_APP_NAME || 'json-translation-api';
+  const result: Transport[] = [];
+
+  for (const sink of parseLogSinks(env.LOG_SINKS)) {
+    switch (sink) {
+      case 'console':
+        result.push(new transports.Console());
+        break;
+      case 'file': {
+        // 按天切分，同时限制单文件大小和保留时长，避免长期运行的 worker 日志无限增长
+        const dirname = env.LOG_DIR || 'logs';
+        const rotation = {
+          dirname,
+          datePattern: 'YYYY-MM-DD',
+          zippedArchive: env.LOG_FILE_COMPRESS === 'true',
+          maxSize: env.LOG_FILE_MAX_SIZE || '20m',
+          maxFiles: env.LOG_FILE_MAX_AGE || '14d',
+          auditFile: path.join(dirname, '.audit.json'),
+        };
+        result.push(new DailyRotateFile({ ...rotation, filename: 'error-%DATE%.log', level: 'error' }));
+        result.push(new DailyRotateFile({ ...rotation, filename: 'combined-%DATE%.log' }));
+        break;
+      }
+      case 'syslog':
+        result.push(
+          new Syslog({
+            host: env.SYSLOG_HOST || 'localhost',
+            port: Number(env.SYSLOG_PORT || 514),
+            protocol: env.SYSLOG_PROTOCOL || 'udp4',
+            app_name: appName,
+            localhost: env.HOSTNAME,
+          }) as unknown as Transport,
+        );
+        break;
+      case 'loki':
+        if (!env.LOKI_URL) {
+          // eslint-disable-next-line no-console
+          console.warn('LOG_SINKS includes loki but LOKI_URL is not set, skipping');
+          break;
+        }
+        result.push(
+          new LokiTransport({
+            host: env.LOKI_URL,
+            basicAuth: env.LOKI_BASIC_AUTH || undefined,
+            labels: { app: appName, ...parseLokiLabels(env.LOKI_LABELS) },
+            json: true,
+            batching: true,
+            interval: Number(env.LOKI_BATCH_INTERVAL_SECONDS || 5),
+            replaceTimestamp: true,
+            onConnectionError: (error) => console.error(`Loki push failed: ${error}`),
+          }),
+        );
+        break;
+    }
+  }
+
+  return result;
+}
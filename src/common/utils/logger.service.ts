@@ -1,5 +1,7 @@
 import { Injectable, LoggerService } from '@nestjs/common';
-import { createLogger, format, transports } from 'winston';
+import { createLogger, format } from 'winston';
+import { buildLogTransports } from './log-sinks';
+import { loadRedactionRules, redactionFormat } from './log-redaction';
 
 @Injectable()
 export class CustomLogger implements LoggerService {
@@ -7,16 +9,15 @@ export class CustomLogger implements LoggerService {
 
   constructor() {
     this.logger = createLogger({
-      level: 'info',
+      level: process.env.LOG_LEVEL || 'info',
       format: format.combine(
+        // 写出前先脱敏：翻译内容、令牌和 webhook 地址不会进入任何输出目标
+        redactionFormat(loadRedactionRules()),
         format.timestamp(),
         format.json(),
       ),
-      transports: [
-        new transports.Console(),
-        new transports.File({ filename: 'error.log', level: 'error' }),
-        new transports.File({ filename: 'combined.log' }),
-      ],
+      // 输出目标由 LOG_SINKS 选择：console、file（按大小/时间轮转）、syslog、loki
+      transports: buildLogTransports(),
     });
   }
 
@@ -0,0 +1,41 @@
+import { AsyncLocalStorage } from 'async_hooks';
+
+/**
+ * 请求耗时拆分的阶段
+ */
+export type TimingPhase = 'auth' | 'storage' | 'enqueue';
+
+export interface RequestTiming {
+  startedAt: number;
+  phases: Partial<Record<TimingPhase, number>>;
+}
+
+const storage = new AsyncLocalStorage<RequestTiming>();
+
+/**
+ * 在新的计时上下文中执行请求处理
+ */
+export function runWithRequestTiming<R>(fn: () => R): R {
+  return storage.run({ startedAt: Date.now(), phases: {} }, fn);
+}
+
+export function currentRequestTiming(): RequestTiming | undefined {
+  return storage.getStore();
+}
+
+/**
+ * 统计某个阶段的耗时并累加到当前请求；不在请求上下文中（如 worker）时直接执行
+ */
+export async function measurePhase<R>(phase: TimingPhase, fn: () => Promise<R>): Promise<R> {
+  const timing = storage.getStore();
+  if (!timing) {
+    return fn();
+  }
+  const start = process.hrtime.bigint();
+  try {
+    return await fn();
+  } finally {
+    const elapsedMs = Number(process.hrtime.bigint() - start) / 1e6;
+    timing.phases[phase] = (timing.phases[phase] ?? 0) + elapsedMs;
+  }
+}
@@ -0,0 +1,62 @@
+import { promises as dns } from 'dns';
+import { isIP } from 'net';
+
+const PRIVATE_IPV4_RANGES: Array<[string, number]> = [
+  ['0.0.0.0', 8],
+  ['10.0.0.0', 8],
+  ['100.64.0.0', 10],
+  ['127.0.0.0', 8],
+  ['169.254.0.0', 16],
+  ['172.16.0.0', 12],
+  ['192.168.0.0', 16],
+  ['198.18.0.0', 15],
+  ['224.0.0.0', 4],
+  ['240.0.0.0', 4],
+];
+
+function ipv4ToInt(ip: string): number {
+  return ip.split('.').reduce((acc, part) => (acc << 8) + Number(part), 0) >>> 0;
+}
+
+/**
+ * 是否为内网、回环、链路本地等不应由服务端主动访问的地址
+ */
+export function isPrivateAddress(ip: string): boolean {
+  const mapped = ip.toLowerCase().match(/^::ffff:(\d+\.\d+\.\d+\.\d+)$/);
+  if (mapped) {
+    return isPrivateAddress(mapped[1]);
+  }
+
+  if (isIP(ip) === 4) {
+    const value = ipv4ToInt(ip);
+    return PRIVATE_IPV4_RANGES.some(([base, bits]) => {
+      const mask = bits === 0 ? 0 : (~0 << (32 - bits)) >>> 0;
+      return (value & mask) === (ipv4ToInt(base) & mask);
+    });
+  }
+
+  const normalized = ip.toLowerCase();
+  return (
+    normalized === '::' ||
+    normalized === '::1' ||
+    normalized.startsWith('fc') ||
+    normalized.startsWith('fd') ||
+    normalized.startsWith('fe80')
+  );
+}
+
+/**
+ * 校验用户提供的地址只指向公网主机，防止借服务端访问内网（SSRF）
+ */
+export async function assertPublicUrl(rawUrl: string): Promise<void> {
+  const url = new URL(rawUrl);
+  if (url.protocol !== 'http:' && url.protocol !== 'https:') {
+    throw new Error(`Unsupported protocol ${url.protocol}`);
+  }
+
+  const hostname = url.hostname.replace(/^\[|\]$/g, '');
+  const addresses = isIP(hostname) ? [hostname] : (await dns.lookup(hostname, { all: true })).map((item) => item.address);
+  if (addresses.length === 0 || addresses.some(isPrivateAddress)) {
+    throw new Error(`${url.hostname} resolves to a private or reserved address`);
+  }
+}
@@ -0,0 +1,35 @@
+import {
+  ProcessRole,
+  WorkerQueue,
+  isReadOnlyMode,
+  resolveProcessRole,
+  resolveWorkerQueues,
+  runsWorkers,
+  servesHttp,
+} from '../process-role';
+
+describe('process-role', () => {
+  it('未设置或无法识别的角色按 all 运行', () => {
+    expect(resolveProcessRole(undefined)).toBe(ProcessRole.ALL);
+    expect(resolveProcessRole('scheduler')).toBe(ProcessRole.ALL);
+    expect(resolveProcessRole(' Worker ')).toBe(ProcessRole.WORKER);
+  });
+
+  it('all 同时提供 HTTP 接口和队列消费者，api 和 worker 各只运行一种', () => {
+    expect([servesHttp(ProcessRole.ALL), runsWorkers(ProcessRole.ALL)]).toEqual([true, true]);
+    expect([servesHttp(ProcessRole.API), runsWorkers(ProcessRole.API)]).toEqual([true, false]);
+    expect([servesHttp(ProcessRole.WORKER), runsWorkers(ProcessRole.WORKER)]).toEqual([false, true]);
+  });
+
+  it('WORKER_QUEUES 为空或都无法识别时消费全部队列', () => {
+    expect(resolveWorkerQueues(undefined)).toEqual([WorkerQueue.TRANSLATION, WorkerQueue.WEBHOOK]);
+    expect(resolveWorkerQueues('emails')).toEqual([WorkerQueue.TRANSLATION, WorkerQueue.WEBHOOK]);
+    expect(resolveWorkerQueues(' Webhook , emails')).toEqual([WorkerQueue.WEBHOOK]);
+  });
+
+  it('READ_ONLY_MODE 只接受 true', () => {
+    expect(isReadOnlyMode({ READ_ONLY_MODE: 'TRUE' } as NodeJS.ProcessEnv)).toBe(true);
+    expect(isReadOnlyMode({ READ_ONLY_MODE: '1' } as NodeJS.ProcessEnv)).toBe(false);
+    expect(isReadOnlyMode({} as NodeJS.ProcessEnv)).toBe(false);
+  });
+});
@@ -0,0 +1,102 @@
+import { mkdtempSync, writeFileSync } from 'fs';
+import { tmpdir } from 'os';
+import { join } from 'path';
+import { buildTlsOptions } from '../tls.config';
+import { buildRedisOptions } from '../redis.config';
+import { buildDatabaseOptions } from '../database.config';
+
+describe('tls.config', () => {
+  const dir = mkdtempSync(join(tmpdir(), 'tls-config-'));
+  const pem = (name: string, content: string) => {
+    const path = join(dir, name);
+    writeFileSync(path, content);
+    return path;
+  };
+  const caFile = pem('ca.pem', 'CA');
+  const certFile = pem('client.pem', 'CERT');
+  const keyFile = pem('client.key', 'KEY');
+
+  const env = (values: Record<string, string>) => values as NodeJS.ProcessEnv;
+
+  describe('buildTlsOptions', () => {
+    it('未启用且没有配置证书时返回 undefined', () => {
+      expect(buildTlsOptions('REDIS', env({}))).toBeUndefined();
+      expect(buildTlsOptions('REDIS', env({ REDIS_TLS: 'false' }))).toBeUndefined();
+    });
+
+    it('<PREFIX>_TLS=true 时使用系统 CA 并默认校验证书', () => {
+      expect(buildTlsOptions('PROVIDER', env({ PROVIDER_TLS: 'true' }))).toEqual({ rejectUnauthorized: true });
+    });
+
+    it('配置了 CA 时自动启用，并读取客户端证书、私钥口令和 SNI 主机名', () => {
+      const options = buildTlsOptions(
+        'DB',
+        env({
+          DB_TLS_CA_FILE: caFile,
+          DB_TLS_CERT_FILE: certFile,
+          DB_TLS_KEY_FILE: keyFile,
+          DB_TLS_KEY_PASSPHRASE: 'secret',
+          DB_TLS_SERVERNAME: 'db.internal',
+        }),
+      );
+
+      expect(options).toEqual({
+        ca: 'CA',
+        cert: 'CERT',
+        key: 'KEY',
+        passphrase: 'secret',
+        servername: 'db.internal',
+        rejectUnauthorized: true,
+      });
+    });
+
+    it('各服务只读取自己前缀的变量', () => {
+      expect(buildTlsOptions('REDIS', env({ DB_TLS: 'true', DB_TLS_CA_FILE: caFile }))).toBeUndefined();
+    });
+
+    it('只有 <PREFIX>_TLS_REJECT_UNAUTHORIZED=false 才关闭证书校验', () => {
+      expect(buildTlsOptions('REDIS', env({ REDIS_TLS: 'true', REDIS_TLS_REJECT_UNAUTHORIZED: 'false' }))).toEqual({
+        rejectUnauthorized: false,
+      });
+      expect(buildTlsOptions('REDIS', env({ REDIS_TLS: 'true', REDIS_TLS_REJECT_UNAUTHORIZED: '0' }))).toEqual({
+        rejectUnauthorized: true,
+      });
+    });
+
+    it('客户端证书和私钥必须同时配置', () => {
+      expect(() => buildTlsOptions('DB', env({ DB_TLS_CERT_FILE: certFile }))).toThrow(
+        'DB_TLS_CERT_FILE and DB_TLS_KEY_FILE must be set together',
+      );
+    });
+
+    it('证书文件无法读取时报告变量名和路径', () => {
+      const missing = join(dir, 'missing.pem');
+
+      expect(() => buildTlsOptions('REDIS', env({ REDIS_TLS_CA_FILE: missing }))).toThrow(
+        `Cannot read REDIS_TLS_CA_FILE (${missing})`,
+      );
+    });
+  });
+
+  describe('connection wiring', () => {
+    it('Redis 连接默认不启用 TLS，启用后传给 ioredis 的 tls 选项', () => {
+      expect(buildRedisOptions(env({}))).toEqual({ host: 'localhost', port: 6379 });
+      expect(buildRedisOptions(env({ REDIS_TLS: 'true', REDIS_TLS_CA_FILE: caFile })).tls).toEqual({
+        ca: 'CA',
+        rejectUnauthorized: true,
+      });
+    });
+
+    it('数据库连接启用 TLS 后通过 driverOptions.connection.ssl 传给 pg 和 mysql2', () => {
+      expect(buildDatabaseOptions(env({})).driverOptions).toBeUndefined();
+
+      for (const driver of ['postgresql', 'mysql']) {
+        const options = buildDatabaseOptions(env({ DB_DRIVER: driver, DB_TLS: 'true', DB_TLS_SERVERNAME: 'db' }));
+
+        expect(options.driverOptions).toEqual({
+          connection: { ssl: { servername: 'db', rejectUnauthorized: true } },
+        });
+      }
+    });
+  });
+});
@@ -0,0 +1,431 @@
+/**
+ * API 变更登记表
+ * 对接口或推送载荷的每个对外可见的变更都在这里登记一条；GET /meta/changelog 原样公开，
+ * 带 deprecation 的接口变更会在该接口的每个响应中附带 Deprecation / Sunset 响应头
+ */
+export enum ApiChangeType {
+  ADDED = 'added',
+  CHANGED = 'changed',
+  DEPRECATED = 'deprecated',
+  REMOVED = 'removed',
+}
+
+export interface ApiChangeEndpoint {
+  method: 'GET' | 'POST' | 'PUT' | 'PATCH' | 'DELETE';
+  /** 完整路由模板，与 Nest 路由一致，如 /api/v1/translation/:id */
+  path: string;
+}
+
+export interface ApiChangeDeprecation {
+  /** 开始弃用的日期（YYYY-MM-DD） */
+  deprecatedAt: string;
+  /** 计划下线日期，为空表示暂未确定 */
+  sunsetAt?: string;
+  /** 替代的接口或字段 */
+  replacement?: string;
+}
+
+export interface ApiChange {
+  /** 稳定的条目 ID，SDK 可据此去重 */
+  id: string;
+  date: string;
+  type: ApiChangeType;
+  /** 是否需要调用方修改代码 */
+  breaking: boolean;
+  summary: string;
+  endpoint?: ApiChangeEndpoint;
+  /** 受影响的载荷，如 webhook:translation.result、response:GET /api/v1/translation/task/:id/result */
+  schema?: string;
+  deprecation?: ApiChangeDeprecation;
+}
+
+export const API_CHANGELOG: ApiChange[] = [
+  {
+    id: '2026-10-16-api-key-expiry',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Change the expiry of an API key after creation; api_key.expiring webhook events and emails are sent ' +
+      'before a key expires.',
+    endpoint: { method: 'PATCH', path: '/api/v1/api-key/:id/expiry' },
+  },
+  {
+    id: '2026-10-16-language-detection-backfill',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Admin batch job detecting the source language of stored documents without fromLang or with a low ' +
+      'detection confidence; results are recorded on the documents.',
+    endpoint: { method: 'POST', path: '/api/v1/admin/language-detection' },
+  },
+  {
+    id: '2026-10-16-incident-annotations',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Task status, task result and document list entries include incidents: provider incidents declared by ' +
+      'operators while the task was queued or running.',
+    endpoint: { method: 'GET', path: '/api/v1/translation/task/:id/status' },
+  },
+  {
+    id: '2026-10-16-audit-log',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Authenticated create, update and delete requests are recorded with the user, API key, route, resource id and ' +
+      'client IP; GET /user/audit_log lists them with filters.',
+    endpoint: { method: 'GET', path: '/api/v1/user/audit_log' },
+  },
+  {
+    id: '2026-10-16-pii-masking',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'maskPii and piiPatterns on task creation mask emails, phone numbers, card numbers and custom patterns ' +
+      'before provider calls; the task result adds piiReport with the masked spans.',
+    endpoint: { method: 'POST', path: '/api/v1/translation/task' },
+  },
+  {
+    id: '2026-10-16-provider-account-failures',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'translation.failed adds the reasons provider_quota_exhausted and provider_credentials_rejected; such tasks ' +
+      'now fail without queue retries when no fallback provider is available.',
+  },
+  {
+    id: '2026-10-16-file-formats',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'GET /meta/formats lists input and output file formats and their per-format options.',
+    endpoint: { method: 'GET', path: '/api/v1/meta/formats' },
+  },
+  {
+    id: '2026-10-16-document-encryption',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'PUT /user/encryption enables envelope encryption of stored source and translated JSON with a per-user ' +
+      'data key; reads are decrypted transparently.',
+    endpoint: { method: 'PUT', path: '/api/v1/user/encryption' },
+  },
+  {
+    id: '2026-10-16-project-target-languages',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Projects declare target languages (PUT /user/projects/:id); POST /user/projects/:id/translate expands a ' +
+      'source document into one task per target, tracked as a job with an aggregate status.',
+    endpoint: { method: 'POST', path: '/api/v1/user/projects/:id/translate' },
+  },
+  {
+    id: '2026-10-16-key-usage',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'GET /translation/:id/usage breaks the billed characters of a document down by key path, with an optional ' +
+      'depth to roll the breakdown up.',
+    endpoint: { method: 'GET', path: '/api/v1/translation/:id/usage' },
+  },
+  {
+    id: '2026-10-16-data-retention',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Per-plan and per-user retention (GET/PUT /user/retention) purges document content after the configured ' +
+      'number of days; purged documents return 410 DOCUMENT_PURGED.',
+    endpoint: { method: 'PUT', path: '/api/v1/user/retention' },
+  },
+  {
+    id: '2026-10-16-webhook-result-diff',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Webhook configs accept includeDiff; translation.completed envelopes then carry data.diff with the keys ' +
+      'changed or removed since the previous successful delivery.',
+    endpoint: { method: 'PUT', path: '/api/v1/webhook/config/:id/payload-format' },
+  },
+  {
+    id: '2026-10-16-custom-mt-engine',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'New provider "custom" translates with a user-configured MT endpoint (URL, auth header, request template ' +
+      'and response path), managed under /user/mt_engine.',
+    endpoint: { method: 'PUT', path: '/api/v1/user/mt_engine' },
+  },
+  {
+    id: '2026-10-16-document-list-cursor',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'The documents list sorts by create_time, update_time or char_total (orderBy, direction), returns ' +
+      'charTotal and a nextCursor for keyset pagination with ?cursor=.',
+    endpoint: { method: 'GET', path: '/api/v1/translation/documents' },
+  },
+  {
+    id: '2026-10-16-usage-reports',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Weekly or monthly usage reports by email or usage.report webhook event, with top documents ' +
+      'and a month-end forecast.',
+    endpoint: { method: 'PUT', path: '/api/v1/user/usage_reports' },
+  },
+  {
+    id: '2026-10-16-document-names',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Documents take an optional name and tags, editable through PATCH :id/metadata; ' +
+      'GET /api/v1/translation/documents filters by ?tag= and searches names with ?q=.',
+    endpoint: { method: 'PATCH', path: '/api/v1/translation/:id/metadata' },
+  },
+  {
+    id: '2026-10-16-result-downloads',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'Download the translated document as a raw, pretty, flat or side-by-side JSON file.',
+    endpoint: { method: 'GET', path: '/api/v1/translation/task/:id/download' },
+  },
+  {
+    id: '2026-10-16-preserve-formatting',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'preserveFormatting repairs whitespace, line endings and placeholder case in translations and ' +
+      'reports whitespace, placeholder_case and line_breaks validation issues.',
+    endpoint: { method: 'POST', path: '/api/v1/translation/task' },
+  },
+  {
+    id: '2026-10-16-duplicate-submissions',
+    date: '2026-10-16',
+    type: ApiChangeType.CHANGED,
+    breaking: true,
+    summary:
+      'Resubmitting the same document and options within 10 minutes returns 409 with documentId ' +
+      'instead of creating a second document, unless force=true.',
+    endpoint: { method: 'POST', path: '/api/v1/translation/task' },
+  },
+  {
+    id: '2026-10-16-ignored-field-suggestions',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'Analyzer suggesting fields to leave untranslated (IDs, URLs, enums, dates, slugs) with confidence.',
+    endpoint: { method: 'POST', path: '/api/v1/tools/analyze' },
+  },
+  {
+    id: '2026-10-16-ignore-profiles',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'Ignore profiles with field, path, regex, include and value-heuristic rules, referenced by profileId.',
+    endpoint: { method: 'POST', path: '/api/v1/user/profiles' },
+  },
+  {
+    id: '2026-10-16-read-only-replicas',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'Read-only API replicas answer mutating requests with 503 (READ_ONLY_MODE) and a Retry-After header.',
+  },
+  {
+    id: '2026-10-16-admin-queues',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'Admin queue statistics, recent failed jobs and requeueing of failed jobs.',
+    endpoint: { method: 'GET', path: '/api/v1/admin/queues' },
+  },
+  {
+    id: '2026-10-16-provider-invoice-reconciliation',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'Admin import of provider invoices and a monthly report comparing them to recorded and charged usage.',
+    endpoint: { method: 'GET', path: '/api/v1/admin/provider-usage/reconciliation' },
+  },
+  {
+    id: '2026-10-16-notification-channels',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Email and Slack notification channels per event type, sharing the webhook retry queue and delivery history.',
+    endpoint: { method: 'POST', path: '/api/v1/webhook/channels' },
+  },
+  {
+    id: '2026-10-16-webhook-mtls',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'Pin a custom CA and present a client certificate (mutual TLS) per webhook config.',
+    endpoint: { method: 'PUT', path: '/api/v1/webhook/config/:id/tls' },
+  },
+  {
+    id: '2026-10-16-translation-lint-rules',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Lint rules (forbidden terms, required terms, length limits, banned characters) checked after translation; ' +
+      'results carry a lint report and blocking violations hold the result webhook until resolved.',
+    endpoint: { method: 'POST', path: '/api/v1/translation/lint-rules' },
+    schema: 'response:GET /api/v1/translation/task/:id/result',
+  },
+  {
+    id: '2026-10-16-document-lock',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Lock a document during manual edits; other edits, deletes and failed-key retries get 423 ' +
+      'and re-translations wait for the lock.',
+    endpoint: { method: 'POST', path: '/api/v1/translation/:id/lock' },
+  },
+  {
+    id: '2026-10-16-webhook-auth-headers',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'Static headers or basic auth per webhook config, stored encrypted and sent with every delivery.',
+    endpoint: { method: 'PUT', path: '/api/v1/webhook/config/:id/auth' },
+  },
+  {
+    id: '2026-10-16-webhook-history-filters',
+    date: '2026-10-16',
+    type: ApiChangeType.CHANGED,
+    breaking: false,
+    summary:
+      'Webhook delivery history accepts status and webhook_id filters, caps limit at 100, ' +
+      'orders ties by id and echoes page and limit; invalid time filters return 400.',
+    endpoint: { method: 'GET', path: '/api/v1/webhook/history' },
+  },
+  {
+    id: '2026-10-16-suppress-webhook',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'suppressWebhook on task creation skips result and failure webhooks for that task (bulk backfills).',
+    endpoint: { method: 'POST', path: '/api/v1/translation/task' },
+  },
+  {
+    id: '2026-10-16-result-webhook-envelope',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary:
+      'Result webhooks can use a versioned translation.completed envelope with document metadata and result links; ' +
+      'new webhook configs use it by default.',
+    endpoint: { method: 'PUT', path: '/api/v1/webhook/config/:id/payload-format' },
+    schema: 'webhook:translation.completed',
+  },
+  {
+    id: '2026-10-16-legacy-result-webhook',
+    date: '2026-10-16',
+    type: ApiChangeType.DEPRECATED,
+    breaking: true,
+    summary: 'The { code, msg, data } result webhook payload is kept for existing webhook configs only.',
+    schema: 'webhook:legacy-result',
+    deprecation: { deprecatedAt: '2026-10-16', replacement: 'webhook:translation.completed' },
+  },
+  {
+    id: '2026-10-16-changelog-feed',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'Machine-readable changelog; deprecated endpoints answer with Deprecation, Sunset and Link headers.',
+    endpoint: { method: 'GET', path: '/api/v1/meta/changelog' },
+  },
+  {
+    id: '2026-10-16-legacy-translation-by-id',
+    date: '2026-10-16',
+    type: ApiChangeType.DEPRECATED,
+    breaking: true,
+    summary: 'The unauthenticated single-text translation lookup is deprecated; use the task result endpoint.',
+    endpoint: { method: 'GET', path: '/api/v1/translation/:id' },
+    deprecation: {
+      deprecatedAt: '2026-10-16',
+      sunsetAt: '2027-04-16',
+      replacement: 'GET /api/v1/translation/task/:id/result',
+    },
+  },
+  {
+    id: '2026-10-16-legacy-translations-by-user',
+    date: '2026-10-16',
+    type: ApiChangeType.DEPRECATED,
+    breaking: true,
+    summary: 'Listing translations by user id is deprecated; use the authenticated document list.',
+    endpoint: { method: 'GET', path: '/api/v1/translation/user/:userId' },
+    deprecation: {
+      deprecatedAt: '2026-10-16',
+      sunsetAt: '2027-04-16',
+      replacement: 'GET /api/v1/translation/documents',
+    },
+  },
+  {
+    id: '2026-10-16-task-status-partial',
+    date: '2026-10-16',
+    type: ApiChangeType.CHANGED,
+    breaking: false,
+    summary:
+      'Finished tasks now report status "completed" or "partial" instead of staying "pending"; ' +
+      'results include failedKeys with the reason each untranslated key failed.',
+    schema: 'response:GET /api/v1/translation/task/:id/result',
+  },
+  {
+    id: '2026-10-16-retry-failed-keys',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'Re-translate only the failed keys of a partial translation.',
+    endpoint: { method: 'POST', path: '/api/v1/translation/task/:id/retry_failed' },
+  },
+  {
+    id: '2026-10-16-error-codes',
+    date: '2026-10-16',
+    type: ApiChangeType.CHANGED,
+    breaking: false,
+    summary:
+      'Error responses carry a stable code and a message localized via Accept-Language (en, zh, ja); ' +
+      'map errors by code rather than by message.',
+    schema: 'response:errors',
+  },
+  {
+    id: '2026-10-16-egress-ips',
+    date: '2026-10-16',
+    type: ApiChangeType.ADDED,
+    breaking: false,
+    summary: 'Static outbound IP addresses for firewall allowlists.',
+    endpoint: { method: 'GET', path: '/api/v1/meta/egress_ips' },
+  },
+  {
+    id: '2026-10-16-request-signature-scope',
+    date: '2026-10-16',
+    type: ApiChangeType.CHANGED,
+    breaking: true,
+    summary:
+      'Signed requests sign "<METHOD>\\n<path>?<query>\\n<timestamp>\\n<raw body>" instead of ' +
+      '"<timestamp>.<raw body>", so a signature only authenticates the endpoint it was computed for.',
+    schema: 'request:signature',
+  },
+];
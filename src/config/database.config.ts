@@ -0,0 +1,86 @@
+import { Options } from '@mikro-orm/core';
+import { PostgreSqlDriver } from '@mikro-orm/postgresql';
+import { MySqlDriver } from '@mikro-orm/mysql';
+import { Migrator } from '@mikro-orm/migrations';
+import { buildTlsOptions } from './tls.config';
+
+export enum StorageDriver {
+  POSTGRESQL = 'postgresql',
+  MYSQL = 'mysql',
+}
+
+/**
+ * 存储驱动能力
+ * 部分功能（表分区、原生 SQL 报表）只在 PostgreSQL 下可用
+ */
+export interface StorageDriverCapabilities {
+  driver: StorageDriver;
+  partitioning: boolean;
+  nativeJson: boolean;
+}
+
+const DRIVER_CAPABILITIES: Record<StorageDriver, StorageDriverCapabilities> = {
+  [StorageDriver.POSTGRESQL]: {
+    driver: StorageDriver.POSTGRESQL,
+    partitioning: true,
+    nativeJson: true,
+  },
+  [StorageDriver.MYSQL]: {
+    driver: StorageDriver.MYSQL,
+    partitioning: false,
+    nativeJson: true,
+  },
+};
+
+const DEFAULT_PORTS: Record<StorageDriver, number> = {
+  [StorageDriver.POSTGRESQL]: 5432,
+  [StorageDriver.MYSQL]: 3306,
+};
+
+export function resolveStorageDriver(value?: string): StorageDriver {
+  const normalized = (value || StorageDriver.POSTGRESQL).toLowerCase();
+  if (normalized === 'postgres' || normalized === StorageDriver.POSTGRESQL) {
+    return StorageDriver.POSTGRESQL;
+  }
+  if (normalized === 'mariadb' || normalized === StorageDriver.MYSQL) {
+    return StorageDriver.MYSQL;
+  }
+  throw new Error(`Unsupported DB_DRIVER "${value}", expected one of: ${Object.values(StorageDriver).join(', ')}`);
+}
+
+export function getStorageCapabilities(driver: StorageDriver): StorageDriverCapabilities {
+  return DRIVER_CAPABILITIES[driver];
+}
+
+/**
+ * 根据环境变量构建 MikroORM 连接配置
+ * DB_DRIVER 选择 postgresql（默认）或 mysql，自托管用户无需额外服务即可运行
+ */
+export function buildDatabaseOptions(env: NodeJS.ProcessEnv = process.env): Options {
+  const driver = resolveStorageDriver(env.DB_DRIVER);
+  // pg 和 mysql2 都通过 knex 的 connection.ssl 接收 TLS 选项
+  const tls = buildTlsOptions('DB', env);
+
+  return {
+    entities: ['./dist/**/*.entity.js'],
+    entitiesTs: ['./src/**/*.entity.ts'],
+    driver: driver === StorageDriver.MYSQL ? MySqlDriver : PostgreSqlDriver,
+    dbName: env.DB_NAME,
+    host: env.DB_HOST,
+    port: parseInt(env.DB_PORT, 10) || DEFAULT_PORTS[driver],
+    user: env.DB_USERNAME,
+    password: env.DB_PASSWORD,
+    debug: env.NODE_ENV === 'development',
+    ...(tls ? { driverOptions: { connection: { ssl: tls } } } : {}),
+    extensions: [Migrator],
+    migrations: {
+      tableName: 'mikro_orm_migrations',
+      path: './dist/migrations',
+      pathTs: './src/migrations',
+      glob: '!(*.d).{js,ts}',
+      transactional: true,
+      allOrNothing: true,
+      snapshot: false,
+    },
+  } as Options;
+}
@@ -0,0 +1,82 @@
+import { DownloadFormat } from '../modules/translation/utils/translation-download';
+import { OutputKeyFormat } from '../modules/translation/utils/output-keys';
+import { PlaceholderStyle } from '../modules/translation/utils/placeholders';
+
+export interface FileFormatOption {
+  /** 请求中的参数名 */
+  name: string;
+  /** input：创建任务时传入；output：读取或下载结果时传入 */
+  scope: 'input' | 'output';
+  type: 'enum' | 'enum[]' | 'boolean';
+  values?: string[];
+  default?: string | boolean;
+  description: string;
+}
+
+export interface FileFormat {
+  id: string;
+  name: string;
+  extensions: string[];
+  mediaType: string;
+  /** 可以作为源文档提交 */
+  input: boolean;
+  /** 可以作为译文输出 */
+  output: boolean;
+  options: FileFormatOption[];
+}
+
+/**
+ * 文件格式登记表
+ * GET /meta/formats 原样公开，客户端据此渲染格式选择；新增格式适配器时在这里登记，
+ * input / output 都为 false 的条目表示已规划但尚不支持
+ */
+export const FILE_FORMATS: FileFormat[] = [
+  {
+    id: 'json',
+    name: 'JSON',
+    extensions: ['.json'],
+    mediaType: 'application/json',
+    input: true,
+    output: true,
+    options: [
+      {
+        name: 'placeholderStyles',
+        scope: 'input',
+        type: 'enum[]',
+        values: Object.values(PlaceholderStyle),
+        description: 'Placeholder syntaxes kept verbatim during translation',
+      },
+      {
+        name: 'preserveFormatting',
+        scope: 'input',
+        type: 'boolean',
+        default: false,
+        description: 'Repair whitespace, line endings and placeholder case in translations',
+      },
+      {
+        name: 'outputKeyFormat',
+        scope: 'input',
+        type: 'enum',
+        values: Object.values(OutputKeyFormat),
+        default: OutputKeyFormat.NESTED,
+        description: 'Key layout of the translated document',
+      },
+      {
+        name: 'format',
+        scope: 'output',
+        type: 'enum',
+        values: Object.values(DownloadFormat),
+        default: DownloadFormat.PRETTY,
+        description: 'Layout of GET /translation/task/:id/download',
+      },
+    ],
+  },
+  ...[
+    { id: 'yaml', name: 'YAML', extensions: ['.yaml', '.yml'], mediaType: 'application/yaml' },
+    { id: 'xliff', name: 'XLIFF', extensions: ['.xlf', '.xliff'], mediaType: 'application/xliff+xml' },
+    { id: 'po', name: 'Gettext PO', extensions: ['.po', '.pot'], mediaType: 'text/x-gettext-translation' },
+    { id: 'arb', name: 'Flutter ARB', extensions: ['.arb'], mediaType: 'application/json' },
+    { id: 'strings', name: 'Apple .strings', extensions: ['.strings'], mediaType: 'text/plain' },
+    { id: 'csv', name: 'CSV', extensions: ['.csv'], mediaType: 'text/csv' },
+  ].map((format) => ({ ...format, input: false, output: false, options: [] })),
+];
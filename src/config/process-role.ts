@@ -0,0 +1,50 @@
+/**
+ * 进程角色
+ * api 只提供 HTTP 接口，worker 只消费队列，all 在同一进程内同时运行两者（共享 Redis / 数据库连接），
+ * 适合不想维护两个容器的小型自托管部署
+ */
+export enum ProcessRole {
+  API = 'api',
+  WORKER = 'worker',
+  ALL = 'all',
+}
+
+export function resolveProcessRole(raw: string | undefined): ProcessRole {
+  const value = (raw ?? '').trim().toLowerCase();
+  return Object.values(ProcessRole).includes(value as ProcessRole) ? (value as ProcessRole) : ProcessRole.ALL;
+}
+
+export function runsWorkers(role: ProcessRole): boolean {
+  return role !== ProcessRole.API;
+}
+
+export function servesHttp(role: ProcessRole): boolean {
+  return role !== ProcessRole.WORKER;
+}
+
+/**
+ * worker 消费的队列
+ * 结果推送单独使用 webhook 队列和并发数；客户接收端响应慢造成的积压只占用推送并发，不影响翻译任务。
+ * 需要进一步隔离时用 WORKER_QUEUES 把两类队列分给不同的 worker 进程，各自扩容
+ */
+export enum WorkerQueue {
+  TRANSLATION = 'translation',
+  WEBHOOK = 'webhook',
+}
+
+/**
+ * 解析 WORKER_QUEUES（逗号分隔），为空时消费全部队列；无法识别的名称忽略
+ */
+export function resolveWorkerQueues(raw: string | undefined): WorkerQueue[] {
+  const names = (raw ?? '').split(',').map((name) => name.trim().toLowerCase());
+  const queues = Object.values(WorkerQueue).filter((queue) => names.includes(queue));
+  return queues.length > 0 ? queues : Object.values(WorkerQueue);
+}
+
+/**
+ * 只读维护模式（READ_ONLY_MODE=true，只能用于 api 角色）
+ * 事故期间额外部署的 API 副本只响应查询类接口，不写库、不入队，分担控制台的读流量
+ */
+export function isReadOnlyMode(env: NodeJS.ProcessEnv = process.env): boolean {
+  return (env.READ_ONLY_MODE ?? '').trim().toLowerCase() === 'true';
+}
@@ -0,0 +1,10 @@
+/**
+ * 可选的机器翻译服务商
+ */
+export enum TranslationProvider {
+  ALIYUN = 'aliyun',
+  /** 用户自行配置的翻译接口（PUT /user/mt_engine） */
+  CUSTOM = 'custom',
+}
+
+export const DEFAULT_TRANSLATION_PROVIDER = TranslationProvider.ALIYUN;
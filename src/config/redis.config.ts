@@ -0,0 +1,23 @@
+import { RedisOptions } from 'ioredis';
+import { buildTlsOptions } from './tls.config';
+
+/**
+ * Redis 连接配置，Bull 队列和各服务自建的客户端共用
+ */
+export function buildRedisOptions(env: NodeJS.ProcessEnv = process.env): RedisOptions {
+  const options: RedisOptions = {
+    host: env.REDIS_HOST || 'localhost',
+    port: parseInt(env.REDIS_PORT, 10) || 6379,
+  };
+  if (env.REDIS_USERNAME) {
+    options.username = env.REDIS_USERNAME;
+  }
+  if (env.REDIS_PASSWORD) {
+    options.password = env.REDIS_PASSWORD;
+  }
+  const tls = buildTlsOptions('REDIS', env);
+  if (tls) {
+    options.tls = tls;
+  }
+  return options;
+}
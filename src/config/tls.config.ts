@@ -0,0 +1,63 @@
+import { readFileSync } from 'fs';
+
+/**
+ * 后端服务的 TLS 配置
+ * 每个服务用独立的环境变量前缀（REDIS_、DB_、PROVIDER_）：
+ *   <PREFIX>_TLS=true                       启用 TLS（配置了 CA / 客户端证书时自动启用）
+ *   <PREFIX>_TLS_CA_FILE=/path/ca.pem       自定义 CA 证书链
+ *   <PREFIX>_TLS_CERT_FILE / _KEY_FILE      客户端证书和私钥（双向 TLS）
+ *   <PREFIX>_TLS_KEY_PASSPHRASE             私钥口令
+ *   <PREFIX>_TLS_SERVERNAME                 SNI / 证书校验使用的主机名
+ *   <PREFIX>_TLS_REJECT_UNAUTHORIZED=false  关闭证书校验（仅用于测试环境）
+ */
+export type TlsServicePrefix = 'REDIS' | 'DB' | 'PROVIDER';
+
+export interface ServiceTlsOptions {
+  ca?: string;
+  cert?: string;
+  key?: string;
+  passphrase?: string;
+  servername?: string;
+  rejectUnauthorized: boolean;
+}
+
+function readPem(path: string | undefined, variable: string): string | undefined {
+  if (!path) {
+    return undefined;
+  }
+  try {
+    return readFileSync(path, 'utf8');
+  } catch (error) {
+    throw new Error(`Cannot read ${variable} (${path}): ${error.message}`);
+  }
+}
+
+/**
+ * 读取指定服务的 TLS 配置，未启用时返回 undefined
+ */
+export function buildTlsOptions(
+  prefix: TlsServicePrefix,
+  env: NodeJS.ProcessEnv = process.env,
+): ServiceTlsOptions | undefined {
+  const variable = (suffix: string) => `${prefix}_TLS${suffix}`;
+  const ca = readPem(env[variable('_CA_FILE')], variable('_CA_FILE'));
+  const cert = readPem(env[variable('_CERT_FILE')], variable('_CERT_FILE'));
+  const key = readPem(env[variable('_KEY_FILE')], variable('_KEY_FILE'));
+
+  if (env[variable('')] !== 'true' && !ca && !cert) {
+    return undefined;
+  }
+  if (!!cert !== !!key) {
+    throw new Error(`${variable('_CERT_FILE')} and ${variable('_KEY_FILE')} must be set together`);
+  }
+
+  const options: ServiceTlsOptions = {
+    rejectUnauthorized: env[variable('_REJECT_UNAUTHORIZED')] !== 'false',
+  };
+  if (ca) options.ca = ca;
+  if (cert) options.cert = cert;
+  if (key) options.key = key;
+  if (env[variable('_KEY_PASSPHRASE')]) options.passphrase = env[variable('_KEY_PASSPHRASE')];
+  if (env[variable('_SERVERNAME')]) options.servername = env[variable('_SERVERNAME')];
+  return options;
+}
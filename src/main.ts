@@ -1,30 +1,5 @@
-import { NestFactory } from '@nestjs/core';
-import { AppModule } from './app.module';
-import { CustomLogger } from './common/utils/logger.service';
-import { ValidationPipe } from '@nestjs/common';
-import { DocumentBuilder, SwaggerModule } from '@nestjs/swagger';
+import { bootstrap } from './bootstrap';
+import { resolveProcessRole } from './config/process-role';
 
-async function bootstrap() {
-  const app = await NestFactory.create(AppModule, {
-    logger: new CustomLogger(),
-  });
-
-  // 全局验证管道
-  app.useGlobalPipes(new ValidationPipe());
-
-  // Swagger 配置
-  const config = new DocumentBuilder()
-    .setTitle('JSON Translation API')
-    .setDescription('API for JSON translation with Stripe payment integration')
-    .setVersion('1.0')
-    .addBearerAuth()
-    .build();
-  const document = SwaggerModule.createDocument(app, config);
-  SwaggerModule.setup('api', app, document);
-
-  // 全局前缀
-  app.setGlobalPrefix('api/v1');
-
-  await app.listen(3000);
-}
-bootstrap(); 
\ No newline at end of file
+// 进程角色由 PROCESS_ROLE 决定（api | worker | all，默认 all），也可通过 jt 命令指定
+bootstrap(resolveProcessRole(process.env.PROCESS_ROLE));
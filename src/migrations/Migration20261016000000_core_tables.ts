@@ -0,0 +1,135 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 初始表结构：翻译主流程、webhook、用量统计和 API Key
+ * 使用 knex schema builder 生成 SQL，PostgreSQL 与 MySQL 通用
+ */
+export class Migration20261016000000_core_tables extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('translation_task', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().index();
+          table.text('content').notNullable();
+          table.string('status', 32).notNullable();
+          table.boolean('is_translated').notNullable().defaultTo(false);
+          table.integer('char_total').notNullable().defaultTo(0);
+          table.string('project', 100).nullable();
+          table.string('api_key_id', 36).nullable().index();
+          table.string('tenant_id', 36).nullable().index();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('user_json_data', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().index();
+          table.text('origin_json').notNullable();
+          table.string('from_lang', 16).notNullable();
+          table.string('to_lang', 16).notNullable();
+          table.text('translated_json').nullable();
+          table.text('ignored_fields').nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('webhook_config', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().index();
+          table.text('webhook_url').notNullable();
+          table.string('tenant_id', 36).nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('send_retry', (table) => {
+          table.string('id', 36).primary();
+          table.string('webhook_id', 36).notNullable();
+          table.string('task_id', 36).notNullable();
+          table.integer('attempt').notNullable();
+          table.string('status', 20).notNullable();
+          table.text('payload').notNullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.index(['webhook_id', 'created_at']);
+        })
+        .toQuery(),
+    );
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('character_usage_log', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable();
+          table.string('json_id', 36).notNullable();
+          table.integer('total_characters').notNullable();
+          table.string('api_key_id', 36).nullable().index();
+          table.string('tenant_id', 36).nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.index(['user_id', 'created_at']);
+        })
+        .toQuery(),
+    );
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('character_usage_log_daily', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable();
+          table.integer('total_characters').notNullable().defaultTo(0);
+          table.string('usage_date', 10).notNullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+          table.unique(['user_id', 'usage_date']);
+        })
+        .toQuery(),
+    );
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('api_key', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().index();
+          table.string('name', 255).notNullable();
+          table.string('key', 255).notNullable().unique();
+          table.timestamp('expires_at').nullable();
+          table.boolean('is_active').notNullable().defaultTo(true);
+          table.boolean('delegated').notNullable().defaultTo(false);
+          table.string('project', 100).nullable();
+          table.timestamp('last_used_at').nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    for (const table of [
+      'api_key',
+      'character_usage_log_daily',
+      'character_usage_log',
+      'send_retry',
+      'webhook_config',
+      'user_json_data',
+      'translation_task',
+    ]) {
+      this.addSql(knex.schema.dropTableIfExists(table).toQuery());
+    }
+  }
+}
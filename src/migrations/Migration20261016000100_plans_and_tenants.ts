@@ -0,0 +1,70 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 计划覆盖、租户表，以及计划 metadata 和用户角色字段
+ */
+export class Migration20261016000100_plans_and_tenants extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('user_plan_override', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().unique();
+          table.integer('monthly_character_limit').nullable();
+          table.json('features').nullable();
+          table.string('reason', 255).nullable();
+          table.string('set_by', 36).nullable();
+          table.timestamp('expires_at').nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('tenant', (table) => {
+          table.string('id', 36).primary();
+          table.string('owner_id', 36).notNullable().index();
+          table.string('slug', 63).notNullable().unique();
+          table.string('name', 255).notNullable();
+          table.boolean('is_active').notNullable().defaultTo(true);
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+
+    // 这两张表可能由旧的初始化脚本创建，列已存在时跳过
+    if (
+      (await knex.schema.hasTable('subscription_plan')) &&
+      !(await knex.schema.hasColumn('subscription_plan', 'metadata'))
+    ) {
+      this.addSql(
+        knex.schema.alterTable('subscription_plan', (table) => table.json('metadata').nullable()).toQuery(),
+      );
+    }
+    if ((await knex.schema.hasTable('user')) && !(await knex.schema.hasColumn('user', 'role'))) {
+      this.addSql(
+        knex.schema
+          .alterTable('user', (table) => table.string('role', 16).notNullable().defaultTo('user'))
+          .toQuery(),
+      );
+    }
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('tenant').toQuery());
+    this.addSql(knex.schema.dropTableIfExists('user_plan_override').toQuery());
+
+    if (await knex.schema.hasColumn('subscription_plan', 'metadata')) {
+      this.addSql(knex.schema.alterTable('subscription_plan', (table) => table.dropColumn('metadata')).toQuery());
+    }
+    if (await knex.schema.hasColumn('user', 'role')) {
+      this.addSql(knex.schema.alterTable('user', (table) => table.dropColumn('role')).toQuery());
+    }
+  }
+}
@@ -0,0 +1,28 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 租户品牌信息：品牌名称、Logo 和邮件回复地址
+ */
+export class Migration20261016000200_tenant_branding extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('tenant', (table) => {
+          table.string('brand_name', 255).nullable();
+          table.string('logo_url', 2048).nullable();
+          table.string('reply_to_email', 255).nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('tenant', (table) => table.dropColumns('brand_name', 'logo_url', 'reply_to_email'))
+        .toQuery(),
+    );
+  }
+}
@@ -0,0 +1,48 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 法律保留与合规导出记录
+ */
+export class Migration20261016000300_legal_hold extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('legal_hold', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().index();
+          table.text('reason').notNullable();
+          table.string('case_reference', 255).nullable();
+          table.string('placed_by', 36).notNullable();
+          table.timestamp('released_at').nullable();
+          table.string('released_by', 36).nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('compliance_export', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().index();
+          table.string('legal_hold_id', 36).nullable().index();
+          table.string('requested_by', 36).notNullable();
+          table.string('file_name', 255).notNullable();
+          table.string('sha256', 64).notNullable();
+          table.integer('size_bytes').notNullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('compliance_export').toQuery());
+    this.addSql(knex.schema.dropTableIfExists('legal_hold').toQuery());
+  }
+}
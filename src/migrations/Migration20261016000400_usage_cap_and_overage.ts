@@ -0,0 +1,44 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 用户自设字符上限与超额用量
+ */
+export class Migration20261016000400_usage_cap_and_overage extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('user_usage_cap', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().unique();
+          table.bigInteger('monthly_character_cap').notNullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('overage_usage', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable();
+          table.string('period', 7).notNullable();
+          table.bigInteger('characters').notNullable().defaultTo(0);
+          table.integer('reported_units').notNullable().defaultTo(0);
+          table.timestamp('last_reported_at').nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+          table.unique(['user_id', 'period']);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('overage_usage').toQuery());
+    this.addSql(knex.schema.dropTableIfExists('user_usage_cap').toQuery());
+  }
+}
@@ -0,0 +1,36 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * API Key 级默认值（忽略字段、服务商、项目），以及任务使用的服务商
+ */
+export class Migration20261016000500_api_key_defaults extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('api_key', (table) => {
+          table.text('default_ignored_fields').nullable();
+          table.string('default_provider', 50).nullable();
+          table.string('default_project', 100).nullable();
+        })
+        .toQuery(),
+    );
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.string('provider', 50).nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.alterTable('user_json_data', (table) => table.dropColumn('provider')).toQuery());
+    this.addSql(
+      knex.schema
+        .alterTable('api_key', (table) => table.dropColumns('default_ignored_fields', 'default_provider', 'default_project'))
+        .toQuery(),
+    );
+  }
+}
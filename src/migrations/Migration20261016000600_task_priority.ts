@@ -0,0 +1,22 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 翻译任务的队列优先级
+ */
+export class Migration20261016000600_task_priority extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('translation_task', (table) => {
+          table.string('priority', 16).nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.alterTable('translation_task', (table) => table.dropColumn('priority')).toQuery());
+  }
+}
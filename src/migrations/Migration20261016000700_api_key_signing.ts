@@ -0,0 +1,22 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * API Key 只接受签名请求的开关
+ */
+export class Migration20261016000700_api_key_signing extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('api_key', (table) => {
+          table.boolean('require_signature').notNullable().defaultTo(false);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.alterTable('api_key', (table) => table.dropColumn('require_signature')).toQuery());
+  }
+}
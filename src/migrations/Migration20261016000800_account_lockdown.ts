@@ -0,0 +1,28 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 账号级 API 锁定
+ */
+export class Migration20261016000800_account_lockdown extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user', (table) => {
+          table.timestamp('api_locked_at').nullable();
+          table.text('api_lock_reason').nullable();
+          table.string('api_locked_by').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user', (table) => table.dropColumns('api_locked_at', 'api_lock_reason', 'api_locked_by'))
+        .toQuery(),
+    );
+  }
+}
@@ -0,0 +1,32 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 定时 / 周期翻译任务
+ */
+export class Migration20261016000900_scheduled_tasks extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('translation_task', (table) => {
+          table.timestamp('scheduled_at').nullable();
+          table.string('cron', 100).nullable();
+          table.string('timezone', 64).nullable();
+          table.index(['user_id', 'status']);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('translation_task', (table) => {
+          table.dropIndex(['user_id', 'status']);
+          table.dropColumns('scheduled_at', 'cron', 'timezone');
+        })
+        .toQuery(),
+    );
+  }
+}
@@ -0,0 +1,41 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 远程 JSON 源同步
+ */
+export class Migration20261016001000_source_sync extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('source_sync', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().index();
+          table.string('tenant_id', 36).nullable();
+          table.string('name', 255).nullable();
+          table.text('url').notNullable();
+          table.string('from_lang', 16).notNullable();
+          table.string('target_langs', 255).notNullable();
+          table.text('ignored_fields').nullable();
+          table.string('cron', 100).notNullable();
+          table.string('timezone', 64).nullable();
+          table.boolean('is_active').notNullable().defaultTo(true);
+          table.string('etag', 255).nullable();
+          table.string('last_content_hash', 64).nullable();
+          table.text('last_content').nullable();
+          table.text('last_results').nullable();
+          table.timestamp('last_fetched_at').nullable();
+          table.timestamp('last_changed_at').nullable();
+          table.text('last_error').nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('source_sync').toQuery());
+  }
+}
@@ -0,0 +1,43 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * GitHub 仓库集成
+ */
+export class Migration20261016001100_github_integration extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('github_integration', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().index();
+          table.string('tenant_id', 36).nullable();
+          table.string('repository', 255).notNullable();
+          table.string('branch', 255).notNullable().defaultTo('main');
+          table.string('source_path', 500).notNullable();
+          table.string('target_path_pattern', 500).notNullable();
+          table.string('from_lang', 16).notNullable();
+          table.string('target_langs', 255).notNullable();
+          table.text('ignored_fields').nullable();
+          table.string('status', 20).notNullable().defaultTo('pending');
+          table.text('access_token').nullable();
+          table.string('github_login', 255).nullable();
+          table.string('hook_id', 64).nullable();
+          table.string('webhook_secret', 64).notNullable();
+          table.boolean('is_active').notNullable().defaultTo(true);
+          table.string('last_synced_sha', 40).nullable();
+          table.text('last_pull_request_url').nullable();
+          table.timestamp('last_synced_at').nullable();
+          table.text('last_error').nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('github_integration').toQuery());
+  }
+}
@@ -0,0 +1,31 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 已完成文档归档到对象存储
+ */
+export class Migration20261016001200_document_archive extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.timestamp('archived_at').nullable();
+          table.string('archive_key', 255).nullable();
+          table.index(['archived_at', 'created_at']);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropIndex(['archived_at', 'created_at']);
+          table.dropColumns('archived_at', 'archive_key');
+        })
+        .toQuery(),
+    );
+  }
+}
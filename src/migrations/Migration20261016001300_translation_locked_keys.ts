@@ -0,0 +1,28 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 译文键级人工修改与锁定
+ */
+export class Migration20261016001300_translation_locked_keys extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.json('locked_keys').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropColumn('locked_keys');
+        })
+        .toQuery(),
+    );
+  }
+}
@@ -0,0 +1,41 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 译文审校流程（machine_translated → in_review → approved）
+ */
+export class Migration20261016001400_translation_review extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.string('review_status', 32).nullable();
+          table.json('key_review_status').nullable();
+          table.string('reviewed_by', 255).nullable();
+          table.timestamp('reviewed_at').nullable();
+          table.index(['user_id', 'review_status']);
+        })
+        .toQuery(),
+    );
+    // 已完成（含已归档）的文档视为机器翻译待审
+    this.addSql(
+      knex('user_json_data')
+        .update({ review_status: 'machine_translated' })
+        .whereNotNull('translated_json')
+        .orWhereNotNull('archived_at')
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropIndex(['user_id', 'review_status']);
+          table.dropColumns('review_status', 'key_review_status', 'reviewed_by', 'reviewed_at');
+        })
+        .toQuery(),
+    );
+  }
+}
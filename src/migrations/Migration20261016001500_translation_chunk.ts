@@ -0,0 +1,35 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 超大文档分片子任务
+ */
+export class Migration20261016001500_translation_chunk extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('translation_chunk', (table) => {
+          table.string('id', 36).primary();
+          table.string('task_id', 36).notNullable().index();
+          table.string('run_id', 36).notNullable();
+          table.integer('index').notNullable();
+          table.json('paths').notNullable();
+          table.integer('char_total').notNullable().defaultTo(0);
+          table.string('status', 16).notNullable().defaultTo('pending');
+          table.text('translated_json').nullable();
+          table.text('error').nullable();
+          table.timestamp('started_at').nullable();
+          table.timestamp('completed_at').nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+          table.index(['run_id', 'status']);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('translation_chunk').toQuery());
+  }
+}
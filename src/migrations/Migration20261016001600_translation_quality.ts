@@ -0,0 +1,29 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 译文质量估计分数
+ */
+export class Migration20261016001600_translation_quality extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.float('quality_score').nullable();
+          table.json('quality_scores').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropColumns('quality_score', 'quality_scores');
+        })
+        .toQuery(),
+    );
+  }
+}
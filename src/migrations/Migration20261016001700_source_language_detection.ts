@@ -0,0 +1,28 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 源语言自动检测
+ */
+export class Migration20261016001700_source_language_detection extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.float('detection_confidence').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropColumn('detection_confidence');
+        })
+        .toQuery(),
+    );
+  }
+}
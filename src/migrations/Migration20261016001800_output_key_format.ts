@@ -0,0 +1,28 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 译文输出的键形态
+ */
+export class Migration20261016001800_output_key_format extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.string('output_key_format').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropColumn('output_key_format');
+        })
+        .toQuery(),
+    );
+  }
+}
@@ -0,0 +1,35 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 占位符语法配置（请求级和 API Key 默认值）及占位符校验结果
+ */
+export class Migration20261016001900_placeholder_styles extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.json('placeholder_styles').nullable();
+          table.json('placeholder_issues').nullable();
+        })
+        .toQuery(),
+    );
+    this.addSql(
+      knex.schema
+        .alterTable('api_key', (table) => {
+          table.string('default_placeholder_styles').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.alterTable('api_key', (table) => table.dropColumn('default_placeholder_styles')).toQuery());
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => table.dropColumns('placeholder_styles', 'placeholder_issues'))
+        .toQuery(),
+    );
+  }
+}
@@ -0,0 +1,28 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 分片翻译的执行次数
+ */
+export class Migration20261016002100_translation_chunk_attempts extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('translation_chunk', (table) => {
+          table.integer('attempts').notNullable().defaultTo(0);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('translation_chunk', (table) => {
+          table.dropColumn('attempts');
+        })
+        .toQuery(),
+    );
+  }
+}
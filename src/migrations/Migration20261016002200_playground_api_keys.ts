@@ -0,0 +1,28 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 文档站试运行密钥：绑定 IP、限定字符数
+ */
+export class Migration20261016002200_playground_api_keys extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('api_key', (table) => {
+          table.boolean('playground').notNullable().defaultTo(false);
+          table.string('bound_ip').nullable();
+          table.integer('max_characters').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('api_key', (table) => table.dropColumns('playground', 'bound_ip', 'max_characters'))
+        .toQuery(),
+    );
+  }
+}
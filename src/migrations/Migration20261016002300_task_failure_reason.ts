@@ -0,0 +1,28 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 翻译任务重试用尽后的失败原因
+ */
+export class Migration20261016002300_task_failure_reason extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('translation_task', (table) => {
+          table.string('failure_reason').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('translation_task', (table) => {
+          table.dropColumn('failure_reason');
+        })
+        .toQuery(),
+    );
+  }
+}
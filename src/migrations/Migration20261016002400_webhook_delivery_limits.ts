@@ -0,0 +1,27 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * webhook 推送的并发和速率上限
+ */
+export class Migration20261016002400_webhook_delivery_limits extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('webhook_config', (table) => {
+          table.integer('max_concurrency').nullable();
+          table.integer('max_per_minute').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('webhook_config', (table) => table.dropColumns('max_concurrency', 'max_per_minute'))
+        .toQuery(),
+    );
+  }
+}
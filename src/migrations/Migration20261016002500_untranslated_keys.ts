@@ -0,0 +1,30 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 翻译服务重试用尽、保留原文的键
+ */
+export class Migration20261016002500_untranslated_keys extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.json('untranslated_keys').nullable();
+        })
+        .toQuery(),
+    );
+    this.addSql(
+      knex.schema
+        .alterTable('translation_chunk', (table) => {
+          table.json('untranslated_keys').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.alterTable('user_json_data', (table) => table.dropColumn('untranslated_keys')).toQuery());
+    this.addSql(knex.schema.alterTable('translation_chunk', (table) => table.dropColumn('untranslated_keys')).toQuery());
+  }
+}
@@ -0,0 +1,30 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 未翻译的键的失败原因
+ */
+export class Migration20261016002600_failed_keys extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.json('failed_keys').nullable();
+        })
+        .toQuery(),
+    );
+    this.addSql(
+      knex.schema
+        .alterTable('translation_chunk', (table) => {
+          table.json('failed_keys').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.alterTable('user_json_data', (table) => table.dropColumn('failed_keys')).toQuery());
+    this.addSql(knex.schema.alterTable('translation_chunk', (table) => table.dropColumn('failed_keys')).toQuery());
+  }
+}
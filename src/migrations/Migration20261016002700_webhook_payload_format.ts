@@ -0,0 +1,22 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 翻译结果推送的载荷格式，已有配置保持旧格式
+ */
+export class Migration20261016002700_webhook_payload_format extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('webhook_config', (table) => {
+          table.string('payload_format', 16).notNullable().defaultTo('legacy');
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.alterTable('webhook_config', (table) => table.dropColumn('payload_format')).toQuery());
+  }
+}
@@ -0,0 +1,22 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 按任务关闭结果推送（批量回填历史文档）
+ */
+export class Migration20261016002800_suppress_webhook extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('translation_task', (table) => {
+          table.boolean('suppress_webhook').notNullable().defaultTo(false);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.alterTable('translation_task', (table) => table.dropColumn('suppress_webhook')).toQuery());
+  }
+}
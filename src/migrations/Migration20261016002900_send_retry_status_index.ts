@@ -0,0 +1,26 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 发送历史按投递结果过滤时使用的索引
+ */
+export class Migration20261016002900_send_retry_status_index extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('send_retry', (table) => {
+          table.index(['webhook_id', 'status', 'created_at']);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('send_retry', (table) => table.dropIndex(['webhook_id', 'status', 'created_at']))
+        .toQuery(),
+    );
+  }
+}
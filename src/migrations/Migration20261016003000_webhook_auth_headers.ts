@@ -0,0 +1,30 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * webhook 推送时附带的认证请求头（加密保存）
+ */
+export class Migration20261016003000_webhook_auth_headers extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('webhook_config', (table) => {
+          table.text('auth_headers').nullable();
+          table.json('auth_header_names').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('webhook_config', (table) => {
+          table.dropColumn('auth_headers');
+          table.dropColumn('auth_header_names');
+        })
+        .toQuery(),
+    );
+  }
+}
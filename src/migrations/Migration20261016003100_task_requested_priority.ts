@@ -0,0 +1,24 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 记录调用方指定的优先级，计划升降级后重新排列队列时使用
+ */
+export class Migration20261016003100_task_requested_priority extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('translation_task', (table) => {
+          table.string('requested_priority', 16).nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema.alterTable('translation_task', (table) => table.dropColumn('requested_priority')).toQuery(),
+    );
+  }
+}
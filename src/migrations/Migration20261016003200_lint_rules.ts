@@ -0,0 +1,41 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 译文检查规则和文档的检查报告
+ */
+export class Migration20261016003200_lint_rules extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('lint_rule', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().index();
+          table.string('name', 255).nullable();
+          table.string('type', 32).notNullable();
+          table.json('languages').nullable();
+          table.string('key_pattern', 255).nullable();
+          table.json('terms').nullable();
+          table.boolean('case_sensitive').notNullable().defaultTo(false);
+          table.integer('max_length').nullable();
+          table.string('characters', 255).nullable();
+          table.boolean('blocking').notNullable().defaultTo(false);
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.json('lint_report').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.alterTable('user_json_data', (table) => table.dropColumn('lint_report')).toQuery());
+    this.addSql(knex.schema.dropTableIfExists('lint_rule').toQuery());
+  }
+}
@@ -0,0 +1,34 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * webhook 推送的自定义 CA 和客户端证书（私钥加密保存）
+ */
+export class Migration20261016003300_webhook_tls extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('webhook_config', (table) => {
+          table.text('tls_ca').nullable();
+          table.text('tls_client_cert').nullable();
+          table.text('tls_client_key').nullable();
+          table.json('tls_info').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('webhook_config', (table) => {
+          table.dropColumn('tls_ca');
+          table.dropColumn('tls_client_cert');
+          table.dropColumn('tls_client_key');
+          table.dropColumn('tls_info');
+        })
+        .toQuery(),
+    );
+  }
+}
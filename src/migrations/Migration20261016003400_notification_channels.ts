@@ -0,0 +1,32 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 邮件和 Slack 通知渠道
+ */
+export class Migration20261016003400_notification_channels extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('notification_channel', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().index();
+          table.string('tenant_id', 36).nullable();
+          table.string('type', 16).notNullable();
+          table.string('name', 255).nullable();
+          table.json('events').notNullable();
+          table.json('recipients').nullable();
+          table.text('slack_webhook_url').nullable();
+          table.boolean('is_active').notNullable().defaultTo(true);
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('notification_channel').toQuery());
+  }
+}
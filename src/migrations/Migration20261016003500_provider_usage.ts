@@ -0,0 +1,46 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 翻译服务计费字符的月度统计和服务商账单
+ */
+export class Migration20261016003500_provider_usage extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('provider_usage_monthly', (table) => {
+          table.string('id', 36).primary();
+          table.string('provider', 32).notNullable();
+          table.string('period', 7).notNullable().index();
+          table.string('user_id', 36).nullable();
+          table.bigInteger('characters').notNullable().defaultTo(0);
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+          table.unique(['provider', 'period', 'user_id']);
+        })
+        .toQuery(),
+    );
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('provider_invoice', (table) => {
+          table.string('id', 36).primary();
+          table.string('provider', 32).notNullable();
+          table.string('period', 7).notNullable();
+          table.bigInteger('characters').notNullable();
+          table.decimal('amount', 12, 2).nullable();
+          table.string('currency', 3).nullable();
+          table.string('reference', 255).nullable();
+          table.string('imported_by', 36).notNullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+          table.unique(['provider', 'period']);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('provider_invoice').toQuery());
+    this.addSql(knex.schema.dropTableIfExists('provider_usage_monthly').toQuery());
+  }
+}
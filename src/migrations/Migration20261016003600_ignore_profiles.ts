@@ -0,0 +1,40 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 忽略配置和文档上的规则快照
+ */
+export class Migration20261016003600_ignore_profiles extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('ignore_profile', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable();
+          table.string('name', 100).notNullable();
+          table.json('fields').nullable();
+          table.json('paths').nullable();
+          table.json('patterns').nullable();
+          table.json('include').nullable();
+          table.json('skip').nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+          table.unique(['user_id', 'name']);
+        })
+        .toQuery(),
+    );
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.json('ignore_rules').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.alterTable('user_json_data', (table) => table.dropColumn('ignore_rules')).toQuery());
+    this.addSql(knex.schema.dropTableIfExists('ignore_profile').toQuery());
+  }
+}
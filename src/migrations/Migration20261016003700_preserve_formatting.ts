@@ -0,0 +1,24 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 文档上的格式保留选项
+ */
+export class Migration20261016003700_preserve_formatting extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.boolean('preserve_formatting').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema.alterTable('user_json_data', (table) => table.dropColumn('preserve_formatting')).toQuery(),
+    );
+  }
+}
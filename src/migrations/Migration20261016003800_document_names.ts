@@ -0,0 +1,32 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 文档名称和标签
+ */
+export class Migration20261016003800_document_names extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.string('name', 200).nullable();
+          table.json('tags').nullable();
+          table.index(['user_id', 'name']);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropIndex(['user_id', 'name']);
+          table.dropColumn('name');
+          table.dropColumn('tags');
+        })
+        .toQuery(),
+    );
+  }
+}
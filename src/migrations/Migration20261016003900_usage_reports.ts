@@ -0,0 +1,30 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 定期用量报告订阅
+ */
+export class Migration20261016003900_usage_reports extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('usage_report_subscription', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().unique();
+          table.string('tenant_id', 36).nullable();
+          table.string('frequency', 16).notNullable();
+          table.json('channels').notNullable();
+          table.string('last_period', 16).nullable();
+          table.timestamp('last_sent_at').nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('usage_report_subscription').toQuery());
+  }
+}
@@ -0,0 +1,45 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 文档列表排序和游标分页：文档上冗余保存计费字符数，按 (排序字段, id) 建索引
+ */
+export class Migration20261016004000_document_sorting extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.integer('char_total').notNullable().defaultTo(0);
+          table.index(['user_id', 'created_at', 'id']);
+          table.index(['user_id', 'updated_at', 'id']);
+          table.index(['user_id', 'char_total', 'id']);
+        })
+        .toQuery(),
+    );
+    // 已有文档从同 ID 的任务上补齐
+    this.addSql(
+      knex('user_json_data')
+        .update({
+          char_total: knex('translation_task')
+            .select('char_total')
+            .where('translation_task.id', knex.ref('user_json_data.id')),
+        })
+        .whereExists(knex('translation_task').where('translation_task.id', knex.ref('user_json_data.id')))
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropIndex(['user_id', 'created_at', 'id']);
+          table.dropIndex(['user_id', 'updated_at', 'id']);
+          table.dropIndex(['user_id', 'char_total', 'id']);
+          table.dropColumn('char_total');
+        })
+        .toQuery(),
+    );
+  }
+}
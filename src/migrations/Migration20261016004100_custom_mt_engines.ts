@@ -0,0 +1,30 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 自定义机器翻译引擎
+ */
+export class Migration20261016004100_custom_mt_engines extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('custom_mt_engine', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().unique();
+          table.string('url', 2048).notNullable();
+          table.string('auth_header_name', 255).nullable();
+          table.text('auth_header_value').nullable();
+          table.text('request_template').notNullable();
+          table.string('response_path', 255).notNullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('custom_mt_engine').toQuery());
+  }
+}
@@ -0,0 +1,44 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 结果推送附带与上一次推送的差异
+ */
+export class Migration20261016004200_webhook_result_diff extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('webhook_config', (table) => {
+          table.boolean('include_diff').notNullable().defaultTo(false);
+        })
+        .toQuery(),
+    );
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.text('delivered_json').nullable();
+          table.timestamp('delivered_at').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropColumn('delivered_json');
+          table.dropColumn('delivered_at');
+        })
+        .toQuery(),
+    );
+    this.addSql(
+      knex.schema
+        .alterTable('webhook_config', (table) => {
+          table.dropColumn('include_diff');
+        })
+        .toQuery(),
+    );
+  }
+}
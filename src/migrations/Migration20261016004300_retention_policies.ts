@@ -0,0 +1,42 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 文档内容保留期：用户的保留设置和文档的清除时间
+ */
+export class Migration20261016004300_retention_policies extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('retention_policy', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().unique();
+          table.integer('retention_days').notNullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.timestamp('purged_at').nullable();
+          table.index(['purged_at', 'updated_at']);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropIndex(['purged_at', 'updated_at']);
+          table.dropColumn('purged_at');
+        })
+        .toQuery(),
+    );
+    this.addSql(knex.schema.dropTableIfExists('retention_policy').toQuery());
+  }
+}
@@ -0,0 +1,30 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 计费字符数按键的分布
+ */
+export class Migration20261016004400_key_usage extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.json('key_usage').nullable();
+          table.integer('key_usage_depth').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropColumn('key_usage');
+          table.dropColumn('key_usage_depth');
+        })
+        .toQuery(),
+    );
+  }
+}
@@ -0,0 +1,46 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 项目设置（目标语言集合）和按目标语言展开的项目任务
+ */
+export class Migration20261016004500_project_settings extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('project_settings', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable();
+          table.string('project', 255).notNullable();
+          table.json('target_langs').notNullable();
+          table.string('source_lang', 16).nullable();
+          table.string('provider', 50).nullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+          table.unique(['user_id', 'project']);
+        })
+        .toQuery(),
+    );
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('project_job', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable();
+          table.string('project', 255).notNullable();
+          table.string('from_lang', 16).notNullable();
+          table.json('tasks').notNullable();
+          table.json('rejected').nullable();
+          table.integer('char_total').notNullable().defaultTo(0);
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.index(['user_id', 'project', 'created_at']);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('project_job').toQuery());
+    this.addSql(knex.schema.dropTableIfExists('project_settings').toQuery());
+  }
+}
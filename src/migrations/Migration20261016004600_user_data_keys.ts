@@ -0,0 +1,26 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 文档内容加密的用户数据密钥；加密后的内容仍保存在原来的文本列中
+ */
+export class Migration20261016004600_user_data_keys extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('user_data_key', (table) => {
+          table.string('id', 36).primary();
+          table.string('user_id', 36).notNullable().unique();
+          table.string('master_key_id', 64).notNullable();
+          table.text('wrapped_key').notNullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('user_data_key').toQuery());
+  }
+}
@@ -0,0 +1,32 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 个人信息屏蔽开关、追加规则和屏蔽报告
+ */
+export class Migration20261016004700_pii_masking extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.boolean('mask_pii').nullable();
+          table.json('pii_patterns').nullable();
+          table.json('pii_report').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropColumn('mask_pii');
+          table.dropColumn('pii_patterns');
+          table.dropColumn('pii_report');
+        })
+        .toQuery(),
+    );
+  }
+}
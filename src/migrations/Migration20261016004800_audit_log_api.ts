@@ -0,0 +1,32 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 审计日志记录操作的 API 密钥和接口，并按用户、时间建索引
+ */
+export class Migration20261016004800_audit_log_api extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('audit_log', (table) => {
+          table.string('api_key_id', 255).nullable();
+          table.string('endpoint', 255).nullable();
+          table.index(['user_id', 'created_at'], 'audit_log_user_id_created_at_index');
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('audit_log', (table) => {
+          table.dropIndex(['user_id', 'created_at'], 'audit_log_user_id_created_at_index');
+          table.dropColumn('api_key_id');
+          table.dropColumn('endpoint');
+        })
+        .toQuery(),
+    );
+  }
+}
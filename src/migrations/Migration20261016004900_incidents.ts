@@ -0,0 +1,31 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 运维声明的故障时间窗，用于在任务状态和文档列表中附带故障说明
+ */
+export class Migration20261016004900_incidents extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .createTableIfNotExists('incident', (table) => {
+          table.string('id', 36).primary();
+          table.string('reference', 50).notNullable();
+          table.string('message', 500).notNullable();
+          table.string('provider', 255).nullable();
+          table.timestamp('starts_at').notNullable();
+          table.timestamp('ends_at').nullable();
+          table.string('created_by', 255).notNullable();
+          table.timestamp('created_at').notNullable().defaultTo(knex.fn.now());
+          table.timestamp('updated_at').notNullable().defaultTo(knex.fn.now());
+          table.index(['starts_at']);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(knex.schema.dropTableIfExists('incident').toQuery());
+  }
+}
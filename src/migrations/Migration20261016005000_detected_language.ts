@@ -0,0 +1,32 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 批量语言检测对已保存文档的检测结果
+ */
+export class Migration20261016005000_detected_language extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.string('detected_lang', 255).nullable();
+          table.float('detected_lang_confidence').nullable();
+          table.timestamp('language_checked_at').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropColumn('detected_lang');
+          table.dropColumn('detected_lang_confidence');
+          table.dropColumn('language_checked_at');
+        })
+        .toQuery(),
+    );
+  }
+}
@@ -0,0 +1,28 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * API Key 到期提醒记录
+ */
+export class Migration20261016005100_api_key_expiry_reminders extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('api_key', (table) => {
+          table.integer('expiry_reminded_days').nullable();
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('api_key', (table) => {
+          table.dropColumn('expiry_reminded_days');
+        })
+        .toQuery(),
+    );
+  }
+}
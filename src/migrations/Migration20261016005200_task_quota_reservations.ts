@@ -0,0 +1,32 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 任务准入时预留的额度，用于重建当月用量计数器
+ */
+export class Migration20261016005200_task_quota_reservations extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('translation_task', (table) => {
+          table.integer('quota_reserved').nullable();
+          table.timestamp('quota_reserved_at').nullable();
+          table.index(['user_id', 'quota_reserved_at']);
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('translation_task', (table) => {
+          table.dropIndex(['user_id', 'quota_reserved_at']);
+          table.dropColumn('quota_reserved');
+          table.dropColumn('quota_reserved_at');
+        })
+        .toQuery(),
+    );
+  }
+}
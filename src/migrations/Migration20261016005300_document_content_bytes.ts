@@ -0,0 +1,36 @@
+import { Migration } from '@mikro-orm/migrations';
+
+/**
+ * 文档上保存原文和译文的明文字节数，存储上限按它累计，不再量加密后的列长度
+ */
+export class Migration20261016005300_document_content_bytes extends Migration {
+  async up(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.integer('content_bytes').notNullable().defaultTo(0);
+        })
+        .toQuery(),
+    );
+    // 已有文档按当前列长度补齐；已加密的文档按密文长度计，下次写入时改为明文字节数
+    this.addSql(
+      knex('user_json_data')
+        .update({
+          content_bytes: knex.raw('OCTET_LENGTH(origin_json) + COALESCE(OCTET_LENGTH(translated_json), 0)'),
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropColumn('content_bytes');
+        })
+        .toQuery(),
+    );
+  }
+}
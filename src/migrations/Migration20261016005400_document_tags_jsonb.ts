@@ -0,0 +1,38 @@
+import { Migration } from '@mikro-orm/migrations';
+import { resolveStorageDriver, StorageDriver } from '../config/database.config';
+
+/**
+ * PostgreSQL 下文档标签改为 jsonb 并建 GIN 索引，标签过滤用 jsonb_exists_any；
+ * MySQL 的 json 列可以直接用 JSON_CONTAINS，不需要变更
+ */
+export class Migration20261016005400_document_tags_jsonb extends Migration {
+  async up(): Promise<void> {
+    if (resolveStorageDriver(process.env.DB_DRIVER) !== StorageDriver.POSTGRESQL) {
+      return;
+    }
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.jsonb('tags').nullable().alter();
+          table.index(['tags'], 'user_json_data_tags_index', 'gin');
+        })
+        .toQuery(),
+    );
+  }
+
+  async down(): Promise<void> {
+    if (resolveStorageDriver(process.env.DB_DRIVER) !== StorageDriver.POSTGRESQL) {
+      return;
+    }
+    const knex = this.getKnex();
+    this.addSql(
+      knex.schema
+        .alterTable('user_json_data', (table) => {
+          table.dropIndex(['tags'], 'user_json_data_tags_index');
+          table.json('tags').nullable().alter();
+        })
+        .toQuery(),
+    );
+  }
+}
@@ -0,0 +1,44 @@
+import { knex } from 'knex';
+import { Migration20261016005400_document_tags_jsonb } from '../Migration20261016005400_document_tags_jsonb';
+
+describe('Migration20261016005400_document_tags_jsonb', () => {
+  const originalDriver = process.env.DB_DRIVER;
+
+  // 迁移只用 knex 生成 SQL，不连接数据库
+  const create = () =>
+    new Migration20261016005400_document_tags_jsonb(
+      { getConnection: () => ({ getKnex: () => knex({ client: 'pg' }) }) } as any,
+      {} as any,
+    );
+
+  afterEach(() => {
+    if (originalDriver === undefined) {
+      delete process.env.DB_DRIVER;
+    } else {
+      process.env.DB_DRIVER = originalDriver;
+    }
+  });
+
+  it('PostgreSQL 下把标签列改为 jsonb 并建 GIN 索引', async () => {
+    process.env.DB_DRIVER = 'postgresql';
+    const migration = create();
+
+    await migration.up();
+
+    const sql = migration.getQueries().join('\n');
+    expect(sql).toContain('alter column "tags" type jsonb using ("tags"::jsonb)');
+    expect(sql).toContain('create index "user_json_data_tags_index" on "user_json_data" using gin ("tags")');
+  });
+
+  it('MySQL 下不执行任何语句，json 列保持不变', async () => {
+    process.env.DB_DRIVER = 'mysql';
+    const up = create();
+    const down = create();
+
+    await up.up();
+    await down.down();
+
+    expect(up.getQueries()).toEqual([]);
+    expect(down.getQueries()).toEqual([]);
+  });
+});
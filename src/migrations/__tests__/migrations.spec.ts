@@ -0,0 +1,81 @@
+import { readdirSync } from 'fs';
+import { join } from 'path';
+import { knex } from 'knex';
+import { Migrator } from '@mikro-orm/migrations';
+import { MySqlDriver } from '@mikro-orm/mysql';
+import { PostgreSqlDriver } from '@mikro-orm/postgresql';
+import { buildDatabaseOptions } from '../../config/database.config';
+
+describe('migrations', () => {
+  const dir = join(__dirname, '..');
+  const files = readdirSync(dir).filter((file) => /^Migration\d{14}_\w+\.ts$/.test(file));
+  const originalDriver = process.env.DB_DRIVER;
+
+  // 迁移只用 knex 生成 SQL，不连接数据库
+  const run = async (file: string, client: 'pg' | 'mysql2', direction: 'up' | 'down') => {
+    const name = file.replace(/\.ts$/, '');
+    const MigrationClass = require(join(dir, file))[name];
+    const migration = new MigrationClass({ getConnection: () => ({ getKnex: () => knex({ client }) }) }, {});
+    await migration[direction]();
+    return migration.getQueries() as string[];
+  };
+
+  afterEach(() => {
+    if (originalDriver === undefined) {
+      delete process.env.DB_DRIVER;
+    } else {
+      process.env.DB_DRIVER = originalDriver;
+    }
+  });
+
+  it('文件名带唯一的时间戳，导出与文件名同名的迁移类', () => {
+    expect(files.length).toBeGreaterThan(0);
+    const timestamps = files.map((file) => file.slice('Migration'.length, 'Migration'.length + 14));
+    expect(new Set(timestamps).size).toBe(files.length);
+
+    for (const file of files) {
+      const name = file.replace(/\.ts$/, '');
+      expect(require(join(dir, file))).toHaveProperty(name);
+    }
+  });
+
+  it.each([
+    ['postgresql', 'pg'],
+    ['mysql', 'mysql2'],
+  ] as const)('%s 下每个迁移都能生成升级和回滚语句', async (driver, client) => {
+    process.env.DB_DRIVER = driver;
+
+    for (const file of files) {
+      await expect(run(file, client, 'up')).resolves.toEqual(expect.any(Array));
+      await expect(run(file, client, 'down')).resolves.toEqual(expect.any(Array));
+    }
+  });
+
+  it('初始迁移创建核心表，回滚时删除', async () => {
+    const [file] = files;
+
+    const up = (await run(file, 'pg', 'up')).join('\n');
+    const down = (await run(file, 'pg', 'down')).join('\n');
+
+    for (const table of ['translation_task', 'user_json_data', 'webhook_config', 'api_key']) {
+      expect(up).toContain(`create table if not exists "${table}"`);
+      expect(down).toContain(`drop table if exists "${table}"`);
+    }
+  });
+
+  it('连接配置加载迁移扩展，只匹配迁移源文件，整批在事务中执行', () => {
+    const options = buildDatabaseOptions({ DB_DRIVER: 'mysql' } as NodeJS.ProcessEnv);
+
+    expect(options.driver).toBe(MySqlDriver);
+    expect(options.port).toBe(3306);
+    expect(options.extensions).toContain(Migrator);
+    expect(options.migrations).toMatchObject({
+      tableName: 'mikro_orm_migrations',
+      pathTs: './src/migrations',
+      glob: '!(*.d).{js,ts}',
+      transactional: true,
+      allOrNothing: true,
+    });
+    expect(buildDatabaseOptions({} as NodeJS.ProcessEnv).driver).toBe(PostgreSqlDriver);
+  });
+});
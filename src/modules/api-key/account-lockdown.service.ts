@@ -0,0 +1,128 @@
+import { ConflictException, ForbiddenException, Injectable, Logger, NotFoundException } from '@nestjs/common';
+import { EntityManager } from '@mikro-orm/core';
+import { User } from '../user/entities/user.entity';
+import { ApiKey } from './entities/api-key.entity';
+import { AccountLockdownDto } from './dto/account-lockdown.dto';
+import { AuditLogService } from '../audit/services/audit-log.service';
+import { AuditAction, AuditSeverity, ResourceType } from '../audit/entities/audit-log.entity';
+
+export interface AccountLockdownStatus {
+  locked: boolean;
+  lockedAt?: Date;
+  reason?: string;
+  lockedBy?: string;
+}
+
+/**
+ * 账号级 API 锁定
+ * 疑似密钥泄露时由用户或管理员立即停用账号的全部 API 活动：拒绝新任务和密钥认证，
+ * worker 暂停该账号的翻译与 webhook 推送，轮换密钥后解锁恢复
+ */
+@Injectable()
+export class AccountLockdownService {
+  private readonly logger = new Logger(AccountLockdownService.name);
+
+  constructor(
+    private readonly em: EntityManager,
+    private readonly auditLogService: AuditLogService,
+  ) {}
+
+  async getStatus(userId: string): Promise<AccountLockdownStatus> {
+    const user = await this.em.findOne(User, { id: userId });
+    if (!user) {
+      throw new NotFoundException('User not found');
+    }
+    return toStatus(user);
+  }
+
+  async isLocked(userId: string): Promise<boolean> {
+    const user = await this.em.findOne(User, { id: userId }, { fields: ['id', 'apiLockedAt'] });
+    return !!user?.apiLockedAt;
+  }
+
+  async assertUnlocked(userId: string): Promise<void> {
+    if (await this.isLocked(userId)) {
+      throw new ForbiddenException('API access for this account is locked');
+    }
+  }
+
+  /**
+   * 锁定账号，可选同时撤销所有有效密钥
+   */
+  async lock(userId: string, dto: AccountLockdownDto, actorId: string): Promise<AccountLockdownStatus> {
+    const user = await this.em.findOne(User, { id: userId });
+    if (!user) {
+      throw new NotFoundException('User not found');
+    }
+    if (user.apiLockedAt) {
+      throw new ConflictException('Account is already locked');
+    }
+
+    user.apiLockedAt = new Date();
+    user.apiLockReason = dto.reason;
+    user.apiLockedBy = actorId;
+    await this.em.flush();
+
+    const revokedKeys = dto.revokeApiKeys
+      ? await this.em.nativeUpdate(ApiKey, { userId, isActive: true }, { isActive: false })
+      : 0;
+
+    await this.auditLogService.log({
+      userId: actorId,
+      action: AuditAction.ACCOUNT_LOCKDOWN,
+      resourceType: ResourceType.USER,
+      resourceId: userId,
+      newValues: { reason: dto.reason, revokedKeys },
+      severity: AuditSeverity.CRITICAL,
+      isHighRisk: true,
+      description: `API access locked for user ${userId}`,
+      tags: ['lockdown'],
+    });
+    this.logger.warn(`API access locked for user ${userId} by ${actorId} (${revokedKeys} key(s) revoked)`);
+    return toStatus(user);
+  }
+
+  /**
+   * 解锁账号；管理员施加的锁定只能由管理员解除
+   */
+  async unlock(userId: string, actorId: string, asAdmin = false): Promise<AccountLockdownStatus> {
+    const user = await this.em.findOne(User, { id: userId });
+    if (!user) {
+      throw new NotFoundException('User not found');
+    }
+    if (!user.apiLockedAt) {
+      throw new ConflictException('Account is not locked');
+    }
+    if (!asAdmin && user.apiLockedBy !== userId) {
+      throw new ForbiddenException('This lockdown was placed by an administrator and can only be lifted by one');
+    }
+
+    const previous = toStatus(user);
+    user.apiLockedAt = undefined;
+    user.apiLockReason = undefined;
+    user.apiLockedBy = undefined;
+    await this.em.flush();
+
+    await this.auditLogService.log({
+      userId: actorId,
+      action: AuditAction.ACCOUNT_UNLOCK,
+      resourceType: ResourceType.USER,
+      resourceId: userId,
+      oldValues: { lockedAt: previous.lockedAt, reason: previous.reason, lockedBy: previous.lockedBy },
+      severity: AuditSeverity.HIGH,
+      description: `API access restored for user ${userId}`,
+      tags: ['lockdown'],
+    });
+    this.logger.log(`API access restored for user ${userId} by ${actorId}`);
+    return toStatus(user);
+  }
+}
+
+function toStatus(user: User): AccountLockdownStatus {
+  return {
+    locked: !!user.apiLockedAt,
+    lockedAt: user.apiLockedAt ?? undefined,
+    reason: user.apiLockReason ?? undefined,
+    lockedBy: user.apiLockedBy ?? undefined,
+  };
+}
@@ -0,0 +1,100 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager } from '@mikro-orm/core';
+import { ApiKeyExpiryService, API_KEY_EXPIRING_EVENT } from './api-key-expiry.service';
+import { WebhookService } from '../webhook/webhook.service';
+import { TenantMailService } from '../tenant/services/tenant-mail.service';
+
+describe('ApiKeyExpiryService', () => {
+  let service: ApiKeyExpiryService;
+
+  const day = 24 * 60 * 60 * 1000;
+  const now = new Date('2026-10-16T12:00:00Z');
+
+  const mockEntityManager = {
+    find: jest.fn(),
+    findOne: jest.fn().mockResolvedValue({ email: 'owner@example.com' }),
+    flush: jest.fn(),
+  };
+
+  const mockWebhookService = {
+    dispatchEvent: jest.fn().mockResolvedValue(undefined),
+  };
+
+  const mockTenantMailService = {
+    send: jest.fn().mockResolvedValue(true),
+  };
+
+  const apiKey = (daysLeft: number, extra: Record<string, any> = {}) => ({
+    id: 'key-1',
+    userId: 'user1',
+    name: 'ci',
+    delegated: false,
+    createdAt: new Date(now.getTime() - 80 * day),
+    expiresAt: new Date(now.getTime() + daysLeft * day),
+    ...extra,
+  });
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        ApiKeyExpiryService,
+        { provide: ConfigService, useValue: { get: jest.fn((key, defaultValue) => defaultValue) } },
+        { provide: EntityManager, useValue: mockEntityManager },
+        { provide: WebhookService, useValue: mockWebhookService },
+        { provide: TenantMailService, useValue: mockTenantMailService },
+      ],
+    }).compile();
+
+    service = module.get<ApiKeyExpiryService>(ApiKeyExpiryService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('剩余天数进入阈值时发送 webhook 和邮件，并记录已提醒的阈值', async () => {
+    const key: any = apiKey(6.5);
+    mockEntityManager.find.mockResolvedValueOnce([key]);
+
+    expect(await service.remindExpiring(now)).toBe(1);
+
+    expect(mockEntityManager.find).toHaveBeenCalledWith(expect.anything(), {
+      isActive: true,
+      playground: false,
+      expiresAt: { $gt: now, $lte: new Date(now.getTime() + 7 * day) },
+    });
+    expect(mockWebhookService.dispatchEvent).toHaveBeenCalledWith('user1', undefined, API_KEY_EXPIRING_EVENT, {
+      apiKeyId: 'key-1',
+      name: 'ci',
+      expiresAt: key.expiresAt.toISOString(),
+      daysLeft: 7,
+      delegated: false,
+      project: null,
+    });
+    expect(mockTenantMailService.send).toHaveBeenCalledWith(
+      undefined,
+      expect.objectContaining({ to: 'owner@example.com', subject: 'API key ci expires in 7 day(s)' }),
+    );
+    expect(key.expiryRemindedDays).toBe(7);
+    expect(mockEntityManager.flush).toHaveBeenCalled();
+  });
+
+  it('同一阈值只提醒一次，进入更小的阈值时再次提醒', async () => {
+    mockEntityManager.find.mockResolvedValueOnce([apiKey(3, { expiryRemindedDays: 7 })]);
+    expect(await service.remindExpiring(now)).toBe(0);
+
+    const key: any = apiKey(0.5, { expiryRemindedDays: 7 });
+    mockEntityManager.find.mockResolvedValueOnce([key]);
+    expect(await service.remindExpiring(now)).toBe(1);
+    expect(key.expiryRemindedDays).toBe(1);
+  });
+
+  it('有效期本身不超过阈值的密钥不提醒', async () => {
+    mockEntityManager.find.mockResolvedValueOnce([apiKey(0.5, { createdAt: new Date(now.getTime() - 0.25 * day) })]);
+
+    expect(await service.remindExpiring(now)).toBe(0);
+    expect(mockWebhookService.dispatchEvent).not.toHaveBeenCalled();
+    expect(mockEntityManager.flush).not.toHaveBeenCalled();
+  });
+});
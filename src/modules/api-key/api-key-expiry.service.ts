@@ -0,0 +1,114 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager } from '@mikro-orm/core';
+import { ApiKey } from './entities/api-key.entity';
+import { User } from '../user/entities/user.entity';
+import { WebhookService } from '../webhook/webhook.service';
+import { TenantMailService } from '../tenant/services/tenant-mail.service';
+import { formatNotification, toEmailBody } from '../webhook/utils/notification-message';
+import { parseThresholds } from '../translation/services/quota-warning.service';
+
+export const API_KEY_EXPIRING_EVENT = 'api_key.expiring';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+/**
+ * API Key 到期提醒
+ * 定时检查即将过期的密钥，剩余天数首次进入配置的阈值（默认 7 天、1 天）时发送 api_key.expiring webhook 事件和邮件；
+ * 每个阈值只提醒一次，修改过期时间后重新计算。有效期本身不超过阈值的密钥（如短期委托密钥）不提醒
+ */
+@Injectable()
+export class ApiKeyExpiryService {
+  private readonly logger = new Logger(ApiKeyExpiryService.name);
+  private readonly thresholds: number[];
+  private readonly emailEnabled: boolean;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly em: EntityManager,
+    private readonly webhookService: WebhookService,
+    private readonly tenantMailService: TenantMailService,
+  ) {
+    this.thresholds = parseThresholds(this.configService.get('API_KEY_EXPIRY_REMINDER_DAYS', '7,1'));
+    this.emailEnabled = this.configService.get('API_KEY_EXPIRY_REMINDER_EMAIL', 'true') === 'true';
+  }
+
+  /**
+   * 发送到期的提醒，返回提醒的密钥数
+   */
+  async remindExpiring(now = new Date()): Promise<number> {
+    if (this.thresholds.length === 0) {
+      return 0;
+    }
+    const horizon = new Date(now.getTime() + this.thresholds[this.thresholds.length - 1] * DAY_MS);
+    const apiKeys = await this.em.find(ApiKey, {
+      isActive: true,
+      playground: false,
+      expiresAt: { $gt: now, $lte: horizon },
+    });
+
+    let reminded = 0;
+    for (const apiKey of apiKeys) {
+      const threshold = this.dueThreshold(apiKey, now);
+      if (threshold === null) {
+        continue;
+      }
+      await this.remind(apiKey, now);
+      apiKey.expiryRemindedDays = threshold;
+      reminded++;
+    }
+    if (reminded > 0) {
+      await this.em.flush();
+      this.logger.log(`Sent expiry reminders for ${reminded} API key(s)`);
+    }
+    return reminded;
+  }
+
+  /**
+   * 剩余天数所在的最小阈值；已提醒过该阈值（或更小的阈值），或密钥创建时就在该阈值内时返回 null
+   */
+  private dueThreshold(apiKey: ApiKey, now: Date): number | null {
+    const msLeft = apiKey.expiresAt!.getTime() - now.getTime();
+    const threshold = this.thresholds.find((days) => msLeft <= days * DAY_MS);
+    if (threshold === undefined) {
+      return null;
+    }
+    if (apiKey.expiryRemindedDays != null && apiKey.expiryRemindedDays <= threshold) {
+      return null;
+    }
+    if (apiKey.expiresAt!.getTime() - apiKey.createdAt.getTime() <= threshold * DAY_MS) {
+      return null;
+    }
+    return threshold;
+  }
+
+  private async remind(apiKey: ApiKey, now: Date): Promise<void> {
+    const data = {
+      apiKeyId: apiKey.id,
+      name: apiKey.name,
+      expiresAt: apiKey.expiresAt!.toISOString(),
+      daysLeft: Math.max(Math.ceil((apiKey.expiresAt!.getTime() - now.getTime()) / DAY_MS), 1),
+      delegated: apiKey.delegated,
+      project: apiKey.project ?? null,
+    };
+    await Promise.all([
+      this.webhookService
+        .dispatchEvent(apiKey.userId, undefined, API_KEY_EXPIRING_EVENT, data)
+        .catch((error) => this.logger.error(`Failed to deliver API key expiry webhook: ${error.message}`)),
+      this.emailEnabled ? this.sendEmail(apiKey.userId, data) : Promise.resolve(),
+    ]);
+  }
+
+  private async sendEmail(userId: string, data: Record<string, any>): Promise<void> {
+    try {
+      const user = await this.em.findOne(User, { id: userId }, { fields: ['email'] });
+      if (!user?.email) {
+        return;
+      }
+      const message = formatNotification(API_KEY_EXPIRING_EVENT, data);
+      await this.tenantMailService.send(undefined, { to: user.email, subject: message.title, ...toEmailBody(message) });
+    } catch (error) {
+      this.logger.error(`Failed to send API key expiry email: ${error.message}`);
+    }
+  }
+}
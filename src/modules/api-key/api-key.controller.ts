@@ -1,8 +1,13 @@
-import { Controller, Get, Post, Delete, UseGuards, Req, Body, Param, ParseUUIDPipe } from '@nestjs/common';
-import { ApiTags, ApiOperation, ApiResponse, ApiBearerAuth } from '@nestjs/swagger';
+import { Controller, Get, Post, Patch, Delete, UseGuards, Req, Body, Param, ParseUUIDPipe, Query } from '@nestjs/common';
+import { ApiTags, ApiOperation, ApiResponse, ApiBearerAuth, ApiQuery } from '@nestjs/swagger';
 import { ApiKeyService } from './api-key.service';
 import { JwtAuthGuard } from '../auth/guards/jwt-auth.guard';
 import { CreateApiKeyDto } from './dto/create-api-key.dto';
+import { CreateDelegatedApiKeyDto } from './dto/create-delegated-api-key.dto';
+import { ApiKeyDefaultsDto } from './dto/api-key-defaults.dto';
+import { UpdateApiKeySigningDto } from './dto/update-api-key-signing.dto';
+import { UpdateApiKeyExpiryDto } from './dto/update-api-key-expiry.dto';
+import { getClientIp } from '../../common/utils/client-ip';
 
 @ApiTags('api-key')
 @Controller('api-key')
@@ -20,6 +25,26 @@ export class ApiKeyController {
     return this.apiKeyService.createApiKey(req.user.id, createApiKeyDto);
   }
 
+  @Post('delegated')
+  @UseGuards(JwtAuthGuard)
+  @ApiOperation({ summary: '生成限定项目、有时效的委托 API Key' })
+  @ApiResponse({ status: 201, description: '成功创建委托 API Key' })
+  @ApiResponse({ status: 400, description: '过期时间不合法' })
+  @ApiResponse({ status: 401, description: '未授权' })
+  async createDelegatedApiKey(@Req() req: any, @Body() dto: CreateDelegatedApiKeyDto) {
+    return this.apiKeyService.createDelegatedApiKey(req.user.id, dto);
+  }
+
+  @Post('playground')
+  @ApiOperation({ summary: '为文档站试运行签发临时 API Key（无需登录，绑定请求 IP，限时限量）' })
+  @ApiResponse({ status: 201, description: '返回密钥、过期时间和可用字符数' })
+  @ApiResponse({ status: 429, description: '该 IP 申请过于频繁' })
+  @ApiResponse({ status: 503, description: '未开启试运行' })
+  async createPlaygroundKey(@Req() req: any) {
+    const apiKey = await this.apiKeyService.createPlaygroundKey(getClientIp(req));
+    return { key: apiKey.key, expiresAt: apiKey.expiresAt, maxCharacters: apiKey.maxCharacters };
+  }
+
   @Get()
   @UseGuards(JwtAuthGuard)
   @ApiOperation({ summary: '获取用户的所有 API Key' })
@@ -29,6 +54,61 @@ export class ApiKeyController {
     return this.apiKeyService.getApiKeys(req.user.id);
   }
 
+  @Get(':id/usage')
+  @UseGuards(JwtAuthGuard)
+  @ApiOperation({ summary: '获取指定 API Key 归属的用量' })
+  @ApiQuery({ name: 'since', required: false, description: '统计起始时间（ISO 8601）' })
+  @ApiResponse({ status: 200, description: '返回该密钥的字符用量' })
+  @ApiResponse({ status: 404, description: 'API Key 不存在' })
+  async getApiKeyUsage(
+    @Req() req: any,
+    @Param('id', ParseUUIDPipe) id: string,
+    @Query('since') since?: string,
+  ) {
+    return this.apiKeyService.getApiKeyUsage(req.user.id, id, since ? new Date(since) : undefined);
+  }
+
+  @Patch(':id/defaults')
+  @UseGuards(JwtAuthGuard)
+  @ApiOperation({ summary: '设置 API Key 的默认忽略字段、服务商和项目' })
+  @ApiResponse({ status: 200, description: '返回更新后的默认值' })
+  @ApiResponse({ status: 400, description: '默认项目与委托密钥绑定的项目不一致' })
+  @ApiResponse({ status: 404, description: 'API Key 不存在' })
+  async updateApiKeyDefaults(
+    @Req() req: any,
+    @Param('id', ParseUUIDPipe) id: string,
+    @Body() dto: ApiKeyDefaultsDto,
+  ) {
+    return this.apiKeyService.updateDefaults(req.user.id, id, dto);
+  }
+
+  @Patch(':id/signing')
+  @UseGuards(JwtAuthGuard)
+  @ApiOperation({ summary: '开启或关闭 API Key 的"只接受签名请求"' })
+  @ApiResponse({ status: 200, description: '返回更新后的 API Key' })
+  @ApiResponse({ status: 404, description: 'API Key 不存在' })
+  async updateApiKeySigning(
+    @Req() req: any,
+    @Param('id', ParseUUIDPipe) id: string,
+    @Body() dto: UpdateApiKeySigningDto,
+  ) {
+    return this.apiKeyService.setSignatureRequired(req.user.id, id, dto.requireSignature);
+  }
+
+  @Patch(':id/expiry')
+  @UseGuards(JwtAuthGuard)
+  @ApiOperation({ summary: '修改 API Key 的过期时间（提前过期或续期）' })
+  @ApiResponse({ status: 200, description: '返回更新后的 API Key，到期提醒重新开始计算' })
+  @ApiResponse({ status: 400, description: '过期时间已过，或委托密钥超出最长有效期' })
+  @ApiResponse({ status: 404, description: 'API Key 不存在或已撤销' })
+  async updateApiKeyExpiry(
+    @Req() req: any,
+    @Param('id', ParseUUIDPipe) id: string,
+    @Body() dto: UpdateApiKeyExpiryDto,
+  ) {
+    return this.apiKeyService.updateExpiry(req.user.id, id, dto.expiresAt);
+  }
+
   @Delete(':id')
   @UseGuards(JwtAuthGuard)
   @ApiOperation({ summary: '撤销指定的 API Key' })
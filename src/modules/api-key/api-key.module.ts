@@ -1,13 +1,19 @@
 import { Module } from '@nestjs/common';
 import { MikroOrmModule } from '@mikro-orm/nestjs';
-import { ApiKey } from '../../entities/api-key.entity';
+import { ApiKey } from './entities/api-key.entity';
 import { ApiKeyController } from './api-key.controller';
 import { ApiKeyService } from './api-key.service';
+import { AccountLockdownService } from './account-lockdown.service';
+import { ApiKeyExpiryService } from './api-key-expiry.service';
+import { CommonModule } from '../../common/common.module';
+import { AuditModule } from '../audit/audit.module';
+import { WebhookModule } from '../webhook/webhook.module';
+import { TenantModule } from '../tenant/tenant.module';
 
 @Module({
-  imports: [MikroOrmModule.forFeature([ApiKey])],
+  imports: [MikroOrmModule.forFeature([ApiKey]), CommonModule, AuditModule, WebhookModule, TenantModule],
   controllers: [ApiKeyController],
-  providers: [ApiKeyService],
-  exports: [ApiKeyService],
+  providers: [ApiKeyService, AccountLockdownService, ApiKeyExpiryService],
+  exports: [ApiKeyService, AccountLockdownService, ApiKeyExpiryService],
 })
 export class ApiKeyModule {} 
\ No newline at end of file
@@ -0,0 +1,241 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager } from '@mikro-orm/core';
+import { UnauthorizedException } from '@nestjs/common';
+import { createHmac } from 'crypto';
+import { ApiKeyService, computeRequestSignature } from './api-key.service';
+import { AccountLockdownService } from './account-lockdown.service';
+import { RedisService } from '../../common/services/redis.service';
+
+describe('ApiKeyService', () => {
+  let service: ApiKeyService;
+
+  const mockEntityManager = {
+    findOne: jest.fn(),
+    find: jest.fn(),
+    create: jest.fn((_entity, data) => ({ ...data })),
+    persistAndFlush: jest.fn(),
+  };
+
+  const mockRedisService = {
+    setIfAbsent: jest.fn(),
+    increment: jest.fn(),
+    client: { decrby: jest.fn().mockResolvedValue(0) },
+  };
+
+  const mockAccountLockdownService = {
+    isLocked: jest.fn(),
+  };
+
+  const config: Record<string, any> = {
+    REQUEST_SIGNATURE_WINDOW_SECONDS: 300,
+    PLAYGROUND_USER_ID: 'playground-user',
+    PLAYGROUND_KEYS_PER_IP_PER_HOUR: 2,
+    API_KEY_DEFAULT_EXPIRY_DAYS: 90,
+  };
+
+  const apiKey = () => ({
+    id: 'key-1',
+    userId: 'user1',
+    key: 'secret-key',
+    isActive: true,
+    delegated: false,
+    requireSignature: false,
+  });
+
+  const now = () => String(Math.floor(Date.now() / 1000));
+  const body = Buffer.from('{"text":"hello"}');
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        ApiKeyService,
+        { provide: EntityManager, useValue: mockEntityManager },
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: AccountLockdownService, useValue: mockAccountLockdownService },
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, def?: any) => config[key] ?? def) } },
+      ],
+    }).compile();
+
+    service = module.get<ApiKeyService>(ApiKeyService);
+    mockRedisService.setIfAbsent.mockResolvedValue(true);
+    mockAccountLockdownService.isLocked.mockResolvedValue(false);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  describe('validateSignedRequest', () => {
+    const request = { method: 'POST', path: '/api/v1/translation/task', body };
+    const sign = (timestamp: string, signed: Partial<typeof request> = {}) =>
+      computeRequestSignature('secret-key', { ...request, ...signed, timestamp });
+
+    it('签名正确时应返回密钥上下文并标记为签名请求', async () => {
+      mockEntityManager.findOne.mockResolvedValue(apiKey());
+      const timestamp = now();
+
+      const context = await service.validateSignedRequest('key-1', timestamp, sign(timestamp), request);
+
+      expect(context.userId).toBe('user1');
+      expect(context.signed).toBe(true);
+      expect(mockRedisService.setIfAbsent).toHaveBeenCalledWith(
+        expect.stringMatching(/^request_signature:key-1:/),
+        timestamp,
+        600,
+      );
+    });
+
+    it('签名内容为方法、路径和查询、时间戳和请求体', () => {
+      const expected = createHmac('sha256', 'secret-key')
+        .update('GET\n/api/v1/translation/task/abc/result?validate=true\n1700000000\n')
+        .digest('hex');
+
+      expect(
+        computeRequestSignature('secret-key', {
+          method: 'get',
+          path: '/api/v1/translation/task/abc/result?validate=true',
+          timestamp: '1700000000',
+        }),
+      ).toBe(expected);
+    });
+
+    it('请求体被篡改时应拒绝', async () => {
+      mockEntityManager.findOne.mockResolvedValue(apiKey());
+      const timestamp = now();
+
+      await expect(
+        service.validateSignedRequest('key-1', timestamp, sign(timestamp), {
+          ...request,
+          body: Buffer.from('{"text":"bye"}'),
+        }),
+      ).rejects.toThrow('Invalid request signature');
+    });
+
+    it.each([
+      ['方法', { method: 'DELETE' }],
+      ['路径', { path: '/api/v1/translation/task/other' }],
+      ['查询', { path: '/api/v1/translation/task?force=true' }],
+    ])('签名不能用于其他%s', async (_part, changed) => {
+      mockEntityManager.findOne.mockResolvedValue(apiKey());
+      const timestamp = now();
+
+      await expect(
+        service.validateSignedRequest('key-1', timestamp, sign(timestamp), { ...request, ...changed }),
+      ).rejects.toThrow('Invalid request signature');
+      expect(mockRedisService.setIfAbsent).not.toHaveBeenCalled();
+    });
+
+    it('超出时间窗口的请求应拒绝', async () => {
+      const timestamp = String(Math.floor(Date.now() / 1000) - 301);
+
+      await expect(service.validateSignedRequest('key-1', timestamp, sign(timestamp), request)).rejects.toThrow(
+        UnauthorizedException,
+      );
+      expect(mockEntityManager.findOne).not.toHaveBeenCalled();
+    });
+
+    it('窗口内重放同一签名应拒绝', async () => {
+      mockEntityManager.findOne.mockResolvedValue(apiKey());
+      mockRedisService.setIfAbsent.mockResolvedValue(false);
+      const timestamp = now();
+
+      await expect(service.validateSignedRequest('key-1', timestamp, sign(timestamp), request)).rejects.toThrow(
+        'Request signature has already been used',
+      );
+    });
+  });
+
+  describe('validateApiKey', () => {
+    it('要求签名的密钥不能直接通过 X-API-Key 使用', async () => {
+      mockEntityManager.findOne.mockResolvedValue({ ...apiKey(), requireSignature: true });
+
+      await expect(service.validateApiKey('secret-key')).rejects.toThrow('This API key only accepts signed requests');
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+    });
+
+    it('账号被锁定时应拒绝密钥认证', async () => {
+      mockEntityManager.findOne.mockResolvedValue(apiKey());
+      mockAccountLockdownService.isLocked.mockResolvedValue(true);
+
+      await expect(service.validateApiKey('secret-key')).rejects.toThrow('API access for this account is locked');
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('playground keys', () => {
+    it('应签发归属演示账号、绑定 IP 的限时限量密钥', async () => {
+      mockRedisService.increment.mockResolvedValueOnce(1);
+
+      const key = await service.createPlaygroundKey('203.0.113.7');
+
+      expect(key).toMatchObject({ userId: 'playground-user', playground: true, boundIp: '203.0.113.7' });
+      expect(key.maxCharacters).toBe(2000);
+      expect(key.expiresAt.getTime()).toBeGreaterThan(Date.now());
+      expect(mockRedisService.increment).toHaveBeenCalledWith('playground_keys:203.0.113.7', 1, 3600);
+    });
+
+    it('同一 IP 申请过多时应拒绝', async () => {
+      mockRedisService.increment.mockResolvedValueOnce(3);
+
+      await expect(service.createPlaygroundKey('203.0.113.7')).rejects.toThrow('Too many playground keys');
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+    });
+
+    it('从其他 IP 使用绑定密钥时应拒绝', async () => {
+      mockEntityManager.findOne.mockResolvedValue({ ...apiKey(), playground: true, boundIp: '203.0.113.7' });
+
+      await expect(service.validateApiKey('secret-key', '198.51.100.1')).rejects.toThrow(
+        'This API key cannot be used from this IP address',
+      );
+      const context = await service.validateApiKey('secret-key', '203.0.113.7');
+      expect(context.playground).toBe(true);
+    });
+
+    it('超出密钥字符上限时应拒绝并退回本次计数', async () => {
+      mockRedisService.increment.mockResolvedValueOnce(2100);
+      const context = { id: 'key-1', userId: 'u', delegated: false, defaults: {}, maxCharacters: 2000 };
+
+      await expect(service.consumeKeyCharacters(context, 300)).rejects.toThrow('limited to 2000 characters');
+      expect(mockRedisService.client.decrby).toHaveBeenCalledWith('api_key_chars:key-1', 300);
+    });
+  });
+
+  describe('expiry', () => {
+    const day = 24 * 60 * 60 * 1000;
+
+    it('未指定过期时间时使用默认有效期，指定过去的时间时拒绝', async () => {
+      const key = await service.createApiKey('user1', { name: 'ci' } as any);
+      expect(key.expiresAt!.getTime()).toBeGreaterThan(Date.now() + 89 * day);
+
+      await expect(
+        service.createApiKey('user1', { name: 'ci', expiresAt: new Date(Date.now() - day) } as any),
+      ).rejects.toThrow('expiresAt must be in the future');
+    });
+
+    it('修改过期时间后清空到期提醒记录，null 表示不过期', async () => {
+      const key: any = { ...apiKey(), expiresAt: new Date(Date.now() + day), expiryRemindedDays: 1 };
+      mockEntityManager.findOne.mockResolvedValueOnce(key);
+      const expiresAt = new Date(Date.now() + 30 * day).toISOString();
+
+      await service.updateExpiry('user1', 'key-1', expiresAt);
+      expect(key.expiresAt.toISOString()).toBe(expiresAt);
+      expect(key.expiryRemindedDays).toBeUndefined();
+
+      mockEntityManager.findOne.mockResolvedValueOnce(key);
+      await service.updateExpiry('user1', 'key-1', null);
+      expect(key.expiresAt).toBeUndefined();
+      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledTimes(2);
+    });
+
+    it('委托密钥不能取消过期时间或超过最长有效期', async () => {
+      mockEntityManager.findOne.mockResolvedValue({ ...apiKey(), delegated: true });
+
+      await expect(service.updateExpiry('user1', 'key-1', null)).rejects.toThrow('must have an expiry');
+      await expect(
+        service.updateExpiry('user1', 'key-1', new Date(Date.now() + 120 * day).toISOString()),
+      ).rejects.toThrow('more than 90 days');
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+    });
+  });
+});
@@ -1,27 +1,267 @@
-import { Injectable, NotFoundException } from '@nestjs/common';
+import {
+  BadRequestException,
+  ForbiddenException,
+  HttpException,
+  HttpStatus,
+  Injectable,
+  Logger,
+  NotFoundException,
+  ServiceUnavailableException,
+  UnauthorizedException,
+} from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
 import { EntityManager } from '@mikro-orm/core';
 import { ApiKey } from './entities/api-key.entity';
 import { CreateApiKeyDto } from './dto/create-api-key.dto';
+import { CreateDelegatedApiKeyDto } from './dto/create-delegated-api-key.dto';
+import { ApiKeyDefaultsDto } from './dto/api-key-defaults.dto';
+import { ApiKeyContext, ApiKeyDefaults } from './interfaces/api-key-context.interface';
+import { CharacterUsageLog } from '../translation/entities/translation-task.entity';
+import { parsePlaceholderStyles } from '../translation/utils/placeholders';
+import { AccountLockdownService } from './account-lockdown.service';
+import { RedisService } from '../../common/services/redis.service';
+import { isReadOnlyMode } from '../../config/process-role';
 import { v4 as uuidv4 } from 'uuid';
+import { createHmac, timingSafeEqual } from 'crypto';
+
+/**
+ * 签名覆盖的请求内容
+ */
+export interface SignedRequestParts {
+  method: string;
+  /** 请求路径和查询字符串，与发送时完全一致，如 /api/v1/translation/task/abc/result?validate=true */
+  path: string;
+  timestamp: string;
+  body?: Buffer | string;
+}
+
+/**
+ * 计算签名请求的 HMAC：HMAC-SHA256(密钥, "<METHOD>\n<路径?查询>\n<timestamp>\n<原始请求体>")，十六进制输出
+ * 方法、路径和查询都在签名范围内，截获的签名不能换到其他接口或资源上使用
+ */
+export function computeRequestSignature(secret: string, request: SignedRequestParts): string {
+  return createHmac('sha256', secret)
+    .update(`${request.method.toUpperCase()}\n${request.path}\n${request.timestamp}\n`)
+    .update(request.body ?? '')
+    .digest('hex');
+}
 
 @Injectable()
 export class ApiKeyService {
-  constructor(private readonly em: EntityManager) {}
+  private readonly logger = new Logger(ApiKeyService.name);
+  private readonly maxDelegatedDays: number;
+  private readonly signatureWindowSeconds: number;
+  private readonly playgroundUserId?: string;
+  private readonly playgroundTtlMinutes: number;
+  private readonly playgroundMaxCharacters: number;
+  private readonly playgroundKeysPerIpPerHour: number;
+  private readonly defaultExpiryDays: number;
+
+  constructor(
+    private readonly em: EntityManager,
+    private readonly configService: ConfigService,
+    private readonly redisService: RedisService,
+    private readonly accountLockdownService: AccountLockdownService,
+  ) {
+    this.maxDelegatedDays = this.configService.get('DELEGATED_KEY_MAX_DAYS', 90);
+    this.signatureWindowSeconds = Number(this.configService.get('REQUEST_SIGNATURE_WINDOW_SECONDS', 300));
+    this.playgroundUserId = this.configService.get('PLAYGROUND_USER_ID') || undefined;
+    this.playgroundTtlMinutes = Number(this.configService.get('PLAYGROUND_KEY_TTL_MINUTES', 30));
+    this.playgroundMaxCharacters = Number(this.configService.get('PLAYGROUND_KEY_MAX_CHARACTERS', 2000));
+    this.playgroundKeysPerIpPerHour = Number(this.configService.get('PLAYGROUND_KEYS_PER_IP_PER_HOUR', 5));
+    this.defaultExpiryDays = Number(this.configService.get('API_KEY_DEFAULT_EXPIRY_DAYS', 0));
+  }
 
+  /**
+   * 未指定过期时间时按 API_KEY_DEFAULT_EXPIRY_DAYS 设置（0 表示不过期）
+   */
   async createApiKey(userId: string, createApiKeyDto: CreateApiKeyDto): Promise<ApiKey> {
+    const expiresAt = createApiKeyDto.expiresAt
+      ? assertFutureExpiry(createApiKeyDto.expiresAt)
+      : this.defaultExpiryDays > 0
+        ? new Date(Date.now() + this.defaultExpiryDays * 24 * 60 * 60 * 1000)
+        : undefined;
     const apiKey = this.em.create(ApiKey, {
       id: uuidv4(),
       userId,
       name: createApiKeyDto.name,
       key: uuidv4(),
-      expiresAt: createApiKeyDto.expiresAt,
+      expiresAt,
       isActive: true,
+      defaultIgnoredFields: createApiKeyDto.defaults?.ignoredFields ?? undefined,
+      defaultProvider: createApiKeyDto.defaults?.provider ?? undefined,
+      defaultProject: createApiKeyDto.defaults?.project ?? undefined,
+      defaultPlaceholderStyles: createApiKeyDto.defaults?.placeholderStyles?.join(',') || undefined,
+      requireSignature: createApiKeyDto.requireSignature ?? false,
     });
 
     await this.em.persistAndFlush(apiKey);
     return apiKey;
   }
 
+  /**
+   * 更新密钥级默认值，null 清除字段，未传的字段保持不变
+   */
+  async updateDefaults(userId: string, id: string, dto: ApiKeyDefaultsDto): Promise<ApiKeyDefaults> {
+    const apiKey = await this.em.findOne(ApiKey, { id, userId });
+    if (!apiKey) {
+      throw new NotFoundException('API Key not found');
+    }
+
+    if (dto.ignoredFields !== undefined) {
+      apiKey.defaultIgnoredFields = dto.ignoredFields ?? undefined;
+    }
+    if (dto.provider !== undefined) {
+      apiKey.defaultProvider = dto.provider ?? undefined;
+    }
+    if (dto.project !== undefined) {
+      if (apiKey.project && dto.project && dto.project !== apiKey.project) {
+        throw new BadRequestException(`API key is restricted to project "${apiKey.project}"`);
+      }
+      apiKey.defaultProject = dto.project ?? undefined;
+    }
+    if (dto.placeholderStyles !== undefined) {
+      apiKey.defaultPlaceholderStyles = dto.placeholderStyles?.join(',') || undefined;
+    }
+
+    await this.em.persistAndFlush(apiKey);
+    return toApiKeyDefaults(apiKey);
+  }
+
+  /**
+   * 修改密钥的过期时间（提前过期或续期），null 表示不过期；委托密钥必须有过期时间且不超过 DELEGATED_KEY_MAX_DAYS。
+   * 修改后重新发送到期提醒
+   */
+  async updateExpiry(userId: string, id: string, expiresAt: string | null): Promise<ApiKey> {
+    const apiKey = await this.em.findOne(ApiKey, { id, userId, isActive: true });
+    if (!apiKey) {
+      throw new NotFoundException('API Key not found');
+    }
+
+    const next = expiresAt === null ? undefined : assertFutureExpiry(expiresAt);
+    if (apiKey.delegated) {
+      if (!next) {
+        throw new BadRequestException('Delegated keys must have an expiry');
+      }
+      if (next.getTime() - Date.now() > this.maxDelegatedDays * 24 * 60 * 60 * 1000) {
+        throw new BadRequestException(`Delegated keys cannot be valid for more than ${this.maxDelegatedDays} days`);
+      }
+    }
+
+    apiKey.expiresAt = next;
+    apiKey.expiryRemindedDays = undefined;
+    await this.em.persistAndFlush(apiKey);
+    this.logger.log(`API key ${apiKey.id} expiry set to ${next?.toISOString() ?? 'never'} by user ${userId}`);
+    return apiKey;
+  }
+
+  /**
+   * 开启或关闭"只接受签名请求"
+   */
+  async setSignatureRequired(userId: string, id: string, requireSignature: boolean): Promise<ApiKey> {
+    const apiKey = await this.em.findOne(ApiKey, { id, userId });
+    if (!apiKey) {
+      throw new NotFoundException('API Key not found');
+    }
+
+    apiKey.requireSignature = requireSignature;
+    await this.em.persistAndFlush(apiKey);
+    this.logger.log(`API key ${apiKey.id} signature requirement set to ${requireSignature} by user ${userId}`);
+    return apiKey;
+  }
+
+  /**
+   * 创建委托密钥
+   * 限定项目且必须在有限期内过期，用量单独归属到该密钥
+   */
+  async createDelegatedApiKey(userId: string, dto: CreateDelegatedApiKeyDto): Promise<ApiKey> {
+    const expiresAt = assertFutureExpiry(dto.expiresAt);
+    if (expiresAt.getTime() - Date.now() > this.maxDelegatedDays * 24 * 60 * 60 * 1000) {
+      throw new BadRequestException(`Delegated keys cannot be valid for more than ${this.maxDelegatedDays} days`);
+    }
+
+    const apiKey = this.em.create(ApiKey, {
+      id: uuidv4(),
+      userId,
+      name: dto.name,
+      key: uuidv4(),
+      expiresAt,
+      project: dto.project,
+      delegated: true,
+      isActive: true,
+    });
+
+    await this.em.persistAndFlush(apiKey);
+    this.logger.log(`Delegated API key ${apiKey.id} created by user ${userId} for project ${dto.project}`);
+    return apiKey;
+  }
+
+  /**
+   * 为文档站的"运行此请求"按钮签发试运行密钥，无需注册
+   * 密钥归属 PLAYGROUND_USER_ID 演示账号，绑定申请方 IP，短时有效且只能翻译少量字符；每个 IP 每小时限签发若干个
+   */
+  async createPlaygroundKey(clientIp: string): Promise<ApiKey> {
+    if (!this.playgroundUserId) {
+      throw new ServiceUnavailableException('The API playground is not enabled');
+    }
+
+    const issued = await this.redisService.increment(`playground_keys:${clientIp}`, 1, 3600);
+    if (issued === null || issued > this.playgroundKeysPerIpPerHour) {
+      throw new HttpException('Too many playground keys requested from this IP address', HttpStatus.TOO_MANY_REQUESTS);
+    }
+
+    const apiKey = this.em.create(ApiKey, {
+      id: uuidv4(),
+      userId: this.playgroundUserId,
+      name: `playground ${clientIp}`,
+      key: uuidv4(),
+      expiresAt: new Date(Date.now() + this.playgroundTtlMinutes * 60 * 1000),
+      isActive: true,
+      playground: true,
+      boundIp: clientIp,
+      maxCharacters: this.playgroundMaxCharacters,
+    });
+
+    await this.em.persistAndFlush(apiKey);
+    this.logger.log(`Playground API key ${apiKey.id} issued to ${clientIp}`);
+    return apiKey;
+  }
+
+  /**
+   * 记录限额密钥本次要翻译的字符数，超出密钥剩余额度时拒绝（不计入）
+   */
+  async consumeKeyCharacters(context: ApiKeyContext, characters: number): Promise<void> {
+    if (!context.maxCharacters) {
+      return;
+    }
+    const ttlSeconds = context.expiresAt
+      ? Math.max(Math.ceil((new Date(context.expiresAt).getTime() - Date.now()) / 1000), 1)
+      : 24 * 60 * 60;
+    const key = `api_key_chars:${context.id}`;
+    const used = await this.redisService.increment(key, characters, ttlSeconds);
+    if (used === null || used > context.maxCharacters) {
+      if (used !== null) {
+        await this.redisService.client.decrby(key, characters).catch(() => undefined);
+      }
+      throw new HttpException(
+        `This API key is limited to ${context.maxCharacters} characters`,
+        HttpStatus.TOO_MANY_REQUESTS,
+      );
+    }
+  }
+
+  /**
+   * 退回已计入密钥的字符数，用于计数之后提交失败的任务
+   */
+  async refundKeyCharacters(context: ApiKeyContext, characters: number): Promise<void> {
+    if (!context.maxCharacters) {
+      return;
+    }
+    await this.redisService.client
+      .decrby(`api_key_chars:${context.id}`, characters)
+      .catch((error) => this.logger.error(`Failed to refund characters of API key ${context.id}: ${error.message}`));
+  }
+
   async getApiKeys(userId: string): Promise<ApiKey[]> {
     return this.em.find(ApiKey, { userId }, { orderBy: { createdAt: 'DESC' } });
   }
@@ -36,4 +276,145 @@ export class ApiKeyService {
     apiKey.isActive = false;
     await this.em.persistAndFlush(apiKey);
   }
-} 
\ No newline at end of file
+
+  /**
+   * 校验请求携带的密钥，无效、已撤销或已过期时返回 null
+   */
+  async validateApiKey(key: string, clientIp?: string): Promise<ApiKeyContext | null> {
+    const apiKey = await this.em.findOne(ApiKey, { key, isActive: true });
+    if (!apiKey) {
+      return null;
+    }
+    if (apiKey.expiresAt && apiKey.expiresAt <= new Date()) {
+      return null;
+    }
+    if (apiKey.requireSignature) {
+      throw new UnauthorizedException('This API key only accepts signed requests');
+    }
+    this.assertBoundIp(apiKey, clientIp);
+
+    return this.touch(apiKey, false);
+  }
+
+  /**
+   * 校验签名请求
+   * 时间戳须在 REQUEST_SIGNATURE_WINDOW_SECONDS 窗口内，同一签名在窗口内只能使用一次，防止重放；
+   * 签名包含方法、路径和查询，不能用于签名时以外的接口
+   */
+  async validateSignedRequest(
+    keyId: string,
+    timestamp: string | undefined,
+    signature: string,
+    request: Omit<SignedRequestParts, 'timestamp'>,
+    clientIp?: string,
+  ): Promise<ApiKeyContext> {
+    if (!timestamp || !/^\d+$/.test(timestamp)) {
+      throw new UnauthorizedException('X-Timestamp header must be a unix timestamp in seconds');
+    }
+    const skew = Math.abs(Math.floor(Date.now() / 1000) - Number(timestamp));
+    if (skew > this.signatureWindowSeconds) {
+      throw new UnauthorizedException(`Request timestamp is outside the ${this.signatureWindowSeconds}s signature window`);
+    }
+
+    const apiKey = await this.em.findOne(ApiKey, { id: keyId, isActive: true });
+    if (!apiKey || (apiKey.expiresAt && apiKey.expiresAt <= new Date())) {
+      throw new UnauthorizedException('Invalid or expired API key');
+    }
+    this.assertBoundIp(apiKey, clientIp);
+
+    const expected = Buffer.from(computeRequestSignature(apiKey.key, { ...request, timestamp }), 'hex');
+    const provided = Buffer.from(signature.replace(/^sha256=/i, ''), 'hex');
+    if (provided.length !== expected.length || !timingSafeEqual(provided, expected)) {
+      throw new UnauthorizedException('Invalid request signature');
+    }
+
+    // 时间戳前后各允许一个窗口，标记保留两个窗口即可覆盖所有可被接受的重放
+    const fresh = await this.redisService.setIfAbsent(
+      `request_signature:${apiKey.id}:${expected.toString('hex')}`,
+      timestamp,
+      this.signatureWindowSeconds * 2,
+    );
+    if (!fresh) {
+      this.logger.warn(`Rejected replayed signed request for API key ${apiKey.id}`);
+      throw new UnauthorizedException('Request signature has already been used');
+    }
+
+    return this.touch(apiKey, true);
+  }
+
+  /**
+   * 获取某个密钥归属的字符用量
+   */
+  async getApiKeyUsage(userId: string, id: string, since?: Date) {
+    const apiKey = await this.em.findOne(ApiKey, { id, userId });
+    if (!apiKey) {
+      throw new NotFoundException('API Key not found');
+    }
+
+    const where: any = { apiKeyId: apiKey.id };
+    if (since) {
+      where.createdAt = { $gte: since };
+    }
+    const logs = await this.em.find(CharacterUsageLog, where);
+
+    return {
+      apiKeyId: apiKey.id,
+      project: apiKey.project,
+      delegated: apiKey.delegated,
+      expiresAt: apiKey.expiresAt,
+      lastUsedAt: apiKey.lastUsedAt,
+      requests: logs.length,
+      totalCharacters: logs.reduce((sum, log) => sum + log.totalCharacters, 0),
+    };
+  }
+
+  private assertBoundIp(apiKey: ApiKey, clientIp?: string): void {
+    if (apiKey.boundIp && apiKey.boundIp !== clientIp) {
+      throw new UnauthorizedException('This API key cannot be used from this IP address');
+    }
+  }
+
+  private async touch(apiKey: ApiKey, signed: boolean): Promise<ApiKeyContext> {
+    if (await this.accountLockdownService.isLocked(apiKey.userId)) {
+      throw new ForbiddenException('API access for this account is locked');
+    }
+
+    // 只读副本不更新最后使用时间
+    if (!isReadOnlyMode()) {
+      apiKey.lastUsedAt = new Date();
+      await this.em.persistAndFlush(apiKey);
+    }
+
+    return {
+      id: apiKey.id,
+      userId: apiKey.userId,
+      delegated: apiKey.delegated,
+      project: apiKey.project,
+      expiresAt: apiKey.expiresAt,
+      defaults: toApiKeyDefaults(apiKey),
+      requireSignature: apiKey.requireSignature,
+      signed,
+      playground: apiKey.playground,
+      maxCharacters: apiKey.maxCharacters ?? undefined,
+    };
+  }
+}
+
+function assertFutureExpiry(value: Date | string): Date {
+  const expiresAt = new Date(value);
+  if (expiresAt.getTime() <= Date.now()) {
+    throw new BadRequestException('expiresAt must be in the future');
+  }
+  return expiresAt;
+}
+
+function toApiKeyDefaults(apiKey: ApiKey): ApiKeyDefaults {
+  return {
+    ignoredFields: apiKey.defaultIgnoredFields ?? undefined,
+    provider: apiKey.defaultProvider ?? undefined,
+    project: apiKey.defaultProject ?? undefined,
+    placeholderStyles: apiKey.defaultPlaceholderStyles
+      ? parsePlaceholderStyles(apiKey.defaultPlaceholderStyles)
+      : undefined,
+  };
+}
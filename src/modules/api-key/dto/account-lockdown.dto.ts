@@ -0,0 +1,15 @@
+import { ApiProperty, ApiPropertyOptional } from '@nestjs/swagger';
+import { IsBoolean, IsNotEmpty, IsOptional, IsString, MaxLength } from 'class-validator';
+
+export class AccountLockdownDto {
+  @ApiProperty({ description: '锁定原因', example: 'API key leaked in a public repository' })
+  @IsString()
+  @IsNotEmpty()
+  @MaxLength(2000)
+  reason: string;
+
+  @ApiPropertyOptional({ description: '同时撤销账号下所有有效的 API Key，解锁前需重新生成', default: false })
+  @IsOptional()
+  @IsBoolean()
+  revokeApiKeys?: boolean;
+}
@@ -0,0 +1,42 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsArray, IsIn, IsOptional, IsString, MaxLength, ValidateIf } from 'class-validator';
+import { TranslationProvider } from '../../../config/providers';
+import { PlaceholderStyle } from '../../translation/utils/placeholders';
+
+/**
+ * 密钥级默认值，传 null 清除对应字段，不传则保持不变
+ */
+export class ApiKeyDefaultsDto {
+  @ApiProperty({ description: '默认忽略翻译的字段，逗号分隔', required: false, nullable: true, example: 'id,url' })
+  @IsOptional()
+  @ValidateIf((_, value) => value !== null)
+  @IsString()
+  ignoredFields?: string | null;
+
+  @ApiProperty({ description: '默认翻译服务商', required: false, nullable: true, enum: TranslationProvider })
+  @IsOptional()
+  @ValidateIf((_, value) => value !== null)
+  @IsIn(Object.values(TranslationProvider))
+  provider?: string | null;
+
+  @ApiProperty({ description: '默认项目', required: false, nullable: true, example: 'mobile-app' })
+  @IsOptional()
+  @ValidateIf((_, value) => value !== null)
+  @IsString()
+  @MaxLength(100)
+  project?: string | null;
+
+  @ApiProperty({
+    description: '默认占位符语法',
+    required: false,
+    nullable: true,
+    enum: PlaceholderStyle,
+    isArray: true,
+    example: ['icu'],
+  })
+  @IsOptional()
+  @ValidateIf((_, value) => value !== null)
+  @IsArray()
+  @IsIn(Object.values(PlaceholderStyle), { each: true })
+  placeholderStyles?: PlaceholderStyle[] | null;
+}
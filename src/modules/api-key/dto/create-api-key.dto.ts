@@ -1,5 +1,7 @@
 import { ApiProperty } from '@nestjs/swagger';
-import { IsString, IsOptional, IsDateString } from 'class-validator';
+import { IsString, IsOptional, IsDateString, IsBoolean, ValidateNested } from 'class-validator';
+import { Type } from 'class-transformer';
+import { ApiKeyDefaultsDto } from './api-key-defaults.dto';
 
 export class CreateApiKeyDto {
   @ApiProperty({
@@ -17,4 +19,23 @@ export class CreateApiKeyDto {
   @IsOptional()
   @IsDateString()
   expiresAt?: Date;
-} 
\ No newline at end of file
+
+  @ApiProperty({
+    description: '密钥级默认值（忽略字段、服务商、项目），请求未指定时使用',
+    required: false,
+    type: ApiKeyDefaultsDto,
+  })
+  @IsOptional()
+  @ValidateNested()
+  @Type(() => ApiKeyDefaultsDto)
+  defaults?: ApiKeyDefaultsDto;
+
+  @ApiProperty({
+    description: '是否只接受 HMAC 签名请求（高安全场景），默认 false',
+    required: false,
+    example: false,
+  })
+  @IsOptional()
+  @IsBoolean()
+  requireSignature?: boolean;
+}
@@ -0,0 +1,27 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsString, IsDateString, IsNotEmpty, MaxLength } from 'class-validator';
+
+export class CreateDelegatedApiKeyDto {
+  @ApiProperty({
+    description: '委托密钥的名称',
+    example: 'Agency X - Q4 localisation',
+  })
+  @IsString()
+  name: string;
+
+  @ApiProperty({
+    description: '密钥可访问的项目',
+    example: 'mobile-app',
+  })
+  @IsString()
+  @IsNotEmpty()
+  @MaxLength(100)
+  project: string;
+
+  @ApiProperty({
+    description: '密钥的过期时间（必填）',
+    example: '2026-12-31T23:59:59Z',
+  })
+  @IsDateString()
+  expiresAt: Date;
+}
@@ -0,0 +1,13 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsDateString, ValidateIf } from 'class-validator';
+
+export class UpdateApiKeyExpiryDto {
+  @ApiProperty({
+    description: '新的过期时间（ISO 8601），null 表示不过期（委托密钥不允许）',
+    example: '2027-01-31T00:00:00Z',
+    nullable: true,
+  })
+  @ValidateIf((dto) => dto.expiresAt !== null)
+  @IsDateString()
+  expiresAt: string | null;
+}
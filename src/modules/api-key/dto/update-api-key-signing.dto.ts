@@ -0,0 +1,11 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsBoolean } from 'class-validator';
+
+export class UpdateApiKeySigningDto {
+  @ApiProperty({
+    description: '是否只接受 HMAC 签名请求，开启后直接携带 X-API-Key 的请求会被拒绝',
+    example: true,
+  })
+  @IsBoolean()
+  requireSignature: boolean;
+}
@@ -17,12 +17,57 @@ export class ApiKey {
   @Property({ nullable: true })
   expiresAt?: Date;
 
+  /** 已发送的最近一次到期提醒（剩余天数阈值），修改过期时间后清空 */
+  @Property({ nullable: true })
+  expiryRemindedDays?: number;
+
   @Property()
   isActive: boolean = true;
 
+  /** 委托密钥：发给外包/代理方的临时密钥，必须带过期时间 */
+  @Property()
+  delegated: boolean = false;
+
+  /** 限定可访问的项目，为空表示不限制 */
+  @Property({ nullable: true })
+  project?: string;
+
+  /** 密钥级默认值：请求未指定时使用，便于共用账号的多个服务各自配置 */
+  @Property({ type: 'text', nullable: true })
+  defaultIgnoredFields?: string;
+
+  @Property({ nullable: true })
+  defaultProvider?: string;
+
+  @Property({ nullable: true })
+  defaultProject?: string;
+
+  /** 逗号分隔的占位符语法 */
+  @Property({ nullable: true })
+  defaultPlaceholderStyles?: string;
+
+  /** 只接受签名请求（X-Api-Key-Id + X-Timestamp + X-Signature），拒绝直接携带 X-API-Key */
+  @Property()
+  requireSignature: boolean = false;
+
+  /** 文档站"试运行"用的临时密钥，归属平台的演示账号 */
+  @Property()
+  playground: boolean = false;
+
+  /** 只允许从该 IP 使用，为空表示不限制 */
+  @Property({ nullable: true })
+  boundIp?: string;
+
+  /** 密钥有效期内可翻译的字符上限，为空表示不限制 */
+  @Property({ nullable: true })
+  maxCharacters?: number;
+
+  @Property({ nullable: true })
+  lastUsedAt?: Date;
+
   @Property()
   createdAt: Date = new Date();
 
   @Property({ onUpdate: () => new Date() })
   updatedAt: Date = new Date();
-} 
\ No newline at end of file
+}
@@ -0,0 +1,31 @@
+import { PlaceholderStyle } from '../../translation/utils/placeholders';
+
+/**
+ * 密钥级默认值，请求体中未指定的字段使用这些值
+ */
+export interface ApiKeyDefaults {
+  ignoredFields?: string;
+  provider?: string;
+  project?: string;
+  placeholderStyles?: PlaceholderStyle[];
+}
+
+/**
+ * 通过 API Key 认证的请求上下文，挂在 request.apiKey 上
+ */
+export interface ApiKeyContext {
+  id: string;
+  userId: string;
+  delegated: boolean;
+  project?: string;
+  expiresAt?: Date;
+  defaults: ApiKeyDefaults;
+  /** 密钥要求签名请求 */
+  requireSignature?: boolean;
+  /** 本次请求通过 HMAC 签名认证 */
+  signed?: boolean;
+  /** 文档站试运行密钥 */
+  playground?: boolean;
+  /** 密钥有效期内可翻译的字符上限 */
+  maxCharacters?: number;
+}
@@ -1,12 +1,24 @@
 import { Module } from '@nestjs/common';
+import { APP_INTERCEPTOR } from '@nestjs/core';
 import { MikroOrmModule } from '@mikro-orm/nestjs';
 
 // 实体
 import { AuditLog } from './entities/audit-log.entity';
+import { LegalHold } from './entities/legal-hold.entity';
+import { ComplianceExport } from './entities/compliance-export.entity';
 import { User } from '../user/entities/user.entity';
+import { CommonModule } from '../../common/common.module';
 
 // 服务
 import { AuditLogService } from './services/audit-log.service';
+import { LegalHoldService } from './services/legal-hold.service';
+
+// 控制器
+import { LegalHoldController } from './controllers/legal-hold.controller';
+import { AuditLogController } from './controllers/audit-log.controller';
+
+// 拦截器
+import { MutationAuditInterceptor } from './interceptors/mutation-audit.interceptor';
 
 /**
  * 审计模块
@@ -16,14 +28,24 @@ import { AuditLogService } from './services/audit-log.service';
   imports: [
     MikroOrmModule.forFeature([
       AuditLog,
+      LegalHold,
+      ComplianceExport,
       User, // 审计日志需要关联用户
     ]),
+    CommonModule,
+  ],
+  controllers: [
+    LegalHoldController,
+    AuditLogController,
   ],
   providers: [
     AuditLogService,
+    LegalHoldService,
+    { provide: APP_INTERCEPTOR, useClass: MutationAuditInterceptor },
   ],
   exports: [
     AuditLogService,
+    LegalHoldService,
   ],
 })
 export class AuditModule {}
\ No newline at end of file
@@ -0,0 +1,73 @@
+import { BadRequestException, Controller, Get, Query, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiQuery, ApiResponse, ApiTags } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../../auth/guards/admin.guard';
+import { AuditAction, ResourceType } from '../entities/audit-log.entity';
+import { AuditLogFilter, AuditLogService } from '../services/audit-log.service';
+
+/** 解析审计日志查询参数，user / admin 两个接口共用 */
+export function parseAuditLogQuery(query: Record<string, string | undefined>): AuditLogFilter {
+  const parseDate = (name: string) => {
+    if (!query[name]) {
+      return undefined;
+    }
+    const date = new Date(query[name]);
+    if (isNaN(date.getTime())) {
+      throw new BadRequestException(`Invalid ${name} date`);
+    }
+    return date;
+  };
+  const parseNumber = (name: string) => {
+    if (query[name] === undefined) {
+      return undefined;
+    }
+    const value = Number(query[name]);
+    if (!Number.isInteger(value) || value < 0) {
+      throw new BadRequestException(`Invalid ${name}`);
+    }
+    return value;
+  };
+  if (query.action && !Object.values(AuditAction).includes(query.action as AuditAction)) {
+    throw new BadRequestException(`Invalid action ${query.action}`);
+  }
+  if (query.resourceType && !Object.values(ResourceType).includes(query.resourceType as ResourceType)) {
+    throw new BadRequestException(`Invalid resourceType ${query.resourceType}`);
+  }
+
+  return {
+    apiKeyId: query.apiKeyId,
+    action: query.action as AuditAction,
+    resourceType: query.resourceType as ResourceType,
+    resourceId: query.resourceId,
+    endpoint: query.endpoint,
+    from: parseDate('from'),
+    to: parseDate('to'),
+    limit: parseNumber('limit'),
+    offset: parseNumber('offset'),
+  };
+}
+
+@ApiTags('admin')
+@Controller('admin/audit_log')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard, AdminGuard)
+export class AuditLogController {
+  constructor(private readonly auditLogService: AuditLogService) {}
+
+  @Get()
+  @ApiOperation({ summary: '跨用户查询审计日志' })
+  @ApiQuery({ name: 'userId', required: false, description: '按用户过滤' })
+  @ApiQuery({ name: 'apiKeyId', required: false, description: '按 API 密钥过滤' })
+  @ApiQuery({ name: 'action', required: false, enum: AuditAction })
+  @ApiQuery({ name: 'resourceType', required: false, enum: ResourceType })
+  @ApiQuery({ name: 'resourceId', required: false })
+  @ApiQuery({ name: 'endpoint', required: false, description: '路由模板包含该字符串，如 /translation' })
+  @ApiQuery({ name: 'from', required: false, description: '起始时间（含），ISO 8601' })
+  @ApiQuery({ name: 'to', required: false, description: '结束时间（不含），ISO 8601' })
+  @ApiQuery({ name: 'limit', required: false, description: '数量，默认 50，最多 200' })
+  @ApiQuery({ name: 'offset', required: false })
+  @ApiResponse({ status: 400, description: '筛选条件无效' })
+  async list(@Query() query: Record<string, string>) {
+    return this.auditLogService.listEntries({ ...parseAuditLogQuery(query), userId: query.userId });
+  }
+}
@@ -0,0 +1,67 @@
+import { Controller, Get, Post, Delete, Body, Param, Query, Req, Res, UseGuards } from '@nestjs/common';
+import { ApiTags, ApiOperation, ApiResponse, ApiBearerAuth, ApiParam, ApiQuery } from '@nestjs/swagger';
+import { Response } from 'express';
+import { JwtAuthGuard } from '../../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../../auth/guards/admin.guard';
+import { LegalHoldService } from '../services/legal-hold.service';
+import { PlaceLegalHoldDto } from '../dto/legal-hold.dto';
+
+@ApiTags('admin')
+@Controller('admin/legal-holds')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard, AdminGuard)
+export class LegalHoldController {
+  constructor(private readonly legalHoldService: LegalHoldService) {}
+
+  @Post('users/:userId')
+  @ApiOperation({ summary: '对用户数据施加法律保留' })
+  @ApiParam({ name: 'userId', description: '用户 ID' })
+  @ApiResponse({ status: 201, description: '法律保留已生效，数据不再参与保留期清理' })
+  @ApiResponse({ status: 409, description: '用户已处于法律保留状态' })
+  async placeHold(@Req() req: any, @Param('userId') userId: string, @Body() dto: PlaceLegalHoldDto) {
+    return this.legalHoldService.placeHold(userId, dto, req.user.id);
+  }
+
+  @Get()
+  @ApiOperation({ summary: '获取法律保留列表' })
+  @ApiQuery({ name: 'userId', required: false, description: '按用户过滤' })
+  async listHolds(@Query('userId') userId?: string) {
+    return this.legalHoldService.listHolds(userId);
+  }
+
+  @Delete(':id')
+  @ApiOperation({ summary: '解除法律保留' })
+  @ApiParam({ name: 'id', description: '法律保留 ID' })
+  @ApiResponse({ status: 409, description: '法律保留已解除' })
+  async releaseHold(@Req() req: any, @Param('id') id: string) {
+    return this.legalHoldService.releaseHold(id, req.user.id);
+  }
+
+  @Post(':id/exports')
+  @ApiOperation({ summary: '生成只读的合规导出包' })
+  @ApiParam({ name: 'id', description: '法律保留 ID' })
+  @ApiResponse({ status: 201, description: '返回导出记录及 SHA-256 校验和' })
+  async createExport(@Req() req: any, @Param('id') id: string) {
+    return this.legalHoldService.createExport(id, req.user.id);
+  }
+
+  @Get(':id/exports')
+  @ApiOperation({ summary: '获取法律保留下的导出记录' })
+  @ApiParam({ name: 'id', description: '法律保留 ID' })
+  async listExports(@Param('id') id: string) {
+    return this.legalHoldService.listExports(id);
+  }
+
+  @Get('exports/:exportId/download')
+  @ApiOperation({ summary: '下载合规导出包' })
+  @ApiParam({ name: 'exportId', description: '导出记录 ID' })
+  @ApiResponse({ status: 200, description: '返回导出包，X-Content-SHA256 为校验和' })
+  @ApiResponse({ status: 500, description: '导出包校验失败' })
+  async downloadExport(@Req() req: any, @Param('exportId') exportId: string, @Res() res: Response) {
+    const { record, content } = await this.legalHoldService.readExport(exportId, req.user.id);
+    res.setHeader('Content-Type', 'application/json');
+    res.setHeader('Content-Disposition', `attachment; filename="${record.fileName}"`);
+    res.setHeader('X-Content-SHA256', record.sha256);
+    res.send(content);
+  }
+}
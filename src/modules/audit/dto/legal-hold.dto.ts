@@ -0,0 +1,16 @@
+import { ApiProperty, ApiPropertyOptional } from '@nestjs/swagger';
+import { IsNotEmpty, IsOptional, IsString, MaxLength } from 'class-validator';
+
+export class PlaceLegalHoldDto {
+  @ApiProperty({ description: '保留原因', example: 'Litigation hold for case #2026-114' })
+  @IsString()
+  @IsNotEmpty()
+  @MaxLength(2000)
+  reason: string;
+
+  @ApiPropertyOptional({ description: '外部案件编号', example: 'CASE-2026-114' })
+  @IsOptional()
+  @IsString()
+  @MaxLength(255)
+  caseReference?: string;
+}
@@ -17,6 +17,14 @@ export enum AuditAction {
   ALERT_ACKNOWLEDGE = 'alert_acknowledge',
   REPORT_GENERATE = 'report_generate',
   CONFIG_CHANGE = 'config_change',
+  LEGAL_HOLD_PLACE = 'legal_hold_place',
+  LEGAL_HOLD_RELEASE = 'legal_hold_release',
+  ACCOUNT_LOCKDOWN = 'account_lockdown',
+  ACCOUNT_UNLOCK = 'account_unlock',
+  USAGE_IMPORT = 'usage_import',
+  PROVIDER_INVOICE_IMPORT = 'provider_invoice_import',
+  QUEUE_JOB_REQUEUE = 'queue_job_requeue',
+  DATA_PURGE = 'data_purge',
 }
 
 export enum ResourceType {
@@ -30,6 +38,11 @@ export enum ResourceType {
   ALERT = 'alert',
   SYSTEM_CONFIG = 'system_config',
   REPORT = 'report',
+  LEGAL_HOLD = 'legal_hold',
+  COMPLIANCE_EXPORT = 'compliance_export',
+  DOCUMENT = 'document',
+  /** 通过 API 的增删改操作（MutationAuditInterceptor 自动记录），具体接口见 endpoint */
+  API_ENDPOINT = 'api_endpoint',
 }
 
 export enum AuditSeverity {
@@ -44,6 +57,7 @@ export enum AuditSeverity {
  * 用于记录所有系统操作的审计跟踪
  */
 @Entity({ tableName: 'audit_log' })
+@Index({ properties: ['userId', 'createdAt'] })
 export class AuditLog extends BaseEntity {
   @ManyToOne(() => User, { nullable: true })
   user?: User;
@@ -60,6 +74,14 @@ export class AuditLog extends BaseEntity {
   @Property({ length: 255, nullable: true })
   resourceId?: string;
 
+  /** 通过 API 密钥操作时的密钥 ID */
+  @Property({ length: 255, nullable: true })
+  apiKeyId?: string;
+
+  /** 请求方法和路由模板，如 DELETE /api/v1/translation/:id */
+  @Property({ length: 255, nullable: true })
+  endpoint?: string;
+
   @Property({ type: 'json', nullable: true })
   oldValues?: Record<string, any>;
 
@@ -0,0 +1,28 @@
+import { Entity, Index, Property } from '@mikro-orm/core';
+import { BaseEntity } from '../../../common/entities/base.entity';
+
+/**
+ * 合规导出记录
+ * 导出包写入后只读，sha256 用于下载时校验内容未被篡改
+ */
+@Entity({ tableName: 'compliance_export' })
+export class ComplianceExport extends BaseEntity {
+  @Property()
+  @Index()
+  userId!: string;
+
+  @Property({ nullable: true })
+  legalHoldId?: string;
+
+  @Property()
+  requestedBy!: string;
+
+  @Property()
+  fileName!: string;
+
+  @Property({ length: 64 })
+  sha256!: string;
+
+  @Property()
+  sizeBytes!: number;
+}
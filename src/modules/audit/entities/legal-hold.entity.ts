@@ -0,0 +1,29 @@
+import { Entity, Index, Property } from '@mikro-orm/core';
+import { BaseEntity } from '../../../common/entities/base.entity';
+
+/**
+ * 法律保留
+ * 处于保留状态（releasedAt 为空）的用户数据不参与任何保留期清理
+ */
+@Entity({ tableName: 'legal_hold' })
+export class LegalHold extends BaseEntity {
+  @Property()
+  @Index()
+  userId!: string;
+
+  @Property({ type: 'text' })
+  reason!: string;
+
+  /** 外部案件编号 */
+  @Property({ nullable: true })
+  caseReference?: string;
+
+  @Property()
+  placedBy!: string;
+
+  @Property({ nullable: true })
+  releasedAt?: Date;
+
+  @Property({ nullable: true })
+  releasedBy?: string;
+}
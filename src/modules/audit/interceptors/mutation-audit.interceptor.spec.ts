@@ -0,0 +1,126 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { ExecutionContext, NotFoundException } from '@nestjs/common';
+import { lastValueFrom, of, throwError } from 'rxjs';
+import { MutationAuditInterceptor } from './mutation-audit.interceptor';
+import { AuditLogService } from '../services/audit-log.service';
+import { AuditAction, ResourceType } from '../entities/audit-log.entity';
+
+describe('MutationAuditInterceptor', () => {
+  let interceptor: MutationAuditInterceptor;
+
+  const mockAuditLogService = {
+    log: jest.fn().mockResolvedValue({}),
+  };
+
+  const buildContext = (req: Record<string, any>, statusCode = 200): ExecutionContext =>
+    ({
+      getType: () => 'http',
+      switchToHttp: () => ({
+        getRequest: () => ({
+          baseUrl: '',
+          headers: { 'user-agent': 'sdk/1.0', 'x-forwarded-for': '198.51.100.7, 10.0.0.1' },
+          params: {},
+          ...req,
+        }),
+        getResponse: () => ({ statusCode }),
+      }),
+    }) as unknown as ExecutionContext;
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        MutationAuditInterceptor,
+        {
+          provide: ConfigService,
+          useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) },
+        },
+        { provide: AuditLogService, useValue: mockAuditLogService },
+      ],
+    }).compile();
+
+    interceptor = module.get<MutationAuditInterceptor>(MutationAuditInterceptor);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('记录删除操作的用户、密钥、接口、资源和 IP，不保存请求体', async () => {
+    const context = buildContext({
+      method: 'DELETE',
+      route: { path: '/api/v1/translation/:id' },
+      params: { id: 'doc-1' },
+      body: { secret: 'x' },
+      user: { id: 'user-1' },
+      apiKey: { id: 'key-1' },
+      requestId: 'req-1',
+    });
+
+    await lastValueFrom(interceptor.intercept(context, { handle: () => of(undefined) }));
+
+    expect(mockAuditLogService.log).toHaveBeenCalledWith(
+      expect.objectContaining({
+        userId: 'user-1',
+        apiKeyId: 'key-1',
+        action: AuditAction.DELETE,
+        resourceType: ResourceType.API_ENDPOINT,
+        resourceId: 'doc-1',
+        endpoint: 'DELETE /api/v1/translation/:id',
+        ipAddress: '198.51.100.7',
+        userAgent: 'sdk/1.0',
+        newValues: { statusCode: 200 },
+        additionalContext: { requestId: 'req-1', source: 'api' },
+      }),
+      { detached: true },
+    );
+    expect(JSON.stringify(mockAuditLogService.log.mock.calls[0][0])).not.toContain('secret');
+  });
+
+  it('创建操作没有路径参数时取响应中的 id', async () => {
+    const context = buildContext(
+      { method: 'POST', route: { path: '/api/v1/user/projects' }, user: { id: 'user-1' } },
+      201,
+    );
+
+    await lastValueFrom(interceptor.intercept(context, { handle: () => of({ id: 'task-9' }) }));
+
+    expect(mockAuditLogService.log).toHaveBeenCalledWith(
+      expect.objectContaining({ action: AuditAction.CREATE, resourceId: 'task-9', newValues: { statusCode: 201 } }),
+      { detached: true },
+    );
+  });
+
+  it('失败的请求记录状态码和错误信息', async () => {
+    const context = buildContext({
+      method: 'PUT',
+      route: { path: '/api/v1/user/projects/:id' },
+      params: { id: 'web' },
+      user: { id: 'user-1' },
+    });
+
+    await expect(
+      lastValueFrom(interceptor.intercept(context, { handle: () => throwError(() => new NotFoundException('gone')) })),
+    ).rejects.toThrow('gone');
+
+    expect(mockAuditLogService.log).toHaveBeenCalledWith(
+      expect.objectContaining({ action: AuditAction.UPDATE, newValues: { statusCode: 404 }, errorMessage: 'gone' }),
+      { detached: true },
+    );
+  });
+
+  it('不记录读取请求和未认证的请求，无效的 IP 不写入', async () => {
+    await lastValueFrom(
+      interceptor.intercept(buildContext({ method: 'GET', user: { id: 'user-1' } }), { handle: () => of({}) }),
+    );
+    await lastValueFrom(interceptor.intercept(buildContext({ method: 'POST' }), { handle: () => of({}) }));
+    expect(mockAuditLogService.log).not.toHaveBeenCalled();
+
+    const context = buildContext({ method: 'POST', user: { id: 'user-1' }, headers: {}, ip: undefined });
+    await lastValueFrom(interceptor.intercept(context, { handle: () => of({}) }));
+    expect(mockAuditLogService.log).toHaveBeenCalledWith(
+      expect.objectContaining({ ipAddress: undefined }),
+      { detached: true },
+    );
+  });
+});
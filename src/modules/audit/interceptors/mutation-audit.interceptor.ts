@@ -0,0 +1,88 @@
+import { CallHandler, ExecutionContext, Injectable, Logger, NestInterceptor } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { Request, Response } from 'express';
+import { isIP } from 'net';
+import { Observable, tap } from 'rxjs';
+import { getClientIp } from '../../../common/utils/client-ip';
+import { AuditAction, ResourceType } from '../entities/audit-log.entity';
+import { AuditLogService } from '../services/audit-log.service';
+
+const METHOD_ACTIONS: Record<string, AuditAction> = {
+  POST: AuditAction.CREATE,
+  PUT: AuditAction.UPDATE,
+  PATCH: AuditAction.UPDATE,
+  DELETE: AuditAction.DELETE,
+};
+
+/**
+ * 记录已认证请求的增删改操作：谁（用户 / API 密钥）、哪个接口和资源、何时、来自哪个 IP，成功和失败都记录。
+ * 拦截器在守卫之后执行，能拿到 req.user 和路由模板；只记录状态码，不保存请求体和响应体。
+ * 审计写入在请求结束后异步进行，失败只记日志，不影响响应
+ */
+@Injectable()
+export class MutationAuditInterceptor implements NestInterceptor {
+  private readonly logger = new Logger(MutationAuditInterceptor.name);
+  private readonly enabled: boolean;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly auditLogService: AuditLogService,
+  ) {
+    this.enabled = this.configService.get('AUDIT_API_MUTATIONS', 'true') !== 'false';
+  }
+
+  intercept(context: ExecutionContext, next: CallHandler): Observable<any> {
+    if (!this.enabled || context.getType() !== 'http') {
+      return next.handle();
+    }
+    const req = context.switchToHttp().getRequest<Request & { user?: any; apiKey?: any; requestId?: string }>();
+    const action = METHOD_ACTIONS[req.method];
+    if (!action || !req.user?.id) {
+      return next.handle();
+    }
+
+    const startedAt = Date.now();
+    return next.handle().pipe(
+      tap({
+        next: (body) => {
+          const res = context.switchToHttp().getResponse<Response>();
+          this.record(req, action, res.statusCode, startedAt, body);
+        },
+        error: (error) => {
+          const statusCode = typeof error?.getStatus === 'function' ? error.getStatus() : 500;
+          this.record(req, action, statusCode, startedAt, undefined, error);
+        },
+      }),
+    );
+  }
+
+  private record(req: any, action: AuditAction, statusCode: number, startedAt: number, body?: any, error?: any) {
+    const ip = getClientIp(req);
+    const routePath = req.route?.path ? `${req.baseUrl}${req.route.path}` : req.path;
+    const params: Record<string, string> = req.params ?? {};
+    const resourceId = params.id ?? Object.values(params)[0] ?? (typeof body?.id === 'string' ? body.id : undefined);
+
+    this.auditLogService
+      .log(
+        {
+          userId: req.user.id,
+          apiKeyId: req.apiKey?.id,
+          action,
+          resourceType: ResourceType.API_ENDPOINT,
+          resourceId,
+          endpoint: `${req.method} ${routePath}`.slice(0, 255),
+          newValues: { statusCode },
+          ipAddress: isIP(ip) ? ip : undefined,
+          userAgent: req.headers?.['user-agent'],
+          additionalContext: { requestId: req.requestId, source: 'api' },
+          tags: ['api'],
+          executionTimeMs: Date.now() - startedAt,
+          errorMessage: error ? String(error.message ?? error).slice(0, 500) : undefined,
+        },
+        { detached: true },
+      )
+      .catch((auditError) => {
+        this.logger.warn(`Failed to record audit entry for ${req.method} ${routePath}: ${auditError.message}`);
+      });
+  }
+}
@@ -0,0 +1,104 @@
+import { Test } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager, RawQueryFragment } from '@mikro-orm/core';
+import { getRepositoryToken } from '@mikro-orm/nestjs';
+import { AuditLogService } from './audit-log.service';
+import { AuditLog } from '../entities/audit-log.entity';
+import { LegalHold } from '../entities/legal-hold.entity';
+import { User } from '../../user/entities/user.entity';
+import { StorageDriverService } from '../../../common/services/storage-driver.service';
+
+describe('AuditLogService', () => {
+  let service: AuditLogService;
+
+  const mockAuditRepository = {
+    findAndCount: jest.fn(),
+    nativeDelete: jest.fn(),
+  };
+
+  const mockEntityManager = {
+    find: jest.fn(),
+  };
+
+  const createService = async (driver = 'postgresql') => {
+    const module = await Test.createTestingModule({
+      providers: [
+        AuditLogService,
+        StorageDriverService,
+        { provide: getRepositoryToken(AuditLog), useValue: mockAuditRepository },
+        { provide: getRepositoryToken(User), useValue: {} },
+        { provide: EntityManager, useValue: mockEntityManager },
+        { provide: ConfigService, useValue: { get: jest.fn(() => driver) } },
+      ],
+    }).compile();
+
+    const created = module.get<AuditLogService>(AuditLogService);
+    jest.spyOn(created, 'log').mockResolvedValue(undefined);
+    return created;
+  };
+
+  beforeEach(async () => {
+    service = await createService();
+    mockAuditRepository.findAndCount.mockResolvedValue([[], 0]);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  describe('findAuditLogs', () => {
+    // tags 是 JSON 列，条件为原生 SQL 片段，还原出 SQL、参数和比较值
+    const tagCondition = () => {
+      const [where] = mockAuditRepository.findAndCount.mock.calls[0];
+      const [condition] = where.$and;
+      const [key] = Object.keys(condition);
+      const { sql, params } = RawQueryFragment.getKnownFragment(key);
+      return { sql, params, value: condition[key] };
+    };
+
+    it('PostgreSQL 下按 jsonb 标签过滤，不使用数组的 && 运算符', async () => {
+      await service.findAuditLogs({ userId: 'user1', tags: ['legal_hold', 'billing'] });
+
+      const [where] = mockAuditRepository.findAndCount.mock.calls[0];
+      expect(where.userId).toBe('user1');
+      expect(where.tags).toBeUndefined();
+      expect(tagCondition()).toEqual({
+        sql: 'jsonb_exists_any(tags, array[?, ?]::text[])',
+        params: ['legal_hold', 'billing'],
+        value: true,
+      });
+    });
+
+    it('MySQL 下按 JSON_CONTAINS 过滤标签', async () => {
+      const mysqlService = await createService('mysql');
+
+      await mysqlService.findAuditLogs({ tags: ['legal_hold'] });
+
+      expect(tagCondition()).toEqual({ sql: '(JSON_CONTAINS(tags, JSON_QUOTE(?)))', params: ['legal_hold'], value: 1 });
+    });
+  });
+
+  describe('cleanupExpiredAuditLogs', () => {
+    it('不删除处于法律保留状态的用户的过期日志', async () => {
+      mockEntityManager.find.mockResolvedValueOnce([{ userId: 'user1' }, { userId: 'user2' }]);
+      mockAuditRepository.nativeDelete.mockResolvedValueOnce(3);
+
+      await service.cleanupExpiredAuditLogs();
+
+      expect(mockEntityManager.find).toHaveBeenCalledWith(LegalHold, { releasedAt: null }, { fields: ['userId'] });
+      expect(mockAuditRepository.nativeDelete).toHaveBeenCalledWith({
+        retentionUntil: { $lte: expect.any(Date) },
+        $or: [{ userId: null }, { userId: { $nin: ['user1', 'user2'] } }],
+      });
+    });
+
+    it('没有保留时按保留期删除全部过期日志', async () => {
+      mockEntityManager.find.mockResolvedValueOnce([]);
+      mockAuditRepository.nativeDelete.mockResolvedValueOnce(0);
+
+      await service.cleanupExpiredAuditLogs();
+
+      expect(mockAuditRepository.nativeDelete).toHaveBeenCalledWith({ retentionUntil: { $lte: expect.any(Date) } });
+    });
+  });
+});
@@ -9,13 +9,17 @@ import {
   AuditSeverity 
 } from '../entities/audit-log.entity';
 import { User } from '../../user/entities/user.entity';
+import { LegalHold } from '../entities/legal-hold.entity';
 import { AuditContext } from '../../../models/models';
+import { StorageDriverService } from '../../../common/services/storage-driver.service';
 
 export interface CreateAuditLogDto {
   userId?: string;
   action: AuditAction;
   resourceType: ResourceType;
   resourceId?: string;
+  apiKeyId?: string;
+  endpoint?: string;
   oldValues?: Record<string, any>;
   newValues?: Record<string, any>;
   ipAddress?: string;
@@ -58,6 +62,21 @@ export interface AuditQueryParams {
   limit?: number;
 }
 
+/** GET /user/audit_log 和 /admin/audit_log 的筛选条件 */
+export interface AuditLogFilter {
+  userId?: string;
+  apiKeyId?: string;
+  action?: AuditAction;
+  resourceType?: ResourceType;
+  resourceId?: string;
+  /** 路由模板前缀，如 /api/v1/translation */
+  endpoint?: string;
+  from?: Date;
+  to?: Date;
+  limit?: number;
+  offset?: number;
+}
+
 export interface AuditStats {
   totalLogs: number;
   byAction: Record<string, number>;
@@ -94,26 +113,31 @@ export class AuditLogService {
     @InjectRepository(User)
     private readonly userRepository: EntityRepository<User>,
     private readonly em: EntityManager,
+    private readonly storageDriverService: StorageDriverService,
   ) {}
 
   /**
    * 记录审计日志
+   * detached 时在独立的 EntityManager 中写入，不会一并 flush 请求上下文中未提交的实体（用于请求结束后补记）
    */
-  async log(dto: CreateAuditLogDto): Promise<AuditLog> {
+  async log(dto: CreateAuditLogDto, options: { detached?: boolean } = {}): Promise<AuditLog> {
+    const em = options.detached ? this.em.fork() : this.em;
     try {
       // 获取用户信息
       let user: User | null = null;
       if (dto.userId) {
-        user = await this.userRepository.findOne({ id: dto.userId });
+        user = await em.findOne(User, { id: dto.userId });
       }
 
       // 创建审计日志
-      const auditLog = this.auditRepository.create({
+      const auditLog = em.create(AuditLog, {
         user,
         userId: dto.userId,
         action: dto.action,
         resourceType: dto.resourceType,
         resourceId: dto.resourceId,
+        apiKeyId: dto.apiKeyId,
+        endpoint: dto.endpoint,
         oldValues: dto.oldValues,
         newValues: dto.newValues,
         ipAddress: dto.ipAddress,
@@ -145,7 +169,7 @@ export class AuditLogService {
       // 设置数据保留期
       auditLog.retentionUntil = this.calculateRetentionDate(auditLog);
 
-      await this.em.persistAndFlush(auditLog);
+      await em.persistAndFlush(auditLog);
 
       // 异步检测可疑活动
       this.detectSuspiciousActivity(auditLog).catch(error => {
@@ -229,7 +253,8 @@ export class AuditLogService {
     }
 
     if (params.tags && params.tags.length > 0) {
-      whereClause.tags = { $overlap: params.tags };
+      // tags 是 JSON 列，按驱动生成条件；原生 SQL 片段只能用于一次查询，这里只查询一次
+      whereClause.$and = [this.storageDriverService.jsonArrayOverlap('tags', params.tags)];
     }
 
     // 分页
@@ -247,6 +272,54 @@ export class AuditLogService {
     return { logs, total };
   }
 
+  /**
+   * 按用户、密钥、操作、资源、接口和时间筛选审计记录，按时间倒序分页；只返回对外公开的字段
+   */
+  async listEntries(filter: AuditLogFilter) {
+    const where: any = {};
+    for (const field of ['userId', 'apiKeyId', 'action', 'resourceType', 'resourceId'] as const) {
+      if (filter[field]) {
+        where[field] = filter[field];
+      }
+    }
+    if (filter.endpoint) {
+      where.endpoint = { $like: `%${filter.endpoint.replace(/[%_\\]/g, '\\$&')}%` };
+    }
+    if (filter.from || filter.to) {
+      where.createdAt = {
+        ...(filter.from && { $gte: filter.from }),
+        ...(filter.to && { $lt: filter.to }),
+      };
+    }
+    const limit = Math.min(Math.max(filter.limit ?? 50, 1), 200);
+    const offset = Math.max(filter.offset ?? 0, 0);
+
+    const [logs, total] = await this.auditRepository.findAndCount(where, {
+      orderBy: { createdAt: 'DESC' },
+      limit,
+      offset,
+    });
+    return {
+      entries: logs.map((log) => ({
+        id: log.id,
+        userId: log.userId ?? null,
+        apiKeyId: log.apiKeyId ?? null,
+        action: log.action,
+        resourceType: log.resourceType,
+        resourceId: log.resourceId ?? null,
+        endpoint: log.endpoint ?? null,
+        ipAddress: log.ipAddress ?? null,
+        userAgent: log.userAgent ?? null,
+        description: log.description ?? null,
+        errorMessage: log.errorMessage ?? null,
+        createdAt: log.createdAt,
+      })),
+      total,
+      limit,
+      offset,
+    };
+  }
+
   /**
    * 获取审计统计信息
    */
@@ -473,8 +546,14 @@ export class AuditLogService {
 
     try {
       const now = new Date();
+      // 处于法律保留状态的用户日志不清理
+      const holds = await this.em.find(LegalHold, { releasedAt: null }, { fields: ['userId'] });
+      const heldUserIds = holds.map(hold => hold.userId);
       const deletedCount = await this.auditRepository.nativeDelete({
         retentionUntil: { $lte: now },
+        ...(heldUserIds.length > 0
+          ? { $or: [{ userId: null }, { userId: { $nin: heldUserIds } }] }
+          : {}),
       });
 
       this.logger.log(`清理过期审计日志完成，删除 ${deletedCount} 条记录`);
@@ -0,0 +1,231 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { BadRequestException, ConflictException, GoneException, NotFoundException } from '@nestjs/common';
+import { ModuleRef } from '@nestjs/core';
+import { EntityManager } from '@mikro-orm/core';
+import { mkdtempSync, readFileSync } from 'fs';
+import { tmpdir } from 'os';
+import { join } from 'path';
+import { LegalHoldService } from './legal-hold.service';
+import { AuditLogService } from './audit-log.service';
+import { LegalHold } from '../entities/legal-hold.entity';
+import { ComplianceExport } from '../entities/compliance-export.entity';
+import { AuditAction } from '../entities/audit-log.entity';
+import { User } from '../../user/entities/user.entity';
+import { TranslationTask, UserJsonData } from '../../translation/entities/translation-task.entity';
+import { WebhookConfig } from '../../webhook/entities/webhook-config.entity';
+import { ApiKey } from '../../api-key/entities/api-key.entity';
+
+describe('LegalHoldService', () => {
+  let service: LegalHoldService;
+  let exportDir: string;
+
+  // 按实体返回的数据，未列出的实体返回空列表
+  let rows: Map<unknown, any[]>;
+
+  const mockEntityManager = {
+    findOne: jest.fn(),
+    find: jest.fn(async (entity: unknown) => rows.get(entity) ?? []),
+    create: jest.fn((_entity: unknown, data: any) => ({ ...data })),
+    persistAndFlush: jest.fn(),
+    flush: jest.fn(),
+  };
+
+  const mockAuditLogService = {
+    log: jest.fn(),
+  };
+
+  const mockDocumentArchiveService = {
+    load: jest.fn(),
+  };
+
+  const hold = { id: 'hold1', userId: 'user1', caseReference: 'CASE-1' };
+
+  beforeEach(async () => {
+    exportDir = mkdtempSync(join(tmpdir(), 'compliance-exports-'));
+    rows = new Map();
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        LegalHoldService,
+        { provide: EntityManager, useValue: mockEntityManager },
+        { provide: AuditLogService, useValue: mockAuditLogService },
+        {
+          provide: ConfigService,
+          useValue: {
+            get: jest.fn((key: string, defaultValue?: any) =>
+              key === 'COMPLIANCE_EXPORT_DIR' ? exportDir : defaultValue,
+            ),
+          },
+        },
+        { provide: ModuleRef, useValue: { get: jest.fn(() => mockDocumentArchiveService) } },
+      ],
+    }).compile();
+
+    service = module.get<LegalHoldService>(LegalHoldService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  describe('placeHold', () => {
+    it('用户不存在时返回 404', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce(null);
+
+      await expect(service.placeHold('user1', { reason: 'lawsuit' }, 'admin1')).rejects.toThrow(NotFoundException);
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+    });
+
+    it('用户已处于保留状态时拒绝重复保留', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce({ id: 'user1' }).mockResolvedValueOnce(hold);
+
+      await expect(service.placeHold('user1', { reason: 'lawsuit' }, 'admin1')).rejects.toThrow(ConflictException);
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+    });
+
+    it('创建保留记录并写入审计日志', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce({ id: 'user1' }).mockResolvedValueOnce(null);
+
+      const created = await service.placeHold('user1', { reason: 'lawsuit', caseReference: 'CASE-1' }, 'admin1');
+
+      expect(created).toMatchObject({
+        userId: 'user1',
+        reason: 'lawsuit',
+        caseReference: 'CASE-1',
+        placedBy: 'admin1',
+      });
+      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith(created);
+      expect(mockAuditLogService.log).toHaveBeenCalledWith(
+        expect.objectContaining({ userId: 'admin1', action: AuditAction.LEGAL_HOLD_PLACE }),
+      );
+    });
+  });
+
+  describe('releaseHold', () => {
+    it('保留记录不存在时返回 404', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce(null);
+
+      await expect(service.releaseHold('hold1', 'admin1')).rejects.toThrow(NotFoundException);
+    });
+
+    it('已解除的保留不能再次解除', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce({ ...hold, releasedAt: new Date() });
+
+      await expect(service.releaseHold('hold1', 'admin1')).rejects.toThrow(ConflictException);
+      expect(mockEntityManager.flush).not.toHaveBeenCalled();
+    });
+
+    it('记录解除人和时间并写入审计日志', async () => {
+      const active = { ...hold, releasedAt: undefined, releasedBy: undefined };
+      mockEntityManager.findOne.mockResolvedValueOnce(active);
+
+      await service.releaseHold('hold1', 'admin1');
+
+      expect(active.releasedAt).toBeInstanceOf(Date);
+      expect(active.releasedBy).toBe('admin1');
+      expect(mockEntityManager.flush).toHaveBeenCalled();
+      expect(mockAuditLogService.log).toHaveBeenCalledWith(
+        expect.objectContaining({ action: AuditAction.LEGAL_HOLD_RELEASE, resourceId: 'hold1' }),
+      );
+    });
+  });
+
+  it('保留期清理只排除未解除保留的用户，同一用户只返回一次', async () => {
+    rows.set(LegalHold, [{ userId: 'user1' }, { userId: 'user2' }, { userId: 'user1' }]);
+
+    await expect(service.getHeldUserIds()).resolves.toEqual(['user1', 'user2']);
+    expect(mockEntityManager.find).toHaveBeenCalledWith(LegalHold, { releasedAt: null }, { fields: ['userId'] });
+  });
+
+  it('已解除的保留不能导出', async () => {
+    mockEntityManager.findOne.mockResolvedValueOnce({ ...hold, releasedAt: new Date() });
+
+    await expect(service.createExport('hold1', 'admin1')).rejects.toThrow(BadRequestException);
+    expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+  });
+
+  const exportBundle = async () => {
+    mockEntityManager.findOne.mockImplementation(async (entity: unknown) => {
+      if (entity === LegalHold) {
+        return hold;
+      }
+      return entity === User ? { id: 'user1', email: 'user1@example.com' } : null;
+    });
+    const record = await service.createExport('hold1', 'admin1');
+    return JSON.parse(readFileSync(join(exportDir, record.fileName), 'utf8'));
+  };
+
+  it('导出已归档的文档时从归档取回原文、译文和任务原文', async () => {
+    const archivedAt = new Date('2026-01-01T00:00:00.000Z');
+    rows.set(TranslationTask, [{ id: 'doc1', userId: 'user1', content: '', status: 'completed' }]);
+    rows.set(UserJsonData, [
+      { id: 'doc1', userId: 'user1', originJson: '', translatedJson: null, archivedAt, archiveKey: 'archive/doc1' },
+    ]);
+    mockDocumentArchiveService.load.mockResolvedValueOnce({
+      originJson: '{"title":"Hello"}',
+      translatedJson: '{"title":"你好"}',
+      archived: true,
+      taskContent: '{"title":"Hello"}',
+    });
+
+    const bundle = await exportBundle();
+
+    expect(mockDocumentArchiveService.load).toHaveBeenCalledWith(expect.objectContaining({ id: 'doc1' }));
+    expect(bundle.data.documents).toEqual([
+      expect.objectContaining({
+        id: 'doc1',
+        originJson: '{"title":"Hello"}',
+        translatedJson: '{"title":"你好"}',
+        archived: true,
+      }),
+    ]);
+    expect(bundle.data.translationTasks).toEqual([
+      expect.objectContaining({ id: 'doc1', content: '{"title":"Hello"}' }),
+    ]);
+    expect(mockEntityManager.create).toHaveBeenCalledWith(
+      ComplianceExport,
+      expect.objectContaining({ userId: 'user1', legalHoldId: 'hold1', sha256: expect.any(String) }),
+    );
+  });
+
+  it('导出 webhook 配置和 API 密钥时不包含请求头、证书私钥和密钥本身', async () => {
+    rows.set(WebhookConfig, [
+      {
+        id: 'wh1',
+        userId: 'user1',
+        webhookUrl: 'https://example.com/hook',
+        authHeaders: 'encrypted-headers',
+        authHeaderNames: ['Authorization'],
+        tlsCa: '-----BEGIN CERTIFICATE-----',
+        tlsClientCert: '-----BEGIN CERTIFICATE-----',
+        tlsClientKey: 'encrypted-key',
+        tlsInfo: { subject: 'CN=client' },
+      },
+    ]);
+    rows.set(ApiKey, [{ id: 'key1', userId: 'user1', key: 'sk_live_abcdef', name: 'ci' }]);
+
+    const bundle = await exportBundle();
+
+    expect(bundle.data.webhookConfigs).toEqual([
+      expect.objectContaining({
+        id: 'wh1',
+        webhookUrl: 'https://example.com/hook',
+        authHeaderNames: ['Authorization'],
+        tlsInfo: { subject: 'CN=client' },
+      }),
+    ]);
+    for (const field of ['authHeaders', 'tlsCa', 'tlsClientCert', 'tlsClientKey']) {
+      expect(bundle.data.webhookConfigs[0]).not.toHaveProperty(field);
+    }
+    expect(bundle.data.apiKeys).toEqual([{ id: 'key1', userId: 'user1', name: 'ci', keyPreview: 'sk_l…' }]);
+  });
+
+  it('内容已按保留策略清除的文档只导出元数据', async () => {
+    rows.set(UserJsonData, [{ id: 'doc1', userId: 'user1', originJson: '', purgedAt: new Date() }]);
+    mockDocumentArchiveService.load.mockRejectedValueOnce(new GoneException('purged'));
+
+    const bundle = await exportBundle();
+
+    expect(bundle.data.documents).toEqual([expect.objectContaining({ id: 'doc1', originJson: null })]);
+  });
+});
@@ -0,0 +1,306 @@
+import {
+  BadRequestException,
+  ConflictException,
+  GoneException,
+  Injectable,
+  InternalServerErrorException,
+  Logger,
+  NotFoundException,
+} from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { ModuleRef } from '@nestjs/core';
+import { EntityManager } from '@mikro-orm/core';
+import { createHash } from 'crypto';
+import { promises as fs } from 'fs';
+import * as path from 'path';
+import { v4 as uuidv4 } from 'uuid';
+import { LegalHold } from '../entities/legal-hold.entity';
+import { ComplianceExport } from '../entities/compliance-export.entity';
+import { AuditLog, AuditAction, ResourceType, AuditSeverity } from '../entities/audit-log.entity';
+import { AuditLogService } from './audit-log.service';
+import { PlaceLegalHoldDto } from '../dto/legal-hold.dto';
+import { User } from '../../user/entities/user.entity';
+import { ApiKey } from '../../api-key/entities/api-key.entity';
+import { Tenant } from '../../tenant/entities/tenant.entity';
+import { WebhookConfig } from '../../webhook/entities/webhook-config.entity';
+import { SendRetry } from '../../translation/entities/send-retry.entity';
+import {
+  TranslationTask,
+  UserJsonData,
+  CharacterUsageLog,
+} from '../../translation/entities/translation-task.entity';
+import { DocumentArchiveService } from '../../translation/services/document-archive.service';
+
+/**
+ * 法律保留服务
+ * 为合规调查冻结用户数据（不参与保留期清理），并生成只读、带校验和的导出包
+ * 所有保留相关操作都会写入审计日志
+ */
+@Injectable()
+export class LegalHoldService {
+  private readonly logger = new Logger(LegalHoldService.name);
+  private readonly exportDir: string;
+
+  constructor(
+    private readonly em: EntityManager,
+    private readonly auditLogService: AuditLogService,
+    private readonly configService: ConfigService,
+    private readonly moduleRef: ModuleRef,
+  ) {
+    this.exportDir = this.configService.get('COMPLIANCE_EXPORT_DIR', 'storage/compliance-exports');
+  }
+
+  async placeHold(userId: string, dto: PlaceLegalHoldDto, adminId: string): Promise<LegalHold> {
+    const user = await this.em.findOne(User, { id: userId });
+    if (!user) {
+      throw new NotFoundException('User not found');
+    }
+    if (await this.getActiveHold(userId)) {
+      throw new ConflictException('User is already on legal hold');
+    }
+
+    const hold = this.em.create(LegalHold, {
+      userId,
+      reason: dto.reason,
+      caseReference: dto.caseReference,
+      placedBy: adminId,
+    });
+    await this.em.persistAndFlush(hold);
+
+    await this.auditLogService.log({
+      userId: adminId,
+      action: AuditAction.LEGAL_HOLD_PLACE,
+      resourceType: ResourceType.LEGAL_HOLD,
+      resourceId: hold.id,
+      newValues: { userId, reason: dto.reason, caseReference: dto.caseReference },
+      severity: AuditSeverity.HIGH,
+      description: `Legal hold placed on user ${userId}`,
+      tags: ['legal_hold'],
+    });
+    this.logger.log(`Legal hold ${hold.id} placed on user ${userId} by ${adminId}`);
+    return hold;
+  }
+
+  async releaseHold(holdId: string, adminId: string): Promise<LegalHold> {
+    const hold = await this.em.findOne(LegalHold, { id: holdId });
+    if (!hold) {
+      throw new NotFoundException('Legal hold not found');
+    }
+    if (hold.releasedAt) {
+      throw new ConflictException('Legal hold has already been released');
+    }
+
+    hold.releasedAt = new Date();
+    hold.releasedBy = adminId;
+    await this.em.flush();
+
+    await this.auditLogService.log({
+      userId: adminId,
+      action: AuditAction.LEGAL_HOLD_RELEASE,
+      resourceType: ResourceType.LEGAL_HOLD,
+      resourceId: hold.id,
+      oldValues: { userId: hold.userId },
+      severity: AuditSeverity.HIGH,
+      description: `Legal hold released for user ${hold.userId}`,
+      tags: ['legal_hold'],
+    });
+    return hold;
+  }
+
+  async listHolds(userId?: string): Promise<LegalHold[]> {
+    return this.em.find(LegalHold, userId ? { userId } : {}, { orderBy: { createdAt: 'DESC' } });
+  }
+
+  async isOnHold(userId: string): Promise<boolean> {
+    return !!(await this.getActiveHold(userId));
+  }
+
+  /**
+   * 当前处于保留状态的用户，供保留期清理任务排除
+   */
+  async getHeldUserIds(): Promise<string[]> {
+    const holds = await this.em.find(LegalHold, { releasedAt: null }, { fields: ['userId'] });
+    return [...new Set(holds.map((hold) => hold.userId))];
+  }
+
+  /**
+   * 生成导出包：用户的全部业务数据写成一个 JSON 文件，文件只读且不可覆盖
+   * 只能对处于保留状态的用户导出
+   */
+  async createExport(holdId: string, adminId: string): Promise<ComplianceExport> {
+    const hold = await this.em.findOne(LegalHold, { id: holdId });
+    if (!hold) {
+      throw new NotFoundException('Legal hold not found');
+    }
+    if (hold.releasedAt) {
+      throw new BadRequestException('Cannot export data for a released legal hold');
+    }
+
+    const id = uuidv4();
+    const bundle = {
+      manifest: {
+        exportId: id,
+        userId: hold.userId,
+        legalHoldId: hold.id,
+        caseReference: hold.caseReference ?? null,
+        generatedAt: new Date().toISOString(),
+        generatedBy: adminId,
+      },
+      data: await this.collectUserData(hold.userId),
+    };
+    const content = Buffer.from(JSON.stringify(bundle, null, 2));
+    const fileName = `${hold.userId}-${id}.json`;
+
+    await fs.mkdir(this.exportDir, { recursive: true });
+    await fs.writeFile(path.join(this.exportDir, fileName), content, { flag: 'wx', mode: 0o444 });
+
+    const record = this.em.create(ComplianceExport, {
+      id,
+      userId: hold.userId,
+      legalHoldId: hold.id,
+      requestedBy: adminId,
+      fileName,
+      sha256: createHash('sha256').update(content).digest('hex'),
+      sizeBytes: content.length,
+    });
+    await this.em.persistAndFlush(record);
+
+    await this.auditLogService.log({
+      userId: adminId,
+      action: AuditAction.EXPORT,
+      resourceType: ResourceType.COMPLIANCE_EXPORT,
+      resourceId: record.id,
+      newValues: { userId: hold.userId, legalHoldId: hold.id, sha256: record.sha256, sizeBytes: record.sizeBytes },
+      severity: AuditSeverity.HIGH,
+      description: `Compliance export generated for user ${hold.userId}`,
+      tags: ['legal_hold'],
+      containsPII: true,
+    });
+    return record;
+  }
+
+  async listExports(holdId: string): Promise<ComplianceExport[]> {
+    return this.em.find(ComplianceExport, { legalHoldId: holdId }, { orderBy: { createdAt: 'DESC' } });
+  }
+
+  /**
+   * 读取导出包，校验和不一致时拒绝返回
+   */
+  async readExport(exportId: string, adminId: string): Promise<{ record: ComplianceExport; content: Buffer }> {
+    const record = await this.em.findOne(ComplianceExport, { id: exportId });
+    if (!record) {
+      throw new NotFoundException('Export not found');
+    }
+
+    const content = await fs.readFile(path.join(this.exportDir, record.fileName));
+    const checksum = createHash('sha256').update(content).digest('hex');
+    if (checksum !== record.sha256) {
+      this.logger.error(`Checksum mismatch for compliance export ${record.id}`);
+      throw new InternalServerErrorException('Export bundle failed integrity check');
+    }
+
+    await this.auditLogService.log({
+      userId: adminId,
+      action: AuditAction.VIEW,
+      resourceType: ResourceType.COMPLIANCE_EXPORT,
+      resourceId: record.id,
+      description: `Compliance export downloaded for user ${record.userId}`,
+      tags: ['legal_hold'],
+      containsPII: true,
+    });
+    return { record, content };
+  }
+
+  private async getActiveHold(userId: string): Promise<LegalHold | null> {
+    return this.em.findOne(LegalHold, { userId, releasedAt: null });
+  }
+
+  private async collectUserData(userId: string): Promise<Record<string, unknown>> {
+    const [user, tasks, documents, usage, webhooks, apiKeys, tenants, auditLogs] = await Promise.all([
+      this.em.findOne(User, { id: userId }),
+      this.em.find(TranslationTask, { userId }),
+      this.em.find(UserJsonData, { userId }),
+      this.em.find(CharacterUsageLog, { userId }),
+      this.em.find(WebhookConfig, { userId }),
+      this.em.find(ApiKey, { userId }),
+      this.em.find(Tenant, { ownerId: userId }),
+      this.em.find(AuditLog, { userId }, { orderBy: { createdAt: 'ASC' } }),
+    ]);
+    const deliveries = webhooks.length
+      ? await this.em.find(SendRetry, { webhookId: { $in: webhooks.map((webhook) => webhook.id) } })
+      : [];
+
+    return {
+      user: user && {
+        id: user.id,
+        email: user.email,
+        firstName: user.firstName,
+        lastName: user.lastName,
+        provider: user.provider,
+        role: user.role,
+        isActive: user.isActive,
+        createdAt: user.createdAt,
+        lastLoginAt: user.lastLoginAt,
+      },
+      ...(await this.loadDocumentContent(tasks, documents)),
+      characterUsage: usage,
+      // 推送请求头、TLS 证书和私钥不导出，只保留展示用的名称和证书摘要
+      webhookConfigs: webhooks.map((webhook) => ({
+        id: webhook.id,
+        webhookUrl: webhook.webhookUrl,
+        tenantId: webhook.tenantId,
+        maxConcurrency: webhook.maxConcurrency,
+        maxPerMinute: webhook.maxPerMinute,
+        payloadFormat: webhook.payloadFormat,
+        includeDiff: webhook.includeDiff,
+        authHeaderNames: webhook.authHeaderNames,
+        tlsInfo: webhook.tlsInfo,
+        createdAt: webhook.createdAt,
+        updatedAt: webhook.updatedAt,
+      })),
+      webhookDeliveries: deliveries,
+      // 只保留密钥的元数据，不导出密钥本身
+      apiKeys: apiKeys.map(({ key, ...rest }) => ({ ...rest, keyPreview: `${key.slice(0, 4)}…` })),
+      tenants,
+      auditLogs: auditLogs.map(({ user: _user, ...rest }) => rest),
+    };
+  }
+
+  /**
+   * 已归档文档的原文、译文和任务原文在数据库中为空，从归档取回后写入导出包；
+   * 已按保留策略清除内容的文档只导出元数据。翻译模块依赖审计模块，这里按需解析归档服务避免循环依赖
+   */
+  private async loadDocumentContent(
+    tasks: TranslationTask[],
+    documents: UserJsonData[],
+  ): Promise<{ translationTasks: Record<string, unknown>[]; documents: Record<string, unknown>[] }> {
+    const documentArchiveService = this.moduleRef.get(DocumentArchiveService, { strict: false });
+    const taskContent = new Map<string, string>();
+    const exported: Record<string, unknown>[] = [];
+    for (const document of documents) {
+      try {
+        const content = await documentArchiveService.load(document);
+        if (content.taskContent !== undefined && content.taskContent !== null) {
+          taskContent.set(document.id, content.taskContent);
+        }
+        exported.push({
+          ...document,
+          originJson: content.originJson,
+          translatedJson: content.translatedJson,
+          archived: content.archived,
+        });
+      } catch (error) {
+        if (!(error instanceof GoneException)) {
+          throw error;
+        }
+        exported.push({ ...document, originJson: null, translatedJson: null });
+      }
+    }
+    return {
+      translationTasks: tasks.map((task) =>
+        taskContent.has(task.id) ? { ...task, content: taskContent.get(task.id) } : { ...task },
+      ),
+      documents: exported,
+    };
+  }
+}
@@ -2,6 +2,7 @@ import { Controller, Post, Body, HttpCode, HttpStatus, Get, UseGuards, Req, Res
 import { ApiTags, ApiOperation, ApiResponse } from '@nestjs/swagger';
 import { AuthGuard } from '@nestjs/passport';
 import { AuthService } from '../services/auth.service';
+import { ReadOnlySafe } from '../../../common/decorators/read-only-safe.decorator';
 import { Response } from 'express';
 
 class RegisterDto {
@@ -45,6 +46,7 @@ export class AuthController {
   }
 
   @Post('login')
+  @ReadOnlySafe()
   @HttpCode(HttpStatus.OK)
   @ApiOperation({ summary: '用户登录' })
   @ApiResponse({ status: 200, description: '登录成功' })
@@ -0,0 +1,67 @@
+import { ExecutionContext, ForbiddenException } from '@nestjs/common';
+import { Reflector } from '@nestjs/core';
+import { ApiKeyGuard } from './api-key.guard';
+import { PlaygroundAllowed } from '../../../common/decorators/playground-allowed.decorator';
+
+class TestController {
+  @PlaygroundAllowed()
+  createTask() {}
+
+  listDocuments() {}
+}
+
+describe('ApiKeyGuard', () => {
+  const mockApiKeyService = {
+    validateApiKey: jest.fn(),
+    validateSignedRequest: jest.fn(),
+  };
+  const guard = new ApiKeyGuard(mockApiKeyService as any, new Reflector());
+
+  const contextFor = (handler: () => void, request: any) =>
+    ({
+      switchToHttp: () => ({ getRequest: () => request }),
+      getHandler: () => handler,
+      getClass: () => TestController,
+    }) as unknown as ExecutionContext;
+
+  const keyRequest = () => ({ headers: { 'x-api-key': 'key' }, ip: '203.0.113.7' });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('普通密钥可以调用所有接口，并挂载用户和密钥上下文', async () => {
+    mockApiKeyService.validateApiKey.mockResolvedValueOnce({ id: 'key1', userId: 'user1' });
+    const request: any = keyRequest();
+
+    await expect(guard.canActivate(contextFor(TestController.prototype.listDocuments, request))).resolves.toBe(true);
+    expect(request.user).toEqual({ id: 'user1' });
+    expect(mockApiKeyService.validateApiKey).toHaveBeenCalledWith('key', '203.0.113.7');
+  });
+
+  it('试运行密钥只能调用 @PlaygroundAllowed() 标记的接口', async () => {
+    const playground = { id: 'key1', userId: 'demo', playground: true };
+    mockApiKeyService.validateApiKey.mockResolvedValue(playground);
+
+    await expect(guard.canActivate(contextFor(TestController.prototype.createTask, keyRequest()))).resolves.toBe(
+      true,
+    );
+    const request: any = keyRequest();
+    await expect(guard.canActivate(contextFor(TestController.prototype.listDocuments, request))).rejects.toThrow(
+      ForbiddenException,
+    );
+    expect(request.apiKey).toBeUndefined();
+  });
+
+  it('签名请求同样限制试运行密钥', async () => {
+    mockApiKeyService.validateSignedRequest.mockResolvedValueOnce({ id: 'key1', userId: 'demo', playground: true });
+    const request = {
+      headers: { 'x-api-key-id': 'key1', 'x-timestamp': '1', 'x-signature': 'sig' },
+      ip: '203.0.113.7',
+    };
+
+    await expect(guard.canActivate(contextFor(TestController.prototype.listDocuments, request))).rejects.toThrow(
+      ForbiddenException,
+    );
+  });
+});
@@ -1,18 +1,77 @@
-import { Injectable, CanActivate, ExecutionContext } from '@nestjs/common';
-import { ApiKeyService } from '../../user/api-key.service';
+import { Injectable, CanActivate, ExecutionContext, ForbiddenException, UnauthorizedException } from '@nestjs/common';
+import { Reflector } from '@nestjs/core';
+import { ApiKeyService } from '../../api-key/api-key.service';
+import { ApiKeyContext } from '../../api-key/interfaces/api-key-context.interface';
+import { measurePhase } from '../../../common/utils/request-timing';
+import { getClientIp } from '../../../common/utils/client-ip';
+import { PLAYGROUND_ALLOWED_KEY } from '../../../common/decorators/playground-allowed.decorator';
 
+export function isSignedRequest(headers: Record<string, any>): boolean {
+  return !!headers['x-api-key-id'] && !!headers['x-signature'];
+}
+
+/**
+ * API Key 认证
+ * 校验 X-API-Key 请求头，或签名请求（X-Api-Key-Id + X-Timestamp + X-Signature，
+ * 对方法、路径和查询、时间戳和原始请求体做 HMAC，密钥本身不出现在请求中），通过后在 request 上挂载 user 和 apiKey 上下文；
+ * 绑定了 IP 的密钥只接受来自该 IP 的请求；试运行密钥只能调用标记为 @PlaygroundAllowed() 的接口
+ */
 @Injectable()
 export class ApiKeyGuard implements CanActivate {
-  constructor(private readonly apiKeyService: ApiKeyService) {}
+  constructor(
+    private readonly apiKeyService: ApiKeyService,
+    private readonly reflector: Reflector,
+  ) {}
 
   async canActivate(context: ExecutionContext): Promise<boolean> {
     const request = context.switchToHttp().getRequest();
-    const apiKey = request.headers['x-api-key'];
+    if (isSignedRequest(request.headers)) {
+      const keyContext = await measurePhase('auth', () =>
+        this.apiKeyService.validateSignedRequest(
+          request.headers['x-api-key-id'],
+          request.headers['x-timestamp'],
+          request.headers['x-signature'],
+          { method: request.method, path: request.originalUrl ?? request.url, body: request.rawBody },
+          getClientIp(request),
+        ),
+      );
+      this.assertPlaygroundAllowed(context, keyContext);
+      request.user = { id: keyContext.userId };
+      request.apiKey = keyContext;
+      return true;
+    }
 
+    const apiKey = request.headers['x-api-key'];
     if (!apiKey) {
       return false;
     }
 
-    return this.apiKeyService.validateApiKey(apiKey);
+    const keyContext = await measurePhase('auth', () =>
+      this.apiKeyService.validateApiKey(apiKey, getClientIp(request)),
+    );
+    if (!keyContext) {
+      throw new UnauthorizedException('Invalid or expired API key');
+    }
+    this.assertPlaygroundAllowed(context, keyContext);
+
+    request.user = { id: keyContext.userId };
+    request.apiKey = keyContext;
+    return true;
+  }
+
+  /**
+   * 试运行密钥都属于同一个演示账号，只开放创建任务和读取自己任务的接口，避免访问其他访客的文档和账户设置
+   */
+  private assertPlaygroundAllowed(context: ExecutionContext, keyContext: ApiKeyContext): void {
+    if (!keyContext.playground) {
+      return;
+    }
+    const allowed = this.reflector.getAllAndOverride<boolean>(PLAYGROUND_ALLOWED_KEY, [
+      context.getHandler(),
+      context.getClass(),
+    ]);
+    if (!allowed) {
+      throw new ForbiddenException('Playground API keys can only create translation tasks and read their results');
+    }
   }
-} 
\ No newline at end of file
+}
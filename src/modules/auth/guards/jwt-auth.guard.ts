@@ -1,5 +1,10 @@
-import { Injectable } from '@nestjs/common';
+import { ExecutionContext, Injectable } from '@nestjs/common';
 import { AuthGuard } from '@nestjs/passport';
+import { measurePhase } from '../../../common/utils/request-timing';
 
 @Injectable()
-export class JwtAuthGuard extends AuthGuard('jwt') {} 
\ No newline at end of file
+export class JwtAuthGuard extends AuthGuard('jwt') {
+  async canActivate(context: ExecutionContext): Promise<boolean> {
+    return measurePhase('auth', async () => (await super.canActivate(context)) as boolean);
+  }
+}
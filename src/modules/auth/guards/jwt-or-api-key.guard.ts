@@ -0,0 +1,26 @@
+import { Injectable, CanActivate, ExecutionContext } from '@nestjs/common';
+import { Reflector } from '@nestjs/core';
+import { ApiKeyService } from '../../api-key/api-key.service';
+import { JwtAuthGuard } from './jwt-auth.guard';
+import { ApiKeyGuard, isSignedRequest } from './api-key.guard';
+
+/**
+ * 同时支持 JWT 和 API Key 的认证：携带 X-API-Key 或签名请求头时走密钥校验，否则走 JWT
+ */
+@Injectable()
+export class JwtOrApiKeyGuard implements CanActivate {
+  private readonly jwtAuthGuard = new JwtAuthGuard();
+  private readonly apiKeyGuard: ApiKeyGuard;
+
+  constructor(apiKeyService: ApiKeyService, reflector: Reflector) {
+    this.apiKeyGuard = new ApiKeyGuard(apiKeyService, reflector);
+  }
+
+  async canActivate(context: ExecutionContext): Promise<boolean> {
+    const request = context.switchToHttp().getRequest();
+    if (request.headers['x-api-key'] || isSignedRequest(request.headers)) {
+      return this.apiKeyGuard.canActivate(context);
+    }
+    return (await this.jwtAuthGuard.canActivate(context)) as boolean;
+  }
+}
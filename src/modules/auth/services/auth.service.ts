@@ -5,6 +5,7 @@ import * as bcrypt from 'bcrypt';
 import { User, AuthProvider } from '../../user/entities/user.entity';
 import { StripeService } from '../../subscription/services/stripe.service';
 import { v4 as uuidv4 } from 'uuid';
+import { isReadOnlyMode } from '../../../config/process-role';
 
 interface OAuthUserData {
   email: string;
@@ -73,9 +74,11 @@ export class AuthService {
       throw new UnauthorizedException('Invalid credentials');
     }
 
-    // 更新最后登录时间
-    user.lastLoginAt = new Date();
-    await this.em.persistAndFlush(user);
+    // 更新最后登录时间（只读副本不写库）
+    if (!isReadOnlyMode()) {
+      user.lastLoginAt = new Date();
+      await this.em.persistAndFlush(user);
+    }
 
     // 生成 JWT token
     const token = this.jwtService.sign({ sub: user.id, email: user.email });
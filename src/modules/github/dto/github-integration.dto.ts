@@ -0,0 +1,41 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { ArrayMaxSize, ArrayNotEmpty, IsArray, IsOptional, IsString, Matches, MaxLength } from 'class-validator';
+
+export class CreateGithubIntegrationDto {
+  @ApiProperty({ description: '仓库（owner/name）', example: 'acme/web-app' })
+  @Matches(/^[\w.-]+\/[\w.-]+$/, { message: 'repository must be in the form owner/name' })
+  repository: string;
+
+  @ApiProperty({ description: '监听的分支，默认 main', required: false, example: 'main' })
+  @IsOptional()
+  @IsString()
+  @MaxLength(255)
+  branch?: string;
+
+  @ApiProperty({ description: '源语言文件路径', example: 'locales/en.json' })
+  @IsString()
+  @MaxLength(500)
+  sourcePath: string;
+
+  @ApiProperty({ description: '目标语言文件路径模板，{lang} 替换为目标语言', example: 'locales/{lang}.json' })
+  @IsString()
+  @MaxLength(500)
+  @Matches(/\{lang\}/, { message: 'targetPathPattern must contain {lang}' })
+  targetPathPattern: string;
+
+  @ApiProperty({ description: '源语言', example: 'en' })
+  @IsString()
+  fromLang: string;
+
+  @ApiProperty({ description: '目标语言', type: [String], example: ['zh', 'ja'] })
+  @IsArray()
+  @ArrayNotEmpty()
+  @ArrayMaxSize(20)
+  @IsString({ each: true })
+  targetLangs: string[];
+
+  @ApiProperty({ description: '忽略翻译的字段，逗号分隔', required: false })
+  @IsOptional()
+  @IsString()
+  ignoredFields?: string;
+}
@@ -0,0 +1,78 @@
+import { Entity, PrimaryKey, Property } from '@mikro-orm/core';
+
+/**
+ * GitHub 仓库集成
+ * 监听源语言文件（如 locales/en.json）的 push，翻译变化的键并对目标语言文件发起 Pull Request
+ */
+@Entity()
+export class GithubIntegration {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  userId!: string;
+
+  @Property({ nullable: true })
+  tenantId?: string;
+
+  /** owner/name */
+  @Property()
+  repository!: string;
+
+  @Property()
+  branch: string = 'main';
+
+  @Property()
+  sourcePath!: string;
+
+  /** 目标文件路径模板，{lang} 替换为目标语言，如 locales/{lang}.json */
+  @Property()
+  targetPathPattern!: string;
+
+  @Property()
+  fromLang!: string;
+
+  /** 目标语言，逗号分隔 */
+  @Property()
+  targetLangs!: string;
+
+  @Property({ type: 'text', nullable: true })
+  ignoredFields?: string;
+
+  /** pending：等待 OAuth 授权；connected：已授权并创建了仓库 webhook */
+  @Property()
+  status: string = 'pending';
+
+  @Property({ type: 'text', nullable: true, hidden: true })
+  accessToken?: string;
+
+  @Property({ nullable: true })
+  githubLogin?: string;
+
+  @Property({ nullable: true })
+  hookId?: string;
+
+  @Property({ hidden: true })
+  webhookSecret!: string;
+
+  @Property()
+  isActive: boolean = true;
+
+  @Property({ nullable: true })
+  lastSyncedSha?: string;
+
+  @Property({ nullable: true })
+  lastPullRequestUrl?: string;
+
+  @Property({ nullable: true })
+  lastSyncedAt?: Date;
+
+  @Property({ type: 'text', nullable: true })
+  lastError?: string;
+
+  @Property()
+  createdAt: Date = new Date();
+
+  @Property({ onUpdate: () => new Date() })
+  updatedAt: Date = new Date();
+}
@@ -0,0 +1,101 @@
+import {
+  Body,
+  Controller,
+  Delete,
+  Get,
+  Headers,
+  HttpCode,
+  HttpStatus,
+  Param,
+  Post,
+  Query,
+  RawBodyRequest,
+  Req,
+  UseGuards,
+} from '@nestjs/common';
+import { ApiBearerAuth, ApiHeader, ApiOperation, ApiResponse, ApiSecurity, ApiTags } from '@nestjs/swagger';
+import { JwtOrApiKeyGuard } from '../auth/guards/jwt-or-api-key.guard';
+import { TenantService } from '../tenant/services/tenant.service';
+import { GithubIntegrationService } from './services/github-integration.service';
+import { CreateGithubIntegrationDto } from './dto/github-integration.dto';
+
+@ApiTags('github')
+@Controller('integrations/github')
+export class GithubController {
+  constructor(
+    private readonly githubIntegrationService: GithubIntegrationService,
+    private readonly tenantService: TenantService,
+  ) {}
+
+  @Post()
+  @ApiBearerAuth()
+  @ApiSecurity('api-key')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiOperation({ summary: '登记 GitHub 仓库的本地化文件，返回 OAuth 授权地址' })
+  @ApiResponse({ status: 201, description: '已登记，访问 authorizeUrl 完成授权后开始监听' })
+  @ApiResponse({ status: 400, description: '参数无效或服务端未配置 GitHub OAuth 应用' })
+  async create(@Req() req: any, @Body() dto: CreateGithubIntegrationDto) {
+    const tenant = await this.tenantService.resolveFromRequest(req);
+    return this.githubIntegrationService.create(req.user.id, dto, tenant?.id);
+  }
+
+  @Get()
+  @ApiBearerAuth()
+  @ApiSecurity('api-key')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiOperation({ summary: '获取 GitHub 集成列表' })
+  async list(@Req() req: any) {
+    return this.githubIntegrationService.list(req.user.id);
+  }
+
+  @Get('oauth/callback')
+  @ApiOperation({ summary: 'GitHub OAuth 回调' })
+  @ApiResponse({ status: 400, description: 'state 无效或已过期' })
+  async callback(@Query('code') code: string, @Query('state') state: string) {
+    return this.githubIntegrationService.completeAuthorization(code, state);
+  }
+
+  @Post('webhook/:id')
+  @HttpCode(HttpStatus.OK)
+  @ApiOperation({ summary: '接收 GitHub push 事件' })
+  @ApiHeader({ name: 'x-hub-signature-256', description: 'GitHub webhook 签名', required: true })
+  @ApiResponse({ status: 401, description: '签名无效' })
+  async webhook(
+    @Param('id') id: string,
+    @Headers('x-github-event') event: string,
+    @Headers('x-hub-signature-256') signature: string,
+    @Req() req: RawBodyRequest<any>,
+    @Body() payload: any,
+  ) {
+    return this.githubIntegrationService.handleWebhook(id, event, signature, req.rawBody, payload);
+  }
+
+  @Get(':id')
+  @ApiBearerAuth()
+  @ApiSecurity('api-key')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiOperation({ summary: '获取 GitHub 集成详情（含最近一次同步和 PR）' })
+  @ApiResponse({ status: 404, description: '不存在' })
+  async get(@Req() req: any, @Param('id') id: string) {
+    return this.githubIntegrationService.get(req.user.id, id);
+  }
+
+  @Post(':id/authorize')
+  @ApiBearerAuth()
+  @ApiSecurity('api-key')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiOperation({ summary: '重新生成 OAuth 授权地址' })
+  async reauthorize(@Req() req: any, @Param('id') id: string) {
+    return this.githubIntegrationService.reauthorize(req.user.id, id);
+  }
+
+  @Delete(':id')
+  @HttpCode(HttpStatus.NO_CONTENT)
+  @ApiBearerAuth()
+  @ApiSecurity('api-key')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiOperation({ summary: '断开 GitHub 集成并删除仓库 webhook' })
+  async remove(@Req() req: any, @Param('id') id: string) {
+    await this.githubIntegrationService.remove(req.user.id, id);
+  }
+}
@@ -0,0 +1,29 @@
+import { Module } from '@nestjs/common';
+import { MikroOrmModule } from '@mikro-orm/nestjs';
+import { BullModule } from '@nestjs/bull';
+import { HttpModule } from '@nestjs/axios';
+import { GithubController } from './github.controller';
+import { GithubIntegration } from './entities/github-integration.entity';
+import { GithubIntegrationRepository } from './repositories/github-integration.repository';
+import { GithubApiService } from './services/github-api.service';
+import { GithubIntegrationService } from './services/github-integration.service';
+import { TranslationModule } from '../translation/translation.module';
+import { ApiKeyModule } from '../api-key/api-key.module';
+import { TenantModule } from '../tenant/tenant.module';
+import { CommonModule } from '../../common/common.module';
+
+@Module({
+  imports: [
+    MikroOrmModule.forFeature([GithubIntegration]),
+    BullModule.registerQueue({ name: 'translation' }),
+    HttpModule,
+    TranslationModule,
+    ApiKeyModule,
+    TenantModule,
+    CommonModule,
+  ],
+  controllers: [GithubController],
+  providers: [GithubIntegrationService, GithubApiService, GithubIntegrationRepository],
+  exports: [GithubIntegrationService],
+})
+export class GithubModule {}
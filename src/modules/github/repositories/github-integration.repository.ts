@@ -0,0 +1,11 @@
+import { Injectable } from '@nestjs/common';
+import { EntityManager } from '@mikro-orm/core';
+import { DataRepository } from '../../../common/repositories/data.repository';
+import { GithubIntegration } from '../entities/github-integration.entity';
+
+@Injectable()
+export class GithubIntegrationRepository extends DataRepository<GithubIntegration> {
+  constructor(em: EntityManager) {
+    super(em, GithubIntegration, 'userId');
+  }
+}
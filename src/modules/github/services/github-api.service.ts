@@ -0,0 +1,179 @@
+import { Injectable } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { HttpService } from '@nestjs/axios';
+import { firstValueFrom } from 'rxjs';
+
+export interface GithubFile {
+  content: string;
+  sha: string;
+}
+
+/**
+ * GitHub REST API 的最小封装（OAuth 换取令牌、读写文件、分支、Pull Request、仓库 webhook）
+ */
+@Injectable()
+export class GithubApiService {
+  private readonly apiUrl: string;
+  private readonly oauthUrl: string;
+  private readonly timeoutMs: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly httpService: HttpService,
+  ) {
+    this.apiUrl = this.configService.get('GITHUB_API_URL', 'https://api.github.com');
+    this.oauthUrl = this.configService.get('GITHUB_OAUTH_URL', 'https://github.com');
+    this.timeoutMs = Number(this.configService.get('GITHUB_API_TIMEOUT_MS', 15000));
+  }
+
+  authorizeUrl(clientId: string, redirectUri: string, state: string): string {
+    const params = new URLSearchParams({ client_id: clientId, redirect_uri: redirectUri, scope: 'repo', state });
+    return `${this.oauthUrl}/login/oauth/authorize?${params.toString()}`;
+  }
+
+  async exchangeCode(clientId: string, clientSecret: string, code: string, redirectUri: string): Promise<string> {
+    const response = await firstValueFrom(
+      this.httpService.post(
+        `${this.oauthUrl}/login/oauth/access_token`,
+        { client_id: clientId, client_secret: clientSecret, code, redirect_uri: redirectUri },
+        { headers: { Accept: 'application/json' }, timeout: this.timeoutMs },
+      ),
+    );
+    if (!response.data?.access_token) {
+      throw new Error(`GitHub OAuth exchange failed: ${response.data?.error_description || response.data?.error}`);
+    }
+    return response.data.access_token;
+  }
+
+  async getLogin(token: string): Promise<string> {
+    const response = await this.request<any>(token, 'GET', '/user');
+    return response.data.login;
+  }
+
+  /**
+   * 读取文件，不存在时返回 null
+   */
+  async getFile(token: string, repository: string, path: string, ref: string): Promise<GithubFile | null> {
+    const response = await this.request<any>(
+      token,
+      'GET',
+      `/repos/${repository}/contents/${encodePath(path)}?ref=${encodeURIComponent(ref)}`,
+      undefined,
+      [404],
+    );
+    if (response.status === 404) {
+      return null;
+    }
+    return { content: Buffer.from(response.data.content, 'base64').toString('utf8'), sha: response.data.sha };
+  }
+
+  /**
+   * 从指定提交创建分支，分支已存在时强制指向该提交
+   */
+  async createBranch(token: string, repository: string, branch: string, sha: string): Promise<void> {
+    const response = await this.request(
+      token,
+      'POST',
+      `/repos/${repository}/git/refs`,
+      { ref: `refs/heads/${branch}`, sha },
+      [422],
+    );
+    if (response.status === 422) {
+      await this.request(token, 'PATCH', `/repos/${repository}/git/refs/heads/${encodePath(branch)}`, {
+        sha,
+        force: true,
+      });
+    }
+  }
+
+  async putFile(
+    token: string,
+    repository: string,
+    branch: string,
+    path: string,
+    content: string,
+    message: string,
+    sha?: string,
+  ): Promise<void> {
+    await this.request(token, 'PUT', `/repos/${repository}/contents/${encodePath(path)}`, {
+      message,
+      content: Buffer.from(content, 'utf8').toString('base64'),
+      branch,
+      ...(sha ? { sha } : {}),
+    });
+  }
+
+  /**
+   * 创建 Pull Request；同一分支已有打开的 PR 时返回它的地址
+   */
+  async createPullRequest(
+    token: string,
+    repository: string,
+    head: string,
+    base: string,
+    title: string,
+    body: string,
+  ): Promise<string> {
+    const response = await this.request<any>(
+      token,
+      'POST',
+      `/repos/${repository}/pulls`,
+      { head, base, title, body },
+      [422],
+    );
+    if (response.status !== 422) {
+      return response.data.html_url;
+    }
+    const [owner] = repository.split('/');
+    const existing = await this.request<any[]>(
+      token,
+      'GET',
+      `/repos/${repository}/pulls?state=open&head=${encodeURIComponent(`${owner}:${head}`)}`,
+    );
+    if (!existing.data.length) {
+      throw new Error(`GitHub rejected the pull request: ${JSON.stringify(response.data)}`);
+    }
+    return existing.data[0].html_url;
+  }
+
+  async createHook(token: string, repository: string, url: string, secret: string): Promise<string> {
+    const response = await this.request<any>(token, 'POST', `/repos/${repository}/hooks`, {
+      name: 'web',
+      active: true,
+      events: ['push'],
+      config: { url, secret, content_type: 'json', insecure_ssl: '0' },
+    });
+    return String(response.data.id);
+  }
+
+  async deleteHook(token: string, repository: string, hookId: string): Promise<void> {
+    await this.request(token, 'DELETE', `/repos/${repository}/hooks/${hookId}`, undefined, [404]);
+  }
+
+  private async request<T = any>(
+    token: string,
+    method: string,
+    path: string,
+    data?: any,
+    allowedStatuses: number[] = [],
+  ) {
+    return firstValueFrom(
+      this.httpService.request<T>({
+        method,
+        url: `${this.apiUrl}${path}`,
+        data,
+        timeout: this.timeoutMs,
+        headers: {
+          Authorization: `Bearer ${token}`,
+          Accept: 'application/vnd.github+json',
+          'X-GitHub-Api-Version': '2022-11-28',
+        },
+        validateStatus: (status) => (status >= 200 && status < 300) || allowedStatuses.includes(status),
+      }),
+    );
+  }
+}
+
+function encodePath(path: string): string {
+  return path.split('/').map(encodeURIComponent).join('/');
+}
@@ -0,0 +1,177 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { getQueueToken } from '@nestjs/bull';
+import { UnauthorizedException } from '@nestjs/common';
+import { createHmac } from 'crypto';
+import { GithubIntegrationService, GITHUB_SYNC_JOB } from './github-integration.service';
+import { GithubApiService } from './github-api.service';
+import { GithubIntegrationRepository } from '../repositories/github-integration.repository';
+import { IncrementalTranslationService } from '../../translation/services/incremental-translation.service';
+import { JsonLimitsService } from '../../translation/services/json-limits.service';
+import { AccountLockdownService } from '../../api-key/account-lockdown.service';
+import { RedisService } from '../../../common/services/redis.service';
+
+describe('GithubIntegrationService', () => {
+  let service: GithubIntegrationService;
+
+  const mockQueue = { add: jest.fn() };
+
+  const mockRepository = {
+    get: jest.fn(),
+    save: jest.fn(),
+  };
+
+  const mockGithubApi = {
+    getFile: jest.fn(),
+    createBranch: jest.fn(),
+    putFile: jest.fn(),
+    createPullRequest: jest.fn(),
+  };
+
+  const mockIncrementalTranslationService = {
+    translate: jest.fn(),
+  };
+
+  const mockAccountLockdownService = {
+    isLocked: jest.fn(),
+  };
+
+  const integration = () => ({
+    id: 'gh-1',
+    userId: 'user1',
+    repository: 'acme/web',
+    branch: 'main',
+    sourcePath: 'locales/en.json',
+    targetPathPattern: 'locales/{lang}.json',
+    fromLang: 'en',
+    targetLangs: 'fr',
+    accessToken: 'token',
+    webhookSecret: 'secret',
+    isActive: true,
+  });
+
+  const push = {
+    ref: 'refs/heads/main',
+    before: 'a'.repeat(40),
+    after: 'b'.repeat(40),
+    commits: [{ added: [], modified: ['locales/en.json'] }],
+  };
+
+  const sign = (body: Buffer) => `sha256=${createHmac('sha256', 'secret').update(body).digest('hex')}`;
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        GithubIntegrationService,
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+        { provide: getQueueToken('translation'), useValue: mockQueue },
+        { provide: GithubIntegrationRepository, useValue: mockRepository },
+        { provide: GithubApiService, useValue: mockGithubApi },
+        { provide: IncrementalTranslationService, useValue: mockIncrementalTranslationService },
+        { provide: AccountLockdownService, useValue: mockAccountLockdownService },
+        { provide: RedisService, useValue: {} },
+        JsonLimitsService,
+      ],
+    }).compile();
+
+    service = module.get<GithubIntegrationService>(GithubIntegrationService);
+    mockRepository.get.mockResolvedValue(integration());
+    mockAccountLockdownService.isLocked.mockResolvedValue(false);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  describe('handleWebhook', () => {
+    it('签名无效时应拒绝', async () => {
+      const body = Buffer.from(JSON.stringify(push));
+
+      await expect(service.handleWebhook('gh-1', 'push', 'sha256=bad', body, push)).rejects.toThrow(
+        UnauthorizedException,
+      );
+      expect(mockQueue.add).not.toHaveBeenCalled();
+    });
+
+    it('源文件在监听分支上变化时应按提交去重入队', async () => {
+      const body = Buffer.from(JSON.stringify(push));
+
+      const result = await service.handleWebhook('gh-1', 'push', sign(body), body, push);
+
+      expect(result.queued).toBe(true);
+      expect(mockQueue.add).toHaveBeenCalledWith(
+        GITHUB_SYNC_JOB,
+        { integrationId: 'gh-1', before: push.before, after: push.after },
+        { jobId: `github:gh-1:${push.after}` },
+      );
+    });
+
+    it('其他分支或未改动源文件的 push 应忽略', async () => {
+      const otherBranch = { ...push, ref: 'refs/heads/dev' };
+      const otherFile = { ...push, commits: [{ added: [], modified: ['README.md'] }] };
+
+      for (const payload of [otherBranch, otherFile]) {
+        const body = Buffer.from(JSON.stringify(payload));
+        expect((await service.handleWebhook('gh-1', 'push', sign(body), body, payload)).queued).toBe(false);
+      }
+      expect(mockQueue.add).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('sync', () => {
+    it('应翻译变化的键并补齐目标文件缺失的键，然后发起 PR', async () => {
+      mockGithubApi.getFile.mockImplementation(async (_token, _repo, path, ref) => {
+        if (path === 'locales/en.json') {
+          return ref === push.after
+            ? { content: '{"hello":"Hello","bye":"Goodbye!","new":"New"}', sha: 's2' }
+            : { content: '{"hello":"Hello","bye":"Goodbye"}', sha: 's1' };
+        }
+        return { content: '{"hello":"Bonjour","bye":"Au revoir"}', sha: 't1' };
+      });
+      mockIncrementalTranslationService.translate.mockResolvedValue({
+        translation: { hello: 'Bonjour', bye: 'Au revoir !', new: 'Nouveau' },
+        taskId: 'task-1',
+      });
+      mockGithubApi.createPullRequest.mockResolvedValue('https://github.com/acme/web/pull/7');
+
+      const result = await service.sync({ integrationId: 'gh-1', before: push.before, after: push.after });
+
+      expect(mockIncrementalTranslationService.translate).toHaveBeenCalledWith(
+        expect.objectContaining({ targetLang: 'fr', changed: [['bye'], ['new']] }),
+      );
+      expect(mockGithubApi.createBranch).toHaveBeenCalledWith('token', 'acme/web', 'json-translation/bbbbbbb', push.after);
+      expect(mockGithubApi.putFile).toHaveBeenCalledWith(
+        'token',
+        'acme/web',
+        'json-translation/bbbbbbb',
+        'locales/fr.json',
+        expect.stringContaining('"new": "Nouveau"'),
+        expect.any(String),
+        't1',
+      );
+      expect(result.pullRequestUrl).toBe('https://github.com/acme/web/pull/7');
+      expect(mockRepository.save).toHaveBeenCalledWith(
+        expect.objectContaining({ lastSyncedSha: push.after, lastPullRequestUrl: result.pullRequestUrl }),
+      );
+    });
+
+    it('源文件超过 JSON 限制时在解析前拒绝并记录错误', async () => {
+      mockGithubApi.getFile.mockResolvedValue({ content: `{"a":${'['.repeat(40)}${']'.repeat(40)}}`, sha: 's2' });
+
+      await expect(service.sync({ integrationId: 'gh-1', before: push.before, after: push.after })).rejects.toThrow(
+        'locales/en.json: JSON nesting exceeds 32 levels',
+      );
+      expect(mockIncrementalTranslationService.translate).not.toHaveBeenCalled();
+      expect(mockRepository.save).toHaveBeenCalledWith(
+        expect.objectContaining({ lastError: 'locales/en.json: JSON nesting exceeds 32 levels' }),
+      );
+    });
+
+    it('账号被锁定时不同步', async () => {
+      mockAccountLockdownService.isLocked.mockResolvedValue(true);
+
+      expect(await service.sync({ integrationId: 'gh-1', before: push.before, after: push.after })).toBeNull();
+      expect(mockGithubApi.getFile).not.toHaveBeenCalled();
+    });
+  });
+});
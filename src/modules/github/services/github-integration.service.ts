@@ -0,0 +1,403 @@
+import {
+  BadRequestException,
+  Injectable,
+  Logger,
+  NotFoundException,
+  UnauthorizedException,
+} from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { InjectQueue } from '@nestjs/bull';
+import { Queue } from 'bull';
+import { createHmac, randomBytes, timingSafeEqual } from 'crypto';
+import { v4 as uuidv4 } from 'uuid';
+import { GithubIntegration } from '../entities/github-integration.entity';
+import { GithubIntegrationRepository } from '../repositories/github-integration.repository';
+import { CreateGithubIntegrationDto } from '../dto/github-integration.dto';
+import { GithubApiService } from './github-api.service';
+import { IncrementalTranslationService } from '../../translation/services/incremental-translation.service';
+import { JsonLimitsService } from '../../translation/services/json-limits.service';
+import { diffJson, flattenJson, isPlainObject, JsonPath, pathKey } from '../../translation/utils/json-diff';
+import { AccountLockdownService } from '../../api-key/account-lockdown.service';
+import { RedisService } from '../../../common/services/redis.service';
+
+export const GITHUB_SYNC_JOB = 'github-sync';
+
+const OAUTH_STATE_TTL_SECONDS = 600;
+const EMPTY_SHA = /^0+$/;
+
+export interface GithubSyncJob {
+  integrationId: string;
+  before: string;
+  after: string;
+}
+
+export interface GithubSyncResult {
+  pullRequestUrl?: string;
+  files: string[];
+  taskIds: Record<string, string>;
+}
+
+/**
+ * GitHub 集成
+ * 用户通过 OAuth 授权后在仓库上创建 push webhook；源语言文件变化时翻译变化的键，
+ * 把更新后的目标语言文件提交到独立分支并发起 Pull Request
+ */
+@Injectable()
+export class GithubIntegrationService {
+  private readonly logger = new Logger(GithubIntegrationService.name);
+  private readonly clientId: string;
+  private readonly clientSecret: string;
+  private readonly callbackUrl: string;
+  private readonly webhookUrl: string;
+  private readonly branchPrefix: string;
+
+  constructor(
+    private readonly configService: ConfigService,
+    @InjectQueue('translation') private readonly translationQueue: Queue,
+    private readonly integrationRepository: GithubIntegrationRepository,
+    private readonly githubApi: GithubApiService,
+    private readonly incrementalTranslationService: IncrementalTranslationService,
+    private readonly accountLockdownService: AccountLockdownService,
+    private readonly redisService: RedisService,
+    private readonly jsonLimitsService: JsonLimitsService,
+  ) {
+    this.clientId = this.configService.get('GITHUB_CLIENT_ID');
+    this.clientSecret = this.configService.get('GITHUB_CLIENT_SECRET');
+    this.callbackUrl = this.configService.get('GITHUB_OAUTH_CALLBACK_URL');
+    this.webhookUrl = this.configService.get('GITHUB_WEBHOOK_URL');
+    this.branchPrefix = this.configService.get('GITHUB_BRANCH_PREFIX', 'json-translation');
+  }
+
+  /**
+   * 登记仓库并返回 OAuth 授权地址，授权完成后才开始监听
+   */
+  async create(userId: string, dto: CreateGithubIntegrationDto, tenantId?: string) {
+    this.assertConfigured();
+    if (dto.sourcePath === dto.targetPathPattern) {
+      throw new BadRequestException('targetPathPattern must differ from sourcePath');
+    }
+
+    const integration = await this.integrationRepository.insert({
+      id: uuidv4(),
+      userId,
+      tenantId,
+      repository: dto.repository,
+      branch: dto.branch || 'main',
+      sourcePath: dto.sourcePath.replace(/^\/+/, ''),
+      targetPathPattern: dto.targetPathPattern.replace(/^\/+/, ''),
+      fromLang: dto.fromLang,
+      targetLangs: [...new Set(dto.targetLangs)].join(','),
+      ignoredFields: dto.ignoredFields,
+      status: 'pending',
+      webhookSecret: randomBytes(32).toString('hex'),
+      isActive: true,
+    });
+    return { ...this.toView(integration), authorizeUrl: await this.authorizeUrl(integration) };
+  }
+
+  /**
+   * 重新授权（令牌被撤销或需要更换授权账号时）
+   */
+  async reauthorize(userId: string, id: string) {
+    this.assertConfigured();
+    const integration = await this.getOwned(userId, id);
+    return { authorizeUrl: await this.authorizeUrl(integration) };
+  }
+
+  /**
+   * OAuth 回调：换取令牌并在仓库上创建 push webhook
+   */
+  async completeAuthorization(code: string, state: string) {
+    this.assertConfigured();
+    const stateKey = `github_oauth_state:${state}`;
+    const integrationId = await this.redisService.getJson<string>(stateKey);
+    if (!integrationId) {
+      throw new BadRequestException('Authorization state is invalid or has expired');
+    }
+    await this.redisService.del(stateKey);
+
+    const integration = await this.integrationRepository.get({ id: integrationId, isActive: true });
+    if (!integration) {
+      throw new NotFoundException('GitHub integration not found');
+    }
+
+    const token = await this.githubApi.exchangeCode(this.clientId, this.clientSecret, code, this.callbackUrl);
+    if (integration.hookId && integration.accessToken) {
+      await this.githubApi
+        .deleteHook(integration.accessToken, integration.repository, integration.hookId)
+        .catch((error) => this.logger.warn(`Failed to remove previous GitHub hook: ${error.message}`));
+    }
+    integration.accessToken = token;
+    integration.githubLogin = await this.githubApi.getLogin(token);
+    integration.hookId = await this.githubApi.createHook(
+      token,
+      integration.repository,
+      `${this.webhookUrl}/${integration.id}`,
+      integration.webhookSecret,
+    );
+    integration.status = 'connected';
+    integration.lastError = undefined;
+    await this.integrationRepository.save(integration);
+    this.logger.log(`GitHub integration ${integration.id} connected to ${integration.repository}`);
+    return this.toView(integration);
+  }
+
+  async list(userId: string) {
+    const integrations = await this.integrationRepository.list(
+      { userId, isActive: true },
+      { orderBy: { createdAt: 'DESC' } },
+    );
+    return integrations.map((integration) => this.toView(integration));
+  }
+
+  async get(userId: string, id: string) {
+    return this.toView(await this.getOwned(userId, id));
+  }
+
+  async remove(userId: string, id: string): Promise<void> {
+    const integration = await this.getOwned(userId, id);
+    if (integration.hookId && integration.accessToken) {
+      await this.githubApi
+        .deleteHook(integration.accessToken, integration.repository, integration.hookId)
+        .catch((error) => this.logger.warn(`Failed to remove GitHub hook ${integration.hookId}: ${error.message}`));
+    }
+    integration.isActive = false;
+    integration.accessToken = undefined;
+    integration.hookId = undefined;
+    await this.integrationRepository.save(integration);
+  }
+
+  /**
+   * 处理 GitHub webhook：校验签名，源文件在监听分支上发生变化时加入同步队列
+   */
+  async handleWebhook(
+    id: string,
+    event: string,
+    signature: string,
+    rawBody: Buffer,
+    payload: any,
+  ): Promise<{ queued: boolean; reason?: string }> {
+    const integration = await this.integrationRepository.get({ id, isActive: true });
+    if (!integration) {
+      throw new NotFoundException('GitHub integration not found');
+    }
+    if (!verifyGithubSignature(integration.webhookSecret, rawBody, signature)) {
+      throw new UnauthorizedException('Invalid GitHub webhook signature');
+    }
+
+    if (event !== 'push') {
+      return { queued: false, reason: `ignored event ${event}` };
+    }
+    if (payload.ref !== `refs/heads/${integration.branch}` || payload.deleted) {
+      return { queued: false, reason: 'not the watched branch' };
+    }
+    const touched = (payload.commits || []).some((commit: any) =>
+      [...(commit.added || []), ...(commit.modified || [])].includes(integration.sourcePath),
+    );
+    if (!touched) {
+      return { queued: false, reason: 'source file unchanged' };
+    }
+
+    const job: GithubSyncJob = { integrationId: integration.id, before: payload.before, after: payload.after };
+    // GitHub 重投同一 push 时按提交去重
+    await this.translationQueue.add(GITHUB_SYNC_JOB, job, { jobId: `github:${integration.id}:${payload.after}` });
+    return { queued: true };
+  }
+
+  /**
+   * 翻译一次 push 带来的源文件变化并发起 Pull Request（由 translation 队列消费者调用）
+   */
+  async sync(job: GithubSyncJob): Promise<GithubSyncResult | null> {
+    const integration = await this.integrationRepository.get({ id: job.integrationId, isActive: true });
+    if (!integration?.accessToken) {
+      return null;
+    }
+    if (await this.accountLockdownService.isLocked(integration.userId)) {
+      this.logger.warn(`Skipping GitHub sync ${integration.id}: account ${integration.userId} is locked`);
+      return null;
+    }
+
+    try {
+      const result = await this.translatePush(integration, job);
+      integration.lastSyncedSha = job.after;
+      integration.lastSyncedAt = new Date();
+      integration.lastPullRequestUrl = result.pullRequestUrl ?? integration.lastPullRequestUrl;
+      integration.lastError = undefined;
+      await this.integrationRepository.save(integration);
+      return result;
+    } catch (error) {
+      integration.lastError = error.message;
+      await this.integrationRepository.save(integration);
+      throw error;
+    }
+  }
+
+  private async translatePush(integration: GithubIntegration, job: GithubSyncJob): Promise<GithubSyncResult> {
+    const token = integration.accessToken;
+    const sourceFile = await this.githubApi.getFile(token, integration.repository, integration.sourcePath, job.after);
+    if (!sourceFile) {
+      return { files: [], taskIds: {} };
+    }
+    const source = this.parseFile(sourceFile.content, integration.sourcePath);
+    const previousFile =
+      job.before && !EMPTY_SHA.test(job.before)
+        ? await this.githubApi.getFile(token, integration.repository, integration.sourcePath, job.before)
+        : null;
+    const diff = diffJson(previousFile ? this.parseFile(previousFile.content, integration.sourcePath) : {}, source);
+
+    const branch = `${this.branchPrefix}/${job.after.slice(0, 7)}`;
+    const updates: Array<{ path: string; content: string; sha?: string }> = [];
+    const taskIds: Record<string, string> = {};
+
+    for (const lang of integration.targetLangs.split(',')) {
+      const path = integration.targetPathPattern.replace(/\{lang\}/g, lang);
+      const targetFile = await this.githubApi.getFile(token, integration.repository, path, job.after);
+      const previousTranslation = targetFile ? this.parseFile(targetFile.content, path) : undefined;
+      // 目标文件中缺失的键（例如上一次的 PR 未合并）也一并补齐
+      const changed = previousTranslation ? withMissingKeys(diff.changed, source, previousTranslation) : diff.changed;
+      if (previousTranslation && changed.length === 0 && diff.removed.length === 0) {
+        continue;
+      }
+
+      const { translation, taskId } = await this.incrementalTranslationService.translate({
+        userId: integration.userId,
+        tenantId: integration.tenantId,
+        fromLang: integration.fromLang,
+        targetLang: lang,
+        ignoredFields: integration.ignoredFields,
+        source,
+        raw: sourceFile.content,
+        changed,
+        previousTranslation,
+      });
+      const content = `${JSON.stringify(translation, null, 2)}\n`;
+      if (targetFile && content === targetFile.content) {
+        continue;
+      }
+      updates.push({ path, content, sha: targetFile?.sha });
+      if (taskId) {
+        taskIds[lang] = taskId;
+      }
+    }
+
+    if (updates.length === 0) {
+      return { files: [], taskIds };
+    }
+
+    await this.githubApi.createBranch(token, integration.repository, branch, job.after);
+    for (const update of updates) {
+      await this.githubApi.putFile(
+        token,
+        integration.repository,
+        branch,
+        update.path,
+        update.content,
+        `Update ${update.path} from ${integration.sourcePath}`,
+        update.sha,
+      );
+    }
+    const pullRequestUrl = await this.githubApi.createPullRequest(
+      token,
+      integration.repository,
+      branch,
+      integration.branch,
+      `Update translations for ${integration.sourcePath} (${job.after.slice(0, 7)})`,
+      [
+        `Translated ${diff.changed.length} changed key(s) from \`${integration.sourcePath}\`` +
+          (diff.removed.length ? ` and removed ${diff.removed.length} key(s).` : '.'),
+        '',
+        ...updates.map((update) => `- \`${update.path}\``),
+      ].join('\n'),
+    );
+    this.logger.log(`GitHub sync ${integration.id} opened ${pullRequestUrl} with ${updates.length} file(s)`);
+    return { pullRequestUrl, files: updates.map((update) => update.path), taskIds };
+  }
+
+  private async authorizeUrl(integration: GithubIntegration): Promise<string> {
+    const state = randomBytes(16).toString('hex');
+    await this.redisService.setJson(`github_oauth_state:${state}`, integration.id, OAUTH_STATE_TTL_SECONDS);
+    return this.githubApi.authorizeUrl(this.clientId, this.callbackUrl, state);
+  }
+
+  private assertConfigured(): void {
+    if (!this.clientId || !this.clientSecret || !this.callbackUrl || !this.webhookUrl) {
+      throw new BadRequestException('GitHub integration is not configured');
+    }
+  }
+
+  private async getOwned(userId: string, id: string): Promise<GithubIntegration> {
+    const integration = await this.integrationRepository.get({ id, userId, isActive: true });
+    if (!integration) {
+      throw new NotFoundException('GitHub integration not found');
+    }
+    return integration;
+  }
+
+  private toView(integration: GithubIntegration) {
+    return {
+      id: integration.id,
+      repository: integration.repository,
+      branch: integration.branch,
+      sourcePath: integration.sourcePath,
+      targetPathPattern: integration.targetPathPattern,
+      fromLang: integration.fromLang,
+      targetLangs: integration.targetLangs.split(','),
+      ignoredFields: integration.ignoredFields,
+      status: integration.status,
+      githubLogin: integration.githubLogin,
+      lastSyncedSha: integration.lastSyncedSha,
+      lastSyncedAt: integration.lastSyncedAt,
+      lastPullRequestUrl: integration.lastPullRequestUrl,
+      lastError: integration.lastError,
+      createdAt: integration.createdAt,
+    };
+  }
+
+  /**
+   * 解析前按 JSON 大小、嵌套深度和键数量限制检查，超限的文件不进入 JSON.parse 和翻译
+   */
+  private parseFile(content: string, path: string): Record<string, any> {
+    const violation = this.jsonLimitsService.check(content);
+    if (violation) {
+      throw new Error(`${path}: ${violation.message}`);
+    }
+    return parseLocaleFile(content, path);
+  }
+}
+
+/**
+ * 校验 X-Hub-Signature-256（sha256=<对原始请求体的 HMAC-SHA256>）
+ */
+export function verifyGithubSignature(secret: string, body: Buffer, signature?: string): boolean {
+  if (!signature || !body) {
+    return false;
+  }
+  const expected = Buffer.from(`sha256=${createHmac('sha256', secret).update(body).digest('hex')}`);
+  const received = Buffer.from(signature);
+  return expected.length === received.length && timingSafeEqual(expected, received);
+}
+
+function parseLocaleFile(content: string, path: string): Record<string, any> {
+  let parsed: any;
+  try {
+    parsed = JSON.parse(content);
+  } catch {
+    throw new Error(`${path} is not valid JSON`);
+  }
+  if (!isPlainObject(parsed)) {
+    throw new Error(`${path} must contain a JSON object`);
+  }
+  return parsed;
+}
+
+function withMissingKeys(changed: JsonPath[], source: any, translation: any): JsonPath[] {
+  const keys = new Set(changed.map(pathKey));
+  const existing = flattenJson(translation);
+  const result = [...changed];
+  for (const key of flattenJson(source).keys()) {
+    if (!existing.has(key) && !keys.has(key)) {
+      result.push(JSON.parse(key));
+    }
+  }
+  return result;
+}
@@ -0,0 +1,92 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { HEADERS_METADATA } from '@nestjs/common/constants';
+import { plainToInstance } from 'class-transformer';
+import { validate } from 'class-validator';
+import { MetaController } from '../meta.controller';
+import { EgressIpsService } from '../../services/egress-ips.service';
+import { ApiChangelogService } from '../../services/api-changelog.service';
+import { FILE_FORMATS } from '../../../../config/file-formats';
+import { TranslationPayload } from '../../../translation/dto/translation-task.dto';
+
+describe('MetaController', () => {
+  let controller: MetaController;
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      controllers: [MetaController],
+      providers: [
+        { provide: EgressIpsService, useValue: { get: jest.fn() } },
+        ApiChangelogService,
+      ],
+    }).compile();
+
+    controller = module.get<MetaController>(MetaController);
+  });
+
+  // 只校验指定字段，忽略请求体中其他必填字段
+  const invalidFields = async (body: Record<string, unknown>) => {
+    const errors = await validate(plainToInstance(TranslationPayload, body), { skipMissingProperties: true });
+    return errors.map((error) => error.property);
+  };
+
+  describe('getFormats', () => {
+    it('原样返回格式登记表，并允许公开缓存', () => {
+      expect(controller.getFormats()).toEqual({ formats: FILE_FORMATS });
+      expect(Reflect.getMetadata(HEADERS_METADATA, MetaController.prototype.getFormats)).toEqual([
+        { name: 'Cache-Control', value: 'public, max-age=3600' },
+      ]);
+    });
+
+    it('格式 ID 和扩展名唯一，目前只支持 JSON 的输入和输出', () => {
+      const ids = FILE_FORMATS.map((format) => format.id);
+      const extensions = FILE_FORMATS.flatMap((format) => format.extensions);
+
+      expect(new Set(ids).size).toBe(ids.length);
+      expect(new Set(extensions).size).toBe(extensions.length);
+      expect(FILE_FORMATS.filter((format) => format.input || format.output).map((format) => format.id)).toEqual([
+        'json',
+      ]);
+    });
+
+    it('已规划但尚不支持的格式不列出选项', () => {
+      for (const format of FILE_FORMATS.filter((item) => !item.input && !item.output)) {
+        expect(format.options).toEqual([]);
+      }
+    });
+
+    it('枚举选项的默认值在可选值中', () => {
+      for (const option of FILE_FORMATS.flatMap((format) => format.options)) {
+        if (option.type === 'enum' && option.default !== undefined) {
+          expect(option.values).toContain(option.default);
+        }
+      }
+    });
+
+    it('列出的输入选项和可选值都能通过创建任务的参数校验', async () => {
+      const inputOptions = FILE_FORMATS.flatMap((format) => format.options).filter(
+        (option) => option.scope === 'input',
+      );
+
+      for (const option of inputOptions) {
+        const samples =
+          option.type === 'boolean'
+            ? [true, false]
+            : option.values.map((value) => (option.type === 'enum[]' ? [value] : value));
+        for (const sample of samples) {
+          expect(await invalidFields({ [option.name]: sample })).not.toContain(option.name);
+        }
+        expect(await invalidFields({ [option.name]: option.type === 'enum[]' ? ['unknown'] : 'unknown' })).toContain(
+          option.name,
+        );
+      }
+    });
+  });
+
+  it('变更记录中登记了格式列表接口', () => {
+    const { entries } = controller.getChangelog();
+
+    expect(entries).toContainEqual(
+      expect.objectContaining({ endpoint: { method: 'GET', path: '/api/v1/meta/formats' } }),
+    );
+  });
+});
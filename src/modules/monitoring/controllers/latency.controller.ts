@@ -0,0 +1,20 @@
+import { Controller, Get, UseGuards } from '@nestjs/common';
+import { ApiTags, ApiOperation, ApiResponse, ApiBearerAuth } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../../auth/guards/admin.guard';
+import { LatencyBudgetService } from '../services/latency-budget.service';
+
+@ApiTags('admin')
+@Controller('admin/latency')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard, AdminGuard)
+export class LatencyController {
+  constructor(private readonly latencyBudgetService: LatencyBudgetService) {}
+
+  @Get()
+  @ApiOperation({ summary: '获取各路由的耗时预算和超预算次数' })
+  @ApiResponse({ status: 200, description: '返回进程启动以来的累计统计' })
+  getLatencyStats() {
+    return this.latencyBudgetService.getStats();
+  }
+}
@@ -0,0 +1,47 @@
+import { BadRequestException, Controller, Get, Header, Query } from '@nestjs/common';
+import { ApiTags, ApiOperation, ApiQuery, ApiResponse } from '@nestjs/swagger';
+import { EgressIpsService } from '../services/egress-ips.service';
+import { ApiChangelogService } from '../services/api-changelog.service';
+import { FILE_FORMATS } from '../../../config/file-formats';
+
+/**
+ * 公开的服务元信息，无需认证
+ */
+@ApiTags('meta')
+@Controller('meta')
+export class MetaController {
+  constructor(
+    private readonly egressIpsService: EgressIpsService,
+    private readonly apiChangelogService: ApiChangelogService,
+  ) {}
+
+  @Get('egress_ips')
+  @Header('Cache-Control', 'public, max-age=3600')
+  @ApiOperation({ summary: '获取 webhook 推送和翻译服务调用使用的固定出口 IP，用于防火墙白名单' })
+  @ApiResponse({ status: 200, description: '返回按用途分组的 IP / CIDR 列表' })
+  getEgressIps() {
+    return this.egressIpsService.get();
+  }
+
+  @Get('changelog')
+  @Header('Cache-Control', 'public, max-age=3600')
+  @ApiOperation({ summary: '获取机器可读的 API 变更记录，包括接口和推送载荷的弃用及下线计划' })
+  @ApiQuery({ name: 'since', required: false, description: '只返回该日期（YYYY-MM-DD，含）之后的变更' })
+  @ApiResponse({ status: 200, description: '按日期倒序返回变更条目' })
+  @ApiResponse({ status: 400, description: 'since 格式错误' })
+  getChangelog(@Query('since') since?: string) {
+    if (since !== undefined && !/^\d{4}-\d{2}-\d{2}$/.test(since)) {
+      throw new BadRequestException('since must be a date in YYYY-MM-DD format');
+    }
+    const entries = this.apiChangelogService.list(since);
+    return { entries, deprecated: entries.filter((entry) => entry.deprecation).length };
+  }
+
+  @Get('formats')
+  @Header('Cache-Control', 'public, max-age=3600')
+  @ApiOperation({ summary: '获取支持的输入 / 输出文件格式及各格式的选项' })
+  @ApiResponse({ status: 200, description: 'input / output 都为 false 的格式已规划但尚不支持' })
+  getFormats() {
+    return { formats: FILE_FORMATS };
+  }
+}
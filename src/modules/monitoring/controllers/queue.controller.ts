@@ -0,0 +1,43 @@
+import { Controller, DefaultValuePipe, Get, Param, ParseIntPipe, Post, Query, Req, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiParam, ApiQuery, ApiResponse, ApiTags } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../../auth/guards/admin.guard';
+import { QueueInspectorService } from '../services/queue-inspector.service';
+
+@ApiTags('admin')
+@Controller('admin/queues')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard, AdminGuard)
+export class QueueController {
+  constructor(private readonly queueInspectorService: QueueInspectorService) {}
+
+  @Get()
+  @ApiOperation({ summary: '各队列的等待、执行中、等待重试和失败任务数' })
+  @ApiResponse({ status: 200, description: '只包含当前进程注册的队列' })
+  async getStats() {
+    return this.queueInspectorService.getStats();
+  }
+
+  @Get(':name/failed')
+  @ApiOperation({ summary: '最近失败的任务及失败原因' })
+  @ApiParam({ name: 'name', description: '队列名称，如 translation、webhook' })
+  @ApiQuery({ name: 'limit', required: false, description: '返回条数，最多 100' })
+  @ApiResponse({ status: 404, description: '队列不存在' })
+  async getFailedJobs(
+    @Param('name') name: string,
+    @Query('limit', new DefaultValuePipe(20), ParseIntPipe) limit: number,
+  ) {
+    return this.queueInspectorService.getFailedJobs(name, Math.min(Math.max(limit, 1), 100));
+  }
+
+  @Post(':name/failed/:jobId/retry')
+  @ApiOperation({ summary: '把失败的任务重新入队' })
+  @ApiParam({ name: 'name', description: '队列名称' })
+  @ApiParam({ name: 'jobId', description: '任务 ID' })
+  @ApiResponse({ status: 201, description: '已重新入队' })
+  @ApiResponse({ status: 404, description: '队列或任务不存在' })
+  @ApiResponse({ status: 409, description: '任务不是失败状态' })
+  async requeue(@Req() req: any, @Param('name') name: string, @Param('jobId') jobId: string) {
+    return this.queueInspectorService.requeue(name, jobId, req.user.id);
+  }
+}
@@ -0,0 +1,29 @@
+import { CallHandler, ExecutionContext, Injectable, NestInterceptor } from '@nestjs/common';
+import { Request, Response } from 'express';
+import { Observable } from 'rxjs';
+import { ApiChangelogService } from '../services/api-changelog.service';
+
+/**
+ * 为变更登记表中已弃用的接口附加 Deprecation / Sunset / Link 响应头
+ * 使用拦截器而不是中间件：路由匹配后才能拿到路由模板（如 /api/v1/translation/:id）；
+ * 响应头在处理函数执行前写入，出错的响应同样带有
+ */
+@Injectable()
+export class DeprecationHeadersInterceptor implements NestInterceptor {
+  constructor(private readonly apiChangelogService: ApiChangelogService) {}
+
+  intercept(context: ExecutionContext, next: CallHandler): Observable<any> {
+    if (context.getType() === 'http') {
+      const req = context.switchToHttp().getRequest<Request>();
+      const routePath = req.route?.path ? `${req.baseUrl}${req.route.path}` : undefined;
+      const headers = routePath ? this.apiChangelogService.deprecationHeaders(req.method, routePath) : null;
+      if (headers) {
+        const res = context.switchToHttp().getResponse<Response>();
+        for (const [name, value] of Object.entries(headers)) {
+          res.setHeader(name, value);
+        }
+      }
+    }
+    return next.handle();
+  }
+}
@@ -0,0 +1,62 @@
+import { EventEmitter } from 'events';
+import { LatencyBudgetMiddleware } from '../latency-budget.middleware';
+import { LatencyBudgetService } from '../../services/latency-budget.service';
+import { currentRequestTiming, measurePhase } from '../../../../common/utils/request-timing';
+
+describe('LatencyBudgetMiddleware', () => {
+  const record = jest.fn();
+  const middleware = new LatencyBudgetMiddleware({ record } as unknown as LatencyBudgetService);
+
+  const response = () => Object.assign(new EventEmitter(), { statusCode: 200 });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('请求结束时按匹配到的路由模板记录，并带上各阶段耗时和用户', async () => {
+    const req: any = {
+      method: 'GET',
+      baseUrl: '/api/v1/translation',
+      route: { path: '/:id' },
+      originalUrl: '/api/v1/translation/abc?include=result',
+      user: { id: 'user1' },
+    };
+    const res = response();
+
+    await new Promise<void>((resolve) =>
+      middleware.use(req, res as any, async () => {
+        await measurePhase('storage', async () => undefined);
+        resolve();
+      }),
+    );
+    res.emit('finish');
+
+    expect(record).toHaveBeenCalledWith(
+      'GET /api/v1/translation/:id',
+      200,
+      expect.objectContaining({ startedAt: expect.any(Number), phases: { storage: expect.any(Number) } }),
+      'user1',
+    );
+  });
+
+  it('没有匹配到路由时使用去掉查询参数的原始路径', () => {
+    const res = response();
+    res.statusCode = 404;
+
+    middleware.use({ method: 'POST', originalUrl: '/api/v1/missing?x=1' } as any, res as any, () => undefined);
+    res.emit('finish');
+
+    expect(record).toHaveBeenCalledWith('POST /api/v1/missing', 404, expect.any(Object), undefined);
+  });
+
+  it('每个请求使用独立的计时上下文，请求之外没有上下文', () => {
+    const seen = [];
+    for (const originalUrl of ['/a', '/b']) {
+      middleware.use({ method: 'GET', originalUrl } as any, response() as any, () => seen.push(currentRequestTiming()));
+    }
+
+    expect(seen[0]).toBeDefined();
+    expect(seen[0]).not.toBe(seen[1]);
+    expect(currentRequestTiming()).toBeUndefined();
+  });
+});
@@ -0,0 +1,29 @@
+import { Injectable, NestMiddleware } from '@nestjs/common';
+import { Request, Response, NextFunction } from 'express';
+import { LatencyBudgetService } from '../services/latency-budget.service';
+import { currentRequestTiming, runWithRequestTiming } from '../../../common/utils/request-timing';
+
+/**
+ * 记录每个请求的耗时，并与所在路由的预算比较
+ * 路由使用匹配到的模板（如 /api/v1/translation/:id），未匹配时使用原始路径
+ */
+@Injectable()
+export class LatencyBudgetMiddleware implements NestMiddleware {
+  constructor(private readonly latencyBudgetService: LatencyBudgetService) {}
+
+  use(req: Request, res: Response, next: NextFunction) {
+    runWithRequestTiming(() => {
+      const timing = currentRequestTiming();
+      res.on('finish', () => {
+        const path = req.route?.path ? `${req.baseUrl}${req.route.path}` : req.originalUrl.split('?')[0];
+        this.latencyBudgetService.record(
+          `${req.method} ${path}`,
+          res.statusCode,
+          timing,
+          (req as any).user?.id,
+        );
+      });
+      next();
+    });
+  }
+}
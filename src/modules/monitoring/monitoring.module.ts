@@ -1,11 +1,26 @@
 import { Module } from '@nestjs/common';
+import { APP_INTERCEPTOR } from '@nestjs/core';
 import { MikroOrmModule } from '@mikro-orm/nestjs';
+import { CommonModule } from '../../common/common.module';
+import { AuditModule } from '../audit/audit.module';
 
 // 实体
 import { SystemMetrics } from './entities/system-metrics.entity';
 
 // 服务
 import { SystemMetricsService } from './services/system-metrics.service';
+import { LatencyBudgetService } from './services/latency-budget.service';
+import { StartupCheckService } from './services/startup-check.service';
+import { EgressIpsService } from './services/egress-ips.service';
+import { ApiChangelogService } from './services/api-changelog.service';
+import { QueueInspectorService } from './services/queue-inspector.service';
+
+// 控制器、中间件与拦截器
+import { LatencyController } from './controllers/latency.controller';
+import { MetaController } from './controllers/meta.controller';
+import { QueueController } from './controllers/queue.controller';
+import { LatencyBudgetMiddleware } from './middleware/latency-budget.middleware';
+import { DeprecationHeadersInterceptor } from './interceptors/deprecation-headers.interceptor';
 
 /**
  * 监控模块
@@ -16,12 +31,31 @@ import { SystemMetricsService } from './services/system-metrics.service';
     MikroOrmModule.forFeature([
       SystemMetrics,
     ]),
+    CommonModule,
+    AuditModule,
+  ],
+  controllers: [
+    LatencyController,
+    MetaController,
+    QueueController,
   ],
   providers: [
     SystemMetricsService,
+    LatencyBudgetService,
+    LatencyBudgetMiddleware,
+    StartupCheckService,
+    EgressIpsService,
+    ApiChangelogService,
+    QueueInspectorService,
+    { provide: APP_INTERCEPTOR, useClass: DeprecationHeadersInterceptor },
   ],
   exports: [
     SystemMetricsService,
+    LatencyBudgetService,
+    LatencyBudgetMiddleware,
+    StartupCheckService,
+    EgressIpsService,
+    ApiChangelogService,
   ],
 })
 export class MonitoringModule {}
\ No newline at end of file
@@ -0,0 +1,41 @@
+import { ApiChangelogService } from '../api-changelog.service';
+import { API_CHANGELOG, ApiChangeType } from '../../../../config/api-changelog';
+
+describe('ApiChangelogService', () => {
+  const service = new ApiChangelogService();
+
+  it('登记表应通过启动校验，并按日期倒序返回', () => {
+    const entries = service.list();
+
+    expect(entries).toHaveLength(API_CHANGELOG.length);
+    const dates = entries.map((entry) => entry.date);
+    expect(dates).toEqual([...dates].sort().reverse());
+  });
+
+  it('since 只返回该日期之后的条目', () => {
+    expect(service.list('2999-01-01')).toEqual([]);
+    expect(service.list('2026-10-16').length).toBeGreaterThan(0);
+  });
+
+  it('已弃用的接口应返回 Deprecation / Sunset / Link 响应头', () => {
+    const headers = service.deprecationHeaders('get', '/api/v1/translation/user/:userId');
+
+    expect(headers).toEqual({
+      Deprecation: `@${Date.UTC(2026, 9, 16) / 1000}`,
+      Sunset: 'Fri, 16 Apr 2027 00:00:00 GMT',
+      Link: expect.stringContaining('rel="deprecation"'),
+    });
+  });
+
+  it('未弃用的接口不返回响应头', () => {
+    expect(service.deprecationHeaders('GET', '/api/v1/translation/task/:id/result')).toBeNull();
+    expect(service.deprecationHeaders('POST', '/api/v1/translation/user/:userId')).toBeNull();
+  });
+
+  it('日期格式错误或 ID 重复时应阻止启动', () => {
+    const entry = { id: 'x', date: '2026-10-16', type: ApiChangeType.ADDED, breaking: false, summary: 'x' };
+
+    expect(() => (service as any).load([{ ...entry, date: '16/10/2026' }])).toThrow('Invalid date');
+    expect(() => (service as any).load([entry, entry])).toThrow('Duplicate API changelog entry x');
+  });
+});
@@ -0,0 +1,91 @@
+import { ConfigService } from '@nestjs/config';
+import { LatencyBudgetService } from '../latency-budget.service';
+import { SystemMetricsService } from '../system-metrics.service';
+import { MetricType } from '../../entities/system-metrics.entity';
+
+describe('LatencyBudgetService', () => {
+  const recordMetrics = jest.fn();
+
+  const create = (config: Record<string, string> = {}) =>
+    new LatencyBudgetService(
+      { get: jest.fn((key: string, defaultValue?: any) => config[key] ?? defaultValue) } as unknown as ConfigService,
+      { recordMetrics } as unknown as SystemMetricsService,
+    );
+
+  // 请求开始于 durationMs 之前
+  const timing = (durationMs: number, phases = {}) => ({ startedAt: Date.now() - durationMs, phases });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+    jest.restoreAllMocks();
+  });
+
+  it('路由预算取内置值，LATENCY_BUDGETS 覆盖或补充，忽略非法值', () => {
+    const service = create({
+      LATENCY_BUDGET_DEFAULT_MS: '800',
+      LATENCY_BUDGETS: JSON.stringify({
+        'GET /api/v1/translation/:id': 300,
+        'GET /api/v1/documents': 150,
+        'GET /api/v1/bad': -1,
+      }),
+    });
+
+    expect(service.getBudget('POST /api/v1/translation/task')).toBe(500);
+    expect(service.getBudget('GET /api/v1/translation/:id')).toBe(300);
+    expect(service.getBudget('GET /api/v1/documents')).toBe(150);
+    expect(service.getBudget('GET /api/v1/bad')).toBe(800);
+    expect(create({ LATENCY_BUDGETS: 'not json' }).getBudget('GET /api/v1/other')).toBe(1000);
+  });
+
+  it('未超预算时只计数，不输出慢请求日志', () => {
+    const service = create();
+
+    expect(service.record('GET /api/v1/translation/:id', 200, timing(50))).toBeNull();
+    expect(service.getStats()).toEqual([
+      { route: 'GET /api/v1/translation/:id', budgetMs: 200, requests: 1, violations: 0, maxMs: expect.any(Number) },
+    ]);
+  });
+
+  it('超出预算时返回按鉴权、存储、入队拆分的耗时', () => {
+    const service = create();
+    const warn = jest.spyOn((service as any).logger, 'warn').mockImplementation(() => undefined);
+
+    const entry = service.record(
+      'POST /api/v1/translation/task',
+      201,
+      timing(900, { auth: 100.04, storage: 300, enqueue: 50 }),
+      'user1',
+    );
+
+    expect(entry).toMatchObject({
+      event: 'slow_request',
+      route: 'POST /api/v1/translation/task',
+      status: 201,
+      budgetMs: 500,
+      breakdown: { auth: 100, storage: 300, enqueue: 50 },
+      userId: 'user1',
+    });
+    expect(entry.breakdown.other).toBeCloseTo(entry.durationMs - 450, 0);
+    expect(JSON.parse(warn.mock.calls[0][0])).toMatchObject({ event: 'slow_request', userId: 'user1' });
+    expect(service.getStats()[0]).toMatchObject({ requests: 1, violations: 1 });
+  });
+
+  it('每分钟写入本周期的请求数和超预算次数后清零，累计统计保留', async () => {
+    const service = create();
+    jest.spyOn((service as any).logger, 'warn').mockImplementation(() => undefined);
+    service.record('GET /api/v1/translation/:id', 200, timing(10));
+    service.record('GET /api/v1/translation/:id', 200, timing(500));
+
+    await service.flushMetrics();
+
+    const tags = { route: 'GET /api/v1/translation/:id', budgetMs: '200' };
+    expect(recordMetrics).toHaveBeenCalledWith([
+      expect.objectContaining({ name: 'http_requests_total', value: 2, type: MetricType.COUNTER, tags }),
+      expect.objectContaining({ name: 'latency_budget_violations', value: 1, type: MetricType.COUNTER, tags }),
+    ]);
+
+    await service.flushMetrics();
+    expect(recordMetrics).toHaveBeenCalledTimes(1);
+    expect(service.getStats()[0]).toMatchObject({ requests: 2, violations: 1 });
+  });
+});
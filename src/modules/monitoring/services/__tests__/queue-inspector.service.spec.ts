@@ -0,0 +1,97 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ModuleRef } from '@nestjs/core';
+import { ConflictException, NotFoundException } from '@nestjs/common';
+import { getQueueToken } from '@nestjs/bull';
+import { QueueInspectorService } from '../queue-inspector.service';
+import { AuditLogService } from '../../../audit/services/audit-log.service';
+
+describe('QueueInspectorService', () => {
+  let service: QueueInspectorService;
+
+  const failedJob = {
+    id: 7,
+    name: 'deliver-translation-result',
+    data: { taskId: 't1' },
+    failedReason: 'connect ECONNREFUSED',
+    attemptsMade: 3,
+    opts: { attempts: 3 },
+    timestamp: Date.parse('2026-10-16T08:00:00Z'),
+    finishedOn: Date.parse('2026-10-16T08:01:00Z'),
+    isFailed: jest.fn(),
+    retry: jest.fn(),
+  };
+  const translationQueue = {
+    getJobCounts: jest.fn(),
+    isPaused: jest.fn(),
+    getFailed: jest.fn(),
+    getJob: jest.fn(),
+  };
+  const queues: Record<string, any> = { [getQueueToken('translation')]: translationQueue };
+  const mockModuleRef = {
+    get: jest.fn((token: string) => {
+      if (!queues[token]) {
+        throw new Error('not found');
+      }
+      return queues[token];
+    }),
+  };
+  const mockAuditLogService = { log: jest.fn() };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        QueueInspectorService,
+        { provide: ModuleRef, useValue: mockModuleRef },
+        { provide: AuditLogService, useValue: mockAuditLogService },
+      ],
+    }).compile();
+
+    service = module.get<QueueInspectorService>(QueueInspectorService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('应返回已注册队列的各状态任务数', async () => {
+    translationQueue.getJobCounts.mockResolvedValue({ waiting: 5, active: 2, delayed: 1, failed: 3, completed: 9 });
+    translationQueue.isPaused.mockResolvedValue(false);
+
+    expect(await service.getStats()).toEqual([
+      { name: 'translation', pending: 5, active: 2, retry: 1, dead: 3, completed: 9, paused: false },
+    ]);
+  });
+
+  it('应列出失败任务和失败原因', async () => {
+    translationQueue.getFailed.mockResolvedValue([failedJob]);
+
+    const jobs = await service.getFailedJobs('translation', 10);
+
+    expect(translationQueue.getFailed).toHaveBeenCalledWith(0, 9);
+    expect(jobs).toEqual([
+      expect.objectContaining({
+        id: '7',
+        failedReason: 'connect ECONNREFUSED',
+        attempts: 3,
+        failedAt: '2026-10-16T08:01:00.000Z',
+      }),
+    ]);
+    await expect(service.getFailedJobs('webhook')).rejects.toThrow(NotFoundException);
+    await expect(service.getFailedJobs('unknown')).rejects.toThrow(NotFoundException);
+  });
+
+  it('只能重新入队失败的任务，并记录审计日志', async () => {
+    translationQueue.getJob.mockResolvedValue(failedJob);
+    failedJob.isFailed.mockResolvedValueOnce(true);
+
+    await service.requeue('translation', '7', 'admin1');
+
+    expect(failedJob.retry).toHaveBeenCalled();
+    expect(mockAuditLogService.log).toHaveBeenCalledWith(
+      expect.objectContaining({ userId: 'admin1', resourceId: 'translation:7' }),
+    );
+
+    failedJob.isFailed.mockResolvedValueOnce(false);
+    await expect(service.requeue('translation', '7', 'admin1')).rejects.toThrow(ConflictException);
+  });
+});
@@ -0,0 +1,86 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { ModuleRef } from '@nestjs/core';
+import { promises as dns } from 'dns';
+import { StartupCheckService } from '../startup-check.service';
+import { RedisService } from '../../../../common/services/redis.service';
+import { StorageDriverService } from '../../../../common/services/storage-driver.service';
+import { MailService } from '../../../../common/services/mail.service';
+import { ErrorReporterService } from '../../../../common/services/error-reporter.service';
+
+describe('StartupCheckService', () => {
+  let service: StartupCheckService;
+  let config: Record<string, string>;
+
+  const mockRedisService = { client: { ping: jest.fn() } };
+  const mockStorageDriverService = { driver: 'postgresql', ping: jest.fn() };
+  const mockMailService = { enabled: false, verify: jest.fn() };
+
+  beforeEach(async () => {
+    config = { ALIYUN_ACCESS_KEY_ID: 'id', ALIYUN_ACCESS_KEY_SECRET: 'secret' };
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        StartupCheckService,
+        {
+          provide: ConfigService,
+          useValue: { get: jest.fn((key: string, defaultValue?: any) => config[key] ?? defaultValue) },
+        },
+        { provide: ModuleRef, useValue: { get: jest.fn(() => ({})) } },
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: StorageDriverService, useValue: mockStorageDriverService },
+        { provide: MailService, useValue: mockMailService },
+        { provide: ErrorReporterService, useValue: { enabled: false } },
+      ],
+    }).compile();
+
+    service = module.get<StartupCheckService>(StartupCheckService);
+    mockRedisService.client.ping.mockResolvedValue('PONG');
+    mockStorageDriverService.ping.mockResolvedValue(true);
+    mockMailService.enabled = false;
+    jest.spyOn(dns, 'lookup').mockResolvedValue({ address: '127.0.0.1', family: 4 } as any);
+  });
+
+  afterEach(() => {
+    jest.restoreAllMocks();
+    jest.clearAllMocks();
+  });
+
+  it('所有依赖可用时应通过并输出能力清单', async () => {
+    const report = await service.run();
+
+    expect(report.ok).toBe(true);
+    expect(report.providers).toEqual(['aliyun']);
+    expect(report.queues).toEqual(['translation', 'webhook', 'webhook-retry']);
+    expect(report.checks.find((check) => check.name === 'smtp').skipped).toBe(true);
+  });
+
+  it('Redis 不可用时应失败并给出修复提示', async () => {
+    mockRedisService.client.ping.mockRejectedValue(new Error('ECONNREFUSED'));
+
+    const report = await service.run();
+    const redis = report.checks.find((check) => check.name === 'redis');
+
+    expect(report.ok).toBe(false);
+    expect(redis.error).toBe('ECONNREFUSED');
+    expect(redis.hint).toContain('localhost:6379');
+  });
+
+  it('缺少翻译服务商凭据时应失败', async () => {
+    delete config.ALIYUN_ACCESS_KEY_SECRET;
+
+    const report = await service.run();
+
+    expect(report.ok).toBe(false);
+    expect(report.checks.find((check) => check.name === 'translation_provider').hint).toContain('ALIYUN_ACCESS_KEY_ID');
+  });
+
+  it('配置了 SMTP 时应校验连接', async () => {
+    mockMailService.enabled = true;
+    mockMailService.verify.mockRejectedValue(new Error('Invalid login'));
+
+    const report = await service.run();
+
+    expect(report.ok).toBe(false);
+    expect(report.checks.find((check) => check.name === 'smtp').error).toBe('Invalid login');
+  });
+});
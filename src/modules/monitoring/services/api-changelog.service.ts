@@ -0,0 +1,72 @@
+import { Injectable } from '@nestjs/common';
+import { API_CHANGELOG, ApiChange } from '../../../config/api-changelog';
+
+export interface DeprecationHeaders {
+  Deprecation: string;
+  Sunset?: string;
+  Link: string;
+}
+
+/**
+ * API 变更登记表的查询，以及已弃用接口的响应头
+ */
+@Injectable()
+export class ApiChangelogService {
+  private readonly entries: ApiChange[];
+  /** "METHOD 路由模板" → 弃用条目 */
+  private readonly deprecatedEndpoints = new Map<string, ApiChange>();
+
+  constructor() {
+    this.entries = this.load(API_CHANGELOG);
+    for (const entry of this.entries) {
+      if (entry.endpoint && entry.deprecation) {
+        this.deprecatedEndpoints.set(`${entry.endpoint.method} ${entry.endpoint.path}`, entry);
+      }
+    }
+  }
+
+  /**
+   * 按日期倒序返回变更，since 只返回该日期（含）之后的条目
+   */
+  list(since?: string): ApiChange[] {
+    return since ? this.entries.filter((entry) => entry.date >= since) : this.entries;
+  }
+
+  /**
+   * 已弃用接口的 Deprecation（RFC 9745）、Sunset（RFC 8594）和指向变更条目的 Link 响应头
+   */
+  deprecationHeaders(method: string, routePath: string): DeprecationHeaders | null {
+    const entry = this.deprecatedEndpoints.get(`${method.toUpperCase()} ${routePath}`);
+    if (!entry) {
+      return null;
+    }
+    const { deprecation } = entry;
+    const links = [`</api/v1/meta/changelog#${entry.id}>; rel="deprecation"; type="application/json"`];
+    if (deprecation.sunsetAt) {
+      links.push(`</api/v1/meta/changelog#${entry.id}>; rel="sunset"; type="application/json"`);
+    }
+    return {
+      Deprecation: `@${Math.floor(Date.parse(deprecation.deprecatedAt) / 1000)}`,
+      ...(deprecation.sunsetAt && { Sunset: new Date(deprecation.sunsetAt).toUTCString() }),
+      Link: links.join(', '),
+    };
+  }
+
+  /**
+   * 启动时校验登记表，日期写错或 ID 重复直接阻止启动
+   */
+  private load(entries: ApiChange[]): ApiChange[] {
+    const ids = new Set<string>();
+    for (const entry of entries) {
+      const dates = [entry.date, entry.deprecation?.deprecatedAt, entry.deprecation?.sunsetAt].filter(Boolean);
+      if (dates.some((date) => !/^\d{4}-\d{2}-\d{2}$/.test(date) || Number.isNaN(Date.parse(date)))) {
+        throw new Error(`Invalid date in API changelog entry ${entry.id}`);
+      }
+      if (ids.has(entry.id)) {
+        throw new Error(`Duplicate API changelog entry ${entry.id}`);
+      }
+      ids.add(entry.id);
+    }
+    return [...entries].sort((a, b) => b.date.localeCompare(a.date));
+  }
+}
@@ -0,0 +1,38 @@
+import { Injectable } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { parseEgressIps } from '../../../common/utils/egress-ips';
+
+export interface EgressIps {
+  /** webhook 推送的出口地址 */
+  webhooks: string[];
+  /** 调用翻译服务商的出口地址 */
+  providers: string[];
+  /** 全部出口地址，便于一次性加入白名单 */
+  all: string[];
+  updatedAt?: string;
+}
+
+/**
+ * 对外公布的固定出口 IP
+ * 由各环境通过 WEBHOOK_EGRESS_IPS / PROVIDER_EGRESS_IPS 配置（与 NAT 网关或出口代理保持一致），
+ * 启动时校验格式，配置错误直接阻止启动
+ */
+@Injectable()
+export class EgressIpsService {
+  private readonly egressIps: EgressIps;
+
+  constructor(private readonly configService: ConfigService) {
+    const webhooks = parseEgressIps(this.configService.get('WEBHOOK_EGRESS_IPS'), 'WEBHOOK_EGRESS_IPS');
+    const providers = parseEgressIps(this.configService.get('PROVIDER_EGRESS_IPS'), 'PROVIDER_EGRESS_IPS');
+    this.egressIps = {
+      webhooks,
+      providers,
+      all: Array.from(new Set([...webhooks, ...providers])),
+      updatedAt: this.configService.get('EGRESS_IPS_UPDATED_AT') || undefined,
+    };
+  }
+
+  get(): EgressIps {
+    return this.egressIps;
+  }
+}
@@ -0,0 +1,165 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { Cron, CronExpression } from '@nestjs/schedule';
+import { SystemMetricsService } from './system-metrics.service';
+import { MetricType, MetricCategory } from '../entities/system-metrics.entity';
+import { RequestTiming } from '../../../common/utils/request-timing';
+
+/**
+ * 默认路由耗时预算（毫秒），键为 "METHOD 路由模板"
+ * 可通过 LATENCY_BUDGETS（JSON）覆盖或补充
+ */
+const DEFAULT_ROUTE_BUDGETS: Record<string, number> = {
+  'POST /api/v1/translation/task': 500,
+  'GET /api/v1/translation/:id': 200,
+  'POST /api/v1/translation/detect': 1500,
+  'GET /api/v1/webhook/history': 500,
+};
+
+export interface RouteLatencyStats {
+  route: string;
+  budgetMs: number;
+  requests: number;
+  violations: number;
+  maxMs: number;
+}
+
+export interface SlowRequestEntry {
+  event: 'slow_request';
+  route: string;
+  status: number;
+  durationMs: number;
+  budgetMs: number;
+  breakdown: {
+    auth: number;
+    storage: number;
+    enqueue: number;
+    other: number;
+  };
+  userId?: string;
+}
+
+/**
+ * 路由耗时预算
+ * 记录每个路由的请求数和超预算次数，超预算的请求以结构化日志输出耗时拆分，
+ * 计数定期写入系统指标用于 SLO 跟踪
+ */
+@Injectable()
+export class LatencyBudgetService {
+  private readonly logger = new Logger(LatencyBudgetService.name);
+  private readonly budgets: Record<string, number>;
+  private readonly defaultBudgetMs: number;
+  private stats = new Map<string, RouteLatencyStats>();
+  private readonly totals = new Map<string, RouteLatencyStats>();
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly systemMetricsService: SystemMetricsService,
+  ) {
+    this.defaultBudgetMs = Number(this.configService.get('LATENCY_BUDGET_DEFAULT_MS', 1000));
+    this.budgets = { ...DEFAULT_ROUTE_BUDGETS, ...parseBudgets(this.configService.get('LATENCY_BUDGETS')) };
+  }
+
+  getBudget(route: string): number {
+    return this.budgets[route] ?? this.defaultBudgetMs;
+  }
+
+  /**
+   * 记录一次请求，超出预算时返回慢请求日志条目
+   */
+  record(route: string, status: number, timing: RequestTiming, userId?: string): SlowRequestEntry | null {
+    const durationMs = Date.now() - timing.startedAt;
+    const budgetMs = this.getBudget(route);
+    const violated = durationMs > budgetMs;
+
+    for (const map of [this.stats, this.totals]) {
+      const stats = map.get(route) ?? { route, budgetMs, requests: 0, violations: 0, maxMs: 0 };
+      stats.requests++;
+      stats.maxMs = Math.max(stats.maxMs, durationMs);
+      if (violated) {
+        stats.violations++;
+      }
+      map.set(route, stats);
+    }
+
+    if (!violated) {
+      return null;
+    }
+
+    const auth = round(timing.phases.auth);
+    const storage = round(timing.phases.storage);
+    const enqueue = round(timing.phases.enqueue);
+    const entry: SlowRequestEntry = {
+      event: 'slow_request',
+      route,
+      status,
+      durationMs,
+      budgetMs,
+      breakdown: { auth, storage, enqueue, other: round(Math.max(durationMs - auth - storage - enqueue, 0)) },
+      userId,
+    };
+    this.logger.warn(JSON.stringify(entry));
+    return entry;
+  }
+
+  /**
+   * 进程启动以来的累计统计
+   */
+  getStats(): RouteLatencyStats[] {
+    return Array.from(this.totals.values()).sort((a, b) => b.violations - a.violations);
+  }
+
+  /**
+   * 每分钟把本周期的请求数和超预算次数写入系统指标
+   */
+  @Cron(CronExpression.EVERY_MINUTE)
+  async flushMetrics(): Promise<void> {
+    if (this.stats.size === 0) {
+      return;
+    }
+    const snapshot = this.stats;
+    this.stats = new Map();
+
+    const metrics = [];
+    for (const stats of snapshot.values()) {
+      const tags = { route: stats.route, budgetMs: String(stats.budgetMs) };
+      metrics.push(
+        {
+          name: 'http_requests_total',
+          value: stats.requests,
+          type: MetricType.COUNTER,
+          category: MetricCategory.PERFORMANCE,
+          tags,
+        },
+        {
+          name: 'latency_budget_violations',
+          value: stats.violations,
+          type: MetricType.COUNTER,
+          category: MetricCategory.PERFORMANCE,
+          tags,
+        },
+      );
+    }
+    await this.systemMetricsService.recordMetrics(metrics);
+  }
+}
+
+function parseBudgets(raw?: string): Record<string, number> {
+  if (!raw) {
+    return {};
+  }
+  try {
+    const parsed = JSON.parse(raw);
+    return Object.fromEntries(
+      Object.entries(parsed)
+        .map(([route, value]) => [route, Number(value)] as const)
+        .filter(([, value]) => Number.isFinite(value) && value > 0),
+    );
+  } catch {
+    return {};
+  }
+}
+
+function round(value = 0): number {
+  return Math.round(value * 10) / 10;
+}
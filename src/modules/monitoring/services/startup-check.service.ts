@@ -0,0 +1,155 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { ModuleRef } from '@nestjs/core';
+import { getQueueToken } from '@nestjs/bull';
+import { Queue } from 'bull';
+import { promises as dns } from 'dns';
+import { RedisService } from '../../../common/services/redis.service';
+import { StorageDriverService } from '../../../common/services/storage-driver.service';
+import { MailService } from '../../../common/services/mail.service';
+import { ErrorReporterService } from '../../../common/services/error-reporter.service';
+import { parseLogSinks } from '../../../common/utils/log-sinks';
+
+export const TRANSLATION_PROVIDER_HOST = 'mt.aliyuncs.com';
+
+export const KNOWN_QUEUES = ['translation', 'webhook', 'webhook-retry'];
+
+export interface StartupCheckResult {
+  name: string;
+  ok: boolean;
+  /** 必需依赖失败时阻止启动 */
+  required: boolean;
+  skipped?: boolean;
+  durationMs: number;
+  error?: string;
+  /** 失败时的修复提示 */
+  hint?: string;
+}
+
+export interface StartupReport {
+  ok: boolean;
+  checks: StartupCheckResult[];
+  providers: string[];
+  features: Record<string, boolean | string>;
+  queues: string[];
+  logSinks: string[];
+}
+
+/**
+ * 启动自检
+ * 服务启动时检查 Redis、数据库、翻译服务商和 SMTP（如已配置）的连通性，
+ * 输出结构化的能力清单；必需依赖不可用时给出具体修复提示，由调用方决定是否终止启动
+ */
+@Injectable()
+export class StartupCheckService {
+  private readonly logger = new Logger(StartupCheckService.name);
+  private readonly timeoutMs: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly moduleRef: ModuleRef,
+    private readonly redisService: RedisService,
+    private readonly storageDriverService: StorageDriverService,
+    private readonly mailService: MailService,
+    private readonly errorReporter: ErrorReporterService,
+  ) {
+    this.timeoutMs = Number(this.configService.get('STARTUP_CHECK_TIMEOUT_MS', 5000));
+  }
+
+  async run(): Promise<StartupReport> {
+    const redisHost = `${this.configService.get('REDIS_HOST', 'localhost')}:${this.configService.get('REDIS_PORT', 6379)}`;
+    const checks = await Promise.all([
+      this.check('redis', true, `Check that Redis is reachable at ${redisHost} and REDIS_PASSWORD is correct`, async () => {
+        await this.redisService.client.ping();
+      }),
+      this.check(
+        'storage',
+        true,
+        `Check DB_HOST/DB_PORT/DB_USERNAME/DB_PASSWORD/DB_DATABASE for the ${this.storageDriverService.driver} database`,
+        async () => {
+          if (!(await this.storageDriverService.ping())) {
+            throw new Error('SELECT 1 failed');
+          }
+        },
+      ),
+      this.check(
+        'translation_provider',
+        true,
+        `Set ALIYUN_ACCESS_KEY_ID and ALIYUN_ACCESS_KEY_SECRET, and allow outbound DNS/HTTPS to ${TRANSLATION_PROVIDER_HOST}`,
+        async () => {
+          if (!this.configService.get('ALIYUN_ACCESS_KEY_ID') || !this.configService.get('ALIYUN_ACCESS_KEY_SECRET')) {
+            throw new Error('Aliyun credentials are not configured');
+          }
+          await dns.lookup(TRANSLATION_PROVIDER_HOST);
+        },
+      ),
+      this.mailService.enabled
+        ? this.check(
+            'smtp',
+            true,
+            'Check SMTP_HOST/SMTP_PORT/SMTP_SECURE/SMTP_USER/SMTP_PASSWORD, or unset SMTP_HOST to only log emails',
+            () => this.mailService.verify(),
+          )
+        : Promise.resolve<StartupCheckResult>({ name: 'smtp', ok: true, required: false, skipped: true, durationMs: 0 }),
+    ]);
+
+    const report: StartupReport = {
+      ok: checks.every((check) => check.ok || !check.required),
+      checks,
+      providers: ['aliyun'],
+      features: {
+        stripeBilling: !!this.configService.get('STRIPE_SECRET_KEY'),
+        email: this.mailService.enabled,
+        errorReporting: this.errorReporter.enabled,
+        tenants: !!this.configService.get('TENANT_BASE_DOMAIN'),
+        quotaMode: this.configService.get('QUOTA_DEFAULT_MODE', 'hard'),
+        storageDriver: this.storageDriverService.driver,
+      },
+      queues: KNOWN_QUEUES.filter((name) => this.hasQueue(name)),
+      logSinks: parseLogSinks(this.configService.get('LOG_SINKS')),
+    };
+
+    this.logger.log(JSON.stringify({ event: 'startup_banner', ...report }));
+    for (const check of checks.filter((item) => !item.ok)) {
+      const message = `Startup check "${check.name}" failed: ${check.error}. Hint: ${check.hint}`;
+      if (check.required) {
+        this.logger.error(message);
+      } else {
+        this.logger.warn(message);
+      }
+    }
+
+    return report;
+  }
+
+  private async check(
+    name: string,
+    required: boolean,
+    hint: string,
+    probe: () => Promise<unknown>,
+  ): Promise<StartupCheckResult> {
+    const startedAt = Date.now();
+    let timer: NodeJS.Timeout;
+    try {
+      await Promise.race([
+        probe(),
+        new Promise((_, reject) => {
+          timer = setTimeout(() => reject(new Error(`timed out after ${this.timeoutMs}ms`)), this.timeoutMs);
+        }),
+      ]);
+      return { name, ok: true, required, durationMs: Date.now() - startedAt };
+    } catch (error) {
+      return { name, ok: false, required, durationMs: Date.now() - startedAt, error: error.message, hint };
+    } finally {
+      clearTimeout(timer);
+    }
+  }
+
+  private hasQueue(name: string): boolean {
+    try {
+      return !!this.moduleRef.get<Queue>(getQueueToken(name), { strict: false });
+    } catch {
+      return false;
+    }
+  }
+}
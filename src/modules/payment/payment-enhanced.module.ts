@@ -33,6 +33,7 @@ import { StripeWebhookGuard } from './guards/stripe-webhook.guard';
 import { AuditModule } from '../audit/audit.module';
 import { MonitoringModule } from '../monitoring/monitoring.module';
 import { CommonModule } from '../../common/common.module';
+import { SubscriptionModule } from '../subscription/subscription.module';
 
 /**
  * 增强的支付模块
@@ -51,6 +52,7 @@ import { CommonModule } from '../../common/common.module';
     AuditModule,
     MonitoringModule,
     CommonModule,
+    SubscriptionModule,
   ],
   controllers: [
     StripeWebhookController,
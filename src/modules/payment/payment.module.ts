@@ -16,6 +16,7 @@ import { StripeWebhookController } from './controllers/stripe-webhook.controller
 import { StripeWebhookGuard } from './guards/stripe-webhook.guard';
 import { SubscriptionModule } from '../subscription/subscription.module';
 import { IdempotencyService } from '../../common/services/idempotency.service';
+import { StorageDriverService } from '../../common/services/storage-driver.service';
 import { RetryConfigService } from '../../common/services/retry-config.service';
 import { CircuitBreakerService } from '../../common/utils/circuit-breaker.service';
 
@@ -43,6 +44,7 @@ import { CircuitBreakerService } from '../../common/utils/circuit-breaker.servic
     WebhookRetryService,
     StripeWebhookGuard,
     IdempotencyService,
+    StorageDriverService,
     RetryConfigService,
     CircuitBreakerService,
   ],
@@ -2,6 +2,8 @@ import { Test, TestingModule } from '@nestjs/testing';
 import { ConfigService } from '@nestjs/config';
 import { EntityManager } from '@mikro-orm/core';
 import { ReconciliationService, ReconciliationParams, IntegrityReport, AnomalyReport } from '../reconciliation.service';
+import { StorageDriverService } from '../../../../common/services/storage-driver.service';
+import { StorageDriver } from '../../../../config/database.config';
 import { ReconciliationType as SessionReconciliationType, SessionStatus, ReconciliationConfig } from '../../entities/reconciliation-session.entity';
 import { ReconciliationType } from '../../entities/reconciliation-report.entity';
 import { EnhancedPaymentLog, PaymentStatus, PaymentEventType, ReconciliationStatus } from '../../entities/enhanced-payment-log.entity';
@@ -57,6 +59,10 @@ describe('ReconciliationService - Enhanced Features', () => {
     createQueryBuilder: jest.fn(),
   };
 
+  const mockStorageDriverService = {
+    driver: StorageDriver.POSTGRESQL,
+  };
+
   const mockConfigService = {
     get: jest.fn(),
   };
@@ -79,6 +85,10 @@ describe('ReconciliationService - Enhanced Features', () => {
           provide: ConfigService,
           useValue: mockConfigService,
         },
+        {
+          provide: StorageDriverService,
+          useValue: mockStorageDriverService,
+        },
         {
           provide: EnhancedPaymentLogService,
           useValue: mockEnhancedPaymentLogService,
@@ -102,6 +112,7 @@ describe('ReconciliationService - Enhanced Features', () => {
 
   afterEach(() => {
     jest.clearAllMocks();
+    mockStorageDriverService.driver = StorageDriver.POSTGRESQL;
   });
 
   describe('performEnhancedReconciliation', () => {
@@ -745,6 +756,19 @@ describe('ReconciliationService - Enhanced Features', () => {
       expect(stats.sessionsByType.manual).toBe(5);
       expect(stats.recentTrends).toHaveLength(1);
     });
+
+    it('近 30 天趋势的起始时间用绑定参数传入，不依赖 PostgreSQL 的 INTERVAL 语法', async () => {
+      mockEntityManager.count.mockResolvedValueOnce(0).mockResolvedValueOnce(0).mockResolvedValueOnce(0);
+      mockEntityManager.find.mockResolvedValueOnce([]);
+      mockEntityManager.getConnection().execute.mockResolvedValueOnce([]).mockResolvedValueOnce([]);
+
+      await service.getSessionStatistics();
+
+      const [sql, params] = mockEntityManager.getConnection().execute.mock.calls[1];
+      expect(sql).not.toContain('INTERVAL');
+      expect(sql).toContain('created_at >= ?');
+      expect(params[0].getTime()).toBeCloseTo(Date.now() - 30 * 24 * 60 * 60 * 1000, -4);
+    });
   });
 
   describe('validateReconciliationConfig', () => {
@@ -1062,6 +1086,20 @@ describe('ReconciliationService - Enhanced Features', () => {
 
         expect(result).toEqual(mockDuplicates);
       });
+
+      it('MySQL 下用 JSON_ARRAYAGG 聚合记录 ID 并解析', async () => {
+        mockStorageDriverService.driver = StorageDriver.MYSQL;
+        mockEntityManager.getConnection().execute.mockResolvedValueOnce([
+          { stripePaymentIntentId: 'pi_duplicate', recordIds: '["record-1", "record-2"]' },
+        ]);
+
+        const result = await (service as any).checkDuplicatePaymentIntents();
+
+        const [sql] = mockEntityManager.getConnection().execute.mock.calls[0];
+        expect(sql).toContain('JSON_ARRAYAGG(id)');
+        expect(sql).not.toContain('array_agg');
+        expect(result).toEqual([{ stripePaymentIntentId: 'pi_duplicate', recordIds: ['record-1', 'record-2'] }]);
+      });
     });
 
     describe('checkFutureTimestamps', () => {
@@ -2,6 +2,8 @@ import { Test, TestingModule } from '@nestjs/testing';
 import { ConfigService } from '@nestjs/config';
 import { EntityManager } from '@mikro-orm/core';
 import { ReconciliationService, ReconciliationParams, IntegrityReport, AnomalyReport, ReconciliationPlan } from '../reconciliation.service';
+import { StorageDriverService } from '../../../../common/services/storage-driver.service';
+import { StorageDriver } from '../../../../config/database.config';
 import { ReconciliationType as SessionReconciliationType, SessionStatus, ReconciliationConfig } from '../../entities/reconciliation-session.entity';
 import { ReconciliationType } from '../../entities/reconciliation-report.entity';
 import { EnhancedPaymentLog, PaymentStatus, PaymentEventType, ReconciliationStatus } from '../../entities/enhanced-payment-log.entity';
@@ -28,6 +30,10 @@ describe('ReconciliationService - Refactored', () => {
     createQueryBuilder: jest.fn(),
   };
 
+  const mockStorageDriverService = {
+    driver: StorageDriver.POSTGRESQL,
+  };
+
   const mockConfigService = {
     get: jest.fn().mockReturnValue('stripe_test_key'),
   };
@@ -50,6 +56,10 @@ describe('ReconciliationService - Refactored', () => {
           provide: ConfigService,
           useValue: mockConfigService,
         },
+        {
+          provide: StorageDriverService,
+          useValue: mockStorageDriverService,
+        },
         {
           provide: EnhancedPaymentLogService,
           useValue: mockEnhancedPaymentLogService,
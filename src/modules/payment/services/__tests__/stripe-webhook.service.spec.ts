@@ -10,6 +10,7 @@ import { PaymentDisputeService } from '../payment-dispute.service';
 import { RetryConfigService } from '../../../../common/services/retry-config.service';
 import { SystemMetricsService } from '../../../monitoring/services/system-metrics.service';
 import { AuditLogService } from '../../../audit/services/audit-log.service';
+import { PlanCacheService } from '../../../subscription/services/plan-cache.service';
 import { 
   EnhancedPaymentLog, 
   ReconciliationStatus,
@@ -115,6 +116,12 @@ describe('StripeWebhookService', () => {
             logAction: jest.fn(),
           },
         },
+        {
+          provide: PlanCacheService,
+          useValue: {
+            invalidate: jest.fn(),
+          },
+        },
       ],
     }).compile();
 
@@ -2,6 +2,8 @@ import { Test, TestingModule } from '@nestjs/testing';
 import { ConfigService } from '@nestjs/config';
 import { EntityManager } from '@mikro-orm/core';
 import { ReconciliationService } from './reconciliation.service';
+import { StorageDriverService } from '../../../common/services/storage-driver.service';
+import { StorageDriver } from '../../../config/database.config';
 import { ReconciliationType, ReconciliationStatus } from '../entities/reconciliation-report.entity';
 import { PaymentLog, PaymentStatus } from '../entities/payment-log.entity';
 import { RetryConfigService } from '../../../common/services/retry-config.service';
@@ -19,6 +21,10 @@ describe('ReconciliationService', () => {
     persistAndFlush: jest.fn(),
   };
 
+  const mockStorageDriverService = {
+    driver: StorageDriver.POSTGRESQL,
+  };
+
   const mockConfigService = {
     get: jest.fn(),
   };
@@ -56,6 +62,10 @@ describe('ReconciliationService', () => {
           provide: ConfigService,
           useValue: mockConfigService,
         },
+        {
+          provide: StorageDriverService,
+          useValue: mockStorageDriverService,
+        },
         {
           provide: RetryConfigService,
           useValue: mockRetryConfigService,
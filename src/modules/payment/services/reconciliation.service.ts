@@ -13,6 +13,8 @@ import { Alert, AlertType, AlertSeverity, AlertStatus } from '../entities/alert.
 import { EnhancedPaymentLogService } from './enhanced-payment-log.service';
 import { v4 as uuidv4 } from 'uuid';
 import { Retry } from '../../../common/decorators/retry.decorator';
+import { StorageDriverService } from '../../../common/services/storage-driver.service';
+import { StorageDriver } from '../../../config/database.config';
 
 export interface StripePaymentRecord {
   id: string;
@@ -124,6 +126,7 @@ export class ReconciliationService {
     private readonly configService: ConfigService,
     private readonly em: EntityManager,
     private readonly enhancedPaymentLogService: EnhancedPaymentLogService,
+    private readonly storageDriverService: StorageDriverService,
   ) {
     this.stripe = new Stripe(this.configService.get('STRIPE_SECRET_KEY'), {
       apiVersion: '2023-08-16',
@@ -739,11 +742,11 @@ export class ReconciliationService {
         COUNT(*) as sessions_count,
         AVG(discrepancies_found) as average_discrepancies
       FROM reconciliation_session 
-      WHERE created_at >= NOW() - INTERVAL '30 days'
+      WHERE created_at >= ?
       GROUP BY DATE(created_at)
       ORDER BY date DESC
       LIMIT 30
-    `);
+    `, [new Date(Date.now() - 30 * 24 * 60 * 60 * 1000)]);
 
     return {
       totalSessions,
@@ -846,9 +849,11 @@ export class ReconciliationService {
   }
 
   private async checkDuplicatePaymentIntents(): Promise<Array<{ stripePaymentIntentId: string; recordIds: string[] }>> {
+    // MySQL 没有 array_agg，用 JSON_ARRAYAGG 聚合记录 ID
+    const aggregate = this.storageDriverService.driver === StorageDriver.MYSQL ? 'JSON_ARRAYAGG(id)' : 'array_agg(id)';
     const duplicates = await this.em.getConnection().execute(`
       SELECT stripe_payment_intent_id as "stripePaymentIntentId", 
-             array_agg(id) as "recordIds",
+             ${aggregate} as "recordIds",
              COUNT(*) as count 
       FROM enhanced_payment_log 
       WHERE stripe_payment_intent_id IS NOT NULL AND stripe_payment_intent_id != ''
@@ -858,7 +863,7 @@ export class ReconciliationService {
 
     return duplicates.map((dup: any) => ({
       stripePaymentIntentId: dup.stripePaymentIntentId,
-      recordIds: dup.recordIds,
+      recordIds: typeof dup.recordIds === 'string' ? JSON.parse(dup.recordIds) : dup.recordIds,
     }));
   }
 
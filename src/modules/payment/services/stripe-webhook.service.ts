@@ -8,6 +8,7 @@ import { PaymentDisputeService } from './payment-dispute.service';
 import { RetryConfigService } from '../../../common/services/retry-config.service';
 import { SystemMetricsService } from '../../monitoring/services/system-metrics.service';
 import { AuditLogService } from '../../audit/services/audit-log.service';
+import { PlanCacheService } from '../../subscription/services/plan-cache.service';
 import { 
   EnhancedPaymentLog, 
   PaymentEventType as EnhancedPaymentEventType, 
@@ -61,6 +62,7 @@ export class StripeWebhookService {
     private readonly retryConfigService: RetryConfigService,
     private readonly metricsService: SystemMetricsService,
     private readonly auditLogService: AuditLogService,
+    private readonly planCacheService: PlanCacheService,
   ) {
     this.stripe = new Stripe(this.configService.get('STRIPE_SECRET_KEY'), {
       apiVersion: '2023-08-16',
@@ -517,6 +519,13 @@ export class StripeWebhookService {
     
     // Log the subscription event for audit purposes
     await this.logWebhookEvent(event, EnhancedPaymentEventType.WEBHOOK_RECEIVED);
+
+    // Drop cached plan limits so the next create request reloads them
+    const subscription = event.data.object as Stripe.Subscription;
+    const user = await this.findOrCreateUserFromCustomer(subscription.customer as string);
+    if (user) {
+      await this.planCacheService.invalidate(user.id);
+    }
     
     // Additional subscription-specific processing can be added here
     this.logger.log(`Successfully processed subscription event: ${event.type}`);
@@ -0,0 +1,54 @@
+import {
+  BadRequestException,
+  Controller,
+  Headers,
+  HttpCode,
+  HttpStatus,
+  Logger,
+  Post,
+  RawBodyRequest,
+  Req,
+} from '@nestjs/common';
+import { ApiHeader, ApiOperation, ApiResponse, ApiTags } from '@nestjs/swagger';
+import { Request } from 'express';
+import { StripeService } from '../services/stripe.service';
+import { BillingSyncResult, BillingSyncService } from '../services/billing-sync.service';
+
+@ApiTags('billing')
+@Controller('billing/stripe')
+export class BillingWebhookController {
+  private readonly logger = new Logger(BillingWebhookController.name);
+
+  constructor(
+    private readonly stripeService: StripeService,
+    private readonly billingSyncService: BillingSyncService,
+  ) {}
+
+  @Post('webhook')
+  @HttpCode(HttpStatus.OK)
+  @ApiOperation({
+    summary: '接收 Stripe 计费事件',
+    description: '处理订阅创建/更新/取消、账单支付和价格变更事件，同步本地订阅与计划表',
+  })
+  @ApiHeader({ name: 'stripe-signature', description: 'Stripe webhook 签名', required: true })
+  @ApiResponse({ status: 200, description: '事件已处理' })
+  @ApiResponse({ status: 400, description: '签名无效或请求体缺失' })
+  async handleWebhook(
+    @Req() req: RawBodyRequest<Request>,
+    @Headers('stripe-signature') signature: string,
+  ): Promise<BillingSyncResult> {
+    if (!signature || !req.rawBody) {
+      throw new BadRequestException('Missing Stripe signature or raw body');
+    }
+
+    let event;
+    try {
+      event = this.stripeService.constructWebhookEvent(req.rawBody, signature);
+    } catch (error) {
+      this.logger.warn(`Rejected Stripe billing webhook: ${error.message}`);
+      throw new BadRequestException('Invalid Stripe signature');
+    }
+
+    return this.billingSyncService.handleEvent(event);
+  }
+}
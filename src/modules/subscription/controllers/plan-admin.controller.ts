@@ -0,0 +1,58 @@
+import { Controller, Put, Body, Param, UseGuards } from '@nestjs/common';
+import { ApiTags, ApiOperation, ApiResponse, ApiBearerAuth, ApiParam } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../../auth/guards/admin.guard';
+import { PlanLimitsService } from '../services/plan-limits.service';
+import { PlanQuotaModeDto } from '../dto/plan-quota-mode.dto';
+import { PlanStorageLimitsDto } from '../dto/plan-storage-limits.dto';
+import { PlanRetentionDto } from '../dto/plan-retention.dto';
+
+@ApiTags('admin')
+@Controller('admin/plans')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard, AdminGuard)
+export class PlanAdminController {
+  constructor(private readonly planLimitsService: PlanLimitsService) {}
+
+  @Put(':planId/quota-mode')
+  @ApiOperation({ summary: '设置计划的额度模式（硬拒绝 / 软警告）' })
+  @ApiParam({ name: 'planId', description: '订阅计划 ID' })
+  @ApiResponse({ status: 200, description: '额度模式已更新' })
+  @ApiResponse({ status: 400, description: 'overage 模式缺少计量价格' })
+  @ApiResponse({ status: 403, description: '需要管理员权限' })
+  @ApiResponse({ status: 404, description: '订阅计划不存在' })
+  async setQuotaMode(@Param('planId') planId: string, @Body() dto: PlanQuotaModeDto) {
+    const plan = await this.planLimitsService.setPlanQuotaMode(planId, dto.quotaMode, dto.overagePriceId);
+    return {
+      planId: plan.id,
+      tier: plan.tier,
+      quotaMode: dto.quotaMode,
+      overagePriceId: plan.metadata?.overagePriceId ?? null,
+    };
+  }
+
+  @Put(':planId/storage-limits')
+  @ApiOperation({ summary: '设置计划的存储上限（文档数 / 总字节数）' })
+  @ApiParam({ name: 'planId', description: '订阅计划 ID' })
+  @ApiResponse({ status: 200, description: '存储上限已更新' })
+  @ApiResponse({ status: 404, description: '订阅计划不存在' })
+  async setStorageLimits(@Param('planId') planId: string, @Body() dto: PlanStorageLimitsDto) {
+    const plan = await this.planLimitsService.setPlanStorageLimits(planId, dto.maxStoredDocuments, dto.maxStoredBytes);
+    return {
+      planId: plan.id,
+      tier: plan.tier,
+      maxStoredDocuments: plan.metadata?.maxStoredDocuments ?? null,
+      maxStoredBytes: plan.metadata?.maxStoredBytes ?? null,
+    };
+  }
+
+  @Put(':planId/retention')
+  @ApiOperation({ summary: '设置计划的文档内容保留天数，到期后由清理任务清除原文和译文' })
+  @ApiParam({ name: 'planId', description: '订阅计划 ID' })
+  @ApiResponse({ status: 200, description: '保留天数已更新' })
+  @ApiResponse({ status: 404, description: '订阅计划不存在' })
+  async setRetention(@Param('planId') planId: string, @Body() dto: PlanRetentionDto) {
+    const plan = await this.planLimitsService.setPlanRetention(planId, dto.retentionDays);
+    return { planId: plan.id, tier: plan.tier, retentionDays: plan.metadata?.retentionDays ?? null };
+  }
+}
@@ -0,0 +1,48 @@
+import { Controller, Get, Put, Delete, Body, Param, UseGuards, Req, NotFoundException } from '@nestjs/common';
+import { ApiTags, ApiOperation, ApiResponse, ApiBearerAuth, ApiParam } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../../auth/guards/admin.guard';
+import { PlanLimitsService } from '../services/plan-limits.service';
+import { PlanOverrideDto } from '../dto/plan-override.dto';
+
+@ApiTags('admin')
+@Controller('admin/plan-overrides')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard, AdminGuard)
+export class PlanOverrideController {
+  constructor(private readonly planLimitsService: PlanLimitsService) {}
+
+  @Get(':userId')
+  @ApiOperation({ summary: '获取用户的计划覆盖及生效限额' })
+  @ApiParam({ name: 'userId', description: '用户 ID' })
+  @ApiResponse({ status: 200, description: '返回覆盖配置和解析后的限额' })
+  async getOverride(@Param('userId') userId: string) {
+    const [override, limits] = await Promise.all([
+      this.planLimitsService.getOverride(userId),
+      this.planLimitsService.resolve(userId),
+    ]);
+    return { override, limits };
+  }
+
+  @Put(':userId')
+  @ApiOperation({ summary: '设置用户的计划覆盖' })
+  @ApiParam({ name: 'userId', description: '用户 ID' })
+  @ApiResponse({ status: 200, description: '覆盖设置成功' })
+  @ApiResponse({ status: 403, description: '需要管理员权限' })
+  async setOverride(@Req() req: any, @Param('userId') userId: string, @Body() dto: PlanOverrideDto) {
+    return this.planLimitsService.setOverride(userId, dto, req.user.id);
+  }
+
+  @Delete(':userId')
+  @ApiOperation({ summary: '移除用户的计划覆盖' })
+  @ApiParam({ name: 'userId', description: '用户 ID' })
+  @ApiResponse({ status: 200, description: '覆盖已移除' })
+  async removeOverride(@Param('userId') userId: string) {
+    const override = await this.planLimitsService.getOverride(userId);
+    if (!override) {
+      throw new NotFoundException('Plan override not found');
+    }
+    await this.planLimitsService.removeOverride(userId);
+    return { success: true };
+  }
+}
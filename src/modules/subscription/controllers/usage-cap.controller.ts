@@ -0,0 +1,42 @@
+import { Controller, Get, Put, Body, UseGuards, Req } from '@nestjs/common';
+import { ApiTags, ApiOperation, ApiResponse, ApiBearerAuth } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../../auth/guards/jwt-auth.guard';
+import { PlanLimitsService } from '../services/plan-limits.service';
+import { OverageBillingService } from '../services/overage-billing.service';
+import { UsageCapDto } from '../dto/usage-cap.dto';
+
+@ApiTags('subscription')
+@Controller('subscription/usage-cap')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard)
+export class UsageCapController {
+  constructor(
+    private readonly planLimitsService: PlanLimitsService,
+    private readonly overageBillingService: OverageBillingService,
+  ) {}
+
+  @Get()
+  @ApiOperation({ summary: '获取每月字符上限及本月超额用量' })
+  @ApiResponse({ status: 200, description: '返回上限、额度模式和本月超额字符数' })
+  async getUsageCap(@Req() req: any) {
+    const [cap, limits, overage] = await Promise.all([
+      this.planLimitsService.getUsageCap(req.user.id),
+      this.planLimitsService.resolve(req.user.id),
+      this.overageBillingService.getUsage(req.user.id),
+    ]);
+    return {
+      monthlyCharacterCap: cap?.monthlyCharacterCap ?? null,
+      monthlyCharacterLimit: limits?.monthlyCharacterLimit ?? 0,
+      quotaMode: limits?.quotaMode,
+      overageCharacters: overage?.characters ?? 0,
+    };
+  }
+
+  @Put()
+  @ApiOperation({ summary: '设置每月字符上限（硬上限，超出后拒绝请求）' })
+  @ApiResponse({ status: 200, description: '上限已更新' })
+  async setUsageCap(@Req() req: any, @Body() dto: UsageCapDto) {
+    const cap = await this.planLimitsService.setUsageCap(req.user.id, dto.monthlyCharacterCap);
+    return { monthlyCharacterCap: cap?.monthlyCharacterCap ?? null };
+  }
+}
@@ -0,0 +1,30 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsOptional, IsInt, Min, IsObject, IsString, IsDateString } from 'class-validator';
+import { PlanFeatures } from '../interfaces/plan-limits.interface';
+
+export class PlanOverrideDto {
+  @ApiProperty({ description: '每月字符额度覆盖值', required: false, example: 500000 })
+  @IsOptional()
+  @IsInt()
+  @Min(0)
+  monthlyCharacterLimit?: number;
+
+  @ApiProperty({
+    description: '功能开关覆盖值',
+    required: false,
+    example: { webhooks: true, customIntegrations: true },
+  })
+  @IsOptional()
+  @IsObject()
+  features?: Partial<PlanFeatures>;
+
+  @ApiProperty({ description: '设置原因', required: false })
+  @IsOptional()
+  @IsString()
+  reason?: string;
+
+  @ApiProperty({ description: '覆盖的过期时间（可选）', required: false, example: '2026-12-31T23:59:59Z' })
+  @IsOptional()
+  @IsDateString()
+  expiresAt?: Date;
+}
@@ -0,0 +1,18 @@
+import { ApiProperty, ApiPropertyOptional } from '@nestjs/swagger';
+import { IsEnum, IsOptional, IsString } from 'class-validator';
+import { QuotaMode } from '../interfaces/plan-limits.interface';
+
+export class PlanQuotaModeDto {
+  @ApiProperty({
+    description: '额度用尽时的处理方式：hard 直接拒绝，soft 本月内继续放行并返回警告，overage 继续放行并按超额用量计费',
+    enum: QuotaMode,
+    example: QuotaMode.SOFT,
+  })
+  @IsEnum(QuotaMode)
+  quotaMode: QuotaMode;
+
+  @ApiPropertyOptional({ description: 'overage 模式使用的 Stripe 计量价格 ID', example: 'price_1Overage' })
+  @IsOptional()
+  @IsString()
+  overagePriceId?: string;
+}
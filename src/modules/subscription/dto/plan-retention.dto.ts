@@ -0,0 +1,11 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsInt, Max, Min, ValidateIf } from 'class-validator';
+
+export class PlanRetentionDto {
+  @ApiProperty({ description: '文档内容保留天数，超过后清除原文和译文；null 表示不清理', nullable: true, example: 90 })
+  @ValidateIf((_, value) => value !== null)
+  @IsInt()
+  @Min(1)
+  @Max(3650)
+  retentionDays: number | null;
+}
@@ -0,0 +1,16 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsInt, Min, ValidateIf } from 'class-validator';
+
+export class PlanStorageLimitsDto {
+  @ApiProperty({ description: '可保存的文档数上限，null 恢复档位默认值', nullable: true, example: 1000 })
+  @ValidateIf((_, value) => value !== null)
+  @IsInt()
+  @Min(0)
+  maxStoredDocuments: number | null;
+
+  @ApiProperty({ description: '已保存文档的总字节数上限，null 恢复档位默认值', nullable: true, example: 104857600 })
+  @ValidateIf((_, value) => value !== null)
+  @IsInt()
+  @Min(0)
+  maxStoredBytes: number | null;
+}
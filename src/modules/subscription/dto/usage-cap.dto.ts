@@ -0,0 +1,14 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsInt, Min, ValidateIf } from 'class-validator';
+
+export class UsageCapDto {
+  @ApiProperty({
+    description: '每月字符上限，达到后拒绝新请求；传 null 取消上限',
+    example: 2000000,
+    nullable: true,
+  })
+  @ValidateIf((_, value) => value !== null)
+  @IsInt()
+  @Min(0)
+  monthlyCharacterCap: number | null;
+}
@@ -0,0 +1,25 @@
+import { Entity, Property, Unique } from '@mikro-orm/core';
+import { BaseEntity } from '../../../common/entities/base.entity';
+
+/**
+ * 超额用量
+ * 每个用户每个计费周期（YYYY-MM）一行，reportedUnits 记录已上报给 Stripe 的计量单位数
+ */
+@Entity({ tableName: 'overage_usage' })
+@Unique({ properties: ['userId', 'period'] })
+export class OverageUsage extends BaseEntity {
+  @Property()
+  userId!: string;
+
+  @Property({ length: 7 })
+  period!: string;
+
+  @Property()
+  characters: number = 0;
+
+  @Property()
+  reportedUnits: number = 0;
+
+  @Property({ nullable: true })
+  lastReportedAt?: Date;
+}
@@ -1,4 +1,5 @@
 import { Entity, PrimaryKey, Property } from '@mikro-orm/core';
+import { PlanMetadata } from '../interfaces/plan-limits.interface';
 
 export enum SubscriptionTier {
   FREE = 'free',
@@ -39,6 +40,9 @@ export class SubscriptionPlan {
   @Property()
   features: string[];
 
+  @Property({ type: 'json', nullable: true })
+  metadata?: PlanMetadata;
+
   @Property()
   createdAt: Date = new Date();
 
@@ -0,0 +1,29 @@
+import { Entity, Property, Unique } from '@mikro-orm/core';
+import { BaseEntity } from '../../../common/entities/base.entity';
+import { PlanFeatures } from '../interfaces/plan-limits.interface';
+
+/**
+ * 用户计划覆盖配置
+ * 管理员可为单个用户调整字符额度或功能开关，优先级高于计划本身
+ */
+@Entity({ tableName: 'user_plan_override' })
+export class UserPlanOverride extends BaseEntity {
+  @Property()
+  @Unique()
+  userId!: string;
+
+  @Property({ nullable: true })
+  monthlyCharacterLimit?: number;
+
+  @Property({ type: 'json', nullable: true })
+  features?: Partial<PlanFeatures>;
+
+  @Property({ type: 'text', nullable: true })
+  reason?: string;
+
+  @Property({ nullable: true })
+  setBy?: string;
+
+  @Property({ nullable: true })
+  expiresAt?: Date;
+}
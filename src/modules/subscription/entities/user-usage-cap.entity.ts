@@ -0,0 +1,16 @@
+import { Entity, Property, Unique } from '@mikro-orm/core';
+import { BaseEntity } from '../../../common/entities/base.entity';
+
+/**
+ * 用户自设的每月字符上限
+ * 用于控制超额计费的支出，达到上限后无论计划额度模式如何都拒绝新请求
+ */
+@Entity({ tableName: 'user_usage_cap' })
+export class UserUsageCap extends BaseEntity {
+  @Property()
+  @Unique()
+  userId!: string;
+
+  @Property()
+  monthlyCharacterCap!: number;
+}
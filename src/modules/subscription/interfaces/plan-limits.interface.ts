@@ -0,0 +1,66 @@
+import { SubscriptionTier } from '../entities/subscription-plan.entity';
+
+/**
+ * 计划功能开关
+ */
+export interface PlanFeatures {
+  apiAccess: boolean;
+  webhooks: boolean;
+  prioritySupport: boolean;
+  customIntegrations: boolean;
+}
+
+export type PlanFeature = keyof PlanFeatures;
+
+/**
+ * 额度用尽时的处理方式
+ * hard: 直接拒绝新请求；soft: 本月内继续放行并返回警告；
+ * overage: 继续放行，超出部分作为超额用量通过 Stripe 计量计费
+ */
+export enum QuotaMode {
+  HARD = 'hard',
+  SOFT = 'soft',
+  OVERAGE = 'overage',
+}
+
+/**
+ * 计划元数据结构
+ * 存储在 subscription_plan.metadata 中，也可来自 Stripe 价格的 metadata（字符串值）
+ */
+export interface PlanMetadata {
+  monthlyCharacterLimit?: number;
+  features?: Partial<PlanFeatures>;
+  quotaMode?: QuotaMode;
+  /** 超额计费使用的 Stripe 计量价格 */
+  overagePriceId?: string;
+  /** 可保存的文档数上限，未设置时使用档位默认值 */
+  maxStoredDocuments?: number;
+  /** 已保存文档（原文 + 译文）的总字节数上限，未设置时使用档位默认值 */
+  maxStoredBytes?: number;
+  /** 文档内容的保留天数，超过后由清理任务清除原文和译文；未设置时不清理 */
+  retentionDays?: number;
+}
+
+/**
+ * 解析后的用户计划限额
+ */
+export interface ResolvedPlanLimits {
+  planId: string;
+  tier: SubscriptionTier;
+  monthlyCharacterLimit: number;
+  features: PlanFeatures;
+  quotaMode: QuotaMode;
+  overagePriceId?: string;
+  /** 用户自行设置的每月字符上限，任何额度模式下都不会超过 */
+  userCharacterCap?: number;
+  /** 存储上限，null 表示不限 */
+  maxStoredDocuments?: number | null;
+  maxStoredBytes?: number | null;
+  /** 文档内容保留天数，null 表示不清理 */
+  retentionDays?: number | null;
+  overridden: boolean;
+  /** 管理员覆盖的到期时间，缓存不会超过这个时间 */
+  overrideExpiresAt?: string;
+  subscriptionStatus?: string;
+  currentPeriodEnd?: string;
+}
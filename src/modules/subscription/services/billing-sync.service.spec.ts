@@ -0,0 +1,167 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { EntityManager } from '@mikro-orm/core';
+import { getQueueToken } from '@nestjs/bull';
+import { BillingSyncService, mapStripeSubscriptionStatus, RERANK_QUEUED_TASKS_JOB } from './billing-sync.service';
+import { PlanCacheService } from './plan-cache.service';
+import { PlanLimitsService } from './plan-limits.service';
+import { RedisService } from '../../../common/services/redis.service';
+import { SubscriptionTier } from '../entities/subscription-plan.entity';
+import { SubscriptionStatus } from '../entities/user-subscription.entity';
+
+describe('BillingSyncService', () => {
+  let service: BillingSyncService;
+
+  const mockEntityManager = {
+    findOne: jest.fn(),
+    find: jest.fn(),
+    create: jest.fn((_entity, data) => ({ ...data })),
+    persistAndFlush: jest.fn(),
+  };
+
+  const mockRedisService = {
+    setIfAbsent: jest.fn(),
+    del: jest.fn(),
+  };
+
+  const mockPlanCacheService = {
+    invalidate: jest.fn(),
+    invalidateAll: jest.fn(),
+  };
+
+  const mockPlanLimitsService = {
+    resolve: jest.fn().mockResolvedValue({ tier: SubscriptionTier.HOBBY }),
+  };
+
+  const mockTranslationQueue = {
+    add: jest.fn(),
+  };
+
+  const hobbyPlan = { id: 'plan-hobby', tier: SubscriptionTier.HOBBY, stripePriceId: 'price_hobby' };
+
+  const stripeSubscription = (overrides: Record<string, any> = {}) => ({
+    id: 'sub_1',
+    customer: 'cus_1',
+    status: 'active',
+    metadata: {},
+    current_period_start: 1700000000,
+    current_period_end: 1702592000,
+    cancel_at_period_end: false,
+    items: { data: [{ price: { id: 'price_hobby' } }] },
+    ...overrides,
+  });
+
+  const event = (type: string, object: any) => ({ id: 'evt_1', type, data: { object } }) as any;
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        BillingSyncService,
+        { provide: EntityManager, useValue: mockEntityManager },
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: PlanCacheService, useValue: mockPlanCacheService },
+        { provide: PlanLimitsService, useValue: mockPlanLimitsService },
+        { provide: getQueueToken('translation'), useValue: mockTranslationQueue },
+      ],
+    }).compile();
+
+    service = module.get<BillingSyncService>(BillingSyncService);
+    mockRedisService.setIfAbsent.mockResolvedValue(true);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('重复投递的事件应直接跳过', async () => {
+    mockRedisService.setIfAbsent.mockResolvedValue(false);
+
+    const result = await service.handleEvent(event('customer.subscription.updated', stripeSubscription()));
+
+    expect(result.duplicate).toBe(true);
+    expect(mockEntityManager.findOne).not.toHaveBeenCalled();
+  });
+
+  it('新订阅应按客户 ID 关联用户并创建本地记录', async () => {
+    const user = { id: 'user1' } as any;
+    mockEntityManager.findOne
+      .mockResolvedValueOnce(hobbyPlan)
+      .mockResolvedValueOnce(null)
+      .mockResolvedValueOnce(user);
+
+    await service.handleEvent(event('customer.subscription.created', stripeSubscription({ status: 'trialing' })));
+
+    const [saved] = mockEntityManager.persistAndFlush.mock.calls[0][0];
+    expect(saved.stripeSubscriptionId).toBe('sub_1');
+    expect(saved.status).toBe(SubscriptionStatus.TRIALING);
+    expect(user.subscriptionPlan).toBe(hobbyPlan);
+    expect(mockPlanCacheService.invalidate).toHaveBeenCalledWith('user1');
+  });
+
+  it('取消订阅应把本地状态置为 canceled', async () => {
+    const existing = { user: { id: 'user1' }, plan: hobbyPlan, status: SubscriptionStatus.ACTIVE } as any;
+    mockEntityManager.findOne.mockResolvedValueOnce(hobbyPlan).mockResolvedValueOnce(existing);
+
+    await service.handleEvent(event('customer.subscription.deleted', stripeSubscription()));
+
+    expect(existing.status).toBe(SubscriptionStatus.CANCELED);
+  });
+
+  it('计划等级变化时应让翻译 worker 重新排列排队中的任务', async () => {
+    const existing = { user: { id: 'user1' }, plan: hobbyPlan, status: SubscriptionStatus.ACTIVE } as any;
+    mockEntityManager.findOne.mockResolvedValueOnce(hobbyPlan).mockResolvedValueOnce(existing);
+    mockPlanLimitsService.resolve
+      .mockResolvedValueOnce({ tier: SubscriptionTier.FREE })
+      .mockResolvedValueOnce({ tier: SubscriptionTier.HOBBY });
+
+    await service.handleEvent(event('customer.subscription.updated', stripeSubscription()));
+
+    expect(mockTranslationQueue.add).toHaveBeenCalledWith(
+      RERANK_QUEUED_TASKS_JOB,
+      { userId: 'user1', tier: SubscriptionTier.HOBBY },
+      expect.objectContaining({ priority: 1 }),
+    );
+  });
+
+  it('计划等级不变时不重新排列', async () => {
+    const existing = { user: { id: 'user1' }, plan: hobbyPlan, status: SubscriptionStatus.ACTIVE } as any;
+    mockEntityManager.findOne.mockResolvedValueOnce(hobbyPlan).mockResolvedValueOnce(existing);
+
+    await service.handleEvent(event('customer.subscription.updated', stripeSubscription()));
+
+    expect(mockTranslationQueue.add).not.toHaveBeenCalled();
+  });
+
+  it('价格更新应同步金额和 metadata 并清除所有计划缓存', async () => {
+    const plan = { ...hobbyPlan, price: 5, currency: 'USD', monthlyCharacterLimit: 100000, metadata: {} } as any;
+    mockEntityManager.findOne.mockResolvedValueOnce(plan);
+
+    await service.handleEvent(
+      event('price.updated', {
+        id: 'price_hobby',
+        unit_amount: 900,
+        currency: 'eur',
+        metadata: { monthly_character_limit: '200000' },
+      }),
+    );
+
+    expect(plan.price).toBe(9);
+    expect(plan.currency).toBe('EUR');
+    expect(plan.monthlyCharacterLimit).toBe(200000);
+    expect(mockPlanCacheService.invalidateAll).toHaveBeenCalled();
+  });
+
+  it('处理失败时应释放去重标记以便 Stripe 重试', async () => {
+    mockEntityManager.findOne.mockRejectedValue(new Error('db down'));
+
+    await expect(service.handleEvent(event('invoice.paid', { id: 'in_1', subscription: 'sub_1' }))).rejects.toThrow(
+      'db down',
+    );
+    expect(mockRedisService.del).toHaveBeenCalledWith('stripe_billing_event:evt_1');
+  });
+
+  it('应该把 Stripe 订阅状态映射为本地状态', () => {
+    expect(mapStripeSubscriptionStatus('past_due')).toBe(SubscriptionStatus.PAST_DUE);
+    expect(mapStripeSubscriptionStatus('incomplete_expired')).toBe(SubscriptionStatus.CANCELED);
+    expect(mapStripeSubscriptionStatus('incomplete')).toBe(SubscriptionStatus.UNPAID);
+  });
+});
@@ -0,0 +1,277 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { EntityManager } from '@mikro-orm/core';
+import { InjectQueue } from '@nestjs/bull';
+import { Queue } from 'bull';
+import Stripe from 'stripe';
+import { SubscriptionPlan, SubscriptionTier } from '../entities/subscription-plan.entity';
+import { UserSubscription, SubscriptionStatus } from '../entities/user-subscription.entity';
+import { User } from '../../user/entities/user.entity';
+import { RedisService } from '../../../common/services/redis.service';
+import { PlanCacheService } from './plan-cache.service';
+import { parsePlanMetadata, PlanLimitsService } from './plan-limits.service';
+
+const EVENT_DEDUPE_TTL_SECONDS = 7 * 24 * 3600;
+
+/** 计划等级变化后由翻译 worker 按新等级重新排列用户排队中的任务 */
+export const RERANK_QUEUED_TASKS_JOB = 'rerank-queued-tasks';
+
+export interface RerankQueuedTasksJob {
+  userId: string;
+  tier: SubscriptionTier;
+}
+
+export interface BillingSyncResult {
+  eventId: string;
+  eventType: string;
+  handled: boolean;
+  duplicate: boolean;
+}
+
+/**
+ * Stripe 订阅计费同步服务
+ * 消费 Stripe webhook 事件，把订阅状态和价格写入 user_subscription / subscription_plan 表，
+ * 本地表以 Stripe 为准，不再需要额外的同步脚本
+ */
+@Injectable()
+export class BillingSyncService {
+  private readonly logger = new Logger(BillingSyncService.name);
+
+  constructor(
+    private readonly em: EntityManager,
+    private readonly redisService: RedisService,
+    private readonly planCacheService: PlanCacheService,
+    private readonly planLimitsService: PlanLimitsService,
+    @InjectQueue('translation') private readonly translationQueue: Queue,
+  ) {}
+
+  async handleEvent(event: Stripe.Event): Promise<BillingSyncResult> {
+    const result: BillingSyncResult = { eventId: event.id, eventType: event.type, handled: true, duplicate: false };
+
+    // Stripe 可能重复投递同一事件，按事件 ID 去重
+    const dedupeKey = `stripe_billing_event:${event.id}`;
+    if (!(await this.redisService.setIfAbsent(dedupeKey, '1', EVENT_DEDUPE_TTL_SECONDS))) {
+      this.logger.log(`Skipping duplicate Stripe event ${event.id}`);
+      return { ...result, duplicate: true };
+    }
+
+    try {
+      switch (event.type) {
+        case 'customer.subscription.created':
+        case 'customer.subscription.updated':
+          await this.syncSubscription(event.data.object as Stripe.Subscription);
+          break;
+        case 'customer.subscription.deleted':
+          await this.syncSubscription(event.data.object as Stripe.Subscription, true);
+          break;
+        case 'invoice.paid':
+          await this.handleInvoicePaid(event.data.object as Stripe.Invoice);
+          break;
+        case 'price.created':
+        case 'price.updated':
+          await this.syncPrice(event.data.object as Stripe.Price);
+          break;
+        case 'product.updated':
+          await this.syncProduct(event.data.object as Stripe.Product);
+          break;
+        default:
+          this.logger.log(`Unhandled billing event type: ${event.type}`);
+          result.handled = false;
+      }
+    } catch (error) {
+      // 处理失败时释放去重标记，让 Stripe 的重试可以再次处理
+      await this.redisService.del(dedupeKey);
+      this.logger.error(`Failed to sync Stripe event ${event.id} (${event.type}): ${error.message}`);
+      throw error;
+    }
+
+    return result;
+  }
+
+  /**
+   * 按 Stripe 订阅创建或更新本地订阅记录
+   */
+  async syncSubscription(subscription: Stripe.Subscription, deleted = false): Promise<UserSubscription | null> {
+    const price = subscription.items?.data?.[0]?.price;
+    const plan = price ? await this.findOrSyncPlan(price) : null;
+    if (!plan) {
+      this.logger.warn(`No local plan for subscription ${subscription.id}, skipping`);
+      return null;
+    }
+
+    let userSubscription = await this.em.findOne(
+      UserSubscription,
+      { stripeSubscriptionId: subscription.id },
+      { populate: ['user'] },
+    );
+
+    let user = userSubscription?.user ?? null;
+    if (!user) {
+      user = await this.findUser(subscription);
+      if (!user) {
+        this.logger.warn(`No local user for subscription ${subscription.id} (customer ${subscription.customer}), skipping`);
+        return null;
+      }
+    }
+
+    const previousTier = (await this.planLimitsService.resolve(user.id))?.tier;
+    if (!userSubscription) {
+      userSubscription = this.em.create(UserSubscription, {
+        id: subscription.id,
+        user,
+        plan,
+        stripeSubscriptionId: subscription.id,
+        status: SubscriptionStatus.ACTIVE,
+        currentPeriodStart: new Date(subscription.current_period_start * 1000),
+        currentPeriodEnd: new Date(subscription.current_period_end * 1000),
+      });
+    }
+
+    userSubscription.plan = plan;
+    userSubscription.status = deleted ? SubscriptionStatus.CANCELED : mapStripeSubscriptionStatus(subscription.status);
+    userSubscription.currentPeriodStart = new Date(subscription.current_period_start * 1000);
+    userSubscription.currentPeriodEnd = new Date(subscription.current_period_end * 1000);
+    userSubscription.cancelAtPeriodEnd = !!subscription.cancel_at_period_end;
+
+    if (
+      userSubscription.status === SubscriptionStatus.ACTIVE ||
+      userSubscription.status === SubscriptionStatus.TRIALING
+    ) {
+      user.subscriptionPlan = plan;
+    }
+
+    await this.em.persistAndFlush([userSubscription, user]);
+    await this.planCacheService.invalidate(user.id);
+    await this.scheduleRerank(user.id, previousTier);
+
+    this.logger.log(`Synced subscription ${subscription.id} for user ${user.id}: ${userSubscription.status}`);
+    return userSubscription;
+  }
+
+  /**
+   * 升降级后让排队中的任务按新等级的优先级执行，等级未变化时不做处理
+   */
+  private async scheduleRerank(userId: string, previousTier?: SubscriptionTier): Promise<void> {
+    const tier = (await this.planLimitsService.resolve(userId))?.tier;
+    if (!tier || tier === previousTier) {
+      return;
+    }
+    const job: RerankQueuedTasksJob = { userId, tier };
+    // 排在积压任务之前执行，否则重新排列没有意义
+    await this.translationQueue.add(RERANK_QUEUED_TASKS_JOB, job, { priority: 1, removeOnComplete: true });
+    this.logger.log(`Plan tier of user ${userId} changed from ${previousTier ?? 'none'} to ${tier}, re-ranking queue`);
+  }
+
+  /**
+   * 账单支付成功：订阅恢复为 active 并记录付款时间
+   */
+  async handleInvoicePaid(invoice: Stripe.Invoice): Promise<void> {
+    if (!invoice.subscription) {
+      return;
+    }
+    const subscriptionId = typeof invoice.subscription === 'string' ? invoice.subscription : invoice.subscription.id;
+    const userSubscription = await this.em.findOne(
+      UserSubscription,
+      { stripeSubscriptionId: subscriptionId },
+      { populate: ['user'] },
+    );
+    if (!userSubscription) {
+      this.logger.warn(`Invoice ${invoice.id} paid for unknown subscription ${subscriptionId}`);
+      return;
+    }
+
+    const paidAt = invoice.status_transitions?.paid_at;
+    userSubscription.status = SubscriptionStatus.ACTIVE;
+    userSubscription.lastPaymentDate = paidAt ? new Date(paidAt * 1000) : new Date();
+    await this.em.persistAndFlush(userSubscription);
+    await this.planCacheService.invalidate(userSubscription.user.id);
+  }
+
+  /**
+   * 同步价格到计划表：已有计划更新价格和元数据；
+   * 未知价格只有在 metadata 中声明了 tier 时才新建计划
+   */
+  async syncPrice(price: Stripe.Price): Promise<SubscriptionPlan | null> {
+    let plan = await this.em.findOne(SubscriptionPlan, { stripePriceId: price.id });
+    const metadata = parsePlanMetadata(price.metadata);
+
+    if (!plan) {
+      const tier = price.metadata?.tier as SubscriptionTier;
+      if (!Object.values(SubscriptionTier).includes(tier)) {
+        this.logger.log(`Ignoring Stripe price ${price.id} without a plan tier`);
+        return null;
+      }
+      const product = typeof price.product === 'object' ? (price.product as Stripe.Product) : null;
+      plan = this.em.create(SubscriptionPlan, {
+        id: price.id,
+        name: price.nickname || product?.name || tier,
+        description: product?.description || '',
+        tier,
+        price: 0,
+        stripePriceId: price.id,
+        stripeProductId: typeof price.product === 'string' ? price.product : price.product.id,
+        monthlyCharacterLimit: metadata.monthlyCharacterLimit ?? 0,
+        features: [],
+      });
+    }
+
+    plan.price = (price.unit_amount ?? 0) / 100;
+    plan.currency = price.currency.toUpperCase();
+    if (metadata.monthlyCharacterLimit !== undefined) {
+      plan.monthlyCharacterLimit = metadata.monthlyCharacterLimit;
+    }
+    plan.metadata = { ...plan.metadata, ...metadata };
+
+    await this.em.persistAndFlush(plan);
+    await this.planCacheService.invalidateAll();
+
+    this.logger.log(`Synced Stripe price ${price.id} to plan ${plan.id}`);
+    return plan;
+  }
+
+  /**
+   * 同步产品名称和描述到关联的计划
+   */
+  async syncProduct(product: Stripe.Product): Promise<void> {
+    const plans = await this.em.find(SubscriptionPlan, { stripeProductId: product.id });
+    for (const plan of plans) {
+      plan.name = product.name;
+      plan.description = product.description ?? plan.description;
+    }
+    if (plans.length > 0) {
+      await this.em.persistAndFlush(plans);
+    }
+  }
+
+  private async findOrSyncPlan(price: Stripe.Price): Promise<SubscriptionPlan | null> {
+    const plan = await this.em.findOne(SubscriptionPlan, { stripePriceId: price.id });
+    return plan ?? this.syncPrice(price);
+  }
+
+  private async findUser(subscription: Stripe.Subscription): Promise<User | null> {
+    const userId = subscription.metadata?.userId;
+    if (userId) {
+      const user = await this.em.findOne(User, { id: userId });
+      if (user) {
+        return user;
+      }
+    }
+    const customerId = typeof subscription.customer === 'string' ? subscription.customer : subscription.customer?.id;
+    return customerId ? this.em.findOne(User, { stripeCustomerId: customerId }) : null;
+  }
+}
+
+export function mapStripeSubscriptionStatus(status: Stripe.Subscription.Status): SubscriptionStatus {
+  switch (status) {
+    case 'active':
+      return SubscriptionStatus.ACTIVE;
+    case 'trialing':
+      return SubscriptionStatus.TRIALING;
+    case 'past_due':
+      return SubscriptionStatus.PAST_DUE;
+    case 'canceled':
+    case 'incomplete_expired':
+      return SubscriptionStatus.CANCELED;
+    default:
+      return SubscriptionStatus.UNPAID;
+  }
+}
@@ -0,0 +1,106 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { Cron, CronExpression } from '@nestjs/schedule';
+import { EntityManager } from '@mikro-orm/core';
+import { OverageUsage } from '../entities/overage-usage.entity';
+import { UserSubscription, SubscriptionStatus } from '../entities/user-subscription.entity';
+import { PlanLimitsService } from './plan-limits.service';
+import { StripeService } from './stripe.service';
+
+/**
+ * 超额计费服务
+ * 记录 overage 模式下超出计划额度的字符数，并定时按计量单位上报到 Stripe
+ */
+@Injectable()
+export class OverageBillingService {
+  private readonly logger = new Logger(OverageBillingService.name);
+  private readonly unitCharacters: number;
+
+  constructor(
+    private readonly em: EntityManager,
+    private readonly configService: ConfigService,
+    private readonly planLimitsService: PlanLimitsService,
+    private readonly stripeService: StripeService,
+  ) {
+    this.unitCharacters = Math.max(Number(this.configService.get('OVERAGE_UNIT_CHARACTERS', 1000)), 1);
+  }
+
+  /**
+   * 累加当前计费周期的超额字符数
+   */
+  async record(userId: string, characters: number, period = currentPeriod()): Promise<OverageUsage> {
+    let usage = await this.em.findOne(OverageUsage, { userId, period });
+    if (!usage) {
+      usage = this.em.create(OverageUsage, { userId, period, characters: 0, reportedUnits: 0 });
+    }
+    usage.characters += characters;
+    await this.em.persistAndFlush(usage);
+    return usage;
+  }
+
+  async getUsage(userId: string, period = currentPeriod()): Promise<OverageUsage | null> {
+    return this.em.findOne(OverageUsage, { userId, period });
+  }
+
+  /**
+   * 上报尚未计费的超额用量；按单位向上取整，只上报与已上报单位数的差值
+   */
+  @Cron(CronExpression.EVERY_HOUR)
+  async reportPending(): Promise<number> {
+    const pending = await this.em.find(OverageUsage, { characters: { $gt: 0 } }, { orderBy: { period: 'ASC' } });
+    let reported = 0;
+
+    for (const usage of pending) {
+      const totalUnits = Math.ceil(usage.characters / this.unitCharacters);
+      const delta = totalUnits - usage.reportedUnits;
+      if (delta <= 0) {
+        continue;
+      }
+
+      try {
+        if (await this.report(usage, delta, totalUnits)) {
+          usage.reportedUnits = totalUnits;
+          usage.lastReportedAt = new Date();
+          await this.em.flush();
+          reported++;
+        }
+      } catch (error) {
+        this.logger.error(`Failed to report overage for user ${usage.userId} (${usage.period}): ${error.message}`);
+      }
+    }
+
+    if (reported > 0) {
+      this.logger.log(`Reported overage usage for ${reported} users`);
+    }
+    return reported;
+  }
+
+  private async report(usage: OverageUsage, units: number, totalUnits: number): Promise<boolean> {
+    const limits = await this.planLimitsService.resolve(usage.userId);
+    if (!limits?.overagePriceId) {
+      this.logger.warn(`No overage price configured for user ${usage.userId}, skipping`);
+      return false;
+    }
+
+    const subscription = await this.em.findOne(UserSubscription, {
+      user: usage.userId,
+      status: { $in: [SubscriptionStatus.ACTIVE, SubscriptionStatus.TRIALING, SubscriptionStatus.PAST_DUE] },
+    });
+    if (!subscription) {
+      this.logger.warn(`No Stripe subscription for user ${usage.userId}, skipping overage report`);
+      return false;
+    }
+
+    // 幂等键包含累计单位数，重试不会重复计费
+    return this.stripeService.reportMeteredUsage(
+      subscription.stripeSubscriptionId,
+      limits.overagePriceId,
+      units,
+      `overage:${usage.id}:${totalUnits}`,
+    );
+  }
+}
+
+function currentPeriod(): string {
+  return new Date().toISOString().slice(0, 7);
+}
@@ -0,0 +1,41 @@
+import { ConfigService } from '@nestjs/config';
+import { PlanCacheService } from './plan-cache.service';
+import { ResolvedPlanLimits } from '../interfaces/plan-limits.interface';
+
+describe('PlanCacheService', () => {
+  const redisService = { setJson: jest.fn() };
+  const configService = { get: jest.fn((key: string, defaultValue?: any) => defaultValue) };
+  const limits = { planId: 'plan-hobby', overridden: false } as ResolvedPlanLimits;
+
+  let service: PlanCacheService;
+
+  beforeEach(() => {
+    jest.clearAllMocks();
+    service = new PlanCacheService(redisService as any, configService as unknown as ConfigService);
+  });
+
+  it('按配置的时间缓存', async () => {
+    await service.set('user1', limits);
+
+    expect(redisService.setJson).toHaveBeenCalledWith('plan_limits:user1', limits, 300);
+  });
+
+  it('管理员覆盖即将到期时只缓存到到期为止', async () => {
+    const overridden = { ...limits, overridden: true, overrideExpiresAt: new Date(Date.now() + 30_000).toISOString() };
+
+    await service.set('user1', overridden);
+
+    const [, , ttlSeconds] = redisService.setJson.mock.calls[0];
+    expect(ttlSeconds).toBeGreaterThanOrEqual(29);
+    expect(ttlSeconds).toBeLessThanOrEqual(30);
+  });
+
+  it('覆盖的到期时间晚于缓存时间时仍按配置的时间缓存', async () => {
+    const overrideExpiresAt = new Date(Date.now() + 3_600_000).toISOString();
+    const overridden = { ...limits, overridden: true, overrideExpiresAt };
+
+    await service.set('user1', overridden);
+
+    expect(redisService.setJson).toHaveBeenCalledWith('plan_limits:user1', overridden, 300);
+  });
+});
@@ -0,0 +1,65 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { RedisService } from '../../../common/services/redis.service';
+import { ResolvedPlanLimits } from '../interfaces/plan-limits.interface';
+
+/**
+ * 用户计划限额缓存
+ * 缓存每个用户的订阅与价格信息，避免在创建请求的热路径上重复查询数据库
+ */
+@Injectable()
+export class PlanCacheService {
+  private readonly logger = new Logger(PlanCacheService.name);
+  private readonly keyPrefix = 'plan_limits:';
+  private readonly ttlSeconds: number;
+
+  constructor(
+    private readonly redisService: RedisService,
+    private readonly configService: ConfigService,
+  ) {
+    this.ttlSeconds = this.configService.get('PLAN_CACHE_TTL_SECONDS', 300);
+  }
+
+  async get(userId: string): Promise<ResolvedPlanLimits | null> {
+    return this.redisService.getJson<ResolvedPlanLimits>(this.key(userId));
+  }
+
+  /**
+   * 带有到期时间的管理员覆盖只缓存到到期为止，到期后重新解析回落到计划限额
+   */
+  async set(userId: string, limits: ResolvedPlanLimits): Promise<void> {
+    let ttlSeconds = Number(this.ttlSeconds);
+    if (limits.overrideExpiresAt) {
+      const remainingSeconds = Math.ceil((new Date(limits.overrideExpiresAt).getTime() - Date.now()) / 1000);
+      ttlSeconds = Math.max(Math.min(ttlSeconds, remainingSeconds), 1);
+    }
+    await this.redisService.setJson(this.key(userId), limits, ttlSeconds);
+  }
+
+  /**
+   * 订阅或价格变更后调用，下次读取时重新加载
+   */
+  async invalidate(userId: string): Promise<void> {
+    if (!userId) {
+      return;
+    }
+    await this.redisService.del(this.key(userId));
+    this.logger.debug(`Invalidated plan limits cache for user ${userId}`);
+  }
+
+  /**
+   * 计划本身变更时清除所有用户的缓存
+   */
+  async invalidateAll(): Promise<void> {
+    const stream = this.redisService.client.scanStream({ match: `${this.keyPrefix}*`, count: 100 });
+    let removed = 0;
+    for await (const keys of stream) {
+      removed += await this.redisService.del(...(keys as string[]));
+    }
+    this.logger.log(`Invalidated ${removed} cached plan limits`);
+  }
+
+  private key(userId: string): string {
+    return `${this.keyPrefix}${userId}`;
+  }
+}
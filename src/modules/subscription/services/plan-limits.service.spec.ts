@@ -0,0 +1,197 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { EntityManager } from '@mikro-orm/core';
+import { ConfigService } from '@nestjs/config';
+import { PlanLimitsService, parsePlanMetadata } from './plan-limits.service';
+import { PlanCacheService } from './plan-cache.service';
+import { SubscriptionTier } from '../entities/subscription-plan.entity';
+import { SubscriptionStatus } from '../entities/user-subscription.entity';
+import { UserPlanOverride } from '../entities/user-plan-override.entity';
+
+describe('PlanLimitsService', () => {
+  let service: PlanLimitsService;
+
+  const mockEntityManager = {
+    findOne: jest.fn(),
+    create: jest.fn(),
+    persistAndFlush: jest.fn(),
+    removeAndFlush: jest.fn(),
+  };
+
+  const mockPlanCacheService = {
+    get: jest.fn(),
+    set: jest.fn(),
+    invalidate: jest.fn(),
+  };
+
+  const hobbyPlan = {
+    id: 'plan-hobby',
+    tier: SubscriptionTier.HOBBY,
+    monthlyCharacterLimit: 100000,
+    features: [],
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        PlanLimitsService,
+        {
+          provide: EntityManager,
+          useValue: mockEntityManager,
+        },
+        {
+          provide: PlanCacheService,
+          useValue: mockPlanCacheService,
+        },
+        {
+          provide: ConfigService,
+          useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) },
+        },
+      ],
+    }).compile();
+
+    service = module.get<PlanLimitsService>(PlanLimitsService);
+    mockPlanCacheService.get.mockResolvedValue(null);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  describe('parsePlanMetadata', () => {
+    it('应该把 Stripe 字符串 metadata 转换为类型化结构', () => {
+      const metadata = parsePlanMetadata({
+        monthly_character_limit: '250000',
+        webhooks: 'true',
+        customIntegrations: 'false',
+        unknown: 'ignored',
+      });
+
+      expect(metadata).toEqual({
+        monthlyCharacterLimit: 250000,
+        features: { webhooks: true, customIntegrations: false },
+      });
+    });
+
+    it('应该忽略非法的额度值', () => {
+      expect(parsePlanMetadata({ monthlyCharacterLimit: 'abc' })).toEqual({});
+      expect(parsePlanMetadata(null)).toEqual({});
+    });
+
+    it('保留天数为 0 或非法时视为不清理', () => {
+      expect(parsePlanMetadata({ retention_days: '90' })).toEqual({ retentionDays: 90 });
+      expect(parsePlanMetadata({ retentionDays: 0 })).toEqual({});
+      expect(parsePlanMetadata({ retentionDays: '-1' })).toEqual({});
+    });
+  });
+
+  describe('resolve', () => {
+    it('命中缓存时不应查询数据库', async () => {
+      const cached = { planId: 'plan1', tier: SubscriptionTier.STANDARD, monthlyCharacterLimit: 7000000 };
+      mockPlanCacheService.get.mockResolvedValue(cached);
+
+      const result = await service.resolve('user1');
+      expect(result).toEqual(cached);
+      expect(mockEntityManager.findOne).not.toHaveBeenCalled();
+    });
+
+    it('应该从有效订阅解析额度并写入缓存', async () => {
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ status: SubscriptionStatus.ACTIVE, plan: hobbyPlan })
+        .mockResolvedValueOnce(null);
+
+      const result = await service.resolve('user1');
+      expect(result.monthlyCharacterLimit).toBe(100000);
+      expect(result.features.webhooks).toBe(true);
+      expect(result.quotaMode).toBe('hard');
+      expect(result.overridden).toBe(false);
+      expect(mockPlanCacheService.set).toHaveBeenCalledWith('user1', result);
+    });
+
+    it('计划 metadata 应覆盖计划默认值', async () => {
+      const plan = {
+        ...hobbyPlan,
+        metadata: { monthlyCharacterLimit: 150000, features: { customIntegrations: true }, quotaMode: 'soft' },
+      };
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ status: SubscriptionStatus.ACTIVE, plan })
+        .mockResolvedValueOnce(null);
+
+      const result = await service.resolve('user1');
+      expect(result.monthlyCharacterLimit).toBe(150000);
+      expect(result.features.customIntegrations).toBe(true);
+      expect(result.quotaMode).toBe('soft');
+    });
+
+    it('存储上限应取计划 metadata，未设置时使用档位默认值', async () => {
+      const plan = { ...hobbyPlan, metadata: { max_stored_documents: '20000' } };
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ status: SubscriptionStatus.ACTIVE, plan })
+        .mockResolvedValueOnce(null);
+
+      const result = await service.resolve('user1');
+      expect(result.maxStoredDocuments).toBe(20000);
+      expect(result.maxStoredBytes).toBe(500 * 1024 * 1024);
+    });
+
+    it('管理员覆盖应优先于计划配置', async () => {
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ status: SubscriptionStatus.ACTIVE, plan: hobbyPlan })
+        .mockResolvedValueOnce({ userId: 'user1', monthlyCharacterLimit: 500000, features: { webhooks: false } });
+
+      const result = await service.resolve('user1');
+      expect(result.monthlyCharacterLimit).toBe(500000);
+      expect(result.features.webhooks).toBe(false);
+      expect(result.overridden).toBe(true);
+      expect(result.overrideExpiresAt).toBeUndefined();
+    });
+
+    it('有到期时间的覆盖应带上到期时间，供缓存限制有效期', async () => {
+      const expiresAt = new Date(Date.now() + 60_000);
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ status: SubscriptionStatus.ACTIVE, plan: hobbyPlan })
+        .mockResolvedValueOnce({ userId: 'user1', monthlyCharacterLimit: 500000, expiresAt });
+
+      const result = await service.resolve('user1');
+      expect(result.overrideExpiresAt).toBe(expiresAt.toISOString());
+      expect(mockPlanCacheService.set).toHaveBeenCalledWith('user1', result);
+    });
+
+    it('过期的覆盖不应生效', async () => {
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ status: SubscriptionStatus.ACTIVE, plan: hobbyPlan })
+        .mockResolvedValueOnce({ userId: 'user1', monthlyCharacterLimit: 1, expiresAt: new Date(Date.now() - 1000) });
+
+      const result = await service.resolve('user1');
+      expect(result.monthlyCharacterLimit).toBe(100000);
+      expect(result.overridden).toBe(false);
+    });
+
+    it('没有订阅时应回退到免费计划', async () => {
+      const freePlan = { id: 'plan-free', tier: SubscriptionTier.FREE, monthlyCharacterLimit: 10000 };
+      mockEntityManager.findOne
+        .mockResolvedValueOnce(null)
+        .mockResolvedValueOnce({ id: 'user1', subscriptionPlan: null })
+        .mockResolvedValueOnce(freePlan)
+        .mockResolvedValueOnce(null);
+
+      const result = await service.resolve('user1');
+      expect(result.tier).toBe(SubscriptionTier.FREE);
+      expect(result.features.webhooks).toBe(false);
+    });
+  });
+
+  describe('setOverride', () => {
+    it('应该保存覆盖并清除缓存', async () => {
+      const override = { userId: 'user1' } as UserPlanOverride;
+      mockEntityManager.findOne.mockResolvedValue(null);
+      mockEntityManager.create.mockReturnValue(override);
+
+      await service.setOverride('user1', { monthlyCharacterLimit: 200000, reason: 'trial' }, 'admin1');
+
+      expect(override.monthlyCharacterLimit).toBe(200000);
+      expect(override.setBy).toBe('admin1');
+      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith(override);
+      expect(mockPlanCacheService.invalidate).toHaveBeenCalledWith('user1');
+    });
+  });
+});
@@ -0,0 +1,367 @@
+import { BadRequestException, Injectable, Logger, NotFoundException } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager } from '@mikro-orm/core';
+import { SubscriptionPlan, SubscriptionTier } from '../entities/subscription-plan.entity';
+import { UserSubscription, SubscriptionStatus } from '../entities/user-subscription.entity';
+import { UserPlanOverride } from '../entities/user-plan-override.entity';
+import { UserUsageCap } from '../entities/user-usage-cap.entity';
+import { User } from '../../user/entities/user.entity';
+import { PlanCacheService } from './plan-cache.service';
+import {
+  PlanFeature,
+  PlanFeatures,
+  PlanMetadata,
+  QuotaMode,
+  ResolvedPlanLimits,
+} from '../interfaces/plan-limits.interface';
+
+export interface SetPlanOverrideDto {
+  monthlyCharacterLimit?: number;
+  features?: Partial<PlanFeatures>;
+  reason?: string;
+  expiresAt?: Date;
+}
+
+const TIER_DEFAULT_FEATURES: Record<SubscriptionTier, PlanFeatures> = {
+  [SubscriptionTier.FREE]: {
+    apiAccess: false,
+    webhooks: false,
+    prioritySupport: false,
+    customIntegrations: false,
+  },
+  [SubscriptionTier.HOBBY]: {
+    apiAccess: true,
+    webhooks: true,
+    prioritySupport: true,
+    customIntegrations: false,
+  },
+  [SubscriptionTier.STANDARD]: {
+    apiAccess: true,
+    webhooks: true,
+    prioritySupport: true,
+    customIntegrations: true,
+  },
+  [SubscriptionTier.PREMIUM]: {
+    apiAccess: true,
+    webhooks: true,
+    prioritySupport: true,
+    customIntegrations: true,
+  },
+};
+
+type StorageLimits = Pick<ResolvedPlanLimits, 'maxStoredDocuments' | 'maxStoredBytes'>;
+
+/**
+ * 各档位默认的存储上限（null 表示不限），计划 metadata 中的 maxStoredDocuments / maxStoredBytes 优先
+ */
+const TIER_DEFAULT_STORAGE: Record<SubscriptionTier, StorageLimits> = {
+  [SubscriptionTier.FREE]: { maxStoredDocuments: 100, maxStoredBytes: 10 * 1024 * 1024 },
+  [SubscriptionTier.HOBBY]: { maxStoredDocuments: 5000, maxStoredBytes: 500 * 1024 * 1024 },
+  [SubscriptionTier.STANDARD]: { maxStoredDocuments: 50000, maxStoredBytes: 5 * 1024 * 1024 * 1024 },
+  [SubscriptionTier.PREMIUM]: { maxStoredDocuments: null, maxStoredBytes: null },
+};
+
+const FEATURE_KEYS: PlanFeature[] = ['apiAccess', 'webhooks', 'prioritySupport', 'customIntegrations'];
+
+/**
+ * 解析计划元数据
+ * Stripe 价格 metadata 的值都是字符串，这里统一转换为类型化结构，无法识别的字段会被忽略
+ */
+export function parsePlanMetadata(raw: Record<string, any> | null | undefined): PlanMetadata {
+  if (!raw || typeof raw !== 'object') {
+    return {};
+  }
+
+  const metadata: PlanMetadata = {};
+  const limit = raw.monthlyCharacterLimit ?? raw.monthly_character_limit;
+  if (limit !== undefined && limit !== null && limit !== '') {
+    const parsed = Number(limit);
+    if (Number.isFinite(parsed) && parsed >= 0) {
+      metadata.monthlyCharacterLimit = Math.floor(parsed);
+    }
+  }
+
+  const rawFeatures = typeof raw.features === 'object' && raw.features !== null ? raw.features : raw;
+  const features: Partial<PlanFeatures> = {};
+  for (const key of FEATURE_KEYS) {
+    const value = rawFeatures[key];
+    if (typeof value === 'boolean') {
+      features[key] = value;
+    } else if (value === 'true' || value === 'false') {
+      features[key] = value === 'true';
+    }
+  }
+  if (Object.keys(features).length > 0) {
+    metadata.features = features;
+  }
+
+  const quotaMode = raw.quotaMode ?? raw.quota_mode;
+  if (Object.values(QuotaMode).includes(quotaMode)) {
+    metadata.quotaMode = quotaMode;
+  }
+
+  const overagePriceId = raw.overagePriceId ?? raw.overage_price_id;
+  if (typeof overagePriceId === 'string' && overagePriceId) {
+    metadata.overagePriceId = overagePriceId;
+  }
+
+  const maxStoredDocuments = parseLimit(raw.maxStoredDocuments ?? raw.max_stored_documents);
+  if (maxStoredDocuments !== undefined) {
+    metadata.maxStoredDocuments = maxStoredDocuments;
+  }
+  const maxStoredBytes = parseLimit(raw.maxStoredBytes ?? raw.max_stored_bytes);
+  if (maxStoredBytes !== undefined) {
+    metadata.maxStoredBytes = maxStoredBytes;
+  }
+  const retentionDays = parseLimit(raw.retentionDays ?? raw.retention_days);
+  if (retentionDays) {
+    metadata.retentionDays = retentionDays;
+  }
+
+  return metadata;
+}
+
+function parseLimit(value: any): number | undefined {
+  if (value === undefined || value === null || value === '') {
+    return undefined;
+  }
+  const parsed = Number(value);
+  return Number.isFinite(parsed) && parsed >= 0 ? Math.floor(parsed) : undefined;
+}
+
+/**
+ * 计划限额解析服务
+ * 统一回答“该用户的字符额度和可用功能是什么”，优先级：管理员覆盖 > 有效订阅 > 用户计划 > 免费计划
+ */
+@Injectable()
+export class PlanLimitsService {
+  private readonly logger = new Logger(PlanLimitsService.name);
+
+  private readonly defaultQuotaMode: QuotaMode;
+
+  constructor(
+    private readonly em: EntityManager,
+    private readonly planCacheService: PlanCacheService,
+    private readonly configService: ConfigService,
+  ) {
+    this.defaultQuotaMode = this.configService.get('QUOTA_DEFAULT_MODE', QuotaMode.HARD);
+  }
+
+  /**
+   * 解析用户当前生效的计划限额（优先走缓存）
+   */
+  async resolve(userId: string): Promise<ResolvedPlanLimits | null> {
+    const cached = await this.planCacheService.get(userId);
+    if (cached) {
+      return cached;
+    }
+
+    const subscription = await this.em.findOne(
+      UserSubscription,
+      { user: userId, status: { $in: [SubscriptionStatus.ACTIVE, SubscriptionStatus.TRIALING] } },
+      { populate: ['plan'] },
+    );
+
+    let plan: SubscriptionPlan | null = subscription?.plan ?? null;
+    if (!plan) {
+      const user = await this.em.findOne(User, { id: userId }, { populate: ['subscriptionPlan'] });
+      plan = user?.subscriptionPlan ?? null;
+    }
+    if (!plan) {
+      plan = await this.em.findOne(SubscriptionPlan, { tier: SubscriptionTier.FREE });
+    }
+    if (!plan) {
+      return null;
+    }
+
+    const metadata = parsePlanMetadata(plan.metadata);
+    const limits: ResolvedPlanLimits = {
+      planId: plan.id,
+      tier: plan.tier,
+      monthlyCharacterLimit: metadata.monthlyCharacterLimit ?? plan.monthlyCharacterLimit,
+      features: {
+        ...TIER_DEFAULT_FEATURES[plan.tier],
+        ...metadata.features,
+      },
+      quotaMode: metadata.quotaMode ?? this.defaultQuotaMode,
+      overagePriceId: metadata.overagePriceId,
+      maxStoredDocuments: metadata.maxStoredDocuments ?? TIER_DEFAULT_STORAGE[plan.tier].maxStoredDocuments,
+      maxStoredBytes: metadata.maxStoredBytes ?? TIER_DEFAULT_STORAGE[plan.tier].maxStoredBytes,
+      retentionDays: metadata.retentionDays ?? null,
+      overridden: false,
+      subscriptionStatus: subscription?.status,
+      currentPeriodEnd: subscription?.currentPeriodEnd?.toISOString(),
+    };
+
+    const override = await this.findActiveOverride(userId);
+    if (override) {
+      if (override.monthlyCharacterLimit !== undefined && override.monthlyCharacterLimit !== null) {
+        limits.monthlyCharacterLimit = override.monthlyCharacterLimit;
+      }
+      limits.features = { ...limits.features, ...override.features };
+      limits.overridden = true;
+      limits.overrideExpiresAt = override.expiresAt?.toISOString();
+    }
+
+    const cap = await this.em.findOne(UserUsageCap, { userId });
+    if (cap) {
+      limits.userCharacterCap = cap.monthlyCharacterCap;
+    }
+
+    await this.planCacheService.set(userId, limits);
+    return limits;
+  }
+
+  async getMonthlyCharacterLimit(userId: string): Promise<number> {
+    const limits = await this.resolve(userId);
+    return limits?.monthlyCharacterLimit ?? 0;
+  }
+
+  async hasFeature(userId: string, feature: PlanFeature): Promise<boolean> {
+    const limits = await this.resolve(userId);
+    return !!limits?.features[feature];
+  }
+
+  async getOverride(userId: string): Promise<UserPlanOverride | null> {
+    return this.em.findOne(UserPlanOverride, { userId });
+  }
+
+  /**
+   * 设置（或更新）用户的计划覆盖
+   */
+  async setOverride(userId: string, dto: SetPlanOverrideDto, adminId: string): Promise<UserPlanOverride> {
+    let override = await this.em.findOne(UserPlanOverride, { userId });
+    if (!override) {
+      override = this.em.create(UserPlanOverride, { userId });
+    }
+
+    override.monthlyCharacterLimit = dto.monthlyCharacterLimit;
+    override.features = dto.features;
+    override.reason = dto.reason;
+    override.expiresAt = dto.expiresAt ? new Date(dto.expiresAt) : undefined;
+    override.setBy = adminId;
+
+    await this.em.persistAndFlush(override);
+    await this.planCacheService.invalidate(userId);
+
+    this.logger.log(`Plan override set for user ${userId} by ${adminId}`);
+    return override;
+  }
+
+  async removeOverride(userId: string): Promise<void> {
+    const override = await this.em.findOne(UserPlanOverride, { userId });
+    if (override) {
+      await this.em.removeAndFlush(override);
+    }
+    await this.planCacheService.invalidate(userId);
+  }
+
+  async getUsageCap(userId: string): Promise<UserUsageCap | null> {
+    return this.em.findOne(UserUsageCap, { userId });
+  }
+
+  /**
+   * 设置用户自己的每月字符硬上限，传 null 取消
+   */
+  async setUsageCap(userId: string, monthlyCharacterCap: number | null): Promise<UserUsageCap | null> {
+    let cap = await this.em.findOne(UserUsageCap, { userId });
+    if (monthlyCharacterCap === null) {
+      if (cap) {
+        await this.em.removeAndFlush(cap);
+      }
+      await this.planCacheService.invalidate(userId);
+      return null;
+    }
+
+    if (!cap) {
+      cap = this.em.create(UserUsageCap, { userId, monthlyCharacterCap });
+    }
+    cap.monthlyCharacterCap = monthlyCharacterCap;
+    await this.em.persistAndFlush(cap);
+    await this.planCacheService.invalidate(userId);
+    return cap;
+  }
+
+  /**
+   * 设置计划的额度模式（管理员操作），所有用户的缓存限额随之失效
+   * overage 模式需要配置 Stripe 计量价格
+   */
+  async setPlanQuotaMode(planId: string, quotaMode: QuotaMode, overagePriceId?: string): Promise<SubscriptionPlan> {
+    const plan = await this.em.findOne(SubscriptionPlan, { id: planId });
+    if (!plan) {
+      throw new NotFoundException('Subscription plan not found');
+    }
+
+    const priceId = overagePriceId ?? plan.metadata?.overagePriceId;
+    if (quotaMode === QuotaMode.OVERAGE && !priceId) {
+      throw new BadRequestException('overagePriceId is required for overage quota mode');
+    }
+
+    plan.metadata = { ...plan.metadata, quotaMode, ...(priceId ? { overagePriceId: priceId } : {}) };
+    await this.em.persistAndFlush(plan);
+    await this.planCacheService.invalidateAll();
+
+    this.logger.log(`Quota mode of plan ${planId} set to ${quotaMode}`);
+    return plan;
+  }
+
+  /**
+   * 设置计划的存储上限（管理员操作），传 null 恢复档位默认值
+   */
+  async setPlanStorageLimits(
+    planId: string,
+    maxStoredDocuments: number | null,
+    maxStoredBytes: number | null,
+  ): Promise<SubscriptionPlan> {
+    const plan = await this.em.findOne(SubscriptionPlan, { id: planId });
+    if (!plan) {
+      throw new NotFoundException('Subscription plan not found');
+    }
+
+    const metadata: PlanMetadata = { ...plan.metadata, maxStoredDocuments, maxStoredBytes };
+    if (maxStoredDocuments === null) {
+      delete metadata.maxStoredDocuments;
+    }
+    if (maxStoredBytes === null) {
+      delete metadata.maxStoredBytes;
+    }
+    plan.metadata = metadata;
+    await this.em.persistAndFlush(plan);
+    await this.planCacheService.invalidateAll();
+
+    this.logger.log(`Storage limits of plan ${planId} set to ${maxStoredDocuments} documents / ${maxStoredBytes} bytes`);
+    return plan;
+  }
+
+  /**
+   * 设置计划的文档内容保留天数（管理员操作），传 null 表示不清理
+   */
+  async setPlanRetention(planId: string, retentionDays: number | null): Promise<SubscriptionPlan> {
+    const plan = await this.em.findOne(SubscriptionPlan, { id: planId });
+    if (!plan) {
+      throw new NotFoundException('Subscription plan not found');
+    }
+
+    const metadata: PlanMetadata = { ...plan.metadata, retentionDays };
+    if (retentionDays === null) {
+      delete metadata.retentionDays;
+    }
+    plan.metadata = metadata;
+    await this.em.persistAndFlush(plan);
+    await this.planCacheService.invalidateAll();
+
+    this.logger.log(`Retention of plan ${planId} set to ${retentionDays ?? 'unlimited'} days`);
+    return plan;
+  }
+
+  private async findActiveOverride(userId: string): Promise<UserPlanOverride | null> {
+    const override = await this.em.findOne(UserPlanOverride, { userId });
+    if (!override) {
+      return null;
+    }
+    if (override.expiresAt && override.expiresAt <= new Date()) {
+      return null;
+    }
+    return override;
+  }
+}
@@ -3,6 +3,7 @@ import { ConfigService } from '@nestjs/config';
 import { EntityManager } from '@mikro-orm/core';
 import { StripeService } from './stripe.service';
 import { RetryConfigService } from '../../../common/services/retry-config.service';
+import { PlanCacheService } from './plan-cache.service';
 import { SubscriptionPlan } from '../entities/subscription-plan.entity';
 import { UserSubscription, SubscriptionStatus } from '../entities/user-subscription.entity';
 
@@ -39,6 +40,10 @@ describe('StripeService', () => {
           provide: RetryConfigService,
           useValue: mockRetryConfigService,
         },
+        {
+          provide: PlanCacheService,
+          useValue: { invalidate: jest.fn() },
+        },
       ],
     }).compile();
 
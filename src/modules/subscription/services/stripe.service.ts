@@ -9,6 +9,7 @@ import { RetryConfigService } from '../../../common/services/retry-config.servic
 import { PaymentLogService } from '../../payment/services/payment-log.service';
 import { PaymentEventType, PaymentStatus } from '../../payment/entities/payment-log.entity';
 import { User } from '../../user/entities/user.entity';
+import { PlanCacheService } from './plan-cache.service';
 
 @Injectable()
 export class StripeService {
@@ -20,6 +21,7 @@ export class StripeService {
     private readonly em: EntityManager,
     private readonly retryConfigService: RetryConfigService,
     private readonly paymentLogService: PaymentLogService,
+    private readonly planCacheService: PlanCacheService,
   ) {
     this.stripe = new Stripe(this.configService.get('STRIPE_SECRET_KEY'), {
       apiVersion: '2023-08-16',
@@ -129,13 +131,51 @@ export class StripeService {
     }
   }
 
-  async handleWebhookEvent(payload: any, signature: string): Promise<void> {
+  /**
+   * 向用户订阅中对应计量价格的订阅项上报用量（累加）
+   * 订阅中没有该价格时返回 false
+   */
+  @Retry()
+  async reportMeteredUsage(
+    stripeSubscriptionId: string,
+    priceId: string,
+    quantity: number,
+    idempotencyKey: string,
+  ): Promise<boolean> {
     try {
-      const event = this.stripe.webhooks.constructEvent(
-        payload,
-        signature,
-        this.configService.get('STRIPE_WEBHOOK_SECRET'),
+      const subscription = await this.stripe.subscriptions.retrieve(stripeSubscriptionId);
+      const item = subscription.items.data.find((candidate) => candidate.price.id === priceId);
+      if (!item) {
+        this.logger.warn(`Subscription ${stripeSubscriptionId} has no item for metered price ${priceId}`);
+        return false;
+      }
+
+      await this.stripe.subscriptionItems.createUsageRecord(
+        item.id,
+        { quantity, timestamp: Math.floor(Date.now() / 1000), action: 'increment' },
+        { idempotencyKey },
       );
+      return true;
+    } catch (error) {
+      this.logger.error(`Failed to report metered usage: ${error.message}`);
+      throw error;
+    }
+  }
+
+  /**
+   * 校验签名并解析 Stripe webhook 事件，payload 必须是原始请求体
+   */
+  constructWebhookEvent(payload: string | Buffer, signature: string): Stripe.Event {
+    return this.stripe.webhooks.constructEvent(
+      payload,
+      signature,
+      this.configService.get('STRIPE_WEBHOOK_SECRET'),
+    );
+  }
+
+  async handleWebhookEvent(payload: any, signature: string): Promise<void> {
+    try {
+      const event = this.constructWebhookEvent(payload, signature);
 
       const object: any = event.data.object; // 临时用 any，或用类型守卫
 
@@ -190,6 +230,7 @@ export class StripeService {
       });
 
       await this.em.persistAndFlush(userSubscription);
+      await this.planCacheService.invalidate(userId);
 
       const user = await this.em.findOne(User, { id: userId });
       if (!user) throw new Error('User not found');
@@ -223,6 +264,7 @@ export class StripeService {
         userSubscription.cancelAtPeriodEnd = subscription.cancel_at_period_end;
 
         await this.em.persistAndFlush(userSubscription);
+        await this.planCacheService.invalidate(userSubscription.user.id);
 
         // 记录订阅变更日志
         await this.paymentLogService.logEvent({
@@ -253,6 +295,7 @@ export class StripeService {
       if (userSubscription) {
         userSubscription.status = SubscriptionStatus.CANCELED;
         await this.em.persistAndFlush(userSubscription);
+        await this.planCacheService.invalidate(userSubscription.user.id);
       }
 
       await this.paymentLogService.logEvent({
@@ -282,6 +325,7 @@ export class StripeService {
         userSubscription.status = SubscriptionStatus.ACTIVE;
         userSubscription.lastPaymentDate = new Date();
         await this.em.persistAndFlush(userSubscription);
+        await this.planCacheService.invalidate(userSubscription.user.id);
 
         await this.paymentLogService.logEvent({
           user: userSubscription.user,
@@ -312,6 +356,7 @@ export class StripeService {
       if (userSubscription) {
         userSubscription.status = SubscriptionStatus.PAST_DUE;
         await this.em.persistAndFlush(userSubscription);
+        await this.planCacheService.invalidate(userSubscription.user.id);
 
         await this.paymentLogService.logEvent({
           user: userSubscription.user,
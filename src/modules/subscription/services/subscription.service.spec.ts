@@ -3,6 +3,7 @@ import { EntityManager } from '@mikro-orm/core';
 import { SubscriptionService } from './subscription.service';
 import { StripeService } from './stripe.service';
 import { UsageService } from '../../user/usage.service';
+import { PlanLimitsService } from './plan-limits.service';
 import { SubscriptionPlan, SubscriptionTier } from '../entities/subscription-plan.entity';
 import { UserSubscription, SubscriptionStatus } from '../entities/user-subscription.entity';
 import { User } from '../../user/entities/user.entity';
@@ -29,6 +30,10 @@ describe('SubscriptionService', () => {
     getCurrentUsage: jest.fn(),
   };
 
+  const mockPlanLimitsService = {
+    resolve: jest.fn(),
+  };
+
   beforeEach(async () => {
     const module: TestingModule = await Test.createTestingModule({
       providers: [
@@ -45,6 +50,10 @@ describe('SubscriptionService', () => {
           provide: UsageService,
           useValue: mockUsageService,
         },
+        {
+          provide: PlanLimitsService,
+          useValue: mockPlanLimitsService,
+        },
       ],
     }).compile();
 
@@ -89,15 +98,15 @@ describe('SubscriptionService', () => {
 
   describe('getSubscriptionUsage', () => {
     it('应该返回用户的订阅使用情况', async () => {
-      const mockSubscription = {
-        id: '1',
-        plan: { id: 'plan1', monthlyCharacterLimit: 10000 },
+      const mockLimits = {
+        planId: 'plan1',
+        tier: SubscriptionTier.HOBBY,
+        monthlyCharacterLimit: 10000,
+        overridden: false,
       };
       const mockUsage = 5000;
 
-      mockEntityManager.findOne
-        .mockResolvedValueOnce(mockSubscription)
-        .mockResolvedValueOnce(mockSubscription.plan);
+      mockPlanLimitsService.resolve.mockResolvedValue(mockLimits);
       mockUsageService.getCurrentUsage.mockResolvedValue(mockUsage);
 
       const result = await service.getSubscriptionUsage('user1');
@@ -111,7 +120,7 @@ describe('SubscriptionService', () => {
     });
 
     it('当没有找到订阅时应该抛出错误', async () => {
-      mockEntityManager.findOne.mockResolvedValue(null);
+      mockPlanLimitsService.resolve.mockResolvedValue(null);
 
       await expect(service.getSubscriptionUsage('user1')).rejects.toThrow('No active subscription found');
     });
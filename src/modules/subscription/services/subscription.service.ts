@@ -3,6 +3,7 @@ import { EntityManager } from '@mikro-orm/core';
 import { SubscriptionPlan, SubscriptionTier } from '../entities/subscription-plan.entity';
 import { UserSubscription } from '../entities/user-subscription.entity';
 import { StripeService } from './stripe.service';
+import { PlanLimitsService } from './plan-limits.service';
 import { UsageService } from '../../user/usage.service';
 import { Retry } from '../../../common/decorators/retry.decorator';
 import { v4 as uuidv4 } from 'uuid';
@@ -15,6 +16,7 @@ export class SubscriptionService {
     private readonly em: EntityManager,
     private readonly stripeService: StripeService,
     private readonly usageService: UsageService,
+    private readonly planLimitsService: PlanLimitsService,
   ) {}
 
   async initializeSubscriptionPlans(): Promise<void> {
@@ -144,18 +146,14 @@ export class SubscriptionService {
     isOverLimit: boolean;
   }> {
     try {
-      const subscription = await this.getUserSubscription(userId);
-      if (!subscription) {
+      const planLimits = await this.planLimitsService.resolve(userId);
+      if (!planLimits) {
         throw new Error('No active subscription found');
       }
 
-      const plan = await this.em.findOne(SubscriptionPlan, {
-        id: subscription.plan.id,
-      });
-
       // 获取当前月份的使用量
       const currentUsage = await this.usageService.getCurrentUsage(userId);
-      const limit = plan.monthlyCharacterLimit;
+      const limit = planLimits.monthlyCharacterLimit;
       const percentage = (currentUsage / limit) * 100;
       const remaining = Math.max(0, limit - currentUsage);
       const isOverLimit = currentUsage >= limit;
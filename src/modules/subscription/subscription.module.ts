@@ -1,19 +1,34 @@
 import { Module } from '@nestjs/common';
 import { MikroOrmModule } from '@mikro-orm/nestjs';
+import { BullModule } from '@nestjs/bull';
 import { SubscriptionController } from './controllers/subscription.controller';
+import { PlanOverrideController } from './controllers/plan-override.controller';
+import { PlanAdminController } from './controllers/plan-admin.controller';
+import { UsageCapController } from './controllers/usage-cap.controller';
+import { BillingWebhookController } from './controllers/billing-webhook.controller';
 import { SubscriptionService } from './services/subscription.service';
 import { StripeService } from './services/stripe.service';
+import { PlanCacheService } from './services/plan-cache.service';
+import { PlanLimitsService } from './services/plan-limits.service';
+import { OverageBillingService } from './services/overage-billing.service';
+import { BillingSyncService } from './services/billing-sync.service';
 import { SubscriptionPlan } from './entities/subscription-plan.entity';
 import { UserSubscription } from './entities/user-subscription.entity';
+import { UserPlanOverride } from './entities/user-plan-override.entity';
+import { UserUsageCap } from './entities/user-usage-cap.entity';
+import { OverageUsage } from './entities/overage-usage.entity';
 import { ConfigModule } from '@nestjs/config';
+import { CommonModule } from '../../common/common.module';
 
 @Module({
   imports: [
-    MikroOrmModule.forFeature([SubscriptionPlan, UserSubscription]),
+    MikroOrmModule.forFeature([SubscriptionPlan, UserSubscription, UserPlanOverride, UserUsageCap, OverageUsage]),
+    BullModule.registerQueue({ name: 'translation' }),
     ConfigModule,
+    CommonModule,
   ],
-  controllers: [SubscriptionController],
-  providers: [SubscriptionService, StripeService],
-  exports: [SubscriptionService],
+  controllers: [SubscriptionController, PlanOverrideController, PlanAdminController, UsageCapController, BillingWebhookController],
+  providers: [SubscriptionService, StripeService, PlanCacheService, PlanLimitsService, OverageBillingService, BillingSyncService],
+  exports: [SubscriptionService, PlanCacheService, PlanLimitsService, OverageBillingService],
 })
-export class SubscriptionModule {} 
\ No newline at end of file
+export class SubscriptionModule {}
@@ -0,0 +1,80 @@
+import { Controller, Get, Post, Patch, Delete, Body, Param, Query, UseGuards, Req } from '@nestjs/common';
+import { ApiTags, ApiOperation, ApiResponse, ApiBearerAuth, ApiParam, ApiQuery } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../../auth/guards/jwt-auth.guard';
+import { TenantService } from '../services/tenant.service';
+import { CreateTenantDto } from '../dto/create-tenant.dto';
+import { UpdateTenantBrandingDto } from '../dto/update-tenant-branding.dto';
+
+@ApiTags('tenant')
+@Controller('tenants')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard)
+export class TenantController {
+  constructor(private readonly tenantService: TenantService) {}
+
+  @Post()
+  @ApiOperation({ summary: '创建租户' })
+  @ApiResponse({ status: 201, description: '租户创建成功' })
+  @ApiResponse({ status: 409, description: '租户标识已被占用' })
+  async createTenant(@Req() req: any, @Body() dto: CreateTenantDto) {
+    return this.tenantService.createTenant(req.user.id, dto);
+  }
+
+  @Get()
+  @ApiOperation({ summary: '获取账户下的租户列表' })
+  @ApiResponse({ status: 200, description: '返回租户列表' })
+  async listTenants(@Req() req: any) {
+    return this.tenantService.listTenants(req.user.id);
+  }
+
+  @Get('usage')
+  @ApiOperation({ summary: '按租户汇总字符用量' })
+  @ApiQuery({ name: 'from', required: false, description: '开始时间，默认本月第一天' })
+  @ApiQuery({ name: 'to', required: false, description: '结束时间，默认当前时间' })
+  @ApiResponse({ status: 200, description: '返回每个租户的用量' })
+  async getUsageReport(@Req() req: any, @Query('from') from?: string, @Query('to') to?: string) {
+    const now = new Date();
+    const start = from ? new Date(from) : new Date(now.getFullYear(), now.getMonth(), 1);
+    const end = to ? new Date(to) : now;
+    return {
+      from: start,
+      to: end,
+      tenants: await this.tenantService.getUsageReport(req.user.id, start, end),
+    };
+  }
+
+  @Get(':slug')
+  @ApiOperation({ summary: '获取租户详情' })
+  @ApiParam({ name: 'slug', description: '租户标识' })
+  @ApiResponse({ status: 404, description: '租户不存在' })
+  async getTenant(@Req() req: any, @Param('slug') slug: string) {
+    return this.tenantService.getTenant(req.user.id, slug);
+  }
+
+  @Get(':slug/branding')
+  @ApiOperation({ summary: '获取租户品牌信息' })
+  @ApiParam({ name: 'slug', description: '租户标识' })
+  @ApiResponse({ status: 200, description: '返回品牌名称、Logo 和回复邮箱' })
+  async getBranding(@Req() req: any, @Param('slug') slug: string) {
+    const tenant = await this.tenantService.getTenant(req.user.id, slug);
+    return this.tenantService.getBranding(tenant);
+  }
+
+  @Patch(':slug/branding')
+  @ApiOperation({ summary: '更新租户品牌信息' })
+  @ApiParam({ name: 'slug', description: '租户标识' })
+  @ApiResponse({ status: 200, description: '返回更新后的品牌信息' })
+  @ApiResponse({ status: 400, description: 'Logo 地址或邮箱格式错误' })
+  async updateBranding(@Req() req: any, @Param('slug') slug: string, @Body() dto: UpdateTenantBrandingDto) {
+    return this.tenantService.updateBranding(req.user.id, slug, dto);
+  }
+
+  @Delete(':slug')
+  @ApiOperation({ summary: '停用租户' })
+  @ApiParam({ name: 'slug', description: '租户标识' })
+  @ApiResponse({ status: 200, description: '租户已停用' })
+  async deactivateTenant(@Req() req: any, @Param('slug') slug: string) {
+    await this.tenantService.deactivateTenant(req.user.id, slug);
+    return { success: true };
+  }
+}
@@ -0,0 +1,18 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsString, Matches, MaxLength } from 'class-validator';
+import { TENANT_SLUG_PATTERN } from '../middleware/tenant.middleware';
+
+export class CreateTenantDto {
+  @ApiProperty({
+    description: '租户标识，用于 X-Tenant-Id 请求头或子域名',
+    example: 'acme',
+  })
+  @IsString()
+  @Matches(TENANT_SLUG_PATTERN, { message: 'slug must be lowercase letters, digits or hyphens' })
+  slug: string;
+
+  @ApiProperty({ description: '租户名称', example: 'Acme Inc.' })
+  @IsString()
+  @MaxLength(255)
+  name: string;
+}
@@ -0,0 +1,28 @@
+import { ApiPropertyOptional } from '@nestjs/swagger';
+import { IsEmail, IsOptional, IsString, IsUrl, MaxLength, ValidateIf } from 'class-validator';
+
+/**
+ * 更新租户品牌信息，传 null 清除对应字段
+ */
+export class UpdateTenantBrandingDto {
+  @ApiPropertyOptional({ description: '品牌名称，未设置时使用租户名称', example: 'Acme Translate' })
+  @IsOptional()
+  @ValidateIf((_, value) => value !== null)
+  @IsString()
+  @MaxLength(255)
+  brandName?: string | null;
+
+  @ApiPropertyOptional({ description: 'Logo 地址', example: 'https://cdn.acme.com/logo.png' })
+  @IsOptional()
+  @ValidateIf((_, value) => value !== null)
+  @IsUrl({ protocols: ['https'], require_protocol: true })
+  @MaxLength(2048)
+  logoUrl?: string | null;
+
+  @ApiPropertyOptional({ description: '邮件回复地址', example: 'support@acme.com' })
+  @IsOptional()
+  @ValidateIf((_, value) => value !== null)
+  @IsEmail()
+  @MaxLength(255)
+  replyToEmail?: string | null;
+}
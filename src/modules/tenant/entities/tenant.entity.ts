@@ -0,0 +1,32 @@
+import { Entity, Property, Unique } from '@mikro-orm/core';
+import { BaseEntity } from '../../../common/entities/base.entity';
+
+/**
+ * 租户
+ * 白标平台客户下的终端租户，文档、webhook 和用量按租户隔离，计费仍归属到 owner 账户
+ */
+@Entity({ tableName: 'tenant' })
+export class Tenant extends BaseEntity {
+  @Property()
+  ownerId!: string;
+
+  @Property()
+  @Unique()
+  slug!: string;
+
+  @Property()
+  name!: string;
+
+  @Property()
+  isActive: boolean = true;
+
+  /** 品牌名称，未设置时使用 name */
+  @Property({ nullable: true })
+  brandName?: string;
+
+  @Property({ nullable: true, length: 2048 })
+  logoUrl?: string;
+
+  @Property({ nullable: true })
+  replyToEmail?: string;
+}
@@ -0,0 +1,9 @@
+/**
+ * 租户品牌信息
+ * 白标场景下随接口响应返回，并用于发给租户终端用户的邮件
+ */
+export interface TenantBranding {
+  name: string;
+  logoUrl: string | null;
+  replyToEmail: string | null;
+}
@@ -0,0 +1,51 @@
+import { BadRequestException, Injectable, NestMiddleware } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+
+export const TENANT_HEADER = 'x-tenant-id';
+export const TENANT_SLUG_PATTERN = /^[a-z0-9][a-z0-9-]{0,62}$/;
+
+/**
+ * 从请求头或子域名中提取租户标识
+ * 请求头优先；子域名只在配置了 TENANT_BASE_DOMAIN 时生效
+ */
+export function extractTenantSlug(
+  header: string | string[] | undefined,
+  host: string | undefined,
+  baseDomain?: string,
+): string | undefined {
+  const fromHeader = Array.isArray(header) ? header[0] : header;
+  if (fromHeader) {
+    return fromHeader.trim().toLowerCase();
+  }
+
+  if (!host || !baseDomain) {
+    return undefined;
+  }
+  const hostname = host.split(':')[0].toLowerCase();
+  const suffix = `.${baseDomain.toLowerCase()}`;
+  if (!hostname.endsWith(suffix)) {
+    return undefined;
+  }
+  const subdomain = hostname.slice(0, -suffix.length);
+  return subdomain && !subdomain.includes('.') ? subdomain : undefined;
+}
+
+@Injectable()
+export class TenantMiddleware implements NestMiddleware {
+  private readonly baseDomain?: string;
+
+  constructor(private readonly configService: ConfigService) {
+    this.baseDomain = this.configService.get('TENANT_BASE_DOMAIN');
+  }
+
+  use(req: any, _res: any, next: () => void) {
+    const slug = extractTenantSlug(req.headers[TENANT_HEADER], req.headers.host, this.baseDomain);
+    if (slug !== undefined) {
+      if (!TENANT_SLUG_PATTERN.test(slug)) {
+        throw new BadRequestException('Invalid tenant identifier');
+      }
+      req.tenantSlug = slug;
+    }
+    next();
+  }
+}
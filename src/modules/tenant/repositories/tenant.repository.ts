@@ -0,0 +1,11 @@
+import { Injectable } from '@nestjs/common';
+import { EntityManager } from '@mikro-orm/core';
+import { DataRepository } from '../../../common/repositories/data.repository';
+import { Tenant } from '../entities/tenant.entity';
+
+@Injectable()
+export class TenantRepository extends DataRepository<Tenant> {
+  constructor(em: EntityManager) {
+    super(em, Tenant, 'ownerId');
+  }
+}
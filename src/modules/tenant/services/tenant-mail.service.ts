@@ -0,0 +1,37 @@
+import { Injectable } from '@nestjs/common';
+import { MailService, MailMessage } from '../../../common/services/mail.service';
+import { TenantService } from './tenant.service';
+import { TenantBranding } from '../interfaces/tenant-branding.interface';
+
+/**
+ * 带租户品牌的邮件发送
+ * 请求属于某个租户时，发件人显示名、回复地址和页眉 Logo 使用该租户的品牌信息
+ */
+@Injectable()
+export class TenantMailService {
+  constructor(
+    private readonly mailService: MailService,
+    private readonly tenantService: TenantService,
+  ) {}
+
+  async send(tenantId: string | null | undefined, message: MailMessage): Promise<boolean> {
+    const branding = await this.tenantService.findBranding(tenantId);
+    return this.mailService.send(branding ? applyBranding(message, branding) : message);
+  }
+}
+
+export function applyBranding(message: MailMessage, branding: TenantBranding): MailMessage {
+  const branded: MailMessage = {
+    ...message,
+    fromName: branding.name,
+    replyTo: branding.replyToEmail ?? message.replyTo,
+  };
+  if (message.html && branding.logoUrl) {
+    branded.html = `<p><img src="${encodeURI(branding.logoUrl)}" alt="${escapeHtml(branding.name)}" height="40"></p>${message.html}`;
+  }
+  return branded;
+}
+
+function escapeHtml(value: string): string {
+  return value.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/"/g, '&quot;');
+}
@@ -0,0 +1,112 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { EntityManager } from '@mikro-orm/core';
+import { NotFoundException } from '@nestjs/common';
+import { TenantService } from './tenant.service';
+import { TenantRepository } from '../repositories/tenant.repository';
+import { extractTenantSlug } from '../middleware/tenant.middleware';
+
+describe('TenantService', () => {
+  let service: TenantService;
+
+  const mockEntityManager = {
+    findOne: jest.fn(),
+    find: jest.fn(),
+    create: jest.fn(),
+    assign: jest.fn((entity, changes) => Object.assign(entity, changes)),
+    persistAndFlush: jest.fn(),
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        TenantService,
+        TenantRepository,
+        {
+          provide: EntityManager,
+          useValue: mockEntityManager,
+        },
+      ],
+    }).compile();
+
+    service = module.get<TenantService>(TenantService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  describe('extractTenantSlug', () => {
+    it('请求头应优先于子域名', () => {
+      expect(extractTenantSlug('Acme', 'other.api.example.com', 'api.example.com')).toBe('acme');
+    });
+
+    it('应从配置的基础域名中解析子域名', () => {
+      expect(extractTenantSlug(undefined, 'acme.api.example.com:443', 'api.example.com')).toBe('acme');
+      expect(extractTenantSlug(undefined, 'api.example.com', 'api.example.com')).toBeUndefined();
+      expect(extractTenantSlug(undefined, 'a.b.api.example.com', 'api.example.com')).toBeUndefined();
+    });
+
+    it('未配置基础域名时忽略子域名', () => {
+      expect(extractTenantSlug(undefined, 'acme.api.example.com')).toBeUndefined();
+    });
+  });
+
+  describe('resolve', () => {
+    it('未携带租户标识时返回 null', async () => {
+      await expect(service.resolve('owner1')).resolves.toBeNull();
+      expect(mockEntityManager.findOne).not.toHaveBeenCalled();
+    });
+
+    it('只能解析属于当前账户的租户', async () => {
+      mockEntityManager.findOne.mockResolvedValue(null);
+
+      await expect(service.resolve('owner1', 'acme')).rejects.toThrow(NotFoundException);
+      expect(mockEntityManager.findOne).toHaveBeenCalledWith(
+        expect.anything(),
+        { slug: 'acme', isActive: true, ownerId: 'owner1' },
+      );
+    });
+  });
+
+  describe('getUsageReport', () => {
+    it('应按租户汇总用量并保留默认空间', async () => {
+      mockEntityManager.find
+        .mockResolvedValueOnce([{ id: 't1', slug: 'acme', name: 'Acme' }])
+        .mockResolvedValueOnce([
+          { tenantId: 't1', totalCharacters: 100 },
+          { tenantId: 't1', totalCharacters: 50 },
+          { tenantId: null, totalCharacters: 10 },
+        ]);
+
+      const report = await service.getUsageReport('owner1', new Date('2026-01-01'), new Date('2026-02-01'));
+
+      expect(report).toEqual([
+        { tenantId: null, slug: null, name: null, requests: 1, totalCharacters: 10 },
+        { tenantId: 't1', slug: 'acme', name: 'Acme', requests: 2, totalCharacters: 150 },
+      ]);
+    });
+  });
+
+  describe('branding', () => {
+    it('未设置品牌名称时使用租户名称', () => {
+      expect(service.getBranding({ name: 'Acme' } as any)).toEqual({
+        name: 'Acme',
+        logoUrl: null,
+        replyToEmail: null,
+      });
+    });
+
+    it('只更新传入的字段，null 表示清除', async () => {
+      const tenant = { slug: 'acme', name: 'Acme', brandName: 'Acme Translate', logoUrl: 'https://a/logo.png' };
+      mockEntityManager.findOne.mockResolvedValue(tenant);
+
+      const branding = await service.updateBranding('owner1', 'acme', {
+        logoUrl: null,
+        replyToEmail: 'support@acme.com',
+      });
+
+      expect(branding).toEqual({ name: 'Acme Translate', logoUrl: null, replyToEmail: 'support@acme.com' });
+      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith(tenant);
+    });
+  });
+});
@@ -0,0 +1,139 @@
+import { Injectable, Logger, NotFoundException } from '@nestjs/common';
+import { EntityData, EntityManager } from '@mikro-orm/core';
+import { v4 as uuidv4 } from 'uuid';
+import { Tenant } from '../entities/tenant.entity';
+import { TenantRepository } from '../repositories/tenant.repository';
+import { CreateTenantDto } from '../dto/create-tenant.dto';
+import { UpdateTenantBrandingDto } from '../dto/update-tenant-branding.dto';
+import { TenantBranding } from '../interfaces/tenant-branding.interface';
+import { CharacterUsageLog } from '../../translation/entities/translation-task.entity';
+
+export interface TenantUsageRow {
+  tenantId: string | null;
+  slug: string | null;
+  name: string | null;
+  requests: number;
+  totalCharacters: number;
+}
+
+/**
+ * 租户服务
+ * 管理白标平台下的租户，并把请求中的租户标识解析为属于当前账户的租户
+ */
+@Injectable()
+export class TenantService {
+  private readonly logger = new Logger(TenantService.name);
+
+  constructor(
+    private readonly tenantRepository: TenantRepository,
+    private readonly em: EntityManager,
+  ) {}
+
+  async createTenant(ownerId: string, dto: CreateTenantDto): Promise<Tenant> {
+    const tenant = await this.tenantRepository.forUser(ownerId).insert({
+      id: uuidv4(),
+      ownerId,
+      slug: dto.slug,
+      name: dto.name,
+    });
+    this.logger.log(`Tenant ${tenant.slug} created for user ${ownerId}`);
+    return tenant;
+  }
+
+  async listTenants(ownerId: string): Promise<Tenant[]> {
+    return this.tenantRepository.forUser(ownerId).list({}, { orderBy: { createdAt: 'ASC' } });
+  }
+
+  async getTenant(ownerId: string, slug: string): Promise<Tenant> {
+    return this.tenantRepository.forUser(ownerId).getOrFail({ slug }, 'Tenant not found');
+  }
+
+  /**
+   * 解析租户标识；未携带标识时返回 null（账户默认空间）
+   * 标识必须属于当前账户且处于启用状态，否则视为不存在
+   */
+  async resolve(ownerId: string, slug?: string): Promise<Tenant | null> {
+    if (!slug) {
+      return null;
+    }
+    const tenant = await this.tenantRepository.forUser(ownerId).get({ slug, isActive: true });
+    if (!tenant) {
+      throw new NotFoundException(`Tenant "${slug}" not found`);
+    }
+    return tenant;
+  }
+
+  async resolveFromRequest(req: any): Promise<Tenant | null> {
+    return this.resolve(req.user.id, req.tenantSlug);
+  }
+
+  /**
+   * 更新品牌信息；未传的字段保持不变，传 null 清除
+   */
+  async updateBranding(ownerId: string, slug: string, dto: UpdateTenantBrandingDto): Promise<TenantBranding> {
+    const tenant = await this.getTenant(ownerId, slug);
+    const changes: EntityData<Tenant> = {};
+    for (const field of ['brandName', 'logoUrl', 'replyToEmail'] as const) {
+      if (dto[field] !== undefined) {
+        changes[field] = dto[field];
+      }
+    }
+    await this.tenantRepository.update(tenant, changes);
+    return this.getBranding(tenant);
+  }
+
+  getBranding(tenant: Tenant): TenantBranding {
+    return {
+      name: tenant.brandName || tenant.name,
+      logoUrl: tenant.logoUrl ?? null,
+      replyToEmail: tenant.replyToEmail ?? null,
+    };
+  }
+
+  /**
+   * 按 id 查找租户品牌信息，供异步流程（邮件、worker）使用
+   */
+  async findBranding(tenantId?: string | null): Promise<TenantBranding | null> {
+    if (!tenantId) {
+      return null;
+    }
+    const tenant = await this.tenantRepository.get({ id: tenantId });
+    return tenant ? this.getBranding(tenant) : null;
+  }
+
+  async deactivateTenant(ownerId: string, slug: string): Promise<void> {
+    const tenant = await this.getTenant(ownerId, slug);
+    await this.tenantRepository.update(tenant, { isActive: false });
+  }
+
+  /**
+   * 按租户汇总账户下的字符用量，tenantId 为 null 的一行代表账户默认空间
+   */
+  async getUsageReport(ownerId: string, from: Date, to: Date): Promise<TenantUsageRow[]> {
+    const [tenants, logs] = await Promise.all([
+      this.listTenants(ownerId),
+      this.em.find(
+        CharacterUsageLog,
+        { userId: ownerId, createdAt: { $gte: from, $lt: to } },
+        { fields: ['tenantId', 'totalCharacters'] },
+      ),
+    ]);
+
+    const rows = new Map<string | null, TenantUsageRow>();
+    rows.set(null, { tenantId: null, slug: null, name: null, requests: 0, totalCharacters: 0 });
+    for (const tenant of tenants) {
+      rows.set(tenant.id, { tenantId: tenant.id, slug: tenant.slug, name: tenant.name, requests: 0, totalCharacters: 0 });
+    }
+
+    for (const log of logs) {
+      const row = rows.get(log.tenantId ?? null);
+      if (!row) {
+        continue;
+      }
+      row.requests += 1;
+      row.totalCharacters += log.totalCharacters;
+    }
+
+    return Array.from(rows.values());
+  }
+}
@@ -0,0 +1,17 @@
+import { Module } from '@nestjs/common';
+import { MikroOrmModule } from '@mikro-orm/nestjs';
+import { CommonModule } from '../../common/common.module';
+import { Tenant } from './entities/tenant.entity';
+import { TenantController } from './controllers/tenant.controller';
+import { TenantService } from './services/tenant.service';
+import { TenantMailService } from './services/tenant-mail.service';
+import { TenantRepository } from './repositories/tenant.repository';
+import { TenantMiddleware } from './middleware/tenant.middleware';
+
+@Module({
+  imports: [MikroOrmModule.forFeature([Tenant]), CommonModule],
+  controllers: [TenantController],
+  providers: [TenantService, TenantMailService, TenantRepository, TenantMiddleware],
+  exports: [TenantService, TenantMailService, TenantMiddleware],
+})
+export class TenantModule {}
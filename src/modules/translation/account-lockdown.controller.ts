@@ -0,0 +1,68 @@
+import { Body, Controller, Delete, Get, Param, Post, Req, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiParam, ApiResponse, ApiTags } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../auth/guards/admin.guard';
+import { AccountLockdownService } from '../api-key/account-lockdown.service';
+import { AccountLockdownDto } from '../api-key/dto/account-lockdown.dto';
+import { TranslationService } from './translation.service';
+
+/**
+ * 账号级 API 锁定
+ * 只接受 JWT 认证，泄露的 API Key 无法自行解锁
+ */
+@ApiTags('account')
+@Controller()
+@ApiBearerAuth()
+export class AccountLockdownController {
+  constructor(
+    private readonly accountLockdownService: AccountLockdownService,
+    private readonly translationService: TranslationService,
+  ) {}
+
+  @Get('account/lockdown')
+  @UseGuards(JwtAuthGuard)
+  @ApiOperation({ summary: '获取账号 API 锁定状态' })
+  async getLockdown(@Req() req: any) {
+    return this.accountLockdownService.getStatus(req.user.id);
+  }
+
+  @Post('account/lockdown')
+  @UseGuards(JwtAuthGuard)
+  @ApiOperation({ summary: '立即停用账号的全部 API 活动（疑似密钥泄露时使用）' })
+  @ApiResponse({ status: 201, description: '已锁定：拒绝新任务和密钥认证，暂停翻译和 webhook 推送' })
+  @ApiResponse({ status: 409, description: '账号已处于锁定状态' })
+  async lock(@Req() req: any, @Body() dto: AccountLockdownDto) {
+    return this.accountLockdownService.lock(req.user.id, dto, req.user.id);
+  }
+
+  @Delete('account/lockdown')
+  @UseGuards(JwtAuthGuard)
+  @ApiOperation({ summary: '解除账号锁定并恢复暂停的任务和推送' })
+  @ApiResponse({ status: 403, description: '锁定由管理员施加，只能由管理员解除' })
+  @ApiResponse({ status: 409, description: '账号未锁定' })
+  async unlock(@Req() req: any) {
+    const status = await this.accountLockdownService.unlock(req.user.id, req.user.id);
+    const resumed = await this.translationService.resumeLockedWork(req.user.id);
+    return { ...status, resumed };
+  }
+
+  @Post('admin/accounts/:userId/lockdown')
+  @UseGuards(JwtAuthGuard, AdminGuard)
+  @ApiOperation({ summary: '管理员锁定用户账号的 API 访问' })
+  @ApiParam({ name: 'userId', description: '用户 ID' })
+  @ApiResponse({ status: 409, description: '账号已处于锁定状态' })
+  async adminLock(@Req() req: any, @Param('userId') userId: string, @Body() dto: AccountLockdownDto) {
+    return this.accountLockdownService.lock(userId, dto, req.user.id);
+  }
+
+  @Delete('admin/accounts/:userId/lockdown')
+  @UseGuards(JwtAuthGuard, AdminGuard)
+  @ApiOperation({ summary: '管理员解除用户账号的 API 锁定' })
+  @ApiParam({ name: 'userId', description: '用户 ID' })
+  @ApiResponse({ status: 409, description: '账号未锁定' })
+  async adminUnlock(@Req() req: any, @Param('userId') userId: string) {
+    const status = await this.accountLockdownService.unlock(userId, req.user.id, true);
+    const resumed = await this.translationService.resumeLockedWork(userId);
+    return { ...status, resumed };
+  }
+}
@@ -0,0 +1,36 @@
+import { Controller, Get, Query, Req, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiQuery, ApiResponse, ApiSecurity, ApiTags } from '@nestjs/swagger';
+import { JwtOrApiKeyGuard } from '../auth/guards/jwt-or-api-key.guard';
+import { AuditAction, ResourceType } from '../audit/entities/audit-log.entity';
+import { AuditLogService } from '../audit/services/audit-log.service';
+import { parseAuditLogQuery } from '../audit/controllers/audit-log.controller';
+
+@ApiTags('user')
+@Controller('user/audit_log')
+@ApiBearerAuth()
+@ApiSecurity('api-key')
+@UseGuards(JwtOrApiKeyGuard)
+export class UserAuditLogController {
+  constructor(private readonly auditLogService: AuditLogService) {}
+
+  @Get()
+  @ApiOperation({ summary: '查询当前用户的审计日志（增删改操作、操作人密钥、接口、IP）' })
+  @ApiQuery({ name: 'apiKeyId', required: false, description: '按 API 密钥过滤' })
+  @ApiQuery({ name: 'action', required: false, enum: AuditAction })
+  @ApiQuery({ name: 'resourceType', required: false, enum: ResourceType })
+  @ApiQuery({ name: 'resourceId', required: false })
+  @ApiQuery({ name: 'endpoint', required: false, description: '路由模板包含该字符串，如 /translation' })
+  @ApiQuery({ name: 'from', required: false, description: '起始时间（含），ISO 8601' })
+  @ApiQuery({ name: 'to', required: false, description: '结束时间（不含），ISO 8601' })
+  @ApiQuery({ name: 'limit', required: false, description: '数量，默认 50，最多 200' })
+  @ApiQuery({ name: 'offset', required: false })
+  @ApiResponse({ status: 400, description: '筛选条件无效' })
+  async list(@Req() req: any, @Query() query: Record<string, string>) {
+    const filter = { ...parseAuditLogQuery(query), userId: req.user.id };
+    // 委托密钥只能看到自己的操作
+    if (req.apiKey?.delegated) {
+      filter.apiKeyId = req.apiKey.id;
+    }
+    return this.auditLogService.listEntries(filter);
+  }
+}
@@ -0,0 +1,54 @@
+import {
+  Body,
+  Controller,
+  Delete,
+  Get,
+  HttpCode,
+  HttpStatus,
+  Post,
+  Put,
+  Req,
+  UseGuards,
+} from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiResponse, ApiSecurity, ApiTags } from '@nestjs/swagger';
+import { JwtOrApiKeyGuard } from '../auth/guards/jwt-or-api-key.guard';
+import { CustomMtEngineService } from './services/custom-mt-engine.service';
+import { CustomMtEngineDto, CustomMtEngineTestDto } from './dto/custom-mt-engine.dto';
+
+@ApiTags('user')
+@Controller('user/mt_engine')
+@ApiBearerAuth()
+@ApiSecurity('api-key')
+@UseGuards(JwtOrApiKeyGuard)
+export class CustomMtEngineController {
+  constructor(private readonly customMtEngineService: CustomMtEngineService) {}
+
+  @Get()
+  @ApiOperation({ summary: '获取自定义翻译引擎配置（不返回认证请求头的值）' })
+  @ApiResponse({ status: 404, description: '未配置' })
+  async get(@Req() req: any) {
+    return this.customMtEngineService.get(req.user.id);
+  }
+
+  @Put()
+  @ApiOperation({ summary: '配置自定义翻译引擎，配置后创建任务时可使用 provider=custom' })
+  @ApiResponse({ status: 400, description: '地址不允许或请求模板无效' })
+  async upsert(@Req() req: any, @Body() dto: CustomMtEngineDto) {
+    return this.customMtEngineService.upsert(req.user.id, dto);
+  }
+
+  @Delete()
+  @HttpCode(HttpStatus.NO_CONTENT)
+  @ApiOperation({ summary: '删除自定义翻译引擎配置' })
+  @ApiResponse({ status: 404, description: '未配置' })
+  async remove(@Req() req: any) {
+    await this.customMtEngineService.remove(req.user.id);
+  }
+
+  @Post('test')
+  @HttpCode(HttpStatus.OK)
+  @ApiOperation({ summary: '用一段文本调用引擎，检查地址、认证和响应映射' })
+  async test(@Req() req: any, @Body() dto: CustomMtEngineTestDto) {
+    return this.customMtEngineService.test(req.user.id, dto.text, dto.fromLang, dto.toLang);
+  }
+}
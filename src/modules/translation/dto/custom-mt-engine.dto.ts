@@ -0,0 +1,49 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsOptional, IsString, IsUrl, Matches, MaxLength } from 'class-validator';
+
+export class CustomMtEngineDto {
+  @ApiProperty({ description: '翻译接口地址（POST）', example: 'https://mt.example.com/translate' })
+  @IsUrl({ require_tld: false, protocols: ['http', 'https'], require_protocol: true })
+  @MaxLength(2048)
+  url: string;
+
+  @ApiProperty({ description: '认证请求头名称', required: false, default: 'Authorization' })
+  @IsOptional()
+  @Matches(/^[A-Za-z0-9-]{1,100}$/, { message: 'authHeaderName must be a valid header name' })
+  authHeaderName?: string;
+
+  @ApiProperty({ description: '认证请求头的值，加密保存且不会返回；修改其他配置时不传则保留原值', required: false })
+  @IsOptional()
+  @IsString()
+  @MaxLength(4096)
+  authHeaderValue?: string;
+
+  @ApiProperty({
+    description: '请求体模板（JSON），{{text}}、{{source}}、{{target}} 替换为原文和语言代码，需写在字符串中',
+    example: '{"q": "{{text}}", "source": "{{source}}", "target": "{{target}}"}',
+  })
+  @IsString()
+  @MaxLength(10000)
+  requestTemplate: string;
+
+  @ApiProperty({ description: '响应中译文所在的点号路径，数组下标写作数字', example: 'translations.0.text' })
+  @IsString()
+  @Matches(/^[^.\s]+(\.[^.\s]+)*$/, { message: 'responsePath must be a dot-separated path' })
+  @MaxLength(255)
+  responsePath: string;
+}
+
+export class CustomMtEngineTestDto {
+  @ApiProperty({ description: '测试原文', example: 'Hello world' })
+  @IsString()
+  @MaxLength(1000)
+  text: string;
+
+  @ApiProperty({ description: '源语言', example: 'en' })
+  @IsString()
+  fromLang: string;
+
+  @ApiProperty({ description: '目标语言', example: 'zh' })
+  @IsString()
+  toLang: string;
+}
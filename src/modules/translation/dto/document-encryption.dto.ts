@@ -0,0 +1,8 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsBoolean } from 'class-validator';
+
+export class DocumentEncryptionDto {
+  @ApiProperty({ description: '是否加密保存之后写入的文档内容', example: true })
+  @IsBoolean()
+  enabled: boolean;
+}
@@ -0,0 +1,22 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsInt, IsOptional, IsString, Max, MaxLength, Min } from 'class-validator';
+
+export class DocumentLockDto {
+  @ApiProperty({ description: '持有者说明（如审校人），其他调用方查询锁时可见', required: false, example: 'alice@example.com' })
+  @IsOptional()
+  @IsString()
+  @MaxLength(200)
+  owner?: string;
+
+  @ApiProperty({ description: '锁有效期（秒），不传时使用服务端默认值，超过上限时按上限处理', required: false })
+  @IsOptional()
+  @IsInt()
+  @Min(30)
+  @Max(86400)
+  ttlSeconds?: number;
+
+  @ApiProperty({ description: '当前持有的锁 token，传入时为续期', required: false })
+  @IsOptional()
+  @IsString()
+  token?: string;
+}
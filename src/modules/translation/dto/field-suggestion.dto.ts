@@ -0,0 +1,22 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsInt, IsNumber, IsOptional, IsString, Max, Min } from 'class-validator';
+
+export class FieldSuggestionRequestDto {
+  @ApiProperty({ description: '样例 JSON 文档' })
+  @IsString()
+  json: string;
+
+  @ApiProperty({ description: '只返回置信度不低于该值的建议', required: false, default: 0.5 })
+  @IsOptional()
+  @IsNumber()
+  @Min(0)
+  @Max(1)
+  minConfidence?: number;
+
+  @ApiProperty({ description: '最多返回的建议数', required: false, default: 100 })
+  @IsOptional()
+  @IsInt()
+  @Min(1)
+  @Max(500)
+  limit?: number;
+}
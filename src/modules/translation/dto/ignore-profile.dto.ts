@@ -0,0 +1,63 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { ArrayMaxSize, IsArray, IsEnum, IsOptional, IsString, MaxLength } from 'class-validator';
+import { SkipHeuristic } from '../utils/ignore-rules';
+
+export class IgnoreProfileDto {
+  @ApiProperty({ description: '名称，同一账户内唯一', example: 'mobile-app' })
+  @IsString()
+  @MaxLength(100)
+  name: string;
+
+  @ApiProperty({ description: '忽略的键名，任意层级匹配（同 ignoredFields）', type: [String], required: false })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(200)
+  @IsString({ each: true })
+  @MaxLength(255, { each: true })
+  fields?: string[];
+
+  @ApiProperty({
+    description: '忽略的路径（点号路径，* 匹配一段，** 匹配任意多段），匹配的节点整体保留原文',
+    type: [String],
+    required: false,
+    example: ['meta.**', 'items.*.sku'],
+  })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(200)
+  @IsString({ each: true })
+  @MaxLength(255, { each: true })
+  paths?: string[];
+
+  @ApiProperty({
+    description: '忽略完整点号路径匹配的正则表达式',
+    type: [String],
+    required: false,
+    example: ['(^|\\.)_[^.]*$'],
+  })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(50)
+  @IsString({ each: true })
+  @MaxLength(200, { each: true })
+  patterns?: string[];
+
+  @ApiProperty({
+    description: '只翻译这些路径下的字符串（写法同 paths），为空表示全部',
+    type: [String],
+    required: false,
+    example: ['ui.**'],
+  })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(200)
+  @IsString({ each: true })
+  @MaxLength(255, { each: true })
+  include?: string[];
+
+  @ApiProperty({ description: '按值跳过的字符串', enum: SkipHeuristic, isArray: true, required: false })
+  @IsOptional()
+  @IsArray()
+  @IsEnum(SkipHeuristic, { each: true })
+  skip?: SkipHeuristic[];
+}
@@ -0,0 +1,41 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsDateString, IsOptional, IsString, Matches, MaxLength } from 'class-validator';
+
+export class IncidentDto {
+  @ApiProperty({ description: '对外展示的故障编号', example: 'INC-42' })
+  @Matches(/^[\w.-]{1,50}$/, { message: 'reference may only contain letters, digits, ".", "_" and "-"' })
+  reference: string;
+
+  @ApiProperty({
+    description: '对用户展示的说明，不填时使用默认说明',
+    required: false,
+    example: 'Translations are delayed due to a provider outage',
+  })
+  @IsOptional()
+  @IsString()
+  @MaxLength(500)
+  message?: string;
+
+  @ApiProperty({ description: '受影响的服务商，不填表示所有服务商', required: false, example: 'aliyun' })
+  @IsOptional()
+  @IsString()
+  @MaxLength(50)
+  provider?: string;
+
+  @ApiProperty({ description: '故障开始时间（ISO 8601），不填为当前时间', required: false })
+  @IsOptional()
+  @IsDateString()
+  startsAt?: string;
+
+  @ApiProperty({ description: '故障结束时间（ISO 8601），不填表示仍在持续', required: false })
+  @IsOptional()
+  @IsDateString()
+  endsAt?: string;
+}
+
+export class ResolveIncidentDto {
+  @ApiProperty({ description: '故障结束时间（ISO 8601），不填为当前时间', required: false })
+  @IsOptional()
+  @IsDateString()
+  endsAt?: string;
+}
@@ -0,0 +1,26 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsBoolean, IsInt, IsOptional, IsString, Max, Min } from 'class-validator';
+
+export class LanguageBackfillDto {
+  @ApiProperty({ description: '只检测该用户的文档，不填为所有用户', required: false })
+  @IsOptional()
+  @IsString()
+  userId?: string;
+
+  @ApiProperty({ description: '同时检测自动检测置信度偏低的文档', required: false, default: true })
+  @IsOptional()
+  @IsBoolean()
+  includeSuspicious?: boolean;
+
+  @ApiProperty({ description: '重新检测已检测过的文档', required: false, default: false })
+  @IsOptional()
+  @IsBoolean()
+  recheck?: boolean;
+
+  @ApiProperty({ description: '最多检测的文档数，不超过 LANGUAGE_BACKFILL_MAX_DOCUMENTS', required: false })
+  @IsOptional()
+  @IsInt()
+  @Min(1)
+  @Max(1000000)
+  limit?: number;
+}
@@ -0,0 +1,79 @@
+import { ApiProperty } from '@nestjs/swagger';
+import {
+  ArrayMaxSize,
+  ArrayNotEmpty,
+  IsArray,
+  IsBoolean,
+  IsEnum,
+  IsInt,
+  IsOptional,
+  IsString,
+  MaxLength,
+  Min,
+} from 'class-validator';
+import { LintRuleType } from '../utils/translation-lint';
+
+export class CreateLintRuleDto {
+  @ApiProperty({ description: '名称', required: false, example: 'No competitor names (JP)' })
+  @IsOptional()
+  @IsString()
+  @MaxLength(255)
+  name?: string;
+
+  @ApiProperty({ description: '规则类型', enum: LintRuleType })
+  @IsEnum(LintRuleType)
+  type: LintRuleType;
+
+  @ApiProperty({ description: '生效的目标语言（ja 同时匹配 ja-JP），为空表示全部', type: [String], required: false })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(50)
+  @IsString({ each: true })
+  languages?: string[];
+
+  @ApiProperty({
+    description: '只检查匹配的键（点号路径，* 匹配一段，** 匹配任意多段），为空表示全部',
+    required: false,
+    example: 'marketing.**',
+  })
+  @IsOptional()
+  @IsString()
+  @MaxLength(255)
+  keyPattern?: string;
+
+  @ApiProperty({
+    description: 'forbidden_term：出现任一即违规；required_term：至少包含其中一个',
+    type: [String],
+    required: false,
+    example: ['様'],
+  })
+  @IsOptional()
+  @IsArray()
+  @ArrayNotEmpty()
+  @ArrayMaxSize(200)
+  @IsString({ each: true })
+  @MaxLength(255, { each: true })
+  terms?: string[];
+
+  @ApiProperty({ description: '词语匹配是否区分大小写，默认 false', required: false })
+  @IsOptional()
+  @IsBoolean()
+  caseSensitive?: boolean;
+
+  @ApiProperty({ description: 'max_length：每个译文字符串的字符数上限', required: false, example: 40 })
+  @IsOptional()
+  @IsInt()
+  @Min(1)
+  maxLength?: number;
+
+  @ApiProperty({ description: 'banned_characters：不允许出现的字符', required: false, example: '™®' })
+  @IsOptional()
+  @IsString()
+  @MaxLength(255)
+  characters?: string;
+
+  @ApiProperty({ description: '违规时暂停结果推送，直到违规解决，默认 false', required: false })
+  @IsOptional()
+  @IsBoolean()
+  blocking?: boolean;
+}
@@ -0,0 +1,68 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { Type } from 'class-transformer';
+import {
+  ArrayMaxSize,
+  ArrayNotEmpty,
+  IsArray,
+  IsBoolean,
+  IsEnum,
+  IsObject,
+  IsOptional,
+  IsString,
+  Matches,
+  ValidateNested,
+} from 'class-validator';
+
+export enum LocaleBundleFormat {
+  /** { 语言: 文档 } 的 JSON */
+  NESTED = 'nested',
+  /** 每个语言一个 {语言}.json 的 ZIP */
+  ZIP = 'zip',
+}
+
+const LOCALE_PATTERN = /^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$/;
+
+export class LocaleDocumentDto {
+  @ApiProperty({ description: '语言代码，同时用作 ZIP 中的文件名', example: 'de' })
+  @IsString()
+  @Matches(LOCALE_PATTERN, { message: 'lang must be a locale code' })
+  lang: string;
+
+  @ApiProperty({ description: 'JSON 文档内容' })
+  @IsString()
+  json: string;
+}
+
+export class LocaleBundleDto {
+  @ApiProperty({ description: '源文档', type: LocaleDocumentDto })
+  @ValidateNested()
+  @Type(() => LocaleDocumentDto)
+  source: LocaleDocumentDto;
+
+  @ApiProperty({ description: '各语言的译文', type: [LocaleDocumentDto] })
+  @IsArray()
+  @ArrayNotEmpty()
+  @ArrayMaxSize(50)
+  @ValidateNested({ each: true })
+  @Type(() => LocaleDocumentDto)
+  translations: LocaleDocumentDto[];
+
+  @ApiProperty({ description: '输出形式', enum: LocaleBundleFormat, default: LocaleBundleFormat.NESTED, required: false })
+  @IsOptional()
+  @IsEnum(LocaleBundleFormat)
+  format?: LocaleBundleFormat;
+
+  @ApiProperty({ description: '译文缺失的键是否补齐（默认 true，false 时省略）', required: false })
+  @IsOptional()
+  @IsBoolean()
+  fillMissing?: boolean;
+
+  @ApiProperty({
+    description: '显式回退链（语言 → 依次查找的语言），未指定的语言按代码推导（fr-CA → fr），最后回退到源语言',
+    required: false,
+    example: { 'fr-CA': ['fr'], 'pt-BR': ['pt-PT', 'es'] },
+  })
+  @IsOptional()
+  @IsObject()
+  fallbacks?: Record<string, string[]>;
+}
@@ -0,0 +1,94 @@
+import { ApiProperty } from '@nestjs/swagger';
+import {
+  ArrayMaxSize,
+  ArrayNotEmpty,
+  ArrayUnique,
+  IsArray,
+  IsBoolean,
+  IsIn,
+  IsOptional,
+  IsString,
+  Matches,
+  MaxLength,
+} from 'class-validator';
+import { TranslationProvider } from '../../../config/providers';
+
+/** 与翻译任务的标签规则一致 */
+const TAG_PATTERN = /^[\w.:-]{1,50}$/;
+
+export class ProjectSettingsDto {
+  @ApiProperty({ description: '目标语言集合', type: [String], example: ['zh', 'ja', 'de'] })
+  @IsArray()
+  @ArrayNotEmpty()
+  @ArrayMaxSize(50)
+  @ArrayUnique()
+  @IsString({ each: true })
+  targetLangs: string[];
+
+  @ApiProperty({ description: '默认源语言，不设置时自动检测', required: false, example: 'en' })
+  @IsOptional()
+  @IsString()
+  sourceLang?: string;
+
+  @ApiProperty({ description: '翻译服务商', required: false, enum: TranslationProvider })
+  @IsOptional()
+  @IsIn(Object.values(TranslationProvider))
+  provider?: string;
+}
+
+export class ProjectTranslateDto {
+  @ApiProperty({ description: '原始JSON内容' })
+  @IsString()
+  jsonContentRaw: string;
+
+  @ApiProperty({ description: '源语言，默认使用项目设置，未设置时自动检测', required: false, example: 'en' })
+  @IsOptional()
+  @IsString()
+  fromLang?: string;
+
+  @ApiProperty({ description: '文档名称，每个目标语言的文档使用相同名称', required: false })
+  @IsOptional()
+  @IsString()
+  @MaxLength(200)
+  name?: string;
+
+  @ApiProperty({ description: '文档标签', required: false, type: [String] })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(20)
+  @Matches(TAG_PATTERN, { each: true, message: 'tags may only contain letters, digits, ".", "_", ":" and "-"' })
+  tags?: string[];
+
+  @ApiProperty({ description: '忽略翻译的字段，逗号分隔', required: false })
+  @IsOptional()
+  @IsString()
+  ignoredFields?: string;
+
+  @ApiProperty({ description: '忽略配置 ID（/user/profiles）', required: false })
+  @IsOptional()
+  @IsString()
+  profileId?: string;
+
+  @ApiProperty({ description: '发给翻译服务前屏蔽个人信息，译文中换回原文', required: false, default: false })
+  @IsOptional()
+  @IsBoolean()
+  maskPii?: boolean;
+
+  @ApiProperty({ description: '除内置规则外需要屏蔽的正则表达式', required: false, type: [String] })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(20)
+  @IsString({ each: true })
+  @MaxLength(200, { each: true })
+  piiPatterns?: string[];
+
+  @ApiProperty({ description: '为 true 时不推送结果和失败 webhook', required: false, default: false })
+  @IsOptional()
+  @IsBoolean()
+  suppressWebhook?: boolean;
+
+  @ApiProperty({ description: '为 true 时跳过重复提交检测', required: false, default: false })
+  @IsOptional()
+  @IsBoolean()
+  force?: boolean;
+}
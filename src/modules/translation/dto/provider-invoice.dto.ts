@@ -0,0 +1,75 @@
+import { ApiProperty } from '@nestjs/swagger';
+import {
+  ArrayMaxSize,
+  IsArray,
+  IsBoolean,
+  IsEnum,
+  IsInt,
+  IsNumber,
+  IsOptional,
+  IsString,
+  Matches,
+  MaxLength,
+  Min,
+} from 'class-validator';
+import { TranslationProvider } from '../../../config/providers';
+import { UsageImportFormat } from './usage-import.dto';
+
+export const PERIOD_PATTERN = /^\d{4}-(0[1-9]|1[0-2])$/;
+
+/**
+ * 翻译服务商的一条月度账单
+ */
+export class ProviderInvoiceRecordDto {
+  @ApiProperty({ enum: TranslationProvider, example: TranslationProvider.ALIYUN })
+  @IsEnum(TranslationProvider)
+  provider: string;
+
+  @ApiProperty({ description: '账单月份（UTC，YYYY-MM）', example: '2026-09' })
+  @Matches(PERIOD_PATTERN, { message: 'period must be formatted as YYYY-MM' })
+  period: string;
+
+  @ApiProperty({ description: '账单中的计费字符数', example: 12500000 })
+  @IsInt()
+  @Min(0)
+  characters: number;
+
+  @ApiProperty({ description: '账单金额', required: false, example: 625.5 })
+  @IsOptional()
+  @IsNumber({ maxDecimalPlaces: 2 })
+  @Min(0)
+  amount?: number;
+
+  @ApiProperty({ description: '币种（ISO 4217）', required: false, example: 'CNY' })
+  @IsOptional()
+  @Matches(/^[A-Z]{3}$/)
+  currency?: string;
+
+  @ApiProperty({ description: '账单号', required: false })
+  @IsOptional()
+  @IsString()
+  @MaxLength(255)
+  reference?: string;
+}
+
+export class ProviderInvoiceImportDto {
+  @ApiProperty({ enum: UsageImportFormat, description: '数据格式' })
+  @IsEnum(UsageImportFormat)
+  format: UsageImportFormat;
+
+  @ApiProperty({ description: 'format=json 时的账单数组', required: false, type: [ProviderInvoiceRecordDto] })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(1000)
+  records?: Record<string, any>[];
+
+  @ApiProperty({ description: 'format=csv 时的 CSV 文本（首行为表头，列名同 JSON 字段）', required: false })
+  @IsOptional()
+  @IsString()
+  csv?: string;
+
+  @ApiProperty({ description: '只校验并返回报告，不写入', required: false, default: false })
+  @IsOptional()
+  @IsBoolean()
+  dryRun?: boolean;
+}
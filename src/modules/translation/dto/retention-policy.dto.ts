@@ -0,0 +1,15 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsInt, Max, Min, ValidateIf } from 'class-validator';
+
+export class RetentionPolicyDto {
+  @ApiProperty({
+    description: '文档内容保留天数，只能缩短计划的保留期（取较小值）；null 恢复为计划的保留期',
+    nullable: true,
+    example: 30,
+  })
+  @ValidateIf((_, value) => value !== null)
+  @IsInt()
+  @Min(1)
+  @Max(3650)
+  retentionDays: number | null;
+}
@@ -0,0 +1,44 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { ArrayMaxSize, ArrayNotEmpty, IsArray, IsBoolean, IsOptional, IsString, IsUrl, MaxLength } from 'class-validator';
+
+export class CreateSourceSyncDto {
+  @ApiProperty({ description: '名称', required: false, example: 'CMS export' })
+  @IsOptional()
+  @IsString()
+  @MaxLength(255)
+  name?: string;
+
+  @ApiProperty({ description: 'JSON 文件地址（http / https）', example: 'https://cms.example.com/export/en.json' })
+  @IsUrl({ protocols: ['http', 'https'], require_protocol: true, require_tld: false })
+  url: string;
+
+  @ApiProperty({ description: '源语言', example: 'en' })
+  @IsString()
+  fromLang: string;
+
+  @ApiProperty({ description: '目标语言', type: [String], example: ['zh', 'ja'] })
+  @IsArray()
+  @ArrayNotEmpty()
+  @ArrayMaxSize(20)
+  @IsString({ each: true })
+  targetLangs: string[];
+
+  @ApiProperty({ description: '忽略翻译的字段，逗号分隔', required: false })
+  @IsOptional()
+  @IsString()
+  ignoredFields?: string;
+
+  @ApiProperty({ description: '拉取计划（cron 表达式）', example: '0 2 * * *' })
+  @IsString()
+  cron: string;
+
+  @ApiProperty({ description: 'cron 表达式使用的时区', required: false, example: 'Asia/Shanghai' })
+  @IsOptional()
+  @IsString()
+  timezone?: string;
+
+  @ApiProperty({ description: '创建后立即同步一次，默认 true', required: false })
+  @IsOptional()
+  @IsBoolean()
+  runNow?: boolean;
+}
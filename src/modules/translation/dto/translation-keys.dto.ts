@@ -0,0 +1,39 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { Type } from 'class-transformer';
+import {
+  ArrayMaxSize,
+  ArrayNotEmpty,
+  IsArray,
+  IsBoolean,
+  IsOptional,
+  IsString,
+  MaxLength,
+  ValidateNested,
+} from 'class-validator';
+
+export class TranslationKeyUpdateDto {
+  @ApiProperty({ description: '键路径，嵌套键以点号分隔', example: 'nav.home' })
+  @IsString()
+  @MaxLength(1000)
+  key: string;
+
+  @ApiProperty({ description: '人工修改后的译文；提供时默认同时锁定该键', required: false, example: '首页' })
+  @IsOptional()
+  @IsString()
+  value?: string;
+
+  @ApiProperty({ description: '是否锁定：锁定的键在重新翻译时保留当前译文', required: false })
+  @IsOptional()
+  @IsBoolean()
+  locked?: boolean;
+}
+
+export class UpdateTranslationKeysDto {
+  @ApiProperty({ type: [TranslationKeyUpdateDto] })
+  @IsArray()
+  @ArrayNotEmpty()
+  @ArrayMaxSize(1000)
+  @ValidateNested({ each: true })
+  @Type(() => TranslationKeyUpdateDto)
+  keys: TranslationKeyUpdateDto[];
+}
@@ -0,0 +1,22 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { ArrayMaxSize, ArrayNotEmpty, IsArray, IsEnum, IsOptional, IsString } from 'class-validator';
+import { ReviewStatus } from '../entities/translation-task.entity';
+
+export class ReviewTransitionDto {
+  @ApiProperty({ enum: ReviewStatus, description: '目标状态', example: ReviewStatus.IN_REVIEW })
+  @IsEnum(ReviewStatus)
+  status: ReviewStatus;
+
+  @ApiProperty({
+    description: '只变更这些键的状态（点号路径）；不传时变更整个文档',
+    required: false,
+    type: [String],
+    example: ['nav.home'],
+  })
+  @IsOptional()
+  @IsArray()
+  @ArrayNotEmpty()
+  @ArrayMaxSize(1000)
+  @IsString({ each: true })
+  keys?: string[];
+}
@@ -1,5 +1,25 @@
 import { ApiProperty } from '@nestjs/swagger';
-import { IsString, IsBoolean, IsNumber, IsOptional } from 'class-validator';
+import {
+  IsString,
+  IsBoolean,
+  IsNumber,
+  IsOptional,
+  IsIn,
+  IsDateString,
+  IsArray,
+  ArrayMaxSize,
+  Matches,
+  MaxLength,
+} from 'class-validator';
+import { TranslationProvider } from '../../../config/providers';
+import { TaskPriority } from '../services/queue-priority.service';
+import { OutputKeyFormat } from '../utils/output-keys';
+import { PlaceholderStyle } from '../utils/placeholders';
+
+/** 标签只允许字母、数字和 . _ : - */
+const TAG_PATTERN = /^[\w.:-]{1,50}$/;
+const MAX_TAGS = 20;
+const MAX_PII_PATTERNS = 20;
 
 export class TranslationTaskPayload {
   @ApiProperty({ description: '用户ID' })
@@ -28,13 +48,218 @@ export class TranslationPayload {
   @IsString()
   jsonContentRaw: string;
 
-  @ApiProperty({ description: '源语言' })
+  @ApiProperty({ description: '源语言，传 auto 时根据内容自动检测', example: 'auto' })
   @IsString()
   fromLang: string;
 
   @ApiProperty({ description: '目标语言' })
   @IsString()
   toLang: string;
+
+  @ApiProperty({ description: '文档名称，便于在列表中查找', required: false, example: 'checkout-page-v2' })
+  @IsOptional()
+  @IsString()
+  @MaxLength(200)
+  name?: string;
+
+  @ApiProperty({ description: '文档标签', required: false, type: [String], example: ['web', 'release-3.2'] })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(MAX_TAGS)
+  @Matches(TAG_PATTERN, { each: true, message: 'tags may only contain letters, digits, ".", "_", ":" and "-"' })
+  tags?: string[];
+
+  @ApiProperty({ description: '忽略翻译的字段，逗号分隔', required: false })
+  @IsOptional()
+  @IsString()
+  ignoredFields?: string;
+
+  @ApiProperty({
+    description: '忽略配置 ID（/user/profiles），配置的规则与 ignoredFields 同时生效',
+    required: false,
+  })
+  @IsOptional()
+  @IsString()
+  profileId?: string;
+
+  @ApiProperty({ description: '所属项目（委托密钥只能访问其绑定的项目）', required: false })
+  @IsOptional()
+  @IsString()
+  project?: string;
+
+  @ApiProperty({ description: '翻译服务商', required: false, enum: TranslationProvider })
+  @IsOptional()
+  @IsIn(Object.values(TranslationProvider))
+  provider?: string;
+
+  @ApiProperty({
+    description: '队列优先级，只能低于计划允许的级别（例如批量任务主动降为 low）',
+    required: false,
+    enum: TaskPriority,
+  })
+  @IsOptional()
+  @IsIn(Object.values(TaskPriority))
+  priority?: TaskPriority;
+
+  @ApiProperty({
+    description: '文档使用的占位符语法，翻译时原样保留并校验译文中没有丢失；不传时使用内置分隔符',
+    required: false,
+    enum: PlaceholderStyle,
+    isArray: true,
+    example: ['icu', 'printf'],
+  })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(Object.values(PlaceholderStyle).length)
+  @IsIn(Object.values(PlaceholderStyle), { each: true })
+  placeholderStyles?: PlaceholderStyle[];
+
+  @ApiProperty({
+    description: '保留原文格式：自动修复译文的首尾空白、占位符大小写和换行符，换行数量不一致时在校验报告中标记',
+    required: false,
+    default: false,
+  })
+  @IsOptional()
+  @IsBoolean()
+  preserveFormatting?: boolean;
+
+  @ApiProperty({
+    description: '发给翻译服务前屏蔽个人信息（邮箱、电话号码、信用卡号和 piiPatterns），译文中换回原文；结果附带屏蔽报告',
+    required: false,
+    default: false,
+  })
+  @IsOptional()
+  @IsBoolean()
+  maskPii?: boolean;
+
+  @ApiProperty({
+    description: '除内置规则外需要屏蔽的正则表达式（maskPii 为 true 时生效）',
+    required: false,
+    type: [String],
+    example: ['EMP-\\d{6}'],
+  })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(MAX_PII_PATTERNS)
+  @IsString({ each: true })
+  @MaxLength(200, { each: true })
+  piiPatterns?: string[];
+
+  @ApiProperty({
+    description: '译文输出的键形态：nested 保持原结构，suffix 叶子键加目标语言后缀，merged 合并原文和译文，flat 点号路径',
+    required: false,
+    enum: OutputKeyFormat,
+    default: OutputKeyFormat.NESTED,
+  })
+  @IsOptional()
+  @IsIn(Object.values(OutputKeyFormat))
+  outputKeyFormat?: OutputKeyFormat;
+
+  @ApiProperty({
+    description: '为 true 时不推送结果和失败 webhook，用于批量回填历史文档；结果仍可通过接口读取',
+    required: false,
+    default: false,
+  })
+  @IsOptional()
+  @IsBoolean()
+  suppressWebhook?: boolean;
+
+  @ApiProperty({
+    description: '为 true 时跳过重复提交检测（窗口期内提交相同文档和翻译选项默认返回 409 及已有文档 ID）',
+    required: false,
+    default: false,
+  })
+  @IsOptional()
+  @IsBoolean()
+  force?: boolean;
+
+  @ApiProperty({ description: '定时执行时间（ISO 8601），与 cron 二选一', required: false, example: '2026-10-17T02:00:00Z' })
+  @IsOptional()
+  @IsDateString()
+  processAt?: string;
+
+  @ApiProperty({ description: '周期执行的 cron 表达式，与 processAt 二选一', required: false, example: '0 2 * * *' })
+  @IsOptional()
+  @IsString()
+  cron?: string;
+
+  @ApiProperty({ description: 'cron 表达式使用的时区', required: false, example: 'Asia/Shanghai' })
+  @IsOptional()
+  @IsString()
+  timezone?: string;
+}
+
+/** 修改文档名称和标签；未提供的字段保持不变，name 传空字符串清除名称 */
+export class DocumentMetadataDto {
+  @ApiProperty({ description: '文档名称，空字符串表示清除', required: false, example: 'checkout-page-v2' })
+  @IsOptional()
+  @IsString()
+  @MaxLength(200)
+  name?: string;
+
+  @ApiProperty({ description: '文档标签，整体替换', required: false, type: [String], example: ['web', 'release-3.2'] })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(MAX_TAGS)
+  @Matches(TAG_PATTERN, { each: true, message: 'tags may only contain letters, digits, ".", "_", ":" and "-"' })
+  tags?: string[];
+}
+
+export class ScheduledTranslation {
+  @ApiProperty({ description: '任务 ID' })
+  id: string;
+
+  @ApiProperty({ description: '定时执行时间', required: false })
+  scheduledAt?: Date;
+
+  @ApiProperty({ description: 'cron 表达式', required: false })
+  cron?: string;
+
+  @ApiProperty({ description: 'cron 时区', required: false })
+  timezone?: string;
+
+  @ApiProperty({ description: '下一次执行时间', required: false })
+  nextRunAt?: Date;
+
+  @ApiProperty({ description: '计费字符数' })
+  charTotal: number;
+
+  @ApiProperty({ description: '所属项目', required: false })
+  project?: string;
+
+  @ApiProperty({ description: '创建时间' })
+  createdAt: Date;
+}
+
+export class TranslationEstimate {
+  @ApiProperty({ description: '计费字符数（已排除忽略字段）' })
+  charTotal: number;
+
+  @ApiProperty({ description: '是否在本月剩余额度内' })
+  fitsQuota: boolean;
+
+  @ApiProperty({ description: '按当前额度模式是否会被接受' })
+  allowed: boolean;
+
+  @ApiProperty({ description: '额度信息' })
+  quota: {
+    mode: string;
+    used: number;
+    limit: number;
+    remaining: number;
+  };
+
+  @ApiProperty({ description: '额度警告', type: [String] })
+  warnings: string[];
+
+  @ApiProperty({ description: '当前排队中的任务数' })
+  queuedTasks: number;
+
+  @ApiProperty({ description: '预计完成耗时（秒）' })
+  estimatedSeconds: number;
+
+  @ApiProperty({ description: '预计完成时间' })
+  estimatedCompletionAt: string;
 }
 
 export class TranslationResponse {
@@ -0,0 +1,99 @@
+import { ApiProperty } from '@nestjs/swagger';
+import {
+  ArrayMaxSize,
+  IsArray,
+  IsBoolean,
+  IsDateString,
+  IsEnum,
+  IsInt,
+  IsOptional,
+  IsString,
+  Matches,
+  MaxLength,
+  Min,
+} from 'class-validator';
+
+export enum UsageImportFormat {
+  JSON = 'json',
+  CSV = 'csv',
+}
+
+/**
+ * 单条历史记录；带 sourceJson 时同时导入文档（翻译任务和原文/译文）
+ */
+export class UsageImportRecordDto {
+  @ApiProperty({ description: '旧系统中的记录 ID，同一来源重复导入时据此去重' })
+  @IsString()
+  @MaxLength(255)
+  externalId: string;
+
+  @ApiProperty({ description: '本系统的用户 ID' })
+  @IsString()
+  userId: string;
+
+  @ApiProperty({ description: '发生时间（ISO 8601）', example: '2026-09-14T08:00:00Z' })
+  @IsDateString()
+  occurredAt: string;
+
+  @ApiProperty({ description: '计费字符数', example: 1200 })
+  @IsInt()
+  @Min(0)
+  characters: number;
+
+  @ApiProperty({ required: false })
+  @IsOptional()
+  @IsString()
+  apiKeyId?: string;
+
+  @ApiProperty({ required: false })
+  @IsOptional()
+  @IsString()
+  tenantId?: string;
+
+  @ApiProperty({ description: '源语言（导入文档时必填）', required: false })
+  @IsOptional()
+  @IsString()
+  fromLang?: string;
+
+  @ApiProperty({ description: '目标语言（导入文档时必填）', required: false })
+  @IsOptional()
+  @IsString()
+  toLang?: string;
+
+  @ApiProperty({ description: '原文 JSON', required: false })
+  @IsOptional()
+  @IsString()
+  sourceJson?: string;
+
+  @ApiProperty({ description: '译文 JSON', required: false })
+  @IsOptional()
+  @IsString()
+  translatedJson?: string;
+}
+
+export class UsageImportDto {
+  @ApiProperty({ enum: UsageImportFormat, description: '数据格式' })
+  @IsEnum(UsageImportFormat)
+  format: UsageImportFormat;
+
+  @ApiProperty({ description: 'format=json 时的记录数组', required: false, type: [UsageImportRecordDto] })
+  @IsOptional()
+  @IsArray()
+  @ArrayMaxSize(10000)
+  records?: Record<string, any>[];
+
+  @ApiProperty({ description: 'format=csv 时的 CSV 文本（首行为表头，列名同 JSON 字段）', required: false })
+  @IsOptional()
+  @IsString()
+  csv?: string;
+
+  @ApiProperty({ description: '来源系统标识，参与去重', required: false, example: 'legacy' })
+  @IsOptional()
+  @Matches(/^[\w.-]{1,64}$/)
+  source?: string;
+
+  @ApiProperty({ description: '只校验并返回报告，不写入', required: false, default: false })
+  @IsOptional()
+  @IsBoolean()
+  dryRun?: boolean;
+}
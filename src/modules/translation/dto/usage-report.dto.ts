@@ -0,0 +1,21 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { ArrayMaxSize, ArrayNotEmpty, IsArray, IsEnum } from 'class-validator';
+import { UsageReportChannel, UsageReportFrequency } from '../entities/usage-report.entity';
+
+export class UsageReportSubscriptionDto {
+  @ApiProperty({ description: '发送周期：weekly 每周一发送上一周，monthly 每月 1 日发送上一个月', enum: UsageReportFrequency })
+  @IsEnum(UsageReportFrequency)
+  frequency: UsageReportFrequency;
+
+  @ApiProperty({
+    description: '发送方式：email 发到账户邮箱，webhook 作为 usage.report 事件推送',
+    enum: UsageReportChannel,
+    isArray: true,
+    example: ['email'],
+  })
+  @IsArray()
+  @ArrayNotEmpty()
+  @ArrayMaxSize(Object.values(UsageReportChannel).length)
+  @IsEnum(UsageReportChannel, { each: true })
+  channels: UsageReportChannel[];
+}
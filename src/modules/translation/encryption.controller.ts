@@ -0,0 +1,29 @@
+import { Body, Controller, Get, Put, Req, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiResponse, ApiSecurity, ApiTags } from '@nestjs/swagger';
+import { JwtOrApiKeyGuard } from '../auth/guards/jwt-or-api-key.guard';
+import { DocumentEncryptionService } from './services/document-encryption.service';
+import { DocumentEncryptionDto } from './dto/document-encryption.dto';
+
+@ApiTags('user')
+@Controller('user/encryption')
+@ApiBearerAuth()
+@ApiSecurity('api-key')
+@UseGuards(JwtOrApiKeyGuard)
+export class EncryptionController {
+  constructor(private readonly documentEncryptionService: DocumentEncryptionService) {}
+
+  @Get()
+  @ApiOperation({ summary: '获取文档内容加密设置' })
+  @ApiResponse({ status: 200, description: 'available 为 false 表示服务端未配置主密钥，无法开启' })
+  async get(@Req() req: any) {
+    return this.documentEncryptionService.getStatus(req.user.id);
+  }
+
+  @Put()
+  @ApiOperation({ summary: '开启或关闭文档内容加密（只影响之后写入的内容）' })
+  @ApiResponse({ status: 200, description: '返回当前设置' })
+  @ApiResponse({ status: 503, description: '服务端未配置主密钥' })
+  async set(@Req() req: any, @Body() dto: DocumentEncryptionDto) {
+    return this.documentEncryptionService.setEnabled(req.user.id, dto.enabled);
+  }
+}
@@ -0,0 +1,39 @@
+import { Entity, PrimaryKey, Property, Unique } from '@mikro-orm/core';
+
+/**
+ * 自定义机器翻译引擎（provider 为 custom 的任务调用）
+ * 用户提供自建或内网部署的翻译服务地址、认证请求头和请求 / 响应的 JSON 映射，每个账户一个
+ */
+@Entity()
+@Unique({ properties: ['userId'] })
+export class CustomMtEngine {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  userId!: string;
+
+  @Property({ length: 2048 })
+  url!: string;
+
+  @Property({ nullable: true })
+  authHeaderName?: string;
+
+  /** 认证请求头的值，使用 SECRETS_ENCRYPTION_KEY 加密保存 */
+  @Property({ type: 'text', nullable: true })
+  authHeaderValue?: string;
+
+  /** 请求体模板（JSON），{{text}}、{{source}}、{{target}} 替换为转义后的原文和语言代码 */
+  @Property({ type: 'text' })
+  requestTemplate!: string;
+
+  /** 响应中译文所在的点号路径，数组下标写作数字，如 translations.0.text */
+  @Property()
+  responsePath!: string;
+
+  @Property()
+  createdAt: Date = new Date();
+
+  @Property({ onUpdate: () => new Date() })
+  updatedAt: Date = new Date();
+}
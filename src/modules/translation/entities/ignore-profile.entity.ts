@@ -0,0 +1,45 @@
+import { Entity, PrimaryKey, Property, Unique } from '@mikro-orm/core';
+
+/**
+ * 忽略配置
+ * 用户保存一组命名的忽略 / 只翻译规则，创建任务时通过 profileId 引用，不必每次都传很长的 ignoredFields；
+ * 任务保存规则的快照，之后修改配置不影响已创建的任务
+ */
+@Entity()
+@Unique({ properties: ['userId', 'name'] })
+export class IgnoreProfile {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  userId!: string;
+
+  @Property()
+  name!: string;
+
+  /** 忽略的键名，任意层级匹配 */
+  @Property({ type: 'json', nullable: true })
+  fields?: string[];
+
+  /** 忽略的路径（* 匹配一段，** 匹配任意多段） */
+  @Property({ type: 'json', nullable: true })
+  paths?: string[];
+
+  /** 匹配完整点号路径的正则表达式 */
+  @Property({ type: 'json', nullable: true })
+  patterns?: string[];
+
+  /** 只翻译这些路径下的字符串 */
+  @Property({ type: 'json', nullable: true })
+  include?: string[];
+
+  /** 按值跳过的规则（SkipHeuristic） */
+  @Property({ type: 'json', nullable: true })
+  skip?: string[];
+
+  @Property()
+  createdAt: Date = new Date();
+
+  @Property({ onUpdate: () => new Date() })
+  updatedAt: Date = new Date();
+}
@@ -0,0 +1,39 @@
+import { Entity, Index, PrimaryKey, Property } from '@mikro-orm/core';
+
+/**
+ * 运维声明的故障时间窗
+ * 窗口内排队或执行的任务在状态、结果和文档列表中附带故障说明，provider 为空表示影响所有服务商
+ */
+@Entity()
+@Index({ properties: ['startsAt'] })
+export class Incident {
+  @PrimaryKey()
+  id!: string;
+
+  /** 对外展示的故障编号，如 INC-42 */
+  @Property({ length: 50 })
+  reference!: string;
+
+  /** 对用户展示的说明 */
+  @Property({ length: 500 })
+  message!: string;
+
+  @Property({ nullable: true })
+  provider?: string;
+
+  @Property()
+  startsAt!: Date;
+
+  /** 为空表示故障仍在持续 */
+  @Property({ nullable: true })
+  endsAt?: Date;
+
+  @Property()
+  createdBy!: string;
+
+  @Property()
+  createdAt: Date = new Date();
+
+  @Property({ onUpdate: () => new Date() })
+  updatedAt: Date = new Date();
+}
@@ -0,0 +1,50 @@
+import { Entity, PrimaryKey, Property } from '@mikro-orm/core';
+
+/**
+ * 译文检查规则
+ * 翻译完成和人工修改译文后按用户的规则检查译文，违规项记录在文档的 lintReport 中；
+ * blocking 规则违规时暂停结果推送，直到违规解决
+ */
+@Entity()
+export class LintRule {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  userId!: string;
+
+  @Property({ nullable: true })
+  name?: string;
+
+  /** LintRuleType */
+  @Property()
+  type!: string;
+
+  /** 生效的目标语言，为空表示全部 */
+  @Property({ type: 'json', nullable: true })
+  languages?: string[];
+
+  /** 只检查匹配的键（* 匹配一段，** 匹配任意多段） */
+  @Property({ nullable: true })
+  keyPattern?: string;
+
+  /** forbidden_term / required_term 的词语 */
+  @Property({ type: 'json', nullable: true })
+  terms?: string[];
+
+  @Property()
+  caseSensitive: boolean = false;
+
+  @Property({ nullable: true })
+  maxLength?: number;
+
+  /** banned_characters 的字符集合 */
+  @Property({ nullable: true })
+  characters?: string;
+
+  @Property()
+  blocking: boolean = false;
+
+  @Property()
+  createdAt: Date = new Date();
+}
@@ -0,0 +1,69 @@
+import { Entity, Index, PrimaryKey, Property, Unique } from '@mikro-orm/core';
+
+/**
+ * 项目设置
+ * 项目仍以任务上的 project 名称标识，这里只保存项目的目标语言集合等默认值；
+ * 向项目提交源文档时按目标语言展开为多个翻译任务
+ */
+@Entity()
+@Unique({ properties: ['userId', 'project'] })
+export class ProjectSettings {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  userId!: string;
+
+  @Property()
+  project!: string;
+
+  @Property({ type: 'json' })
+  targetLangs!: string[];
+
+  /** 提交时未指定源语言使用的默认值，为空时自动检测 */
+  @Property({ nullable: true })
+  sourceLang?: string;
+
+  @Property({ nullable: true })
+  provider?: string;
+
+  @Property()
+  createdAt: Date = new Date();
+
+  @Property({ onUpdate: () => new Date() })
+  updatedAt: Date = new Date();
+}
+
+/**
+ * 一次向项目提交的源文档；每个目标语言对应一个翻译任务，整体状态由这些任务的状态汇总
+ */
+@Entity()
+@Index({ properties: ['userId', 'project', 'createdAt'] })
+export class ProjectJob {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  userId!: string;
+
+  @Property()
+  project!: string;
+
+  @Property()
+  fromLang!: string;
+
+  /** 目标语言 → 任务 ID */
+  @Property({ type: 'json' })
+  tasks: Record<string, string> = {};
+
+  /** 创建任务时被拒绝的目标语言 → 错误消息 */
+  @Property({ type: 'json', nullable: true })
+  rejected?: Record<string, string>;
+
+  /** 每个目标语言任务的计费字符数 */
+  @Property()
+  charTotal: number = 0;
+
+  @Property()
+  createdAt: Date = new Date();
+}
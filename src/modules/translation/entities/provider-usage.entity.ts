@@ -0,0 +1,66 @@
+import { Entity, PrimaryKey, Property } from '@mikro-orm/core';
+
+/**
+ * 每个账户每月实际发给翻译服务计费的字符数（不含缓存命中和断点复用）
+ * 由 Redis 计数器定时同步，userId 为空表示无法归属到账户的调用
+ */
+@Entity()
+export class ProviderUsageMonthly {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  provider!: string;
+
+  /** YYYY-MM（UTC） */
+  @Property()
+  period!: string;
+
+  @Property({ nullable: true })
+  userId?: string;
+
+  @Property()
+  characters: number = 0;
+
+  @Property({ onUpdate: () => new Date() })
+  updatedAt: Date = new Date();
+}
+
+/**
+ * 翻译服务商的月度账单（管理员导入），用于与记录的调用量对账
+ */
+@Entity()
+export class ProviderInvoice {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  provider!: string;
+
+  /** YYYY-MM（UTC） */
+  @Property()
+  period!: string;
+
+  /** 账单中的计费字符数 */
+  @Property()
+  characters!: number;
+
+  @Property({ type: 'decimal', precision: 12, scale: 2, nullable: true })
+  amount?: number;
+
+  @Property({ nullable: true })
+  currency?: string;
+
+  /** 账单号 */
+  @Property({ nullable: true })
+  reference?: string;
+
+  @Property()
+  importedBy!: string;
+
+  @Property()
+  createdAt: Date = new Date();
+
+  @Property({ onUpdate: () => new Date() })
+  updatedAt: Date = new Date();
+}
@@ -0,0 +1,23 @@
+import { Entity, PrimaryKey, Property, Unique } from '@mikro-orm/core';
+
+/**
+ * 用户自行设置的文档内容保留天数，只能比计划的保留期更短（取两者较小值）
+ */
+@Entity()
+@Unique({ properties: ['userId'] })
+export class RetentionPolicy {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  userId!: string;
+
+  @Property()
+  retentionDays!: number;
+
+  @Property()
+  createdAt: Date = new Date();
+
+  @Property({ onUpdate: () => new Date() })
+  updatedAt: Date = new Date();
+}
@@ -0,0 +1,72 @@
+import { Entity, PrimaryKey, Property } from '@mikro-orm/core';
+
+/**
+ * 远程 JSON 源同步
+ * 按计划拉取用户登记的 JSON 地址，与上一次拉取的内容比对，只翻译变化的键并通过 webhook 通知
+ */
+@Entity()
+export class SourceSync {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  userId!: string;
+
+  @Property({ nullable: true })
+  tenantId?: string;
+
+  @Property({ nullable: true })
+  name?: string;
+
+  @Property({ type: 'text' })
+  url!: string;
+
+  @Property()
+  fromLang!: string;
+
+  /** 目标语言，逗号分隔 */
+  @Property()
+  targetLangs!: string;
+
+  @Property({ type: 'text', nullable: true })
+  ignoredFields?: string;
+
+  @Property()
+  cron!: string;
+
+  @Property({ nullable: true })
+  timezone?: string;
+
+  @Property()
+  isActive: boolean = true;
+
+  /** 上次响应的 ETag，用于条件请求 */
+  @Property({ nullable: true })
+  etag?: string;
+
+  @Property({ nullable: true })
+  lastContentHash?: string;
+
+  /** 上一次拉取的源文档，用于计算差异 */
+  @Property({ type: 'text', nullable: true })
+  lastContent?: string;
+
+  /** 各目标语言的最新完整译文（JSON 对象，语言 => 译文） */
+  @Property({ type: 'text', nullable: true })
+  lastResults?: string;
+
+  @Property({ nullable: true })
+  lastFetchedAt?: Date;
+
+  @Property({ nullable: true })
+  lastChangedAt?: Date;
+
+  @Property({ type: 'text', nullable: true })
+  lastError?: string;
+
+  @Property()
+  createdAt: Date = new Date();
+
+  @Property({ onUpdate: () => new Date() })
+  updatedAt: Date = new Date();
+}
@@ -0,0 +1,62 @@
+import { Entity, PrimaryKey, Property } from '@mikro-orm/core';
+
+/**
+ * 超大文档的分片子任务
+ * 按叶子路径切分，各分片由不同 worker 并行翻译，全部完成后按源文档结构合并
+ */
+@Entity()
+export class TranslationChunk {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  taskId!: string;
+
+  /** 同一次切分的分片共享 runId，周期任务每次运行都会重新切分 */
+  @Property()
+  runId!: string;
+
+  @Property()
+  index!: number;
+
+  /** 分片包含的叶子路径 */
+  @Property({ type: 'json' })
+  paths!: string[][];
+
+  @Property()
+  charTotal: number = 0;
+
+  /** pending / processing / completed / failed */
+  @Property()
+  status: string = 'pending';
+
+  /** 已执行的翻译次数（含队列自动重试和手动重试） */
+  @Property()
+  attempts: number = 0;
+
+  @Property({ type: 'text', nullable: true })
+  translatedJson?: string;
+
+  @Property({ type: 'text', nullable: true })
+  error?: string;
+
+  /** 分片内重试用尽、保留原文的键（点号路径） */
+  @Property({ type: 'json', nullable: true })
+  untranslatedKeys?: string[];
+
+  /** 未翻译的键 → 失败原因 */
+  @Property({ type: 'json', nullable: true })
+  failedKeys?: Record<string, { reason: string; message: string }>;
+
+  @Property({ nullable: true })
+  startedAt?: Date;
+
+  @Property({ nullable: true })
+  completedAt?: Date;
+
+  @Property()
+  createdAt: Date = new Date();
+
+  @Property({ onUpdate: () => new Date() })
+  updatedAt: Date = new Date();
+}
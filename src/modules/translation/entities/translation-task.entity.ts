@@ -1,4 +1,13 @@
-import { Entity, PrimaryKey, Property } from '@mikro-orm/core';
+import { Entity, Enum, PrimaryKey, Property } from '@mikro-orm/core';
+import { IgnoreRules } from '../utils/ignore-rules';
+import { MaskedSpan } from '../utils/pii-masking';
+
+/** 译文审校状态 */
+export enum ReviewStatus {
+  MACHINE_TRANSLATED = 'machine_translated',
+  IN_REVIEW = 'in_review',
+  APPROVED = 'approved',
+}
 
 @Entity()
 export class TranslationTask {
@@ -20,6 +29,53 @@ export class TranslationTask {
   @Property()
   charTotal: number = 0;
 
+  @Property({ nullable: true })
+  project?: string;
+
+  /** 通过 API Key 创建时记录密钥，用于用量归属 */
+  @Property({ nullable: true })
+  apiKeyId?: string;
+
+  /** 白标平台的租户，为空表示账户默认空间 */
+  @Property({ nullable: true })
+  tenantId?: string;
+
+  /** 队列优先级：critical / default / low */
+  @Property({ nullable: true })
+  priority?: string;
+
+  /** 调用方在请求中指定的优先级，计划升降级重新计算优先级时仍只允许降级 */
+  @Property({ nullable: true })
+  requestedPriority?: string;
+
+  /** 定时执行时间（一次性定时任务） */
+  @Property({ nullable: true })
+  scheduledAt?: Date;
+
+  /** 周期执行的 cron 表达式，每次触发都会重新翻译并推送 */
+  @Property({ nullable: true })
+  cron?: string;
+
+  /** cron 表达式使用的时区（IANA 名称），为空时按服务器时区 */
+  @Property({ nullable: true })
+  timezone?: string;
+
+  /** 为 true 时不推送结果和 translation.failed webhook（批量回填历史文档） */
+  @Property()
+  suppressWebhook: boolean = false;
+
+  /** 重试用尽后的失败原因分类（status 为 failed 时） */
+  @Property({ nullable: true })
+  failureReason?: string;
+
+  /** 准入时在当月用量计数器中预留的字符数，记账、失败或取消时释放 */
+  @Property({ nullable: true })
+  quotaReserved?: number;
+
+  /** 预留时间，决定预留计在哪个月的计数器 */
+  @Property({ nullable: true })
+  quotaReservedAt?: Date;
+
   @Property()
   createdAt: Date = new Date();
 
@@ -47,9 +103,141 @@ export class UserJsonData {
   @Property({ nullable: true })
   translatedJson?: string;
 
+  /** 便于查找的文档名称（例如 checkout-page-v2），不要求唯一 */
+  @Property({ nullable: true, length: 200 })
+  name?: string;
+
+  /** 文档标签，列表接口可按标签筛选 */
+  @Property({ type: 'json', nullable: true })
+  tags?: string[];
+
+  /** 计费字符数（与任务的 charTotal 相同），文档列表按它排序 */
+  @Property()
+  charTotal: number = 0;
+
   @Property({ nullable: true })
   ignoredFields?: string;
 
+  /** 创建时引用的忽略配置的规则快照（IgnoreRules），与 ignoredFields 同时生效 */
+  @Property({ type: 'json', nullable: true })
+  ignoreRules?: IgnoreRules;
+
+  @Property({ nullable: true })
+  provider?: string;
+
+  /** 源语言由自动检测得出时的置信度（0-1），为空表示调用方指定了源语言 */
+  @Property({ type: 'float', nullable: true })
+  detectionConfidence?: number;
+
+  /** 批量语言检测对已保存原文的检测结果，不修改 fromLang；语言为空表示样本都无法识别 */
+  @Property({ nullable: true })
+  detectedLang?: string;
+
+  @Property({ type: 'float', nullable: true })
+  detectedLangConfidence?: number;
+
+  @Property({ nullable: true })
+  languageCheckedAt?: Date;
+
+  /** 占位符语法（PlaceholderStyle），为空时使用内置分隔符 */
+  @Property({ type: 'json', nullable: true })
+  placeholderStyles?: string[];
+
+  /** 翻译后按原文修复首尾空白、占位符大小写和换行符，其余格式差异在校验报告中标记 */
+  @Property({ nullable: true })
+  preserveFormatting?: boolean;
+
+  /** 发给翻译服务前屏蔽个人信息，译文中换回原文 */
+  @Property({ nullable: true })
+  maskPii?: boolean;
+
+  /** 调用方追加的屏蔽规则（正则表达式） */
+  @Property({ type: 'json', nullable: true })
+  piiPatterns?: string[];
+
+  /** 原文中被屏蔽的片段（点号路径 → 类型和位置，不含原文），创建任务时生成 */
+  @Property({ type: 'json', nullable: true })
+  piiReport?: Record<string, MaskedSpan[]>;
+
+  /** 译文中丢失占位符的键（点号路径 → 缺失的占位符），每次翻译完成后重新计算 */
+  @Property({ type: 'json', nullable: true })
+  placeholderIssues?: Record<string, string[]>;
+
+  /** 翻译服务重试用尽、保留原文的键（点号路径），非空时文档为部分翻译 */
+  @Property({ type: 'json', nullable: true })
+  untranslatedKeys?: string[];
+
+  /** 未翻译的键（点号路径）→ 失败原因分类和错误信息 */
+  @Property({ type: 'json', nullable: true })
+  failedKeys?: Record<string, { reason: string; message: string }>;
+
+  /** 最近一次译文校验报告（ValidationReport），翻译完成和人工修改后重新生成 */
+  @Property({ type: 'json', nullable: true })
+  validationReport?: Record<string, any>;
+
+  /** 计费字符数按键路径（前 keyUsageDepth 段，pathKey）的分布，超出保存上限的键合并到 "*" */
+  @Property({ type: 'json', nullable: true })
+  keyUsage?: Record<string, number>;
+
+  @Property({ nullable: true })
+  keyUsageDepth?: number;
+
+  /** 最近一次按用户检查规则检查的报告（LintReport），blocking 为 true 时暂停结果推送 */
+  @Property({ type: 'json', nullable: true })
+  lintReport?: Record<string, any>;
+
+  /** 译文输出的键形态（nested / suffix / merged / flat），只影响结果读取和推送，存储的译文始终保持原结构 */
+  @Property({ nullable: true })
+  outputKeyFormat?: string;
+
+  /** 人工修改后锁定的键（点号路径），重新翻译时保留当前译文 */
+  @Property({ type: 'json', nullable: true })
+  lockedKeys?: string[];
+
+  /** 文档的审校状态，翻译完成时为 machine_translated，重新翻译后回到 machine_translated */
+  @Enum({ items: () => ReviewStatus, nullable: true })
+  reviewStatus?: ReviewStatus;
+
+  /** 单个键的审校状态（点号路径），未列出的键沿用文档状态 */
+  @Property({ type: 'json', nullable: true })
+  keyReviewStatus?: Record<string, ReviewStatus>;
+
+  @Property({ nullable: true })
+  reviewedBy?: string;
+
+  @Property({ nullable: true })
+  reviewedAt?: Date;
+
+  /** 译文质量分（0-1，按字符数加权），为空表示未评估 */
+  @Property({ type: 'float', nullable: true })
+  qualityScore?: number;
+
+  /** 每个译文字符串的质量分（点号路径 → 0-1） */
+  @Property({ type: 'json', nullable: true })
+  qualityScores?: Record<string, number>;
+
+  /** 数据库中原文和译文的明文字节数，写库时由加密订阅者计算，存储上限按它累计（密文更长，不能直接量列长度） */
+  @Property({ default: 0 })
+  contentBytes: number = 0;
+
+  /** 归档到对象存储的时间；归档后 originJson / translatedJson 清空，读取时从 archiveKey 取回 */
+  @Property({ nullable: true })
+  archivedAt?: Date;
+
+  @Property({ nullable: true })
+  archiveKey?: string;
+
+  /** 最近一次成功推送的译文（按输出键形态格式化后），webhook 开启 includeDiff 时保存，用于计算下一次推送的差异 */
+  @Property({ type: 'text', nullable: true, hidden: true })
+  deliveredJson?: string;
+
+  @Property({ nullable: true })
+  deliveredAt?: Date;
+
+  /** 按保留策略清除原文和译文的时间，之后只保留元数据 */
+  @Property({ nullable: true })
+  purgedAt?: Date;
+
   @Property()
   createdAt: Date = new Date();
 
@@ -68,6 +256,12 @@ export class CharacterUsageLog {
   @Property()
   jsonId: string;
 
+  @Property({ nullable: true })
+  apiKeyId?: string;
+
+  @Property({ nullable: true })
+  tenantId?: string;
+
   @Property()
   totalCharacters: number;
 
@@ -96,20 +290,5 @@ export class CharacterUsageLogDaily {
   updatedAt: Date = new Date();
 }
 
-@Entity()
-export class WebhookConfig {
-  @PrimaryKey()
-  id: string;
-
-  @Property()
-  userId: string;
-
-  @Property()
-  webhookUrl: string;
-
-  @Property()
-  createdAt: Date = new Date();
-
-  @Property({ onUpdate: () => new Date() })
-  updatedAt: Date = new Date();
-} 
\ No newline at end of file
+// webhook 配置实体统一定义在 webhook 模块，这里保留导出以兼容旧引用
+export { WebhookConfig } from '../../webhook/entities/webhook-config.entity';
@@ -0,0 +1,48 @@
+import { Entity, Enum, PrimaryKey, Property, Unique } from '@mikro-orm/core';
+
+export enum UsageReportFrequency {
+  WEEKLY = 'weekly',
+  MONTHLY = 'monthly',
+}
+
+/** 报告的发送方式：email 发到账户邮箱，webhook 作为 usage.report 事件推送（同时发给订阅该事件的通知渠道） */
+export enum UsageReportChannel {
+  EMAIL = 'email',
+  WEBHOOK = 'webhook',
+}
+
+/**
+ * 用量报告订阅，每个账户一条
+ * 定时任务在每个周期（周一开始的自然周 / 自然月）结束后发送上一个周期的用量汇总
+ */
+@Entity()
+@Unique({ properties: ['userId'] })
+export class UsageReportSubscription {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  userId!: string;
+
+  @Property({ nullable: true })
+  tenantId?: string;
+
+  @Enum({ items: () => UsageReportFrequency })
+  frequency!: UsageReportFrequency;
+
+  @Property({ type: 'json' })
+  channels!: UsageReportChannel[];
+
+  /** 最近一次已发送（或订阅时已结束）的周期，如 2026-W41、2026-09 */
+  @Property({ nullable: true })
+  lastPeriod?: string;
+
+  @Property({ nullable: true })
+  lastSentAt?: Date;
+
+  @Property()
+  createdAt: Date = new Date();
+
+  @Property({ onUpdate: () => new Date() })
+  updatedAt: Date = new Date();
+}
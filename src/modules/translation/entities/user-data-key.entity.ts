@@ -0,0 +1,27 @@
+import { Entity, PrimaryKey, Property, Unique } from '@mikro-orm/core';
+
+/**
+ * 用户的文档数据密钥
+ * 开启内容加密的用户有一把随机生成的数据密钥，用主密钥（DATA_ENCRYPTION_MASTER_KEY）加密后保存；
+ * 记录存在即表示开启，删除记录关闭加密
+ */
+@Entity()
+@Unique({ properties: ['userId'] })
+export class UserDataKey {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  userId!: string;
+
+  /** 加密数据密钥所用主密钥的 ID，轮换主密钥后旧数据仍按各自的 ID 解密 */
+  @Property()
+  masterKeyId!: string;
+
+  /** 主密钥加密后的数据密钥（base64） */
+  @Property({ type: 'text', hidden: true })
+  wrappedKey!: string;
+
+  @Property()
+  createdAt: Date = new Date();
+}
@@ -0,0 +1,51 @@
+import { Body, Controller, Delete, Get, HttpCode, HttpStatus, Param, Post, Put, Req, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiResponse, ApiSecurity, ApiTags } from '@nestjs/swagger';
+import { JwtOrApiKeyGuard } from '../auth/guards/jwt-or-api-key.guard';
+import { IgnoreProfileService } from './services/ignore-profile.service';
+import { IgnoreProfileDto } from './dto/ignore-profile.dto';
+
+@ApiTags('user')
+@Controller('user/profiles')
+@ApiBearerAuth()
+@ApiSecurity('api-key')
+@UseGuards(JwtOrApiKeyGuard)
+export class IgnoreProfileController {
+  constructor(private readonly ignoreProfileService: IgnoreProfileService) {}
+
+  @Post()
+  @ApiOperation({ summary: '保存忽略配置，创建翻译任务时通过 profileId 引用' })
+  @ApiResponse({ status: 201, description: '已保存' })
+  @ApiResponse({ status: 400, description: '没有任何规则、正则无效或超出数量限制' })
+  @ApiResponse({ status: 409, description: '名称已存在' })
+  async create(@Req() req: any, @Body() dto: IgnoreProfileDto) {
+    return this.ignoreProfileService.create(req.user.id, dto);
+  }
+
+  @Get()
+  @ApiOperation({ summary: '获取忽略配置列表' })
+  async list(@Req() req: any) {
+    return this.ignoreProfileService.list(req.user.id);
+  }
+
+  @Get(':id')
+  @ApiOperation({ summary: '获取忽略配置' })
+  @ApiResponse({ status: 404, description: '不存在' })
+  async get(@Req() req: any, @Param('id') id: string) {
+    return this.ignoreProfileService.get(req.user.id, id);
+  }
+
+  @Put(':id')
+  @ApiOperation({ summary: '替换忽略配置（已创建的任务不受影响）' })
+  @ApiResponse({ status: 404, description: '不存在' })
+  async update(@Req() req: any, @Param('id') id: string, @Body() dto: IgnoreProfileDto) {
+    return this.ignoreProfileService.update(req.user.id, id, dto);
+  }
+
+  @Delete(':id')
+  @HttpCode(HttpStatus.NO_CONTENT)
+  @ApiOperation({ summary: '删除忽略配置' })
+  @ApiResponse({ status: 404, description: '不存在' })
+  async remove(@Req() req: any, @Param('id') id: string) {
+    await this.ignoreProfileService.remove(req.user.id, id);
+  }
+}
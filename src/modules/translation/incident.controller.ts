@@ -0,0 +1,61 @@
+import {
+  Body,
+  Controller,
+  DefaultValuePipe,
+  Delete,
+  Get,
+  HttpCode,
+  HttpStatus,
+  Param,
+  ParseIntPipe,
+  Post,
+  Query,
+  Req,
+  UseGuards,
+} from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiParam, ApiQuery, ApiResponse, ApiTags } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../auth/guards/admin.guard';
+import { IncidentService } from './services/incident.service';
+import { IncidentDto, ResolveIncidentDto } from './dto/incident.dto';
+
+@ApiTags('admin')
+@Controller('admin/incidents')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard, AdminGuard)
+export class IncidentController {
+  constructor(private readonly incidentService: IncidentService) {}
+
+  @Post()
+  @ApiOperation({ summary: '声明故障时间窗，窗口内的任务在状态和文档列表中附带故障说明' })
+  @ApiResponse({ status: 201, description: '已声明' })
+  @ApiResponse({ status: 400, description: '结束时间早于开始时间' })
+  async declare(@Req() req: any, @Body() dto: IncidentDto) {
+    return this.incidentService.declare(dto, req.user.id);
+  }
+
+  @Get()
+  @ApiOperation({ summary: '获取最近的故障，按开始时间倒序' })
+  @ApiQuery({ name: 'limit', required: false, description: '数量，默认 50，最多 200' })
+  async list(@Query('limit', new DefaultValuePipe(50), ParseIntPipe) limit?: number) {
+    return { incidents: await this.incidentService.list(limit) };
+  }
+
+  @Post(':id/resolve')
+  @HttpCode(HttpStatus.OK)
+  @ApiOperation({ summary: '结束故障时间窗' })
+  @ApiParam({ name: 'id', description: '故障 ID' })
+  @ApiResponse({ status: 404, description: '故障不存在' })
+  async resolve(@Param('id') id: string, @Body() dto: ResolveIncidentDto) {
+    return this.incidentService.resolve(id, dto);
+  }
+
+  @Delete(':id')
+  @HttpCode(HttpStatus.NO_CONTENT)
+  @ApiOperation({ summary: '删除误报的故障' })
+  @ApiParam({ name: 'id', description: '故障 ID' })
+  @ApiResponse({ status: 404, description: '故障不存在' })
+  async remove(@Param('id') id: string) {
+    await this.incidentService.remove(id);
+  }
+}
@@ -0,0 +1,21 @@
+/**
+ * 翻译完成后发布的字符用量事件，由用量汇总批量写入明细和日汇总
+ */
+export interface CharacterUsageEvent {
+  taskId: string;
+  userId: string;
+  apiKeyId?: string;
+  tenantId?: string;
+  characters: number;
+  /** 用量发生时间（ISO 8601），决定计入哪一天 */
+  occurredAt: string;
+  /** 任务准入时预留的字符数，发布时从预留月份的计数器中扣除 */
+  reserved?: number;
+  /** 预留时间（ISO 8601） */
+  reservedAt?: string;
+}
+
+/** 待汇总的用量事件列表 */
+export const USAGE_EVENTS_KEY = 'usage:events';
+/** 正在汇总的批次，汇总完成后删除 */
+export const USAGE_EVENTS_PROCESSING_KEY = 'usage:events:processing';
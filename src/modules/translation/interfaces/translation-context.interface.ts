@@ -0,0 +1,16 @@
+import { ApiKeyContext } from '../../api-key/interfaces/api-key-context.interface';
+
+/**
+ * 创建翻译任务时的调用方上下文
+ */
+export interface TranslationRequestContext {
+  apiKey?: ApiKeyContext;
+  tenantId?: string;
+  /** 调用方已经为这个任务预留的额度（项目按全部目标语言统一预留），创建时不再预留，失败时由调用方释放 */
+  quotaReservation?: QuotaReservation;
+}
+
+export interface QuotaReservation {
+  characters: number;
+  reservedAt: Date;
+}
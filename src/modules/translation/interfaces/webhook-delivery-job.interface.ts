@@ -0,0 +1,54 @@
+/**
+ * webhook 队列中的翻译结果推送任务
+ * 只携带任务标识，推送时再从数据库读取结果，避免大文档常驻 Redis
+ */
+export const WEBHOOK_DELIVERY_JOB = 'deliver-translation-result';
+
+export interface WebhookDeliveryJob {
+  userId: string;
+  tenantId?: string;
+  taskId: string;
+}
+
+export const TRANSLATION_COMPLETED_EVENT = 'translation.completed';
+export const TRANSLATION_RESULT_PAYLOAD_VERSION = 2;
+
+/**
+ * 带版本号的翻译结果推送载荷（webhook 配置为 envelope 格式时使用）
+ * 字段只增不减；不兼容的变化会提升 version
+ */
+export interface TranslationResultEnvelope {
+  version: number;
+  /** 每次推送唯一，重试时保持不变，可用于去重 */
+  id: string;
+  event: string;
+  createdAt: string;
+  data: {
+    documentId: string;
+    taskId: string;
+    fromLang: string;
+    toLang: string;
+    charTotal: number;
+    status: string;
+    partial: boolean;
+    untranslatedKeys: string[];
+    outputKeyFormat: string;
+    createdAt: string;
+    completedAt: string;
+    links: { result: string; status: string };
+    translatedJson: string;
+    /** webhook 配置开启 includeDiff 时附带，与上一次成功推送的译文相比；没有上一次推送时为 null */
+    diff?: TranslationResultDiff | null;
+  };
+}
+
+/**
+ * 译文与上一次成功推送的版本之间的差异，接收方可据此增量更新
+ * changed 只包含新增或变化的叶子（保持原有层级，数组整体作为叶子），removed 为已删除叶子的路径
+ */
+export interface TranslationResultDiff {
+  /** 上一次成功推送的时间 */
+  since: string;
+  changed: Record<string, any>;
+  removed: string[][];
+}
@@ -0,0 +1,29 @@
+import { Body, Controller, Get, Param, Post, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiParam, ApiResponse, ApiTags } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../auth/guards/admin.guard';
+import { LanguageBackfillService } from './services/language-backfill.service';
+import { LanguageBackfillDto } from './dto/language-backfill.dto';
+
+@ApiTags('admin')
+@Controller('admin/language-detection')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard, AdminGuard)
+export class LanguageBackfillController {
+  constructor(private readonly languageBackfillService: LanguageBackfillService) {}
+
+  @Post()
+  @ApiOperation({ summary: '提交批量语言检测任务，检测缺少源语言或自动检测置信度偏低的已保存文档' })
+  @ApiResponse({ status: 201, description: '返回任务 ID 和状态' })
+  async start(@Body() dto: LanguageBackfillDto) {
+    return this.languageBackfillService.start(dto);
+  }
+
+  @Get(':jobId')
+  @ApiOperation({ summary: '查看批量语言检测任务的进度和结果' })
+  @ApiParam({ name: 'jobId', description: '任务 ID' })
+  @ApiResponse({ status: 404, description: '任务不存在或已过期' })
+  async get(@Param('jobId') jobId: string) {
+    return this.languageBackfillService.getJob(jobId);
+  }
+}
@@ -0,0 +1,36 @@
+import { Body, Controller, Delete, Get, HttpCode, HttpStatus, Param, Post, Req, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiResponse, ApiSecurity, ApiTags } from '@nestjs/swagger';
+import { JwtOrApiKeyGuard } from '../auth/guards/jwt-or-api-key.guard';
+import { TranslationLintService } from './services/translation-lint.service';
+import { CreateLintRuleDto } from './dto/lint-rule.dto';
+
+@ApiTags('translation')
+@Controller('translation/lint-rules')
+@ApiBearerAuth()
+@ApiSecurity('api-key')
+@UseGuards(JwtOrApiKeyGuard)
+export class LintRuleController {
+  constructor(private readonly translationLintService: TranslationLintService) {}
+
+  @Post()
+  @ApiOperation({ summary: '添加译文检查规则，之后完成或修改的译文按规则检查' })
+  @ApiResponse({ status: 201, description: '已添加' })
+  @ApiResponse({ status: 400, description: '缺少该类型必填的字段或超出数量限制' })
+  async create(@Req() req: any, @Body() dto: CreateLintRuleDto) {
+    return this.translationLintService.create(req.user.id, dto);
+  }
+
+  @Get()
+  @ApiOperation({ summary: '获取译文检查规则列表' })
+  async list(@Req() req: any) {
+    return this.translationLintService.list(req.user.id);
+  }
+
+  @Delete(':id')
+  @HttpCode(HttpStatus.NO_CONTENT)
+  @ApiOperation({ summary: '删除译文检查规则（已有文档的报告在重新检查后更新）' })
+  @ApiResponse({ status: 404, description: '不存在' })
+  async remove(@Req() req: any, @Param('id') id: string) {
+    await this.translationLintService.remove(req.user.id, id);
+  }
+}
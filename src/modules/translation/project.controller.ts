@@ -0,0 +1,91 @@
+import {
+  Body,
+  Controller,
+  DefaultValuePipe,
+  Delete,
+  Get,
+  HttpCode,
+  HttpStatus,
+  Param,
+  ParseIntPipe,
+  Post,
+  Put,
+  Query,
+  Req,
+  UseGuards,
+} from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiQuery, ApiResponse, ApiSecurity, ApiTags } from '@nestjs/swagger';
+import { JwtOrApiKeyGuard } from '../auth/guards/jwt-or-api-key.guard';
+import { TenantService } from '../tenant/services/tenant.service';
+import { ProjectService } from './services/project.service';
+import { ProjectSettingsDto, ProjectTranslateDto } from './dto/project.dto';
+
+@ApiTags('user')
+@Controller('user/projects')
+@ApiBearerAuth()
+@ApiSecurity('api-key')
+@UseGuards(JwtOrApiKeyGuard)
+export class ProjectController {
+  constructor(
+    private readonly projectService: ProjectService,
+    private readonly tenantService: TenantService,
+  ) {}
+
+  @Get()
+  @ApiOperation({ summary: '获取项目设置列表' })
+  async list(@Req() req: any) {
+    return this.projectService.listSettings(req.user.id, req.apiKey);
+  }
+
+  @Get(':id')
+  @ApiOperation({ summary: '获取项目设置（目标语言集合）' })
+  @ApiResponse({ status: 404, description: '项目没有设置' })
+  async get(@Req() req: any, @Param('id') id: string) {
+    return this.projectService.getSettings(req.user.id, id, req.apiKey);
+  }
+
+  @Put(':id')
+  @ApiOperation({ summary: '保存项目设置，id 为任务上的项目名称' })
+  @ApiResponse({ status: 200, description: '已保存' })
+  @ApiResponse({ status: 403, description: '委托密钥绑定了其他项目' })
+  async put(@Req() req: any, @Param('id') id: string, @Body() dto: ProjectSettingsDto) {
+    return this.projectService.putSettings(req.user.id, id, dto, req.apiKey);
+  }
+
+  @Delete(':id')
+  @HttpCode(HttpStatus.NO_CONTENT)
+  @ApiOperation({ summary: '删除项目设置（已创建的任务不受影响）' })
+  @ApiResponse({ status: 404, description: '项目没有设置' })
+  async remove(@Req() req: any, @Param('id') id: string) {
+    await this.projectService.removeSettings(req.user.id, id, req.apiKey);
+  }
+
+  @Post(':id/translate')
+  @ApiOperation({ summary: '提交源文档，按项目的目标语言展开为多个翻译任务' })
+  @ApiResponse({ status: 201, description: '返回项目任务及各语言任务的状态' })
+  @ApiResponse({ status: 400, description: 'JSON 内容无效，或除源语言外没有目标语言' })
+  @ApiResponse({ status: 404, description: '项目没有设置' })
+  @ApiResponse({ status: 429, description: '全部语言的字符数超出额度' })
+  async translate(@Req() req: any, @Param('id') id: string, @Body() dto: ProjectTranslateDto) {
+    const tenant = await this.tenantService.resolveFromRequest(req);
+    return this.projectService.translate(req.user.id, id, dto, { apiKey: req.apiKey, tenantId: tenant?.id });
+  }
+
+  @Get(':id/jobs')
+  @ApiOperation({ summary: '获取项目最近的项目任务' })
+  @ApiQuery({ name: 'limit', required: false, description: '数量，默认 20，最多 100' })
+  async listJobs(
+    @Req() req: any,
+    @Param('id') id: string,
+    @Query('limit', new DefaultValuePipe(20), ParseIntPipe) limit?: number,
+  ) {
+    return this.projectService.listJobs(req.user.id, id, limit, req.apiKey);
+  }
+
+  @Get(':id/jobs/:jobId')
+  @ApiOperation({ summary: '获取项目任务的汇总状态和各语言任务' })
+  @ApiResponse({ status: 404, description: '项目任务不存在' })
+  async getJob(@Req() req: any, @Param('id') id: string, @Param('jobId') jobId: string) {
+    return this.projectService.getJob(req.user.id, id, jobId, req.apiKey);
+  }
+}
@@ -0,0 +1,29 @@
+import { Controller, Delete, Get, Param, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiParam, ApiResponse, ApiTags } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../auth/guards/admin.guard';
+import { ProviderCacheService } from './services/provider-cache.service';
+
+@ApiTags('admin')
+@Controller('admin/provider-cache')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard, AdminGuard)
+export class ProviderCacheController {
+  constructor(private readonly providerCacheService: ProviderCacheService) {}
+
+  @Get()
+  @ApiOperation({ summary: '按语言对查看翻译服务响应缓存的命中统计' })
+  @ApiResponse({ status: 200, description: '各语言对的命中 / 未命中次数、命中率和节省的字符数' })
+  async getStats() {
+    return this.providerCacheService.getStats();
+  }
+
+  @Delete(':from/:to')
+  @ApiOperation({ summary: '清空一个语言对的响应缓存（翻译服务或词汇调整后使用）' })
+  @ApiParam({ name: 'from', description: '源语言' })
+  @ApiParam({ name: 'to', description: '目标语言' })
+  @ApiResponse({ status: 200, description: '返回删除的缓存条目数' })
+  async flush(@Param('from') from: string, @Param('to') to: string) {
+    return { removed: await this.providerCacheService.flush(from, to) };
+  }
+}
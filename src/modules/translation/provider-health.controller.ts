@@ -0,0 +1,29 @@
+import { Controller, Delete, Get, Param, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiParam, ApiResponse, ApiTags } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../auth/guards/admin.guard';
+import { ProviderHealthService } from './services/provider-health.service';
+
+@ApiTags('admin')
+@Controller('admin/provider-health')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard, AdminGuard)
+export class ProviderHealthController {
+  constructor(private readonly providerHealthService: ProviderHealthService) {}
+
+  @Get()
+  @ApiOperation({ summary: '查看各翻译服务商是否因额度用尽或凭证失效而暂停，以及备用服务商' })
+  @ApiResponse({ status: 200, description: '各服务商的暂停原因、暂停时间和自动恢复时间' })
+  async getStatus() {
+    return { providers: await this.providerHealthService.getStatus() };
+  }
+
+  @Delete(':provider/pause')
+  @ApiOperation({ summary: '提前恢复被暂停的服务商（更换凭证或充值之后）' })
+  @ApiParam({ name: 'provider', description: '服务商' })
+  @ApiResponse({ status: 200, description: '已恢复，返回最新状态' })
+  async resume(@Param('provider') provider: string) {
+    await this.providerHealthService.resume(provider);
+    return { providers: await this.providerHealthService.getStatus() };
+  }
+}
@@ -0,0 +1,48 @@
+import {
+  BadRequestException,
+  Body,
+  Controller,
+  DefaultValuePipe,
+  Get,
+  ParseIntPipe,
+  Post,
+  Query,
+  Req,
+  UseGuards,
+} from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiQuery, ApiResponse, ApiTags } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../auth/guards/admin.guard';
+import { ProviderReconciliationService } from './services/provider-reconciliation.service';
+import { PERIOD_PATTERN, ProviderInvoiceImportDto } from './dto/provider-invoice.dto';
+
+@ApiTags('admin')
+@Controller('admin/provider-usage')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard, AdminGuard)
+export class ProviderReconciliationController {
+  constructor(private readonly providerReconciliationService: ProviderReconciliationService) {}
+
+  @Post('invoices')
+  @ApiOperation({ summary: '导入翻译服务商的月度账单（支持 JSON / CSV 和试运行）' })
+  @ApiResponse({ status: 201, description: '导入报告；存在校验错误时整批不写入' })
+  @ApiResponse({ status: 400, description: '数据格式无效' })
+  async importInvoices(@Req() req: any, @Body() dto: ProviderInvoiceImportDto) {
+    return this.providerReconciliationService.importInvoices(dto, req.user.id);
+  }
+
+  @Get('reconciliation')
+  @ApiOperation({ summary: '对比服务商账单、记录的翻译服务调用量和向用户计费的字符数' })
+  @ApiQuery({ name: 'period', required: true, description: '月份（UTC，YYYY-MM）', example: '2026-09' })
+  @ApiQuery({ name: 'limit', required: false, description: '返回的账户数（按未计费字符数排序），最多 500' })
+  @ApiResponse({ status: 200, description: '按服务商的差异和按账户的计费对比' })
+  async getReport(
+    @Query('period') period: string,
+    @Query('limit', new DefaultValuePipe(50), ParseIntPipe) limit: number,
+  ) {
+    if (!PERIOD_PATTERN.test(period ?? '')) {
+      throw new BadRequestException('period must be formatted as YYYY-MM');
+    }
+    return this.providerReconciliationService.getReport(period, Math.min(Math.max(limit, 1), 500));
+  }
+}
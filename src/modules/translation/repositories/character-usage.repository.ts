@@ -0,0 +1,68 @@
+import { Injectable } from '@nestjs/common';
+import { EntityManager } from '@mikro-orm/core';
+import { v4 as uuidv4 } from 'uuid';
+import { DataRepository } from '../../../common/repositories/data.repository';
+import { CharacterUsageLog, CharacterUsageLogDaily } from '../entities/translation-task.entity';
+import { ProviderInvoice, ProviderUsageMonthly } from '../entities/provider-usage.entity';
+import { UsageReportSubscription } from '../entities/usage-report.entity';
+
+@Injectable()
+export class CharacterUsageLogRepository extends DataRepository<CharacterUsageLog> {
+  constructor(em: EntityManager) {
+    super(em, CharacterUsageLog, 'userId');
+  }
+}
+
+@Injectable()
+export class CharacterUsageLogDailyRepository extends DataRepository<CharacterUsageLogDaily> {
+  constructor(em: EntityManager) {
+    super(em, CharacterUsageLogDaily, 'userId');
+  }
+
+  /**
+   * 累加某天的字符用量，当天没有记录时新建
+   */
+  async increment(userId: string, usageDate: string, characters: number): Promise<CharacterUsageLogDaily> {
+    const daily = await this.get({ userId, usageDate });
+    if (daily) {
+      daily.totalCharacters += characters;
+      await this.save(daily);
+      return daily;
+    }
+    return this.insert({
+      id: uuidv4(),
+      userId,
+      usageDate,
+      totalCharacters: characters,
+    });
+  }
+
+  /**
+   * 统计从指定日期（YYYY-MM-DD，含）起的字符用量
+   */
+  async sumSince(userId: string, fromDate: string): Promise<number> {
+    const rows = await this.list({ userId, usageDate: { $gte: fromDate } });
+    return rows.reduce((sum, row) => sum + row.totalCharacters, 0);
+  }
+}
+
+@Injectable()
+export class ProviderUsageMonthlyRepository extends DataRepository<ProviderUsageMonthly> {
+  constructor(em: EntityManager) {
+    super(em, ProviderUsageMonthly, 'userId');
+  }
+}
+
+@Injectable()
+export class ProviderInvoiceRepository extends DataRepository<ProviderInvoice> {
+  constructor(em: EntityManager) {
+    super(em, ProviderInvoice);
+  }
+}
+
+@Injectable()
+export class UsageReportSubscriptionRepository extends DataRepository<UsageReportSubscription> {
+  constructor(em: EntityManager) {
+    super(em, UsageReportSubscription, 'userId');
+  }
+}
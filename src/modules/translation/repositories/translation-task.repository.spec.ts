@@ -0,0 +1,81 @@
+import { ConfigService } from '@nestjs/config';
+import { EntityManager, RawQueryFragment } from '@mikro-orm/core';
+import { UserJsonDataRepository } from './translation-task.repository';
+import { StorageDriverService } from '../../../common/services/storage-driver.service';
+import { StorageDriver } from '../../../config/database.config';
+
+describe('UserJsonDataRepository', () => {
+  const repositoryFor = (driver: StorageDriver) =>
+    new UserJsonDataRepository(
+      {} as EntityManager,
+      new StorageDriverService({ get: () => driver } as unknown as ConfigService, {} as EntityManager),
+    );
+
+  // 把条件里的原生 SQL 片段还原为 SQL、参数和比较值
+  const fragments = (conditions: Record<string, any>[]) =>
+    conditions.map((condition) => {
+      const [key] = Object.keys(condition);
+      const fragment = RawQueryFragment.getKnownFragment(key);
+      return { sql: fragment.sql, params: fragment.params, value: condition[key] };
+    });
+
+  it('没有标签和关键词时不生成条件', () => {
+    expect(repositoryFor(StorageDriver.POSTGRESQL).searchConditions()).toEqual([]);
+  });
+
+  it('PostgreSQL 下用 jsonb_exists_any 匹配任一标签', () => {
+    const [tags] = fragments(repositoryFor(StorageDriver.POSTGRESQL).searchConditions(['web', 'mobile']));
+
+    expect(tags).toEqual({
+      sql: 'jsonb_exists_any(tags, array[?, ?]::text[])',
+      params: ['web', 'mobile'],
+      value: true,
+    });
+  });
+
+  it('MySQL 下用 JSON_CONTAINS 逐个匹配标签', () => {
+    const [tags] = fragments(repositoryFor(StorageDriver.MYSQL).searchConditions(['web', 'mobile']));
+
+    expect(tags).toEqual({
+      sql: '(JSON_CONTAINS(tags, JSON_QUOTE(?)) OR JSON_CONTAINS(tags, JSON_QUOTE(?)))',
+      params: ['web', 'mobile'],
+      value: 1,
+    });
+  });
+
+  it.each([StorageDriver.POSTGRESQL, StorageDriver.MYSQL])('%s 下名称按小写匹配每个词，并转义通配符', (driver) => {
+    const words = fragments(repositoryFor(driver).searchConditions([], ['Checkout', '100%_off']));
+
+    expect(words).toEqual([
+      { sql: 'lower(name)', params: [], value: { $like: '%checkout%' } },
+      { sql: 'lower(name)', params: [], value: { $like: '%100\\%\\_off%' } },
+    ]);
+  });
+
+  it('每次调用生成新的 SQL 片段，可以分别用于列表和计数查询', () => {
+    const repository = repositoryFor(StorageDriver.POSTGRESQL);
+
+    const [first] = repository.searchConditions(['web']);
+    const [second] = repository.searchConditions(['web']);
+
+    expect(Object.keys(first)[0]).not.toBe(Object.keys(second)[0]);
+  });
+
+  describe('scopeConditions', () => {
+    it('不受限的范围不生成条件', () => {
+      expect(repositoryFor(StorageDriver.POSTGRESQL).scopeConditions({ userId: 'user1' })).toEqual([]);
+    });
+
+    it('按任务上的项目和创建密钥限定文档', () => {
+      const [scope] = fragments(
+        repositoryFor(StorageDriver.MYSQL).scopeConditions({ userId: 'user1', project: 'web', apiKeyId: 'key1' }),
+      );
+
+      expect(scope).toEqual({
+        sql: '(id in (select id from translation_task where user_id = ? and project = ? and api_key_id = ?))',
+        params: ['user1', 'web', 'key1'],
+        value: true,
+      });
+    });
+  });
+});
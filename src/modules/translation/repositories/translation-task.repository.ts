@@ -0,0 +1,139 @@
+import { Injectable } from '@nestjs/common';
+import { EntityManager, FilterQuery, raw } from '@mikro-orm/core';
+import { DataRepository } from '../../../common/repositories/data.repository';
+import { StorageDriverService } from '../../../common/services/storage-driver.service';
+import { TranslationTask, UserJsonData } from '../entities/translation-task.entity';
+import { SourceSync } from '../entities/source-sync.entity';
+import { TranslationChunk } from '../entities/translation-chunk.entity';
+import { LintRule } from '../entities/lint-rule.entity';
+import { IgnoreProfile } from '../entities/ignore-profile.entity';
+import { CustomMtEngine } from '../entities/custom-mt-engine.entity';
+import { RetentionPolicy } from '../entities/retention-policy.entity';
+import { ProjectJob, ProjectSettings } from '../entities/project.entity';
+import { UserDataKey } from '../entities/user-data-key.entity';
+import { Incident } from '../entities/incident.entity';
+import { TaskScope } from '../utils/task-scope';
+
+@Injectable()
+export class TranslationTaskRepository extends DataRepository<TranslationTask> {
+  constructor(em: EntityManager) {
+    super(em, TranslationTask, 'userId');
+  }
+}
+
+@Injectable()
+export class UserJsonDataRepository extends DataRepository<UserJsonData> {
+  constructor(
+    em: EntityManager,
+    private readonly storageDriverService: StorageDriverService,
+  ) {
+    super(em, UserJsonData, 'userId');
+  }
+
+  /**
+   * 文档列表的标签和名称条件：带有其中任一标签，且名称包含每个词（不区分大小写，通配符按字面匹配）
+   * 条件里的原生 SQL 片段只能用于一次查询，每次查询都要重新生成
+   */
+  searchConditions(tags: string[] = [], words: string[] = []): FilterQuery<UserJsonData>[] {
+    const conditions: FilterQuery<UserJsonData>[] = [];
+    if (tags.length > 0) {
+      conditions.push(this.storageDriverService.jsonArrayOverlap('tags', tags) as FilterQuery<UserJsonData>);
+    }
+    for (const word of words) {
+      const pattern = `%${word.toLowerCase().replace(/[\\%_]/g, '\\$&')}%`;
+      conditions.push({ [raw('lower(name)')]: { $like: pattern } });
+    }
+    return conditions;
+  }
+
+  /**
+   * 密钥受限时只列出范围内的文档；项目和创建密钥记录在同 ID 的翻译任务上
+   * 条件里的原生 SQL 片段只能用于一次查询，每次查询都要重新生成
+   */
+  scopeConditions(scope: TaskScope): FilterQuery<UserJsonData>[] {
+    const clauses = ['user_id = ?'];
+    const params = [scope.userId];
+    if (scope.project) {
+      clauses.push('project = ?');
+      params.push(scope.project);
+    }
+    if (scope.apiKeyId) {
+      clauses.push('api_key_id = ?');
+      params.push(scope.apiKeyId);
+    }
+    if (clauses.length === 1) {
+      return [];
+    }
+    return [{ [raw(`(id in (select id from translation_task where ${clauses.join(' and ')}))`, params)]: true }];
+  }
+}
+
+@Injectable()
+export class SourceSyncRepository extends DataRepository<SourceSync> {
+  constructor(em: EntityManager) {
+    super(em, SourceSync, 'userId');
+  }
+}
+
+@Injectable()
+export class TranslationChunkRepository extends DataRepository<TranslationChunk> {
+  constructor(em: EntityManager) {
+    super(em, TranslationChunk);
+  }
+}
+
+@Injectable()
+export class LintRuleRepository extends DataRepository<LintRule> {
+  constructor(em: EntityManager) {
+    super(em, LintRule, 'userId');
+  }
+}
+
+@Injectable()
+export class IgnoreProfileRepository extends DataRepository<IgnoreProfile> {
+  constructor(em: EntityManager) {
+    super(em, IgnoreProfile, 'userId');
+  }
+}
+
+@Injectable()
+export class CustomMtEngineRepository extends DataRepository<CustomMtEngine> {
+  constructor(em: EntityManager) {
+    super(em, CustomMtEngine, 'userId');
+  }
+}
+
+@Injectable()
+export class RetentionPolicyRepository extends DataRepository<RetentionPolicy> {
+  constructor(em: EntityManager) {
+    super(em, RetentionPolicy, 'userId');
+  }
+}
+
+@Injectable()
+export class ProjectSettingsRepository extends DataRepository<ProjectSettings> {
+  constructor(em: EntityManager) {
+    super(em, ProjectSettings, 'userId');
+  }
+}
+
+@Injectable()
+export class ProjectJobRepository extends DataRepository<ProjectJob> {
+  constructor(em: EntityManager) {
+    super(em, ProjectJob, 'userId');
+  }
+}
+
+@Injectable()
+export class UserDataKeyRepository extends DataRepository<UserDataKey> {
+  constructor(em: EntityManager) {
+    super(em, UserDataKey, 'userId');
+  }
+}
+
+@Injectable()
+export class IncidentRepository extends DataRepository<Incident> {
+  constructor(em: EntityManager) {
+    super(em, Incident);
+  }
+}
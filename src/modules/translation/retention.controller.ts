@@ -0,0 +1,27 @@
+import { Body, Controller, Get, Put, Req, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiResponse, ApiSecurity, ApiTags } from '@nestjs/swagger';
+import { JwtOrApiKeyGuard } from '../auth/guards/jwt-or-api-key.guard';
+import { DocumentRetentionService } from './services/document-retention.service';
+import { RetentionPolicyDto } from './dto/retention-policy.dto';
+
+@ApiTags('user')
+@Controller('user/retention')
+@ApiBearerAuth()
+@ApiSecurity('api-key')
+@UseGuards(JwtOrApiKeyGuard)
+export class RetentionController {
+  constructor(private readonly documentRetentionService: DocumentRetentionService) {}
+
+  @Get()
+  @ApiOperation({ summary: '获取文档内容保留期（计划、用户设置和实际生效的天数）' })
+  async get(@Req() req: any) {
+    return this.documentRetentionService.getPolicy(req.user.id);
+  }
+
+  @Put()
+  @ApiOperation({ summary: '设置文档内容保留天数，到期文档的原文和译文会被清除' })
+  @ApiResponse({ status: 200, description: '返回生效的保留期' })
+  async set(@Req() req: any, @Body() dto: RetentionPolicyDto) {
+    return this.documentRetentionService.setPolicy(req.user.id, dto.retentionDays);
+  }
+}
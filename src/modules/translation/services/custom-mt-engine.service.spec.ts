@@ -0,0 +1,142 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { HttpService } from '@nestjs/axios';
+import { BadRequestException } from '@nestjs/common';
+import { of, throwError } from 'rxjs';
+import { CustomMtEngineService, readPath, renderTemplate } from './custom-mt-engine.service';
+import { CustomMtEngineRepository } from '../repositories/translation-task.repository';
+import { SecretBoxService } from '../../../common/services/secret-box.service';
+import { classifyTaskFailure } from '../utils/task-failure';
+
+describe('CustomMtEngineService', () => {
+  let service: CustomMtEngineService;
+
+  const engine = {
+    id: 'engine1',
+    userId: 'user1',
+    url: 'https://203.0.113.10/translate',
+    authHeaderName: 'X-Api-Key',
+    authHeaderValue: 'enc:secret',
+    requestTemplate: '{"q": "{{text}}", "source": "{{source}}", "target": "{{target}}"}',
+    responsePath: 'translations.0.text',
+  };
+
+  const mockHttpService = { post: jest.fn() };
+
+  const mockEngineRepository = {
+    get: jest.fn(),
+    getOrFail: jest.fn(),
+    build: jest.fn((data) => ({ ...data })),
+    save: jest.fn(),
+    delete: jest.fn(),
+  };
+
+  const mockSecretBox = {
+    encrypt: jest.fn((value: string) => `enc:${value}`),
+    decrypt: jest.fn((value: string) => value.replace(/^enc:/, '')),
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        CustomMtEngineService,
+        {
+          provide: ConfigService,
+          useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) },
+        },
+        { provide: HttpService, useValue: mockHttpService },
+        { provide: CustomMtEngineRepository, useValue: mockEngineRepository },
+        { provide: SecretBoxService, useValue: mockSecretBox },
+      ],
+    }).compile();
+
+    service = module.get<CustomMtEngineService>(CustomMtEngineService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('模板变量按 JSON 字符串转义，响应路径可以访问数组下标', () => {
+    expect(renderTemplate(engine.requestTemplate, { text: 'Say "hi"\n', source: 'en', target: 'zh' })).toEqual({
+      q: 'Say "hi"\n',
+      source: 'en',
+      target: 'zh',
+    });
+    expect(readPath({ translations: [{ text: '你好' }] }, 'translations.0.text')).toBe('你好');
+    expect(readPath({ translations: [] }, 'translations.0.text')).toBeUndefined();
+  });
+
+  it('保存时加密认证请求头，返回的配置不包含其值', async () => {
+    mockEngineRepository.get.mockResolvedValueOnce(null);
+
+    const view = await service.upsert('user1', { ...engine, authHeaderValue: 'secret' });
+
+    expect(mockEngineRepository.save).toHaveBeenCalledWith(expect.objectContaining({ authHeaderValue: 'enc:secret' }));
+    expect(view).toMatchObject({ authHeaderName: 'X-Api-Key', authHeaderConfigured: true });
+    expect(JSON.stringify(view)).not.toContain('secret');
+  });
+
+  it('应拒绝内网地址和无效的请求模板', async () => {
+    await expect(service.upsert('user1', { ...engine, url: 'http://127.0.0.1:8080/translate' })).rejects.toThrow(
+      'Engine URL is not allowed',
+    );
+    await expect(service.upsert('user1', { ...engine, requestTemplate: '{"q": "text"}' })).rejects.toThrow(
+      'Request template must contain {{text}}',
+    );
+    await expect(service.upsert('user1', { ...engine, requestTemplate: '{"q": {{text}}}' })).rejects.toThrow(
+      BadRequestException,
+    );
+    expect(mockEngineRepository.save).not.toHaveBeenCalled();
+  });
+
+  it('翻译时按模板请求并带上认证请求头', async () => {
+    mockEngineRepository.get.mockResolvedValueOnce(engine);
+    mockHttpService.post.mockReturnValue(of({ data: { translations: [{ text: '你好' }] } }));
+
+    await expect(service.translate('user1', 'Hello', 'en', 'zh')).resolves.toBe('你好');
+    await service.translate('user1', 'Bye', 'en', 'zh');
+
+    expect(mockHttpService.post).toHaveBeenCalledWith(
+      engine.url,
+      { q: 'Hello', source: 'en', target: 'zh' },
+      expect.objectContaining({ headers: expect.objectContaining({ 'X-Api-Key': 'secret' }) }),
+    );
+    // 配置在进程内缓存
+    expect(mockEngineRepository.get).toHaveBeenCalledTimes(1);
+  });
+
+  it('响应中没有译文时报不可重试的错误，引擎的 5xx 可重试', async () => {
+    mockEngineRepository.get.mockResolvedValueOnce(engine);
+    mockHttpService.post.mockReturnValueOnce(of({ data: { result: 'x' } }));
+
+    const mappingError = await service.translate('user1', 'Hello', 'en', 'zh').catch((error) => error);
+    expect(classifyTaskFailure(mappingError).retryable).toBe(false);
+
+    mockHttpService.post.mockReturnValueOnce(
+      throwError(() => Object.assign(new Error('Request failed'), { response: { status: 503 } })),
+    );
+    const upstreamError = await service.translate('user1', 'Hello', 'en', 'zh').catch((error) => error);
+    expect(classifyTaskFailure(upstreamError).retryable).toBe(true);
+  });
+
+  it('测试调用返回译文或错误，不抛出', async () => {
+    mockEngineRepository.getOrFail.mockResolvedValue(engine);
+    mockHttpService.post.mockReturnValueOnce(of({ data: { translations: [{ text: '你好' }] } }));
+    await expect(service.test('user1', 'Hello', 'en', 'zh')).resolves.toMatchObject({ ok: true, translated: '你好' });
+
+    mockHttpService.post.mockReturnValueOnce(
+      throwError(() => Object.assign(new Error('Request failed'), { response: { status: 401 } })),
+    );
+    await expect(service.test('user1', 'Hello', 'en', 'zh')).resolves.toEqual({
+      ok: false,
+      error: 'Request failed',
+      status: 401,
+    });
+  });
+
+  it('未配置引擎时不能使用 custom', async () => {
+    mockEngineRepository.get.mockResolvedValueOnce(null);
+    await expect(service.assertConfigured('user1')).rejects.toThrow('No custom translation engine is configured');
+  });
+});
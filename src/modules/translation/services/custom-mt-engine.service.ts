@@ -0,0 +1,192 @@
+import { BadRequestException, Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { HttpService } from '@nestjs/axios';
+import { firstValueFrom } from 'rxjs';
+import { v4 as uuidv4 } from 'uuid';
+import { CustomMtEngine } from '../entities/custom-mt-engine.entity';
+import { CustomMtEngineDto } from '../dto/custom-mt-engine.dto';
+import { CustomMtEngineRepository } from '../repositories/translation-task.repository';
+import { SecretBoxService } from '../../../common/services/secret-box.service';
+import { assertPublicUrl } from '../../../common/utils/url-safety';
+
+const TEMPLATE_VARIABLES = /\{\{\s*(text|source|target)\s*\}\}/g;
+
+/** 配置在 worker 进程内缓存的时间，修改后其他进程最迟在这之后生效 */
+const ENGINE_CACHE_TTL_MS = 60 * 1000;
+
+/**
+ * 自定义机器翻译引擎
+ * 任务的 provider 为 custom 时，worker 按用户配置的模板拼出请求体、带上认证请求头 POST 到用户的翻译接口，
+ * 再按 responsePath 取出译文；与其他翻译服务一样参与重试和失败分类，但不使用共享的响应缓存和服务商限流
+ */
+@Injectable()
+export class CustomMtEngineService {
+  private readonly logger = new Logger(CustomMtEngineService.name);
+  private readonly timeoutMs: number;
+  private readonly allowPrivateHosts: boolean;
+  private readonly cache = new Map<string, { engine: CustomMtEngine | null; expiresAt: number }>();
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly httpService: HttpService,
+    private readonly engineRepository: CustomMtEngineRepository,
+    private readonly secretBox: SecretBoxService,
+  ) {
+    this.timeoutMs = Number(this.configService.get('CUSTOM_MT_TIMEOUT_MS', 15000));
+    this.allowPrivateHosts = this.configService.get('CUSTOM_MT_ALLOW_PRIVATE_HOSTS', 'false') === 'true';
+  }
+
+  async upsert(userId: string, dto: CustomMtEngineDto) {
+    await this.assertReachable(dto.url);
+    assertTemplate(dto.requestTemplate);
+
+    const existing = await this.engineRepository.get({ userId });
+    const engine = existing ?? this.engineRepository.build({ id: uuidv4(), userId } as CustomMtEngine);
+    Object.assign(engine, {
+      url: dto.url,
+      authHeaderName: dto.authHeaderName ?? existing?.authHeaderName,
+      requestTemplate: dto.requestTemplate,
+      responsePath: dto.responsePath,
+    });
+    if (dto.authHeaderValue !== undefined) {
+      engine.authHeaderValue = dto.authHeaderValue ? this.secretBox.encrypt(dto.authHeaderValue) : undefined;
+    }
+    await this.engineRepository.save(engine);
+    this.cache.delete(userId);
+    return toView(engine);
+  }
+
+  async get(userId: string) {
+    return toView(await this.getOwned(userId));
+  }
+
+  async remove(userId: string): Promise<void> {
+    await this.engineRepository.delete(await this.getOwned(userId));
+    this.cache.delete(userId);
+  }
+
+  /**
+   * 创建 provider 为 custom 的任务前检查用户已配置引擎
+   */
+  async assertConfigured(userId: string): Promise<void> {
+    if (!(await this.engineRepository.get({ userId }))) {
+      throw new BadRequestException('No custom translation engine is configured');
+    }
+  }
+
+  /**
+   * 用一段文本调用引擎，返回译文和耗时，用于保存配置后验证
+   */
+  async test(userId: string, text: string, fromLang: string, toLang: string) {
+    const engine = await this.getOwned(userId);
+    const startedAt = Date.now();
+    try {
+      const translated = await this.call(engine, text, fromLang, toLang);
+      return { ok: true, translated, latencyMs: Date.now() - startedAt };
+    } catch (error) {
+      return { ok: false, error: error.message, status: error.response?.status ?? null };
+    }
+  }
+
+  /**
+   * 翻译一段文本（由 TranslationUtils 调用）；HTTP 错误原样抛出，按状态码分类和重试
+   */
+  async translate(userId: string, text: string, fromLang: string, toLang: string): Promise<string> {
+    const engine = await this.load(userId);
+    if (!engine) {
+      throw new BadRequestException('No custom translation engine is configured');
+    }
+    return this.call(engine, text, fromLang, toLang);
+  }
+
+  private async call(engine: CustomMtEngine, text: string, fromLang: string, toLang: string): Promise<string> {
+    const body = renderTemplate(engine.requestTemplate, { text, source: fromLang, target: toLang });
+    const headers: Record<string, string> = { 'Content-Type': 'application/json' };
+    if (engine.authHeaderValue) {
+      headers[engine.authHeaderName || 'Authorization'] = this.secretBox.decrypt(engine.authHeaderValue);
+    }
+
+    const response = await firstValueFrom(
+      this.httpService.post(engine.url, body, { headers, timeout: this.timeoutMs, maxRedirects: 0 }),
+    );
+    const translated = readPath(response.data, engine.responsePath);
+    if (typeof translated !== 'string') {
+      // 映射配置错误，重试没有意义
+      throw Object.assign(new Error(`Custom translation engine response has no string at ${engine.responsePath}`), {
+        status: 400,
+      });
+    }
+    return translated;
+  }
+
+  private async load(userId: string): Promise<CustomMtEngine | null> {
+    const cached = this.cache.get(userId);
+    if (cached && cached.expiresAt > Date.now()) {
+      return cached.engine;
+    }
+    const engine = await this.engineRepository.get({ userId });
+    // 域名解析结果可能在保存后改变，刷新缓存时重新检查
+    if (engine) {
+      await this.assertReachable(engine.url);
+    }
+    this.cache.set(userId, { engine, expiresAt: Date.now() + ENGINE_CACHE_TTL_MS });
+    return engine;
+  }
+
+  private async getOwned(userId: string): Promise<CustomMtEngine> {
+    return this.engineRepository.getOrFail({ userId }, 'Custom translation engine not found');
+  }
+
+  private async assertReachable(url: string): Promise<void> {
+    if (this.allowPrivateHosts) {
+      return;
+    }
+    try {
+      await assertPublicUrl(url);
+    } catch (error) {
+      this.logger.warn(`Rejected custom translation engine URL ${url}: ${error.message}`);
+      throw new BadRequestException(`Engine URL is not allowed: ${error.message}`);
+    }
+  }
+}
+
+/**
+ * 替换模板变量并解析为 JSON；变量值按 JSON 字符串转义，因此模板中的变量需要写在引号内
+ */
+export function renderTemplate(template: string, values: Record<'text' | 'source' | 'target', string>): any {
+  const rendered = template.replace(TEMPLATE_VARIABLES, (_, name: keyof typeof values) =>
+    JSON.stringify(values[name]).slice(1, -1),
+  );
+  return JSON.parse(rendered);
+}
+
+/** 按点号路径读取响应中的值，数字段可以访问数组下标 */
+export function readPath(source: any, path: string): any {
+  return path
+    .split('.')
+    .reduce((node, key) => (node !== null && typeof node === 'object' ? node[key] : undefined), source);
+}
+
+function assertTemplate(template: string): void {
+  if (!/\{\{\s*text\s*\}\}/.test(template)) {
+    throw new BadRequestException('Request template must contain {{text}}');
+  }
+  try {
+    renderTemplate(template, { text: 'Hello "world"', source: 'en', target: 'zh' });
+  } catch (error) {
+    throw new BadRequestException(`Request template is not valid JSON: ${error.message}`);
+  }
+}
+
+function toView(engine: CustomMtEngine) {
+  return {
+    id: engine.id,
+    url: engine.url,
+    authHeaderName: engine.authHeaderName ?? 'Authorization',
+    authHeaderConfigured: !!engine.authHeaderValue,
+    requestTemplate: engine.requestTemplate,
+    responsePath: engine.responsePath,
+    createdAt: engine.createdAt,
+    updatedAt: engine.updatedAt,
+  };
+}
@@ -0,0 +1,104 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { DocumentArchiveService } from './document-archive.service';
+import { TranslationTaskRepository, UserJsonDataRepository } from '../repositories/translation-task.repository';
+import { ObjectStorageService } from '../../../common/services/object-storage.service';
+import { DocumentEncryptionService } from './document-encryption.service';
+
+describe('DocumentArchiveService', () => {
+  let service: DocumentArchiveService;
+  const objects = new Map<string, Buffer>();
+
+  const mockObjectStorage = {
+    put: jest.fn(async (key: string, body: Buffer) => {
+      objects.set(key, body);
+    }),
+    get: jest.fn(async (key: string) => objects.get(key)),
+    delete: jest.fn(),
+  };
+
+  const mockTaskRepository = {
+    list: jest.fn(),
+  };
+
+  const mockUserJsonDataRepository = {
+    list: jest.fn(),
+    save: jest.fn(),
+  };
+
+  const oldDocument = () => ({
+    id: 'task1',
+    userId: 'user1',
+    originJson: '{"a":"hello"}',
+    translatedJson: '{"a":"bonjour"}',
+    createdAt: new Date('2026-01-01'),
+  });
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        DocumentArchiveService,
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+        { provide: ObjectStorageService, useValue: mockObjectStorage },
+        { provide: TranslationTaskRepository, useValue: mockTaskRepository },
+        { provide: UserJsonDataRepository, useValue: mockUserJsonDataRepository },
+        {
+          provide: DocumentEncryptionService,
+          useValue: { seal: jest.fn(async (_userId, value) => value), open: jest.fn((value) => value) },
+        },
+      ],
+    }).compile();
+
+    service = module.get<DocumentArchiveService>(DocumentArchiveService);
+    objects.clear();
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('应把到期文档写入对象存储并清空数据库中的内容，读取时透明取回', async () => {
+    const document: any = oldDocument();
+    const task: any = { id: 'task1', status: 'pending', content: document.originJson };
+    mockUserJsonDataRepository.list.mockResolvedValue([document]);
+    mockTaskRepository.list.mockResolvedValue([task]);
+
+    const result = await service.archiveBatch();
+
+    expect(result).toMatchObject({ scanned: 1, archived: 1, cursor: document.createdAt });
+    expect(document).toMatchObject({ originJson: '', translatedJson: null, archiveKey: 'documents/user1/task1.json.gz' });
+    expect(task.content).toBe('');
+    expect(mockUserJsonDataRepository.save).toHaveBeenCalledWith([document, task]);
+
+    expect(await service.load(document)).toEqual({
+      originJson: '{"a":"hello"}',
+      translatedJson: '{"a":"bonjour"}',
+      archived: true,
+    });
+  });
+
+  it('周期任务每次运行都要读取原文，不归档', async () => {
+    const document = oldDocument();
+    mockUserJsonDataRepository.list.mockResolvedValue([document]);
+    mockTaskRepository.list.mockResolvedValue([{ id: 'task1', cron: '0 * * * *' }]);
+
+    const result = await service.archiveBatch();
+
+    expect(result.archived).toBe(0);
+    expect(mockObjectStorage.put).not.toHaveBeenCalled();
+    expect(document.originJson).toBe('{"a":"hello"}');
+  });
+
+  it('写入对象存储失败时保留数据库内容', async () => {
+    const document = oldDocument();
+    mockUserJsonDataRepository.list.mockResolvedValue([document]);
+    mockTaskRepository.list.mockResolvedValue([]);
+    mockObjectStorage.put.mockRejectedValueOnce(new Error('bucket unavailable'));
+
+    const result = await service.archiveBatch();
+
+    expect(result.archived).toBe(0);
+    expect(mockUserJsonDataRepository.save).not.toHaveBeenCalled();
+    expect(document.translatedJson).toBe('{"a":"bonjour"}');
+  });
+});
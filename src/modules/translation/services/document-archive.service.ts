@@ -0,0 +1,154 @@
+import { GoneException, Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { promisify } from 'util';
+import { gunzip, gzip } from 'zlib';
+import { UserJsonData } from '../entities/translation-task.entity';
+import { TranslationTaskRepository, UserJsonDataRepository } from '../repositories/translation-task.repository';
+import { ObjectStorageService } from '../../../common/services/object-storage.service';
+import { DocumentEncryptionService } from './document-encryption.service';
+
+const gzipAsync = promisify(gzip);
+const gunzipAsync = promisify(gunzip);
+
+export interface DocumentContent {
+  originJson: string;
+  translatedJson?: string;
+  /** 内容来自对象存储归档 */
+  archived: boolean;
+  /** 归档时任务记录中的原文（只有归档的文档才有，未归档时任务的 content 列仍然完整） */
+  taskContent?: string;
+}
+
+interface ArchivedDocument {
+  id: string;
+  userId: string;
+  originJson: string;
+  translatedJson?: string;
+  /** 归档时任务记录中的原文 */
+  taskContent?: string;
+}
+
+export interface ArchiveBatchResult {
+  scanned: number;
+  archived: number;
+  /** 本批最后一条记录的创建时间，下一批从这里继续 */
+  cursor?: Date;
+}
+
+/**
+ * 已完成文档归档
+ * 超过 ARCHIVE_AFTER_DAYS 天的已完成文档压缩后写入对象存储，数据库中只保留元数据，
+ * 读取时透明取回（较慢，响应中会标记 archived）。周期任务每次运行都要读取原文，不归档；
+ * 开启内容加密的用户归档中的原文和译文同样加密
+ */
+@Injectable()
+export class DocumentArchiveService {
+  private readonly logger = new Logger(DocumentArchiveService.name);
+  readonly afterDays: number;
+  private readonly batchSize: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly objectStorage: ObjectStorageService,
+    private readonly taskRepository: TranslationTaskRepository,
+    private readonly userJsonDataRepository: UserJsonDataRepository,
+    private readonly documentEncryptionService: DocumentEncryptionService,
+  ) {
+    this.afterDays = Number(this.configService.get('ARCHIVE_AFTER_DAYS', 90));
+    this.batchSize = Number(this.configService.get('ARCHIVE_BATCH_SIZE', 200));
+  }
+
+  /**
+   * 归档一批到期文档，按创建时间推进游标，跳过的周期任务不会阻塞后续批次
+   */
+  async archiveBatch(after?: Date): Promise<ArchiveBatchResult> {
+    if (this.afterDays <= 0) {
+      return { scanned: 0, archived: 0 };
+    }
+    const cutoff = new Date(Date.now() - this.afterDays * 24 * 60 * 60 * 1000);
+    const documents = await this.userJsonDataRepository.list(
+      {
+        archivedAt: null,
+        translatedJson: { $ne: null },
+        createdAt: after ? { $lt: cutoff, $gt: after } : { $lt: cutoff },
+      },
+      { limit: this.batchSize, orderBy: { createdAt: 'ASC' } },
+    );
+    if (documents.length === 0) {
+      return { scanned: 0, archived: 0 };
+    }
+
+    const tasks = new Map(
+      (await this.taskRepository.list({ id: { $in: documents.map((document) => document.id) } })).map((task) => [
+        task.id,
+        task,
+      ]),
+    );
+
+    let archived = 0;
+    for (const document of documents) {
+      const task = tasks.get(document.id);
+      if (task?.cron || task?.status === 'scheduled') {
+        continue;
+      }
+      try {
+        const archiveKey = `documents/${document.userId}/${document.id}.json.gz`;
+        const seal = (value?: string) => this.documentEncryptionService.seal(document.userId, value);
+        const body: ArchivedDocument = {
+          id: document.id,
+          userId: document.userId,
+          originJson: await seal(document.originJson),
+          translatedJson: await seal(document.translatedJson),
+          taskContent: await seal(task?.content),
+        };
+        await this.objectStorage.put(archiveKey, await gzipAsync(JSON.stringify(body)), 'application/gzip');
+
+        document.originJson = '';
+        document.translatedJson = null;
+        // 差异基准不归档，恢复后的第一次推送不附带差异
+        document.deliveredJson = null;
+        document.archivedAt = new Date();
+        document.archiveKey = archiveKey;
+        if (task) {
+          task.content = '';
+        }
+        await this.userJsonDataRepository.save(task ? [document, task] : document);
+        archived++;
+      } catch (error) {
+        this.logger.error(`Failed to archive document ${document.id}: ${error.message}`);
+      }
+    }
+
+    if (archived > 0) {
+      this.logger.log(`Archived ${archived} document(s) older than ${this.afterDays} days`);
+    }
+    return { scanned: documents.length, archived, cursor: documents[documents.length - 1].createdAt };
+  }
+
+  /**
+   * 读取文档内容，已归档时从对象存储取回；已按保留策略清除的文档返回 410
+   */
+  async load(document: UserJsonData): Promise<DocumentContent> {
+    if (document.purgedAt) {
+      throw new GoneException('Document content was purged under the retention policy');
+    }
+    if (!document.archivedAt || !document.archiveKey) {
+      return { originJson: document.originJson, translatedJson: document.translatedJson, archived: false };
+    }
+    const archived: ArchivedDocument = JSON.parse(
+      (await gunzipAsync(await this.objectStorage.get(document.archiveKey))).toString('utf8'),
+    );
+    return {
+      originJson: this.documentEncryptionService.open(archived.originJson),
+      translatedJson: this.documentEncryptionService.open(archived.translatedJson),
+      archived: true,
+      taskContent: this.documentEncryptionService.open(archived.taskContent),
+    };
+  }
+
+  async discard(document: UserJsonData): Promise<void> {
+    if (document.archiveKey) {
+      await this.objectStorage.delete(document.archiveKey);
+    }
+  }
+}
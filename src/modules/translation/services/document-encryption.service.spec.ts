@@ -0,0 +1,151 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { ChangeSetType, EntityManager } from '@mikro-orm/core';
+import { randomBytes } from 'crypto';
+import { DocumentEncryptionService, isSealed } from './document-encryption.service';
+import { UserDataKeyRepository } from '../repositories/translation-task.repository';
+import { TranslationTask, UserJsonData } from '../entities/translation-task.entity';
+
+describe('DocumentEncryptionService', () => {
+  let service: DocumentEncryptionService;
+  const config: Record<string, string> = {};
+  const keys = new Map<string, any>();
+
+  const mockEntityManager = {
+    getEventManager: jest.fn(() => ({ registerSubscriber: jest.fn() })),
+    fork: jest.fn(() => ({ findOne: jest.fn(async (_entity, { userId }) => keys.get(userId) ?? null) })),
+  };
+
+  const mockDataKeyRepository = {
+    get: jest.fn(async ({ userId }) => keys.get(userId) ?? null),
+    insert: jest.fn(async (data) => {
+      keys.set(data.userId, { ...data, createdAt: new Date() });
+    }),
+    delete: jest.fn(async (key) => {
+      keys.delete(key.userId);
+    }),
+  };
+
+  const create = async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        DocumentEncryptionService,
+        {
+          provide: ConfigService,
+          useValue: { get: jest.fn((key: string, defaultValue?: any) => config[key] ?? defaultValue) },
+        },
+        { provide: EntityManager, useValue: mockEntityManager },
+        { provide: UserDataKeyRepository, useValue: mockDataKeyRepository },
+      ],
+    }).compile();
+    return module.get<DocumentEncryptionService>(DocumentEncryptionService);
+  };
+
+  beforeEach(async () => {
+    config.DATA_ENCRYPTION_MASTER_KEY = randomBytes(32).toString('base64');
+    keys.clear();
+    service = await create();
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('开启后写入的内容加密保存，读取时解密；未开启的用户原样保存', async () => {
+    await service.setEnabled('user1', true);
+
+    const sealed = await service.seal('user1', '{"a":"secret"}');
+    expect(isSealed(sealed)).toBe(true);
+    expect(sealed).not.toContain('secret');
+    expect(service.open(sealed)).toBe('{"a":"secret"}');
+
+    await expect(service.seal('user2', '{"a":"plain"}')).resolves.toBe('{"a":"plain"}');
+    expect(service.open('{"a":"plain"}')).toBe('{"a":"plain"}');
+  });
+
+  it('flush 时只加密写入的数据，读取时解密并更新快照', async () => {
+    await service.setEnabled('user1', true);
+    const document = Object.assign(new UserJsonData(), { userId: 'user1', originJson: '{"a":"hi"}' });
+    const changeSet = { entity: document, type: ChangeSetType.CREATE, payload: { originJson: '{"a":"hi"}' } };
+
+    await service.onFlush({ uow: { getChangeSets: () => [changeSet] } } as any);
+
+    expect(isSealed(changeSet.payload.originJson)).toBe(true);
+    expect(document.originJson).toBe('{"a":"hi"}');
+
+    const snapshot = { originJson: changeSet.payload.originJson };
+    const loaded: any = Object.assign(new UserJsonData(), {
+      userId: 'user1',
+      originJson: changeSet.payload.originJson,
+      __helper: { __originalEntityData: snapshot },
+    });
+    service.onLoad({ entity: loaded } as any);
+
+    expect(loaded.originJson).toBe('{"a":"hi"}');
+    expect(snapshot.originJson).toBe('{"a":"hi"}');
+  });
+
+  it('内容变化时记下明文字节数，不按密文长度计算', async () => {
+    await service.setEnabled('user1', true);
+    const document = Object.assign(new UserJsonData(), {
+      userId: 'user1',
+      originJson: '{"a":"你好"}',
+      translatedJson: '{"a":"hi"}',
+    });
+    const changeSet: any = { entity: document, type: ChangeSetType.UPDATE, payload: { translatedJson: '{"a":"hi"}' } };
+
+    await service.onFlush({ uow: { getChangeSets: () => [changeSet] } } as any);
+
+    const plaintextBytes = Buffer.byteLength('{"a":"你好"}') + Buffer.byteLength('{"a":"hi"}');
+    expect(changeSet.payload.contentBytes).toBe(plaintextBytes);
+    expect(document.contentBytes).toBe(plaintextBytes);
+    expect(Buffer.byteLength(changeSet.payload.translatedJson)).toBeGreaterThan(Buffer.byteLength('{"a":"hi"}'));
+
+    const metadataOnly: any = { entity: document, type: ChangeSetType.UPDATE, payload: { name: 'checkout' } };
+    await service.onFlush({ uow: { getChangeSets: () => [metadataOnly] } } as any);
+    expect(metadataOnly.payload.contentBytes).toBeUndefined();
+  });
+
+  it('任务内容同样加密', async () => {
+    await service.setEnabled('user1', true);
+    const task = Object.assign(new TranslationTask(), { userId: 'user1' });
+    const changeSet = { entity: task, type: ChangeSetType.UPDATE, payload: { content: '{"a":"hi"}', status: 'done' } };
+
+    await service.onFlush({ uow: { getChangeSets: () => [changeSet] } } as any);
+
+    expect(isSealed(changeSet.payload.content)).toBe(true);
+    expect(changeSet.payload.status).toBe('done');
+  });
+
+  it('关闭后仍能读取之前加密的内容，新内容不再加密', async () => {
+    await service.setEnabled('user1', true);
+    const sealed = await service.seal('user1', 'before');
+
+    await service.setEnabled('user1', false);
+
+    expect(service.open(sealed)).toBe('before');
+    await expect(service.seal('user1', 'after')).resolves.toBe('after');
+  });
+
+  it('主密钥轮换后旧密文用旧主密钥解密', async () => {
+    await service.setEnabled('user1', true);
+    const sealed = await service.seal('user1', 'rotated');
+
+    config.DATA_ENCRYPTION_PREVIOUS_MASTER_KEYS = `default:${config.DATA_ENCRYPTION_MASTER_KEY}`;
+    config.DATA_ENCRYPTION_MASTER_KEY_ID = 'k2';
+    config.DATA_ENCRYPTION_MASTER_KEY = randomBytes(32).toString('base64');
+    const rotated = await create();
+
+    expect(rotated.open(sealed)).toBe('rotated');
+    delete config.DATA_ENCRYPTION_PREVIOUS_MASTER_KEYS;
+    delete config.DATA_ENCRYPTION_MASTER_KEY_ID;
+  });
+
+  it('未配置主密钥时不能开启', async () => {
+    delete config.DATA_ENCRYPTION_MASTER_KEY;
+    const unconfigured = await create();
+
+    await expect(unconfigured.setEnabled('user1', true)).rejects.toThrow('Data encryption is not configured');
+    await expect(unconfigured.getStatus('user1')).resolves.toMatchObject({ enabled: false, available: false });
+  });
+});
@@ -0,0 +1,228 @@
+import { Injectable, Logger, OnModuleInit, ServiceUnavailableException } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { ChangeSetType, EntityManager, EventArgs, EventSubscriber, FlushEventArgs, wrap } from '@mikro-orm/core';
+import { createCipheriv, createDecipheriv, randomBytes } from 'crypto';
+import { v4 as uuidv4 } from 'uuid';
+import { TranslationTask, UserJsonData } from '../entities/translation-task.entity';
+import { UserDataKey } from '../entities/user-data-key.entity';
+import { UserDataKeyRepository } from '../repositories/translation-task.repository';
+
+const ALGORITHM = 'aes-256-gcm';
+const PREFIX = 'denc:v1:';
+
+/** 用户密钥在进程内缓存的时间，开关加密后其他进程最迟在这之后生效 */
+const KEY_CACHE_TTL_MS = 60 * 1000;
+const MAX_UNWRAPPED_KEYS = 1000;
+
+/** 加密保存的列 */
+const SEALED_FIELDS = new Map<Function, string[]>([
+  [UserJsonData, ['originJson', 'translatedJson', 'deliveredJson']],
+  [TranslationTask, ['content']],
+]);
+
+/**
+ * 文档内容静态加密（信封加密）
+ * 开启加密的用户有一把随机数据密钥，以主密钥加密后保存；文档的原文、译文和任务内容写库前用数据密钥按
+ * AES-256-GCM 加密，密文格式为 denc:v1:<masterKeyId>:<wrappedKey>:<iv+tag+ciphertext>，带着被加密的数据密钥，
+ * 读取时只需主密钥即可解密。加解密在 ORM 的 flush / load 事件中完成，业务代码读写的始终是明文；
+ * 未开启的用户和开启前写入的明文原样读写
+ */
+@Injectable()
+export class DocumentEncryptionService implements EventSubscriber, OnModuleInit {
+  private readonly logger = new Logger(DocumentEncryptionService.name);
+  private readonly masterKeyId: string;
+  private readonly masterKeys = new Map<string, Buffer>();
+  private readonly userKeys = new Map<string, { key: UserDataKey | null; expiresAt: number }>();
+  private readonly unwrappedKeys = new Map<string, Buffer>();
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly em: EntityManager,
+    private readonly dataKeyRepository: UserDataKeyRepository,
+  ) {
+    this.masterKeyId = this.configService.get('DATA_ENCRYPTION_MASTER_KEY_ID', 'default');
+    const current = this.configService.get<string>('DATA_ENCRYPTION_MASTER_KEY');
+    if (current) {
+      this.masterKeys.set(this.masterKeyId, parseMasterKey(current, 'DATA_ENCRYPTION_MASTER_KEY'));
+    }
+    // 轮换后的旧主密钥，格式 id:base64,id:base64，只用于解密
+    const previous = this.configService.get<string>('DATA_ENCRYPTION_PREVIOUS_MASTER_KEYS', '');
+    for (const entry of previous.split(',').filter(Boolean)) {
+      const [id, encoded] = entry.trim().split(':');
+      this.masterKeys.set(id, parseMasterKey(encoded ?? '', `DATA_ENCRYPTION_PREVIOUS_MASTER_KEYS (${id})`));
+    }
+  }
+
+  onModuleInit(): void {
+    this.em.getEventManager().registerSubscriber(this);
+  }
+
+  getSubscribedEntities() {
+    return [UserJsonData, TranslationTask];
+  }
+
+  async getStatus(userId: string) {
+    const key = await this.dataKeyRepository.get({ userId });
+    return {
+      enabled: !!key,
+      enabledAt: key?.createdAt ?? null,
+      masterKeyId: key?.masterKeyId ?? null,
+      available: this.masterKeys.has(this.masterKeyId),
+    };
+  }
+
+  /**
+   * 开启时生成数据密钥，之后写入的文档加密保存；关闭时删除密钥记录，之后写入明文，
+   * 已加密的内容仍可读取（密文中带有被加密的数据密钥），再次保存时转为明文
+   */
+  async setEnabled(userId: string, enabled: boolean) {
+    const existing = await this.dataKeyRepository.get({ userId });
+    if (enabled && !existing) {
+      const masterKey = this.requireMasterKey();
+      await this.dataKeyRepository.insert({
+        id: uuidv4(),
+        userId,
+        masterKeyId: this.masterKeyId,
+        wrappedKey: encryptBytes(masterKey, randomBytes(32)).toString('base64'),
+      });
+      this.logger.log(`Document encryption enabled for user ${userId}`);
+    } else if (!enabled && existing) {
+      await this.dataKeyRepository.delete(existing);
+      this.logger.log(`Document encryption disabled for user ${userId}`);
+    }
+    this.userKeys.delete(userId);
+    return this.getStatus(userId);
+  }
+
+  /**
+   * 按用户的设置加密一个值；未开启加密、空值和已加密的值原样返回
+   */
+  async seal(userId: string, value: string | null | undefined): Promise<string | null | undefined> {
+    if (!value || isSealed(value)) {
+      return value;
+    }
+    const key = await this.loadUserKey(userId);
+    if (!key) {
+      return value;
+    }
+    const ciphertext = encryptBytes(this.unwrap(key.masterKeyId, key.wrappedKey), Buffer.from(value, 'utf8'));
+    return `${PREFIX}${key.masterKeyId}:${key.wrappedKey}:${ciphertext.toString('base64')}`;
+  }
+
+  /** 解密一个值，明文原样返回 */
+  open(value: string | null | undefined): string | null | undefined {
+    if (!value || !isSealed(value)) {
+      return value;
+    }
+    const [masterKeyId, wrappedKey, payload] = value.slice(PREFIX.length).split(':');
+    const dataKey = this.unwrap(masterKeyId, wrappedKey);
+    return decryptBytes(dataKey, Buffer.from(payload, 'base64')).toString('utf8');
+  }
+
+  /**
+   * 写库前加密变更中的内容列；只修改写入的数据，实体上仍是明文，flush 后的快照与实体一致。
+   * 文档内容变化时同时记下明文字节数，存储用量按它统计
+   */
+  async onFlush({ uow }: FlushEventArgs): Promise<void> {
+    for (const changeSet of uow.getChangeSets()) {
+      const fields = SEALED_FIELDS.get(changeSet.entity.constructor);
+      if (!fields || (changeSet.type !== ChangeSetType.CREATE && changeSet.type !== ChangeSetType.UPDATE)) {
+        continue;
+      }
+      const userId = (changeSet.entity as UserJsonData | TranslationTask).userId;
+      const payload = changeSet.payload as Record<string, any>;
+      if (changeSet.entity instanceof UserJsonData && ('originJson' in payload || 'translatedJson' in payload)) {
+        changeSet.entity.contentBytes = contentBytes(changeSet.entity);
+        payload.contentBytes = changeSet.entity.contentBytes;
+      }
+      for (const field of fields) {
+        if (typeof payload[field] === 'string') {
+          payload[field] = await this.seal(userId, payload[field]);
+        }
+      }
+    }
+  }
+
+  /**
+   * 读取后解密，并把快照改为明文，避免未修改的实体在下一次 flush 时被当作已变更
+   */
+  onLoad({ entity }: EventArgs<UserJsonData | TranslationTask>): void {
+    const fields = SEALED_FIELDS.get(entity.constructor) ?? [];
+    const target = entity as Record<string, any>;
+    const snapshot = wrap(entity, true)?.__originalEntityData as Record<string, any>;
+    for (const field of fields) {
+      if (typeof target[field] === 'string' && isSealed(target[field])) {
+        target[field] = this.open(target[field]);
+        if (snapshot) {
+          snapshot[field] = target[field];
+        }
+      }
+    }
+  }
+
+  private async loadUserKey(userId: string): Promise<UserDataKey | null> {
+    const cached = this.userKeys.get(userId);
+    if (cached && cached.expiresAt > Date.now()) {
+      return cached.key;
+    }
+    // flush 过程中不能在同一个 EntityManager 上查询（可能触发自动 flush），使用独立的上下文
+    const key = await this.em.fork().findOne(UserDataKey, { userId });
+    this.userKeys.set(userId, { key, expiresAt: Date.now() + KEY_CACHE_TTL_MS });
+    return key;
+  }
+
+  private unwrap(masterKeyId: string, wrappedKey: string): Buffer {
+    const cached = this.unwrappedKeys.get(wrappedKey);
+    if (cached) {
+      return cached;
+    }
+    const masterKey = this.masterKeys.get(masterKeyId);
+    if (!masterKey) {
+      throw new ServiceUnavailableException(`Data encryption master key ${masterKeyId} is not configured`);
+    }
+    const dataKey = decryptBytes(masterKey, Buffer.from(wrappedKey, 'base64'));
+    if (this.unwrappedKeys.size >= MAX_UNWRAPPED_KEYS) {
+      this.unwrappedKeys.clear();
+    }
+    this.unwrappedKeys.set(wrappedKey, dataKey);
+    return dataKey;
+  }
+
+  private requireMasterKey(): Buffer {
+    const masterKey = this.masterKeys.get(this.masterKeyId);
+    if (!masterKey) {
+      throw new ServiceUnavailableException('Data encryption is not configured');
+    }
+    return masterKey;
+  }
+}
+
+export function isSealed(value: string): boolean {
+  return value.startsWith(PREFIX);
+}
+
+function contentBytes(document: UserJsonData): number {
+  return Buffer.byteLength(document.originJson ?? '') + Buffer.byteLength(document.translatedJson ?? '');
+}
+
+function parseMasterKey(encoded: string, name: string): Buffer {
+  const key = Buffer.from(encoded, 'base64');
+  if (key.length !== 32) {
+    throw new Error(`${name} must be 32 bytes encoded as base64`);
+  }
+  return key;
+}
+
+/** iv(12) + tag(16) + ciphertext */
+function encryptBytes(key: Buffer, plaintext: Buffer): Buffer {
+  const iv = randomBytes(12);
+  const cipher = createCipheriv(ALGORITHM, key, iv);
+  const ciphertext = Buffer.concat([cipher.update(plaintext), cipher.final()]);
+  return Buffer.concat([iv, cipher.getAuthTag(), ciphertext]);
+}
+
+function decryptBytes(key: Buffer, sealed: Buffer): Buffer {
+  const decipher = createDecipheriv(ALGORITHM, key, sealed.subarray(0, 12));
+  decipher.setAuthTag(sealed.subarray(12, 28));
+  return Buffer.concat([decipher.update(sealed.subarray(28)), decipher.final()]);
+}
@@ -0,0 +1,76 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { HttpStatus } from '@nestjs/common';
+import { DocumentLockService } from './document-lock.service';
+import { RedisService } from '../../../common/services/redis.service';
+
+describe('DocumentLockService', () => {
+  let service: DocumentLockService;
+
+  const mockRedisService = {
+    client: { eval: jest.fn() },
+    getJson: jest.fn(),
+    del: jest.fn(),
+  };
+
+  const heldLock = { documentId: 'doc1', token: 'held', expiresAt: '2026-10-16T08:15:00.000Z' };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        DocumentLockService,
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, def?: any) => def) } },
+      ],
+    }).compile();
+
+    service = module.get<DocumentLockService>(DocumentLockService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('加锁成功时返回 token，有效期不超过上限', async () => {
+    mockRedisService.client.eval.mockResolvedValueOnce(null);
+
+    const lock = await service.acquire('doc1', { owner: 'alice', ttlSeconds: 86400 });
+
+    expect(lock).toMatchObject({ documentId: 'doc1', owner: 'alice', token: expect.any(String) });
+    expect(new Date(lock.expiresAt).getTime() - new Date(lock.lockedAt).getTime()).toBe(3600 * 1000);
+    expect(mockRedisService.client.eval).toHaveBeenCalledWith(
+      expect.any(String),
+      1,
+      'document_lock:doc1',
+      expect.any(String),
+      lock.token,
+      3600,
+    );
+  });
+
+  it('已被其他人锁定时返回 423', async () => {
+    mockRedisService.client.eval.mockResolvedValueOnce(JSON.stringify(heldLock));
+
+    await expect(service.acquire('doc1')).rejects.toMatchObject({ status: HttpStatus.LOCKED });
+  });
+
+  it('只有持有 token 的请求可以修改，未加锁时不限制', async () => {
+    mockRedisService.getJson
+      .mockResolvedValueOnce(heldLock)
+      .mockResolvedValueOnce(heldLock)
+      .mockResolvedValueOnce(null);
+
+    await expect(service.assertEditable('doc1', 'other')).rejects.toThrow('Document is locked for editing');
+    await expect(service.assertEditable('doc1', 'held')).resolves.toBeUndefined();
+    await expect(service.assertEditable('doc1')).resolves.toBeUndefined();
+  });
+
+  it('token 不一致时拒绝解锁，force 时直接删除', async () => {
+    mockRedisService.getJson.mockResolvedValue(heldLock);
+    mockRedisService.client.eval.mockResolvedValueOnce(0);
+
+    await expect(service.release('doc1', 'other')).rejects.toMatchObject({ status: HttpStatus.LOCKED });
+    await expect(service.release('doc1', undefined, true)).resolves.toBe(true);
+    expect(mockRedisService.del).toHaveBeenCalledWith('document_lock:doc1');
+  });
+});
@@ -0,0 +1,124 @@
+import { HttpException, HttpStatus, Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { randomUUID } from 'crypto';
+import { RedisService } from '../../../common/services/redis.service';
+
+export interface DocumentLock {
+  documentId: string;
+  /** 持有者凭据，修改键和解锁时通过 X-Lock-Token 请求头传回 */
+  token: string;
+  /** 持有者说明（如审校人），只用于展示 */
+  owner?: string;
+  lockedAt: string;
+  expiresAt: string;
+}
+
+const lockKey = (documentId: string) => `document_lock:${documentId}`;
+
+// 未加锁或凭据一致时写入（续期），否则返回当前锁
+const ACQUIRE_SCRIPT = `
+local current = redis.call('GET', KEYS[1])
+if current and cjson.decode(current).token ~= ARGV[2] then
+  return current
+end
+redis.call('SET', KEYS[1], ARGV[1], 'EX', ARGV[3])
+return false
+`;
+
+// 只有凭据一致时才删除，返回 0 表示锁由其他人持有
+const RELEASE_SCRIPT = `
+local current = redis.call('GET', KEYS[1])
+if current and cjson.decode(current).token ~= ARGV[1] then
+  return 0
+end
+redis.call('DEL', KEYS[1])
+return 1
+`;
+
+/**
+ * 人工编辑期间的文档锁
+ * 外部审校界面编辑译文前加锁，锁有效期内其他调用方修改键会返回 423，
+ * 周期任务重新翻译和失败键补译会延后到锁释放之后执行；锁按 TTL 自动过期，避免界面异常退出后一直占用
+ */
+@Injectable()
+export class DocumentLockService {
+  private readonly logger = new Logger(DocumentLockService.name);
+  private readonly defaultTtlSeconds: number;
+  private readonly maxTtlSeconds: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly redisService: RedisService,
+  ) {
+    this.defaultTtlSeconds = Number(this.configService.get('DOCUMENT_LOCK_TTL_SECONDS', 900));
+    this.maxTtlSeconds = Number(this.configService.get('DOCUMENT_LOCK_MAX_TTL_SECONDS', 3600));
+  }
+
+  /**
+   * 加锁；传入当前持有的 token 时续期。已被其他人锁定时返回 423
+   */
+  async acquire(
+    documentId: string,
+    options: { owner?: string; ttlSeconds?: number; token?: string } = {},
+  ): Promise<DocumentLock> {
+    const ttlSeconds = Math.min(options.ttlSeconds ?? this.defaultTtlSeconds, this.maxTtlSeconds);
+    const now = Date.now();
+    const lock: DocumentLock = {
+      documentId,
+      token: options.token ?? randomUUID(),
+      owner: options.owner,
+      lockedAt: new Date(now).toISOString(),
+      expiresAt: new Date(now + ttlSeconds * 1000).toISOString(),
+    };
+    const held = (await this.redisService.client.eval(
+      ACQUIRE_SCRIPT,
+      1,
+      lockKey(documentId),
+      JSON.stringify(lock),
+      lock.token,
+      ttlSeconds,
+    )) as string | null;
+    if (held) {
+      throw this.lockedException();
+    }
+    return lock;
+  }
+
+  /**
+   * 解锁；force 为 true 时忽略 token（文档所有者强制解锁）。返回是否存在过锁
+   */
+  async release(documentId: string, token?: string, force = false): Promise<boolean> {
+    const current = await this.get(documentId);
+    if (!current) {
+      return false;
+    }
+    if (force) {
+      await this.redisService.del(lockKey(documentId));
+      this.logger.warn(`Document lock on ${documentId} held by ${current.owner ?? 'unknown'} was force-released`);
+      return true;
+    }
+    const released = await this.redisService.client.eval(RELEASE_SCRIPT, 1, lockKey(documentId), token ?? '');
+    if (released === 0) {
+      throw this.lockedException();
+    }
+    return true;
+  }
+
+  async get(documentId: string): Promise<DocumentLock | null> {
+    return this.redisService.getJson<DocumentLock>(lockKey(documentId));
+  }
+
+  /**
+   * 文档被锁定且请求没有带上持有的 token 时返回 423
+   */
+  async assertEditable(documentId: string, token?: string): Promise<void> {
+    const lock = await this.get(documentId);
+    if (lock && lock.token !== token) {
+      throw this.lockedException();
+    }
+  }
+
+  private lockedException(): HttpException {
+    return new HttpException('Document is locked for editing', HttpStatus.LOCKED);
+  }
+}
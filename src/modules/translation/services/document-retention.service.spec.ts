@@ -0,0 +1,151 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager } from '@mikro-orm/core';
+import { DocumentRetentionService } from './document-retention.service';
+import { DocumentArchiveService } from './document-archive.service';
+import { TranslationChunkService } from './translation-chunk.service';
+import { PlanLimitsService } from '../../subscription/services/plan-limits.service';
+import { LegalHoldService } from '../../audit/services/legal-hold.service';
+import { AuditLogService } from '../../audit/services/audit-log.service';
+import { AuditAction } from '../../audit/entities/audit-log.entity';
+import {
+  RetentionPolicyRepository,
+  TranslationTaskRepository,
+  UserJsonDataRepository,
+} from '../repositories/translation-task.repository';
+
+describe('DocumentRetentionService', () => {
+  let service: DocumentRetentionService;
+  const now = new Date('2026-10-16T00:00:00Z');
+
+  const mockEntityManager = {
+    find: jest.fn().mockResolvedValue([{ metadata: { retentionDays: 90 } }, { metadata: {} }]),
+    getConnection: jest.fn(() => ({ execute: mockExecute })),
+  };
+  const mockExecute = jest.fn();
+
+  const mockPlanLimitsService = {
+    resolve: jest.fn().mockResolvedValue({ retentionDays: 90 }),
+  };
+
+  const mockLegalHoldService = {
+    getHeldUserIds: jest.fn().mockResolvedValue([]),
+  };
+
+  const mockAuditLogService = {
+    log: jest.fn(),
+  };
+
+  const mockDocumentArchiveService = {
+    discard: jest.fn(),
+  };
+
+  const mockTranslationChunkService = {
+    discard: jest.fn(),
+  };
+
+  const mockPolicyRepository = {
+    get: jest.fn().mockResolvedValue(null),
+    list: jest.fn().mockResolvedValue([]),
+    insert: jest.fn(),
+    save: jest.fn(),
+    delete: jest.fn(),
+  };
+
+  const mockTaskRepository = {
+    list: jest.fn(),
+    save: jest.fn(),
+  };
+
+  const mockUserJsonDataRepository = {
+    list: jest.fn(),
+    save: jest.fn(),
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        DocumentRetentionService,
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+        { provide: EntityManager, useValue: mockEntityManager },
+        { provide: PlanLimitsService, useValue: mockPlanLimitsService },
+        { provide: LegalHoldService, useValue: mockLegalHoldService },
+        { provide: AuditLogService, useValue: mockAuditLogService },
+        { provide: DocumentArchiveService, useValue: mockDocumentArchiveService },
+        { provide: TranslationChunkService, useValue: mockTranslationChunkService },
+        { provide: RetentionPolicyRepository, useValue: mockPolicyRepository },
+        { provide: TranslationTaskRepository, useValue: mockTaskRepository },
+        { provide: UserJsonDataRepository, useValue: mockUserJsonDataRepository },
+      ],
+    }).compile();
+
+    service = module.get<DocumentRetentionService>(DocumentRetentionService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('用户设置只能缩短计划的保留期', async () => {
+    mockPolicyRepository.get.mockResolvedValueOnce({ retentionDays: 30 });
+    await expect(service.getPolicy('user1')).resolves.toEqual({
+      planRetentionDays: 90,
+      userRetentionDays: 30,
+      effectiveRetentionDays: 30,
+    });
+
+    mockPolicyRepository.get.mockResolvedValueOnce({ retentionDays: 365 });
+    await expect(service.getPolicy('user1')).resolves.toMatchObject({ effectiveRetentionDays: 90 });
+
+    mockPlanLimitsService.resolve.mockResolvedValueOnce({ retentionDays: null });
+    await expect(service.getPolicy('user1')).resolves.toMatchObject({ effectiveRetentionDays: null });
+  });
+
+  it('应清除到期文档的内容并写审计日志，跳过周期任务和进行中的任务', async () => {
+    mockExecute.mockResolvedValueOnce([{ user_id: 'user1' }]);
+    const expired: any = { id: 'doc1', userId: 'user1', originJson: '{"a":"hi"}', translatedJson: '{"a":"你好"}' };
+    const recurring: any = { id: 'doc2', userId: 'user1', originJson: '{"a":"hi"}' };
+    const running: any = { id: 'doc3', userId: 'user1', originJson: '{"a":"hi"}' };
+    mockUserJsonDataRepository.list.mockResolvedValueOnce([expired, recurring, running]).mockResolvedValueOnce([]);
+    const task: any = { id: 'doc1', status: 'completed', content: '{"a":"hi"}' };
+    mockTaskRepository.list.mockResolvedValueOnce([
+      task,
+      { id: 'doc2', status: 'completed', cron: '0 * * * *' },
+      { id: 'doc3', status: 'processing' },
+    ]);
+
+    await expect(service.purgeDue(now)).resolves.toBe(1);
+
+    expect(mockUserJsonDataRepository.list).toHaveBeenCalledWith(
+      expect.objectContaining({ userId: 'user1', purgedAt: null, updatedAt: { $lt: new Date('2026-07-18') } }),
+      expect.anything(),
+    );
+    expect(expired).toMatchObject({ originJson: '', translatedJson: null, purgedAt: now });
+    expect(task.content).toBe('');
+    expect(recurring.originJson).toBe('{"a":"hi"}');
+    expect(mockDocumentArchiveService.discard).toHaveBeenCalledWith(expired);
+    expect(mockTranslationChunkService.discard).toHaveBeenCalledWith('doc1');
+    expect(mockAuditLogService.log).toHaveBeenCalledWith(
+      expect.objectContaining({
+        userId: 'user1',
+        action: AuditAction.DATA_PURGE,
+        newValues: expect.objectContaining({ documentIds: ['doc1'], retentionDays: 90 }),
+      }),
+    );
+  });
+
+  it('法律保留中的用户不清理', async () => {
+    mockLegalHoldService.getHeldUserIds.mockResolvedValueOnce(['user1']);
+    mockExecute.mockResolvedValueOnce([{ user_id: 'user1' }]);
+
+    await expect(service.purgeDue(now)).resolves.toBe(0);
+    expect(mockUserJsonDataRepository.list).not.toHaveBeenCalled();
+  });
+
+  it('没有任何保留设置时不检查文档', async () => {
+    mockEntityManager.find.mockResolvedValueOnce([{ metadata: {} }]);
+
+    await expect(service.purgeDue(now)).resolves.toBe(0);
+    expect(mockExecute).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,195 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager } from '@mikro-orm/core';
+import { v4 as uuidv4 } from 'uuid';
+import { PlanLimitsService, parsePlanMetadata } from '../../subscription/services/plan-limits.service';
+import { SubscriptionPlan } from '../../subscription/entities/subscription-plan.entity';
+import { LegalHoldService } from '../../audit/services/legal-hold.service';
+import { AuditLogService } from '../../audit/services/audit-log.service';
+import { AuditAction, AuditSeverity, ResourceType } from '../../audit/entities/audit-log.entity';
+import { DocumentArchiveService } from './document-archive.service';
+import { TranslationChunkService } from './translation-chunk.service';
+import {
+  RetentionPolicyRepository,
+  TranslationTaskRepository,
+  UserJsonDataRepository,
+} from '../repositories/translation-task.repository';
+import { UserJsonData } from '../entities/translation-task.entity';
+
+const DAY_MS = 24 * 3600 * 1000;
+
+/** 仍在排队、执行或等待下次触发的任务需要原文，不清理 */
+const ACTIVE_STATUSES = new Set(['pending', 'processing', 'scheduled', 'paused']);
+
+export interface RetentionPolicyView {
+  /** 计划规定的保留天数，null 表示不清理 */
+  planRetentionDays: number | null;
+  userRetentionDays: number | null;
+  /** 实际生效的保留天数（两者中较小的一个） */
+  effectiveRetentionDays: number | null;
+}
+
+/**
+ * 文档内容保留期
+ * 计划（metadata.retentionDays）和用户都可以设置保留天数，取较小值；worker 定时清除最后修改时间早于保留期的文档的
+ * 原文、译文和归档，只保留名称、语言、字符数等元数据，每个用户每次清理写一条审计日志。法律保留中的用户不清理
+ */
+@Injectable()
+export class DocumentRetentionService {
+  private readonly logger = new Logger(DocumentRetentionService.name);
+  private readonly batchSize: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly em: EntityManager,
+    private readonly planLimitsService: PlanLimitsService,
+    private readonly legalHoldService: LegalHoldService,
+    private readonly auditLogService: AuditLogService,
+    private readonly documentArchiveService: DocumentArchiveService,
+    private readonly translationChunkService: TranslationChunkService,
+    private readonly policyRepository: RetentionPolicyRepository,
+    private readonly taskRepository: TranslationTaskRepository,
+    private readonly userJsonDataRepository: UserJsonDataRepository,
+  ) {
+    this.batchSize = Number(this.configService.get('RETENTION_PURGE_BATCH_SIZE', 200));
+  }
+
+  async getPolicy(userId: string): Promise<RetentionPolicyView> {
+    const [limits, policy] = await Promise.all([
+      this.planLimitsService.resolve(userId),
+      this.policyRepository.get({ userId }),
+    ]);
+    const planRetentionDays = limits?.retentionDays ?? null;
+    const userRetentionDays = policy?.retentionDays ?? null;
+    const candidates = [planRetentionDays, userRetentionDays].filter((days) => days !== null);
+    return {
+      planRetentionDays,
+      userRetentionDays,
+      effectiveRetentionDays: candidates.length ? Math.min(...candidates) : null,
+    };
+  }
+
+  /**
+   * 设置用户自己的保留天数，传 null 恢复为计划的保留期
+   */
+  async setPolicy(userId: string, retentionDays: number | null): Promise<RetentionPolicyView> {
+    const existing = await this.policyRepository.get({ userId });
+    if (retentionDays === null) {
+      if (existing) {
+        await this.policyRepository.delete(existing);
+      }
+    } else if (existing) {
+      existing.retentionDays = retentionDays;
+      await this.policyRepository.save(existing);
+    } else {
+      await this.policyRepository.insert({ id: uuidv4(), userId, retentionDays });
+    }
+    return this.getPolicy(userId);
+  }
+
+  /**
+   * 清理所有到期的文档，由 worker 定时调用，返回清理的文档数
+   */
+  async purgeDue(now = new Date()): Promise<number> {
+    const shortest = await this.shortestRetentionDays();
+    if (shortest === null) {
+      return 0;
+    }
+    const held = new Set(await this.legalHoldService.getHeldUserIds());
+    const rows = await this.em.getConnection().execute(
+      'SELECT DISTINCT user_id FROM user_json_data WHERE purged_at IS NULL AND updated_at < ?',
+      [new Date(now.getTime() - shortest * DAY_MS)],
+    );
+
+    let purged = 0;
+    for (const { user_id: userId } of rows) {
+      if (held.has(userId)) {
+        continue;
+      }
+      try {
+        purged += await this.purgeUser(userId, now);
+      } catch (error) {
+        this.logger.error(`Retention purge failed for user ${userId}: ${error.message}`);
+      }
+    }
+    return purged;
+  }
+
+  private async purgeUser(userId: string, now: Date): Promise<number> {
+    const { effectiveRetentionDays: days } = await this.getPolicy(userId);
+    if (!days) {
+      return 0;
+    }
+    const cutoff = new Date(now.getTime() - days * DAY_MS);
+    const purgedIds: string[] = [];
+    let cursor = '';
+    for (;;) {
+      const documents = await this.userJsonDataRepository.list(
+        { userId, purgedAt: null, updatedAt: { $lt: cutoff }, id: { $gt: cursor } },
+        { limit: this.batchSize, orderBy: { id: 'ASC' } },
+      );
+      if (documents.length === 0) {
+        break;
+      }
+      const tasks = new Map(
+        (await this.taskRepository.list({ id: { $in: documents.map((document) => document.id) } })).map((task) => [
+          task.id,
+          task,
+        ]),
+      );
+      for (const document of documents) {
+        const task = tasks.get(document.id);
+        if (task && (task.cron || ACTIVE_STATUSES.has(task.status))) {
+          continue;
+        }
+        await this.purgeDocument(document, now);
+        if (task) {
+          task.content = '';
+          await this.taskRepository.save(task);
+        }
+        purgedIds.push(document.id);
+      }
+      cursor = documents[documents.length - 1].id;
+    }
+
+    if (purgedIds.length > 0) {
+      await this.auditLogService.log({
+        userId,
+        action: AuditAction.DATA_PURGE,
+        resourceType: ResourceType.DOCUMENT,
+        newValues: { documents: purgedIds.length, documentIds: purgedIds, retentionDays: days, cutoff },
+        severity: AuditSeverity.MEDIUM,
+        description: `Purged the content of ${purgedIds.length} document(s) older than ${days} days`,
+        tags: ['retention'],
+      });
+      this.logger.log(`Purged ${purgedIds.length} document(s) of user ${userId} under a ${days}-day retention`);
+    }
+    return purgedIds.length;
+  }
+
+  private async purgeDocument(document: UserJsonData, now: Date): Promise<void> {
+    await this.documentArchiveService.discard(document);
+    await this.translationChunkService.discard(document.id);
+    Object.assign(document, {
+      originJson: '',
+      translatedJson: null,
+      deliveredJson: null,
+      archiveKey: null,
+      purgedAt: now,
+    });
+    await this.userJsonDataRepository.save(document);
+  }
+
+  /**
+   * 所有计划和用户设置中最短的保留天数，用于缩小需要检查的用户范围
+   */
+  private async shortestRetentionDays(): Promise<number | null> {
+    const plans = await this.em.find(SubscriptionPlan, {});
+    const [policy] = await this.policyRepository.list({}, { limit: 1, orderBy: { retentionDays: 'ASC' } });
+    const days = [
+      ...plans.map((plan) => parsePlanMetadata(plan.metadata).retentionDays),
+      policy?.retentionDays,
+    ].filter((value) => !!value);
+    return days.length ? Math.min(...days) : null;
+  }
+}
@@ -0,0 +1,53 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { DuplicateSubmissionService } from './duplicate-submission.service';
+import { RedisService } from '../../../common/services/redis.service';
+
+describe('DuplicateSubmissionService', () => {
+  let service: DuplicateSubmissionService;
+
+  const mockRedisService = {
+    client: { set: jest.fn(), get: jest.fn(), del: jest.fn() },
+  };
+  const payload = { jsonContentRaw: '{"a":"hello"}', fromLang: 'en', toLang: 'de' };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        DuplicateSubmissionService,
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+        { provide: RedisService, useValue: mockRedisService },
+      ],
+    }).compile();
+
+    service = module.get<DuplicateSubmissionService>(DuplicateSubmissionService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('内容和语言对相同时指纹相同，翻译选项不同时指纹不同', () => {
+    expect(service.fingerprint({ ...payload })).toBe(service.fingerprint(payload));
+    expect(service.fingerprint({ ...payload, toLang: 'fr' })).not.toBe(service.fingerprint(payload));
+    expect(service.fingerprint({ ...payload, ignoredFields: 'id' })).not.toBe(service.fingerprint(payload));
+  });
+
+  it('指纹未被占用时占用并返回 undefined，已占用时返回原文档 ID', async () => {
+    mockRedisService.client.set.mockResolvedValueOnce('OK').mockResolvedValueOnce(null);
+    mockRedisService.client.get.mockResolvedValueOnce('doc1');
+
+    await expect(service.claim('u1', 'fp', 'doc1')).resolves.toBeUndefined();
+    await expect(service.claim('u1', 'fp', 'doc2')).resolves.toBe('doc1');
+    expect(mockRedisService.client.set).toHaveBeenCalledWith('translation_submission:u1:fp', 'doc1', 'EX', 600, 'NX');
+  });
+
+  it('只释放仍指向该文档的指纹；Redis 不可用时不检测', async () => {
+    mockRedisService.client.get.mockResolvedValueOnce('doc2');
+    await service.release('u1', 'fp', 'doc1');
+    expect(mockRedisService.client.del).not.toHaveBeenCalled();
+
+    mockRedisService.client.set.mockRejectedValueOnce(new Error('connection refused'));
+    await expect(service.claim('u1', 'fp', 'doc1')).resolves.toBeUndefined();
+  });
+});
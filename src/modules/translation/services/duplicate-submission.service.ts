@@ -0,0 +1,92 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { createHash } from 'crypto';
+import { TranslationPayload } from '../dto/translation-task.dto';
+import { RedisService } from '../../../common/services/redis.service';
+
+/**
+ * 重复提交检测
+ * 客户端超时重试、界面双击会在短时间内提交同一文档和同一语言对，每次都会创建文档并计费。
+ * 提交时按内容和翻译选项计算指纹，窗口期内同一用户的相同指纹指向已创建的文档；force=true 时跳过检测
+ */
+@Injectable()
+export class DuplicateSubmissionService {
+  private readonly logger = new Logger(DuplicateSubmissionService.name);
+  readonly windowSeconds: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly redisService: RedisService,
+  ) {
+    this.windowSeconds = Number(this.configService.get('TRANSLATION_DUPLICATE_WINDOW_SECONDS', 600));
+  }
+
+  /**
+   * 内容相同但翻译选项不同（忽略字段、服务商、输出形态、定时等）的提交不视为重复
+   */
+  fingerprint(payload: TranslationPayload): string {
+    return createHash('sha256')
+      .update(
+        JSON.stringify([
+          payload.jsonContentRaw,
+          payload.fromLang,
+          payload.toLang,
+          payload.ignoredFields ?? null,
+          payload.profileId ?? null,
+          payload.project ?? null,
+          payload.provider ?? null,
+          payload.placeholderStyles ?? null,
+          payload.outputKeyFormat ?? null,
+          payload.preserveFormatting ?? null,
+          payload.maskPii ? (payload.piiPatterns ?? []) : null,
+          payload.processAt ?? null,
+          payload.cron ?? null,
+        ]),
+      )
+      .digest('hex');
+  }
+
+  /**
+   * 为新文档占用指纹，返回窗口期内已占用该指纹的文档 ID；未重复或检测关闭（窗口为 0）时返回 undefined。
+   * 用 SET NX 占用，同时到达的两次提交只有一次成功；overwrite 用于原文档已不存在时改为指向新文档。
+   * Redis 不可用时不检测
+   */
+  async claim(userId: string, fingerprint: string, documentId: string, overwrite = false): Promise<string | undefined> {
+    if (!(this.windowSeconds > 0)) {
+      return undefined;
+    }
+    const key = this.key(userId, fingerprint);
+    try {
+      if (overwrite) {
+        await this.redisService.client.set(key, documentId, 'EX', this.windowSeconds);
+        return undefined;
+      }
+      const claimed = await this.redisService.client.set(key, documentId, 'EX', this.windowSeconds, 'NX');
+      if (claimed === 'OK') {
+        return undefined;
+      }
+      return (await this.redisService.client.get(key)) ?? undefined;
+    } catch (error) {
+      this.logger.error(`Duplicate submission check unavailable: ${error.message}`);
+      return undefined;
+    }
+  }
+
+  /**
+   * 提交没有完成（例如额度检查失败）时释放指纹，只释放仍指向该文档的指纹
+   */
+  async release(userId: string, fingerprint: string, documentId: string): Promise<void> {
+    const key = this.key(userId, fingerprint);
+    try {
+      if ((await this.redisService.client.get(key)) === documentId) {
+        await this.redisService.client.del(key);
+      }
+    } catch (error) {
+      this.logger.error(`Failed to release duplicate submission fingerprint: ${error.message}`);
+    }
+  }
+
+  private key(userId: string, fingerprint: string): string {
+    return `translation_submission:${userId}:${fingerprint}`;
+  }
+}
@@ -0,0 +1,35 @@
+import { BadRequestException, Injectable } from '@nestjs/common';
+import { FieldSuggestionRequestDto } from '../dto/field-suggestion.dto';
+import { FieldSuggestion, suggestIgnoredFields } from '../utils/field-suggestions';
+import { JsonLimitsService } from './json-limits.service';
+
+export interface FieldSuggestionReport {
+  suggestions: FieldSuggestion[];
+  /** 建议的路径，可直接作为忽略配置（/user/profiles）的 paths */
+  profile: { paths: string[] };
+}
+
+/**
+ * 分析样例文档，建议不需要翻译的字段，接入新的文档结构时不必逐个字段排查
+ */
+@Injectable()
+export class FieldSuggestionService {
+  constructor(private readonly jsonLimitsService: JsonLimitsService) {}
+
+  analyze(dto: FieldSuggestionRequestDto): FieldSuggestionReport {
+    this.jsonLimitsService.assert(dto.json);
+
+    let document: any;
+    try {
+      document = JSON.parse(dto.json);
+    } catch {
+      throw new BadRequestException('Invalid JSON content');
+    }
+
+    const suggestions = suggestIgnoredFields(document, {
+      minConfidence: dto.minConfidence,
+      limit: dto.limit ?? 100,
+    });
+    return { suggestions, profile: { paths: suggestions.map((suggestion) => suggestion.path) } };
+  }
+}
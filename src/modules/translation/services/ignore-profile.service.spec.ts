@@ -0,0 +1,73 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { BadRequestException, ConflictException } from '@nestjs/common';
+import { IgnoreProfileService } from './ignore-profile.service';
+import { IgnoreProfileRepository } from '../repositories/translation-task.repository';
+import { SkipHeuristic } from '../utils/ignore-rules';
+
+describe('IgnoreProfileService', () => {
+  let service: IgnoreProfileService;
+
+  const scopedRepository = {
+    count: jest.fn(),
+    get: jest.fn(),
+    getOrFail: jest.fn(),
+    insert: jest.fn(async (entity) => entity),
+  };
+  const mockProfileRepository = {
+    forUser: jest.fn(() => scopedRepository),
+    save: jest.fn(),
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        IgnoreProfileService,
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+        { provide: IgnoreProfileRepository, useValue: mockProfileRepository },
+      ],
+    }).compile();
+
+    service = module.get<IgnoreProfileService>(IgnoreProfileService);
+    scopedRepository.count.mockResolvedValue(0);
+    scopedRepository.get.mockResolvedValue(null);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  describe('create', () => {
+    it('应去重后保存规则', async () => {
+      const profile = await service.create('u1', {
+        name: 'mobile',
+        fields: ['id', ' id ', 'sku'],
+        skip: [SkipHeuristic.URLS, SkipHeuristic.URLS],
+      });
+
+      expect(profile).toMatchObject({ userId: 'u1', name: 'mobile', fields: ['id', 'sku'], skip: ['urls'] });
+      expect(profile.paths).toBeUndefined();
+    });
+
+    it('应拒绝空配置、无效正则和重名', async () => {
+      await expect(service.create('u1', { name: 'empty' })).rejects.toThrow(BadRequestException);
+      await expect(service.create('u1', { name: 'bad', patterns: ['('] })).rejects.toThrow('Invalid pattern (');
+
+      scopedRepository.get.mockResolvedValue({ id: 'p1', name: 'mobile' });
+      await expect(service.create('u1', { name: 'mobile', fields: ['id'] })).rejects.toThrow(ConflictException);
+      expect(scopedRepository.insert).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('update', () => {
+    it('应整体替换规则', async () => {
+      const profile = { id: 'p1', name: 'mobile', fields: ['id'], paths: ['meta.**'] };
+      scopedRepository.getOrFail.mockResolvedValue(profile);
+
+      await service.update('u1', 'p1', { name: 'mobile', include: ['ui.**'] });
+
+      expect(profile).toMatchObject({ fields: undefined, paths: undefined, include: ['ui.**'] });
+      expect(mockProfileRepository.save).toHaveBeenCalledWith(profile);
+    });
+  });
+});
@@ -0,0 +1,98 @@
+import { BadRequestException, ConflictException, Injectable } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { v4 as uuidv4 } from 'uuid';
+import { IgnoreProfile } from '../entities/ignore-profile.entity';
+import { IgnoreProfileDto } from '../dto/ignore-profile.dto';
+import { IgnoreProfileRepository } from '../repositories/translation-task.repository';
+import { IgnoreRules, SkipHeuristic } from '../utils/ignore-rules';
+
+const unique = (values?: string[]) => (values?.length ? [...new Set(values)] : undefined);
+
+/**
+ * 忽略配置（/user/profiles）
+ * 创建任务时传 profileId 引用，配置的规则与请求中的 ignoredFields 同时生效
+ */
+@Injectable()
+export class IgnoreProfileService {
+  private readonly maxProfilesPerUser: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly profileRepository: IgnoreProfileRepository,
+  ) {
+    this.maxProfilesPerUser = Number(this.configService.get('IGNORE_PROFILES_MAX_PER_USER', 50));
+  }
+
+  async create(userId: string, dto: IgnoreProfileDto): Promise<IgnoreProfile> {
+    const rules = this.toRules(dto);
+    const profiles = this.profileRepository.forUser(userId);
+    if ((await profiles.count()) >= this.maxProfilesPerUser) {
+      throw new BadRequestException(`At most ${this.maxProfilesPerUser} ignore profiles are allowed per account`);
+    }
+    await this.assertNameAvailable(userId, dto.name);
+
+    return profiles.insert({ id: uuidv4(), userId, name: dto.name, ...rules });
+  }
+
+  async list(userId: string): Promise<IgnoreProfile[]> {
+    return this.profileRepository.forUser(userId).list({}, { orderBy: { name: 'ASC' } });
+  }
+
+  async get(userId: string, id: string): Promise<IgnoreProfile> {
+    return this.profileRepository.forUser(userId).getOrFail({ id }, 'Ignore profile not found');
+  }
+
+  /**
+   * 整体替换配置的规则；已创建的任务使用创建时的快照，不受影响
+   */
+  async update(userId: string, id: string, dto: IgnoreProfileDto): Promise<IgnoreProfile> {
+    const profile = await this.get(userId, id);
+    const rules = this.toRules(dto);
+    if (dto.name !== profile.name) {
+      await this.assertNameAvailable(userId, dto.name);
+    }
+    // 规则中未提供的种类为 undefined，覆盖原有的值
+    Object.assign(profile, { name: dto.name, ...rules });
+    await this.profileRepository.save(profile);
+    return profile;
+  }
+
+  async remove(userId: string, id: string): Promise<void> {
+    await this.profileRepository.delete(await this.get(userId, id));
+  }
+
+  /**
+   * 创建任务时读取配置的规则快照
+   */
+  async resolve(userId: string, id: string): Promise<IgnoreRules> {
+    const { fields, paths, patterns, include, skip } = await this.get(userId, id);
+    return { fields, paths, patterns, include, skip: skip as SkipHeuristic[] };
+  }
+
+  private toRules(dto: IgnoreProfileDto): IgnoreRules {
+    for (const pattern of dto.patterns ?? []) {
+      try {
+        new RegExp(pattern);
+      } catch (error) {
+        throw new BadRequestException(`Invalid pattern ${pattern}: ${error.message}`);
+      }
+    }
+    const rules: IgnoreRules = {
+      fields: unique(dto.fields?.map((field) => field.trim()).filter(Boolean)),
+      paths: unique(dto.paths),
+      patterns: unique(dto.patterns),
+      include: unique(dto.include),
+      skip: unique(dto.skip) as SkipHeuristic[] | undefined,
+    };
+    if (!Object.values(rules).some(Boolean)) {
+      throw new BadRequestException('An ignore profile needs at least one rule');
+    }
+    return rules;
+  }
+
+  private async assertNameAvailable(userId: string, name: string): Promise<void> {
+    if (await this.profileRepository.forUser(userId).get({ name })) {
+      throw new ConflictException(`An ignore profile named ${name} already exists`);
+    }
+  }
+}
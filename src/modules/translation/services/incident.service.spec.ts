@@ -0,0 +1,128 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { BadRequestException } from '@nestjs/common';
+import { IncidentService } from './incident.service';
+import {
+  IncidentRepository,
+  TranslationTaskRepository,
+  UserJsonDataRepository,
+} from '../repositories/translation-task.repository';
+
+describe('IncidentService', () => {
+  let service: IncidentService;
+
+  const outage = {
+    id: 'incident1',
+    reference: 'INC-42',
+    message: 'Translations may be delayed due to provider incident INC-42',
+    startsAt: new Date('2026-10-16T10:00:00Z'),
+    endsAt: new Date('2026-10-16T11:00:00Z'),
+  };
+
+  const mockIncidentRepository = {
+    insert: jest.fn(async (data) => ({ ...data })),
+    list: jest.fn().mockResolvedValue([]),
+    getOrFail: jest.fn(),
+    update: jest.fn(async (entity, changes) => Object.assign(entity, changes)),
+    delete: jest.fn(),
+  };
+
+  const mockTaskRepository = {
+    list: jest.fn().mockResolvedValue([]),
+  };
+
+  const mockUserJsonDataRepository = {
+    get: jest.fn(),
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        IncidentService,
+        { provide: IncidentRepository, useValue: mockIncidentRepository },
+        { provide: TranslationTaskRepository, useValue: mockTaskRepository },
+        { provide: UserJsonDataRepository, useValue: mockUserJsonDataRepository },
+      ],
+    }).compile();
+
+    service = module.get<IncidentService>(IncidentService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('声明故障时未填说明使用默认说明，结束时间早于开始时间时拒绝', async () => {
+    const incident = await service.declare({ reference: 'INC-42', startsAt: '2026-10-16T10:00:00Z' }, 'admin1');
+    expect(incident).toMatchObject({
+      reference: 'INC-42',
+      message: 'Translations may be delayed due to provider incident INC-42',
+      endsAt: null,
+      ongoing: true,
+      createdBy: 'admin1',
+    });
+
+    await expect(
+      service.declare(
+        { reference: 'INC-43', startsAt: '2026-10-16T10:00:00Z', endsAt: '2026-10-16T09:00:00Z' },
+        'admin1',
+      ),
+    ).rejects.toThrow(BadRequestException);
+  });
+
+  it('未结束的任务按到当前为止的时间窗匹配故障', async () => {
+    mockIncidentRepository.list.mockResolvedValueOnce([{ ...outage, endsAt: undefined }]);
+    const task: any = { id: 'task1', userId: 'user1', status: 'pending', createdAt: new Date('2026-10-16T09:00:00Z') };
+
+    const incidents = await service.forTask(task);
+
+    expect(incidents).toEqual([expect.objectContaining({ reference: 'INC-42', ongoing: true, provider: null })]);
+    expect(mockIncidentRepository.list).toHaveBeenCalledWith(
+      { startsAt: { $lte: expect.any(Date) }, $or: [{ endsAt: null }, { endsAt: { $gte: task.createdAt } }] },
+      { orderBy: { startsAt: 'ASC' } },
+    );
+    expect(mockUserJsonDataRepository.get).not.toHaveBeenCalled();
+  });
+
+  it('限定服务商的故障只标注使用该服务商的任务', async () => {
+    const scoped = { ...outage, provider: 'deepl' };
+    mockIncidentRepository.list.mockResolvedValueOnce([scoped]).mockResolvedValueOnce([scoped]);
+    const task: any = {
+      id: 'task1',
+      userId: 'user1',
+      status: 'completed',
+      createdAt: new Date('2026-10-16T09:30:00Z'),
+      updatedAt: new Date('2026-10-16T10:30:00Z'),
+    };
+
+    mockUserJsonDataRepository.get.mockResolvedValueOnce({ id: 'task1', provider: null });
+    expect(await service.forTask(task)).toEqual([]);
+
+    mockUserJsonDataRepository.get.mockResolvedValueOnce({ id: 'task1', provider: 'deepl' });
+    expect(await service.forTask(task)).toHaveLength(1);
+  });
+
+  it('文档列表按各自任务的时间窗标注，故障前已完成的文档不标注', async () => {
+    mockIncidentRepository.list.mockResolvedValueOnce([outage]);
+    mockTaskRepository.list.mockResolvedValueOnce([
+      {
+        id: 'doc1',
+        status: 'completed',
+        createdAt: new Date('2026-10-16T08:00:00Z'),
+        updatedAt: new Date('2026-10-16T08:05:00Z'),
+      },
+      {
+        id: 'doc2',
+        status: 'completed',
+        createdAt: new Date('2026-10-16T10:10:00Z'),
+        updatedAt: new Date('2026-10-16T12:00:00Z'),
+      },
+    ]);
+    const documents: any[] = [{ id: 'doc1' }, { id: 'doc2' }];
+
+    const incidents = await service.forDocuments(documents);
+
+    expect(incidents.get('doc1')).toEqual([]);
+    expect(incidents.get('doc2')).toEqual([expect.objectContaining({ reference: 'INC-42', ongoing: false })]);
+    expect(mockIncidentRepository.list).toHaveBeenCalledTimes(1);
+  });
+});
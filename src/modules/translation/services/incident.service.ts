@@ -0,0 +1,177 @@
+import { BadRequestException, Injectable, Logger } from '@nestjs/common';
+import { v4 as uuidv4 } from 'uuid';
+import { Incident } from '../entities/incident.entity';
+import { TranslationTask, UserJsonData } from '../entities/translation-task.entity';
+import { IncidentDto, ResolveIncidentDto } from '../dto/incident.dto';
+import {
+  IncidentRepository,
+  TranslationTaskRepository,
+  UserJsonDataRepository,
+} from '../repositories/translation-task.repository';
+import { DEFAULT_TRANSLATION_PROVIDER } from '../../../config/providers';
+
+/** 仍在排队或执行中的任务状态，这些任务的时间窗延续到当前 */
+const ACTIVE_STATUSES = new Set(['pending', 'processing', 'scheduled', 'paused']);
+
+/** 附在任务状态、结果和文档列表上的故障说明 */
+export interface IncidentNotice {
+  reference: string;
+  message: string;
+  provider: string | null;
+  startsAt: Date;
+  endsAt: Date | null;
+  ongoing: boolean;
+}
+
+interface IncidentSubject {
+  provider?: string | null;
+  from: Date;
+  to: Date;
+}
+
+/**
+ * 故障说明
+ * 运维通过管理接口声明故障时间窗，任务从创建到结束（未结束的任务到当前）与窗口有重叠、且使用受影响的服务商时，
+ * 任务状态、结果和文档列表中附带故障编号和说明，用户不必再为同一次故障提交工单
+ */
+@Injectable()
+export class IncidentService {
+  private readonly logger = new Logger(IncidentService.name);
+
+  constructor(
+    private readonly incidentRepository: IncidentRepository,
+    private readonly taskRepository: TranslationTaskRepository,
+    private readonly userJsonDataRepository: UserJsonDataRepository,
+  ) {}
+
+  async declare(dto: IncidentDto, adminId: string) {
+    const startsAt = dto.startsAt ? new Date(dto.startsAt) : new Date();
+    const endsAt = dto.endsAt ? new Date(dto.endsAt) : undefined;
+    assertWindow(startsAt, endsAt);
+    const incident = await this.incidentRepository.insert({
+      id: uuidv4(),
+      reference: dto.reference,
+      message: dto.message?.trim() || `Translations may be delayed due to provider incident ${dto.reference}`,
+      provider: dto.provider,
+      startsAt,
+      endsAt,
+      createdBy: adminId,
+    } as Incident);
+    this.logger.log(`Incident ${incident.reference} declared by ${adminId} (${incident.provider ?? 'all providers'})`);
+    return toIncidentView(incident);
+  }
+
+  async list(limit = 50) {
+    const incidents = await this.incidentRepository.list(
+      {},
+      { limit: Math.min(Math.max(limit, 1), 200), orderBy: { startsAt: 'DESC' } },
+    );
+    return incidents.map(toIncidentView);
+  }
+
+  async resolve(id: string, dto: ResolveIncidentDto) {
+    const incident = await this.incidentRepository.getOrFail({ id }, 'Incident not found');
+    const endsAt = dto.endsAt ? new Date(dto.endsAt) : new Date();
+    assertWindow(incident.startsAt, endsAt);
+    await this.incidentRepository.update(incident, { endsAt });
+    this.logger.log(`Incident ${incident.reference} resolved`);
+    return toIncidentView(incident);
+  }
+
+  /** 删除误报的故障，之后不再出现在任何任务上 */
+  async remove(id: string): Promise<void> {
+    await this.incidentRepository.delete(await this.incidentRepository.getOrFail({ id }, 'Incident not found'));
+  }
+
+  /**
+   * 影响某个任务的故障；只有命中限定服务商的故障时才读取文档上的服务商
+   */
+  async forTask(task: TranslationTask): Promise<IncidentNotice[]> {
+    const subject = { from: task.createdAt, to: taskEnd(task) };
+    const incidents = await this.overlapping(subject.from, subject.to);
+    if (incidents.some((incident) => incident.provider)) {
+      const userData = await this.userJsonDataRepository.get({ id: task.id, userId: task.userId });
+      return this.match(incidents, { ...subject, provider: userData?.provider });
+    }
+    return this.match(incidents, subject);
+  }
+
+  /**
+   * 文档列表中每个文档受影响的故障（文档 ID → 故障），一页文档只查询一次任务和故障
+   */
+  async forDocuments(documents: UserJsonData[]): Promise<Map<string, IncidentNotice[]>> {
+    const result = new Map<string, IncidentNotice[]>();
+    if (documents.length === 0) {
+      return result;
+    }
+    const tasks = await this.taskRepository.list({ id: { $in: documents.map((document) => document.id) } });
+    const tasksById = new Map(tasks.map((task) => [task.id, task]));
+    const subjects = documents.map((document) => {
+      const task = tasksById.get(document.id);
+      return {
+        id: document.id,
+        provider: document.provider,
+        from: task?.createdAt ?? document.createdAt,
+        to: task ? taskEnd(task) : document.updatedAt,
+      };
+    });
+
+    const from = new Date(Math.min(...subjects.map((subject) => subject.from.getTime())));
+    const to = new Date(Math.max(...subjects.map((subject) => subject.to.getTime())));
+    const incidents = await this.overlapping(from, to);
+    for (const subject of subjects) {
+      result.set(subject.id, incidents.length ? this.match(incidents, subject) : []);
+    }
+    return result;
+  }
+
+  private async overlapping(from: Date, to: Date): Promise<Incident[]> {
+    return this.incidentRepository.list(
+      { startsAt: { $lte: to }, $or: [{ endsAt: null }, { endsAt: { $gte: from } }] },
+      { orderBy: { startsAt: 'ASC' } },
+    );
+  }
+
+  private match(incidents: Incident[], subject: IncidentSubject): IncidentNotice[] {
+    const provider = subject.provider || DEFAULT_TRANSLATION_PROVIDER;
+    return incidents
+      .filter(
+        (incident) =>
+          incident.startsAt <= subject.to &&
+          (!incident.endsAt || incident.endsAt >= subject.from) &&
+          (!incident.provider || incident.provider === provider),
+      )
+      .map(toNotice);
+  }
+}
+
+function taskEnd(task: TranslationTask): Date {
+  return ACTIVE_STATUSES.has(task.status) ? new Date() : task.updatedAt;
+}
+
+function assertWindow(startsAt: Date, endsAt?: Date): void {
+  if (endsAt && endsAt < startsAt) {
+    throw new BadRequestException('Incident endsAt must not be before startsAt');
+  }
+}
+
+function toNotice(incident: Incident): IncidentNotice {
+  return {
+    reference: incident.reference,
+    message: incident.message,
+    provider: incident.provider ?? null,
+    startsAt: incident.startsAt,
+    endsAt: incident.endsAt ?? null,
+    ongoing: !incident.endsAt || incident.endsAt > new Date(),
+  };
+}
+
+function toIncidentView(incident: Incident) {
+  return {
+    id: incident.id,
+    ...toNotice(incident),
+    createdBy: incident.createdBy,
+    createdAt: incident.createdAt,
+    updatedAt: incident.updatedAt,
+  };
+}
@@ -0,0 +1,128 @@
+import { Injectable } from '@nestjs/common';
+import { v4 as uuidv4 } from 'uuid';
+import { TranslationTaskRepository, UserJsonDataRepository } from '../repositories/translation-task.repository';
+import { TranslationUtils } from '../utils/translation.utils';
+import { diffJson, JsonPath, mergeTranslation, pathKey, pickPaths } from '../utils/json-diff';
+import { QuotaService } from './quota.service';
+import { UsageRollupService } from './usage-rollup.service';
+import { StorageLimitService } from './storage-limit.service';
+import { DEFAULT_TRANSLATION_PROVIDER } from '../../../config/providers';
+
+export interface IncrementalTranslationInput {
+  userId: string;
+  tenantId?: string;
+  fromLang: string;
+  targetLang: string;
+  ignoredFields?: string;
+  /** 新的完整源文档 */
+  source: Record<string, any>;
+  /** 新源文档的原始文本，记录到任务中 */
+  raw: string;
+  /** 相对上一版源文档变化的叶子 */
+  changed: JsonPath[];
+  /** 上一版完整译文；缺省时整篇翻译 */
+  previousTranslation?: any;
+}
+
+export interface IncrementalTranslationResult {
+  translation: Record<string, any>;
+  /** 没有需要翻译的键时不创建任务 */
+  taskId?: string;
+  charTotal: number;
+}
+
+/**
+ * 增量翻译
+ * 只翻译源文档中变化的键，再按新源文档的结构与上一版译文合并；
+ * 远程源同步和 GitHub 集成共用，每次翻译记录为一个翻译任务并按变化部分计费
+ */
+@Injectable()
+export class IncrementalTranslationService {
+  constructor(
+    private readonly taskRepository: TranslationTaskRepository,
+    private readonly userJsonDataRepository: UserJsonDataRepository,
+    private readonly translationUtils: TranslationUtils,
+    private readonly quotaService: QuotaService,
+    private readonly usageRollupService: UsageRollupService,
+    private readonly storageLimitService: StorageLimitService,
+  ) {}
+
+  async translate(input: IncrementalTranslationInput): Promise<IncrementalTranslationResult> {
+    const changed = input.previousTranslation === undefined ? diffJson({}, input.source).changed : input.changed;
+    if (changed.length === 0) {
+      return { translation: mergeTranslation(input.source, input.previousTranslation, {}, new Set()), charTotal: 0 };
+    }
+
+    const delta = JSON.stringify(pickPaths(input.source, changed));
+    const charTotal = this.translationUtils.countJsonChars(delta, {
+      sourceData: JSON.parse(delta),
+      sourceLang: input.fromLang,
+      targetLang: input.targetLang,
+      ignoredFields: this.translationUtils.getIgnoredFields(input.ignoredFields || ''),
+    });
+    await this.storageLimitService.assertCanStore(input.userId, Buffer.byteLength(input.raw));
+    const reservedAt = new Date();
+    await this.quotaService.assertWithinQuota(input.userId, charTotal, input.tenantId);
+
+    // 预留在记账时随用量事件结算，翻译或保存失败时释放
+    try {
+      const translated = JSON.parse(
+        await this.translationUtils.translateJson(delta, input.fromLang, input.targetLang, input.ignoredFields || '', {
+          userId: input.userId,
+        }),
+      );
+      const translation = mergeTranslation(
+        input.source,
+        input.previousTranslation,
+        translated,
+        new Set(changed.map(pathKey)),
+      );
+      const taskId = await this.recordTask(input, delta, charTotal, reservedAt, JSON.stringify(translation, null, 2));
+      return { translation, taskId, charTotal };
+    } catch (error) {
+      await this.quotaService.release(input.userId, charTotal, reservedAt);
+      throw error;
+    }
+  }
+
+  private async recordTask(
+    input: IncrementalTranslationInput,
+    delta: string,
+    charTotal: number,
+    reservedAt: Date,
+    translatedJson: string,
+  ): Promise<string> {
+    const id = uuidv4();
+    const task = this.taskRepository.build({
+      id,
+      userId: input.userId,
+      content: delta,
+      status: 'pending',
+      isTranslated: true,
+      charTotal,
+      tenantId: input.tenantId,
+    });
+    const userData = this.userJsonDataRepository.build({
+      id,
+      userId: input.userId,
+      originJson: input.raw,
+      fromLang: input.fromLang,
+      toLang: input.targetLang,
+      ignoredFields: input.ignoredFields,
+      provider: DEFAULT_TRANSLATION_PROVIDER,
+      translatedJson,
+      charTotal,
+    });
+    await this.taskRepository.save([task, userData]);
+    await this.usageRollupService.publish({
+      taskId: id,
+      userId: input.userId,
+      tenantId: input.tenantId,
+      characters: charTotal,
+      occurredAt: new Date().toISOString(),
+      reserved: charTotal,
+      reservedAt: reservedAt.toISOString(),
+    });
+    return id;
+  }
+}
@@ -0,0 +1,47 @@
+import { BadRequestException, Injectable, PayloadTooLargeException } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { assertJsonWithinLimits, JsonLimitError, JsonLimits } from '../utils/json-limits';
+
+/**
+ * 按 JSON_MAX_BYTES / JSON_MAX_DEPTH / JSON_MAX_KEYS 检查待解析的文档
+ * 所有接收外部 JSON 文档的入口（翻译、项目翻译、GitHub 和 URL 同步、历史导入）在 JSON.parse 之前调用
+ */
+@Injectable()
+export class JsonLimitsService {
+  readonly limits: JsonLimits;
+
+  constructor(private readonly configService: ConfigService) {
+    this.limits = {
+      maxBytes: Number(this.configService.get('JSON_MAX_BYTES', 10 * 1024 * 1024)),
+      maxDepth: Number(this.configService.get('JSON_MAX_DEPTH', 32)),
+      maxKeys: Number(this.configService.get('JSON_MAX_KEYS', 100000)),
+    };
+  }
+
+  /**
+   * 返回超出的限制，未超出时返回 undefined
+   */
+  check(raw: string): JsonLimitError | undefined {
+    try {
+      assertJsonWithinLimits(raw ?? '', this.limits);
+      return undefined;
+    } catch (error) {
+      if (error instanceof JsonLimitError) {
+        return error;
+      }
+      throw error;
+    }
+  }
+
+  /**
+   * 超出大小时抛出 413，超出嵌套深度或键数量时抛出 400
+   */
+  assert(raw: string): void {
+    const error = this.check(raw);
+    if (error) {
+      throw error.limit === 'bytes'
+        ? new PayloadTooLargeException(error.message)
+        : new BadRequestException(error.message);
+    }
+  }
+}
@@ -0,0 +1,131 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { getQueueToken } from '@nestjs/bull';
+import { NotFoundException } from '@nestjs/common';
+import { LanguageBackfillService, LANGUAGE_BACKFILL_JOB } from './language-backfill.service';
+import { TranslationService } from '../translation.service';
+import { DocumentArchiveService } from './document-archive.service';
+import { UserJsonDataRepository } from '../repositories/translation-task.repository';
+
+describe('LanguageBackfillService', () => {
+  let service: LanguageBackfillService;
+
+  const mockTranslationQueue = {
+    add: jest.fn(async (name, data, options) => ({ id: options.jobId })),
+    getJob: jest.fn(),
+  };
+
+  const mockTranslationService = {
+    detectDocumentLanguage: jest.fn(),
+  };
+
+  const mockDocumentArchiveService = {
+    load: jest.fn(async (document) => ({ originJson: document.originJson, archived: false })),
+  };
+
+  const mockUserJsonDataRepository = {
+    list: jest.fn(),
+    save: jest.fn(),
+  };
+
+  const job = (data: Record<string, any>) => ({ id: 'language-backfill:1', data, progress: jest.fn() }) as any;
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        LanguageBackfillService,
+        { provide: ConfigService, useValue: { get: jest.fn((key, defaultValue) => defaultValue) } },
+        { provide: getQueueToken('translation'), useValue: mockTranslationQueue },
+        { provide: TranslationService, useValue: mockTranslationService },
+        { provide: DocumentArchiveService, useValue: mockDocumentArchiveService },
+        { provide: UserJsonDataRepository, useValue: mockUserJsonDataRepository },
+      ],
+    }).compile();
+
+    service = module.get<LanguageBackfillService>(LanguageBackfillService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('提交任务时补全默认选项，limit 不超过上限', async () => {
+    mockTranslationQueue.getJob.mockResolvedValueOnce({
+      id: 'language-backfill:x',
+      data: {},
+      progress: () => 0,
+      getState: async () => 'waiting',
+      timestamp: Date.now(),
+    });
+
+    await service.start({ userId: 'user1', limit: 50000 });
+
+    expect(mockTranslationQueue.add).toHaveBeenCalledWith(
+      LANGUAGE_BACKFILL_JOB,
+      { userId: 'user1', includeSuspicious: true, recheck: false, limit: 10000 },
+      expect.objectContaining({ jobId: expect.stringMatching(/^language-backfill:/), attempts: 1 }),
+    );
+  });
+
+  it('其他队列任务的 ID 不能作为检测任务查询', async () => {
+    await expect(service.getJob('123')).rejects.toThrow(NotFoundException);
+    expect(mockTranslationQueue.getJob).not.toHaveBeenCalled();
+  });
+
+  it('按 ID 分页检测并记录语言和置信度，不修改 fromLang', async () => {
+    const documents: any[] = [
+      { id: 'doc1', fromLang: 'auto', originJson: '{"a":"Bonjour"}' },
+      { id: 'doc2', fromLang: 'en', detectionConfidence: 0.65, originJson: '{"a":"Hallo Welt"}' },
+      { id: 'doc3', fromLang: '', originJson: '{}' },
+      { id: 'doc4', fromLang: '', originJson: 'not json' },
+    ];
+    mockUserJsonDataRepository.list.mockResolvedValueOnce(documents).mockResolvedValueOnce([]);
+    mockTranslationService.detectDocumentLanguage
+      .mockResolvedValueOnce({ language: 'fr', confidence: 0.9 })
+      .mockResolvedValueOnce({ language: 'de', confidence: 1 })
+      .mockResolvedValueOnce(null);
+    const backfill = job({ includeSuspicious: true, recheck: false, limit: 100 });
+
+    const result = await service.run(backfill);
+
+    expect(result).toEqual({ scanned: 4, detected: 2, undetermined: 1, failed: 1, mismatched: ['doc2'] });
+    expect(documents[0]).toMatchObject({ fromLang: 'auto', detectedLang: 'fr', detectedLangConfidence: 0.9 });
+    expect(documents[2].languageCheckedAt).toBeInstanceOf(Date);
+    expect(documents[3].languageCheckedAt).toBeUndefined();
+    expect(mockUserJsonDataRepository.list).toHaveBeenNthCalledWith(
+      1,
+      {
+        languageCheckedAt: null,
+        purgedAt: null,
+        $or: [{ fromLang: { $in: ['', 'auto'] } }, { fromLang: null }, { detectionConfidence: { $lt: 0.8 } }],
+        id: { $gt: '' },
+      },
+      { limit: 100, orderBy: { id: 'ASC' } },
+    );
+    expect(mockUserJsonDataRepository.list).toHaveBeenNthCalledWith(
+      2,
+      expect.objectContaining({ id: { $gt: 'doc4' } }),
+      expect.anything(),
+    );
+    expect(backfill.progress).toHaveBeenCalledWith(4);
+  });
+
+  it('达到 limit 后停止', async () => {
+    mockUserJsonDataRepository.list.mockResolvedValueOnce([{ id: 'doc1', fromLang: '', originJson: '{"a":"Hi"}' }]);
+    mockTranslationService.detectDocumentLanguage.mockResolvedValueOnce({ language: 'en', confidence: 1 });
+
+    const result = await service.run(job({ userId: 'user1', includeSuspicious: false, recheck: true, limit: 1 }));
+
+    expect(result.scanned).toBe(1);
+    expect(mockUserJsonDataRepository.list).toHaveBeenCalledTimes(1);
+    expect(mockUserJsonDataRepository.list).toHaveBeenCalledWith(
+      {
+        userId: 'user1',
+        purgedAt: null,
+        $or: [{ fromLang: { $in: ['', 'auto'] } }, { fromLang: null }],
+        id: { $gt: '' },
+      },
+      { limit: 1, orderBy: { id: 'ASC' } },
+    );
+  });
+});
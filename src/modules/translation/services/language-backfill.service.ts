@@ -0,0 +1,163 @@
+import { Injectable, Logger, NotFoundException } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { InjectQueue } from '@nestjs/bull';
+import { Job, Queue } from 'bull';
+import { v4 as uuidv4 } from 'uuid';
+import { AUTO_DETECT_LANGUAGE, TranslationService } from '../translation.service';
+import { DocumentArchiveService } from './document-archive.service';
+import { UserJsonDataRepository } from '../repositories/translation-task.repository';
+import { UserJsonData } from '../entities/translation-task.entity';
+
+export const LANGUAGE_BACKFILL_JOB = 'detect-document-languages';
+
+/** 每次任务最多记录的检测结果与 fromLang 不一致的文档数 */
+const MAX_REPORTED_MISMATCHES = 100;
+
+export interface LanguageBackfillJob {
+  userId?: string;
+  /** 同时检测自动检测置信度低于 LANGUAGE_BACKFILL_SUSPICIOUS_CONFIDENCE 的文档 */
+  includeSuspicious: boolean;
+  /** 重新检测已检测过的文档 */
+  recheck: boolean;
+  limit: number;
+}
+
+export interface LanguageBackfillResult {
+  scanned: number;
+  detected: number;
+  /** 没有可翻译的字符串，或样本都无法识别 */
+  undetermined: number;
+  failed: number;
+  /** 检测结果与 fromLang 不一致的文档 ID（最多 MAX_REPORTED_MISMATCHES 个） */
+  mismatched: string[];
+}
+
+/**
+ * 已保存文档的批量语言检测
+ * 管理员提交后在 worker 中按文档 ID 分页处理缺少源语言（空或 auto）的文档，可选包括自动检测置信度偏低的文档；
+ * 抽样检测原文（归档的文档从对象存储取回），把语言和置信度记录在 detectedLang / detectedLangConfidence，
+ * 不修改 fromLang。检测调用走服务商限流，已检测过的文档默认跳过，任务中断后重新提交即可继续
+ */
+@Injectable()
+export class LanguageBackfillService {
+  private readonly logger = new Logger(LanguageBackfillService.name);
+  private readonly batchSize: number;
+  private readonly maxDocuments: number;
+  private readonly suspiciousConfidence: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    @InjectQueue('translation') private readonly translationQueue: Queue,
+    private readonly translationService: TranslationService,
+    private readonly documentArchiveService: DocumentArchiveService,
+    private readonly userJsonDataRepository: UserJsonDataRepository,
+  ) {
+    this.batchSize = Math.max(Number(this.configService.get('LANGUAGE_BACKFILL_BATCH_SIZE', 100)), 1);
+    this.maxDocuments = Math.max(Number(this.configService.get('LANGUAGE_BACKFILL_MAX_DOCUMENTS', 10000)), 1);
+    this.suspiciousConfidence = Number(this.configService.get('LANGUAGE_BACKFILL_SUSPICIOUS_CONFIDENCE', 0.8));
+  }
+
+  async start(options: Partial<LanguageBackfillJob> = {}) {
+    const data: LanguageBackfillJob = {
+      userId: options.userId,
+      includeSuspicious: options.includeSuspicious ?? true,
+      recheck: options.recheck ?? false,
+      limit: Math.min(Math.max(options.limit ?? this.maxDocuments, 1), this.maxDocuments),
+    };
+    const job = await this.translationQueue.add(LANGUAGE_BACKFILL_JOB, data, {
+      jobId: `language-backfill:${uuidv4()}`,
+      attempts: 1,
+    });
+    this.logger.log(`Language backfill ${job.id} queued (${data.userId ?? 'all users'}, limit ${data.limit})`);
+    return this.getJob(String(job.id));
+  }
+
+  async getJob(jobId: string) {
+    const job = jobId.startsWith('language-backfill:') ? await this.translationQueue.getJob(jobId) : null;
+    if (!job) {
+      throw new NotFoundException('Language backfill job not found');
+    }
+    return {
+      id: String(job.id),
+      state: await job.getState(),
+      options: job.data,
+      scanned: Number(job.progress()) || 0,
+      result: (job.returnvalue as LanguageBackfillResult) ?? null,
+      failedReason: job.failedReason ?? null,
+      createdAt: new Date(job.timestamp),
+      finishedAt: job.finishedOn ? new Date(job.finishedOn) : null,
+    };
+  }
+
+  async run(job: Job<LanguageBackfillJob>): Promise<LanguageBackfillResult> {
+    const { limit } = job.data;
+    const result: LanguageBackfillResult = { scanned: 0, detected: 0, undetermined: 0, failed: 0, mismatched: [] };
+    let cursor = '';
+    while (result.scanned < limit) {
+      const documents = await this.userJsonDataRepository.list(
+        { ...this.candidateFilter(job.data), id: { $gt: cursor } },
+        { limit: Math.min(this.batchSize, limit - result.scanned), orderBy: { id: 'ASC' } },
+      );
+      if (documents.length === 0) {
+        break;
+      }
+      for (const document of documents) {
+        await this.detect(document, result);
+        result.scanned++;
+      }
+      await this.userJsonDataRepository.save(documents);
+      cursor = documents[documents.length - 1].id;
+      await job.progress(result.scanned);
+    }
+    this.logger.log(
+      `Language backfill ${job.id} finished: ${result.scanned} scanned, ${result.detected} detected, ` +
+        `${result.undetermined} undetermined, ${result.failed} failed`,
+    );
+    return result;
+  }
+
+  private candidateFilter({ userId, includeSuspicious, recheck }: LanguageBackfillJob): Record<string, any> {
+    const conditions: Record<string, any>[] = [{ fromLang: { $in: ['', AUTO_DETECT_LANGUAGE] } }, { fromLang: null }];
+    if (includeSuspicious) {
+      conditions.push({ detectionConfidence: { $lt: this.suspiciousConfidence } });
+    }
+    return {
+      ...(userId && { userId }),
+      ...(!recheck && { languageCheckedAt: null }),
+      purgedAt: null,
+      $or: conditions,
+    };
+  }
+
+  private async detect(document: UserJsonData, result: LanguageBackfillResult): Promise<void> {
+    try {
+      const { originJson } = await this.documentArchiveService.load(document);
+      const detection = await this.translationService.detectDocumentLanguage(
+        JSON.parse(originJson),
+        document.ignoredFields,
+        document.ignoreRules,
+      );
+      document.detectedLang = detection?.language || undefined;
+      document.detectedLangConfidence = detection?.language ? detection.confidence : undefined;
+      document.languageCheckedAt = new Date();
+      if (!document.detectedLang) {
+        result.undetermined++;
+        return;
+      }
+      result.detected++;
+      const declared = document.fromLang;
+      if (
+        declared &&
+        declared !== AUTO_DETECT_LANGUAGE &&
+        declared !== document.detectedLang &&
+        result.mismatched.length < MAX_REPORTED_MISMATCHES
+      ) {
+        result.mismatched.push(document.id);
+      }
+    } catch (error) {
+      // 不记录检测时间，下次提交时重试
+      result.failed++;
+      this.logger.warn(`Language detection failed for document ${document.id}: ${error.message}`);
+    }
+  }
+}
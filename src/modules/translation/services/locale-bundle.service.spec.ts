@@ -0,0 +1,43 @@
+import { BadRequestException } from '@nestjs/common';
+import { LocaleBundleService } from './locale-bundle.service';
+
+describe('LocaleBundleService', () => {
+  const service = new LocaleBundleService();
+  const source = { lang: 'en', json: '{"title":"Hello","nav":{"home":"Home","about":"About"}}' };
+
+  it('应按地区代码回退到父语言，再回退到源语言', () => {
+    const result = service.merge({
+      source,
+      translations: [
+        { lang: 'fr', json: '{"title":"Bonjour","nav":{"home":"Accueil"}}' },
+        { lang: 'fr-CA', json: '{"title":"Allô"}' },
+      ],
+    });
+
+    expect(result.bundle['fr-CA']).toEqual({ title: 'Allô', nav: { home: 'Accueil', about: 'About' } });
+    expect(result.report['fr-CA'].fallbackKeys).toEqual({ 'nav.home': 'fr', 'nav.about': 'en' });
+    expect(result.report.fr.fallbackKeys).toEqual({ 'nav.about': 'en' });
+  });
+
+  it('应使用显式回退链', () => {
+    const result = service.merge({
+      source,
+      translations: [
+        { lang: 'es', json: '{"nav":{"home":"Inicio"}}' },
+        { lang: 'pt-BR', json: '{"title":"Olá"}' },
+      ],
+      fallbacks: { 'pt-BR': ['es'] },
+    });
+
+    expect(result.report['pt-BR'].fallbackKeys['nav.home']).toBe('es');
+  });
+
+  it('回退链引用不存在的语言或语言重复时应拒绝', () => {
+    expect(() =>
+      service.merge({ source, translations: [{ lang: 'fr', json: '{}' }], fallbacks: { fr: ['it'] } }),
+    ).toThrow(BadRequestException);
+    expect(() =>
+      service.merge({ source, translations: [{ lang: 'en', json: '{}' }] }),
+    ).toThrow('Duplicate locale: en');
+  });
+});
@@ -0,0 +1,86 @@
+import { BadRequestException, Injectable } from '@nestjs/common';
+import { LocaleBundleDto } from '../dto/locale-bundle.dto';
+import { alignToSource, implicitFallbacks } from '../utils/locale-bundle';
+import { isPlainObject } from '../utils/json-diff';
+import { createZip } from '../../../common/utils/zip';
+
+export interface LocaleBundle {
+  /** 语言 → 文档（含源语言） */
+  bundle: Record<string, Record<string, any>>;
+  /** 每个目标语言缺失和多出的键，以及补齐的值来自哪个语言 */
+  report: Record<string, { missingKeys: string[]; extraKeys: string[]; fallbackKeys: Record<string, string> }>;
+}
+
+/**
+ * 把源文档和多份译文合并为多语言包，所有译文对齐到源文档的结构；
+ * 缺失的键沿回退链（fr-CA → fr → 源语言）补齐
+ */
+@Injectable()
+export class LocaleBundleService {
+  merge(dto: LocaleBundleDto): LocaleBundle {
+    const langs = [dto.source.lang, ...dto.translations.map((translation) => translation.lang)];
+    const duplicate = langs.find((lang, index) => langs.indexOf(lang) !== index);
+    if (duplicate) {
+      throw new BadRequestException(`Duplicate locale: ${duplicate}`);
+    }
+
+    const source = this.parse(dto.source.lang, dto.source.json);
+    const documents = new Map(
+      dto.translations.map((translation) => [translation.lang, this.parse(translation.lang, translation.json)]),
+    );
+    const result: LocaleBundle = { bundle: { [dto.source.lang]: source }, report: {} };
+    for (const [lang, translation] of documents) {
+      const { document, missingKeys, extraKeys, fallbackKeys } = alignToSource(source, translation, {
+        fillMissing: dto.fillMissing !== false,
+        fallbacks: this.fallbackChain(lang, dto, [...documents.keys()]).map((locale) => ({
+          locale,
+          document: documents.get(locale),
+        })),
+        sourceLocale: dto.source.lang,
+      });
+      result.bundle[lang] = document;
+      result.report[lang] = { missingKeys, extraKeys, fallbackKeys };
+    }
+    return result;
+  }
+
+  /**
+   * 回退链只在提交的译文中查找（源语言总是最后一环，不需要列出）；链中的语言自身缺失的键不再继续传递
+   */
+  private fallbackChain(lang: string, dto: LocaleBundleDto, available: string[]): string[] {
+    const explicit = dto.fallbacks?.[lang];
+    if (explicit === undefined) {
+      return implicitFallbacks(lang, available);
+    }
+    if (!Array.isArray(explicit) || explicit.some((locale) => typeof locale !== 'string')) {
+      throw new BadRequestException(`Fallbacks for ${lang} must be a list of locales`);
+    }
+    const unknown = explicit.find((locale) => locale !== dto.source.lang && !available.includes(locale));
+    if (unknown) {
+      throw new BadRequestException(`Fallback locale ${unknown} for ${lang} is not in the bundle`);
+    }
+    return explicit.filter((locale) => locale !== lang && locale !== dto.source.lang);
+  }
+
+  toZip(bundle: LocaleBundle['bundle']): Buffer {
+    return createZip(
+      Object.entries(bundle).map(([lang, document]) => ({
+        name: `${lang}.json`,
+        content: JSON.stringify(document, null, 2),
+      })),
+    );
+  }
+
+  private parse(lang: string, json: string): Record<string, any> {
+    let document: any;
+    try {
+      document = JSON.parse(json);
+    } catch {
+      throw new BadRequestException(`Invalid JSON for locale ${lang}`);
+    }
+    if (!isPlainObject(document)) {
+      throw new BadRequestException(`JSON for locale ${lang} must be an object`);
+    }
+    return document;
+  }
+}
@@ -0,0 +1,194 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { MonthlyUsageCounterService } from './monthly-usage-counter.service';
+import { RedisService } from '../../../common/services/redis.service';
+import {
+  CharacterUsageLogDailyRepository,
+  CharacterUsageLogRepository,
+} from '../repositories/character-usage.repository';
+import { TranslationTaskRepository } from '../repositories/translation-task.repository';
+import {
+  USAGE_EVENTS_KEY,
+  USAGE_EVENTS_PROCESSING_KEY,
+} from '../interfaces/character-usage-event.interface';
+
+describe('MonthlyUsageCounterService', () => {
+  let service: MonthlyUsageCounterService;
+
+  // 内存中的 Redis：计数器和事件列表，eval 按脚本的 key 数量模拟预留和结算
+  const store = new Map<string, number>();
+  const lists = new Map<string, string[]>();
+
+  const mockRedisClient = {
+    get: jest.fn(async (key: string) => (store.has(key) ? String(store.get(key)) : null)),
+    set: jest.fn(async (key: string, value: string, ..._options: unknown[]) => {
+      if (store.has(key)) {
+        return null;
+      }
+      store.set(key, Number(value));
+      return 'OK';
+    }),
+    eval: jest.fn(async (_script: string, numKeys: number, ...args: any[]) => {
+      if (numKeys === 1) {
+        const [key, characters] = args;
+        if (!store.has(key)) {
+          return null;
+        }
+        store.set(key, store.get(key) + Number(characters));
+        return store.get(key);
+      }
+      const [reservedKey, occurredKey, reserved, characters] = args;
+      if (reserved && store.has(reservedKey)) {
+        store.set(reservedKey, store.get(reservedKey) - Number(reserved));
+      }
+      if (characters && store.has(occurredKey)) {
+        store.set(occurredKey, store.get(occurredKey) + Number(characters));
+      }
+      return 1;
+    }),
+    lrange: jest.fn(async (key: string) => lists.get(key) ?? []),
+  };
+
+  const mockDailyUsageRepository = {
+    sumSince: jest.fn(),
+  };
+
+  const mockUsageLogRepository = {
+    list: jest.fn(),
+  };
+
+  const mockTaskRepository = {
+    list: jest.fn(),
+  };
+
+  const period = () => new Date().toISOString().slice(0, 7);
+  const key = (userId = 'user1') => `usage:monthly:${period()}:${userId}`;
+  const now = () => new Date().toISOString();
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        MonthlyUsageCounterService,
+        { provide: RedisService, useValue: { client: mockRedisClient } },
+        { provide: CharacterUsageLogDailyRepository, useValue: mockDailyUsageRepository },
+        { provide: CharacterUsageLogRepository, useValue: mockUsageLogRepository },
+        { provide: TranslationTaskRepository, useValue: mockTaskRepository },
+      ],
+    }).compile();
+
+    service = module.get<MonthlyUsageCounterService>(MonthlyUsageCounterService);
+    mockDailyUsageRepository.sumSince.mockResolvedValue(500);
+    mockUsageLogRepository.list.mockResolvedValue([]);
+    mockTaskRepository.list.mockResolvedValue([]);
+  });
+
+  afterEach(() => {
+    store.clear();
+    lists.clear();
+    jest.clearAllMocks();
+  });
+
+  it('计数器存在时只读 Redis，不查询数据库', async () => {
+    store.set(key(), 1234);
+
+    await expect(service.get('user1')).resolves.toBe(1234);
+    expect(mockDailyUsageRepository.sumSince).not.toHaveBeenCalled();
+  });
+
+  it('计数器不存在时按已汇总用量、未汇总事件和进行中任务的预留重建', async () => {
+    lists.set(USAGE_EVENTS_PROCESSING_KEY, [
+      JSON.stringify({ taskId: 't1', userId: 'user1', characters: 30, occurredAt: now() }),
+      JSON.stringify({ taskId: 't2', userId: 'user1', characters: 40, occurredAt: now() }),
+    ]);
+    lists.set(USAGE_EVENTS_KEY, [
+      JSON.stringify({ taskId: 't3', userId: 'user1', characters: 20, occurredAt: now() }),
+      JSON.stringify({ taskId: 't4', userId: 'user2', characters: 99, occurredAt: now() }),
+      JSON.stringify({ taskId: 't5', userId: 'user1', characters: 99, occurredAt: '2020-01-01T00:00:00.000Z' }),
+    ]);
+    // 处理中批次的 t1 已经写库，计入数据库用量
+    mockUsageLogRepository.list.mockResolvedValue([{ jsonId: 't1' }]);
+    mockTaskRepository.list.mockResolvedValue([{ quotaReserved: 100 }, { quotaReserved: 50 }]);
+
+    await expect(service.get('user1')).resolves.toBe(500 + 40 + 20 + 150);
+    expect(mockDailyUsageRepository.sumSince).toHaveBeenCalledWith('user1', `${period()}-01`);
+    expect(mockUsageLogRepository.list).toHaveBeenCalledWith({ jsonId: { $in: ['t1', 't2', 't3'] } });
+    expect(mockTaskRepository.list).toHaveBeenCalledWith(
+      expect.objectContaining({ userId: 'user1', quotaReserved: { $gt: 0 } }),
+    );
+    expect(mockRedisClient.set).toHaveBeenCalledWith(key(), '710', 'EX', expect.any(Number), 'NX');
+  });
+
+  it('并发重建时以先写入的为准', async () => {
+    mockRedisClient.set.mockImplementationOnce(async (setKey: string) => {
+      store.set(setKey, 520);
+      return null;
+    });
+
+    await expect(service.get('user1')).resolves.toBe(520);
+  });
+
+  it('并发预留原子累加，每个请求都看到之前的预留', async () => {
+    const totals = await Promise.all(Array.from({ length: 5 }, () => service.reserve('user1', 100)));
+
+    expect([...totals].sort((a, b) => a - b)).toEqual([600, 700, 800, 900, 1000]);
+    expect(store.get(key())).toBe(1000);
+
+    await service.release('user1', 100);
+    await service.release('user1', 100);
+    await expect(service.get('user1')).resolves.toBe(800);
+  });
+
+  it('发布后汇总前计数器过期，重建时不丢失未汇总的用量', async () => {
+    await service.reserve('user1', 100);
+    const event = {
+      taskId: 't1',
+      userId: 'user1',
+      characters: 90,
+      occurredAt: now(),
+      reserved: 100,
+      reservedAt: now(),
+    };
+    lists.set(USAGE_EVENTS_KEY, [JSON.stringify(event)]);
+    await service.record(event);
+    expect(store.get(key())).toBe(590);
+
+    store.delete(key());
+    await expect(service.get('user1')).resolves.toBe(590);
+
+    // 汇总写库后事件离开列表，再次过期重建结果不变
+    store.delete(key());
+    lists.clear();
+    mockDailyUsageRepository.sumSince.mockResolvedValue(590);
+    await expect(service.get('user1')).resolves.toBe(590);
+  });
+
+  it('跨月完成的任务从预留月份扣除预留，向用量月份累加', async () => {
+    store.set('usage:monthly:2026-10:user1', 1000);
+    store.set('usage:monthly:2026-11:user1', 0);
+
+    await service.record({
+      taskId: 't1',
+      userId: 'user1',
+      characters: 80,
+      occurredAt: '2026-11-01T00:01:00.000Z',
+      reserved: 100,
+      reservedAt: '2026-10-31T23:59:00.000Z',
+    });
+
+    expect(store.get('usage:monthly:2026-10:user1')).toBe(900);
+    expect(store.get('usage:monthly:2026-11:user1')).toBe(80);
+  });
+
+  it('结算时计数器不存在则跳过，不从 0 开始创建', async () => {
+    await service.record({ taskId: 't1', userId: 'user1', characters: 10, occurredAt: now() });
+
+    expect(store.has(key())).toBe(false);
+  });
+
+  it('Redis 不可用时读数据库，预留返回数据库用量加本次字符数', async () => {
+    mockRedisClient.get.mockRejectedValueOnce(new Error('ECONNREFUSED'));
+    mockRedisClient.eval.mockRejectedValueOnce(new Error('ECONNREFUSED'));
+
+    await expect(service.get('user1')).resolves.toBe(500);
+    await expect(service.reserve('user1', 100)).resolves.toBe(600);
+  });
+});
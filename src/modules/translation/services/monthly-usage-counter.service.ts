@@ -0,0 +1,217 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { RedisService } from '../../../common/services/redis.service';
+import {
+  CharacterUsageLogDailyRepository,
+  CharacterUsageLogRepository,
+} from '../repositories/character-usage.repository';
+import { TranslationTaskRepository } from '../repositories/translation-task.repository';
+import {
+  CharacterUsageEvent,
+  USAGE_EVENTS_KEY,
+  USAGE_EVENTS_PROCESSING_KEY,
+} from '../interfaces/character-usage-event.interface';
+
+/** 计数器保留到下个月之后，跨月的慢任务仍能结算上个月的预留 */
+const COUNTER_TTL_SECONDS = 40 * 24 * 3600;
+
+/**
+ * 计数器存在时累加并返回新值；不存在时返回 nil，由调用方重建后重试，避免从 0 开始少计当月用量
+ */
+const INCREMENT_IF_EXISTS_SCRIPT = `
+if redis.call('EXISTS', KEYS[1]) == 1 then
+  return redis.call('INCRBY', KEYS[1], ARGV[1])
+end
+return false
+`;
+
+/**
+ * 结算：从预留月份的计数器扣除预留，向用量月份的计数器加上实际用量；
+ * 不存在的计数器跳过，重建时会从数据库和未汇总的事件中算出同样的结果
+ */
+const SETTLE_SCRIPT = `
+local reserved = tonumber(ARGV[1])
+local characters = tonumber(ARGV[2])
+if reserved ~= 0 and redis.call('EXISTS', KEYS[1]) == 1 then
+  redis.call('DECRBY', KEYS[1], reserved)
+end
+if characters ~= 0 and redis.call('EXISTS', KEYS[2]) == 1 then
+  redis.call('INCRBY', KEYS[2], characters)
+end
+return 1
+`;
+
+/**
+ * 当月字符用量计数器
+ * 额度检查以 Redis 中的计数为准，计数 = 已汇总的用量 + 已发布未汇总的用量 + 进行中任务的预留：
+ * 准入时原子地 INCRBY 预留本次请求的字符数，超额拒绝或任务失败、取消时 DECRBY 释放，
+ * 任务完成发布用量事件时把预留结算为实际用量。并发的请求各自看到包含对方预留的结果，不会一起越过额度。
+ * 数据库仍由用量汇总定时批量写入；计数器不存在（月初、过期或被淘汰）时按上面的公式从数据库和 Redis 事件列表重建，
+ * Redis 不可用时直接读数据库（不预留）
+ */
+@Injectable()
+export class MonthlyUsageCounterService {
+  private readonly logger = new Logger(MonthlyUsageCounterService.name);
+
+  constructor(
+    private readonly redisService: RedisService,
+    private readonly dailyUsageRepository: CharacterUsageLogDailyRepository,
+    private readonly usageLogRepository: CharacterUsageLogRepository,
+    private readonly taskRepository: TranslationTaskRepository,
+  ) {}
+
+  /**
+   * 当月已使用（含预留）的字符数
+   */
+  async get(userId: string): Promise<number> {
+    const period = periodOf(new Date());
+    const key = counterKey(userId, period);
+    try {
+      const value = await this.redisService.client.get(key);
+      if (value !== null) {
+        return Number(value);
+      }
+      return await this.seed(key, userId, period);
+    } catch (error) {
+      this.logger.warn(`Reading usage counter for user ${userId} failed, using database: ${error.message}`);
+      return this.sumFromDatabase(userId, period);
+    }
+  }
+
+  /**
+   * 在当月计数器中预留字符数，返回预留后的总数；Redis 不可用时返回数据库用量加本次字符数（未预留）
+   */
+  async reserve(userId: string, characters: number): Promise<number> {
+    const period = periodOf(new Date());
+    const key = counterKey(userId, period);
+    try {
+      for (let attempt = 0; attempt < 2; attempt++) {
+        const value = await this.redisService.client.eval(INCREMENT_IF_EXISTS_SCRIPT, 1, key, characters);
+        if (value !== null) {
+          return Number(value);
+        }
+        await this.seed(key, userId, period);
+      }
+      throw new Error('counter disappeared right after it was rebuilt');
+    } catch (error) {
+      this.logger.warn(`Reserving quota for user ${userId} failed, using database: ${error.message}`);
+      return (await this.sumFromDatabase(userId, period)) + characters;
+    }
+  }
+
+  /**
+   * 释放预留（超额拒绝、任务失败或取消）
+   */
+  async release(userId: string, characters: number, reservedAt: Date | string = new Date()): Promise<void> {
+    await this.settle(userId, characters, reservedAt, 0, reservedAt);
+  }
+
+  /**
+   * 把用量事件结算到计数器：扣除任务的预留，加上实际用量
+   */
+  async record(event: CharacterUsageEvent): Promise<void> {
+    await this.settle(
+      event.userId,
+      event.reserved ?? 0,
+      event.reservedAt ?? event.occurredAt,
+      event.characters,
+      event.occurredAt,
+    );
+  }
+
+  private async settle(
+    userId: string,
+    reserved: number,
+    reservedAt: Date | string,
+    characters: number,
+    occurredAt: Date | string,
+  ): Promise<void> {
+    if (reserved <= 0 && characters <= 0) {
+      return;
+    }
+    try {
+      await this.redisService.client.eval(
+        SETTLE_SCRIPT,
+        2,
+        counterKey(userId, periodOf(reservedAt)),
+        counterKey(userId, periodOf(occurredAt)),
+        Math.max(reserved, 0),
+        Math.max(characters, 0),
+      );
+    } catch (error) {
+      this.logger.warn(`Updating usage counter for user ${userId} failed: ${error.message}`);
+    }
+  }
+
+  /**
+   * 重建计数器；并发重建时以先写入的为准
+   */
+  private async seed(key: string, userId: string, period: string): Promise<number> {
+    const [recorded, pending, reserved] = await Promise.all([
+      this.sumFromDatabase(userId, period),
+      this.sumPendingEvents(userId, period),
+      this.sumReservations(userId, period),
+    ]);
+    const used = recorded + pending + reserved;
+    if ((await this.redisService.client.set(key, String(used), 'EX', COUNTER_TTL_SECONDS, 'NX')) === 'OK') {
+      this.logger.log(`Rebuilt usage counter for user ${userId} (${period}): ${used}`);
+      return used;
+    }
+    return Number((await this.redisService.client.get(key)) ?? used);
+  }
+
+  private sumFromDatabase(userId: string, period: string): Promise<number> {
+    return this.dailyUsageRepository.sumSince(userId, `${period}-01`);
+  }
+
+  /**
+   * 已发布但还没写库的用量：待汇总列表和处理中的批次里该用户该月的事件，处理中批次已写库的任务除外
+   */
+  private async sumPendingEvents(userId: string, period: string): Promise<number> {
+    const client = this.redisService.client;
+    const [queued, processing] = await Promise.all([
+      client.lrange(USAGE_EVENTS_KEY, 0, -1),
+      client.lrange(USAGE_EVENTS_PROCESSING_KEY, 0, -1),
+    ]);
+    const events = new Map<string, CharacterUsageEvent>();
+    for (const item of [...processing, ...queued]) {
+      try {
+        const event: CharacterUsageEvent = JSON.parse(item);
+        if (event.userId === userId && periodOf(event.occurredAt) === period) {
+          events.set(event.taskId, event);
+        }
+      } catch {
+        // 格式错误的事件汇总时会丢弃
+      }
+    }
+    if (events.size === 0) {
+      return 0;
+    }
+    const recorded = await this.usageLogRepository.list({ jsonId: { $in: [...events.keys()] } });
+    for (const log of recorded) {
+      events.delete(log.jsonId);
+    }
+    return [...events.values()].reduce((sum, event) => sum + event.characters, 0);
+  }
+
+  /**
+   * 进行中任务在该月的预留
+   */
+  private async sumReservations(userId: string, period: string): Promise<number> {
+    const from = new Date(`${period}-01T00:00:00.000Z`);
+    const to = new Date(Date.UTC(from.getUTCFullYear(), from.getUTCMonth() + 1, 1));
+    const tasks = await this.taskRepository.list({
+      userId,
+      quotaReserved: { $gt: 0 },
+      quotaReservedAt: { $gte: from, $lt: to },
+    });
+    return tasks.reduce((sum, task) => sum + (task.quotaReserved ?? 0), 0);
+  }
+}
+
+function counterKey(userId: string, period: string): string {
+  return `usage:monthly:${period}:${userId}`;
+}
+
+function periodOf(value: Date | string): string {
+  return new Date(value).toISOString().slice(0, 7);
+}
@@ -0,0 +1,142 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { BadRequestException, ForbiddenException } from '@nestjs/common';
+import { aggregateJobStatus, ProjectService } from './project.service';
+import { TranslationService } from '../translation.service';
+import { QuotaService } from './quota.service';
+import { JsonLimitsService } from './json-limits.service';
+import {
+  ProjectJobRepository,
+  ProjectSettingsRepository,
+  TranslationTaskRepository,
+} from '../repositories/translation-task.repository';
+
+describe('ProjectService', () => {
+  let service: ProjectService;
+
+  const settings = { id: 'settings1', userId: 'user1', project: 'web', targetLangs: ['en', 'zh', 'ja', 'de'] };
+
+  const mockTranslationService = {
+    estimateTranslation: jest.fn().mockResolvedValue({ charTotal: 100 }),
+    createTranslationTask: jest.fn(),
+  };
+
+  const mockQuotaService = {
+    assertWithinQuota: jest.fn(),
+    release: jest.fn(),
+  };
+
+  const mockSettingsRepository = {
+    list: jest.fn(),
+    get: jest.fn(),
+    getOrFail: jest.fn().mockResolvedValue(settings),
+    build: jest.fn((data) => ({ ...data })),
+    save: jest.fn(),
+    delete: jest.fn(),
+  };
+
+  const mockJobRepository = {
+    list: jest.fn(),
+    getOrFail: jest.fn(),
+    build: jest.fn((data) => ({ ...data })),
+    save: jest.fn(),
+  };
+
+  const mockTaskRepository = {
+    list: jest.fn().mockResolvedValue([]),
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        ProjectService,
+        { provide: TranslationService, useValue: mockTranslationService },
+        { provide: QuotaService, useValue: mockQuotaService },
+        { provide: ProjectSettingsRepository, useValue: mockSettingsRepository },
+        { provide: ProjectJobRepository, useValue: mockJobRepository },
+        { provide: TranslationTaskRepository, useValue: mockTaskRepository },
+        {
+          provide: JsonLimitsService,
+          useValue: new JsonLimitsService({ get: jest.fn((key: string, defaultValue?: any) => defaultValue) } as any),
+        },
+      ],
+    }).compile();
+
+    service = module.get<ProjectService>(ProjectService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('按目标语言展开为多个任务，跳过源语言，额度按全部语言预留，被拒绝的语言释放自己的份额', async () => {
+    mockTranslationService.createTranslationTask
+      .mockResolvedValueOnce({ task: { id: 'task-zh' } })
+      .mockRejectedValueOnce(new Error('Monthly character cap reached'))
+      .mockResolvedValueOnce({ task: { id: 'task-de' } });
+    mockTaskRepository.list.mockResolvedValueOnce([
+      { id: 'task-zh', status: 'pending' },
+      { id: 'task-de', status: 'pending' },
+    ]);
+
+    const job = await service.translate('user1', 'web', { jsonContentRaw: '{"a":"Hello"}', fromLang: 'en' });
+
+    expect(mockQuotaService.assertWithinQuota).toHaveBeenCalledWith('user1', 300, undefined);
+    expect(mockTranslationService.createTranslationTask).toHaveBeenCalledTimes(3);
+    expect(mockTranslationService.createTranslationTask).toHaveBeenCalledWith(
+      'user1',
+      expect.objectContaining({ fromLang: 'en', toLang: 'zh', project: 'web' }),
+      { quotaReservation: { characters: 100, reservedAt: expect.any(Date) } },
+    );
+    expect(mockQuotaService.release).toHaveBeenCalledTimes(1);
+    expect(mockQuotaService.release).toHaveBeenCalledWith('user1', 100, expect.any(Date));
+    expect(mockJobRepository.save).toHaveBeenCalledWith(
+      expect.objectContaining({
+        tasks: { zh: 'task-zh', de: 'task-de' },
+        rejected: { ja: 'Monthly character cap reached' },
+      }),
+    );
+    expect(job).toMatchObject({ status: 'processing', counts: { pending: 2, rejected: 1 } });
+  });
+
+  it('第一个语言创建失败时不创建项目任务', async () => {
+    mockTranslationService.createTranslationTask.mockRejectedValueOnce(new BadRequestException('Invalid JSON content'));
+
+    await expect(service.translate('user1', 'web', { jsonContentRaw: '{', fromLang: 'en' })).rejects.toThrow(
+      'Invalid JSON content',
+    );
+    expect(mockJobRepository.save).not.toHaveBeenCalled();
+    expect(mockQuotaService.release).toHaveBeenCalledWith('user1', 300, expect.any(Date));
+  });
+
+  it('除源语言外没有目标语言时返回 400', async () => {
+    mockSettingsRepository.getOrFail.mockResolvedValueOnce({ ...settings, targetLangs: ['en'] });
+
+    await expect(service.translate('user1', 'web', { jsonContentRaw: '{}', fromLang: 'en' })).rejects.toThrow(
+      'Project has no target languages other than the source language',
+    );
+  });
+
+  it('绑定项目的委托密钥不能访问其他项目', async () => {
+    await expect(service.getSettings('user1', 'web', { project: 'mobile' } as any)).rejects.toThrow(
+      ForbiddenException,
+    );
+  });
+
+  it('按各语言任务的状态汇总', () => {
+    expect(aggregateJobStatus(['pending', 'pending'])).toBe('pending');
+    expect(aggregateJobStatus(['completed', 'processing'])).toBe('processing');
+    expect(aggregateJobStatus(['completed', 'completed'])).toBe('completed');
+    expect(aggregateJobStatus(['completed', 'rejected'])).toBe('partial');
+    expect(aggregateJobStatus(['failed', 'deleted'])).toBe('failed');
+  });
+
+  it('嵌套过深的文档在解析和预留额度之前拒绝', async () => {
+    const jsonContentRaw = `{"a":${'['.repeat(40)}${']'.repeat(40)}}`;
+
+    await expect(service.translate('user1', 'web', { jsonContentRaw } as any)).rejects.toThrow(
+      'JSON nesting exceeds 32 levels',
+    );
+    expect(mockTranslationService.estimateTranslation).not.toHaveBeenCalled();
+    expect(mockQuotaService.assertWithinQuota).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,228 @@
+import { BadRequestException, ForbiddenException, Injectable, Logger } from '@nestjs/common';
+import { v4 as uuidv4 } from 'uuid';
+import { AUTO_DETECT_LANGUAGE, TranslationService } from '../translation.service';
+import { QuotaService } from './quota.service';
+import { JsonLimitsService } from './json-limits.service';
+import { ProjectJob, ProjectSettings } from '../entities/project.entity';
+import { ProjectSettingsDto, ProjectTranslateDto } from '../dto/project.dto';
+import {
+  ProjectJobRepository,
+  ProjectSettingsRepository,
+  TranslationTaskRepository,
+} from '../repositories/translation-task.repository';
+import { TranslationRequestContext } from '../interfaces/translation-context.interface';
+import { ApiKeyContext } from '../../api-key/interfaces/api-key-context.interface';
+
+/** 仍在排队或执行中的任务状态 */
+const ACTIVE_STATUSES = new Set(['pending', 'processing', 'scheduled', 'paused']);
+
+export type ProjectJobStatus = 'pending' | 'processing' | 'completed' | 'partial' | 'failed';
+
+/**
+ * 汇总各目标语言任务的状态：有任务未结束时为 pending / processing；
+ * 全部结束后全部成功为 completed，没有任何成功为 failed，否则为 partial（有未翻译键的任务也算部分成功）
+ */
+export function aggregateJobStatus(statuses: string[]): ProjectJobStatus {
+  if (statuses.some((status) => ACTIVE_STATUSES.has(status))) {
+    return statuses.every((status) => status === 'pending') ? 'pending' : 'processing';
+  }
+  if (statuses.every((status) => status === 'completed')) {
+    return 'completed';
+  }
+  return statuses.some((status) => status === 'completed' || status === 'partial') ? 'partial' : 'failed';
+}
+
+/**
+ * 项目的目标语言展开
+ * 项目设置保存目标语言集合；向项目提交一份源文档时为每个目标语言创建一个普通翻译任务，
+ * 记录为一个项目任务（ProjectJob），查询时按各语言任务的状态汇总
+ */
+@Injectable()
+export class ProjectService {
+  private readonly logger = new Logger(ProjectService.name);
+
+  constructor(
+    private readonly translationService: TranslationService,
+    private readonly quotaService: QuotaService,
+    private readonly settingsRepository: ProjectSettingsRepository,
+    private readonly jobRepository: ProjectJobRepository,
+    private readonly taskRepository: TranslationTaskRepository,
+    private readonly jsonLimitsService: JsonLimitsService,
+  ) {}
+
+  async listSettings(userId: string, apiKey?: ApiKeyContext) {
+    const filter = apiKey?.project ? { userId, project: apiKey.project } : { userId };
+    const settings = await this.settingsRepository.list(filter, { orderBy: { project: 'ASC' } });
+    return settings.map(toSettingsView);
+  }
+
+  async getSettings(userId: string, project: string, apiKey?: ApiKeyContext) {
+    assertProjectAccess(project, apiKey);
+    return toSettingsView(await this.getOwnedSettings(userId, project));
+  }
+
+  async putSettings(userId: string, project: string, dto: ProjectSettingsDto, apiKey?: ApiKeyContext) {
+    assertProjectAccess(project, apiKey);
+    const existing = await this.settingsRepository.get({ userId, project });
+    const settings = existing ?? this.settingsRepository.build({ id: uuidv4(), userId, project } as ProjectSettings);
+    Object.assign(settings, {
+      targetLangs: dto.targetLangs,
+      sourceLang: dto.sourceLang ?? null,
+      provider: dto.provider ?? null,
+    });
+    await this.settingsRepository.save(settings);
+    return toSettingsView(settings);
+  }
+
+  async removeSettings(userId: string, project: string, apiKey?: ApiKeyContext): Promise<void> {
+    assertProjectAccess(project, apiKey);
+    await this.settingsRepository.delete(await this.getOwnedSettings(userId, project));
+  }
+
+  /**
+   * 为项目的每个目标语言创建翻译任务；先按全部语言的字符数检查额度，避免只展开一部分。
+   * 第一个语言创建失败时原样抛出（通常对所有语言都成立，如 JSON 无效），之后的失败记录在 rejected 中
+   */
+  async translate(userId: string, project: string, dto: ProjectTranslateDto, context: TranslationRequestContext = {}) {
+    assertProjectAccess(project, context.apiKey);
+    this.jsonLimitsService.assert(dto.jsonContentRaw);
+    const settings = await this.getOwnedSettings(userId, project);
+    const fromLang = dto.fromLang ?? settings.sourceLang ?? AUTO_DETECT_LANGUAGE;
+    const targets = settings.targetLangs.filter((lang) => lang !== fromLang);
+    if (targets.length === 0) {
+      throw new BadRequestException('Project has no target languages other than the source language');
+    }
+
+    const payload = {
+      jsonContentRaw: dto.jsonContentRaw,
+      fromLang,
+      name: dto.name,
+      tags: dto.tags,
+      ignoredFields: dto.ignoredFields,
+      profileId: dto.profileId,
+      project,
+      provider: settings.provider,
+      maskPii: dto.maskPii,
+      piiPatterns: dto.piiPatterns,
+      suppressWebhook: dto.suppressWebhook,
+      force: dto.force,
+    };
+    const { charTotal } = await this.translationService.estimateTranslation(
+      userId,
+      { ...payload, toLang: targets[0] },
+      context.apiKey,
+    );
+    // 按全部语言一次预留，再分给各语言的任务；没有创建出任务的语言立即释放自己的份额
+    const reservedAt = new Date();
+    await this.quotaService.assertWithinQuota(userId, charTotal * targets.length, context.tenantId);
+    const quotaReservation = { characters: charTotal, reservedAt };
+
+    const job = this.jobRepository.build({
+      id: uuidv4(),
+      userId,
+      project,
+      fromLang,
+      charTotal,
+      tasks: {},
+    } as ProjectJob);
+    const rejected: Record<string, string> = {};
+    for (const [index, toLang] of targets.entries()) {
+      try {
+        const { task } = await this.translationService.createTranslationTask(
+          userId,
+          { ...payload, toLang },
+          { ...context, quotaReservation },
+        );
+        job.tasks[toLang] = task.id;
+      } catch (error) {
+        if (index === 0) {
+          await this.quotaService.release(userId, charTotal * targets.length, reservedAt);
+          throw error;
+        }
+        await this.quotaService.release(userId, charTotal, reservedAt);
+        this.logger.warn(`Project ${project} job ${job.id}: ${toLang} was rejected: ${error.message}`);
+        rejected[toLang] = error.message;
+      }
+    }
+    job.rejected = Object.keys(rejected).length ? rejected : undefined;
+    await this.jobRepository.save(job);
+    return this.toJobView(job);
+  }
+
+  async listJobs(userId: string, project: string, limit = 20, apiKey?: ApiKeyContext) {
+    assertProjectAccess(project, apiKey);
+    const jobs = await this.jobRepository.list(
+      { userId, project },
+      { limit: Math.min(Math.max(limit, 1), 100), orderBy: { createdAt: 'DESC' } },
+    );
+    return Promise.all(jobs.map((job) => this.toJobView(job)));
+  }
+
+  async getJob(userId: string, project: string, jobId: string, apiKey?: ApiKeyContext) {
+    assertProjectAccess(project, apiKey);
+    const job = await this.jobRepository.getOrFail({ id: jobId, userId, project }, 'Project job not found');
+    return this.toJobView(job);
+  }
+
+  private async toJobView(job: ProjectJob) {
+    const taskIds = Object.values(job.tasks);
+    const tasks = taskIds.length ? await this.taskRepository.list({ id: { $in: taskIds } }) : [];
+    const byId = new Map(tasks.map((task) => [task.id, task]));
+
+    const languages = [
+      ...Object.entries(job.tasks).map(([toLang, taskId]) => {
+        const task = byId.get(taskId);
+        return {
+          toLang,
+          taskId,
+          // 语言任务被单独删除后按失败计
+          status: task?.status ?? 'deleted',
+          failureReason: task?.failureReason,
+        };
+      }),
+      ...Object.entries(job.rejected ?? {}).map(([toLang, error]) => ({
+        toLang,
+        taskId: null,
+        status: 'rejected',
+        failureReason: error,
+      })),
+    ];
+    const counts: Record<string, number> = {};
+    for (const { status } of languages) {
+      counts[status] = (counts[status] ?? 0) + 1;
+    }
+
+    return {
+      id: job.id,
+      project: job.project,
+      fromLang: job.fromLang,
+      charTotal: job.charTotal,
+      status: aggregateJobStatus(languages.map((language) => language.status)),
+      counts,
+      languages,
+      createdAt: job.createdAt,
+    };
+  }
+
+  private async getOwnedSettings(userId: string, project: string): Promise<ProjectSettings> {
+    return this.settingsRepository.getOrFail({ userId, project }, 'Project settings not found');
+  }
+}
+
+/** 绑定项目的委托密钥只能访问该项目 */
+function assertProjectAccess(project: string, apiKey?: ApiKeyContext): void {
+  if (apiKey?.project && apiKey.project !== project) {
+    throw new ForbiddenException(`API key is restricted to project "${apiKey.project}"`);
+  }
+}
+
+function toSettingsView(settings: ProjectSettings) {
+  return {
+    project: settings.project,
+    targetLangs: settings.targetLangs,
+    sourceLang: settings.sourceLang ?? null,
+    provider: settings.provider ?? null,
+    createdAt: settings.createdAt,
+    updatedAt: settings.updatedAt,
+  };
+}
@@ -0,0 +1,61 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { ProviderCacheService } from './provider-cache.service';
+import { TranslationUtils } from '../utils/translation.utils';
+import { RedisService } from '../../../common/services/redis.service';
+
+describe('ProviderCacheService', () => {
+  let service: ProviderCacheService;
+  const store = new Map<string, string>();
+
+  const mockMulti = {
+    hincrby: jest.fn().mockReturnThis(),
+    exec: jest.fn(),
+  };
+  const mockRedisService = {
+    client: {
+      get: jest.fn(async (key: string) => store.get(key) ?? null),
+      setex: jest.fn(async (key: string, _ttl: number, value: string) => {
+        store.set(key, value);
+      }),
+      multi: jest.fn(() => mockMulti),
+    },
+    del: jest.fn(),
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        ProviderCacheService,
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+      ],
+    }).compile();
+
+    service = module.get<ProviderCacheService>(ProviderCacheService);
+    store.clear();
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('相同片段第二次翻译时命中缓存，不再调用翻译服务', async () => {
+    const utils = new TranslationUtils(service);
+    const callProvider = jest.spyOn(utils as any, 'callProvider');
+
+    await utils.translateJson('{"a":"Hello","b":"Hello"}', 'en', 'fr', '', { provider: 'aliyun' });
+
+    expect(callProvider).toHaveBeenCalledTimes(1);
+    expect(mockMulti.hincrby).toHaveBeenCalledWith('provider_cache_stats:en:fr', 'hits', 1);
+    expect(mockMulti.hincrby).toHaveBeenCalledWith('provider_cache_stats:en:fr', 'savedChars', 5);
+  });
+
+  it('翻译服务或语言对不同时使用不同的缓存键', async () => {
+    await service.set({ text: 'Hello', from: 'en', to: 'fr', provider: 'aliyun' }, 'Bonjour');
+
+    expect(await service.get({ text: 'Hello', from: 'en', to: 'fr', provider: 'aliyun' })).toBe('Bonjour');
+    expect(await service.get({ text: 'Hello', from: 'en', to: 'fr', provider: 'deepl' })).toBeNull();
+    expect(await service.get({ text: 'Hello', from: 'en', to: 'de', provider: 'aliyun' })).toBeNull();
+  });
+});
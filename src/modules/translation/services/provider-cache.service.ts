@@ -0,0 +1,130 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { createHash } from 'crypto';
+import { RedisService } from '../../../common/services/redis.service';
+
+export interface ProviderSegment {
+  text: string;
+  from: string;
+  to: string;
+  provider?: string;
+  /** 影响译文的其他调用参数 */
+  options?: Record<string, any>;
+}
+
+export interface ProviderCacheStats {
+  from: string;
+  to: string;
+  hits: number;
+  misses: number;
+  /** 命中的字符数，即节省的翻译服务调用量 */
+  savedChars: number;
+  hitRate: number;
+}
+
+const CACHE_PREFIX = 'provider_cache:';
+const STATS_PREFIX = 'provider_cache_stats:';
+
+/**
+ * 翻译服务响应缓存
+ * 以 hash(原文, 源语言, 目标语言, 翻译服务, 参数) 为键在 Redis 中共享，所有用户命中同一份结果，
+ * 用于降低运营侧的翻译服务成本；按语言对统计命中率，可由管理员按语言对清空
+ */
+@Injectable()
+export class ProviderCacheService {
+  private readonly logger = new Logger(ProviderCacheService.name);
+  private readonly enabled: boolean;
+  private readonly ttlSeconds: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly redisService: RedisService,
+  ) {
+    this.enabled = this.configService.get('PROVIDER_CACHE_ENABLED', 'true') === 'true';
+    this.ttlSeconds = Number(this.configService.get('PROVIDER_CACHE_TTL_SECONDS', 30 * 24 * 60 * 60));
+  }
+
+  async get(segment: ProviderSegment): Promise<string | null> {
+    if (!this.enabled) {
+      return null;
+    }
+    try {
+      const cached = await this.redisService.client.get(this.key(segment));
+      await this.redisService.client
+        .multi()
+        .hincrby(this.statsKey(segment.from, segment.to), cached !== null ? 'hits' : 'misses', 1)
+        .hincrby(this.statsKey(segment.from, segment.to), 'savedChars', cached !== null ? segment.text.length : 0)
+        .exec();
+      return cached;
+    } catch (error) {
+      this.logger.error(`Provider cache read failed: ${error.message}`);
+      return null;
+    }
+  }
+
+  async set(segment: ProviderSegment, translation: string): Promise<void> {
+    if (!this.enabled) {
+      return;
+    }
+    try {
+      await this.redisService.client.setex(this.key(segment), this.ttlSeconds, translation);
+    } catch (error) {
+      this.logger.error(`Provider cache write failed: ${error.message}`);
+    }
+  }
+
+  async getStats(): Promise<ProviderCacheStats[]> {
+    const stats: ProviderCacheStats[] = [];
+    const stream = this.redisService.client.scanStream({ match: `${STATS_PREFIX}*`, count: 100 });
+    for await (const keys of stream) {
+      for (const key of keys as string[]) {
+        const [from, to] = key.slice(STATS_PREFIX.length).split(':');
+        const values = await this.redisService.client.hgetall(key);
+        const hits = Number(values.hits ?? 0);
+        const misses = Number(values.misses ?? 0);
+        stats.push({
+          from,
+          to,
+          hits,
+          misses,
+          savedChars: Number(values.savedChars ?? 0),
+          hitRate: hits + misses > 0 ? Math.round((hits / (hits + misses)) * 1000) / 1000 : 0,
+        });
+      }
+    }
+    return stats.sort((a, b) => b.hits - a.hits);
+  }
+
+  /**
+   * 清空一个语言对的缓存和统计，返回删除的条目数
+   */
+  async flush(from: string, to: string): Promise<number> {
+    const stream = this.redisService.client.scanStream({ match: `${CACHE_PREFIX}${from}:${to}:*`, count: 500 });
+    let removed = 0;
+    for await (const keys of stream) {
+      removed += await this.redisService.del(...(keys as string[]));
+    }
+    await this.redisService.del(this.statsKey(from, to));
+    this.logger.log(`Flushed ${removed} cached provider response(s) for ${from}->${to}`);
+    return removed;
+  }
+
+  private key(segment: ProviderSegment): string {
+    const digest = createHash('sha256')
+      .update(
+        JSON.stringify([
+          segment.text,
+          segment.from,
+          segment.to,
+          segment.provider ?? '',
+          Object.entries(segment.options ?? {}).sort(([a], [b]) => a.localeCompare(b)),
+        ]),
+      )
+      .digest('hex');
+    return `${CACHE_PREFIX}${segment.from}:${segment.to}:${digest}`;
+  }
+
+  private statsKey(from: string, to: string): string {
+    return `${STATS_PREFIX}${from}:${to}`;
+  }
+}
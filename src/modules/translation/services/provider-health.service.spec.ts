@@ -0,0 +1,87 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { ProviderHealthService } from './provider-health.service';
+import { RedisService } from '../../../common/services/redis.service';
+import { MailService } from '../../../common/services/mail.service';
+import { ErrorReporterService } from '../../../common/services/error-reporter.service';
+import { ProviderPausedError, TaskFailureReason } from '../utils/task-failure';
+
+describe('ProviderHealthService', () => {
+  let service: ProviderHealthService;
+  const config: Record<string, any> = { PROVIDER_FALLBACKS: 'deepl', PROVIDER_ALERT_EMAILS: 'ops@example.com' };
+  const store = new Map<string, string>();
+
+  const mockRedisService = {
+    getJson: jest.fn(async (key: string) => (store.has(key) ? JSON.parse(store.get(key)) : null)),
+    setIfAbsent: jest.fn(async (key: string, value: string) => {
+      if (store.has(key)) {
+        return false;
+      }
+      store.set(key, value);
+      return true;
+    }),
+    del: jest.fn(async (key: string) => Number(store.delete(key))),
+  };
+
+  const mockMailService = {
+    send: jest.fn(),
+  };
+
+  const mockErrorReporter = {
+    captureException: jest.fn(),
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        ProviderHealthService,
+        {
+          provide: ConfigService,
+          useValue: { get: jest.fn((key: string, defaultValue?: any) => config[key] ?? defaultValue) },
+        },
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: MailService, useValue: mockMailService },
+        { provide: ErrorReporterService, useValue: mockErrorReporter },
+      ],
+    }).compile();
+
+    service = module.get<ProviderHealthService>(ProviderHealthService);
+  });
+
+  afterEach(() => {
+    store.clear();
+    jest.clearAllMocks();
+  });
+
+  it('账号失败时暂停服务商、通知运维并转给备用服务商', async () => {
+    const error = Object.assign(new Error('Account is in arrears'), { code: 'Arrearage' });
+
+    await expect(service.report('aliyun', error)).resolves.toBe(true);
+    await expect(service.route('aliyun')).resolves.toBe('deepl');
+    expect(mockErrorReporter.captureException).toHaveBeenCalledTimes(1);
+    expect(mockMailService.send).toHaveBeenCalledWith(expect.objectContaining({ to: ['ops@example.com'] }));
+
+    // 其他 worker 再次发现时不重复告警
+    await service.report('aliyun', error);
+    expect(mockErrorReporter.captureException).toHaveBeenCalledTimes(1);
+  });
+
+  it('普通错误不暂停', async () => {
+    await expect(service.report('aliyun', new Error('socket hang up'))).resolves.toBe(false);
+    await expect(service.route('aliyun')).resolves.toBe('aliyun');
+  });
+
+  it('全部服务商暂停时抛出 ProviderPausedError，恢复后重新使用', async () => {
+    await service.pause('aliyun', TaskFailureReason.PROVIDER_CREDENTIALS_REJECTED, 'Access key disabled');
+    await service.pause('deepl', TaskFailureReason.PROVIDER_QUOTA_EXHAUSTED, 'Quota exhausted');
+
+    await expect(service.route('aliyun')).rejects.toBeInstanceOf(ProviderPausedError);
+
+    await service.resume('aliyun');
+    await expect(service.route('aliyun')).resolves.toBe('aliyun');
+    await expect(service.getStatus()).resolves.toEqual([
+      expect.objectContaining({ provider: 'aliyun', paused: false, fallbacks: ['deepl'] }),
+      expect.objectContaining({ provider: 'deepl', paused: true }),
+    ]);
+  });
+});
@@ -0,0 +1,182 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { RedisService } from '../../../common/services/redis.service';
+import { MailService } from '../../../common/services/mail.service';
+import { ErrorReporterService } from '../../../common/services/error-reporter.service';
+import { TranslationProvider } from '../../../config/providers';
+import { ProviderPausedError, providerAccountFailure, TaskFailureReason } from '../utils/task-failure';
+
+/** 各 worker 缓存 Redis 中暂停状态的时间 */
+const STATE_CACHE_TTL_MS = 5000;
+
+export interface ProviderPause {
+  provider: string;
+  reason: TaskFailureReason;
+  message: string;
+  pausedAt: string;
+  resumeAt: string;
+}
+
+export interface ProviderHealthStatus {
+  provider: string;
+  paused: boolean;
+  pause?: ProviderPause;
+  /** 暂停期间依次尝试的备用服务商 */
+  fallbacks: string[];
+}
+
+function pauseKey(provider: string): string {
+  return `provider_pause:${provider}`;
+}
+
+/**
+ * 翻译服务商账号健康
+ * 服务商返回额度 / 余额用尽或凭证被拒时暂停该服务商（Redis 中带过期时间，所有 worker 共享），
+ * 第一个发现的 worker 通知运维；暂停期间的调用按 PROVIDER_FALLBACKS 依次转给备用服务商，全部不可用时直接失败，
+ * 不再对失效的凭证重试。暂停到期后自动恢复，运维修复账号后也可以通过管理接口提前恢复
+ */
+@Injectable()
+export class ProviderHealthService {
+  private readonly logger = new Logger(ProviderHealthService.name);
+  private readonly pauseSeconds: number;
+  private readonly fallbacks: string[];
+  private readonly alertEmails: string[];
+  private readonly cached = new Map<string, { pause: ProviderPause | null; expiresAt: number }>();
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly redisService: RedisService,
+    private readonly mailService: MailService,
+    private readonly errorReporter: ErrorReporterService,
+  ) {
+    this.pauseSeconds = Number(this.configService.get('PROVIDER_PAUSE_SECONDS', 1800));
+    this.fallbacks = parseList(this.configService.get('PROVIDER_FALLBACKS', ''));
+    this.alertEmails = parseList(this.configService.get('PROVIDER_ALERT_EMAILS', ''));
+  }
+
+  /**
+   * 选择实际调用的服务商：请求的服务商未暂停时原样返回，否则返回第一个未暂停的备用服务商，
+   * 都已暂停时抛出 ProviderPausedError
+   */
+  async route(provider: string): Promise<string> {
+    const candidates = [provider, ...this.fallbacks.filter((fallback) => fallback !== provider)];
+    let first: ProviderPause | null = null;
+    for (const candidate of candidates) {
+      const pause = await this.getPause(candidate);
+      if (!pause) {
+        if (candidate !== provider) {
+          this.logger.debug(`Provider ${provider} is paused, routing to ${candidate}`);
+        }
+        return candidate;
+      }
+      first = first ?? pause;
+    }
+    throw new ProviderPausedError(
+      provider,
+      first.reason,
+      `Translation provider ${provider} is paused until ${first.resumeAt} (${first.reason}), no fallback is available`,
+    );
+  }
+
+  /**
+   * 检查服务商调用的错误，属于账号层面的失败时暂停该服务商并返回 true
+   */
+  async report(provider: string, error: any): Promise<boolean> {
+    const reason = providerAccountFailure(error);
+    if (!reason || error instanceof ProviderPausedError) {
+      return false;
+    }
+    await this.pause(provider, reason, String(error?.message ?? error));
+    return true;
+  }
+
+  async pause(provider: string, reason: TaskFailureReason, message: string): Promise<ProviderPause> {
+    const now = new Date();
+    const pause: ProviderPause = {
+      provider,
+      reason,
+      message: message.slice(0, 500),
+      pausedAt: now.toISOString(),
+      resumeAt: new Date(now.getTime() + this.pauseSeconds * 1000).toISOString(),
+    };
+    // 先记入本进程缓存，Redis 不可用时本进程在缓存期内也不会再调用该服务商
+    this.cached.set(provider, { pause, expiresAt: Date.now() + STATE_CACHE_TTL_MS });
+
+    // 只有第一个写入暂停标记的 worker 发出告警
+    const first = await this.redisService.setIfAbsent(pauseKey(provider), JSON.stringify(pause), this.pauseSeconds);
+    if (first) {
+      await this.alert(pause);
+    }
+    return pause;
+  }
+
+  /**
+   * 提前恢复服务商（运维更换凭证或充值后调用）
+   */
+  async resume(provider: string): Promise<void> {
+    this.cached.delete(provider);
+    await this.redisService.del(pauseKey(provider));
+    this.logger.log(`Translation provider ${provider} resumed`);
+  }
+
+  async getStatus(): Promise<ProviderHealthStatus[]> {
+    const providers = new Set<string>([
+      ...Object.values(TranslationProvider).filter((provider) => provider !== TranslationProvider.CUSTOM),
+      ...this.fallbacks,
+    ]);
+    const statuses: ProviderHealthStatus[] = [];
+    for (const provider of providers) {
+      this.cached.delete(provider);
+      const pause = await this.getPause(provider);
+      statuses.push({
+        provider,
+        paused: !!pause,
+        pause: pause ?? undefined,
+        fallbacks: this.fallbacks.filter((fallback) => fallback !== provider),
+      });
+    }
+    return statuses;
+  }
+
+  private async getPause(provider: string): Promise<ProviderPause | null> {
+    const cached = this.cached.get(provider);
+    if (cached && cached.expiresAt > Date.now()) {
+      return cached.pause;
+    }
+    const pause = await this.redisService.getJson<ProviderPause>(pauseKey(provider));
+    this.cached.set(provider, { pause, expiresAt: Date.now() + STATE_CACHE_TTL_MS });
+    return pause;
+  }
+
+  private async alert(pause: ProviderPause): Promise<void> {
+    const summary =
+      `Translation provider ${pause.provider} paused until ${pause.resumeAt}: ${pause.reason} (${pause.message})`;
+    this.logger.error(summary);
+    this.errorReporter.captureException(new Error(summary), {
+      source: 'worker',
+      tags: { provider: pause.provider, reason: pause.reason },
+      extra: { fallbacks: this.fallbacks },
+    });
+    if (this.alertEmails.length > 0) {
+      await this.mailService.send({
+        to: this.alertEmails,
+        subject: `[Action required] Translation provider ${pause.provider} paused`,
+        text: [
+          summary,
+          '',
+          this.fallbacks.length
+            ? `Traffic is being routed to: ${this.fallbacks.join(', ')}.`
+            : 'No fallback provider is configured; translations using this provider will fail until it is resumed.',
+          `Fix the provider account, then resume it with DELETE /api/v1/admin/provider-health/${pause.provider}/pause.`,
+        ].join('\n'),
+      });
+    }
+  }
+}
+
+function parseList(value: string): string[] {
+  return value
+    .split(',')
+    .map((item) => item.trim())
+    .filter(Boolean);
+}
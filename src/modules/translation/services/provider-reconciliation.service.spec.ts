@@ -0,0 +1,121 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { ProviderReconciliationService } from './provider-reconciliation.service';
+import { ProviderUsageService } from './provider-usage.service';
+import { UsageImportFormat } from '../dto/usage-import.dto';
+import {
+  CharacterUsageLogDailyRepository,
+  ProviderInvoiceRepository,
+} from '../repositories/character-usage.repository';
+import { AuditLogService } from '../../audit/services/audit-log.service';
+
+describe('ProviderReconciliationService', () => {
+  let service: ProviderReconciliationService;
+
+  const mockInvoiceRepository = {
+    list: jest.fn(),
+    build: jest.fn((data) => ({ ...data })),
+    save: jest.fn(),
+  };
+  const mockDailyUsageRepository = { list: jest.fn() };
+  const mockProviderUsageService = { getUsage: jest.fn() };
+  const mockAuditLogService = { log: jest.fn() };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        ProviderReconciliationService,
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+        { provide: ProviderInvoiceRepository, useValue: mockInvoiceRepository },
+        { provide: CharacterUsageLogDailyRepository, useValue: mockDailyUsageRepository },
+        { provide: ProviderUsageService, useValue: mockProviderUsageService },
+        { provide: AuditLogService, useValue: mockAuditLogService },
+      ],
+    }).compile();
+
+    service = module.get<ProviderReconciliationService>(ProviderReconciliationService);
+    mockInvoiceRepository.list.mockResolvedValue([]);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  describe('importInvoices', () => {
+    it('应从 CSV 导入账单，并覆盖同一服务商同一月份的旧账单', async () => {
+      const existing = { id: 'inv1', provider: 'aliyun', period: '2026-08', characters: 1 };
+      mockInvoiceRepository.list.mockResolvedValue([existing]);
+
+      const csv = [
+        'provider,period,characters,amount,currency',
+        'aliyun,2026-08,900,45.5,CNY',
+        'aliyun,2026-09,1000,,',
+      ];
+
+      const report = await service.importInvoices({ format: UsageImportFormat.CSV, csv: csv.join('\n') }, 'admin1');
+
+      expect(report).toMatchObject({ total: 2, imported: 1, replaced: 1, errors: [] });
+      expect(existing).toMatchObject({ characters: 900, amount: 45.5, currency: 'CNY', importedBy: 'admin1' });
+      expect(mockInvoiceRepository.save).toHaveBeenCalledWith([
+        existing,
+        expect.objectContaining({ provider: 'aliyun', period: '2026-09', characters: 1000 }),
+      ]);
+      expect(mockAuditLogService.log).toHaveBeenCalled();
+    });
+
+    it('有无效记录时整批不写入', async () => {
+      const report = await service.importInvoices(
+        {
+          format: UsageImportFormat.JSON,
+          records: [
+            { provider: 'aliyun', period: '2026-13', characters: 10 },
+            { provider: 'aliyun', period: '2026-09', characters: 10 },
+            { provider: 'aliyun', period: '2026-09', characters: 20 },
+          ],
+        },
+        'admin1',
+      );
+
+      expect(report.errors.map((error) => error.row)).toEqual([1, 3]);
+      expect(report.errors[1].errors).toContain('duplicate provider and period in the same import');
+      expect(mockInvoiceRepository.save).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('getReport', () => {
+    it('应对比账单与记录的调用量，并列出翻译服务计费但未向用户计费的账户', async () => {
+      mockProviderUsageService.getUsage.mockResolvedValue([
+        { provider: 'aliyun', userId: 'u1', characters: 600 },
+        { provider: 'aliyun', userId: 'u2', characters: 300 },
+        { provider: 'aliyun', userId: undefined, characters: 100 },
+      ]);
+      mockInvoiceRepository.list.mockResolvedValue([{ provider: 'aliyun', period: '2026-09', characters: 1050 }]);
+      mockDailyUsageRepository.list.mockResolvedValue([
+        { userId: 'u1', usageDate: '2026-09-01', totalCharacters: 400 },
+        { userId: 'u2', usageDate: '2026-09-02', totalCharacters: 500 },
+      ]);
+
+      const report = await service.getReport('2026-09');
+
+      expect(mockDailyUsageRepository.list).toHaveBeenCalledWith({
+        usageDate: { $gte: '2026-09-01', $lte: '2026-09-31' },
+      });
+      expect(report.providers).toEqual([
+        expect.objectContaining({
+          provider: 'aliyun',
+          invoicedCharacters: 1050,
+          recordedCharacters: 1000,
+          difference: 50,
+          differencePercent: 5,
+          withinTolerance: false,
+        }),
+      ]);
+      expect(report).toMatchObject({ chargedCharacters: 900, providerCharacters: 1000, accountsTotal: 3 });
+      expect(report.accounts.map(({ userId, unchargedCharacters }) => [userId, unchargedCharacters])).toEqual([
+        ['u1', 200],
+        [null, 100],
+        ['u2', 0],
+      ]);
+    });
+  });
+});
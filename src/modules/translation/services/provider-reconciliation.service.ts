@@ -0,0 +1,274 @@
+import { BadRequestException, Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { plainToInstance } from 'class-transformer';
+import { validate } from 'class-validator';
+import { v4 as uuidv4 } from 'uuid';
+import { ProviderInvoiceImportDto, ProviderInvoiceRecordDto } from '../dto/provider-invoice.dto';
+import { UsageImportFormat } from '../dto/usage-import.dto';
+import {
+  CharacterUsageLogDailyRepository,
+  ProviderInvoiceRepository,
+} from '../repositories/character-usage.repository';
+import { ProviderUsageService } from './provider-usage.service';
+import { UsageImportRowError } from './usage-import.service';
+import { AuditLogService } from '../../audit/services/audit-log.service';
+import { AuditAction, AuditSeverity, ResourceType } from '../../audit/entities/audit-log.entity';
+import { parseCsv } from '../../../common/utils/csv';
+
+export interface ProviderInvoiceImportReport {
+  dryRun: boolean;
+  total: number;
+  /** 新增的账单 */
+  imported: number;
+  /** 覆盖了同一服务商、同一月份已有账单的记录 */
+  replaced: number;
+  errors: UsageImportRowError[];
+}
+
+export interface ProviderReconciliationLine {
+  provider: string;
+  /** 账单字符数，未导入账单时为 null */
+  invoicedCharacters: number | null;
+  /** 本系统记录的翻译服务计费字符数 */
+  recordedCharacters: number;
+  /** 账单 - 记录，正数表示服务商多收 */
+  difference: number | null;
+  differencePercent: number | null;
+  withinTolerance: boolean | null;
+  amount?: number;
+  currency?: string;
+  reference?: string;
+}
+
+export interface AccountReconciliationLine {
+  /** 为空表示无法归属到账户的调用 */
+  userId: string | null;
+  /** 向用户计费的字符数 */
+  chargedCharacters: number;
+  /** 该账户产生的翻译服务计费字符数 */
+  providerCharacters: number;
+  /** 翻译服务计费但没有向用户计费的字符数 */
+  unchargedCharacters: number;
+}
+
+export interface ProviderReconciliationReport {
+  period: string;
+  tolerancePercent: number;
+  chargedCharacters: number;
+  providerCharacters: number;
+  providers: ProviderReconciliationLine[];
+  /** 按未计费字符数从多到少排列 */
+  accounts: AccountReconciliationLine[];
+  accountsTotal: number;
+}
+
+/**
+ * 翻译服务账单对账
+ * 管理员按月导入服务商账单（JSON / CSV），报告对比账单、本系统记录的翻译服务调用量和向用户计费的字符数，
+ * 发现服务商计费与记录不符，以及翻译服务计费但没有向用户计费的账户
+ */
+@Injectable()
+export class ProviderReconciliationService {
+  private readonly logger = new Logger(ProviderReconciliationService.name);
+  private readonly tolerancePercent: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly invoiceRepository: ProviderInvoiceRepository,
+    private readonly dailyUsageRepository: CharacterUsageLogDailyRepository,
+    private readonly providerUsageService: ProviderUsageService,
+    private readonly auditLogService: AuditLogService,
+  ) {
+    this.tolerancePercent = Number(this.configService.get('PROVIDER_RECONCILIATION_TOLERANCE_PERCENT', 1));
+  }
+
+  /**
+   * 导入账单；只要有一条记录校验失败就整批不写入，同一服务商和月份的账单以最后导入的为准
+   */
+  async importInvoices(dto: ProviderInvoiceImportDto, actorId: string): Promise<ProviderInvoiceImportReport> {
+    const rows = this.readRows(dto);
+    const errors: UsageImportRowError[] = [];
+    const records: ProviderInvoiceRecordDto[] = [];
+    const seen = new Set<string>();
+
+    for (const [index, row] of rows.entries()) {
+      const record = plainToInstance(ProviderInvoiceRecordDto, normalizeRow(row));
+      const messages = (await validate(record)).flatMap((error) => Object.values(error.constraints || {}));
+      const key = `${record.provider}:${record.period}`;
+      if (seen.has(key)) {
+        messages.push('duplicate provider and period in the same import');
+      }
+      seen.add(key);
+
+      if (messages.length > 0) {
+        errors.push({ row: index + 1, externalId: record.reference, errors: messages });
+      } else {
+        records.push(record);
+      }
+    }
+
+    const report: ProviderInvoiceImportReport = {
+      dryRun: !!dto.dryRun,
+      total: rows.length,
+      imported: 0,
+      replaced: 0,
+      errors,
+    };
+    if (dto.dryRun || errors.length > 0) {
+      return report;
+    }
+
+    const existing = new Map(
+      (await this.invoiceRepository.list({ $or: records.map(({ provider, period }) => ({ provider, period })) })).map(
+        (invoice) => [`${invoice.provider}:${invoice.period}`, invoice],
+      ),
+    );
+    const invoices = records.map((record) => {
+      const fields = {
+        characters: record.characters,
+        amount: record.amount,
+        currency: record.currency,
+        reference: record.reference,
+        importedBy: actorId,
+      };
+      const invoice = existing.get(`${record.provider}:${record.period}`);
+      if (invoice) {
+        report.replaced += 1;
+        return Object.assign(invoice, fields);
+      }
+      report.imported += 1;
+      return this.invoiceRepository.build({
+        id: uuidv4(),
+        provider: record.provider,
+        period: record.period,
+        ...fields,
+      });
+    });
+    await this.invoiceRepository.save(invoices);
+
+    await this.auditLogService.log({
+      userId: actorId,
+      action: AuditAction.PROVIDER_INVOICE_IMPORT,
+      resourceType: ResourceType.SYSTEM_CONFIG,
+      newValues: { invoices: records.map(({ provider, period, characters }) => ({ provider, period, characters })) },
+      severity: AuditSeverity.MEDIUM,
+      description: `Imported ${records.length} provider invoice(s)`,
+      tags: ['provider_invoice_import'],
+    });
+    this.logger.log(`Provider invoices: ${report.imported} imported, ${report.replaced} replaced`);
+    return report;
+  }
+
+  async getReport(period: string, limit = 50): Promise<ProviderReconciliationReport> {
+    const [usage, invoices, charged] = await Promise.all([
+      this.providerUsageService.getUsage(period),
+      this.invoiceRepository.list({ period }),
+      this.dailyUsageRepository.list({ usageDate: { $gte: `${period}-01`, $lte: `${period}-31` } }),
+    ]);
+
+    const recordedByProvider = new Map<string, number>();
+    const accounts = new Map<string | null, AccountReconciliationLine>();
+    const accountOf = (userId: string | null) => {
+      let account = accounts.get(userId);
+      if (!account) {
+        account = { userId, chargedCharacters: 0, providerCharacters: 0, unchargedCharacters: 0 };
+        accounts.set(userId, account);
+      }
+      return account;
+    };
+    for (const row of usage) {
+      recordedByProvider.set(row.provider, (recordedByProvider.get(row.provider) ?? 0) + row.characters);
+      accountOf(row.userId ?? null).providerCharacters += row.characters;
+    }
+    for (const daily of charged) {
+      accountOf(daily.userId).chargedCharacters += daily.totalCharacters;
+    }
+
+    const providers = [...new Set([...recordedByProvider.keys(), ...invoices.map((invoice) => invoice.provider)])]
+      .sort()
+      .map((provider) => {
+        const recordedCharacters = recordedByProvider.get(provider) ?? 0;
+        const invoice = invoices.find((item) => item.provider === provider);
+        if (!invoice) {
+          return {
+            provider,
+            invoicedCharacters: null,
+            recordedCharacters,
+            difference: null,
+            differencePercent: null,
+            withinTolerance: null,
+          };
+        }
+        const difference = invoice.characters - recordedCharacters;
+        const differencePercent =
+          recordedCharacters > 0 ? Math.round((difference / recordedCharacters) * 10000) / 100 : null;
+        return {
+          provider,
+          invoicedCharacters: invoice.characters,
+          recordedCharacters,
+          difference,
+          differencePercent,
+          withinTolerance:
+            differencePercent === null ? difference === 0 : Math.abs(differencePercent) <= this.tolerancePercent,
+          amount: invoice.amount === undefined || invoice.amount === null ? undefined : Number(invoice.amount),
+          currency: invoice.currency,
+          reference: invoice.reference,
+        };
+      });
+
+    const accountLines = [...accounts.values()]
+      .map((account) => ({
+        ...account,
+        unchargedCharacters: Math.max(account.providerCharacters - account.chargedCharacters, 0),
+      }))
+      .sort((a, b) => b.unchargedCharacters - a.unchargedCharacters || b.providerCharacters - a.providerCharacters);
+
+    return {
+      period,
+      tolerancePercent: this.tolerancePercent,
+      chargedCharacters: charged.reduce((sum, daily) => sum + daily.totalCharacters, 0),
+      providerCharacters: usage.reduce((sum, row) => sum + row.characters, 0),
+      providers,
+      accounts: accountLines.slice(0, limit),
+      accountsTotal: accountLines.length,
+    };
+  }
+
+  private readRows(dto: ProviderInvoiceImportDto): Record<string, any>[] {
+    let rows: Record<string, any>[];
+    if (dto.format === UsageImportFormat.CSV) {
+      if (!dto.csv) {
+        throw new BadRequestException('csv is required when format is csv');
+      }
+      try {
+        rows = parseCsv(dto.csv);
+      } catch (error) {
+        throw new BadRequestException(`Invalid CSV: ${error.message}`);
+      }
+    } else {
+      if (!dto.records) {
+        throw new BadRequestException('records is required when format is json');
+      }
+      rows = dto.records;
+    }
+
+    if (rows.length === 0) {
+      throw new BadRequestException('No records to import');
+    }
+    return rows;
+  }
+}
+
+/**
+ * CSV 中的值都是字符串：空值视为未提供，数字列转换为数字
+ */
+function normalizeRow(row: Record<string, any>): Record<string, any> {
+  const normalized: Record<string, any> = {};
+  for (const [key, value] of Object.entries(row || {})) {
+    if (value === '' || value === null) {
+      continue;
+    }
+    normalized[key] = ['characters', 'amount'].includes(key) && typeof value === 'string' ? Number(value) : value;
+  }
+  return normalized;
+}
@@ -0,0 +1,78 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { ProviderThrottleService } from './provider-throttle.service';
+import { RedisService } from '../../../common/services/redis.service';
+import { CircuitBreakerState } from '../../../common/utils/circuit-breaker.service';
+
+describe('ProviderThrottleService', () => {
+  let service: ProviderThrottleService;
+
+  const mockRedisService = {
+    client: { eval: jest.fn() },
+  };
+
+  const config: Record<string, any> = {
+    PROVIDER_CIRCUIT_FAILURE_THRESHOLD: 2,
+    PROVIDER_CIRCUIT_COOLDOWN_MS: 1000,
+    PROVIDER_THROTTLE_MAX_WAIT_MS: 50,
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        ProviderThrottleService,
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, def?: any) => config[key] ?? def) } },
+      ],
+    }).compile();
+
+    service = module.get<ProviderThrottleService>(ProviderThrottleService);
+    mockRedisService.client.eval.mockResolvedValue(0);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+    jest.useRealTimers();
+  });
+
+  it('取到令牌时直接调用翻译服务', async () => {
+    await expect(service.execute('aliyun', async () => 'ok')).resolves.toBe('ok');
+    expect(mockRedisService.client.eval).toHaveBeenCalledWith(expect.any(String), 1, 'provider_bucket:aliyun', 10, 10);
+  });
+
+  it('令牌不足时等待后重试，超过最长等待时间返回 429', async () => {
+    mockRedisService.client.eval.mockResolvedValueOnce(10).mockResolvedValueOnce(0);
+    await expect(service.execute('aliyun', async () => 'ok')).resolves.toBe('ok');
+    expect(mockRedisService.client.eval).toHaveBeenCalledTimes(2);
+
+    mockRedisService.client.eval.mockResolvedValueOnce(500);
+    await expect(service.execute('aliyun', async () => 'ok')).rejects.toThrow('rate limit reached');
+  });
+
+  it('连续失败后熔断，冷却期内直接失败', async () => {
+    const failing = jest.fn().mockRejectedValue(new Error('provider down'));
+    await expect(service.execute('aliyun', failing)).rejects.toThrow('provider down');
+    await expect(service.execute('aliyun', failing)).rejects.toThrow('provider down');
+
+    await expect(service.execute('aliyun', failing)).rejects.toThrow('circuit open');
+    expect(failing).toHaveBeenCalledTimes(2);
+    expect(service.getStatus()[0]).toMatchObject({ provider: 'aliyun', state: CircuitBreakerState.OPEN });
+  });
+
+  it('冷却结束后放行一个探测请求，成功则恢复', async () => {
+    jest.useFakeTimers({ now: Date.now() });
+    const failing = jest.fn().mockRejectedValue(new Error('provider down'));
+    await expect(service.execute('aliyun', failing)).rejects.toThrow();
+    await expect(service.execute('aliyun', failing)).rejects.toThrow();
+
+    jest.setSystemTime(Date.now() + 1000);
+    await expect(service.execute('aliyun', async () => 'ok')).resolves.toBe('ok');
+    expect(service.getStatus()[0]).toMatchObject({ state: CircuitBreakerState.CLOSED, consecutiveFailures: 0 });
+  });
+
+  it('Redis 不可用时不限流', async () => {
+    mockRedisService.client.eval.mockRejectedValueOnce(new Error('ECONNREFUSED'));
+
+    await expect(service.execute('aliyun', async () => 'ok')).resolves.toBe('ok');
+  });
+});
@@ -0,0 +1,169 @@
+import { HttpException, HttpStatus, Injectable, Logger, ServiceUnavailableException } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { RedisService } from '../../../common/services/redis.service';
+import { CircuitBreakerState } from '../../../common/utils/circuit-breaker.service';
+
+/**
+ * Redis 令牌桶：按 Redis 服务器时间补充令牌，取到令牌返回 0，否则返回需要等待的毫秒数
+ */
+const TAKE_TOKEN_SCRIPT = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(now - ts, 0) * rate / 1000)
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+else
+  wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return wait
+`;
+
+interface ProviderCircuit {
+  state: CircuitBreakerState;
+  consecutiveFailures: number;
+  openedAt: number;
+  /** 半开状态下已有一个探测请求在执行 */
+  probing: boolean;
+}
+
+export interface ProviderCircuitStatus {
+  provider: string;
+  state: CircuitBreakerState;
+  consecutiveFailures: number;
+  retryAt?: string;
+}
+
+/**
+ * 翻译服务调用的限流和熔断
+ * 每个翻译服务一个令牌桶（存在 Redis 中，所有 worker 共享 QPS），取不到令牌时等待，超过最长等待时间返回 429；
+ * 连续失败达到阈值后熔断，冷却期内直接失败（503），冷却结束后放行一个探测请求，成功则恢复
+ */
+@Injectable()
+export class ProviderThrottleService {
+  private readonly logger = new Logger(ProviderThrottleService.name);
+  private readonly defaultQps: number;
+  private readonly maxWaitMs: number;
+  private readonly failureThreshold: number;
+  private readonly cooldownMs: number;
+  private readonly circuits = new Map<string, ProviderCircuit>();
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly redisService: RedisService,
+  ) {
+    this.defaultQps = Number(this.configService.get('PROVIDER_QPS', 10));
+    this.maxWaitMs = Number(this.configService.get('PROVIDER_THROTTLE_MAX_WAIT_MS', 10000));
+    this.failureThreshold = Math.max(Number(this.configService.get('PROVIDER_CIRCUIT_FAILURE_THRESHOLD', 5)), 1);
+    this.cooldownMs = Number(this.configService.get('PROVIDER_CIRCUIT_COOLDOWN_MS', 30000));
+  }
+
+  async execute<T>(provider: string, fn: () => Promise<T>): Promise<T> {
+    const circuit = this.circuitOf(provider);
+    this.assertClosed(provider, circuit);
+    await this.acquire(provider);
+
+    try {
+      const result = await fn();
+      this.onSuccess(provider, circuit);
+      return result;
+    } catch (error) {
+      this.onFailure(provider, circuit, error);
+      throw error;
+    }
+  }
+
+  getStatus(): ProviderCircuitStatus[] {
+    return [...this.circuits.entries()].map(([provider, circuit]) => ({
+      provider,
+      state: circuit.state,
+      consecutiveFailures: circuit.consecutiveFailures,
+      retryAt:
+        circuit.state === CircuitBreakerState.OPEN
+          ? new Date(circuit.openedAt + this.cooldownMs).toISOString()
+          : undefined,
+    }));
+  }
+
+  private circuitOf(provider: string): ProviderCircuit {
+    let circuit = this.circuits.get(provider);
+    if (!circuit) {
+      circuit = { state: CircuitBreakerState.CLOSED, consecutiveFailures: 0, openedAt: 0, probing: false };
+      this.circuits.set(provider, circuit);
+    }
+    return circuit;
+  }
+
+  private assertClosed(provider: string, circuit: ProviderCircuit): void {
+    if (circuit.state === CircuitBreakerState.OPEN && Date.now() - circuit.openedAt >= this.cooldownMs) {
+      circuit.state = CircuitBreakerState.HALF_OPEN;
+      circuit.probing = false;
+    }
+    if (circuit.state === CircuitBreakerState.HALF_OPEN && !circuit.probing) {
+      circuit.probing = true;
+      return;
+    }
+    if (circuit.state !== CircuitBreakerState.CLOSED) {
+      throw new ServiceUnavailableException(`Translation provider ${provider} is unavailable (circuit open)`);
+    }
+  }
+
+  private onSuccess(provider: string, circuit: ProviderCircuit): void {
+    if (circuit.state !== CircuitBreakerState.CLOSED) {
+      this.logger.log(`Circuit for provider ${provider} closed`);
+    }
+    circuit.state = CircuitBreakerState.CLOSED;
+    circuit.consecutiveFailures = 0;
+    circuit.probing = false;
+  }
+
+  private onFailure(provider: string, circuit: ProviderCircuit, error: Error): void {
+    circuit.consecutiveFailures += 1;
+    circuit.probing = false;
+    if (circuit.state === CircuitBreakerState.HALF_OPEN || circuit.consecutiveFailures >= this.failureThreshold) {
+      if (circuit.state !== CircuitBreakerState.OPEN) {
+        this.logger.warn(
+          `Circuit for provider ${provider} opened after ${circuit.consecutiveFailures} failure(s): ${error.message}`,
+        );
+      }
+      circuit.state = CircuitBreakerState.OPEN;
+      circuit.openedAt = Date.now();
+    }
+  }
+
+  private async acquire(provider: string): Promise<void> {
+    const qps = Number(this.configService.get(`PROVIDER_QPS_${provider.toUpperCase()}`, this.defaultQps));
+    if (!(qps > 0)) {
+      return;
+    }
+    const deadline = Date.now() + this.maxWaitMs;
+
+    for (;;) {
+      const wait = await this.takeToken(provider, qps);
+      if (wait <= 0) {
+        return;
+      }
+      if (Date.now() + wait > deadline) {
+        throw new HttpException(`Translation provider ${provider} rate limit reached`, HttpStatus.TOO_MANY_REQUESTS);
+      }
+      await new Promise((resolve) => setTimeout(resolve, wait));
+    }
+  }
+
+  private async takeToken(provider: string, qps: number): Promise<number> {
+    try {
+      return Number(await this.redisService.client.eval(TAKE_TOKEN_SCRIPT, 1, `provider_bucket:${provider}`, qps, qps));
+    } catch (error) {
+      // 限流只是保护，Redis 不可用时不阻塞翻译
+      this.logger.error(`Provider rate limiter unavailable: ${error.message}`);
+      return 0;
+    }
+  }
+}
@@ -0,0 +1,70 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { ProviderUsageService, previousPeriod } from './provider-usage.service';
+import { ProviderUsageMonthlyRepository } from '../repositories/character-usage.repository';
+import { RedisService } from '../../../common/services/redis.service';
+
+describe('ProviderUsageService', () => {
+  let service: ProviderUsageService;
+
+  const mockMulti = {
+    hincrby: jest.fn().mockReturnThis(),
+    expire: jest.fn().mockReturnThis(),
+    exec: jest.fn(),
+  };
+  const mockRedisService = {
+    client: { multi: jest.fn(() => mockMulti), hgetall: jest.fn() },
+  };
+  const mockUsageRepository = {
+    list: jest.fn(),
+    build: jest.fn((data) => ({ ...data })),
+    save: jest.fn(),
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        ProviderUsageService,
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: ProviderUsageMonthlyRepository, useValue: mockUsageRepository },
+      ],
+    }).compile();
+
+    service = module.get<ProviderUsageService>(ProviderUsageService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('应按月、翻译服务和账户累加计数', async () => {
+    await service.record('aliyun', 12, 'u1');
+
+    expect(mockMulti.hincrby).toHaveBeenCalledWith(
+      expect.stringMatching(/^provider_usage:\d{4}-\d{2}$/),
+      'aliyun|u1',
+      12,
+    );
+  });
+
+  it('同步时写入累计值，只更新有变化的行', async () => {
+    mockRedisService.client.hgetall.mockResolvedValue({ 'aliyun|u1': '120', 'aliyun|u2': '30', 'aliyun|': '5' });
+    const unchanged = { provider: 'aliyun', period: '2026-09', userId: 'u2', characters: 30 };
+    const stale = { provider: 'aliyun', period: '2026-09', userId: 'u1', characters: 100 };
+    mockUsageRepository.list.mockResolvedValue([unchanged, stale]);
+
+    const written = await service.flush(['2026-09']);
+
+    expect(written).toBe(2);
+    expect(stale.characters).toBe(120);
+    expect(mockUsageRepository.save).toHaveBeenCalledWith([
+      stale,
+      expect.objectContaining({ provider: 'aliyun', period: '2026-09', userId: undefined, characters: 5 }),
+    ]);
+  });
+
+  it('应跨年计算上个月份', () => {
+    expect(previousPeriod(new Date('2026-01-15T00:00:00Z'))).toBe('2025-12');
+  });
+});
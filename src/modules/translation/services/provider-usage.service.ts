@@ -0,0 +1,102 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { v4 as uuidv4 } from 'uuid';
+import { RedisService } from '../../../common/services/redis.service';
+import { ProviderUsageMonthlyRepository } from '../repositories/character-usage.repository';
+import { ProviderUsageMonthly } from '../entities/provider-usage.entity';
+
+const usageKey = (period: string) => `provider_usage:${period}`;
+
+/** 哈希字段：翻译服务|用户 ID，无法归属的调用用户 ID 为空 */
+const fieldOf = (provider: string, userId?: string) => `${provider}|${userId ?? ''}`;
+
+/** 当前月份（UTC，YYYY-MM） */
+export function currentPeriod(now = new Date()): string {
+  return now.toISOString().slice(0, 7);
+}
+
+/** 上个月份（UTC，YYYY-MM） */
+export function previousPeriod(now = new Date()): string {
+  return new Date(Date.UTC(now.getUTCFullYear(), now.getUTCMonth() - 1, 1)).toISOString().slice(0, 7);
+}
+
+/**
+ * 翻译服务计费字符统计
+ * 每次成功调用翻译服务（缓存命中和断点复用不计）时按月、服务和账户累加 Redis 计数，
+ * worker 定时把计数同步到数据库，供与服务商账单对账；计数写入失败只记录日志，不影响翻译
+ */
+@Injectable()
+export class ProviderUsageService {
+  private readonly logger = new Logger(ProviderUsageService.name);
+  private readonly counterTtlSeconds: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly redisService: RedisService,
+    private readonly usageRepository: ProviderUsageMonthlyRepository,
+  ) {
+    this.counterTtlSeconds = Number(this.configService.get('PROVIDER_USAGE_COUNTER_TTL_DAYS', 100)) * 24 * 60 * 60;
+  }
+
+  async record(provider: string, characters: number, userId?: string): Promise<void> {
+    if (characters <= 0) {
+      return;
+    }
+    const key = usageKey(currentPeriod());
+    try {
+      await this.redisService.client
+        .multi()
+        .hincrby(key, fieldOf(provider, userId), characters)
+        .expire(key, this.counterTtlSeconds)
+        .exec();
+    } catch (error) {
+      this.logger.error(`Recording ${characters} ${provider} characters failed: ${error.message}`);
+    }
+  }
+
+  /**
+   * 把指定月份（默认本月和上月）的 Redis 计数写入数据库；写入的是累计值，重复执行结果相同
+   * Redis 计数已过期的月份保留数据库中最后一次同步的值
+   */
+  async flush(periods = [currentPeriod(), previousPeriod()]): Promise<number> {
+    let written = 0;
+    for (const period of periods) {
+      const counters = await this.redisService.client.hgetall(usageKey(period));
+      const fields = Object.keys(counters ?? {});
+      if (fields.length === 0) {
+        continue;
+      }
+
+      const existing = new Map(
+        (await this.usageRepository.list({ period })).map((row) => [fieldOf(row.provider, row.userId), row]),
+      );
+      const rows: ProviderUsageMonthly[] = [];
+      for (const field of fields) {
+        const characters = Number(counters[field]);
+        const [provider, userId] = field.split('|');
+        const row =
+          existing.get(field) ??
+          this.usageRepository.build({ id: uuidv4(), provider, period, userId: userId || undefined, characters: 0 });
+        if (row.characters !== characters) {
+          row.characters = characters;
+          rows.push(row);
+        }
+      }
+      if (rows.length > 0) {
+        await this.usageRepository.save(rows);
+        written += rows.length;
+      }
+    }
+    return written;
+  }
+
+  /**
+   * 某月的计费字符明细；先同步一次计数，保证当月数据是最新的（Redis 不可用时使用上次同步的值）
+   */
+  async getUsage(period: string): Promise<ProviderUsageMonthly[]> {
+    await this.flush([period]).catch((error) =>
+      this.logger.warn(`Syncing provider usage for ${period} failed, using the last synced totals: ${error.message}`),
+    );
+    return this.usageRepository.list({ period });
+  }
+}
@@ -0,0 +1,100 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { getQueueToken } from '@nestjs/bull';
+import { QualityEstimationService, QUALITY_ESTIMATION_JOB } from './quality-estimation.service';
+import { UserJsonDataRepository } from '../repositories/translation-task.repository';
+import { TranslationUtils } from '../utils/translation.utils';
+import { DocumentArchiveService } from './document-archive.service';
+
+describe('QualityEstimationService', () => {
+  let service: QualityEstimationService;
+
+  const mockTranslationQueue = { add: jest.fn() };
+  const mockUserJsonDataRepository = { get: jest.fn(), save: jest.fn() };
+  const mockTranslationUtils = {
+    translateJson: jest.fn(),
+    getIgnoredFields: jest.fn((fields: string) => (fields ? fields.split(',') : [])),
+  };
+  const mockDocumentArchiveService = {
+    load: jest.fn(async (document: any) => ({
+      originJson: document.originJson,
+      translatedJson: document.translatedJson,
+      archived: false,
+    })),
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        QualityEstimationService,
+        { provide: getQueueToken('translation'), useValue: mockTranslationQueue },
+        { provide: UserJsonDataRepository, useValue: mockUserJsonDataRepository },
+        { provide: TranslationUtils, useValue: mockTranslationUtils },
+        { provide: DocumentArchiveService, useValue: mockDocumentArchiveService },
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+      ],
+    }).compile();
+
+    service = module.get<QualityEstimationService>(QualityEstimationService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('超过字符上限的文档不评估', async () => {
+    await service.schedule('task1', 50000);
+    await service.schedule('task2', 100);
+
+    expect(mockTranslationQueue.add).toHaveBeenCalledTimes(1);
+    expect(mockTranslationQueue.add).toHaveBeenCalledWith(QUALITY_ESTIMATION_JOB, { taskId: 'task2' }, expect.anything());
+  });
+
+  it('按回译相似度为每个字符串打分，跳过忽略字段和锁定的键', async () => {
+    const document: any = {
+      id: 'task1',
+      originJson: JSON.stringify({ title: 'Save changes', nav: { home: 'Home' }, id: 'abc', count: 3 }),
+      translatedJson: JSON.stringify({ title: 'Enregistrer', nav: { home: 'Accueil' }, id: 'abc', count: 3 }),
+      fromLang: 'en',
+      toLang: 'fr',
+      ignoredFields: 'id',
+      lockedKeys: ['nav.home'],
+    };
+    mockUserJsonDataRepository.get.mockResolvedValue(document);
+    mockTranslationUtils.translateJson.mockResolvedValue('{"title":"Save"}');
+
+    await service.estimate('task1');
+
+    expect(mockTranslationUtils.translateJson).toHaveBeenCalledWith('{"title":"Enregistrer"}', 'fr', 'en', 'id', {
+      provider: undefined,
+    });
+    expect(document.qualityScores).toEqual({ title: 0.333 });
+    expect(document.qualityScore).toBe(0.333);
+    expect(service.summarize(document).lowConfidenceKeys).toEqual([{ key: 'title', score: 0.333 }]);
+    expect(mockUserJsonDataRepository.save).toHaveBeenCalledWith(document);
+  });
+
+  it('已归档的文档从归档取回原文和译文后评分', async () => {
+    const document: any = {
+      id: 'task1',
+      originJson: '',
+      translatedJson: null,
+      archivedAt: new Date(),
+      archiveKey: 'archive/user1/task1.json.gz',
+      fromLang: 'en',
+      toLang: 'fr',
+    };
+    mockUserJsonDataRepository.get.mockResolvedValue(document);
+    mockDocumentArchiveService.load.mockResolvedValueOnce({
+      originJson: JSON.stringify({ title: 'Save' }),
+      translatedJson: JSON.stringify({ title: 'Enregistrer' }),
+      archived: true,
+    });
+    mockTranslationUtils.translateJson.mockResolvedValue('{"title":"Save"}');
+
+    await service.estimate('task1');
+
+    expect(mockDocumentArchiveService.load).toHaveBeenCalledWith(document);
+    expect(document.qualityScores).toEqual({ title: 1 });
+  });
+});
@@ -0,0 +1,137 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { InjectQueue } from '@nestjs/bull';
+import { Queue } from 'bull';
+import { UserJsonData } from '../entities/translation-task.entity';
+import { UserJsonDataRepository } from '../repositories/translation-task.repository';
+import { DocumentArchiveService } from './document-archive.service';
+import { TranslationUtils } from '../utils/translation.utils';
+import { flattenJson, getPath, pickPaths } from '../utils/json-diff';
+import { IgnoreMatcher } from '../utils/ignore-rules';
+import { stringSimilarity } from '../utils/similarity';
+
+export const QUALITY_ESTIMATION_JOB = 'estimate-quality';
+
+export interface QualitySummary {
+  /** 按字符数加权的整体分数，未评估时为 null */
+  score: number | null;
+  threshold: number;
+  lowConfidenceKeys: { key: string; score: number }[];
+}
+
+/**
+ * 译文质量估计
+ * 当前翻译服务不返回置信度，按回译相似度打分：把译文翻回源语言与原文比较，分数越低越值得人工审校。
+ * 在翻译完成后作为独立的低优先级队列任务执行，不阻塞结果推送；人工修改和锁定的键不评分
+ */
+@Injectable()
+export class QualityEstimationService {
+  private readonly logger = new Logger(QualityEstimationService.name);
+  private readonly enabled: boolean;
+  private readonly maxChars: number;
+  private readonly threshold: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    @InjectQueue('translation') private readonly translationQueue: Queue,
+    private readonly userJsonDataRepository: UserJsonDataRepository,
+    private readonly translationUtils: TranslationUtils,
+    private readonly documentArchiveService: DocumentArchiveService,
+  ) {
+    this.enabled = this.configService.get('QUALITY_ESTIMATION_ENABLED', 'true') === 'true';
+    this.maxChars = Number(this.configService.get('QUALITY_ESTIMATION_MAX_CHARS', 20000));
+    this.threshold = Number(this.configService.get('QUALITY_LOW_CONFIDENCE_THRESHOLD', 0.6));
+  }
+
+  async schedule(taskId: string, charTotal: number): Promise<void> {
+    if (!this.enabled || charTotal > this.maxChars) {
+      return;
+    }
+    await this.translationQueue.add(
+      QUALITY_ESTIMATION_JOB,
+      { taskId },
+      { jobId: `${QUALITY_ESTIMATION_JOB}:${taskId}:${Date.now()}`, priority: 100, removeOnComplete: true },
+    );
+  }
+
+  /**
+   * 为文档的每个译文字符串评分并保存（由 translation 队列消费者调用）
+   */
+  async estimate(taskId: string): Promise<void> {
+    const document = await this.userJsonDataRepository.get({ id: taskId });
+    if (!document || document.purgedAt || (!document.translatedJson && !document.archivedAt)) {
+      return;
+    }
+    const content = await this.documentArchiveService.load(document);
+    if (!content.translatedJson) {
+      return;
+    }
+
+    const source = JSON.parse(content.originJson);
+    const translated = JSON.parse(content.translatedJson);
+    const ignored = this.translationUtils.getIgnoredFields(document.ignoredFields);
+    const ignore = IgnoreMatcher.compile(document.ignoreRules);
+    const locked = new Set(document.lockedKeys ?? []);
+    const paths = [...flattenJson(source).entries()]
+      .filter(([, value]) => typeof value === 'string' && value.trim() !== '')
+      .map(([key]) => JSON.parse(key) as string[])
+      .filter((path) => !path.some((key) => ignored.includes(key)) && !locked.has(path.join('.')))
+      .filter((path) => !ignore?.excludes(path, getPath(source, path)))
+      .filter((path) => typeof getPath(translated, path) === 'string');
+    if (paths.length === 0) {
+      return;
+    }
+
+    const backTranslated = JSON.parse(
+      await this.translationUtils.translateJson(
+        JSON.stringify(pickPaths(translated, paths)),
+        document.toLang,
+        document.fromLang,
+        document.ignoredFields || '',
+        {
+          provider: document.provider,
+          userId: document.userId,
+          ignoreRules: document.ignoreRules,
+          maskPii: document.maskPii,
+          piiPatterns: document.piiPatterns,
+        },
+      ),
+    );
+
+    const scores: Record<string, number> = {};
+    let weighted = 0;
+    let totalChars = 0;
+    for (const path of paths) {
+      const original: string = getPath(source, path);
+      const score = Math.round(stringSimilarity(original, String(getPath(backTranslated, path) ?? '')) * 1000) / 1000;
+      scores[path.join('.')] = score;
+      weighted += score * original.length;
+      totalChars += original.length;
+    }
+
+    document.qualityScores = scores;
+    document.qualityScore = Math.round((weighted / totalChars) * 1000) / 1000;
+    await this.userJsonDataRepository.save(document);
+    this.logger.log(`Quality of ${taskId}: ${document.qualityScore} over ${paths.length} string(s)`);
+  }
+
+  /**
+   * 人工修改过的键不再沿用机器译文的分数
+   */
+  forgetKeys(document: UserJsonData, keys: string[]): void {
+    if (!document.qualityScores) {
+      return;
+    }
+    for (const key of keys) {
+      delete document.qualityScores[key];
+    }
+  }
+
+  summarize(document: UserJsonData): QualitySummary {
+    const lowConfidenceKeys = Object.entries(document.qualityScores ?? {})
+      .filter(([, score]) => score < this.threshold)
+      .sort(([, a], [, b]) => a - b)
+      .map(([key, score]) => ({ key, score }));
+    return { score: document.qualityScore ?? null, threshold: this.threshold, lowConfidenceKeys };
+  }
+}
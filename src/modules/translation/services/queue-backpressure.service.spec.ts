@@ -0,0 +1,53 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { HttpException } from '@nestjs/common';
+import { getQueueToken } from '@nestjs/bull';
+import { QueueBackpressureService } from './queue-backpressure.service';
+import { SubscriptionTier } from '../../subscription/entities/subscription-plan.entity';
+
+describe('QueueBackpressureService', () => {
+  const mockTranslationQueue = { getJobCounts: jest.fn() };
+
+  const createService = async (config: Record<string, string> = {}) => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        QueueBackpressureService,
+        { provide: getQueueToken('translation'), useValue: mockTranslationQueue },
+        {
+          provide: ConfigService,
+          useValue: { get: jest.fn((key: string, defaultValue?: any) => config[key] ?? defaultValue) },
+        },
+      ],
+    }).compile();
+    return module.get<QueueBackpressureService>(QueueBackpressureService);
+  };
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('积压低于阈值时正常接受', async () => {
+    const service = await createService();
+    mockTranslationQueue.getJobCounts.mockResolvedValue({ waiting: 10, active: 2, delayed: 5000 });
+
+    expect(await service.assertAccepting('user1', SubscriptionTier.FREE)).toBeUndefined();
+  });
+
+  it('预计等待超过阈值时返回拥堵状态和预计开始时间', async () => {
+    const service = await createService({ BACKPRESSURE_MAX_WAIT_SECONDS: '600' });
+    mockTranslationQueue.getJobCounts.mockResolvedValue({ waiting: 118, active: 2 });
+
+    const status = await service.assertAccepting('user1', SubscriptionTier.STANDARD);
+
+    expect(status).toMatchObject({ congested: true, queueDepth: 120, estimatedWaitSeconds: 600 });
+    expect(new Date(status.estimatedStartAt).getTime()).toBeGreaterThan(Date.now());
+  });
+
+  it('拥堵时配置为拒绝的计划返回 429', async () => {
+    const service = await createService({ BACKPRESSURE_MAX_QUEUE_DEPTH: '100', BACKPRESSURE_REJECT_TIERS: 'free' });
+    mockTranslationQueue.getJobCounts.mockResolvedValue({ waiting: 100, active: 0 });
+
+    await expect(service.assertAccepting('user1', SubscriptionTier.FREE)).rejects.toThrow(HttpException);
+    await expect(service.assertAccepting('user2', SubscriptionTier.HOBBY)).resolves.toMatchObject({ congested: true });
+  });
+});
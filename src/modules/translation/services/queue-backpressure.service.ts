@@ -0,0 +1,85 @@
+import { HttpException, HttpStatus, Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { InjectQueue } from '@nestjs/bull';
+import { Queue } from 'bull';
+import { SubscriptionTier } from '../../subscription/entities/subscription-plan.entity';
+
+export interface BackpressureStatus {
+  /** 队列积压超过阈值 */
+  congested: boolean;
+  queueDepth: number;
+  estimatedWaitSeconds: number;
+  estimatedStartAt: string;
+}
+
+/**
+ * 创建接口的队列背压
+ * 积压的任务数或预计等待时间超过阈值时，接口改为返回 202 并附上预计开始时间；
+ * BACKPRESSURE_REJECT_TIERS 中的计划直接返回 429，避免任务在数小时的积压中静默等待
+ */
+@Injectable()
+export class QueueBackpressureService {
+  private readonly logger = new Logger(QueueBackpressureService.name);
+  private readonly maxQueueDepth: number;
+  private readonly maxWaitSeconds: number;
+  private readonly avgQueuedTaskSeconds: number;
+  private readonly rejectTiers: Set<string>;
+
+  constructor(
+    private readonly configService: ConfigService,
+    @InjectQueue('translation') private readonly translationQueue: Queue,
+  ) {
+    this.maxQueueDepth = Number(this.configService.get('BACKPRESSURE_MAX_QUEUE_DEPTH', 1000));
+    this.maxWaitSeconds = Number(this.configService.get('BACKPRESSURE_MAX_WAIT_SECONDS', 900));
+    this.avgQueuedTaskSeconds = Number(this.configService.get('TRANSLATION_AVG_TASK_SECONDS', 5));
+    this.rejectTiers = new Set(
+      String(this.configService.get('BACKPRESSURE_REJECT_TIERS', ''))
+        .split(',')
+        .map((tier) => tier.trim())
+        .filter(Boolean),
+    );
+  }
+
+  /**
+   * 按当前积压估算新任务的开始时间（不区分优先级，对高优先级任务偏保守）
+   */
+  async check(): Promise<BackpressureStatus> {
+    const counts = await this.translationQueue.getJobCounts();
+    const queueDepth = (counts.waiting ?? 0) + (counts.active ?? 0);
+    const estimatedWaitSeconds = Math.ceil(queueDepth * this.avgQueuedTaskSeconds);
+    const congested =
+      (this.maxQueueDepth > 0 && queueDepth >= this.maxQueueDepth) ||
+      (this.maxWaitSeconds > 0 && estimatedWaitSeconds >= this.maxWaitSeconds);
+
+    return {
+      congested,
+      queueDepth,
+      estimatedWaitSeconds,
+      estimatedStartAt: new Date(Date.now() + estimatedWaitSeconds * 1000).toISOString(),
+    };
+  }
+
+  /**
+   * 队列拥堵时，配置为拒绝的计划抛出 429，其余返回拥堵状态由调用方以 202 响应
+   */
+  async assertAccepting(userId: string, tier: SubscriptionTier): Promise<BackpressureStatus | undefined> {
+    const status = await this.check();
+    if (!status.congested) {
+      return undefined;
+    }
+
+    if (this.rejectTiers.has(tier)) {
+      this.logger.warn(`Rejecting task for ${tier} user ${userId}: queue depth ${status.queueDepth}`);
+      throw new HttpException(
+        {
+          statusCode: HttpStatus.TOO_MANY_REQUESTS,
+          message: 'Translation queue is congested, retry later',
+          queueDepth: status.queueDepth,
+          retryAfterSeconds: status.estimatedWaitSeconds,
+        },
+        HttpStatus.TOO_MANY_REQUESTS,
+      );
+    }
+    return status;
+  }
+}
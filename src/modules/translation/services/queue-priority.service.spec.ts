@@ -0,0 +1,74 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { QueuePriorityService, TaskPriority } from './queue-priority.service';
+import { PlanLimitsService } from '../../subscription/services/plan-limits.service';
+import { SubscriptionTier } from '../../subscription/entities/subscription-plan.entity';
+
+describe('QueuePriorityService', () => {
+  const mockPlanLimitsService = { resolve: jest.fn() };
+
+  const createService = async (config: Record<string, string> = {}) => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        QueuePriorityService,
+        { provide: PlanLimitsService, useValue: mockPlanLimitsService },
+        {
+          provide: ConfigService,
+          useValue: { get: jest.fn((key: string, defaultValue?: any) => config[key] ?? defaultValue) },
+        },
+      ],
+    }).compile();
+    return module.get<QueuePriorityService>(QueuePriorityService);
+  };
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('付费计划应进入 critical，免费计划进入 low', async () => {
+    const service = await createService();
+
+    mockPlanLimitsService.resolve.mockResolvedValueOnce({ tier: SubscriptionTier.STANDARD });
+    expect(await service.resolve('user1', 100)).toEqual({
+      priority: TaskPriority.CRITICAL,
+      tier: SubscriptionTier.STANDARD,
+      queuePriority: 1,
+    });
+
+    mockPlanLimitsService.resolve.mockResolvedValueOnce({ tier: SubscriptionTier.FREE });
+    expect(await service.resolve('user2', 100)).toEqual({
+      priority: TaskPriority.LOW,
+      tier: SubscriptionTier.FREE,
+      queuePriority: 10,
+    });
+  });
+
+  it('超大文档应降一级', async () => {
+    const service = await createService({ QUEUE_LARGE_PAYLOAD_CHARS: '1000' });
+    mockPlanLimitsService.resolve.mockResolvedValue({ tier: SubscriptionTier.PREMIUM });
+
+    expect((await service.resolve('user1', 5000)).priority).toBe(TaskPriority.DEFAULT);
+  });
+
+  it('请求只能降低优先级，不能高于计划级别', async () => {
+    const service = await createService();
+    mockPlanLimitsService.resolve.mockResolvedValue({ tier: SubscriptionTier.HOBBY });
+
+    expect((await service.resolve('user1', 100, TaskPriority.LOW)).priority).toBe(TaskPriority.LOW);
+    expect((await service.resolve('user1', 100, TaskPriority.CRITICAL)).priority).toBe(TaskPriority.DEFAULT);
+  });
+
+  it('应该支持按配置覆盖计划映射和队列权重', async () => {
+    const service = await createService({
+      QUEUE_PRIORITY_BY_TIER: 'free=default,unknown=critical',
+      QUEUE_PRIORITY_WEIGHTS: 'default=3,low=abc',
+    });
+    mockPlanLimitsService.resolve.mockResolvedValue(null);
+
+    expect(await service.resolve('user1', 100)).toEqual({
+      priority: TaskPriority.DEFAULT,
+      tier: SubscriptionTier.FREE,
+      queuePriority: 3,
+    });
+  });
+});
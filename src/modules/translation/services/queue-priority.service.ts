@@ -0,0 +1,114 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { PlanLimitsService } from '../../subscription/services/plan-limits.service';
+import { SubscriptionTier } from '../../subscription/entities/subscription-plan.entity';
+
+export enum TaskPriority {
+  CRITICAL = 'critical',
+  DEFAULT = 'default',
+  LOW = 'low',
+}
+
+/** 从高到低排列，用于升降级 */
+const PRIORITY_ORDER: TaskPriority[] = [TaskPriority.CRITICAL, TaskPriority.DEFAULT, TaskPriority.LOW];
+
+/** Bull 的 priority 数值越小越先执行 */
+const DEFAULT_BULL_PRIORITIES: Record<TaskPriority, number> = {
+  [TaskPriority.CRITICAL]: 1,
+  [TaskPriority.DEFAULT]: 5,
+  [TaskPriority.LOW]: 10,
+};
+
+const DEFAULT_TIER_PRIORITIES: Record<SubscriptionTier, TaskPriority> = {
+  [SubscriptionTier.PREMIUM]: TaskPriority.CRITICAL,
+  [SubscriptionTier.STANDARD]: TaskPriority.CRITICAL,
+  [SubscriptionTier.HOBBY]: TaskPriority.DEFAULT,
+  [SubscriptionTier.FREE]: TaskPriority.LOW,
+};
+
+export interface ResolvedPriority {
+  priority: TaskPriority;
+  /** 用户当前计划等级 */
+  tier: SubscriptionTier;
+  /** 传给 Bull 的 priority 值 */
+  queuePriority: number;
+}
+
+/**
+ * 解析 key=value,key2=value2 形式的配置，忽略无法识别的键和值
+ */
+export function parsePriorityMap<K extends string, V>(
+  raw: string | undefined,
+  keys: readonly K[],
+  parseValue: (value: string) => V | undefined,
+): Partial<Record<K, V>> {
+  const result: Partial<Record<K, V>> = {};
+  for (const pair of (raw ?? '').split(',')) {
+    const [key, value] = pair.split('=').map((part) => part?.trim());
+    const parsed = value !== undefined ? parseValue(value) : undefined;
+    if (keys.includes(key as K) && parsed !== undefined) {
+      result[key as K] = parsed;
+    }
+  }
+  return result;
+}
+
+/**
+ * 翻译任务队列优先级
+ * 按用户计划确定基础优先级，超大文档降一级；调用方可以在请求中主动降低优先级，但不能高于计划允许的级别。
+ * 付费用户的任务不会被免费用户的大文档堵在后面
+ */
+@Injectable()
+export class QueuePriorityService {
+  private readonly logger = new Logger(QueuePriorityService.name);
+  private readonly tierPriorities: Record<SubscriptionTier, TaskPriority>;
+  private readonly queuePriorities: Record<TaskPriority, number>;
+  private readonly largePayloadChars: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly planLimitsService: PlanLimitsService,
+  ) {
+    const isPriority = (value: string) =>
+      PRIORITY_ORDER.includes(value as TaskPriority) ? (value as TaskPriority) : undefined;
+    const isPositiveInt = (value: string) => (/^\d+$/.test(value) && Number(value) > 0 ? Number(value) : undefined);
+
+    this.tierPriorities = {
+      ...DEFAULT_TIER_PRIORITIES,
+      ...parsePriorityMap(this.configService.get('QUEUE_PRIORITY_BY_TIER'), Object.values(SubscriptionTier), isPriority),
+    };
+    this.queuePriorities = {
+      ...DEFAULT_BULL_PRIORITIES,
+      ...parsePriorityMap(this.configService.get('QUEUE_PRIORITY_WEIGHTS'), PRIORITY_ORDER, isPositiveInt),
+    };
+    this.largePayloadChars = Number(this.configService.get('QUEUE_LARGE_PAYLOAD_CHARS', 200000));
+  }
+
+  async resolve(userId: string, charTotal: number, requested?: TaskPriority): Promise<ResolvedPriority> {
+    const limits = await this.planLimitsService.resolve(userId);
+    const tier = limits?.tier ?? SubscriptionTier.FREE;
+    let priority = this.tierPriorities[tier];
+
+    if (this.largePayloadChars > 0 && charTotal >= this.largePayloadChars) {
+      priority = demote(priority);
+    }
+    // 只允许主动降级
+    if (requested && PRIORITY_ORDER.indexOf(requested) > PRIORITY_ORDER.indexOf(priority)) {
+      priority = requested;
+    }
+
+    return { priority, tier, queuePriority: this.queuePriorities[priority] };
+  }
+
+  /**
+   * 已保存任务重新入队时使用的 Bull 优先级，未记录优先级的旧任务按 default 处理
+   */
+  weightOf(priority?: string): number {
+    const known = PRIORITY_ORDER.includes(priority as TaskPriority) ? (priority as TaskPriority) : TaskPriority.DEFAULT;
+    return this.queuePriorities[known];
+  }
+}
+
+function demote(priority: TaskPriority): TaskPriority {
+  return PRIORITY_ORDER[Math.min(PRIORITY_ORDER.indexOf(priority) + 1, PRIORITY_ORDER.length - 1)];
+}
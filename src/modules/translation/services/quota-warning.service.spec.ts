@@ -0,0 +1,89 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager } from '@mikro-orm/core';
+import { QuotaWarningService, QUOTA_WARNING_EVENT, parseThresholds } from './quota-warning.service';
+import { RedisService } from '../../../common/services/redis.service';
+import { WebhookService } from '../../webhook/webhook.service';
+import { TenantMailService } from '../../tenant/services/tenant-mail.service';
+import { PlanLimitsService } from '../../subscription/services/plan-limits.service';
+import { CharacterUsageLogDailyRepository } from '../repositories/character-usage.repository';
+
+describe('QuotaWarningService', () => {
+  let service: QuotaWarningService;
+  let sentMarkers: Set<string>;
+
+  const mockRedisService = {
+    setIfAbsent: jest.fn(async (key: string) => {
+      if (sentMarkers.has(key)) {
+        return false;
+      }
+      sentMarkers.add(key);
+      return true;
+    }),
+  };
+
+  const mockWebhookService = {
+    dispatchEvent: jest.fn().mockResolvedValue(true),
+  };
+
+  const mockTenantMailService = {
+    send: jest.fn().mockResolvedValue(true),
+  };
+
+  const mockEntityManager = {
+    findOne: jest.fn().mockResolvedValue({ email: 'user@example.com' }),
+  };
+
+  beforeEach(async () => {
+    sentMarkers = new Set();
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        QuotaWarningService,
+        {
+          provide: ConfigService,
+          useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) },
+        },
+        { provide: EntityManager, useValue: mockEntityManager },
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: WebhookService, useValue: mockWebhookService },
+        { provide: TenantMailService, useValue: mockTenantMailService },
+        { provide: PlanLimitsService, useValue: { resolve: jest.fn() } },
+        { provide: CharacterUsageLogDailyRepository, useValue: { sumSince: jest.fn() } },
+      ],
+    }).compile();
+
+    service = module.get<QuotaWarningService>(QuotaWarningService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('应解析并排序阈值，忽略非法值', () => {
+    expect(parseThresholds('100, 80,abc,0,80')).toEqual([80, 100]);
+  });
+
+  it('未达到阈值时不通知', async () => {
+    await expect(service.evaluate('user1', undefined, 700, 1000)).resolves.toBeNull();
+    expect(mockWebhookService.dispatchEvent).not.toHaveBeenCalled();
+  });
+
+  it('越过阈值时发送 webhook 和邮件，同一阈值当月只通知一次', async () => {
+    const event = await service.evaluate('user1', 'tenant1', 850, 1000);
+
+    expect(event).toMatchObject({ threshold: 80, percent: 85, used: 850, limit: 1000 });
+    expect(mockWebhookService.dispatchEvent).toHaveBeenCalledWith('user1', 'tenant1', QUOTA_WARNING_EVENT, event);
+    expect(mockTenantMailService.send).toHaveBeenCalledWith('tenant1', expect.objectContaining({ to: 'user@example.com' }));
+
+    await expect(service.evaluate('user1', 'tenant1', 900, 1000)).resolves.toBeNull();
+    expect(mockWebhookService.dispatchEvent).toHaveBeenCalledTimes(1);
+  });
+
+  it('同时越过多个阈值时只通知最高的一个', async () => {
+    const event = await service.evaluate('user1', undefined, 1200, 1000);
+
+    expect(event?.threshold).toBe(100);
+    expect(mockWebhookService.dispatchEvent).toHaveBeenCalledTimes(1);
+    await expect(service.evaluate('user1', undefined, 850, 1000)).resolves.toBeNull();
+  });
+});
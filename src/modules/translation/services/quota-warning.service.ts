@@ -0,0 +1,132 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager } from '@mikro-orm/core';
+import { RedisService } from '../../../common/services/redis.service';
+import { WebhookService } from '../../webhook/webhook.service';
+import { TenantMailService } from '../../tenant/services/tenant-mail.service';
+import { PlanLimitsService } from '../../subscription/services/plan-limits.service';
+import { CharacterUsageLogDailyRepository } from '../repositories/character-usage.repository';
+import { User } from '../../user/entities/user.entity';
+
+export const QUOTA_WARNING_EVENT = 'quota.warning';
+
+export interface QuotaWarningEvent {
+  threshold: number;
+  percent: number;
+  used: number;
+  limit: number;
+  period: string;
+}
+
+/**
+ * 额度预警
+ * 当月用量首次越过配置的阈值（默认 80%、100%）时发送 quota.warning webhook 事件，并可选发送邮件
+ * 每个阈值每月只通知一次
+ */
+@Injectable()
+export class QuotaWarningService {
+  private readonly logger = new Logger(QuotaWarningService.name);
+  private readonly thresholds: number[];
+  private readonly emailEnabled: boolean;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly em: EntityManager,
+    private readonly redisService: RedisService,
+    private readonly webhookService: WebhookService,
+    private readonly tenantMailService: TenantMailService,
+    private readonly planLimitsService: PlanLimitsService,
+    private readonly dailyUsageRepository: CharacterUsageLogDailyRepository,
+  ) {
+    this.thresholds = parseThresholds(this.configService.get('QUOTA_WARNING_THRESHOLDS', '80,100'));
+    this.emailEnabled = this.configService.get('QUOTA_WARNING_EMAIL', 'true') === 'true';
+  }
+
+  /**
+   * 按当月实际用量检查，在用量写入后调用
+   */
+  async checkUsage(userId: string, tenantId?: string): Promise<void> {
+    const limits = await this.planLimitsService.resolve(userId);
+    if (!limits) {
+      return;
+    }
+    const used = await this.dailyUsageRepository.sumSince(userId, `${currentPeriod()}-01`);
+    await this.evaluate(userId, tenantId, used, limits.monthlyCharacterLimit);
+  }
+
+  /**
+   * 对给定用量触发尚未通知过的阈值；同时越过多个阈值时只通知最高的一个
+   */
+  async evaluate(userId: string, tenantId: string | undefined, used: number, limit: number): Promise<QuotaWarningEvent | null> {
+    if (limit <= 0 || this.thresholds.length === 0) {
+      return null;
+    }
+
+    const percent = Math.floor((used / limit) * 100);
+    const crossed = this.thresholds.filter((threshold) => percent >= threshold);
+    if (crossed.length === 0) {
+      return null;
+    }
+
+    const period = currentPeriod();
+    const ttlSeconds = 40 * 24 * 3600;
+    let notify: number | null = null;
+    for (const threshold of crossed) {
+      const key = `quota_warning:${userId}:${period}:${threshold}`;
+      if (await this.redisService.setIfAbsent(key, String(used), ttlSeconds)) {
+        notify = threshold;
+      }
+    }
+    if (notify === null) {
+      return null;
+    }
+
+    const event: QuotaWarningEvent = { threshold: notify, percent, used, limit, period };
+    this.logger.log(`User ${userId} reached ${percent}% of monthly quota (threshold ${notify}%)`);
+    await Promise.all([
+      this.webhookService
+        .dispatchEvent(userId, tenantId, QUOTA_WARNING_EVENT, event)
+        .catch((error) => this.logger.error(`Failed to deliver quota warning webhook: ${error.message}`)),
+      this.emailEnabled ? this.sendEmail(userId, tenantId, event) : Promise.resolve(),
+    ]);
+    return event;
+  }
+
+  private async sendEmail(userId: string, tenantId: string | undefined, event: QuotaWarningEvent): Promise<void> {
+    const user = await this.em.findOne(User, { id: userId }, { fields: ['email'] });
+    if (!user?.email) {
+      return;
+    }
+
+    const subject =
+      event.threshold >= 100
+        ? 'Your monthly character quota has been used up'
+        : `You have used ${event.percent}% of your monthly character quota`;
+    const text = [
+      `You have used ${event.used} of ${event.limit} characters (${event.percent}%) in ${event.period}.`,
+      event.threshold >= 100
+        ? 'New translation requests may be rejected until the quota resets or your plan is upgraded.'
+        : 'Consider upgrading your plan to avoid interruptions.',
+    ].join('\n\n');
+
+    await this.tenantMailService.send(tenantId, {
+      to: user.email,
+      subject,
+      text,
+      html: text.split('\n\n').map((line) => `<p>${line}</p>`).join(''),
+    });
+  }
+}
+
+export function parseThresholds(raw: string): number[] {
+  return [...new Set(
+    String(raw)
+      .split(',')
+      .map((value) => Number(value.trim()))
+      .filter((value) => Number.isFinite(value) && value > 0),
+  )].sort((a, b) => a - b);
+}
+
+function currentPeriod(): string {
+  return new Date().toISOString().slice(0, 7);
+}
@@ -0,0 +1,187 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { HttpStatus } from '@nestjs/common';
+import { QuotaService } from './quota.service';
+import { CharacterUsageLogDailyRepository } from '../repositories/character-usage.repository';
+import { PlanLimitsService } from '../../subscription/services/plan-limits.service';
+import { QuotaMode } from '../../subscription/interfaces/plan-limits.interface';
+import { QuotaWarningService } from './quota-warning.service';
+import { OverageBillingService } from '../../subscription/services/overage-billing.service';
+import { MonthlyUsageCounterService } from './monthly-usage-counter.service';
+
+describe('QuotaService', () => {
+  let service: QuotaService;
+
+  const mockDailyUsageRepository = {
+    sumSince: jest.fn(),
+  };
+
+  const mockPlanLimitsService = {
+    resolve: jest.fn(),
+  };
+
+  const mockQuotaWarningService = {
+    evaluate: jest.fn().mockResolvedValue(null),
+  };
+
+  const mockOverageBillingService = {
+    record: jest.fn(),
+  };
+
+  const mockMonthlyUsageCounter = {
+    get: jest.fn(),
+    reserve: jest.fn(),
+    release: jest.fn(),
+  };
+
+  const limits = (quotaMode: QuotaMode, extra: Record<string, any> = {}) => ({
+    planId: 'plan1',
+    tier: 'hobby',
+    monthlyCharacterLimit: 1000,
+    quotaMode,
+    features: {},
+    overridden: false,
+    ...extra,
+  });
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        QuotaService,
+        {
+          provide: CharacterUsageLogDailyRepository,
+          useValue: mockDailyUsageRepository,
+        },
+        {
+          provide: PlanLimitsService,
+          useValue: mockPlanLimitsService,
+        },
+        {
+          provide: QuotaWarningService,
+          useValue: mockQuotaWarningService,
+        },
+        {
+          provide: OverageBillingService,
+          useValue: mockOverageBillingService,
+        },
+        {
+          provide: MonthlyUsageCounterService,
+          useValue: mockMonthlyUsageCounter,
+        },
+      ],
+    }).compile();
+
+    service = module.get<QuotaService>(QuotaService);
+    mockMonthlyUsageCounter.get.mockResolvedValue(900);
+    mockMonthlyUsageCounter.reserve.mockImplementation(async (_userId: string, requested: number) => 900 + requested);
+    mockDailyUsageRepository.sumSince.mockResolvedValue(900);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('额度充足时应放行、预留本次字符数且没有警告', async () => {
+    mockPlanLimitsService.resolve.mockResolvedValue(limits(QuotaMode.HARD));
+
+    const result = await service.assertWithinQuota('user1', 50);
+    expect(result.allowed).toBe(true);
+    expect(result.used).toBe(900);
+    expect(result.remaining).toBe(100);
+    expect(result.warnings).toEqual([]);
+    expect(mockMonthlyUsageCounter.reserve).toHaveBeenCalledWith('user1', 50);
+    expect(mockMonthlyUsageCounter.release).not.toHaveBeenCalled();
+    expect(mockDailyUsageRepository.sumSince).not.toHaveBeenCalled();
+  });
+
+  it('硬模式超额时应释放预留并返回 429', async () => {
+    mockPlanLimitsService.resolve.mockResolvedValue(limits(QuotaMode.HARD));
+
+    await expect(service.assertWithinQuota('user1', 200, 'tenant1')).rejects.toMatchObject({
+      status: HttpStatus.TOO_MANY_REQUESTS,
+    });
+    expect(mockMonthlyUsageCounter.release).toHaveBeenCalledWith('user1', 200);
+    expect(mockQuotaWarningService.evaluate).toHaveBeenCalledWith('user1', 'tenant1', 1100, 1000);
+  });
+
+  it('并发提交时按原子预留的结果判断，不会一起越过额度', async () => {
+    mockPlanLimitsService.resolve.mockResolvedValue(limits(QuotaMode.HARD));
+    // 模拟 Redis INCRBY/DECRBY：每次预留立即看到之前所有预留
+    let counter = 900;
+    mockMonthlyUsageCounter.reserve.mockImplementation(async (_userId: string, requested: number) => {
+      counter += requested;
+      return counter;
+    });
+    mockMonthlyUsageCounter.release.mockImplementation(async (_userId: string, requested: number) => {
+      counter -= requested;
+    });
+    mockMonthlyUsageCounter.get.mockImplementation(async () => counter);
+
+    const results = await Promise.allSettled(
+      Array.from({ length: 5 }, () => service.assertWithinQuota('user1', 40)),
+    );
+
+    expect(results.filter((result) => result.status === 'fulfilled')).toHaveLength(2);
+    expect(results.filter((result) => result.status === 'rejected')).toHaveLength(3);
+    expect(counter).toBe(980);
+    const check = await service.check('user1', 40);
+    expect(check.allowed).toBe(false);
+    expect(check.used).toBe(980);
+  });
+
+  it('只检查时读取计数器，不预留', async () => {
+    mockPlanLimitsService.resolve.mockResolvedValue(limits(QuotaMode.HARD));
+
+    const results = await Promise.all([service.check('user1', 50), service.check('user1', 50)]);
+    expect(results.every((result) => result.allowed && result.used === 900)).toBe(true);
+    expect(mockMonthlyUsageCounter.get).toHaveBeenCalledWith('user1');
+    expect(mockMonthlyUsageCounter.reserve).not.toHaveBeenCalled();
+  });
+
+  it('软模式超额时应放行并附带警告', async () => {
+    mockPlanLimitsService.resolve.mockResolvedValue(limits(QuotaMode.SOFT));
+
+    const result = await service.assertWithinQuota('user1', 200);
+    expect(result.allowed).toBe(true);
+    expect(result.mode).toBe(QuotaMode.SOFT);
+    expect(result.warnings).toHaveLength(1);
+  });
+
+  it('超额模式超额时应放行并计算超额字符数', async () => {
+    mockPlanLimitsService.resolve.mockResolvedValue(limits(QuotaMode.OVERAGE));
+
+    const result = await service.assertWithinQuota('user1', 200);
+    expect(result.allowed).toBe(true);
+    expect(result.overage).toBe(100);
+    expect(result.warnings).toHaveLength(1);
+  });
+
+  it('用户自设上限在任何模式下都应拒绝', async () => {
+    mockPlanLimitsService.resolve.mockResolvedValue(limits(QuotaMode.OVERAGE, { userCharacterCap: 1050 }));
+
+    await expect(service.assertWithinQuota('user1', 200)).rejects.toMatchObject({
+      status: HttpStatus.TOO_MANY_REQUESTS,
+      response: expect.objectContaining({ message: 'Monthly character cap reached' }),
+    });
+  });
+
+  it('任务完成后只把超出额度的部分记为超额用量', async () => {
+    mockPlanLimitsService.resolve.mockResolvedValue(limits(QuotaMode.OVERAGE));
+    mockDailyUsageRepository.sumSince.mockResolvedValue(1150);
+
+    await expect(service.recordOverage('user1', 300)).resolves.toBe(150);
+    expect(mockOverageBillingService.record).toHaveBeenCalledWith('user1', 150);
+  });
+
+  it('非超额模式不记录超额用量', async () => {
+    mockPlanLimitsService.resolve.mockResolvedValue(limits(QuotaMode.SOFT));
+
+    await expect(service.recordOverage('user1', 300)).resolves.toBe(0);
+    expect(mockOverageBillingService.record).not.toHaveBeenCalled();
+  });
+
+  it('无法解析计划时应拒绝', async () => {
+    mockPlanLimitsService.resolve.mockResolvedValue(null);
+
+    await expect(service.check('user1', 1)).rejects.toMatchObject({ status: HttpStatus.FORBIDDEN });
+  });
+});
@@ -0,0 +1,157 @@
+import { HttpException, HttpStatus, Injectable, Logger } from '@nestjs/common';
+import { CharacterUsageLogDailyRepository } from '../repositories/character-usage.repository';
+import { PlanLimitsService } from '../../subscription/services/plan-limits.service';
+import { OverageBillingService } from '../../subscription/services/overage-billing.service';
+import { QuotaMode, ResolvedPlanLimits } from '../../subscription/interfaces/plan-limits.interface';
+import { QuotaWarningService } from './quota-warning.service';
+import { MonthlyUsageCounterService } from './monthly-usage-counter.service';
+
+export interface QuotaCheckResult {
+  allowed: boolean;
+  mode: QuotaMode;
+  used: number;
+  requested: number;
+  limit: number;
+  remaining: number;
+  /** 本次请求预计产生的超额字符数（overage 模式） */
+  overage: number;
+  /** 被用户自设上限拦截 */
+  capped: boolean;
+  warnings: string[];
+}
+
+/**
+ * 字符额度检查服务
+ * 硬模式在额度用尽时直接拒绝；软模式允许用户在当月继续提交，并在响应中附带警告；
+ * 超额模式继续放行并记录超额用量用于计费。用户自设的上限在任何模式下都会拒绝
+ */
+@Injectable()
+export class QuotaService {
+  private readonly logger = new Logger(QuotaService.name);
+
+  constructor(
+    private readonly dailyUsageRepository: CharacterUsageLogDailyRepository,
+    private readonly planLimitsService: PlanLimitsService,
+    private readonly quotaWarningService: QuotaWarningService,
+    private readonly overageBillingService: OverageBillingService,
+    private readonly monthlyUsageCounter: MonthlyUsageCounterService,
+  ) {}
+
+  /**
+   * 当月已记账的字符数（按日汇总表累加），与汇总写入的批次一致，用于计算超额；
+   * 请求前的额度检查读 Redis 计数器
+   */
+  async getMonthlyUsage(userId: string): Promise<number> {
+    const monthStart = `${new Date().toISOString().slice(0, 7)}-01`;
+    return this.dailyUsageRepository.sumSince(userId, monthStart);
+  }
+
+  /**
+   * 只检查不预留（试算、预估）
+   */
+  async check(userId: string, requested: number): Promise<QuotaCheckResult> {
+    const limits = await this.resolveLimits(userId);
+    return evaluate(limits, await this.monthlyUsageCounter.get(userId), requested);
+  }
+
+  /**
+   * 检查额度并预留本次请求的字符数，硬模式下超额时释放预留并抛出 429。
+   * 预留与计数器中其他请求的预留原子累加，并发提交不会一起越过额度；
+   * 放行后由调用方把预留记在任务上，任务完成时随用量事件结算，失败或放弃时调用 release；
+   * 被拒绝时按超额后的用量触发额度预警，保证硬模式用户也能收到 100% 通知
+   */
+  async assertWithinQuota(userId: string, requested: number, tenantId?: string): Promise<QuotaCheckResult> {
+    const limits = await this.resolveLimits(userId);
+    const total = await this.monthlyUsageCounter.reserve(userId, requested);
+    const result = evaluate(limits, total - requested, requested);
+    if (!result.allowed) {
+      await this.monthlyUsageCounter.release(userId, requested);
+      this.logger.warn(`Quota exceeded for user ${userId}: ${result.used + requested}/${result.limit}`);
+      this.quotaWarningService
+        .evaluate(userId, tenantId, result.used + requested, result.limit)
+        .catch((error) => this.logger.error(`Quota warning failed: ${error.message}`));
+      throw new HttpException(
+        {
+          statusCode: HttpStatus.TOO_MANY_REQUESTS,
+          message: result.capped ? 'Monthly character cap reached' : 'Monthly character quota exceeded',
+          used: result.used,
+          requested,
+          limit: result.limit,
+        },
+        HttpStatus.TOO_MANY_REQUESTS,
+      );
+    }
+    if (result.warnings.length > 0) {
+      this.logger.warn(`User ${userId} is over quota in ${result.mode} mode: ${result.used + requested}/${result.limit}`);
+    }
+    return result;
+  }
+
+  /**
+   * 释放 assertWithinQuota 的预留；reservedAt 为预留时间，决定从哪个月的计数器中扣除
+   */
+  async release(userId: string, characters: number, reservedAt?: Date): Promise<void> {
+    if (characters > 0) {
+      await this.monthlyUsageCounter.release(userId, characters, reservedAt);
+    }
+  }
+
+  /**
+   * 任务用量写入后调用：overage 模式下把超出额度的部分记为超额用量
+   * 返回本次记录的超额字符数
+   */
+  async recordOverage(userId: string, characters: number): Promise<number> {
+    const limits = await this.planLimitsService.resolve(userId);
+    if (!limits || limits.quotaMode !== QuotaMode.OVERAGE) {
+      return 0;
+    }
+
+    const used = await this.getMonthlyUsage(userId);
+    const overage = Math.min(characters, Math.max(used - limits.monthlyCharacterLimit, 0));
+    if (overage > 0) {
+      await this.overageBillingService.record(userId, overage);
+    }
+    return overage;
+  }
+
+  private async resolveLimits(userId: string): Promise<ResolvedPlanLimits> {
+    const limits = await this.planLimitsService.resolve(userId);
+    if (!limits) {
+      throw new HttpException('No active subscription found', HttpStatus.FORBIDDEN);
+    }
+    return limits;
+  }
+}
+
+function evaluate(limits: ResolvedPlanLimits, used: number, requested: number): QuotaCheckResult {
+  const limit = limits.monthlyCharacterLimit;
+  const projected = used + requested;
+  const exceeded = projected > limit;
+  const capped = limits.userCharacterCap !== undefined && limits.userCharacterCap !== null
+    && projected > limits.userCharacterCap;
+  const overage = exceeded && limits.quotaMode === QuotaMode.OVERAGE
+    ? Math.min(requested, projected - limit)
+    : 0;
+
+  const warnings: string[] = [];
+  if (exceeded && limits.quotaMode === QuotaMode.SOFT) {
+    warnings.push(
+      `Monthly character quota exceeded (${projected}/${limit}); requests are allowed until the end of the current period`,
+    );
+  }
+  if (overage > 0) {
+    warnings.push(`Monthly character quota exceeded; ${overage} characters will be billed as overage`);
+  }
+
+  return {
+    allowed: !capped && (!exceeded || limits.quotaMode !== QuotaMode.HARD),
+    mode: limits.quotaMode,
+    used,
+    requested,
+    limit,
+    remaining: Math.max(limit - used, 0),
+    overage,
+    capped,
+    warnings,
+  };
+}
@@ -0,0 +1,286 @@
+import { BadRequestException, Injectable, Logger, NotFoundException } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { HttpService } from '@nestjs/axios';
+import { InjectQueue } from '@nestjs/bull';
+import { Queue } from 'bull';
+import { firstValueFrom } from 'rxjs';
+import { createHash } from 'crypto';
+import { v4 as uuidv4 } from 'uuid';
+import { SourceSync } from '../entities/source-sync.entity';
+import { CreateSourceSyncDto } from '../dto/source-sync.dto';
+import { SourceSyncRepository } from '../repositories/translation-task.repository';
+import { assertCronSchedule, nextCronRun } from '../utils/schedule.utils';
+import { diffJson, isPlainObject } from '../utils/json-diff';
+import { IncrementalTranslationService } from './incremental-translation.service';
+import { JsonLimitsService } from './json-limits.service';
+import { WebhookService } from '../../webhook/webhook.service';
+import { AccountLockdownService } from '../../api-key/account-lockdown.service';
+import { assertPublicUrl } from '../../../common/utils/url-safety';
+
+export const SOURCE_SYNC_JOB = 'sync-source';
+export const SOURCE_SYNC_UPDATED_EVENT = 'source_sync.updated';
+
+export interface SourceSyncRunResult {
+  changed: boolean;
+  changedKeys: number;
+  removedKeys: number;
+  taskIds: Record<string, string>;
+}
+
+/**
+ * 远程 JSON 源同步（持续本地化）
+ * 按 cron 拉取用户登记的 JSON 地址，与上一次内容做叶子级比对，
+ * 每个目标语言只翻译变化的键并与上一次的译文合并，完成后发送 source_sync.updated webhook
+ */
+@Injectable()
+export class SourceSyncService {
+  private readonly logger = new Logger(SourceSyncService.name);
+  private readonly timeoutMs: number;
+  private readonly maxBytes: number;
+  private readonly maxPerUser: number;
+  private readonly allowPrivateHosts: boolean;
+  private readonly cronMinIntervalMinutes: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly httpService: HttpService,
+    @InjectQueue('translation') private readonly translationQueue: Queue,
+    private readonly sourceSyncRepository: SourceSyncRepository,
+    private readonly incrementalTranslationService: IncrementalTranslationService,
+    private readonly webhookService: WebhookService,
+    private readonly accountLockdownService: AccountLockdownService,
+    private readonly jsonLimitsService: JsonLimitsService,
+  ) {
+    this.timeoutMs = Number(this.configService.get('SOURCE_SYNC_TIMEOUT_MS', 15000));
+    this.maxBytes = Number(this.configService.get('SOURCE_SYNC_MAX_BYTES', 5 * 1024 * 1024));
+    this.maxPerUser = Number(this.configService.get('SOURCE_SYNC_MAX_PER_USER', 20));
+    this.allowPrivateHosts = this.configService.get('SOURCE_SYNC_ALLOW_PRIVATE_HOSTS', 'false') === 'true';
+    this.cronMinIntervalMinutes = Number(this.configService.get('SCHEDULE_CRON_MIN_INTERVAL_MINUTES', 60));
+  }
+
+  async create(userId: string, dto: CreateSourceSyncDto, tenantId?: string): Promise<SourceSync> {
+    assertCronSchedule(dto.cron, dto.timezone, this.cronMinIntervalMinutes);
+    await this.assertFetchable(dto.url);
+    if ((await this.sourceSyncRepository.count({ userId, isActive: true })) >= this.maxPerUser) {
+      throw new BadRequestException(`At most ${this.maxPerUser} source syncs are allowed per account`);
+    }
+
+    const sync = await this.sourceSyncRepository.insert({
+      id: uuidv4(),
+      userId,
+      tenantId,
+      name: dto.name,
+      url: dto.url,
+      fromLang: dto.fromLang,
+      targetLangs: [...new Set(dto.targetLangs)].join(','),
+      ignoredFields: dto.ignoredFields,
+      cron: dto.cron,
+      timezone: dto.timezone,
+      isActive: true,
+    });
+
+    await this.translationQueue.add(
+      SOURCE_SYNC_JOB,
+      { syncId: sync.id },
+      { jobId: sync.id, repeat: { cron: sync.cron, tz: sync.timezone } },
+    );
+    if (dto.runNow !== false) {
+      await this.trigger(userId, sync.id);
+    }
+    return sync;
+  }
+
+  async list(userId: string) {
+    const syncs = await this.sourceSyncRepository.list({ userId, isActive: true }, { orderBy: { createdAt: 'DESC' } });
+    return syncs.map((sync) => this.toView(sync));
+  }
+
+  async get(userId: string, id: string) {
+    return this.toView(await this.getOwned(userId, id));
+  }
+
+  /**
+   * 立即同步一次（不影响原有计划）
+   */
+  async trigger(userId: string, id: string): Promise<void> {
+    const sync = await this.getOwned(userId, id);
+    await this.translationQueue.add(SOURCE_SYNC_JOB, { syncId: sync.id }, { jobId: `${sync.id}:manual:${Date.now()}` });
+  }
+
+  async remove(userId: string, id: string): Promise<void> {
+    const sync = await this.getOwned(userId, id);
+    await this.translationQueue.removeRepeatable(SOURCE_SYNC_JOB, {
+      cron: sync.cron,
+      tz: sync.timezone,
+      jobId: sync.id,
+    });
+    sync.isActive = false;
+    await this.sourceSyncRepository.save(sync);
+  }
+
+  /**
+   * 执行一次同步（由 translation 队列消费者调用）
+   */
+  async run(syncId: string): Promise<SourceSyncRunResult | null> {
+    const sync = await this.sourceSyncRepository.get({ id: syncId, isActive: true });
+    if (!sync) {
+      return null;
+    }
+    if (await this.accountLockdownService.isLocked(sync.userId)) {
+      this.logger.warn(`Skipping source sync ${sync.id}: account ${sync.userId} is locked`);
+      return null;
+    }
+
+    try {
+      const result = await this.synchronize(sync);
+      sync.lastError = undefined;
+      await this.sourceSyncRepository.save(sync);
+      return result;
+    } catch (error) {
+      sync.lastError = error.message;
+      await this.sourceSyncRepository.save(sync);
+      throw error;
+    }
+  }
+
+  private async synchronize(sync: SourceSync): Promise<SourceSyncRunResult> {
+    const unchanged: SourceSyncRunResult = { changed: false, changedKeys: 0, removedKeys: 0, taskIds: {} };
+    await this.assertFetchable(sync.url);
+
+    const response = await firstValueFrom(
+      this.httpService.get<string>(sync.url, {
+        responseType: 'text',
+        transformResponse: (data) => data,
+        timeout: this.timeoutMs,
+        maxContentLength: this.maxBytes,
+        // 重定向目标未经过内网地址校验，不跟随
+        maxRedirects: 0,
+        headers: sync.etag ? { 'If-None-Match': sync.etag } : {},
+        validateStatus: (status) => (status >= 200 && status < 300) || status === 304,
+      }),
+    );
+    sync.lastFetchedAt = new Date();
+    if (response.status === 304) {
+      return unchanged;
+    }
+
+    const raw = response.data;
+    const hash = createHash('sha256').update(raw).digest('hex');
+    sync.etag = (response.headers?.etag as string) || undefined;
+    if (hash === sync.lastContentHash) {
+      return unchanged;
+    }
+
+    const violation = this.jsonLimitsService.check(raw);
+    if (violation) {
+      throw new Error(`Source JSON rejected: ${violation.message}`);
+    }
+    let next: any;
+    try {
+      next = JSON.parse(raw);
+    } catch {
+      throw new Error('Source did not return valid JSON');
+    }
+    if (!isPlainObject(next)) {
+      throw new Error('Source JSON must be an object');
+    }
+
+    const previous = sync.lastContent ? JSON.parse(sync.lastContent) : {};
+    const diff = diffJson(previous, next);
+    const results: Record<string, any> = sync.lastResults ? JSON.parse(sync.lastResults) : {};
+    const taskIds: Record<string, string> = {};
+    const targetLangs = sync.targetLangs.split(',');
+
+    // 只有格式变化（空白、键顺序）时不翻译也不通知
+    const allTranslated = targetLangs.every((lang) => results[lang] !== undefined);
+    if (diff.changed.length === 0 && diff.removed.length === 0 && allTranslated) {
+      sync.lastContent = raw;
+      sync.lastContentHash = hash;
+      return unchanged;
+    }
+
+    for (const lang of targetLangs) {
+      const { translation, taskId } = await this.incrementalTranslationService.translate({
+        userId: sync.userId,
+        tenantId: sync.tenantId,
+        fromLang: sync.fromLang,
+        targetLang: lang,
+        ignoredFields: sync.ignoredFields,
+        source: next,
+        raw,
+        changed: diff.changed,
+        previousTranslation: results[lang],
+      });
+      results[lang] = translation;
+      if (taskId) {
+        taskIds[lang] = taskId;
+      }
+    }
+
+    sync.lastContent = raw;
+    sync.lastContentHash = hash;
+    sync.lastResults = JSON.stringify(results);
+    sync.lastChangedAt = new Date();
+
+    const result: SourceSyncRunResult = {
+      changed: true,
+      changedKeys: diff.changed.length,
+      removedKeys: diff.removed.length,
+      taskIds,
+    };
+    await this.webhookService
+      .dispatchEvent(sync.userId, sync.tenantId, SOURCE_SYNC_UPDATED_EVENT, {
+        syncId: sync.id,
+        name: sync.name,
+        url: sync.url,
+        changedKeys: diff.changed.map((path) => path.join('.')),
+        removedKeys: diff.removed.map((path) => path.join('.')),
+        translations: Object.fromEntries(
+          targetLangs.map((lang) => [lang, { taskId: taskIds[lang], data: results[lang] }]),
+        ),
+      })
+      .catch((error) => this.logger.error(`Failed to deliver source sync webhook: ${error.message}`));
+
+    this.logger.log(
+      `Source sync ${sync.id}: ${diff.changed.length} changed, ${diff.removed.length} removed key(s) translated into ${Object.keys(taskIds).length} language(s)`,
+    );
+    return result;
+  }
+
+  private async assertFetchable(url: string): Promise<void> {
+    if (this.allowPrivateHosts) {
+      return;
+    }
+    try {
+      await assertPublicUrl(url);
+    } catch (error) {
+      throw new BadRequestException(`Source URL is not allowed: ${error.message}`);
+    }
+  }
+
+  private async getOwned(userId: string, id: string): Promise<SourceSync> {
+    const sync = await this.sourceSyncRepository.get({ id, userId, isActive: true });
+    if (!sync) {
+      throw new NotFoundException('Source sync not found');
+    }
+    return sync;
+  }
+
+  private toView(sync: SourceSync) {
+    return {
+      id: sync.id,
+      name: sync.name,
+      url: sync.url,
+      fromLang: sync.fromLang,
+      targetLangs: sync.targetLangs.split(','),
+      ignoredFields: sync.ignoredFields,
+      cron: sync.cron,
+      timezone: sync.timezone,
+      nextRunAt: nextCronRun(sync.cron, sync.timezone),
+      lastFetchedAt: sync.lastFetchedAt,
+      lastChangedAt: sync.lastChangedAt,
+      lastError: sync.lastError,
+      createdAt: sync.createdAt,
+    };
+  }
+}
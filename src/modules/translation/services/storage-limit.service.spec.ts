@@ -0,0 +1,51 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { HttpStatus } from '@nestjs/common';
+import { EntityManager } from '@mikro-orm/core';
+import { StorageLimitService } from './storage-limit.service';
+import { PlanLimitsService } from '../../subscription/services/plan-limits.service';
+
+describe('StorageLimitService', () => {
+  let service: StorageLimitService;
+
+  const mockExecute = jest.fn();
+  const mockEntityManager = {
+    getConnection: jest.fn(() => ({ execute: mockExecute })),
+  };
+
+  const mockPlanLimitsService = {
+    resolve: jest.fn(),
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        StorageLimitService,
+        { provide: EntityManager, useValue: mockEntityManager },
+        { provide: PlanLimitsService, useValue: mockPlanLimitsService },
+      ],
+    }).compile();
+
+    service = module.get<StorageLimitService>(StorageLimitService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('按文档上保存的明文字节数累计，不量加密后的列长度', async () => {
+    mockExecute.mockResolvedValueOnce([{ documents: '3', bytes: '1200' }]);
+
+    await expect(service.getUsage('user1')).resolves.toEqual({ documents: 3, bytes: 1200 });
+    const [sql, params] = mockExecute.mock.calls[0];
+    expect(sql).toContain('SUM(content_bytes)');
+    expect(sql).not.toContain('OCTET_LENGTH');
+    expect(params).toEqual(['user1']);
+  });
+
+  it('超出字节上限时拒绝保存新文档', async () => {
+    mockExecute.mockResolvedValueOnce([{ documents: 3, bytes: 900 }]);
+    mockPlanLimitsService.resolve.mockResolvedValueOnce({ maxStoredDocuments: 10, maxStoredBytes: 1000 });
+
+    await expect(service.assertCanStore('user1', 200)).rejects.toMatchObject({ status: HttpStatus.FORBIDDEN });
+  });
+});
@@ -0,0 +1,85 @@
+import { HttpException, HttpStatus, Injectable, Logger } from '@nestjs/common';
+import { EntityManager } from '@mikro-orm/core';
+import { PlanLimitsService } from '../../subscription/services/plan-limits.service';
+
+export interface StorageMeter {
+  used: number;
+  /** null 表示不限 */
+  limit: number | null;
+  remaining: number | null;
+}
+
+export interface StorageStatus {
+  documents: StorageMeter;
+  bytes: StorageMeter;
+}
+
+/**
+ * 文档存储上限
+ * 按计划限制保存的文档数和总字节数（原文 + 译文的明文大小），避免免费用户的存储无限增长。
+ * 新任务只按原文大小预估，译文大小在完成后计入；已归档和已清除的文档不占数据库空间，不计字节数
+ */
+@Injectable()
+export class StorageLimitService {
+  private readonly logger = new Logger(StorageLimitService.name);
+
+  constructor(
+    private readonly em: EntityManager,
+    private readonly planLimitsService: PlanLimitsService,
+  ) {}
+
+  async getUsage(userId: string): Promise<{ documents: number; bytes: number }> {
+    const [row] = await this.em.getConnection().execute(
+      'SELECT COUNT(*) AS documents, COALESCE(SUM(content_bytes), 0) AS bytes FROM user_json_data WHERE user_id = ?',
+      [userId],
+    );
+    return { documents: Number(row?.documents ?? 0), bytes: Number(row?.bytes ?? 0) };
+  }
+
+  async getStatus(userId: string): Promise<StorageStatus> {
+    const [usage, limits] = await Promise.all([this.getUsage(userId), this.planLimitsService.resolve(userId)]);
+    return {
+      documents: meter(usage.documents, limits?.maxStoredDocuments),
+      bytes: meter(usage.bytes, limits?.maxStoredBytes),
+    };
+  }
+
+  /**
+   * 保存新文档前检查，超出上限时抛出 403
+   */
+  async assertCanStore(userId: string, bytes: number): Promise<void> {
+    const status = await this.getStatus(userId);
+    if (status.documents.limit !== null && status.documents.used + 1 > status.documents.limit) {
+      this.logger.warn(`Document limit reached for user ${userId}: ${status.documents.used}/${status.documents.limit}`);
+      throw new HttpException(
+        {
+          statusCode: HttpStatus.FORBIDDEN,
+          message: `Stored document limit reached (${status.documents.limit} documents); delete old documents or upgrade your plan`,
+          used: status.documents.used,
+          limit: status.documents.limit,
+        },
+        HttpStatus.FORBIDDEN,
+      );
+    }
+    if (status.bytes.limit !== null && status.bytes.used + bytes > status.bytes.limit) {
+      this.logger.warn(`Storage limit reached for user ${userId}: ${status.bytes.used + bytes}/${status.bytes.limit}`);
+      throw new HttpException(
+        {
+          statusCode: HttpStatus.FORBIDDEN,
+          message: `Storage limit exceeded (${status.bytes.limit} bytes); delete old documents or upgrade your plan`,
+          used: status.bytes.used,
+          requested: bytes,
+          limit: status.bytes.limit,
+        },
+        HttpStatus.FORBIDDEN,
+      );
+    }
+  }
+}
+
+function meter(used: number, limit: number | null | undefined): StorageMeter {
+  if (limit === undefined || limit === null) {
+    return { used, limit: null, remaining: null };
+  }
+  return { used, limit, remaining: Math.max(limit - used, 0) };
+}
@@ -0,0 +1,89 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { TranslationCheckpointService } from './translation-checkpoint.service';
+import { TranslationUtils } from '../utils/translation.utils';
+import { RedisService } from '../../../common/services/redis.service';
+
+describe('TranslationCheckpointService', () => {
+  let service: TranslationCheckpointService;
+
+  const mockMulti = {
+    hset: jest.fn().mockReturnThis(),
+    expire: jest.fn().mockReturnThis(),
+    exec: jest.fn(),
+  };
+  const mockRedisService = {
+    client: { hgetall: jest.fn(), multi: jest.fn(() => mockMulti) },
+    del: jest.fn(),
+  };
+
+  const userData: any = {
+    id: 'task1',
+    originJson: '{"a":"one","b":"two","c":"three"}',
+    fromLang: 'en',
+    toLang: 'fr',
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        TranslationCheckpointService,
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+      ],
+    }).compile();
+
+    service = module.get<TranslationCheckpointService>(TranslationCheckpointService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('重试时跳过断点中已完成的键，只翻译剩余的键并记录断点', async () => {
+    mockRedisService.client.hgetall.mockResolvedValue({ '["a"]': '"un"', '["b"]': '"deux"' });
+    const utils = new TranslationUtils();
+    const translateString = jest.spyOn(utils as any, 'translateString');
+
+    const checkpoint = await service.open(userData);
+    const result = await utils.translateJson(userData.originJson, 'en', 'fr', '', { checkpoint });
+
+    expect(JSON.parse(result)).toEqual({ a: 'un', b: 'deux', c: 'three' });
+    expect(translateString).toHaveBeenCalledTimes(1);
+    expect(mockMulti.hset).toHaveBeenCalledWith(
+      expect.stringMatching(/^translation_checkpoint:task1:/),
+      '["c"]',
+      '"three"',
+    );
+  });
+
+  it('嵌套对象中途中断时从最后一个已翻译的叶子继续', async () => {
+    const nested = { ...userData, originJson: '{"nav":{"home":"Home","about":"About","items":["One","Two"]}}' };
+    mockRedisService.client.hgetall.mockResolvedValue({ '["nav","home"]': '"Accueil"', '["nav","items","0"]': '"Un"' });
+    const utils = new TranslationUtils();
+    const translateString = jest.spyOn(utils as any, 'translateString');
+
+    const checkpoint = await service.open(nested);
+    const result = await utils.translateJson(nested.originJson, 'en', 'fr', '', { checkpoint });
+
+    expect(JSON.parse(result).nav).toEqual({ home: 'Accueil', about: 'About', items: ['Un', 'Two'] });
+    expect(translateString).toHaveBeenCalledTimes(2);
+  });
+
+  it('原文变化后使用新的断点键', async () => {
+    await service.clear(userData);
+    await service.clear({ ...userData, originJson: '{"a":"changed"}' });
+
+    const [[first], [second]] = mockRedisService.del.mock.calls;
+    expect(first).not.toBe(second);
+  });
+
+  it('Redis 不可用时不影响翻译', async () => {
+    mockRedisService.client.hgetall.mockRejectedValue(new Error('ECONNREFUSED'));
+    mockMulti.exec.mockRejectedValue(new Error('ECONNREFUSED'));
+
+    const checkpoint = await service.open(userData);
+    await expect(checkpoint.save('a', 'un')).resolves.toBeUndefined();
+    expect(checkpoint.completed).toEqual({});
+  });
+});
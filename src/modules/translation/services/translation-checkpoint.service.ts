@@ -0,0 +1,76 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { createHash } from 'crypto';
+import { UserJsonData } from '../entities/translation-task.entity';
+import { TranslationCheckpoint } from '../utils/translation.utils';
+import { RedisService } from '../../../common/services/redis.service';
+
+/**
+ * 长文档翻译的断点
+ * 每个字符串叶子翻译完成后按路径写入 Redis 哈希，worker 崩溃或翻译服务故障导致任务（或分片）重试时
+ * 从最后一个已翻译的键继续，不再重复调用（和计费）翻译服务。键包含原文和语言的摘要，文档内容变化后旧断点自然失效
+ */
+@Injectable()
+export class TranslationCheckpointService {
+  private readonly logger = new Logger(TranslationCheckpointService.name);
+  private readonly ttlSeconds: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly redisService: RedisService,
+  ) {
+    this.ttlSeconds = Number(this.configService.get('TRANSLATION_CHECKPOINT_TTL_SECONDS', 7 * 24 * 60 * 60));
+  }
+
+  async open(userData: UserJsonData): Promise<TranslationCheckpoint> {
+    const key = this.keyOf(userData);
+    const completed: Record<string, any> = {};
+    try {
+      for (const [field, raw] of Object.entries(await this.redisService.client.hgetall(key))) {
+        completed[field] = JSON.parse(raw);
+      }
+    } catch (error) {
+      this.logger.error(`Failed to read checkpoint for ${userData.id}: ${error.message}`);
+    }
+    if (Object.keys(completed).length > 0) {
+      this.logger.log(`Resuming ${userData.id} from checkpoint: ${Object.keys(completed).length} key(s) done`);
+    }
+
+    return {
+      completed,
+      save: async (field: string, value: any) => {
+        try {
+          await this.redisService.client
+            .multi()
+            .hset(key, field, JSON.stringify(value))
+            .expire(key, this.ttlSeconds)
+            .exec();
+        } catch (error) {
+          // 断点只是优化，写入失败不影响翻译
+          this.logger.error(`Failed to save checkpoint for ${userData.id}: ${error.message}`);
+        }
+      },
+    };
+  }
+
+  async clear(userData: UserJsonData): Promise<void> {
+    await this.redisService.del(this.keyOf(userData));
+  }
+
+  private keyOf(userData: UserJsonData): string {
+    const digest = createHash('sha256')
+      .update(
+        [
+          userData.originJson,
+          userData.fromLang,
+          userData.toLang,
+          userData.ignoredFields ?? '',
+          // 没有忽略配置的文档保持原来的键，升级前写入的断点仍然有效
+          ...(userData.ignoreRules ? [JSON.stringify(userData.ignoreRules)] : []),
+        ].join('\0'),
+      )
+      .digest('hex')
+      .slice(0, 16);
+    return `translation_checkpoint:${userData.id}:${digest}`;
+  }
+}
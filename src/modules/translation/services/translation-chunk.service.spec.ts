@@ -0,0 +1,117 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { getQueueToken } from '@nestjs/bull';
+import { TranslationChunkService, TRANSLATE_CHUNK_JOB } from './translation-chunk.service';
+import { TranslationChunkRepository } from '../repositories/translation-task.repository';
+import { TranslationUtils } from '../utils/translation.utils';
+import { RedisService } from '../../../common/services/redis.service';
+
+describe('TranslationChunkService', () => {
+  let service: TranslationChunkService;
+
+  const mockTranslationQueue = { add: jest.fn() };
+  const mockChunkRepository = {
+    get: jest.fn(),
+    list: jest.fn(),
+    count: jest.fn(),
+    build: jest.fn((data) => ({ ...data })),
+    save: jest.fn(),
+    delete: jest.fn(),
+  };
+  const mockTranslationUtils = { translateJson: jest.fn() };
+  const mockRedisService = { setIfAbsent: jest.fn() };
+
+  const config: Record<string, any> = { CHUNK_THRESHOLD_CHARS: 10, CHUNK_TARGET_CHARS: 10 };
+  const userData: any = {
+    id: 'task1',
+    originJson: JSON.stringify({ title: 'Hello world', nav: { home: 'Home page', about: 'About us' } }),
+    fromLang: 'en',
+    toLang: 'fr',
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        TranslationChunkService,
+        { provide: getQueueToken('translation'), useValue: mockTranslationQueue },
+        { provide: TranslationChunkRepository, useValue: mockChunkRepository },
+        { provide: TranslationUtils, useValue: mockTranslationUtils },
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, def?: any) => config[key] ?? def) } },
+      ],
+    }).compile();
+
+    service = module.get<TranslationChunkService>(TranslationChunkService);
+    mockChunkRepository.list.mockResolvedValue([]);
+    mockRedisService.setIfAbsent.mockResolvedValue(true);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('应按叶子切分文档并把每个分片加入队列', async () => {
+    const count = await service.split({ id: 'task1', charTotal: 28 } as any, userData, 5);
+
+    expect(count).toBe(3);
+    const [chunks] = mockChunkRepository.save.mock.calls[0];
+    expect(chunks.map((chunk) => chunk.paths)).toEqual([[['title']], [['nav', 'home']], [['nav', 'about']]]);
+    expect(mockTranslationQueue.add).toHaveBeenCalledTimes(3);
+    expect(mockTranslationQueue.add).toHaveBeenCalledWith(
+      TRANSLATE_CHUNK_JOB,
+      { taskId: 'task1', chunkId: chunks[0].id },
+      { jobId: chunks[0].id, priority: 5, attempts: 3, backoff: { type: 'exponential', delay: 5000 } },
+    );
+  });
+
+  it('应只重新提交失败的分片，并使用新的 jobId', async () => {
+    const failed = { id: 'c2', taskId: 'task1', status: 'failed', attempts: 3, error: 'timeout' } as any;
+    mockChunkRepository.list.mockResolvedValueOnce([failed]);
+
+    expect(await service.retryFailed('task1', 5)).toBe(1);
+    expect(mockChunkRepository.list).toHaveBeenCalledWith(
+      { taskId: 'task1', status: 'failed' },
+      { orderBy: { index: 'ASC' } },
+    );
+    expect(failed).toMatchObject({ status: 'pending', error: null });
+    expect(mockTranslationQueue.add).toHaveBeenCalledWith(
+      TRANSLATE_CHUNK_JOB,
+      { taskId: 'task1', chunkId: 'c2' },
+      expect.objectContaining({ jobId: 'c2:retry:3' }),
+    );
+  });
+
+  it('还有未完成的分片时不合并', async () => {
+    mockChunkRepository.get.mockResolvedValue({ id: 'c1', runId: 'run1', paths: [['title']], status: 'pending' });
+    mockTranslationUtils.translateJson.mockResolvedValue('{"title":"Bonjour"}');
+    mockChunkRepository.count.mockResolvedValue(1);
+
+    expect(await service.translateChunk('c1', userData)).toBeNull();
+    expect(mockRedisService.setIfAbsent).not.toHaveBeenCalled();
+  });
+
+  it('最后一个分片完成时按源文档结构合并译文', async () => {
+    const chunk = { id: 'c2', runId: 'run1', paths: [['nav', 'home'], ['nav', 'about']], status: 'pending' } as any;
+    mockChunkRepository.get.mockResolvedValue(chunk);
+    mockTranslationUtils.translateJson.mockResolvedValue('{"nav":{"home":"Accueil","about":"À propos"}}');
+    mockChunkRepository.count.mockResolvedValue(0);
+    mockChunkRepository.list.mockResolvedValue([
+      { paths: [['title']], translatedJson: '{"title":"Bonjour"}' },
+      chunk,
+    ]);
+
+    const merged = await service.translateChunk('c2', userData);
+
+    expect(chunk.status).toBe('completed');
+    expect(JSON.parse(merged)).toEqual({ title: 'Bonjour', nav: { home: 'Accueil', about: 'À propos' } });
+  });
+
+  it('分片翻译失败时标记为 failed 并抛出', async () => {
+    const chunk = { id: 'c1', runId: 'run1', paths: [['title']], status: 'pending' } as any;
+    mockChunkRepository.get.mockResolvedValue(chunk);
+    mockTranslationUtils.translateJson.mockRejectedValue(new Error('provider down'));
+
+    await expect(service.translateChunk('c1', userData)).rejects.toThrow('provider down');
+    expect(chunk).toMatchObject({ status: 'failed', error: 'provider down' });
+  });
+});
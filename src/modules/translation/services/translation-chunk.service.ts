@@ -0,0 +1,249 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { InjectQueue } from '@nestjs/bull';
+import { Queue } from 'bull';
+import { v4 as uuidv4 } from 'uuid';
+import { TranslationTask, UserJsonData } from '../entities/translation-task.entity';
+import { TranslationChunk } from '../entities/translation-chunk.entity';
+import { TranslationChunkRepository } from '../repositories/translation-task.repository';
+import { TranslationCheckpoint, TranslationUtils, UntranslatedKeyFailure } from '../utils/translation.utils';
+import { chunkJsonPaths } from '../utils/json-chunker';
+import { parsePlaceholderStyles } from '../utils/placeholders';
+import { getPath, isPlainObject, mergeTranslation, pathKey, pickPaths, setPath } from '../utils/json-diff';
+import { RedisService } from '../../../common/services/redis.service';
+
+export const TRANSLATE_CHUNK_JOB = 'translate-chunk';
+
+export interface TranslateChunkJob {
+  taskId: string;
+  chunkId: string;
+}
+
+export interface ChunkProgress {
+  total: number;
+  completed: number;
+  failed: number;
+  chunks: {
+    index: number;
+    status: string;
+    charTotal: number;
+    startedAt?: Date;
+    completedAt?: Date;
+    attempts: number;
+    error?: string;
+  }[];
+}
+
+/**
+ * 超大文档分片翻译
+ * 字符数超过 CHUNK_THRESHOLD_CHARS 的文档按叶子切成约 CHUNK_TARGET_CHARS 的分片，
+ * 每片作为独立的队列任务由不同 worker 并行处理，最后一片完成时按源文档结构合并；
+ * 分片失败时由队列按退避策略重试，重试用尽后可以只重新提交失败的分片
+ */
+@Injectable()
+export class TranslationChunkService {
+  private readonly logger = new Logger(TranslationChunkService.name);
+  private readonly thresholdChars: number;
+  private readonly targetChars: number;
+  private readonly attempts: number;
+  private readonly backoffMs: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    @InjectQueue('translation') private readonly translationQueue: Queue,
+    private readonly chunkRepository: TranslationChunkRepository,
+    private readonly translationUtils: TranslationUtils,
+    private readonly redisService: RedisService,
+  ) {
+    this.thresholdChars = Number(this.configService.get('CHUNK_THRESHOLD_CHARS', 50000));
+    this.targetChars = Math.max(Number(this.configService.get('CHUNK_TARGET_CHARS', 20000)), 1);
+    this.attempts = Math.max(Number(this.configService.get('CHUNK_JOB_ATTEMPTS', 3)), 1);
+    this.backoffMs = Number(this.configService.get('CHUNK_JOB_BACKOFF_MS', 5000));
+  }
+
+  shouldChunk(task: TranslationTask): boolean {
+    return this.thresholdChars > 0 && task.charTotal >= this.thresholdChars;
+  }
+
+  /**
+   * 切分文档并把分片加入队列，返回分片数；文档无法切成多片时返回 0，由调用方整体翻译
+   */
+  async split(task: TranslationTask, userData: UserJsonData, queuePriority: number): Promise<number> {
+    const source = JSON.parse(userData.originJson);
+    if (!isPlainObject(source)) {
+      return 0;
+    }
+    const parts = chunkJsonPaths(source, this.targetChars);
+    if (parts.length < 2) {
+      return 0;
+    }
+
+    // 周期任务每次运行重新切分，上一次的分片不再需要
+    await this.discard(task.id);
+
+    const runId = uuidv4();
+    const chunks = parts.map((part, index) =>
+      this.chunkRepository.build({
+        id: uuidv4(),
+        taskId: task.id,
+        runId,
+        index,
+        paths: part.paths,
+        charTotal: part.chars,
+        status: 'pending',
+      }),
+    );
+    await this.chunkRepository.save(chunks);
+
+    for (const chunk of chunks) {
+      await this.enqueue(chunk, chunk.id, queuePriority);
+    }
+    this.logger.log(`Task ${task.id} split into ${chunks.length} chunk(s)`);
+    return chunks.length;
+  }
+
+  /**
+   * 重新提交最近一次切分中失败的分片，已完成的分片不再翻译；返回重新提交的分片数
+   */
+  async retryFailed(taskId: string, queuePriority: number): Promise<number> {
+    const failed = await this.chunkRepository.list({ taskId, status: 'failed' }, { orderBy: { index: 'ASC' } });
+    for (const chunk of failed) {
+      chunk.status = 'pending';
+      chunk.error = null;
+    }
+    await this.chunkRepository.save(failed);
+    for (const chunk of failed) {
+      // 原 jobId 的失败任务仍保留在队列中，重试使用新的 jobId
+      await this.enqueue(chunk, `${chunk.id}:retry:${chunk.attempts}`, queuePriority);
+    }
+    if (failed.length > 0) {
+      this.logger.log(`Task ${taskId}: retrying ${failed.length} failed chunk(s)`);
+    }
+    return failed.length;
+  }
+
+  private async enqueue(chunk: TranslationChunk, jobId: string, queuePriority: number): Promise<void> {
+    await this.translationQueue.add(
+      TRANSLATE_CHUNK_JOB,
+      { taskId: chunk.taskId, chunkId: chunk.id } as TranslateChunkJob,
+      {
+        jobId,
+        priority: queuePriority,
+        attempts: this.attempts,
+        backoff: { type: 'exponential', delay: this.backoffMs },
+      },
+    );
+  }
+
+  /**
+   * 翻译一个分片；本次切分的全部分片都完成时返回合并后的译文，否则返回 null
+   * 分片保留原文路径，可与整篇翻译共用同一份断点
+   */
+  async translateChunk(
+    chunkId: string,
+    userData: UserJsonData,
+    checkpoint?: TranslationCheckpoint,
+  ): Promise<string | null> {
+    const chunk = await this.chunkRepository.get({ id: chunkId });
+    if (!chunk) {
+      // 已被新一次切分替换
+      return null;
+    }
+    const source = JSON.parse(userData.originJson);
+
+    if (chunk.status !== 'completed') {
+      chunk.status = 'processing';
+      chunk.startedAt = new Date();
+      chunk.attempts += 1;
+      await this.chunkRepository.save(chunk);
+      const untranslatedKeys: string[] = [];
+      const failedKeys: Record<string, UntranslatedKeyFailure> = {};
+      try {
+        chunk.translatedJson = await this.translationUtils.translateJson(
+          JSON.stringify(pickPaths(source, chunk.paths)),
+          userData.fromLang,
+          userData.toLang,
+          userData.ignoredFields || '',
+          {
+            provider: userData.provider,
+            userId: userData.userId,
+            ignoreRules: userData.ignoreRules,
+            checkpoint,
+            placeholderStyles: parsePlaceholderStyles(userData.placeholderStyles ?? []),
+            preserveFormatting: userData.preserveFormatting,
+            maskPii: userData.maskPii,
+            piiPatterns: userData.piiPatterns,
+            untranslatedKeys,
+            failedKeys,
+          },
+        );
+        chunk.untranslatedKeys = untranslatedKeys.length > 0 ? untranslatedKeys : null;
+        chunk.failedKeys = untranslatedKeys.length > 0 ? failedKeys : null;
+        chunk.status = 'completed';
+        chunk.error = null;
+        chunk.completedAt = new Date();
+      } catch (error) {
+        chunk.status = 'failed';
+        chunk.error = error.message;
+        throw error;
+      } finally {
+        await this.chunkRepository.save(chunk);
+      }
+    }
+
+    const remaining = await this.chunkRepository.count({ runId: chunk.runId, status: { $ne: 'completed' } });
+    if (remaining > 0) {
+      return null;
+    }
+    // 多个分片同时完成时只由一个 worker 合并
+    if (!(await this.redisService.setIfAbsent(`translation_chunks_merge:${chunk.runId}`, chunk.taskId, 3600))) {
+      return null;
+    }
+    const chunks = await this.chunkRepository.list({ runId: chunk.runId }, { orderBy: { index: 'ASC' } });
+    const untranslated = chunks.flatMap((item) => item.untranslatedKeys ?? []);
+    userData.untranslatedKeys = untranslated.length > 0 ? untranslated : undefined;
+    userData.failedKeys =
+      untranslated.length > 0 ? Object.assign({}, ...chunks.map((item) => item.failedKeys ?? {})) : undefined;
+    return this.merge(source, chunks);
+  }
+
+  async getProgress(taskId: string): Promise<ChunkProgress | null> {
+    const chunks = await this.chunkRepository.list({ taskId }, { orderBy: { index: 'ASC' } });
+    if (chunks.length === 0) {
+      return null;
+    }
+    return {
+      total: chunks.length,
+      completed: chunks.filter((chunk) => chunk.status === 'completed').length,
+      failed: chunks.filter((chunk) => chunk.status === 'failed').length,
+      chunks: chunks.map((chunk) => ({
+        index: chunk.index,
+        status: chunk.status,
+        charTotal: chunk.charTotal,
+        startedAt: chunk.startedAt,
+        completedAt: chunk.completedAt,
+        attempts: chunk.attempts,
+        error: chunk.error,
+      })),
+    };
+  }
+
+  async discard(taskId: string): Promise<void> {
+    for (const chunk of await this.chunkRepository.list({ taskId })) {
+      await this.chunkRepository.delete(chunk);
+    }
+  }
+
+  private merge(source: any, chunks: TranslationChunk[]): string {
+    const combined: Record<string, any> = {};
+    const keys = new Set<string>();
+    for (const chunk of chunks) {
+      const translated = JSON.parse(chunk.translatedJson);
+      for (const path of chunk.paths) {
+        setPath(combined, path, getPath(translated, path));
+        keys.add(pathKey(path));
+      }
+    }
+    return JSON.stringify(mergeTranslation(source, {}, combined, keys), null, 2);
+  }
+}
@@ -0,0 +1,103 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { BadRequestException } from '@nestjs/common';
+import { TranslationLintService } from './translation-lint.service';
+import { LintRuleRepository } from '../repositories/translation-task.repository';
+import { LintRuleType } from '../utils/translation-lint';
+
+describe('TranslationLintService', () => {
+  let service: TranslationLintService;
+
+  const scopedRuleRepository = { count: jest.fn(), insert: jest.fn(async (data) => data), getOrFail: jest.fn() };
+  const mockLintRuleRepository = {
+    forUser: jest.fn(() => scopedRuleRepository),
+    list: jest.fn(),
+    delete: jest.fn(),
+  };
+
+  const document = (data: Record<string, any> = {}): any => ({
+    id: 'task1',
+    userId: 'user1',
+    toLang: 'ja',
+    translatedJson: '{"greeting":"田中さん","legal":"ACME™"}',
+    ...data,
+  });
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        TranslationLintService,
+        { provide: LintRuleRepository, useValue: mockLintRuleRepository },
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, def?: any) => def) } },
+      ],
+    }).compile();
+
+    service = module.get<TranslationLintService>(TranslationLintService);
+    scopedRuleRepository.count.mockResolvedValue(0);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  describe('create', () => {
+    it('应只保存该规则类型使用的字段', async () => {
+      const rule = await service.create('user1', {
+        type: LintRuleType.MAX_LENGTH,
+        maxLength: 40,
+        terms: ['ignored'],
+        languages: ['ja', 'ja'],
+      });
+
+      expect(rule).toMatchObject({ userId: 'user1', maxLength: 40, terms: undefined, languages: ['ja'] });
+      expect(rule.blocking).toBe(false);
+    });
+
+    it('缺少该类型必填的字段时应拒绝', async () => {
+      await expect(service.create('user1', { type: LintRuleType.FORBIDDEN_TERM })).rejects.toThrow(
+        'Lint rule of type forbidden_term requires terms',
+      );
+    });
+
+    it('超出每个账户的规则数量上限时应拒绝', async () => {
+      scopedRuleRepository.count.mockResolvedValue(100);
+
+      await expect(
+        service.create('user1', { type: LintRuleType.BANNED_CHARACTERS, characters: '™' }),
+      ).rejects.toThrow(BadRequestException);
+      expect(scopedRuleRepository.insert).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('lint', () => {
+    it('应把违规项和汇总保存到文档，blocking 规则违规时标记暂停推送', async () => {
+      const doc = document();
+      mockLintRuleRepository.list.mockResolvedValue([
+        { id: 'honorific', type: LintRuleType.REQUIRED_TERM, terms: ['様'], keyPattern: 'greeting', blocking: true },
+        { id: 'chars', type: LintRuleType.BANNED_CHARACTERS, characters: '™' },
+      ]);
+
+      const report = await service.lint(doc);
+
+      expect(report).toMatchObject({
+        passed: false,
+        blocking: true,
+        summary: { required_term: 1, banned_characters: 1 },
+      });
+      expect(Object.keys(report.violations)).toEqual(['greeting', 'legal']);
+      expect(doc.lintReport).toBe(report);
+      expect(service.isBlocked(doc)).toBe(true);
+    });
+
+    it('没有规则时返回通过的报告并清空文档上的旧报告', async () => {
+      const doc = document({ lintReport: { blocking: true } });
+      mockLintRuleRepository.list.mockResolvedValue([]);
+
+      const report = await service.lint(doc);
+
+      expect(report).toMatchObject({ passed: true, blocking: false, violations: {} });
+      expect(doc.lintReport).toBeUndefined();
+      expect(service.isBlocked(doc)).toBe(false);
+    });
+  });
+});
@@ -0,0 +1,113 @@
+import { BadRequestException, Injectable } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { v4 as uuidv4 } from 'uuid';
+import { UserJsonData } from '../entities/translation-task.entity';
+import { LintRule } from '../entities/lint-rule.entity';
+import { CreateLintRuleDto } from '../dto/lint-rule.dto';
+import { LintRuleRepository } from '../repositories/translation-task.repository';
+import { LintRuleType, LintViolations, lintTranslation } from '../utils/translation-lint';
+
+export interface LintReport {
+  passed: boolean;
+  /** 存在 blocking 规则的违规，结果推送暂停到违规解决 */
+  blocking: boolean;
+  checkedAt: string;
+  /** 各规则类型的违规数量 */
+  summary: Record<string, number>;
+  violations: LintViolations;
+}
+
+/** 各规则类型必填的字段 */
+const REQUIRED_FIELDS: Record<LintRuleType, keyof CreateLintRuleDto> = {
+  [LintRuleType.FORBIDDEN_TERM]: 'terms',
+  [LintRuleType.REQUIRED_TERM]: 'terms',
+  [LintRuleType.MAX_LENGTH]: 'maxLength',
+  [LintRuleType.BANNED_CHARACTERS]: 'characters',
+};
+
+/**
+ * 译文检查规则
+ * 用户按市场配置禁用词、必须出现的敬语、长度上限和禁用字符，翻译完成和人工修改后检查译文，
+ * 报告随文档保存；blocking 规则违规时结果推送暂停，修改译文或规则后重新检查通过再推送
+ */
+@Injectable()
+export class TranslationLintService {
+  private readonly maxRulesPerUser: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly lintRuleRepository: LintRuleRepository,
+  ) {
+    this.maxRulesPerUser = Number(this.configService.get('LINT_MAX_RULES_PER_USER', 100));
+  }
+
+  async create(userId: string, dto: CreateLintRuleDto): Promise<LintRule> {
+    const field = REQUIRED_FIELDS[dto.type];
+    if (dto[field] === undefined || dto[field] === '') {
+      throw new BadRequestException(`Lint rule of type ${dto.type} requires ${field}`);
+    }
+    const rules = this.lintRuleRepository.forUser(userId);
+    if ((await rules.count()) >= this.maxRulesPerUser) {
+      throw new BadRequestException(`At most ${this.maxRulesPerUser} lint rules are allowed per account`);
+    }
+
+    return rules.insert({
+      id: uuidv4(),
+      userId,
+      name: dto.name,
+      type: dto.type,
+      languages: dto.languages?.length ? [...new Set(dto.languages)] : undefined,
+      keyPattern: dto.keyPattern,
+      terms: field === 'terms' ? [...new Set(dto.terms)] : undefined,
+      caseSensitive: dto.caseSensitive ?? false,
+      maxLength: field === 'maxLength' ? dto.maxLength : undefined,
+      characters: field === 'characters' ? dto.characters : undefined,
+      blocking: dto.blocking ?? false,
+    });
+  }
+
+  async list(userId: string): Promise<LintRule[]> {
+    return this.lintRuleRepository.forUser(userId).list({}, { orderBy: { createdAt: 'ASC' } });
+  }
+
+  async remove(userId: string, id: string): Promise<void> {
+    const rule = await this.lintRuleRepository.forUser(userId).getOrFail({ id }, 'Lint rule not found');
+    await this.lintRuleRepository.delete(rule);
+  }
+
+  /**
+   * 按用户当前的规则重新检查译文并保存到文档（不落库，由调用方保存）；
+   * 没有规则时返回通过的报告，文档上不保存报告。已归档的文档由调用方传入取回的译文
+   */
+  async lint(document: UserJsonData, translatedJson = document.translatedJson): Promise<LintReport | undefined> {
+    if (!translatedJson) {
+      return undefined;
+    }
+    const rules = await this.lintRuleRepository.list({ userId: document.userId });
+    const violations = rules.length > 0 ? lintTranslation(JSON.parse(translatedJson), rules, document.toLang) : {};
+    const summary: Record<string, number> = {};
+    let blocking = false;
+    for (const keyViolations of Object.values(violations)) {
+      for (const violation of keyViolations) {
+        summary[violation.type] = (summary[violation.type] ?? 0) + 1;
+        blocking ||= violation.blocking;
+      }
+    }
+    const report: LintReport = {
+      passed: Object.keys(violations).length === 0,
+      blocking,
+      checkedAt: new Date().toISOString(),
+      summary,
+      violations,
+    };
+    document.lintReport = rules.length > 0 ? report : undefined;
+    return report;
+  }
+
+  /**
+   * 文档当前是否因 blocking 违规暂停推送
+   */
+  isBlocked(document: UserJsonData): boolean {
+    return !!document.lintReport?.blocking;
+  }
+}
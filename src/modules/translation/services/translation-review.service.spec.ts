@@ -0,0 +1,84 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConflictException } from '@nestjs/common';
+import { TranslationReviewService } from './translation-review.service';
+import { DocumentArchiveService } from './document-archive.service';
+import { TranslationTaskRepository, UserJsonDataRepository } from '../repositories/translation-task.repository';
+import { ReviewStatus } from '../entities/translation-task.entity';
+
+describe('TranslationReviewService', () => {
+  let service: TranslationReviewService;
+
+  const mockTaskRepository = { get: jest.fn() };
+  const mockUserJsonDataRepository = { get: jest.fn(), save: jest.fn() };
+  const mockDocumentArchiveService = {
+    load: jest.fn(async (document) => ({ originJson: document.originJson, translatedJson: document.translatedJson })),
+  };
+
+  const document = (data: Record<string, any> = {}): any => ({
+    id: 'task1',
+    userId: 'user1',
+    translatedJson: '{"nav":{"home":"首页","about":"关于"}}',
+    ...data,
+  });
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        TranslationReviewService,
+        { provide: TranslationTaskRepository, useValue: mockTaskRepository },
+        { provide: UserJsonDataRepository, useValue: mockUserJsonDataRepository },
+        { provide: DocumentArchiveService, useValue: mockDocumentArchiveService },
+      ],
+    }).compile();
+
+    service = module.get<TranslationReviewService>(TranslationReviewService);
+    mockTaskRepository.get.mockResolvedValue({ id: 'task1', userId: 'user1', isTranslated: true });
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('文档应按 machine_translated → in_review → approved 流转并记录审校人', async () => {
+    const doc = document();
+    mockUserJsonDataRepository.get.mockResolvedValue(doc);
+
+    await service.transition('user1', 'task1', { status: ReviewStatus.IN_REVIEW });
+    const result = await service.transition('user1', 'task1', { status: ReviewStatus.APPROVED });
+
+    expect(result).toMatchObject({ reviewStatus: ReviewStatus.APPROVED, reviewedBy: 'user1' });
+    expect(mockUserJsonDataRepository.save).toHaveBeenCalledTimes(2);
+  });
+
+  it('不允许跳过审校直接通过', async () => {
+    mockUserJsonDataRepository.get.mockResolvedValue(document());
+
+    await expect(service.transition('user1', 'task1', { status: ReviewStatus.APPROVED })).rejects.toThrow(
+      ConflictException,
+    );
+    expect(mockUserJsonDataRepository.save).not.toHaveBeenCalled();
+  });
+
+  it('可以只变更单个键的状态', async () => {
+    const doc = document({ reviewStatus: ReviewStatus.IN_REVIEW });
+    mockUserJsonDataRepository.get.mockResolvedValue(doc);
+
+    const result = await service.transition('user1', 'task1', { status: ReviewStatus.APPROVED, keys: ['nav.home'] });
+
+    expect(result.reviewStatus).toBe(ReviewStatus.IN_REVIEW);
+    expect(result.keyReviewStatus).toEqual({ 'nav.home': ReviewStatus.APPROVED });
+  });
+
+  it('重新翻译后回到待审，只保留锁定键的状态', () => {
+    const doc = document({
+      reviewStatus: ReviewStatus.APPROVED,
+      lockedKeys: ['nav.home'],
+      keyReviewStatus: { 'nav.home': ReviewStatus.APPROVED, 'nav.about': ReviewStatus.APPROVED },
+    });
+
+    service.resetAfterTranslation(doc);
+
+    expect(doc.reviewStatus).toBe(ReviewStatus.MACHINE_TRANSLATED);
+    expect(doc.keyReviewStatus).toEqual({ 'nav.home': ReviewStatus.APPROVED });
+  });
+});
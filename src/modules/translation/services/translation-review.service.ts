@@ -0,0 +1,125 @@
+import { BadRequestException, ConflictException, Injectable, Logger, NotFoundException } from '@nestjs/common';
+import { ReviewStatus, UserJsonData } from '../entities/translation-task.entity';
+import { ReviewTransitionDto } from '../dto/translation-review.dto';
+import { TranslationTaskRepository, UserJsonDataRepository } from '../repositories/translation-task.repository';
+import { DocumentArchiveService } from './document-archive.service';
+import { getPath, isPlainObject } from '../utils/json-diff';
+import { parseKeyPath } from '../utils/locked-keys';
+import { ApiKeyContext } from '../../api-key/interfaces/api-key-context.interface';
+import { taskScope } from '../utils/task-scope';
+
+/** 允许的状态流转：送审、通过、退回重译、重新打开已通过的文档 */
+const REVIEW_TRANSITIONS: Record<ReviewStatus, ReviewStatus[]> = {
+  [ReviewStatus.MACHINE_TRANSLATED]: [ReviewStatus.IN_REVIEW],
+  [ReviewStatus.IN_REVIEW]: [ReviewStatus.APPROVED, ReviewStatus.MACHINE_TRANSLATED],
+  [ReviewStatus.APPROVED]: [ReviewStatus.IN_REVIEW],
+};
+
+export function reviewStatusOf(document: UserJsonData): ReviewStatus {
+  return document.reviewStatus ?? ReviewStatus.MACHINE_TRANSLATED;
+}
+
+/**
+ * 译文审校流程
+ * 文档（或单个键）按 machine_translated → in_review → approved 流转，团队可以只发布已通过审校的译文
+ */
+@Injectable()
+export class TranslationReviewService {
+  private readonly logger = new Logger(TranslationReviewService.name);
+
+  constructor(
+    private readonly taskRepository: TranslationTaskRepository,
+    private readonly userJsonDataRepository: UserJsonDataRepository,
+    private readonly documentArchiveService: DocumentArchiveService,
+  ) {}
+
+  async getReview(userId: string, taskId: string, apiKey?: ApiKeyContext) {
+    return this.toView(await this.getTranslated(userId, taskId, apiKey));
+  }
+
+  async transition(userId: string, taskId: string, dto: ReviewTransitionDto, apiKey?: ApiKeyContext) {
+    const document = await this.getTranslated(userId, taskId, apiKey);
+
+    if (dto.keys) {
+      const { translatedJson } = await this.documentArchiveService.load(document);
+      const translated = JSON.parse(translatedJson);
+      const keyStatus = { ...(document.keyReviewStatus ?? {}) };
+      for (const key of dto.keys) {
+        const value = getPath(translated, parseKeyPath(key));
+        if (value === undefined || isPlainObject(value)) {
+          throw new BadRequestException(`Key "${key}" does not exist or is not a translated value`);
+        }
+        this.assertTransition(keyStatus[key] ?? reviewStatusOf(document), dto.status, key);
+        keyStatus[key] = dto.status;
+      }
+      document.keyReviewStatus = keyStatus;
+    } else {
+      this.assertTransition(reviewStatusOf(document), dto.status);
+      document.reviewStatus = dto.status;
+      // 文档整体流转后，单个键的状态不再有意义
+      document.keyReviewStatus = null;
+    }
+
+    document.reviewedBy = userId;
+    document.reviewedAt = new Date();
+    await this.userJsonDataRepository.save(document);
+    this.logger.log(`Translation ${taskId} moved to ${dto.status}${dto.keys ? ` for ${dto.keys.length} key(s)` : ''}`);
+    return this.toView(document);
+  }
+
+  /**
+   * 机器重新翻译后回到待审状态，只保留锁定键（内容未变）的键级状态
+   */
+  resetAfterTranslation(document: UserJsonData): void {
+    document.reviewStatus = ReviewStatus.MACHINE_TRANSLATED;
+    if (document.keyReviewStatus) {
+      const locked = new Set(document.lockedKeys ?? []);
+      const kept = Object.entries(document.keyReviewStatus).filter(([key]) => locked.has(key));
+      document.keyReviewStatus = kept.length > 0 ? Object.fromEntries(kept) : null;
+    }
+  }
+
+  /**
+   * 人工修改已通过审校的译文后重新进入审校
+   */
+  reopenAfterEdit(document: UserJsonData, keys: string[]): void {
+    if (reviewStatusOf(document) === ReviewStatus.APPROVED) {
+      document.reviewStatus = ReviewStatus.IN_REVIEW;
+    }
+    if (document.keyReviewStatus) {
+      for (const key of keys) {
+        if (document.keyReviewStatus[key] === ReviewStatus.APPROVED) {
+          document.keyReviewStatus[key] = ReviewStatus.IN_REVIEW;
+        }
+      }
+    }
+  }
+
+  private assertTransition(from: ReviewStatus, to: ReviewStatus, key?: string): void {
+    if (!REVIEW_TRANSITIONS[from].includes(to)) {
+      throw new ConflictException(`Cannot move ${key ? `key "${key}"` : 'translation'} from ${from} to ${to}`);
+    }
+  }
+
+  private async getTranslated(userId: string, taskId: string, apiKey?: ApiKeyContext): Promise<UserJsonData> {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    const document = task ? await this.userJsonDataRepository.get({ id: taskId, userId }) : null;
+    if (!document) {
+      throw new NotFoundException('Translation not found');
+    }
+    if (!task.isTranslated) {
+      throw new ConflictException('Translation has not finished yet');
+    }
+    return document;
+  }
+
+  private toView(document: UserJsonData) {
+    return {
+      id: document.id,
+      reviewStatus: reviewStatusOf(document),
+      keyReviewStatus: document.keyReviewStatus ?? {},
+      reviewedBy: document.reviewedBy ?? null,
+      reviewedAt: document.reviewedAt ?? null,
+    };
+  }
+}
@@ -0,0 +1,68 @@
+import { Injectable } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { UserJsonData } from '../entities/translation-task.entity';
+import { ValidationIssues, ValidationOptions, validateTranslationJson } from '../utils/translation-validation';
+import { parsePlaceholderStyles } from '../utils/placeholders';
+
+export interface ValidationReport {
+  valid: boolean;
+  checkedAt: string;
+  /** 各类问题的数量 */
+  summary: Record<string, number>;
+  issues: ValidationIssues;
+}
+
+/**
+ * 译文校验报告
+ * 翻译完成后检查占位符、空译文、长度比例、HTML 标签配对和 JSON 结构，报告随文档保存，
+ * 人工修改译文后重新生成
+ */
+@Injectable()
+export class TranslationValidationService {
+  private readonly minLengthRatio: number;
+  private readonly maxLengthRatio: number;
+  private readonly minLengthForRatio: number;
+
+  constructor(private readonly configService: ConfigService) {
+    this.minLengthRatio = Number(this.configService.get('VALIDATION_MIN_LENGTH_RATIO', 0.3));
+    this.maxLengthRatio = Number(this.configService.get('VALIDATION_MAX_LENGTH_RATIO', 3));
+    this.minLengthForRatio = Number(this.configService.get('VALIDATION_MIN_LENGTH_FOR_RATIO', 20));
+  }
+
+  validate(document: UserJsonData, originJson: string, translatedJson: string): ValidationReport {
+    const issues = validateTranslationJson(originJson, translatedJson, this.optionsFor(document));
+    const summary: Record<string, number> = {};
+    for (const keyIssues of Object.values(issues)) {
+      for (const issue of keyIssues) {
+        summary[issue.type] = (summary[issue.type] ?? 0) + 1;
+      }
+    }
+    return {
+      valid: Object.keys(issues).length === 0,
+      checkedAt: new Date().toISOString(),
+      summary,
+      issues,
+    };
+  }
+
+  /**
+   * 重新校验并保存到文档（不落库，由调用方保存）
+   */
+  refresh(document: UserJsonData): ValidationReport | undefined {
+    if (!document.originJson || !document.translatedJson) {
+      return undefined;
+    }
+    document.validationReport = this.validate(document, document.originJson, document.translatedJson);
+    return document.validationReport;
+  }
+
+  private optionsFor(document: UserJsonData): ValidationOptions {
+    return {
+      placeholderStyles: parsePlaceholderStyles(document.placeholderStyles ?? []),
+      minLengthRatio: this.minLengthRatio,
+      maxLengthRatio: this.maxLengthRatio,
+      minLengthForRatio: this.minLengthForRatio,
+      checkFormatting: !!document.preserveFormatting,
+    };
+  }
+}
@@ -0,0 +1,144 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager } from '@mikro-orm/core';
+import { UsageImportService } from './usage-import.service';
+import { UsageRollupService } from './usage-rollup.service';
+import { JsonLimitsService } from './json-limits.service';
+import { UsageImportFormat } from '../dto/usage-import.dto';
+import { TranslationTaskRepository, UserJsonDataRepository } from '../repositories/translation-task.repository';
+import { AuditLogService } from '../../audit/services/audit-log.service';
+
+describe('UsageImportService', () => {
+  let service: UsageImportService;
+
+  const mockEntityManager = {
+    find: jest.fn(),
+  };
+
+  const mockTaskRepository = {
+    list: jest.fn(),
+    build: jest.fn((data) => ({ ...data })),
+    save: jest.fn(),
+  };
+
+  const mockUserJsonDataRepository = {
+    build: jest.fn((data) => ({ ...data })),
+  };
+
+  const mockUsageRollupService = {
+    backfill: jest.fn(),
+  };
+
+  const mockAuditLogService = {
+    log: jest.fn(),
+  };
+
+  const csv = [
+    'externalId,userId,occurredAt,characters,fromLang,toLang,sourceJson',
+    'r1,u1,2026-09-01T10:00:00Z,100,,,',
+    'r2,u1,2026-10-02T10:00:00Z,50,en,fr,"{""a"":""b""}"',
+  ].join('\n');
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        UsageImportService,
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+        { provide: EntityManager, useValue: mockEntityManager },
+        { provide: TranslationTaskRepository, useValue: mockTaskRepository },
+        { provide: UserJsonDataRepository, useValue: mockUserJsonDataRepository },
+        { provide: UsageRollupService, useValue: mockUsageRollupService },
+        { provide: AuditLogService, useValue: mockAuditLogService },
+        JsonLimitsService,
+      ],
+    }).compile();
+
+    service = module.get<UsageImportService>(UsageImportService);
+    mockEntityManager.find.mockResolvedValue([{ id: 'u1' }]);
+    mockTaskRepository.list.mockResolvedValue([]);
+    mockUsageRollupService.backfill.mockImplementation(async (events) => ({
+      events: events.length,
+      users: 1,
+      characters: events.reduce((sum, event) => sum + event.characters, 0),
+    }));
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('试运行只返回报告，不写入', async () => {
+    const report = await service.import({ format: UsageImportFormat.CSV, csv, dryRun: true }, 'admin');
+
+    expect(report).toMatchObject({ dryRun: true, total: 2, valid: 2, imported: 0, errors: [] });
+    expect(report.monthlyTotals).toEqual({ u1: { '2026-09': 100, '2026-10': 50 } });
+    expect(mockUsageRollupService.backfill).not.toHaveBeenCalled();
+    expect(mockTaskRepository.save).not.toHaveBeenCalled();
+  });
+
+  it('应导入用量并为带原文的记录创建文档', async () => {
+    const report = await service.import({ format: UsageImportFormat.CSV, csv, source: 'legacy' }, 'admin');
+
+    expect(report).toMatchObject({ imported: 2, skipped: 0, documents: 1, characters: 150 });
+    const [events] = mockUsageRollupService.backfill.mock.calls[0];
+    expect(events[0]).toMatchObject({ userId: 'u1', characters: 100, occurredAt: '2026-09-01T10:00:00.000Z' });
+    const [documents] = mockTaskRepository.save.mock.calls[0];
+    expect(documents).toHaveLength(2);
+    expect(documents[0].id).toBe(events[1].taskId);
+    expect(mockAuditLogService.log).toHaveBeenCalled();
+  });
+
+  it('同一来源重复导入时应得到相同的任务 ID', async () => {
+    await service.import({ format: UsageImportFormat.CSV, csv, source: 'legacy' }, 'admin');
+    await service.import({ format: UsageImportFormat.CSV, csv, source: 'legacy' }, 'admin');
+
+    const [first] = mockUsageRollupService.backfill.mock.calls[0];
+    const [second] = mockUsageRollupService.backfill.mock.calls[1];
+    expect(second.map((event) => event.taskId)).toEqual(first.map((event) => event.taskId));
+  });
+
+  it('存在无效记录时整批不写入并逐行报告错误', async () => {
+    const report = await service.import(
+      {
+        format: UsageImportFormat.JSON,
+        records: [
+          { externalId: 'r1', userId: 'u1', occurredAt: '2026-09-01T10:00:00Z', characters: 10 },
+          { externalId: 'r2', userId: 'u1', occurredAt: 'yesterday', characters: -1 },
+          { externalId: 'r3', userId: 'ghost', occurredAt: '2026-09-01T10:00:00Z', characters: 10 },
+          { externalId: 'r1', userId: 'u1', occurredAt: '2026-09-02T10:00:00Z', characters: 10 },
+        ],
+      },
+      'admin',
+    );
+
+    expect(report.errors.map((error) => error.row)).toEqual([2, 3, 4]);
+    expect(report.errors[1].errors).toEqual(['user ghost does not exist']);
+    expect(report.errors[2].errors).toEqual(['duplicate externalId for this user in the same import']);
+    expect(mockUsageRollupService.backfill).not.toHaveBeenCalled();
+  });
+
+  it('原文超过 JSON 嵌套深度限制时在解析前拒绝该记录', async () => {
+    const report = await service.import(
+      {
+        format: UsageImportFormat.JSON,
+        records: [
+          {
+            externalId: 'r1',
+            userId: 'u1',
+            occurredAt: '2026-09-01T10:00:00Z',
+            characters: 10,
+            fromLang: 'en',
+            toLang: 'fr',
+            sourceJson: `{"a":${'['.repeat(40)}${']'.repeat(40)}}`,
+          },
+        ],
+      },
+      'admin',
+    );
+
+    expect(report.errors).toEqual([
+      expect.objectContaining({ row: 1, errors: ['sourceJson: JSON nesting exceeds 32 levels'] }),
+    ]);
+    expect(mockUsageRollupService.backfill).not.toHaveBeenCalled();
+  });
+});
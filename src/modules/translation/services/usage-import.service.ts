@@ -0,0 +1,263 @@
+import { BadRequestException, Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager } from '@mikro-orm/core';
+import { plainToInstance } from 'class-transformer';
+import { validate } from 'class-validator';
+import { v5 as uuidv5 } from 'uuid';
+import { UsageImportDto, UsageImportFormat, UsageImportRecordDto } from '../dto/usage-import.dto';
+import { TranslationTaskRepository, UserJsonDataRepository } from '../repositories/translation-task.repository';
+import { UsageRollupService } from './usage-rollup.service';
+import { JsonLimitsService } from './json-limits.service';
+import { User } from '../../user/entities/user.entity';
+import { AuditLogService } from '../../audit/services/audit-log.service';
+import { AuditAction, AuditSeverity, ResourceType } from '../../audit/entities/audit-log.entity';
+import { parseCsv } from '../../../common/utils/csv';
+
+/** 导入记录 ID 的命名空间，同一来源、用户和 externalId 总是得到同一个任务 ID */
+const IMPORT_ID_NAMESPACE = '1b671a64-40d5-491e-99b0-da01ff1f3341';
+
+export interface UsageImportRowError {
+  row: number;
+  externalId?: string;
+  errors: string[];
+}
+
+export interface UsageImportReport {
+  dryRun: boolean;
+  total: number;
+  valid: number;
+  /** 之前已导入过的记录 */
+  skipped: number;
+  imported: number;
+  documents: number;
+  characters: number;
+  /** 每个用户按月份汇总的导入字符数，用于核对月度计数 */
+  monthlyTotals: Record<string, Record<string, number>>;
+  errors: UsageImportRowError[];
+}
+
+/**
+ * 历史用量导入
+ * 从旧系统迁移时回填用量和文档记录，使客户的历史和当月额度计数从一开始就是正确的。
+ * 只要有一条记录校验失败就整批不写入；按来源 + 用户 + externalId 去重，可安全重跑
+ */
+@Injectable()
+export class UsageImportService {
+  private readonly logger = new Logger(UsageImportService.name);
+  private readonly maxRecords: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly em: EntityManager,
+    private readonly taskRepository: TranslationTaskRepository,
+    private readonly userJsonDataRepository: UserJsonDataRepository,
+    private readonly usageRollupService: UsageRollupService,
+    private readonly auditLogService: AuditLogService,
+    private readonly jsonLimitsService: JsonLimitsService,
+  ) {
+    this.maxRecords = Number(this.configService.get('USAGE_IMPORT_MAX_RECORDS', 10000));
+  }
+
+  async import(dto: UsageImportDto, actorId: string): Promise<UsageImportReport> {
+    const rows = this.readRows(dto);
+    const source = dto.source || 'import';
+    const dryRun = !!dto.dryRun;
+    const errors: UsageImportRowError[] = [];
+    const records: Array<{ row: number; record: UsageImportRecordDto; taskId: string }> = [];
+    const seen = new Set<string>();
+
+    for (const [index, row] of rows.entries()) {
+      const record = plainToInstance(UsageImportRecordDto, normalizeRow(row));
+      const messages = (await validate(record)).flatMap((error) => Object.values(error.constraints || {}));
+      messages.push(...checkRecord(record, this.jsonLimitsService));
+
+      const taskId = uuidv5(`${source}:${record.userId}:${record.externalId}`, IMPORT_ID_NAMESPACE);
+      if (seen.has(taskId)) {
+        messages.push('duplicate externalId for this user in the same import');
+      }
+      seen.add(taskId);
+
+      if (messages.length > 0) {
+        errors.push({ row: index + 1, externalId: record.externalId, errors: messages });
+      } else {
+        records.push({ row: index + 1, record, taskId });
+      }
+    }
+
+    const userIds = [...new Set(records.map(({ record }) => record.userId))];
+    const existingUsers = new Set(
+      (await this.em.find(User, { id: { $in: userIds } }, { fields: ['id'] })).map((user) => user.id),
+    );
+    for (const { row, record } of records) {
+      if (!existingUsers.has(record.userId)) {
+        errors.push({ row, externalId: record.externalId, errors: [`user ${record.userId} does not exist`] });
+      }
+    }
+    errors.sort((a, b) => a.row - b.row);
+
+    const valid = records.filter(({ record }) => existingUsers.has(record.userId));
+    const existingTasks = new Set(
+      (await this.taskRepository.list({ id: { $in: valid.map(({ taskId }) => taskId) } })).map((task) => task.id),
+    );
+    const report: UsageImportReport = {
+      dryRun,
+      total: rows.length,
+      valid: valid.length,
+      skipped: 0,
+      imported: 0,
+      documents: 0,
+      characters: 0,
+      monthlyTotals: {},
+      errors,
+    };
+    for (const { record } of valid) {
+      const month = new Date(record.occurredAt).toISOString().slice(0, 7);
+      const totals = (report.monthlyTotals[record.userId] ??= {});
+      totals[month] = (totals[month] ?? 0) + record.characters;
+    }
+
+    if (dryRun || errors.length > 0) {
+      return report;
+    }
+
+    const documents = valid
+      .filter(({ record, taskId }) => record.sourceJson && !existingTasks.has(taskId))
+      .flatMap(({ record, taskId }) => this.buildDocument(record, taskId));
+    if (documents.length > 0) {
+      await this.taskRepository.save(documents);
+    }
+
+    const result = await this.usageRollupService.backfill(
+      valid.map(({ record, taskId }) => ({
+        taskId,
+        userId: record.userId,
+        apiKeyId: record.apiKeyId,
+        tenantId: record.tenantId,
+        characters: record.characters,
+        occurredAt: new Date(record.occurredAt).toISOString(),
+      })),
+    );
+    report.imported = result.events;
+    report.skipped = valid.length - result.events;
+    report.documents = documents.length / 2;
+    report.characters = result.characters;
+
+    await this.auditLogService.log({
+      userId: actorId,
+      action: AuditAction.USAGE_IMPORT,
+      resourceType: ResourceType.SYSTEM_CONFIG,
+      newValues: { source, imported: report.imported, skipped: report.skipped, characters: report.characters },
+      severity: AuditSeverity.HIGH,
+      description: `Imported ${report.imported} historical usage record(s) from ${source}`,
+      tags: ['usage_import'],
+    });
+    this.logger.log(
+      `Usage import from ${source}: ${report.imported} imported, ${report.skipped} skipped, ${report.characters} characters`,
+    );
+    return report;
+  }
+
+  private readRows(dto: UsageImportDto): Record<string, any>[] {
+    let rows: Record<string, any>[];
+    if (dto.format === UsageImportFormat.CSV) {
+      if (!dto.csv) {
+        throw new BadRequestException('csv is required when format is csv');
+      }
+      try {
+        rows = parseCsv(dto.csv);
+      } catch (error) {
+        throw new BadRequestException(`Invalid CSV: ${error.message}`);
+      }
+    } else {
+      if (!dto.records) {
+        throw new BadRequestException('records is required when format is json');
+      }
+      rows = dto.records;
+    }
+
+    if (rows.length === 0) {
+      throw new BadRequestException('No records to import');
+    }
+    if (rows.length > this.maxRecords) {
+      throw new BadRequestException(`At most ${this.maxRecords} records can be imported at once`);
+    }
+    return rows;
+  }
+
+  private buildDocument(record: UsageImportRecordDto, taskId: string) {
+    const createdAt = new Date(record.occurredAt);
+    return [
+      this.taskRepository.build({
+        id: taskId,
+        userId: record.userId,
+        content: record.sourceJson,
+        status: 'pending',
+        isTranslated: !!record.translatedJson,
+        charTotal: record.characters,
+        apiKeyId: record.apiKeyId,
+        tenantId: record.tenantId,
+        createdAt,
+      }),
+      this.userJsonDataRepository.build({
+        id: taskId,
+        userId: record.userId,
+        originJson: record.sourceJson,
+        fromLang: record.fromLang,
+        toLang: record.toLang,
+        translatedJson: record.translatedJson,
+        charTotal: record.characters,
+        createdAt,
+      }),
+    ];
+  }
+}
+
+/**
+ * CSV 中的值都是字符串：空值视为未提供，数字列转换为数字
+ */
+function normalizeRow(row: Record<string, any>): Record<string, any> {
+  const normalized: Record<string, any> = {};
+  for (const [key, value] of Object.entries(row || {})) {
+    if (value === '' || value === null) {
+      continue;
+    }
+    normalized[key] = key === 'characters' && typeof value === 'string' ? Number(value) : value;
+  }
+  return normalized;
+}
+
+function checkRecord(record: UsageImportRecordDto, jsonLimits: JsonLimitsService): string[] {
+  const messages: string[] = [];
+  if (record.occurredAt && new Date(record.occurredAt).getTime() > Date.now()) {
+    messages.push('occurredAt must not be in the future');
+  }
+  if (record.sourceJson) {
+    if (!record.fromLang || !record.toLang) {
+      messages.push('fromLang and toLang are required when sourceJson is given');
+    }
+    for (const field of ['sourceJson', 'translatedJson'] as const) {
+      if (!record[field]) {
+        continue;
+      }
+      // 先按大小、深度和键数量限制检查，超限的文档不进入 JSON.parse
+      const violation = jsonLimits.check(record[field]);
+      if (violation) {
+        messages.push(`${field}: ${violation.message}`);
+      } else if (!isJson(record[field])) {
+        messages.push(`${field} must be valid JSON`);
+      }
+    }
+  } else if (record.translatedJson) {
+    messages.push('translatedJson requires sourceJson');
+  }
+  return messages;
+}
+
+function isJson(value: string): boolean {
+  try {
+    JSON.parse(value);
+    return true;
+  } catch {
+    return false;
+  }
+}
@@ -0,0 +1,181 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager } from '@mikro-orm/core';
+import { previousPeriod, USAGE_REPORT_EVENT, UsageReportService } from './usage-report.service';
+import { RedisService } from '../../../common/services/redis.service';
+import { WebhookService } from '../../webhook/webhook.service';
+import { TenantMailService } from '../../tenant/services/tenant-mail.service';
+import { PlanLimitsService } from '../../subscription/services/plan-limits.service';
+import {
+  CharacterUsageLogDailyRepository,
+  CharacterUsageLogRepository,
+  UsageReportSubscriptionRepository,
+} from '../repositories/character-usage.repository';
+import { UserJsonDataRepository } from '../repositories/translation-task.repository';
+import { UsageReportChannel, UsageReportFrequency } from '../entities/usage-report.entity';
+
+describe('UsageReportService', () => {
+  let service: UsageReportService;
+  let sentMarkers: Set<string>;
+  const now = new Date('2026-10-16T00:00:00Z');
+
+  const mockRedisService = {
+    setIfAbsent: jest.fn(async (key: string) => {
+      if (sentMarkers.has(key)) {
+        return false;
+      }
+      sentMarkers.add(key);
+      return true;
+    }),
+    del: jest.fn(),
+  };
+
+  const mockWebhookService = {
+    dispatchEvent: jest.fn().mockResolvedValue(true),
+  };
+
+  const mockTenantMailService = {
+    send: jest.fn().mockResolvedValue(true),
+  };
+
+  const mockSubscriptionRepository = {
+    get: jest.fn(),
+    getOrFail: jest.fn(),
+    insert: jest.fn(async (data) => data),
+    save: jest.fn(),
+    delete: jest.fn(),
+    list: jest.fn(),
+  };
+
+  const mockUsageLogRepository = {
+    list: jest.fn().mockResolvedValue([
+      { jsonId: 'doc1', totalCharacters: 500 },
+      { jsonId: 'doc2', totalCharacters: 200 },
+      { jsonId: 'doc1', totalCharacters: 700 },
+    ]),
+  };
+
+  beforeEach(async () => {
+    sentMarkers = new Set();
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        UsageReportService,
+        {
+          provide: ConfigService,
+          useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) },
+        },
+        { provide: EntityManager, useValue: { findOne: jest.fn().mockResolvedValue({ email: 'user@example.com' }) } },
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: WebhookService, useValue: mockWebhookService },
+        { provide: TenantMailService, useValue: mockTenantMailService },
+        {
+          provide: PlanLimitsService,
+          useValue: { resolve: jest.fn().mockResolvedValue({ monthlyCharacterLimit: 5000 }) },
+        },
+        { provide: UsageReportSubscriptionRepository, useValue: mockSubscriptionRepository },
+        { provide: CharacterUsageLogRepository, useValue: mockUsageLogRepository },
+        { provide: CharacterUsageLogDailyRepository, useValue: { sumSince: jest.fn().mockResolvedValue(3000) } },
+        {
+          provide: UserJsonDataRepository,
+          useValue: { list: jest.fn().mockResolvedValue([{ id: 'doc1', name: 'checkout-page-v2' }]) },
+        },
+      ],
+    }).compile();
+
+    service = module.get<UsageReportService>(UsageReportService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('应计算上一个自然周和自然月', () => {
+    expect(previousPeriod(UsageReportFrequency.WEEKLY, now)).toEqual({
+      label: '2026-W41',
+      start: new Date('2026-10-05T00:00:00Z'),
+      end: new Date('2026-10-12T00:00:00Z'),
+    });
+    expect(previousPeriod(UsageReportFrequency.WEEKLY, new Date('2027-01-06T12:00:00Z')).label).toBe('2026-W53');
+    expect(previousPeriod(UsageReportFrequency.MONTHLY, new Date('2026-10-01T06:00:00Z'))).toEqual({
+      label: '2026-09',
+      start: new Date('2026-09-01T00:00:00Z'),
+      end: new Date('2026-10-01T00:00:00Z'),
+    });
+  });
+
+  it('报告应包含用量最多的文档和按日均用量推算的月底用量', async () => {
+    const report = await service.build('user1', UsageReportFrequency.WEEKLY, now);
+
+    expect(report.period).toEqual({ label: '2026-W41', from: '2026-10-05', to: '2026-10-11' });
+    expect(report.totalCharacters).toBe(1400);
+    expect(report.documents).toBe(2);
+    expect(report.topDocuments).toEqual([
+      { id: 'doc1', name: 'checkout-page-v2', characters: 1200 },
+      { id: 'doc2', name: null, characters: 200 },
+    ]);
+    // 日均 200 字符，距月底 16 天
+    expect(report.forecast).toEqual({
+      month: '2026-10',
+      used: 3000,
+      projected: 6200,
+      limit: 5000,
+      projectedPercent: 124,
+      exhaustsOn: '2026-10-26',
+    });
+  });
+
+  it('订阅时不补发已结束的周期', async () => {
+    mockSubscriptionRepository.get.mockResolvedValueOnce(null);
+
+    const subscription = await service.subscribe(
+      'user1',
+      { frequency: UsageReportFrequency.MONTHLY, channels: [UsageReportChannel.EMAIL, UsageReportChannel.EMAIL] },
+      undefined,
+      now,
+    );
+
+    expect(subscription).toMatchObject({ lastPeriod: '2026-09', channels: [UsageReportChannel.EMAIL] });
+  });
+
+  it('周期结束后应按订阅的方式发送一次', async () => {
+    const subscription: any = {
+      id: 'sub1',
+      userId: 'user1',
+      frequency: UsageReportFrequency.WEEKLY,
+      channels: [UsageReportChannel.EMAIL, UsageReportChannel.WEBHOOK],
+      lastPeriod: '2026-W40',
+    };
+    mockSubscriptionRepository.list.mockResolvedValueOnce([subscription]).mockResolvedValueOnce([]);
+
+    await expect(service.sendDue(now)).resolves.toBe(1);
+
+    expect(mockWebhookService.dispatchEvent).toHaveBeenCalledWith(
+      'user1',
+      undefined,
+      USAGE_REPORT_EVENT,
+      expect.objectContaining({ totalCharacters: 1400 }),
+    );
+    expect(mockTenantMailService.send).toHaveBeenCalledWith(
+      undefined,
+      expect.objectContaining({ to: 'user@example.com', subject: 'Usage report for 2026-W41' }),
+    );
+    expect(subscription.lastPeriod).toBe('2026-W41');
+    expect(mockSubscriptionRepository.save).toHaveBeenCalledWith(subscription);
+
+    mockSubscriptionRepository.list.mockResolvedValueOnce([subscription]).mockResolvedValueOnce([]);
+    await expect(service.sendDue(now)).resolves.toBe(0);
+    expect(mockTenantMailService.send).toHaveBeenCalledTimes(1);
+  });
+
+  it('其他 worker 已在发送同一周期时跳过', async () => {
+    sentMarkers.add('usage_report:user1:2026-W41');
+    mockSubscriptionRepository.list
+      .mockResolvedValueOnce([
+        { id: 'sub1', userId: 'user1', frequency: UsageReportFrequency.WEEKLY, channels: [UsageReportChannel.EMAIL] },
+      ])
+      .mockResolvedValueOnce([]);
+
+    await expect(service.sendDue(now)).resolves.toBe(0);
+    expect(mockTenantMailService.send).not.toHaveBeenCalled();
+  });
+});
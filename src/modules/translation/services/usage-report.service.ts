@@ -0,0 +1,277 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { EntityManager } from '@mikro-orm/core';
+import { v4 as uuidv4 } from 'uuid';
+import { RedisService } from '../../../common/services/redis.service';
+import { WebhookService } from '../../webhook/webhook.service';
+import { TenantMailService } from '../../tenant/services/tenant-mail.service';
+import { PlanLimitsService } from '../../subscription/services/plan-limits.service';
+import {
+  CharacterUsageLogDailyRepository,
+  CharacterUsageLogRepository,
+  UsageReportSubscriptionRepository,
+} from '../repositories/character-usage.repository';
+import { UserJsonDataRepository } from '../repositories/translation-task.repository';
+import {
+  UsageReportChannel,
+  UsageReportFrequency,
+  UsageReportSubscription,
+} from '../entities/usage-report.entity';
+import { UsageReportSubscriptionDto } from '../dto/usage-report.dto';
+import { formatNotification, toEmailBody } from '../../webhook/utils/notification-message';
+import { User } from '../../user/entities/user.entity';
+
+export const USAGE_REPORT_EVENT = 'usage.report';
+
+const DAY_MS = 24 * 3600 * 1000;
+
+export interface UsageReportPeriod {
+  /** 2026-W41 / 2026-09 */
+  label: string;
+  start: Date;
+  /** 不含 */
+  end: Date;
+}
+
+export interface UsageReport {
+  frequency: UsageReportFrequency;
+  period: { label: string; from: string; to: string };
+  totalCharacters: number;
+  /** 周期内产生用量的文档数 */
+  documents: number;
+  topDocuments: { id: string; name: string | null; characters: number }[];
+  /** 按报告周期的日均用量推算当月月底的用量 */
+  forecast: {
+    month: string;
+    used: number;
+    projected: number;
+    limit: number | null;
+    projectedPercent: number | null;
+    /** 预计用完额度的日期，不会用完时为 null */
+    exhaustsOn: string | null;
+  };
+}
+
+/**
+ * 定期用量报告
+ * 用户订阅后，worker 每小时检查一次，每个周期结束后按订阅的方式发送上一个周期的用量汇总：
+ * 总字符数、用量最多的文档和当月用量预测。同一周期通过 Redis 标记只发送一次（多个 worker 同时运行）
+ */
+@Injectable()
+export class UsageReportService {
+  private readonly logger = new Logger(UsageReportService.name);
+  private readonly topDocuments: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly em: EntityManager,
+    private readonly redisService: RedisService,
+    private readonly webhookService: WebhookService,
+    private readonly tenantMailService: TenantMailService,
+    private readonly planLimitsService: PlanLimitsService,
+    private readonly subscriptionRepository: UsageReportSubscriptionRepository,
+    private readonly usageLogRepository: CharacterUsageLogRepository,
+    private readonly dailyUsageRepository: CharacterUsageLogDailyRepository,
+    private readonly userJsonDataRepository: UserJsonDataRepository,
+  ) {
+    this.topDocuments = Number(this.configService.get('USAGE_REPORT_TOP_DOCUMENTS', 5));
+  }
+
+  /**
+   * 订阅或修改订阅；第一份报告在下一个周期结束后发送，不补发订阅前已结束的周期
+   */
+  async subscribe(
+    userId: string,
+    dto: UsageReportSubscriptionDto,
+    tenantId?: string,
+    now = new Date(),
+  ): Promise<UsageReportSubscription> {
+    const channels = [...new Set(dto.channels)];
+    const lastPeriod = previousPeriod(dto.frequency, now).label;
+    const existing = await this.subscriptionRepository.get({ userId });
+    if (!existing) {
+      return this.subscriptionRepository.insert({
+        id: uuidv4(),
+        userId,
+        tenantId,
+        frequency: dto.frequency,
+        channels,
+        lastPeriod,
+      });
+    }
+    if (existing.frequency !== dto.frequency) {
+      existing.lastPeriod = lastPeriod;
+    }
+    Object.assign(existing, { frequency: dto.frequency, channels, tenantId: tenantId ?? existing.tenantId });
+    await this.subscriptionRepository.save(existing);
+    return existing;
+  }
+
+  async get(userId: string): Promise<UsageReportSubscription> {
+    return this.subscriptionRepository.getOrFail({ userId }, 'Usage report subscription not found');
+  }
+
+  async unsubscribe(userId: string): Promise<void> {
+    await this.subscriptionRepository.delete(await this.get(userId));
+  }
+
+  /**
+   * 生成上一个周期的报告（不发送），未指定周期时使用订阅的周期，没有订阅时按周
+   */
+  async preview(userId: string, frequency?: UsageReportFrequency, now = new Date()): Promise<UsageReport> {
+    const subscription = frequency ? null : await this.subscriptionRepository.get({ userId });
+    return this.build(userId, frequency ?? subscription?.frequency ?? UsageReportFrequency.WEEKLY, now);
+  }
+
+  async build(userId: string, frequency: UsageReportFrequency, now = new Date()): Promise<UsageReport> {
+    const period = previousPeriod(frequency, now);
+    const logs = await this.usageLogRepository.list({
+      userId,
+      createdAt: { $gte: period.start, $lt: period.end },
+    });
+
+    const byDocument = new Map<string, number>();
+    let totalCharacters = 0;
+    for (const log of logs) {
+      totalCharacters += log.totalCharacters;
+      byDocument.set(log.jsonId, (byDocument.get(log.jsonId) ?? 0) + log.totalCharacters);
+    }
+    const top = [...byDocument.entries()].sort((a, b) => b[1] - a[1]).slice(0, this.topDocuments);
+    // 已删除的文档没有名称
+    const documents = top.length
+      ? await this.userJsonDataRepository.list({ userId, id: { $in: top.map(([id]) => id) } })
+      : [];
+    const names = new Map(documents.map((document) => [document.id, document.name]));
+
+    const periodDays = (period.end.getTime() - period.start.getTime()) / DAY_MS;
+    return {
+      frequency,
+      period: {
+        label: period.label,
+        from: toDate(period.start),
+        to: toDate(new Date(period.end.getTime() - DAY_MS)),
+      },
+      totalCharacters,
+      documents: byDocument.size,
+      topDocuments: top.map(([id, characters]) => ({ id, name: names.get(id) ?? null, characters })),
+      forecast: await this.forecast(userId, totalCharacters / periodDays, now),
+    };
+  }
+
+  /**
+   * 发送所有到期的报告，由 worker 定时调用
+   */
+  async sendDue(now = new Date()): Promise<number> {
+    let sent = 0;
+    let cursor = '';
+    for (;;) {
+      const subscriptions = await this.subscriptionRepository.list(
+        { id: { $gt: cursor } },
+        { limit: 100, orderBy: { id: 'ASC' } },
+      );
+      if (subscriptions.length === 0) {
+        return sent;
+      }
+      for (const subscription of subscriptions) {
+        if (await this.sendIfDue(subscription, now)) {
+          sent += 1;
+        }
+      }
+      cursor = subscriptions[subscriptions.length - 1].id;
+    }
+  }
+
+  private async sendIfDue(subscription: UsageReportSubscription, now: Date): Promise<boolean> {
+    const { label } = previousPeriod(subscription.frequency, now);
+    if (subscription.lastPeriod === label) {
+      return false;
+    }
+    const key = `usage_report:${subscription.userId}:${label}`;
+    if (!(await this.redisService.setIfAbsent(key, now.toISOString(), 40 * 24 * 3600))) {
+      return false;
+    }
+
+    try {
+      const report = await this.build(subscription.userId, subscription.frequency, now);
+      await this.deliver(subscription, report);
+      subscription.lastPeriod = label;
+      subscription.lastSentAt = now;
+      await this.subscriptionRepository.save(subscription);
+      return true;
+    } catch (error) {
+      // 释放标记，下一次检查时重试
+      await this.redisService.del(key);
+      this.logger.error(`Failed to send ${label} usage report to user ${subscription.userId}: ${error.message}`);
+      return false;
+    }
+  }
+
+  private async deliver(subscription: UsageReportSubscription, report: UsageReport): Promise<void> {
+    const { userId, tenantId } = subscription;
+    await Promise.all([
+      subscription.channels.includes(UsageReportChannel.WEBHOOK)
+        ? this.webhookService
+            .dispatchEvent(userId, tenantId, USAGE_REPORT_EVENT, report)
+            .catch((error) => this.logger.error(`Failed to deliver usage report webhook: ${error.message}`))
+        : Promise.resolve(),
+      subscription.channels.includes(UsageReportChannel.EMAIL)
+        ? this.sendEmail(userId, tenantId, report)
+        : Promise.resolve(),
+    ]);
+  }
+
+  private async sendEmail(userId: string, tenantId: string | undefined, report: UsageReport): Promise<void> {
+    const user = await this.em.findOne(User, { id: userId }, { fields: ['email'] });
+    if (!user?.email) {
+      return;
+    }
+    const message = formatNotification(USAGE_REPORT_EVENT, report);
+    await this.tenantMailService.send(tenantId, { to: user.email, subject: message.title, ...toEmailBody(message) });
+  }
+
+  private async forecast(userId: string, dailyRate: number, now: Date): Promise<UsageReport['forecast']> {
+    const month = now.toISOString().slice(0, 7);
+    const nextMonth = Date.UTC(now.getUTCFullYear(), now.getUTCMonth() + 1, 1);
+    const used = await this.dailyUsageRepository.sumSince(userId, `${month}-01`);
+    const projected = Math.round(used + (dailyRate * (nextMonth - now.getTime())) / DAY_MS);
+
+    const limit = (await this.planLimitsService.resolve(userId))?.monthlyCharacterLimit;
+    if (!limit || limit <= 0) {
+      return { month, used, projected, limit: null, projectedPercent: null, exhaustsOn: null };
+    }
+    let exhaustsOn: string | null = null;
+    if (used >= limit) {
+      exhaustsOn = toDate(now);
+    } else if (projected >= limit) {
+      exhaustsOn = toDate(new Date(now.getTime() + ((limit - used) / dailyRate) * DAY_MS));
+    }
+    return { month, used, projected, limit, projectedPercent: Math.floor((projected / limit) * 100), exhaustsOn };
+  }
+}
+
+/**
+ * now 之前最近一个已结束的周期（UTC）：周一开始的自然周，或自然月
+ */
+export function previousPeriod(frequency: UsageReportFrequency, now: Date): UsageReportPeriod {
+  if (frequency === UsageReportFrequency.MONTHLY) {
+    const start = new Date(Date.UTC(now.getUTCFullYear(), now.getUTCMonth() - 1, 1));
+    const end = new Date(Date.UTC(now.getUTCFullYear(), now.getUTCMonth(), 1));
+    return { label: start.toISOString().slice(0, 7), start, end };
+  }
+  const today = Date.UTC(now.getUTCFullYear(), now.getUTCMonth(), now.getUTCDate());
+  const end = new Date(today - ((now.getUTCDay() + 6) % 7) * DAY_MS);
+  const start = new Date(end.getTime() - 7 * DAY_MS);
+  return { label: isoWeek(start), start, end };
+}
+
+/** ISO 周编号（YYYY-Www），按该周的周四所在的年份计算 */
+function isoWeek(monday: Date): string {
+  const thursday = new Date(monday.getTime() + 3 * DAY_MS);
+  const year = thursday.getUTCFullYear();
+  const week = Math.floor((thursday.getTime() - Date.UTC(year, 0, 1)) / (7 * DAY_MS)) + 1;
+  return `${year}-W${String(week).padStart(2, '0')}`;
+}
+
+function toDate(date: Date): string {
+  return date.toISOString().slice(0, 10);
+}
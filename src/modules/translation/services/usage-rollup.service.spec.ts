@@ -0,0 +1,153 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { UsageRollupService, USAGE_EVENTS_KEY, USAGE_EVENTS_PROCESSING_KEY } from './usage-rollup.service';
+import { QuotaService } from './quota.service';
+import { QuotaWarningService } from './quota-warning.service';
+import { MonthlyUsageCounterService } from './monthly-usage-counter.service';
+import { RedisService } from '../../../common/services/redis.service';
+import { ErrorReporterService } from '../../../common/services/error-reporter.service';
+import {
+  CharacterUsageLogRepository,
+  CharacterUsageLogDailyRepository,
+} from '../repositories/character-usage.repository';
+
+describe('UsageRollupService', () => {
+  let service: UsageRollupService;
+
+  const mockRedisClient = {
+    rpush: jest.fn(),
+    lrange: jest.fn(),
+    eval: jest.fn(),
+  };
+
+  const mockRedisService = {
+    client: mockRedisClient,
+    del: jest.fn(),
+  };
+
+  const mockUsageLogRepository = {
+    list: jest.fn(),
+    build: jest.fn((data) => ({ ...data })),
+    save: jest.fn(),
+  };
+
+  const mockDailyUsageRepository = {
+    get: jest.fn(),
+    build: jest.fn((data) => ({ ...data })),
+  };
+
+  const mockQuotaService = {
+    recordOverage: jest.fn().mockResolvedValue(0),
+  };
+
+  const mockQuotaWarningService = {
+    checkUsage: jest.fn().mockResolvedValue(undefined),
+  };
+
+  const mockMonthlyUsageCounter = {
+    record: jest.fn().mockResolvedValue(undefined),
+  };
+
+  const event = (taskId: string, userId: string, characters: number, occurredAt = '2026-10-16T08:00:00.000Z') =>
+    JSON.stringify({ taskId, userId, characters, occurredAt });
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        UsageRollupService,
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: CharacterUsageLogRepository, useValue: mockUsageLogRepository },
+        { provide: CharacterUsageLogDailyRepository, useValue: mockDailyUsageRepository },
+        { provide: QuotaService, useValue: mockQuotaService },
+        { provide: QuotaWarningService, useValue: mockQuotaWarningService },
+        { provide: ErrorReporterService, useValue: { captureException: jest.fn() } },
+        { provide: MonthlyUsageCounterService, useValue: mockMonthlyUsageCounter },
+      ],
+    }).compile();
+
+    service = module.get<UsageRollupService>(UsageRollupService);
+    mockRedisClient.lrange.mockResolvedValue([]);
+    mockUsageLogRepository.list.mockResolvedValue([]);
+    mockDailyUsageRepository.get.mockResolvedValue(null);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('发布事件只写入 Redis 列表并把预留结算到当月计数器', async () => {
+    const published = {
+      taskId: 't1',
+      userId: 'u1',
+      characters: 10,
+      occurredAt: '2026-10-16T08:00:00.000Z',
+      reserved: 12,
+      reservedAt: '2026-10-16T07:59:00.000Z',
+    };
+    await service.publish(published);
+
+    expect(mockRedisClient.rpush).toHaveBeenCalledWith(USAGE_EVENTS_KEY, expect.stringContaining('"taskId":"t1"'));
+    expect(mockMonthlyUsageCounter.record).toHaveBeenCalledWith(published);
+    expect(mockUsageLogRepository.save).not.toHaveBeenCalled();
+  });
+
+  it('Redis 不可用时应同步记账', async () => {
+    mockRedisClient.rpush.mockRejectedValueOnce(new Error('ECONNREFUSED'));
+
+    await service.publish({ taskId: 't1', userId: 'u1', characters: 10, occurredAt: '2026-10-16T08:00:00.000Z' });
+
+    expect(mockUsageLogRepository.save).toHaveBeenCalled();
+    expect(mockMonthlyUsageCounter.record).toHaveBeenCalled();
+  });
+
+  it('应按用户和日期合并日汇总，并在一次保存中写入', async () => {
+    const existing = { userId: 'u1', usageDate: '2026-10-16', totalCharacters: 100 };
+    mockDailyUsageRepository.get.mockImplementation(async ({ userId }) => (userId === 'u1' ? existing : null));
+    mockRedisClient.eval.mockResolvedValue([event('t1', 'u1', 10), event('t2', 'u1', 20), event('t3', 'u2', 5)]);
+
+    const result = await service.flush();
+
+    expect(result).toEqual({ claimed: 3, events: 3, users: 2, characters: 35 });
+    expect(existing.totalCharacters).toBe(130);
+    const [saved] = mockUsageLogRepository.save.mock.calls[0];
+    expect(saved).toHaveLength(5);
+    expect(mockQuotaService.recordOverage).toHaveBeenCalledWith('u1', 30);
+    expect(mockQuotaService.recordOverage).toHaveBeenCalledWith('u2', 5);
+    expect(mockRedisService.del).toHaveBeenCalledWith(USAGE_EVENTS_PROCESSING_KEY);
+  });
+
+  it('应先重放上次中断的批次，并跳过已记账的任务', async () => {
+    mockRedisClient.lrange.mockResolvedValue([event('t1', 'u1', 10), event('t2', 'u1', 20)]);
+    mockUsageLogRepository.list.mockResolvedValue([{ jsonId: 't1' }]);
+
+    const result = await service.flush();
+
+    expect(mockRedisClient.eval).not.toHaveBeenCalled();
+    expect(result.claimed).toBe(2);
+    expect(result.events).toBe(1);
+    expect(mockQuotaService.recordOverage).toHaveBeenCalledWith('u1', 20);
+  });
+
+  it('写库失败时应保留处理中的批次', async () => {
+    mockRedisClient.eval.mockResolvedValue([event('t1', 'u1', 10)]);
+    mockUsageLogRepository.save.mockRejectedValueOnce(new Error('db down'));
+
+    await expect(service.flush()).rejects.toThrow('db down');
+    expect(mockRedisService.del).not.toHaveBeenCalled();
+  });
+
+  it('回填历史用量时不计超额也不发额度提醒', async () => {
+    const result = await service.backfill([
+      { taskId: 't1', userId: 'u1', characters: 10, occurredAt: '2026-09-01T08:00:00.000Z' },
+    ]);
+
+    expect(result.events).toBe(1);
+    expect(mockUsageLogRepository.save).toHaveBeenCalled();
+    expect(mockMonthlyUsageCounter.record).toHaveBeenCalledWith(
+      expect.objectContaining({ taskId: 't1', characters: 10, occurredAt: '2026-09-01T08:00:00.000Z' }),
+    );
+    expect(mockQuotaService.recordOverage).not.toHaveBeenCalled();
+    expect(mockQuotaWarningService.checkUsage).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,200 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { v4 as uuidv4 } from 'uuid';
+import { RedisService } from '../../../common/services/redis.service';
+import { ErrorReporterService } from '../../../common/services/error-reporter.service';
+import {
+  CharacterUsageLogRepository,
+  CharacterUsageLogDailyRepository,
+} from '../repositories/character-usage.repository';
+import { CharacterUsageLogDaily } from '../entities/translation-task.entity';
+import {
+  CharacterUsageEvent,
+  USAGE_EVENTS_KEY,
+  USAGE_EVENTS_PROCESSING_KEY,
+} from '../interfaces/character-usage-event.interface';
+import { QuotaService } from './quota.service';
+import { QuotaWarningService } from './quota-warning.service';
+import { MonthlyUsageCounterService } from './monthly-usage-counter.service';
+
+export { USAGE_EVENTS_KEY, USAGE_EVENTS_PROCESSING_KEY };
+
+/**
+ * 原子地把一批事件从待处理列表移到处理中列表，处理完成后再删除；
+ * 进程在中途退出时，下一次汇总会先重放处理中列表
+ */
+const CLAIM_BATCH_SCRIPT = `
+local items = redis.call('LRANGE', KEYS[1], 0, tonumber(ARGV[1]) - 1)
+if #items > 0 then
+  redis.call('LTRIM', KEYS[1], #items, -1)
+  redis.call('RPUSH', KEYS[2], unpack(items))
+end
+return items
+`;
+
+export interface UsageRollupResult {
+  /** 本次从队列取出的事件数（含重放和格式错误的事件） */
+  claimed: number;
+  /** 实际记账的事件数 */
+  events: number;
+  users: number;
+  characters: number;
+}
+
+/**
+ * 字符用量汇总
+ * 翻译任务完成时只把用量事件写入 Redis 列表，不再同步写库；
+ * worker 定时批量取出事件，一次 flush 写入明细并按用户、日期合并累加日汇总，
+ * 之后再记录超额用量和额度提醒。用量记账变慢或失败不会拖慢结果推送
+ */
+@Injectable()
+export class UsageRollupService {
+  private readonly logger = new Logger(UsageRollupService.name);
+  private readonly batchSize: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly redisService: RedisService,
+    private readonly usageLogRepository: CharacterUsageLogRepository,
+    private readonly dailyUsageRepository: CharacterUsageLogDailyRepository,
+    private readonly quotaService: QuotaService,
+    private readonly quotaWarningService: QuotaWarningService,
+    private readonly errorReporter: ErrorReporterService,
+    private readonly monthlyUsageCounter: MonthlyUsageCounterService,
+  ) {
+    this.batchSize = Math.max(Number(this.configService.get('USAGE_ROLLUP_BATCH_SIZE', 500)), 1);
+  }
+
+  /**
+   * 发布用量事件并把任务的预留结算为实际用量，额度检查立即可见；Redis 不可用时退回到同步写库，保证用量不丢失
+   */
+  async publish(event: CharacterUsageEvent): Promise<void> {
+    try {
+      await this.redisService.client.rpush(USAGE_EVENTS_KEY, JSON.stringify(event));
+    } catch (error) {
+      this.logger.warn(`Publishing usage event for task ${event.taskId} failed, recording synchronously: ${error.message}`);
+      await this.apply([event]);
+    }
+    await this.monthlyUsageCounter.record(event);
+  }
+
+  /**
+   * 汇总一批事件，先处理上次中断遗留的批次
+   */
+  async flush(): Promise<UsageRollupResult> {
+    const client = this.redisService.client;
+    let raw = await client.lrange(USAGE_EVENTS_PROCESSING_KEY, 0, -1);
+    if (raw.length === 0) {
+      raw = (await client.eval(
+        CLAIM_BATCH_SCRIPT,
+        2,
+        USAGE_EVENTS_KEY,
+        USAGE_EVENTS_PROCESSING_KEY,
+        this.batchSize,
+      )) as string[];
+    }
+    if (raw.length === 0) {
+      return { claimed: 0, events: 0, users: 0, characters: 0 };
+    }
+
+    const events: CharacterUsageEvent[] = [];
+    for (const item of raw) {
+      try {
+        events.push(JSON.parse(item));
+      } catch {
+        this.logger.error(`Dropping malformed usage event: ${item}`);
+      }
+    }
+
+    const result = await this.apply(events);
+    await this.redisService.del(USAGE_EVENTS_PROCESSING_KEY);
+    return { claimed: raw.length, ...result };
+  }
+
+  /**
+   * 直接写入历史用量（迁移导入），已记账的任务跳过，不计超额也不触发额度提醒；
+   * 没有经过发布，写库后补记到当月计数器
+   */
+  async backfill(events: CharacterUsageEvent[]): Promise<Omit<UsageRollupResult, 'claimed'>> {
+    return this.apply(events, false);
+  }
+
+  /**
+   * 写入明细和日汇总（同一次 flush），已有明细的任务视为重放并跳过
+   */
+  private async apply(events: CharacterUsageEvent[], notify = true): Promise<Omit<UsageRollupResult, 'claimed'>> {
+    if (events.length === 0) {
+      return { events: 0, users: 0, characters: 0 };
+    }
+    const taskIds = events.map((event) => event.taskId);
+    const recorded = new Set(
+      (await this.usageLogRepository.list({ jsonId: { $in: taskIds } })).map((log) => log.jsonId),
+    );
+    const fresh = events.filter((event) => !recorded.has(event.taskId));
+    if (fresh.length === 0) {
+      return { events: 0, users: 0, characters: 0 };
+    }
+
+    const logs = fresh.map((event) =>
+      this.usageLogRepository.build({
+        id: uuidv4(),
+        jsonId: event.taskId,
+        userId: event.userId,
+        totalCharacters: event.characters,
+        apiKeyId: event.apiKeyId,
+        tenantId: event.tenantId,
+        createdAt: new Date(event.occurredAt),
+      }),
+    );
+
+    const dailyTotals = new Map<string, { userId: string; usageDate: string; characters: number }>();
+    const userTotals = new Map<string, { characters: number; tenantId?: string }>();
+    for (const event of fresh) {
+      const usageDate = event.occurredAt.slice(0, 10);
+      const dailyKey = `${event.userId}:${usageDate}`;
+      const daily = dailyTotals.get(dailyKey) ?? { userId: event.userId, usageDate, characters: 0 };
+      daily.characters += event.characters;
+      dailyTotals.set(dailyKey, daily);
+
+      const user = userTotals.get(event.userId) ?? { characters: 0, tenantId: event.tenantId };
+      user.characters += event.characters;
+      userTotals.set(event.userId, user);
+    }
+
+    const dailies: CharacterUsageLogDaily[] = [];
+    for (const { userId, usageDate, characters } of dailyTotals.values()) {
+      const daily = await this.dailyUsageRepository.get({ userId, usageDate });
+      if (daily) {
+        daily.totalCharacters += characters;
+        dailies.push(daily);
+      } else {
+        dailies.push(this.dailyUsageRepository.build({ id: uuidv4(), userId, usageDate, totalCharacters: characters }));
+      }
+    }
+    await this.usageLogRepository.save([...logs, ...dailies]);
+
+    if (notify) {
+      for (const [userId, { characters, tenantId }] of userTotals) {
+        await this.quotaService
+          .recordOverage(userId, characters)
+          .catch((error) => this.report(error, userId, 'overage'));
+        await this.quotaWarningService
+          .checkUsage(userId, tenantId)
+          .catch((error) => this.logger.error(`Quota warning failed: ${error.message}`));
+      }
+    } else {
+      for (const event of fresh) {
+        await this.monthlyUsageCounter.record(event);
+      }
+    }
+
+    const characters = fresh.reduce((sum, event) => sum + event.characters, 0);
+    this.logger.log(`Rolled up ${fresh.length} usage event(s), ${characters} characters for ${userTotals.size} user(s)`);
+    return { events: fresh.length, users: userTotals.size, characters };
+  }
+
+  private report(error: Error, userId: string, stage: string): void {
+    this.logger.error(`Usage rollup ${stage} failed for user ${userId}: ${error.message}`);
+    this.errorReporter.captureException(error, { source: 'worker', userId, tags: { stage: `usage_rollup_${stage}` } });
+  }
+}
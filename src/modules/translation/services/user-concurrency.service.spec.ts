@@ -0,0 +1,56 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { UserConcurrencyService } from './user-concurrency.service';
+import { RedisService } from '../../../common/services/redis.service';
+
+describe('UserConcurrencyService', () => {
+  let service: UserConcurrencyService;
+
+  const mockRedisService = {
+    client: { eval: jest.fn(), zrem: jest.fn() },
+  };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        UserConcurrencyService,
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, defaultValue?: any) => defaultValue) } },
+        { provide: RedisService, useValue: mockRedisService },
+      ],
+    }).compile();
+
+    service = module.get<UserConcurrencyService>(UserConcurrencyService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('取得名额后释放时应删除对应的令牌', async () => {
+    mockRedisService.client.eval.mockResolvedValue(1);
+
+    const slot = await service.acquire('u1');
+    await slot.release();
+
+    expect(slot.acquired).toBe(true);
+    const [, , key, limit, , token] = mockRedisService.client.eval.mock.calls[0];
+    expect([key, limit]).toEqual(['translation_inflight:u1', 3]);
+    expect(mockRedisService.client.zrem).toHaveBeenCalledWith('translation_inflight:u1', token);
+  });
+
+  it('名额已满时返回稍后重试的延迟', async () => {
+    mockRedisService.client.eval.mockResolvedValue(0);
+
+    const slot = await service.acquire('u1');
+
+    expect(slot.acquired).toBe(false);
+    expect(slot.retryAfterMs).toBeGreaterThanOrEqual(2000);
+    expect(slot.retryAfterMs).toBeLessThan(4000);
+  });
+
+  it('Redis 不可用时不限制', async () => {
+    mockRedisService.client.eval.mockRejectedValue(new Error('ECONNREFUSED'));
+
+    await expect(service.acquire('u1')).resolves.toMatchObject({ acquired: true });
+  });
+});
@@ -0,0 +1,92 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { v4 as uuidv4 } from 'uuid';
+import { RedisService } from '../../../common/services/redis.service';
+
+/**
+ * 计数信号量：有序集合的成员是名额令牌，分数是按 Redis 服务器时间计算的过期时间
+ * 先清理过期的令牌，名额未满时加入新令牌；worker 崩溃没有释放的名额在过期后自动回收
+ */
+const ACQUIRE_SLOT_SCRIPT = `
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[1]) then
+  return 0
+end
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[2]), ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`;
+
+export interface UserTaskSlot {
+  acquired: boolean;
+  /** 未取得时建议的延迟（毫秒） */
+  retryAfterMs?: number;
+  release(): Promise<void>;
+}
+
+const noop = async () => undefined;
+
+/**
+ * 按用户限制同时执行的翻译任务数
+ * 一个用户一次提交的大批文档不会占满所有 worker：超过上限的任务放回队列，在同一优先级中排到其他用户的任务之后，
+ * 空出的 worker 先处理其他用户的任务。名额存在 Redis 中，所有 worker 共享；Redis 不可用时不限制
+ */
+@Injectable()
+export class UserConcurrencyService {
+  private readonly logger = new Logger(UserConcurrencyService.name);
+  private readonly maxConcurrent: number;
+  private readonly retryDelayMs: number;
+  private readonly slotTtlMs: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly redisService: RedisService,
+  ) {
+    this.maxConcurrent = Number(this.configService.get('TRANSLATION_MAX_CONCURRENT_PER_USER', 3));
+    this.retryDelayMs = Number(this.configService.get('TRANSLATION_CONCURRENCY_RETRY_MS', 2000));
+    this.slotTtlMs = Number(this.configService.get('TRANSLATION_CONCURRENCY_SLOT_TTL_MS', 30 * 60 * 1000));
+  }
+
+  /**
+   * 为用户占用一个执行名额；TRANSLATION_MAX_CONCURRENT_PER_USER=0 时不限制
+   */
+  async acquire(userId: string): Promise<UserTaskSlot> {
+    if (!(this.maxConcurrent > 0)) {
+      return { acquired: true, release: noop };
+    }
+
+    const key = `translation_inflight:${userId}`;
+    const token = uuidv4();
+    try {
+      const acquired = await this.redisService.client.eval(
+        ACQUIRE_SLOT_SCRIPT,
+        1,
+        key,
+        this.maxConcurrent,
+        this.slotTtlMs,
+        token,
+      );
+      if (Number(acquired) !== 1) {
+        // 随机抖动，避免同一用户的大批任务同时到期、再次一起抢名额
+        const jitter = Math.floor(Math.random() * this.retryDelayMs);
+        return { acquired: false, retryAfterMs: this.retryDelayMs + jitter, release: noop };
+      }
+    } catch (error) {
+      this.logger.error(`Per-user concurrency limiter unavailable: ${error.message}`);
+      return { acquired: true, release: noop };
+    }
+
+    return {
+      acquired: true,
+      release: async () => {
+        try {
+          await this.redisService.client.zrem(key, token);
+        } catch (error) {
+          this.logger.error(`Failed to release translation slot of user ${userId}: ${error.message}`);
+        }
+      },
+    };
+  }
+}
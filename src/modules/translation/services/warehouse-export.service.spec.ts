@@ -0,0 +1,107 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { gunzipSync } from 'zlib';
+import { WarehouseDataset, WarehouseExportService } from './warehouse-export.service';
+import { CharacterUsageLogRepository } from '../repositories/character-usage.repository';
+import { SendRetryRepository } from '../../webhook/repositories/send-retry.repository';
+import { ObjectStorageService } from '../../../common/services/object-storage.service';
+import { RedisService } from '../../../common/services/redis.service';
+import { parseCsv } from '../../../common/utils/csv';
+
+describe('WarehouseExportService', () => {
+  let service: WarehouseExportService;
+
+  const config: Record<string, string> = {
+    WAREHOUSE_EXPORT_ENABLED: 'true',
+    WAREHOUSE_EXPORT_DATASETS: 'webhook_deliveries',
+    WAREHOUSE_EXPORT_ROWS_PER_FILE: '2',
+    WAREHOUSE_EXPORT_MAX_CATCHUP_DAYS: '3',
+  };
+  const mockObjectStorage = { put: jest.fn() };
+  const mockRedisService = { client: { get: jest.fn(), set: jest.fn() } };
+  const mockUsageLogRepository = { list: jest.fn() };
+  const mockSendRetryRepository = { list: jest.fn() };
+
+  const delivery = (id: string) => ({
+    id,
+    webhookId: 'w1',
+    taskId: 't1',
+    attempt: 1,
+    status: 'success',
+    payload: '{"translatedJson":"secret"}',
+    createdAt: new Date('2026-10-15T08:00:00Z'),
+  });
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        WarehouseExportService,
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, def?: any) => config[key] ?? def) } },
+        { provide: ObjectStorageService, useValue: mockObjectStorage },
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: CharacterUsageLogRepository, useValue: mockUsageLogRepository },
+        { provide: SendRetryRepository, useValue: mockSendRetryRepository },
+      ],
+    }).compile();
+
+    service = module.get<WarehouseExportService>(WarehouseExportService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  describe('exportDay', () => {
+    it('应按日期分区分批写入压缩的 CSV 和清单，不导出推送载荷', async () => {
+      mockSendRetryRepository.list
+        .mockResolvedValueOnce([delivery('d1'), delivery('d2')])
+        .mockResolvedValueOnce([delivery('d3')]);
+
+      const result = await service.exportDay(WarehouseDataset.WEBHOOK_DELIVERIES, '2026-10-15');
+
+      expect(result).toEqual({
+        dataset: 'webhook_deliveries',
+        date: '2026-10-15',
+        rows: 3,
+        files: ['part-00000.csv.gz', 'part-00001.csv.gz'],
+      });
+      expect(mockSendRetryRepository.list).toHaveBeenCalledWith(
+        { createdAt: { $gte: new Date('2026-10-15T00:00:00Z'), $lt: new Date('2026-10-16T00:00:00Z') } },
+        expect.objectContaining({ offset: 2, limit: 2 }),
+      );
+      const [key, body] = mockObjectStorage.put.mock.calls[0];
+      expect(key).toBe('warehouse/webhook_deliveries/dt=2026-10-15/part-00000.csv.gz');
+      const csv = gunzipSync(body).toString();
+      expect(parseCsv(csv)[0]).toEqual({
+        id: 'd1',
+        webhook_id: 'w1',
+        task_id: 't1',
+        attempt: '1',
+        status: 'success',
+        created_at: '2026-10-15T08:00:00.000Z',
+      });
+      expect(csv).not.toContain('secret');
+      expect(mockObjectStorage.put).toHaveBeenLastCalledWith(
+        'warehouse/webhook_deliveries/dt=2026-10-15/_manifest.json',
+        expect.any(Buffer),
+        'application/json',
+      );
+    });
+  });
+
+  describe('exportPending', () => {
+    it('应从上次导出的日期之后补导到昨天，最多补导配置的天数', async () => {
+      mockRedisService.client.get.mockResolvedValue('2026-10-01');
+      mockSendRetryRepository.list.mockResolvedValue([]);
+
+      const results = await service.exportPending(new Date('2026-10-16T01:00:00Z'));
+
+      expect(results.map((result) => result.date)).toEqual(['2026-10-13', '2026-10-14', '2026-10-15']);
+      expect(mockRedisService.client.set).toHaveBeenLastCalledWith(
+        'warehouse_export:last:webhook_deliveries',
+        '2026-10-15',
+      );
+      expect(mockUsageLogRepository.list).not.toHaveBeenCalled();
+    });
+  });
+});
@@ -0,0 +1,183 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { promisify } from 'util';
+import { gzip } from 'zlib';
+import { CharacterUsageLogRepository } from '../repositories/character-usage.repository';
+import { SendRetryRepository } from '../../webhook/repositories/send-retry.repository';
+import { ObjectStorageService } from '../../../common/services/object-storage.service';
+import { RedisService } from '../../../common/services/redis.service';
+import { formatCsv } from '../../../common/utils/csv';
+
+const gzipAsync = promisify(gzip);
+
+export type WarehouseExportFormat = 'csv' | 'jsonl';
+
+export enum WarehouseDataset {
+  WEBHOOK_DELIVERIES = 'webhook_deliveries',
+  USAGE_EVENTS = 'usage_events',
+}
+
+interface DatasetSource {
+  columns: string[];
+  /** 读取 [from, to) 内的一页记录，按创建时间和 ID 排序保证分页稳定 */
+  fetch(from: Date, to: Date, offset: number, limit: number): Promise<Record<string, unknown>[]>;
+}
+
+export interface WarehouseExportResult {
+  dataset: WarehouseDataset;
+  date: string;
+  rows: number;
+  files: string[];
+}
+
+const exportedKey = (dataset: WarehouseDataset) => `warehouse_export:last:${dataset}`;
+
+/** 日期（UTC，YYYY-MM-DD）加减天数 */
+function shiftDate(date: string, days: number): string {
+  const value = new Date(`${date}T00:00:00Z`);
+  value.setUTCDate(value.getUTCDate() + days);
+  return value.toISOString().slice(0, 10);
+}
+
+/**
+ * 数据仓库批量导出
+ * worker 每天把前一天（UTC）的 webhook 推送记录和字符用量事件按数据集、日期分区写入对象存储：
+ * warehouse/{dataset}/dt=YYYY-MM-DD/part-00000.csv.gz，另附 _manifest.json（行数、列、文件列表）。
+ * Hive 风格的分区路径可以直接被 BigQuery / Athena / Snowflake 等外部表或定时加载任务读取，分析团队不必再调用 REST API。
+ * 每个数据集记录已导出到的日期，worker 停机后按天补导（最多 WAREHOUSE_EXPORT_MAX_CATCHUP_DAYS 天）；
+ * 重新导出同一天会覆盖清单和同名文件，下游应以清单中列出的文件为准
+ */
+@Injectable()
+export class WarehouseExportService {
+  private readonly logger = new Logger(WarehouseExportService.name);
+  readonly enabled: boolean;
+  private readonly format: WarehouseExportFormat;
+  private readonly datasets: WarehouseDataset[];
+  private readonly rowsPerFile: number;
+  private readonly maxCatchupDays: number;
+  private readonly prefix: string;
+  private readonly sources: Record<WarehouseDataset, DatasetSource>;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly objectStorage: ObjectStorageService,
+    private readonly redisService: RedisService,
+    private readonly usageLogRepository: CharacterUsageLogRepository,
+    private readonly sendRetryRepository: SendRetryRepository,
+  ) {
+    this.enabled = this.configService.get('WAREHOUSE_EXPORT_ENABLED', 'false') === 'true';
+    this.format = this.configService.get('WAREHOUSE_EXPORT_FORMAT', 'csv') === 'jsonl' ? 'jsonl' : 'csv';
+    const allDatasets = Object.values(WarehouseDataset);
+    this.datasets = String(this.configService.get('WAREHOUSE_EXPORT_DATASETS', allDatasets.join(',')))
+      .split(',')
+      .map((name) => name.trim())
+      .filter((name): name is WarehouseDataset => allDatasets.includes(name as WarehouseDataset));
+    this.rowsPerFile = Number(this.configService.get('WAREHOUSE_EXPORT_ROWS_PER_FILE', 50000));
+    this.maxCatchupDays = Number(this.configService.get('WAREHOUSE_EXPORT_MAX_CATCHUP_DAYS', 7));
+    this.prefix = this.configService.get('WAREHOUSE_EXPORT_PREFIX', 'warehouse/');
+
+    const window = (from: Date, to: Date) => ({ createdAt: { $gte: from, $lt: to } });
+    const page = (offset: number, limit: number) => ({
+      offset,
+      limit,
+      orderBy: { createdAt: 'asc', id: 'asc' } as const,
+    });
+    this.sources = {
+      // 推送载荷可能包含译文，不导出
+      [WarehouseDataset.WEBHOOK_DELIVERIES]: {
+        columns: ['id', 'webhook_id', 'task_id', 'attempt', 'status', 'created_at'],
+        fetch: async (from, to, offset, limit) =>
+          (await this.sendRetryRepository.list(window(from, to), page(offset, limit))).map((row) => ({
+            id: row.id,
+            webhook_id: row.webhookId,
+            task_id: row.taskId,
+            attempt: row.attempt,
+            status: row.status,
+            created_at: row.createdAt,
+          })),
+      },
+      [WarehouseDataset.USAGE_EVENTS]: {
+        columns: ['id', 'user_id', 'document_id', 'api_key_id', 'tenant_id', 'characters', 'created_at'],
+        fetch: async (from, to, offset, limit) =>
+          (await this.usageLogRepository.list(window(from, to), page(offset, limit))).map((row) => ({
+            id: row.id,
+            user_id: row.userId,
+            document_id: row.jsonId,
+            api_key_id: row.apiKeyId,
+            tenant_id: row.tenantId,
+            characters: row.totalCharacters,
+            created_at: row.createdAt,
+          })),
+      },
+    };
+  }
+
+  /**
+   * 导出所有启用的数据集中尚未导出的完整日期（截至昨天）
+   */
+  async exportPending(now = new Date()): Promise<WarehouseExportResult[]> {
+    if (!this.enabled) {
+      return [];
+    }
+    const yesterday = shiftDate(now.toISOString().slice(0, 10), -1);
+    const earliest = shiftDate(yesterday, -(this.maxCatchupDays - 1));
+    const results: WarehouseExportResult[] = [];
+
+    for (const dataset of this.datasets) {
+      const last = await this.redisService.client.get(exportedKey(dataset));
+      let date = last ? shiftDate(last, 1) : yesterday;
+      if (date < earliest) {
+        this.logger.warn(`Warehouse export of ${dataset} stopped at ${last}, skipping ahead to ${earliest}`);
+        date = earliest;
+      }
+      for (; date <= yesterday; date = shiftDate(date, 1)) {
+        results.push(await this.exportDay(dataset, date));
+        await this.redisService.client.set(exportedKey(dataset), date);
+      }
+    }
+    return results;
+  }
+
+  /**
+   * 导出一个数据集一天（UTC）的数据；没有数据时只写清单，下游据此区分“没有数据”和“尚未导出”
+   */
+  async exportDay(dataset: WarehouseDataset, date: string): Promise<WarehouseExportResult> {
+    const source = this.sources[dataset];
+    const from = new Date(`${date}T00:00:00Z`);
+    const to = new Date(`${shiftDate(date, 1)}T00:00:00Z`);
+    const partition = `${this.prefix}${dataset}/dt=${date}/`;
+    const files: string[] = [];
+    let rows = 0;
+
+    for (let part = 0; ; part++) {
+      const batch = await source.fetch(from, to, part * this.rowsPerFile, this.rowsPerFile);
+      if (batch.length === 0) {
+        break;
+      }
+      const file = `part-${String(part).padStart(5, '0')}.${this.format}.gz`;
+      const body = await gzipAsync(this.serialize(source, batch));
+      await this.objectStorage.put(`${partition}${file}`, body, 'application/gzip');
+      files.push(file);
+      rows += batch.length;
+      if (batch.length < this.rowsPerFile) {
+        break;
+      }
+    }
+
+    const manifest = { dataset, date, format: this.format, compression: 'gzip', columns: source.columns, rows, files };
+    await this.objectStorage.put(
+      `${partition}_manifest.json`,
+      Buffer.from(JSON.stringify(manifest, null, 2)),
+      'application/json',
+    );
+    this.logger.log(`Exported ${rows} ${dataset} row(s) for ${date} in ${files.length} file(s)`);
+    return { dataset, date, rows, files };
+  }
+
+  private serialize(source: DatasetSource, rows: Record<string, unknown>[]): Buffer {
+    if (this.format === 'jsonl') {
+      return Buffer.from(rows.map((row) => JSON.stringify(row)).join('\n').concat('\n'));
+    }
+    return Buffer.from(formatCsv(source.columns, rows));
+  }
+}
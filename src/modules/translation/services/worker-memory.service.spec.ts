@@ -0,0 +1,48 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { WorkerMemoryService } from './worker-memory.service';
+
+const MB = 1024 * 1024;
+
+describe('WorkerMemoryService', () => {
+  let service: WorkerMemoryService;
+  const config: Record<string, any> = { WORKER_MEMORY_BUDGET_MB: 100, WORKER_TASK_MAX_MEMORY_MB: 500 };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        WorkerMemoryService,
+        {
+          provide: ConfigService,
+          useValue: { get: jest.fn((key: string, defaultValue?: any) => config[key] ?? defaultValue) },
+        },
+      ],
+    }).compile();
+
+    service = module.get<WorkerMemoryService>(WorkerMemoryService);
+  });
+
+  it('按文档大小乘以系数估算，预算不足时拒绝并在释放后重新接受', () => {
+    const first = service.reserve(6 * MB);
+    expect(first).toMatchObject({ admitted: true, estimatedBytes: 60 * MB });
+
+    const second = service.reserve(5 * MB);
+    expect(second).toMatchObject({ admitted: false, rejection: 'busy' });
+    expect(second.retryAfterMs).toBeGreaterThanOrEqual(5000);
+
+    first.release();
+    first.release();
+    expect(service.reserve(5 * MB).admitted).toBe(true);
+  });
+
+  it('超过整个预算的任务只在空闲时单独执行，超过单个任务上限时直接拒绝', () => {
+    const large = service.reserve(20 * MB);
+    expect(large.admitted).toBe(true);
+    expect(service.reserve(1024).rejection).toBe('busy');
+    large.release();
+
+    const tooLarge = service.reserve(60 * MB);
+    expect(tooLarge).toMatchObject({ admitted: false, rejection: 'too_large' });
+    expect(service.tooLargeMessage(tooLarge.estimatedBytes)).toContain('above the per-task limit of 500 MB');
+  });
+});
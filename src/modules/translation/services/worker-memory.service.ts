@@ -0,0 +1,95 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+
+const MB = 1024 * 1024;
+
+export interface MemoryReservation {
+  admitted: boolean;
+  /** too_large：超过单个任务的上限，不会执行；busy：当前进程内存预算不足，稍后再试 */
+  rejection?: 'too_large' | 'busy';
+  /** 预计占用的内存（字节） */
+  estimatedBytes: number;
+  /** 未执行时建议的延迟（毫秒） */
+  retryAfterMs?: number;
+  release(): void;
+}
+
+/**
+ * worker 进程的内存准入控制
+ * 一个任务解析原文、生成译文和中间结构需要的内存按文档大小乘以系数估算；同一进程中并发执行的任务
+ * 估算值之和不超过 WORKER_MEMORY_BUDGET_MB，超出时任务放回队列稍后再试，避免同时处理几个超大文档导致 OOM。
+ * 估算值超过整个预算的任务只在进程空闲时单独执行；超过 WORKER_TASK_MAX_MEMORY_MB 的任务直接失败。
+ * 预算只统计本进程，多个 worker 进程各自计算
+ */
+@Injectable()
+export class WorkerMemoryService {
+  private readonly logger = new Logger(WorkerMemoryService.name);
+  private readonly budgetBytes: number;
+  private readonly maxTaskBytes: number;
+  private readonly factor: number;
+  private readonly retryDelayMs: number;
+  private reservedBytes = 0;
+  private running = 0;
+
+  constructor(private readonly configService: ConfigService) {
+    this.budgetBytes = Number(this.configService.get('WORKER_MEMORY_BUDGET_MB', 1024)) * MB;
+    this.maxTaskBytes = Number(this.configService.get('WORKER_TASK_MAX_MEMORY_MB', 0)) * MB;
+    this.factor = Number(this.configService.get('WORKER_TASK_MEMORY_FACTOR', 10));
+    this.retryDelayMs = Number(this.configService.get('WORKER_MEMORY_RETRY_MS', 5000));
+  }
+
+  estimate(documentBytes: number): number {
+    return Math.ceil(documentBytes * this.factor);
+  }
+
+  /**
+   * 为任务预留内存；WORKER_MEMORY_BUDGET_MB=0 时不限制
+   */
+  reserve(documentBytes: number): MemoryReservation {
+    const estimatedBytes = this.estimate(documentBytes);
+    if (!(this.budgetBytes > 0)) {
+      return { admitted: true, estimatedBytes, release: () => undefined };
+    }
+    if (this.maxTaskBytes > 0 && estimatedBytes > this.maxTaskBytes) {
+      return { admitted: false, rejection: 'too_large', estimatedBytes, release: () => undefined };
+    }
+    // 超过整个预算的任务在没有其他任务执行时也允许，保证它最终能执行
+    if (this.running > 0 && this.reservedBytes + estimatedBytes > this.budgetBytes) {
+      return {
+        admitted: false,
+        rejection: 'busy',
+        estimatedBytes,
+        retryAfterMs: this.retryDelayMs + Math.floor(Math.random() * this.retryDelayMs),
+        release: () => undefined,
+      };
+    }
+
+    this.reservedBytes += estimatedBytes;
+    this.running += 1;
+    if (estimatedBytes > this.budgetBytes) {
+      this.logger.warn(`Running a task estimated at ${Math.round(estimatedBytes / MB)} MB alone (over the budget)`);
+    }
+
+    let released = false;
+    return {
+      admitted: true,
+      estimatedBytes,
+      release: () => {
+        if (released) {
+          return;
+        }
+        released = true;
+        this.reservedBytes -= estimatedBytes;
+        this.running -= 1;
+      },
+    };
+  }
+
+  /** 超过单个任务上限时的错误消息 */
+  tooLargeMessage(estimatedBytes: number): string {
+    return (
+      `Document needs an estimated ${Math.ceil(estimatedBytes / MB)} MB of worker memory, ` +
+      `above the per-task limit of ${Math.round(this.maxTaskBytes / MB)} MB; split it into smaller documents`
+    );
+  }
+}
@@ -0,0 +1,56 @@
+import { Body, Controller, Delete, Get, HttpCode, HttpStatus, Param, Post, Req, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiResponse, ApiSecurity, ApiTags } from '@nestjs/swagger';
+import { JwtOrApiKeyGuard } from '../auth/guards/jwt-or-api-key.guard';
+import { TenantService } from '../tenant/services/tenant.service';
+import { SourceSyncService } from './services/source-sync.service';
+import { CreateSourceSyncDto } from './dto/source-sync.dto';
+
+@ApiTags('translation')
+@Controller('translation/sources')
+@ApiBearerAuth()
+@ApiSecurity('api-key')
+@UseGuards(JwtOrApiKeyGuard)
+export class SourceSyncController {
+  constructor(
+    private readonly sourceSyncService: SourceSyncService,
+    private readonly tenantService: TenantService,
+  ) {}
+
+  @Post()
+  @ApiOperation({ summary: '登记远程 JSON 源，按计划拉取、比对并翻译变化的键' })
+  @ApiResponse({ status: 201, description: '已登记，默认立即同步一次' })
+  @ApiResponse({ status: 400, description: 'cron 表达式无效、地址不可访问或超出数量限制' })
+  async create(@Req() req: any, @Body() dto: CreateSourceSyncDto) {
+    const tenant = await this.tenantService.resolveFromRequest(req);
+    return this.sourceSyncService.create(req.user.id, dto, tenant?.id);
+  }
+
+  @Get()
+  @ApiOperation({ summary: '获取远程 JSON 源列表' })
+  async list(@Req() req: any) {
+    return this.sourceSyncService.list(req.user.id);
+  }
+
+  @Get(':id')
+  @ApiOperation({ summary: '获取远程 JSON 源详情（含最近一次同步状态）' })
+  @ApiResponse({ status: 404, description: '不存在' })
+  async get(@Req() req: any, @Param('id') id: string) {
+    return this.sourceSyncService.get(req.user.id, id);
+  }
+
+  @Post(':id/run')
+  @HttpCode(HttpStatus.ACCEPTED)
+  @ApiOperation({ summary: '立即同步一次' })
+  @ApiResponse({ status: 202, description: '已加入队列' })
+  async run(@Req() req: any, @Param('id') id: string) {
+    await this.sourceSyncService.trigger(req.user.id, id);
+    return { queued: true };
+  }
+
+  @Delete(':id')
+  @HttpCode(HttpStatus.NO_CONTENT)
+  @ApiOperation({ summary: '删除远程 JSON 源并停止同步' })
+  async remove(@Req() req: any, @Param('id') id: string) {
+    await this.sourceSyncService.remove(req.user.id, id);
+  }
+}
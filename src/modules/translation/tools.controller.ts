@@ -0,0 +1,52 @@
+import { Body, Controller, HttpCode, HttpStatus, Post, Res, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiResponse, ApiSecurity, ApiTags } from '@nestjs/swagger';
+import { Response } from 'express';
+import { JwtOrApiKeyGuard } from '../auth/guards/jwt-or-api-key.guard';
+import { ReadOnlySafe } from '../../common/decorators/read-only-safe.decorator';
+import { LocaleBundleService } from './services/locale-bundle.service';
+import { LocaleBundleDto, LocaleBundleFormat } from './dto/locale-bundle.dto';
+import { FieldSuggestionService } from './services/field-suggestion.service';
+import { FieldSuggestionRequestDto } from './dto/field-suggestion.dto';
+
+/**
+ * 不消耗翻译额度的 JSON 工具
+ */
+@ApiTags('tools')
+@Controller('tools')
+@ApiBearerAuth()
+export class ToolsController {
+  constructor(
+    private readonly localeBundleService: LocaleBundleService,
+    private readonly fieldSuggestionService: FieldSuggestionService,
+  ) {}
+
+  @Post('merge')
+  @ReadOnlySafe()
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '合并源文档和多份译文为多语言包（JSON 或每个语言一个文件的 ZIP）' })
+  @ApiResponse({ status: 200, description: 'nested 返回 bundle 和各语言缺失 / 多出的键；zip 返回 application/zip' })
+  @ApiResponse({ status: 400, description: 'JSON 无效或语言重复' })
+  async merge(@Body() dto: LocaleBundleDto, @Res() res: Response) {
+    const result = this.localeBundleService.merge(dto);
+    if (dto.format === LocaleBundleFormat.ZIP) {
+      res.setHeader('Content-Type', 'application/zip');
+      res.setHeader('Content-Disposition', 'attachment; filename="locales.zip"');
+      res.status(HttpStatus.OK).send(this.localeBundleService.toZip(result.bundle));
+      return;
+    }
+    res.status(HttpStatus.OK).json(result);
+  }
+
+  @Post('analyze')
+  @ReadOnlySafe()
+  @HttpCode(HttpStatus.OK)
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '分析样例文档，建议不需要翻译的字段（ID、链接、枚举、日期、slug 等）及置信度' })
+  @ApiResponse({ status: 200, description: 'suggestions 按置信度从高到低排列；profile.paths 可直接用于创建忽略配置' })
+  @ApiResponse({ status: 400, description: 'JSON 无效' })
+  analyze(@Body() dto: FieldSuggestionRequestDto) {
+    return this.fieldSuggestionService.analyze(dto);
+  }
+}
@@ -1,40 +1,408 @@
-import { Controller, Post, Body, Get, Param, UseGuards, Req } from '@nestjs/common';
+import {
+  Controller,
+  Post,
+  Patch,
+  Body,
+  Get,
+  Delete,
+  Param,
+  UseGuards,
+  Req,
+  Res,
+  HttpCode,
+  HttpStatus,
+  Query,
+  Headers,
+  DefaultValuePipe,
+  ParseIntPipe,
+  ParseEnumPipe,
+  ParseBoolPipe,
+} from '@nestjs/common';
+import { Response } from 'express';
 import { TranslationService } from './translation.service';
-import { ApiTags, ApiOperation, ApiResponse, ApiBearerAuth } from '@nestjs/swagger';
-import { JwtAuthGuard } from '../auth/guards/jwt-auth.guard';
-import { TranslationTaskPayload } from './dto/translation-task.dto';
+import { ApiTags, ApiOperation, ApiResponse, ApiBearerAuth, ApiSecurity, ApiQuery } from '@nestjs/swagger';
+import { JwtOrApiKeyGuard } from '../auth/guards/jwt-or-api-key.guard';
+import {
+  TranslationPayload,
+  TranslationEstimate,
+  ScheduledTranslation,
+  DocumentMetadataDto,
+} from './dto/translation-task.dto';
+import { UpdateTranslationKeysDto } from './dto/translation-keys.dto';
+import { DocumentLockDto } from './dto/document-lock.dto';
+import { ReviewTransitionDto } from './dto/translation-review.dto';
+import { ReviewStatus } from './entities/translation-task.entity';
+import { OutputKeyFormat } from './utils/output-keys';
+import { DownloadFormat } from './utils/translation-download';
+import { DocumentSortField, SortDirection } from './utils/list-cursor';
+import { TenantService } from '../tenant/services/tenant.service';
+import { StorageLimitService } from './services/storage-limit.service';
+import { TranslationReviewService } from './services/translation-review.service';
+import { ReadOnlySafe } from '../../common/decorators/read-only-safe.decorator';
+import { PlaygroundAllowed } from '../../common/decorators/playground-allowed.decorator';
 
 @ApiTags('translation')
 @Controller('translation')
 @ApiBearerAuth()
 export class TranslationController {
-  constructor(private readonly translationService: TranslationService) {}
+  constructor(
+    private readonly translationService: TranslationService,
+    private readonly tenantService: TenantService,
+    private readonly storageLimitService: StorageLimitService,
+    private readonly translationReviewService: TranslationReviewService,
+  ) {}
 
   @Post('task')
-  @UseGuards(JwtAuthGuard)
+  @PlaygroundAllowed()
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
   @ApiOperation({ summary: '创建翻译任务' })
   @ApiResponse({ status: 201, description: '成功创建翻译任务' })
+  @ApiResponse({ status: 202, description: '队列拥堵：任务已接受，backpressure 中给出预计开始时间' })
   @ApiResponse({ status: 400, description: '请求参数错误' })
   @ApiResponse({ status: 401, description: '未授权' })
-  async createTranslationTask(@Req() req: any, @Body() payload: TranslationTaskPayload) {
-    return this.translationService.createTranslationTask(req.user.id, payload.taskId);
+  @ApiResponse({ status: 403, description: '已达到计划的文档数或存储空间上限' })
+  @ApiResponse({ status: 409, description: '窗口期内重复提交了相同的文档，documentId 为已有文档（force=true 可跳过）' })
+  @ApiResponse({ status: 429, description: '字符额度已用尽（硬模式），或队列拥堵且计划配置为拒绝' })
+  async createTranslationTask(
+    @Req() req: any,
+    @Body() payload: TranslationPayload,
+    @Res({ passthrough: true }) res: Response,
+  ) {
+    const tenant = await this.tenantService.resolveFromRequest(req);
+    const result = await this.translationService.createTranslationTask(req.user.id, payload, {
+      apiKey: req.apiKey,
+      tenantId: tenant?.id,
+    });
+    if (result.quota.warnings.length > 0) {
+      res.setHeader('X-Quota-Warning', result.quota.warnings.join('; '));
+    }
+    if (result.backpressure) {
+      res.status(HttpStatus.ACCEPTED);
+      res.setHeader('X-Queue-Backpressure', `depth=${result.backpressure.queueDepth}`);
+      res.setHeader('X-Estimated-Start-At', result.backpressure.estimatedStartAt);
+    }
+    return tenant ? { ...result, branding: this.tenantService.getBranding(tenant) } : result;
+  }
+
+  @Post('estimate')
+  @ReadOnlySafe()
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '试算翻译字符数、额度和预计完成时间（不创建任务）' })
+  @ApiResponse({ status: 201, type: TranslationEstimate })
+  @ApiResponse({ status: 400, description: 'JSON 内容无效' })
+  async estimateTranslation(@Req() req: any, @Body() payload: TranslationPayload) {
+    return this.translationService.estimateTranslation(req.user.id, payload, req.apiKey);
+  }
+
+  @Get('scheduled')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '获取定时 / 周期翻译任务' })
+  @ApiResponse({ status: 200, type: [ScheduledTranslation] })
+  async listScheduledTasks(@Req() req: any) {
+    return this.translationService.listScheduledTasks(req.user.id, req.apiKey);
+  }
+
+  @Delete('scheduled/:id')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @HttpCode(HttpStatus.NO_CONTENT)
+  @ApiOperation({ summary: '取消定时 / 周期翻译任务' })
+  @ApiResponse({ status: 204, description: '已取消' })
+  @ApiResponse({ status: 404, description: '定时任务不存在或已执行' })
+  async cancelScheduledTask(@Req() req: any, @Param('id') id: string) {
+    await this.translationService.cancelScheduledTask(req.user.id, id, req.apiKey);
+  }
+
+  @Get('storage')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '获取已保存文档的数量、总字节数及计划上限' })
+  @ApiResponse({ status: 200, description: 'limit / remaining 为 null 表示不限' })
+  async getStorage(@Req() req: any) {
+    return this.storageLimitService.getStatus(req.user.id);
+  }
+
+  @Get('documents')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '获取已保存的翻译文档列表（不含内容）' })
+  @ApiQuery({ name: 'reviewStatus', required: false, enum: ReviewStatus, description: '审校状态' })
+  @ApiQuery({ name: 'fromLang', required: false, description: '源语言' })
+  @ApiQuery({ name: 'toLang', required: false, description: '目标语言' })
+  @ApiQuery({ name: 'tag', required: false, description: '标签，逗号分隔时匹配任一标签' })
+  @ApiQuery({ name: 'q', required: false, description: '按名称搜索，名称需包含每个词（不区分大小写）' })
+  @ApiQuery({ name: 'orderBy', required: false, enum: DocumentSortField, description: '排序字段，默认 create_time' })
+  @ApiQuery({ name: 'direction', required: false, enum: SortDirection, description: '排序方向，默认 desc' })
+  @ApiQuery({ name: 'cursor', required: false, description: '上一页返回的 nextCursor，传入时忽略 page' })
+  @ApiQuery({ name: 'page', required: false, description: '页码' })
+  @ApiQuery({ name: 'limit', required: false, description: '每页数量（最多 100）' })
+  @ApiResponse({ status: 200, description: '返回文档列表、总数和下一页的游标' })
+  @ApiResponse({ status: 400, description: '游标无效或与排序不一致' })
+  async listDocuments(
+    @Req() req: any,
+    @Query('reviewStatus', new ParseEnumPipe(ReviewStatus, { optional: true })) reviewStatus?: ReviewStatus,
+    @Query('fromLang') fromLang?: string,
+    @Query('toLang') toLang?: string,
+    @Query('tag') tag?: string,
+    @Query('q') q?: string,
+    @Query('orderBy', new ParseEnumPipe(DocumentSortField, { optional: true })) orderBy?: DocumentSortField,
+    @Query('direction', new ParseEnumPipe(SortDirection, { optional: true })) direction?: SortDirection,
+    @Query('cursor') cursor?: string,
+    @Query('page', new DefaultValuePipe(1), ParseIntPipe) page?: number,
+    @Query('limit', new DefaultValuePipe(20), ParseIntPipe) limit?: number,
+  ) {
+    return this.translationService.listDocuments(
+      req.user.id,
+      { reviewStatus, fromLang, toLang, tag, q },
+      Math.max(page, 1),
+      Math.min(Math.max(limit, 1), 100),
+      { sort: orderBy, direction, cursor },
+      req.apiKey,
+    );
+  }
+
+  @Get('task/:id/status')
+  @PlaygroundAllowed()
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '获取任务状态；超大文档分片翻译时返回每个分片的进度' })
+  @ApiResponse({ status: 200, description: 'chunks 为 null 表示任务未分片' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  async getTaskStatus(@Req() req: any, @Param('id') id: string) {
+    return this.translationService.getTaskStatus(req.user.id, id, req.apiKey);
+  }
+
+  @Post('task/:id/chunks/retry')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @HttpCode(HttpStatus.ACCEPTED)
+  @ApiOperation({ summary: '重新提交分片翻译中失败的分片（已完成的分片不会重新翻译）' })
+  @ApiResponse({ status: 202, description: '返回重新提交的分片数' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  @ApiResponse({ status: 409, description: '任务没有失败的分片或已取消' })
+  async retryFailedChunks(@Req() req: any, @Param('id') id: string) {
+    return this.translationService.retryFailedChunks(req.user.id, id, req.apiKey);
+  }
+
+  @Post('task/:id/retry_failed')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @HttpCode(HttpStatus.ACCEPTED)
+  @ApiOperation({ summary: '部分翻译的文档只重新翻译失败的键（已翻译的键不会重新翻译，也不重复计费）' })
+  @ApiResponse({ status: 202, description: '返回重新提交的键数，完成后任务状态变为 completed（仍有失败时保持 partial）' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  @ApiResponse({ status: 409, description: '文档没有失败的键、已归档或任务已取消' })
+  @ApiResponse({ status: 423, description: '文档正在人工编辑（已锁定）' })
+  async retryFailedKeys(@Req() req: any, @Param('id') id: string) {
+    return this.translationService.retryFailedKeys(req.user.id, id, req.apiKey);
+  }
+
+  @Get('task/:id/result')
+  @PlaygroundAllowed()
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '获取任务的原文和译文（已归档的文档会从冷存储取回，较慢）' })
+  @ApiQuery({ name: 'keyFormat', required: false, enum: OutputKeyFormat, description: '覆盖创建任务时指定的输出键形态' })
+  @ApiQuery({ name: 'validate', required: false, type: Boolean, description: '重新校验结构和占位符，按键返回问题' })
+  @ApiResponse({ status: 200, description: 'archived 为 true 时表示结果来自归档，同时返回 X-Archived-Result 头' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  async getTaskResult(
+    @Req() req: any,
+    @Param('id') id: string,
+    @Res({ passthrough: true }) res: Response,
+    @Query('keyFormat', new ParseEnumPipe(OutputKeyFormat, { optional: true })) keyFormat?: OutputKeyFormat,
+    @Query('validate', new DefaultValuePipe(false), ParseBoolPipe) validate?: boolean,
+  ) {
+    const result = await this.translationService.getTaskResult(req.user.id, id, { keyFormat, validate }, req.apiKey);
+    if (result.archived) {
+      res.setHeader('X-Archived-Result', 'true');
+    }
+    return result;
+  }
+
+  @Get('task/:id/download')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '下载译文 JSON 文件（raw 紧凑、pretty 缩进、flat 点号路径键值、side_by_side 原文和译文并列）' })
+  @ApiQuery({ name: 'format', required: false, enum: DownloadFormat, description: '默认 pretty' })
+  @ApiResponse({ status: 200, description: 'application/json 附件，Content-Disposition 中带文件名' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  @ApiResponse({ status: 409, description: '翻译尚未完成' })
+  async downloadTaskResult(
+    @Req() req: any,
+    @Param('id') id: string,
+    @Res() res: Response,
+    @Query('format', new DefaultValuePipe(DownloadFormat.PRETTY), new ParseEnumPipe(DownloadFormat))
+    format: DownloadFormat,
+  ) {
+    const { fileName, content } = await this.translationService.getTaskDownload(req.user.id, id, format, req.apiKey);
+    res.setHeader('Content-Type', 'application/json; charset=utf-8');
+    res.setHeader('Content-Disposition', `attachment; filename="${fileName}"`);
+    res.status(HttpStatus.OK).send(content);
+  }
+
+  @Patch(':id/metadata')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '修改文档名称和标签' })
+  @ApiResponse({ status: 200, description: '返回更新后的名称和标签' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  async updateDocumentMetadata(@Req() req: any, @Param('id') id: string, @Body() dto: DocumentMetadataDto) {
+    return this.translationService.updateDocumentMetadata(req.user.id, id, dto, req.apiKey);
+  }
+
+  @Patch(':id/keys')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '人工修改单个键的译文，并锁定 / 解锁（锁定的键重新翻译时不会被覆盖）' })
+  @ApiResponse({ status: 200, description: '返回更新后的译文和锁定的键' })
+  @ApiResponse({ status: 400, description: '键不存在或不是译文叶子' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  @ApiResponse({ status: 409, description: '翻译尚未完成或已归档' })
+  @ApiResponse({ status: 423, description: '文档已被锁定，需要在 X-Lock-Token 中传入持有的锁 token' })
+  async updateTranslationKeys(
+    @Req() req: any,
+    @Param('id') id: string,
+    @Body() dto: UpdateTranslationKeysDto,
+    @Headers('x-lock-token') lockToken?: string,
+  ) {
+    return this.translationService.updateTranslationKeys(req.user.id, id, dto, lockToken, req.apiKey);
+  }
+
+  @Post(':id/lock')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @HttpCode(HttpStatus.OK)
+  @ApiOperation({ summary: '人工编辑前锁定文档：锁定期间其他调用方不能修改键，重新翻译延后到解锁之后' })
+  @ApiResponse({ status: 200, description: '返回锁及 token，修改键和解锁时通过 X-Lock-Token 传回；带 token 调用为续期' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  @ApiResponse({ status: 409, description: '翻译尚未完成' })
+  @ApiResponse({ status: 423, description: '文档已被其他人锁定' })
+  async lockDocument(@Req() req: any, @Param('id') id: string, @Body() dto: DocumentLockDto) {
+    return this.translationService.lockDocument(req.user.id, id, dto, req.apiKey);
+  }
+
+  @Get(':id/lock')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '查询文档锁（不返回 token）' })
+  @ApiResponse({ status: 200, description: '未锁定时返回 null' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  async getDocumentLock(@Req() req: any, @Param('id') id: string) {
+    return this.translationService.getDocumentLock(req.user.id, id, req.apiKey);
+  }
+
+  @Delete(':id/lock')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @HttpCode(HttpStatus.NO_CONTENT)
+  @ApiOperation({ summary: '解锁文档；force=true 时不校验 token（所有者强制解锁）' })
+  @ApiQuery({ name: 'force', required: false, type: Boolean })
+  @ApiResponse({ status: 204, description: '已解锁' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  @ApiResponse({ status: 423, description: 'token 与当前锁不一致' })
+  async unlockDocument(
+    @Req() req: any,
+    @Param('id') id: string,
+    @Headers('x-lock-token') lockToken?: string,
+    @Query('force', new DefaultValuePipe(false), ParseBoolPipe) force?: boolean,
+  ) {
+    await this.translationService.unlockDocument(req.user.id, id, lockToken, force, req.apiKey);
+  }
+
+  @Get(':id/validation')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '获取译文校验报告（占位符、空译文、长度比例、HTML 配对、JSON 结构）' })
+  @ApiResponse({ status: 200, description: 'issues 按键列出未通过的检查，summary 为各类问题数量' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  @ApiResponse({ status: 409, description: '翻译尚未完成' })
+  async getValidationReport(@Req() req: any, @Param('id') id: string) {
+    return this.translationService.getValidationReport(req.user.id, id, req.apiKey);
+  }
+
+  @Get(':id/usage')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '获取计费字符数按键的分布' })
+  @ApiQuery({ name: 'depth', required: false, description: '按前几层键汇总，默认且最多为统计时的层级' })
+  @ApiResponse({ status: 200, description: 'keys 按字符数从多到少排列，超出保存上限的键合并为 key 为 null 的一项' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  @ApiResponse({ status: 410, description: '文档内容已按保留策略清除且没有统计记录' })
+  async getKeyUsage(
+    @Req() req: any,
+    @Param('id') id: string,
+    @Query('depth', new ParseIntPipe({ optional: true })) depth?: number,
+  ) {
+    return this.translationService.getKeyUsage(req.user.id, id, depth, req.apiKey);
+  }
+
+  @Post(':id/lint')
+  @HttpCode(HttpStatus.OK)
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '按当前的检查规则重新检查译文，通过后恢复因违规暂停的结果推送' })
+  @ApiResponse({ status: 200, description: 'violations 按键列出违规项，blocking 为 true 时结果推送仍暂停' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  @ApiResponse({ status: 409, description: '翻译尚未完成' })
+  async lintDocument(@Req() req: any, @Param('id') id: string) {
+    return this.translationService.lintDocument(req.user.id, id, req.apiKey);
+  }
+
+  @Get(':id/review')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @ApiOperation({ summary: '获取译文的审校状态（文档及单个键）' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  async getReview(@Req() req: any, @Param('id') id: string) {
+    return this.translationReviewService.getReview(req.user.id, id, req.apiKey);
+  }
+
+  @Post(':id/review')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @HttpCode(HttpStatus.OK)
+  @ApiOperation({ summary: '变更审校状态：machine_translated → in_review → approved（可退回或重新打开）' })
+  @ApiResponse({ status: 200, description: '返回变更后的审校状态' })
+  @ApiResponse({ status: 400, description: '键不存在或不是译文叶子' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  @ApiResponse({ status: 409, description: '不允许的状态流转或翻译尚未完成' })
+  async transitionReview(@Req() req: any, @Param('id') id: string, @Body() dto: ReviewTransitionDto) {
+    return this.translationReviewService.transition(req.user.id, id, dto, req.apiKey);
+  }
+
+  @Delete(':id')
+  @UseGuards(JwtOrApiKeyGuard)
+  @ApiSecurity('api-key')
+  @HttpCode(HttpStatus.NO_CONTENT)
+  @ApiOperation({ summary: '删除已保存的翻译文档，释放存储额度' })
+  @ApiResponse({ status: 204, description: '已删除' })
+  @ApiResponse({ status: 404, description: '翻译不存在' })
+  @ApiResponse({ status: 409, description: '任务仍在处理或为定时任务' })
+  @ApiResponse({ status: 423, description: '文档正在人工编辑（已锁定）' })
+  async deleteDocument(@Req() req: any, @Param('id') id: string) {
+    await this.translationService.deleteDocument(req.user.id, id, req.apiKey);
   }
 
   @Get(':id')
-  @ApiOperation({ summary: '获取翻译结果' })
+  @ApiOperation({ summary: '获取翻译结果（已弃用，请使用 task/:id/result）', deprecated: true })
   @ApiResponse({ status: 200, description: '返回翻译结果' })
   async getTranslation(@Param('id') id: string) {
     return this.translationService.getTranslation(id);
   }
 
   @Get('user/:userId')
-  @ApiOperation({ summary: '获取用户的所有翻译' })
+  @ApiOperation({ summary: '获取用户的所有翻译（已弃用，请使用 documents）', deprecated: true })
   @ApiResponse({ status: 200, description: '返回用户的翻译列表' })
   async getTranslationsByUser(@Param('userId') userId: string) {
     return this.translationService.getTranslationsByUser(userId);
   }
 
   @Post('detect')
+  @ReadOnlySafe()
   @ApiOperation({ summary: '检测文本语言' })
   @ApiResponse({ status: 200, description: '返回检测到的语言' })
   async detectLanguage(@Body('text') text: string) {
@@ -1,9 +1,106 @@
 import { Module } from '@nestjs/common';
 import { TranslationController } from './translation.controller';
+import { AccountLockdownController } from './account-lockdown.controller';
+import { SourceSyncController } from './source-sync.controller';
+import { LintRuleController } from './lint-rule.controller';
+import { IgnoreProfileController } from './ignore-profile.controller';
+import { UsageImportController } from './usage-import.controller';
+import { ProviderCacheController } from './provider-cache.controller';
+import { ProviderReconciliationController } from './provider-reconciliation.controller';
+import { ProviderHealthController } from './provider-health.controller';
+import { ToolsController } from './tools.controller';
+import { UsageReportController } from './usage-report.controller';
+import { CustomMtEngineController } from './custom-mt-engine.controller';
+import { RetentionController } from './retention.controller';
+import { ProjectController } from './project.controller';
+import { EncryptionController } from './encryption.controller';
+import { UserAuditLogController } from './audit-log.controller';
+import { IncidentController } from './incident.controller';
+import { LanguageBackfillController } from './language-backfill.controller';
 import { TranslationService } from './translation.service';
 import { MikroOrmModule } from '@mikro-orm/nestjs';
+import { BullModule } from '@nestjs/bull';
 import { TranslationTask, UserJsonData, CharacterUsageLog, CharacterUsageLogDaily, WebhookConfig } from './entities/translation-task.entity';
 import { HttpModule } from '@nestjs/axios';
+import { TranslationUtils } from './utils/translation.utils';
+import { QuotaService } from './services/quota.service';
+import { MonthlyUsageCounterService } from './services/monthly-usage-counter.service';
+import { QuotaWarningService } from './services/quota-warning.service';
+import { QueuePriorityService } from './services/queue-priority.service';
+import { DocumentLockService } from './services/document-lock.service';
+import { QueueBackpressureService } from './services/queue-backpressure.service';
+import { UsageRollupService } from './services/usage-rollup.service';
+import { SourceSyncService } from './services/source-sync.service';
+import { IncrementalTranslationService } from './services/incremental-translation.service';
+import { UsageImportService } from './services/usage-import.service';
+import { StorageLimitService } from './services/storage-limit.service';
+import { DocumentArchiveService } from './services/document-archive.service';
+import { TranslationReviewService } from './services/translation-review.service';
+import { TranslationChunkService } from './services/translation-chunk.service';
+import { QualityEstimationService } from './services/quality-estimation.service';
+import { TranslationValidationService } from './services/translation-validation.service';
+import { TranslationLintService } from './services/translation-lint.service';
+import { IgnoreProfileService } from './services/ignore-profile.service';
+import { TranslationCheckpointService } from './services/translation-checkpoint.service';
+import { ProviderCacheService } from './services/provider-cache.service';
+import { ProviderThrottleService } from './services/provider-throttle.service';
+import { ProviderUsageService } from './services/provider-usage.service';
+import { UserConcurrencyService } from './services/user-concurrency.service';
+import { WorkerMemoryService } from './services/worker-memory.service';
+import { WarehouseExportService } from './services/warehouse-export.service';
+import { ProviderReconciliationService } from './services/provider-reconciliation.service';
+import { ProviderHealthService } from './services/provider-health.service';
+import { IncidentService } from './services/incident.service';
+import { LanguageBackfillService } from './services/language-backfill.service';
+import { RetryConfigService } from '../../common/services/retry-config.service';
+import { LocaleBundleService } from './services/locale-bundle.service';
+import { FieldSuggestionService } from './services/field-suggestion.service';
+import { JsonLimitsService } from './services/json-limits.service';
+import { DuplicateSubmissionService } from './services/duplicate-submission.service';
+import { UsageReportService } from './services/usage-report.service';
+import { CustomMtEngineService } from './services/custom-mt-engine.service';
+import { DocumentRetentionService } from './services/document-retention.service';
+import { ProjectService } from './services/project.service';
+import { DocumentEncryptionService } from './services/document-encryption.service';
+import { TranslationRepository } from './translation.repository';
+import {
+  TranslationTaskRepository,
+  UserJsonDataRepository,
+  SourceSyncRepository,
+  TranslationChunkRepository,
+  LintRuleRepository,
+  IgnoreProfileRepository,
+  CustomMtEngineRepository,
+  RetentionPolicyRepository,
+  ProjectSettingsRepository,
+  ProjectJobRepository,
+  UserDataKeyRepository,
+  IncidentRepository,
+} from './repositories/translation-task.repository';
+import { SourceSync } from './entities/source-sync.entity';
+import { TranslationChunk } from './entities/translation-chunk.entity';
+import { LintRule } from './entities/lint-rule.entity';
+import { IgnoreProfile } from './entities/ignore-profile.entity';
+import { UsageReportSubscription } from './entities/usage-report.entity';
+import { CustomMtEngine } from './entities/custom-mt-engine.entity';
+import { RetentionPolicy } from './entities/retention-policy.entity';
+import { ProjectJob, ProjectSettings } from './entities/project.entity';
+import { UserDataKey } from './entities/user-data-key.entity';
+import { Incident } from './entities/incident.entity';
+import { ProviderInvoice, ProviderUsageMonthly } from './entities/provider-usage.entity';
+import {
+  CharacterUsageLogRepository,
+  CharacterUsageLogDailyRepository,
+  ProviderUsageMonthlyRepository,
+  ProviderInvoiceRepository,
+  UsageReportSubscriptionRepository,
+} from './repositories/character-usage.repository';
+import { SubscriptionModule } from '../subscription/subscription.module';
+import { WebhookModule } from '../webhook/webhook.module';
+import { ApiKeyModule } from '../api-key/api-key.module';
+import { TenantModule } from '../tenant/tenant.module';
+import { AuditModule } from '../audit/audit.module';
+import { CommonModule } from '../../common/common.module';
 
 @Module({
   imports: [
@@ -13,11 +110,130 @@ import { HttpModule } from '@nestjs/axios';
       CharacterUsageLog,
       CharacterUsageLogDaily,
       WebhookConfig,
+      SourceSync,
+      TranslationChunk,
+      LintRule,
+      IgnoreProfile,
+      ProviderUsageMonthly,
+      ProviderInvoice,
+      UsageReportSubscription,
+      CustomMtEngine,
+      RetentionPolicy,
+      ProjectSettings,
+      ProjectJob,
+      UserDataKey,
+      Incident,
     ]),
+    BullModule.registerQueue({ name: 'translation' }, { name: 'webhook' }),
     HttpModule,
+    SubscriptionModule,
+    WebhookModule,
+    ApiKeyModule,
+    TenantModule,
+    AuditModule,
+    CommonModule,
+  ],
+  controllers: [
+    // translation/lint-rules 必须在 TranslationController 的 GET :id 之前注册
+    LintRuleController,
+    IgnoreProfileController,
+    TranslationController,
+    AccountLockdownController,
+    SourceSyncController,
+    UsageImportController,
+    ProviderCacheController,
+    ProviderReconciliationController,
+    ProviderHealthController,
+    ToolsController,
+    UsageReportController,
+    CustomMtEngineController,
+    RetentionController,
+    ProjectController,
+    EncryptionController,
+    UserAuditLogController,
+    IncidentController,
+    LanguageBackfillController,
+  ],
+  providers: [
+    TranslationService,
+    TranslationUtils,
+    QuotaService,
+    MonthlyUsageCounterService,
+    QuotaWarningService,
+    QueuePriorityService,
+    DocumentLockService,
+    QueueBackpressureService,
+    StorageLimitService,
+    DocumentArchiveService,
+    TranslationReviewService,
+    TranslationChunkService,
+    QualityEstimationService,
+    TranslationValidationService,
+    TranslationLintService,
+    IgnoreProfileService,
+    TranslationCheckpointService,
+    ProviderCacheService,
+    ProviderThrottleService,
+    ProviderUsageService,
+    ProviderHealthService,
+    IncidentService,
+    LanguageBackfillService,
+    UserConcurrencyService,
+    WorkerMemoryService,
+    WarehouseExportService,
+    ProviderReconciliationService,
+    RetryConfigService,
+    LocaleBundleService,
+    FieldSuggestionService,
+    JsonLimitsService,
+    DuplicateSubmissionService,
+    UsageReportService,
+    CustomMtEngineService,
+    DocumentRetentionService,
+    ProjectService,
+    DocumentEncryptionService,
+    UsageRollupService,
+    IncrementalTranslationService,
+    SourceSyncService,
+    UsageImportService,
+    TranslationRepository,
+    TranslationTaskRepository,
+    UserJsonDataRepository,
+    SourceSyncRepository,
+    TranslationChunkRepository,
+    LintRuleRepository,
+    IgnoreProfileRepository,
+    CharacterUsageLogRepository,
+    CharacterUsageLogDailyRepository,
+    ProviderUsageMonthlyRepository,
+    ProviderInvoiceRepository,
+    UsageReportSubscriptionRepository,
+    CustomMtEngineRepository,
+    RetentionPolicyRepository,
+    ProjectSettingsRepository,
+    ProjectJobRepository,
+    UserDataKeyRepository,
+    IncidentRepository,
+  ],
+  exports: [
+    TranslationService,
+    QuotaService,
+    UsageRollupService,
+    IncrementalTranslationService,
+    SourceSyncService,
+    DocumentArchiveService,
+    QualityEstimationService,
+    ProviderUsageService,
+    UserConcurrencyService,
+    WorkerMemoryService,
+    WarehouseExportService,
+    UsageReportService,
+    DocumentRetentionService,
+    LanguageBackfillService,
+    JsonLimitsService,
+    TranslationTaskRepository,
+    UserJsonDataRepository,
+    CharacterUsageLogDailyRepository,
   ],
-  controllers: [TranslationController],
-  providers: [TranslationService],
-  exports: [TranslationService],
 })
-export class TranslationModule {} 
\ No newline at end of file
+export class TranslationModule {}
@@ -1,8 +1,15 @@
-import { EntityRepository } from '@mikro-orm/core';
+import { Injectable } from '@nestjs/common';
+import { EntityManager } from '@mikro-orm/core';
+import { DataRepository } from '../../common/repositories/data.repository';
 import { Translation } from './entities/translation.entity';
 
-export class TranslationRepository extends EntityRepository<Translation> {
+@Injectable()
+export class TranslationRepository extends DataRepository<Translation> {
+  constructor(em: EntityManager) {
+    super(em, Translation, 'userId');
+  }
+
   async findById(id: string): Promise<Translation | null> {
-    return this.findOne({ id });
+    return this.get({ id });
   }
-} 
\ No newline at end of file
+}
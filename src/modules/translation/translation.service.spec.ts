@@ -3,10 +3,44 @@ import { EntityManager } from '@mikro-orm/core';
 import { HttpService } from '@nestjs/axios';
 import { ConfigService } from '@nestjs/config';
 import { getQueueToken } from '@nestjs/bull';
+import { BadRequestException, NotFoundException } from '@nestjs/common';
+import { TranslationProvider } from '../../config/providers';
 import { TranslationService } from './translation.service';
 import { WebhookService } from '../webhook/webhook.service';
+import { WebhookRateLimiterService } from '../webhook/services/webhook-rate-limiter.service';
+import { NotificationChannelService } from '../webhook/services/notification-channel.service';
 import { TranslationUtils } from './utils/translation.utils';
+import { OutputKeyFormat } from './utils/output-keys';
+import { DocumentSortField } from './utils/list-cursor';
+import { recordKeyUsage } from './utils/key-usage';
 import { Translation } from './entities/translation.entity';
+import { QuotaService } from './services/quota.service';
+import { UsageRollupService } from './services/usage-rollup.service';
+import { QueuePriorityService } from './services/queue-priority.service';
+import { QueueBackpressureService } from './services/queue-backpressure.service';
+import { StorageLimitService } from './services/storage-limit.service';
+import { JsonLimitsService } from './services/json-limits.service';
+import { DocumentArchiveService } from './services/document-archive.service';
+import { TranslationReviewService } from './services/translation-review.service';
+import { TranslationChunkService } from './services/translation-chunk.service';
+import { QualityEstimationService } from './services/quality-estimation.service';
+import { TranslationValidationService } from './services/translation-validation.service';
+import { TranslationLintService } from './services/translation-lint.service';
+import { TranslationCheckpointService } from './services/translation-checkpoint.service';
+import { ProviderThrottleService } from './services/provider-throttle.service';
+import { DocumentLockService } from './services/document-lock.service';
+import { IgnoreProfileService } from './services/ignore-profile.service';
+import { DuplicateSubmissionService } from './services/duplicate-submission.service';
+import { CustomMtEngineService } from './services/custom-mt-engine.service';
+import { IncidentService } from './services/incident.service';
+import { ErrorReporterService } from '../../common/services/error-reporter.service';
+import { RedisService } from '../../common/services/redis.service';
+import { StorageDriverService } from '../../common/services/storage-driver.service';
+import { AccountLockdownService } from '../api-key/account-lockdown.service';
+import { ApiKeyService } from '../api-key/api-key.service';
+import { TranslationRepository } from './translation.repository';
+import { TranslationTaskRepository, UserJsonDataRepository } from './repositories/translation-task.repository';
+import { SendRetryRepository } from '../webhook/repositories/send-retry.repository';
 import { TranslationTask, UserJsonData, WebhookConfig } from './entities/translation-task.entity';
 import { of } from 'rxjs';
 
@@ -20,8 +54,11 @@ describe('TranslationService', () => {
   const mockEntityManager = {
     findOne: jest.fn(),
     find: jest.fn(),
+    findAndCount: jest.fn(),
+    count: jest.fn(),
     create: jest.fn(),
     persistAndFlush: jest.fn(),
+    removeAndFlush: jest.fn(),
   };
 
   const mockHttpService = {
@@ -30,22 +67,167 @@ describe('TranslationService', () => {
 
   const mockWebhookService = {
     notifyTranslationComplete: jest.fn(),
+    resolveDeliveryConfig: jest.fn(),
+    dispatchEvent: jest.fn().mockResolvedValue(true),
+    deliveryHeaders: jest.fn().mockReturnValue({}),
+    deliveryAgent: jest.fn(),
+  };
+
+  const mockWebhookRateLimiter = {
+    acquire: jest.fn().mockResolvedValue({ acquired: true, release: jest.fn() }),
+  };
+
+  const mockNotificationChannelService = {
+    publish: jest.fn().mockResolvedValue(undefined),
   };
 
   const mockTranslationUtils = {
     translateJson: jest.fn(),
+    retranslateKeys: jest.fn(),
     getIgnoredFields: jest.fn(),
     countJsonChars: jest.fn(),
   };
 
   const mockConfigService = {
-    get: jest.fn(),
+    get: jest.fn((key: string, defaultValue?: any) => defaultValue),
+  };
+
+  const mockQuotaService = {
+    assertWithinQuota: jest.fn(),
+    check: jest.fn(),
+    release: jest.fn(),
+  };
+
+  const mockUsageRollupService = {
+    publish: jest.fn().mockResolvedValue(undefined),
+  };
+
+  const mockTranslationQueue = {
+    add: jest.fn(),
+    getJobCounts: jest.fn(),
+    getJob: jest.fn(),
+    getJobs: jest.fn(),
+    removeRepeatable: jest.fn(),
+  };
+
+  const mockQueuePriorityService = {
+    resolve: jest.fn().mockResolvedValue({ priority: 'default', tier: 'free', queuePriority: 5 }),
+    weightOf: jest.fn().mockReturnValue(5),
+  };
+
+  const mockQueueBackpressureService = {
+    assertAccepting: jest.fn().mockResolvedValue(undefined),
+  };
+
+  const mockStorageLimitService = {
+    assertCanStore: jest.fn().mockResolvedValue(undefined),
+  };
+
+  const mockDocumentArchiveService = {
+    load: jest.fn(async (document) => ({
+      originJson: document.originJson,
+      translatedJson: document.translatedJson,
+      archived: false,
+    })),
+    discard: jest.fn(),
+  };
+
+  const mockTranslationReviewService = {
+    resetAfterTranslation: jest.fn(),
+    reopenAfterEdit: jest.fn(),
+  };
+
+  const mockTranslationChunkService = {
+    shouldChunk: jest.fn().mockReturnValue(false),
+    split: jest.fn(),
+    translateChunk: jest.fn(),
+    getProgress: jest.fn(),
+    retryFailed: jest.fn(),
+    discard: jest.fn(),
+  };
+
+  const mockQualityEstimationService = {
+    schedule: jest.fn().mockResolvedValue(undefined),
+    forgetKeys: jest.fn(),
+    summarize: jest.fn().mockReturnValue({ score: null, threshold: 0.6, lowConfidenceKeys: [] }),
+  };
+
+  const mockTranslationCheckpointService = {
+    open: jest.fn().mockResolvedValue({ completed: {}, save: jest.fn() }),
+    clear: jest.fn(),
+  };
+
+  const mockProviderThrottleService = {
+    execute: jest.fn((_provider: string, fn: () => Promise<any>) => fn()),
+  };
+
+  const mockAccountLockdownService = {
+    isLocked: jest.fn().mockResolvedValue(false),
+    assertUnlocked: jest.fn().mockResolvedValue(undefined),
+  };
+
+  const mockApiKeyService = {
+    consumeKeyCharacters: jest.fn().mockResolvedValue(undefined),
+    refundKeyCharacters: jest.fn().mockResolvedValue(undefined),
+  };
+
+  const mockRedisService = {
+    client: { sadd: jest.fn(), smembers: jest.fn().mockResolvedValue([]) },
+    del: jest.fn(),
+  };
+
+  const mockDocumentLockService = {
+    get: jest.fn().mockResolvedValue(null),
+    assertEditable: jest.fn().mockResolvedValue(undefined),
+    acquire: jest.fn(),
+    release: jest.fn().mockResolvedValue(true),
+  };
+
+  const mockIgnoreProfileService = {
+    resolve: jest.fn(),
+  };
+
+  const mockDuplicateSubmissionService = {
+    windowSeconds: 600,
+    fingerprint: jest.fn().mockReturnValue('fingerprint'),
+    claim: jest.fn().mockResolvedValue(undefined),
+    release: jest.fn().mockResolvedValue(undefined),
+  };
+
+  const mockCustomMtEngineService = {
+    assertConfigured: jest.fn().mockResolvedValue(undefined),
+  };
+
+  const mockIncidentService = {
+    forTask: jest.fn().mockResolvedValue([]),
+    forDocuments: jest.fn().mockResolvedValue(new Map()),
+  };
+
+  const mockTranslationLintService = {
+    lint: jest.fn().mockResolvedValue(undefined),
+    isBlocked: jest.fn((document) => !!document.lintReport?.blocking),
+  };
+
+  const mockWebhookQueue = {
+    add: jest.fn(),
+  };
+
+  const mockErrorReporter = {
+    captureException: jest.fn(),
   };
 
   beforeEach(async () => {
     const module: TestingModule = await Test.createTestingModule({
       providers: [
         TranslationService,
+        TranslationValidationService,
+        JsonLimitsService,
+        // 数据访问层使用真实实现，底层 EntityManager 为 mock
+        TranslationRepository,
+        TranslationTaskRepository,
+        UserJsonDataRepository,
+        SendRetryRepository,
+        StorageDriverService,
         {
           provide: EntityManager,
           useValue: mockEntityManager,
@@ -58,6 +240,14 @@ describe('TranslationService', () => {
           provide: WebhookService,
           useValue: mockWebhookService,
         },
+        {
+          provide: WebhookRateLimiterService,
+          useValue: mockWebhookRateLimiter,
+        },
+        {
+          provide: NotificationChannelService,
+          useValue: mockNotificationChannelService,
+        },
         {
           provide: TranslationUtils,
           useValue: mockTranslationUtils,
@@ -66,11 +256,97 @@ describe('TranslationService', () => {
           provide: ConfigService,
           useValue: mockConfigService,
         },
+        {
+          provide: QuotaService,
+          useValue: mockQuotaService,
+        },
+        {
+          provide: ErrorReporterService,
+          useValue: mockErrorReporter,
+        },
+        {
+          provide: UsageRollupService,
+          useValue: mockUsageRollupService,
+        },
+        {
+          provide: QueuePriorityService,
+          useValue: mockQueuePriorityService,
+        },
+        {
+          provide: QueueBackpressureService,
+          useValue: mockQueueBackpressureService,
+        },
+        {
+          provide: StorageLimitService,
+          useValue: mockStorageLimitService,
+        },
+        {
+          provide: DocumentArchiveService,
+          useValue: mockDocumentArchiveService,
+        },
+        {
+          provide: TranslationReviewService,
+          useValue: mockTranslationReviewService,
+        },
+        {
+          provide: TranslationChunkService,
+          useValue: mockTranslationChunkService,
+        },
+        {
+          provide: QualityEstimationService,
+          useValue: mockQualityEstimationService,
+        },
+        {
+          provide: TranslationCheckpointService,
+          useValue: mockTranslationCheckpointService,
+        },
+        {
+          provide: ProviderThrottleService,
+          useValue: mockProviderThrottleService,
+        },
+        {
+          provide: AccountLockdownService,
+          useValue: mockAccountLockdownService,
+        },
+        {
+          provide: ApiKeyService,
+          useValue: mockApiKeyService,
+        },
+        {
+          provide: RedisService,
+          useValue: mockRedisService,
+        },
+        {
+          provide: DocumentLockService,
+          useValue: mockDocumentLockService,
+        },
+        {
+          provide: TranslationLintService,
+          useValue: mockTranslationLintService,
+        },
+        {
+          provide: IgnoreProfileService,
+          useValue: mockIgnoreProfileService,
+        },
+        {
+          provide: DuplicateSubmissionService,
+          useValue: mockDuplicateSubmissionService,
+        },
+        {
+          provide: CustomMtEngineService,
+          useValue: mockCustomMtEngineService,
+        },
+        {
+          provide: IncidentService,
+          useValue: mockIncidentService,
+        },
         {
           provide: getQueueToken('translation'),
-          useValue: {
-            add: jest.fn(),
-          },
+          useValue: mockTranslationQueue,
+        },
+        {
+          provide: getQueueToken('webhook'),
+          useValue: mockWebhookQueue,
         },
       ],
     }).compile();
@@ -87,19 +363,385 @@ describe('TranslationService', () => {
   });
 
   describe('createTranslationTask', () => {
-    it('应该创建一个新的翻译任务', async () => {
+    const payload = { jsonContentRaw: '{"text": "hello"}', fromLang: 'en', toLang: 'zh' };
+    const quota = { allowed: true, mode: 'hard', used: 0, requested: 5, limit: 10000, remaining: 10000, overage: 0, capped: false, warnings: [] };
+
+    it('应该创建一个新的翻译任务并加入队列', async () => {
       const userId = 'user123';
-      const content = 'test content';
-      const mockTask = { id: 'task123', userId, content, status: 'pending' };
-      
-      mockEntityManager.create.mockReturnValue(mockTask);
+      const mockTask = { id: 'task123', userId, content: payload.jsonContentRaw, status: 'pending' };
+      const mockUserData = { id: 'task123', userId };
+
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockTranslationUtils.getIgnoredFields.mockReturnValue([]);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+      mockEntityManager.create.mockReturnValueOnce(mockTask).mockReturnValueOnce(mockUserData);
       mockEntityManager.persistAndFlush.mockResolvedValue(undefined);
 
-      const result = await service.createTranslationTask(userId, content);
-      
-      expect(result).toEqual(mockTask);
-      expect(mockEntityManager.create).toHaveBeenCalledWith(TranslationTask, expect.any(Object));
-      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith(mockTask);
+      const result = await service.createTranslationTask(userId, payload);
+
+      expect(result).toEqual({ task: mockTask, quota });
+      expect(mockQuotaService.assertWithinQuota).toHaveBeenCalledWith(userId, 5, undefined);
+      expect(mockEntityManager.create).toHaveBeenCalledWith(
+        TranslationTask,
+        expect.objectContaining({ charTotal: 5, quotaReserved: 5, quotaReservedAt: expect.any(Date) }),
+      );
+      expect(mockEntityManager.create).toHaveBeenCalledWith(UserJsonData, expect.objectContaining({ fromLang: 'en' }));
+      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith([mockTask, mockUserData]);
+      expect(mockEntityManager.create).toHaveBeenCalledWith(TranslationTask, expect.objectContaining({ priority: 'default' }));
+      expect(mockTranslationQueue.add).toHaveBeenCalledWith(
+        'translate-json',
+        { taskId: expect.any(String) },
+        { priority: 5, attempts: 3, backoff: { type: 'exponential', delay: 5000 } },
+      );
+      expect(mockQueuePriorityService.resolve).toHaveBeenCalledWith(userId, 5, undefined);
+    });
+
+    it('队列拥堵时仍创建任务并返回背压信息', async () => {
+      const backpressure = {
+        congested: true,
+        queueDepth: 1200,
+        estimatedWaitSeconds: 6000,
+        estimatedStartAt: '2026-10-16T10:00:00.000Z',
+      };
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+      mockQueueBackpressureService.assertAccepting.mockResolvedValueOnce(backpressure);
+
+      const result = await service.createTranslationTask('user123', payload);
+
+      expect(mockQueueBackpressureService.assertAccepting).toHaveBeenCalledWith('user123', 'free');
+      expect(result.backpressure).toEqual(backpressure);
+      expect(mockTranslationQueue.add).toHaveBeenCalled();
+    });
+
+    it('引用忽略配置时按配置规则计费，并在文档上保存规则快照', async () => {
+      const rules = { paths: ['meta.**'], skip: ['urls'] };
+      mockIgnoreProfileService.resolve.mockResolvedValueOnce(rules);
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+
+      await service.createTranslationTask('user123', { ...payload, profileId: 'profile1' });
+
+      expect(mockIgnoreProfileService.resolve).toHaveBeenCalledWith('user123', 'profile1');
+      expect(mockTranslationUtils.countJsonChars).toHaveBeenCalledWith(
+        payload.jsonContentRaw,
+        expect.objectContaining({ ignore: expect.anything() }),
+      );
+      expect(mockEntityManager.create).toHaveBeenCalledWith(
+        UserJsonData,
+        expect.objectContaining({ ignoreRules: rules }),
+      );
+    });
+
+    it('开启个人信息屏蔽时保存规则和屏蔽报告，无效的正则返回 400', async () => {
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+
+      await service.createTranslationTask('user123', {
+        ...payload,
+        jsonContentRaw: '{"contact":"Mail ann@example.com or EMP-123456"}',
+        maskPii: true,
+        piiPatterns: ['EMP-\\d{6}'],
+      });
+
+      expect(mockEntityManager.create).toHaveBeenCalledWith(
+        UserJsonData,
+        expect.objectContaining({
+          maskPii: true,
+          piiPatterns: ['EMP-\\d{6}'],
+          piiReport: {
+            contact: [
+              { type: 'email', start: 5, end: 20 },
+              { type: 'custom', start: 24, end: 34 },
+            ],
+          },
+        }),
+      );
+      await expect(
+        service.createTranslationTask('user123', { ...payload, maskPii: true, piiPatterns: ['('] }),
+      ).rejects.toThrow(BadRequestException);
+    });
+
+    it('窗口期内重复提交时返回 409 和已有文档 ID，不创建任务', async () => {
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+      mockDuplicateSubmissionService.claim.mockResolvedValueOnce('doc1');
+      mockEntityManager.findOne.mockResolvedValueOnce({ id: 'doc1', userId: 'user123' });
+
+      await expect(service.createTranslationTask('user123', payload)).rejects.toMatchObject({
+        status: 409,
+        response: expect.objectContaining({ documentId: 'doc1' }),
+      });
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+      expect(mockTranslationQueue.add).not.toHaveBeenCalled();
+    });
+
+    it('已有文档被删除或 force=true 时正常创建', async () => {
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+      mockDuplicateSubmissionService.claim.mockResolvedValueOnce('doc1');
+      mockEntityManager.findOne.mockResolvedValueOnce(null);
+      mockEntityManager.create.mockImplementation((_entity, data) => data);
+
+      await service.createTranslationTask('user123', payload);
+      await service.createTranslationTask('user123', { ...payload, force: true });
+
+      expect(mockDuplicateSubmissionService.claim).toHaveBeenLastCalledWith(
+        'user123',
+        'fingerprint',
+        expect.any(String),
+        true,
+      );
+      expect(mockDuplicateSubmissionService.fingerprint).toHaveBeenCalledTimes(1);
+      expect(mockTranslationQueue.add).toHaveBeenCalledTimes(2);
+      mockEntityManager.create.mockReset();
+    });
+
+    it('入队失败时释放指纹、退回密钥字符数并删除已保存的任务', async () => {
+      const apiKey = { id: 'key1', userId: 'user123', delegated: false, defaults: {}, maxCharacters: 100 };
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+      mockEntityManager.create.mockImplementationOnce((_entity, data) => data).mockImplementationOnce((_entity, data) => data);
+      mockTranslationQueue.add.mockRejectedValueOnce(new Error('queue unavailable'));
+
+      await expect(service.createTranslationTask('user123', payload, { apiKey })).rejects.toThrow('queue unavailable');
+
+      const [[, , id]] = mockDuplicateSubmissionService.claim.mock.calls;
+      expect(mockDuplicateSubmissionService.release).toHaveBeenCalledWith('user123', 'fingerprint', id);
+      expect(mockApiKeyService.refundKeyCharacters).toHaveBeenCalledWith(apiKey, 5);
+      expect(mockQuotaService.release).toHaveBeenCalledWith('user123', 5, expect.any(Date));
+      expect(mockEntityManager.removeAndFlush).toHaveBeenCalledWith(
+        expect.objectContaining({ id, originJson: payload.jsonContentRaw }),
+      );
+      expect(mockEntityManager.removeAndFlush).toHaveBeenCalledWith(expect.objectContaining({ id, status: 'pending' }));
+    });
+
+    it('保存失败时释放指纹并退回密钥字符数', async () => {
+      const apiKey = { id: 'key1', userId: 'user123', delegated: false, defaults: {}, maxCharacters: 100 };
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+      mockEntityManager.persistAndFlush.mockRejectedValueOnce(new Error('db down'));
+
+      await expect(service.createTranslationTask('user123', payload, { apiKey })).rejects.toThrow();
+
+      expect(mockDuplicateSubmissionService.release).toHaveBeenCalled();
+      expect(mockApiKeyService.refundKeyCharacters).toHaveBeenCalledWith(apiKey, 5);
+      expect(mockQuotaService.release).toHaveBeenCalledWith('user123', 5, expect.any(Date));
+      expect(mockTranslationQueue.add).not.toHaveBeenCalled();
+      expect(mockEntityManager.removeAndFlush).not.toHaveBeenCalled();
+    });
+
+    it('项目翻译已预留额度时只检查不再预留，失败时由调用方释放', async () => {
+      const reservedAt = new Date('2026-10-16T08:00:00.000Z');
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.check.mockResolvedValue(quota);
+      mockEntityManager.persistAndFlush.mockRejectedValueOnce(new Error('db down'));
+
+      await expect(
+        service.createTranslationTask('user123', payload, { quotaReservation: { characters: 5, reservedAt } }),
+      ).rejects.toThrow('db down');
+
+      expect(mockQuotaService.check).toHaveBeenCalledWith('user123', 0);
+      expect(mockQuotaService.assertWithinQuota).not.toHaveBeenCalled();
+      expect(mockEntityManager.create).toHaveBeenCalledWith(
+        TranslationTask,
+        expect.objectContaining({ quotaReserved: 5, quotaReservedAt: reservedAt }),
+      );
+      expect(mockQuotaService.release).not.toHaveBeenCalled();
+    });
+
+    it('provider 为 custom 但未配置引擎时拒绝创建', async () => {
+      mockCustomMtEngineService.assertConfigured.mockRejectedValueOnce(
+        new BadRequestException('No custom translation engine is configured'),
+      );
+
+      await expect(
+        service.createTranslationTask('user123', { ...payload, provider: TranslationProvider.CUSTOM }),
+      ).rejects.toThrow(BadRequestException);
+      expect(mockCustomMtEngineService.assertConfigured).toHaveBeenCalledWith('user123');
+      expect(mockTranslationQueue.add).not.toHaveBeenCalled();
+    });
+
+    it('嵌套过深的文档应在解析前拒绝', async () => {
+      const jsonContentRaw = `{"a":${'['.repeat(40)}${']'.repeat(40)}}`;
+
+      await expect(service.createTranslationTask('user123', { ...payload, jsonContentRaw })).rejects.toThrow(
+        'JSON nesting exceeds 32 levels',
+      );
+      expect(mockTranslationUtils.countJsonChars).not.toHaveBeenCalled();
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+    });
+
+    it('fromLang 为 auto 时应抽样检测源语言并保存到记录', async () => {
+      const content = JSON.stringify({ title: 'Bonjour tout le monde', body: 'Merci beaucoup', code: 'OK' });
+      mockTranslationUtils.countJsonChars.mockReturnValue(30);
+      mockTranslationUtils.getIgnoredFields.mockReturnValue([]);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+      mockEntityManager.create.mockImplementationOnce((_entity, data) => data).mockImplementationOnce((_entity, data) => data);
+      const detect = jest.spyOn(service, 'detectLanguage').mockResolvedValue('fr');
+
+      const result = await service.createTranslationTask('user123', { ...payload, jsonContentRaw: content, fromLang: 'auto' });
+
+      expect(detect).toHaveBeenCalledTimes(2);
+      expect(result.detection).toEqual({ language: 'fr', confidence: 1 });
+      expect(mockEntityManager.create).toHaveBeenCalledWith(
+        UserJsonData,
+        expect.objectContaining({ fromLang: 'fr', detectionConfidence: 1 }),
+      );
+    });
+
+    it('检测结果不一致导致置信度过低时应拒绝', async () => {
+      const content = JSON.stringify({ a: 'Bonjour tout le monde', b: 'Guten Morgen zusammen' });
+      mockTranslationUtils.countJsonChars.mockReturnValue(30);
+      mockTranslationUtils.getIgnoredFields.mockReturnValue([]);
+      jest.spyOn(service, 'detectLanguage').mockResolvedValueOnce('fr').mockResolvedValueOnce('de');
+
+      await expect(
+        service.createTranslationTask('user123', { ...payload, jsonContentRaw: content, fromLang: 'auto' }),
+      ).rejects.toThrow('Source language could not be detected reliably');
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+    });
+
+    it('额度检查失败时不应创建任务', async () => {
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.assertWithinQuota.mockRejectedValue(new Error('Monthly character quota exceeded'));
+
+      await expect(service.createTranslationTask('user123', payload)).rejects.toThrow('Monthly character quota exceeded');
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+      expect(mockTranslationQueue.add).not.toHaveBeenCalled();
+    });
+
+    it('超出存储上限时不应创建任务', async () => {
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockStorageLimitService.assertCanStore.mockRejectedValueOnce(new Error('Stored document limit reached'));
+
+      await expect(service.createTranslationTask('user123', payload)).rejects.toThrow('Stored document limit reached');
+      expect(mockStorageLimitService.assertCanStore).toHaveBeenCalledWith(
+        'user123',
+        Buffer.byteLength(payload.jsonContentRaw),
+      );
+      expect(mockQuotaService.assertWithinQuota).not.toHaveBeenCalled();
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+    });
+
+    it('委托密钥创建的任务应归属到密钥和绑定项目', async () => {
+      const apiKey = { id: 'key1', userId: 'user123', delegated: true, project: 'mobile-app', defaults: {} };
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+      mockEntityManager.create.mockImplementationOnce((_entity, data) => data).mockImplementationOnce((_entity, data) => data);
+
+      await service.createTranslationTask('user123', payload, { apiKey, tenantId: 'tenant1' });
+
+      expect(mockEntityManager.create).toHaveBeenCalledWith(
+        TranslationTask,
+        expect.objectContaining({ project: 'mobile-app', apiKeyId: 'key1', tenantId: 'tenant1' }),
+      );
+      expect(mockApiKeyService.consumeKeyCharacters).toHaveBeenCalledWith(apiKey, 5);
+    });
+
+    it('委托密钥访问其他项目时应拒绝', async () => {
+      const apiKey = { id: 'key1', userId: 'user123', delegated: true, project: 'mobile-app', defaults: {} };
+
+      await expect(
+        service.createTranslationTask('user123', { ...payload, project: 'web' }, { apiKey }),
+      ).rejects.toThrow('API key is restricted to project "mobile-app"');
+      expect(mockQuotaService.assertWithinQuota).not.toHaveBeenCalled();
+    });
+
+    it('请求未指定的字段应使用 API Key 的默认值', async () => {
+      const apiKey = {
+        id: 'key2',
+        userId: 'user123',
+        delegated: false,
+        defaults: { ignoredFields: 'id,url', provider: 'aliyun', project: 'web' },
+      };
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+      mockEntityManager.create.mockImplementationOnce((_entity, data) => data).mockImplementationOnce((_entity, data) => data);
+
+      await service.createTranslationTask('user123', { ...payload, ignoredFields: 'name' }, { apiKey });
+
+      expect(mockEntityManager.create).toHaveBeenCalledWith(TranslationTask, expect.objectContaining({ project: 'web' }));
+      expect(mockEntityManager.create).toHaveBeenCalledWith(
+        UserJsonData,
+        expect.objectContaining({ ignoredFields: 'name', provider: 'aliyun' }),
+      );
+    });
+
+    it('指定 processAt 时应以延迟任务入队', async () => {
+      const processAt = new Date(Date.now() + 60 * 60 * 1000).toISOString();
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+      mockEntityManager.create.mockImplementationOnce((_entity, data) => data).mockImplementationOnce((_entity, data) => data);
+
+      const { task } = await service.createTranslationTask('user123', { ...payload, processAt });
+
+      expect(task.status).toBe('scheduled');
+      expect(mockTranslationQueue.add).toHaveBeenCalledWith(
+        'translate-json',
+        { taskId: task.id },
+        expect.objectContaining({ jobId: task.id, delay: expect.any(Number) }),
+      );
+      expect(mockTranslationQueue.add.mock.calls[0][2].delay).toBeGreaterThan(0);
+    });
+
+    it('指定 cron 时应注册周期任务', async () => {
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+      mockQuotaService.assertWithinQuota.mockResolvedValue(quota);
+      mockEntityManager.create.mockImplementationOnce((_entity, data) => data).mockImplementationOnce((_entity, data) => data);
+
+      const { task } = await service.createTranslationTask('user123', {
+        ...payload,
+        cron: '0 2 * * *',
+        timezone: 'Asia/Shanghai',
+      });
+
+      expect(task.cron).toBe('0 2 * * *');
+      expect(mockTranslationQueue.add).toHaveBeenCalledWith(
+        'translate-json',
+        { taskId: task.id },
+        expect.objectContaining({ jobId: task.id, repeat: { cron: '0 2 * * *', tz: 'Asia/Shanghai' } }),
+      );
+    });
+
+    it('cron 间隔过短或与 processAt 同时指定时应返回 400', async () => {
+      mockTranslationUtils.countJsonChars.mockReturnValue(5);
+
+      await expect(service.createTranslationTask('user123', { ...payload, cron: '*/5 * * * *' })).rejects.toThrow(
+        'cannot run more often',
+      );
+      await expect(
+        service.createTranslationTask('user123', { ...payload, cron: '0 2 * * *', processAt: new Date().toISOString() }),
+      ).rejects.toThrow('processAt and cron cannot be used together');
+      expect(mockTranslationQueue.add).not.toHaveBeenCalled();
+    });
+
+    it('非法 JSON 应返回 400', async () => {
+      await expect(
+        service.createTranslationTask('user123', { ...payload, jsonContentRaw: '{invalid' }),
+      ).rejects.toThrow('Invalid JSON content');
+    });
+  });
+
+  describe('estimateTranslation', () => {
+    it('应返回字符数、额度和预计完成时间，且不创建任务', async () => {
+      mockTranslationUtils.getIgnoredFields.mockReturnValue([]);
+      mockTranslationUtils.countJsonChars.mockResolvedValue(4000);
+      mockQuotaService.check.mockResolvedValue({
+        allowed: true, mode: 'hard', used: 0, requested: 4000, limit: 10000, remaining: 10000,
+        overage: 0, capped: false, warnings: [],
+      });
+      mockTranslationQueue.getJobCounts.mockResolvedValue({ waiting: 2, active: 1, delayed: 0 });
+
+      const estimate = await service.estimateTranslation('user123', {
+        jsonContentRaw: '{"text": "hello"}',
+        fromLang: 'en',
+        toLang: 'zh',
+      });
+
+      expect(estimate).toMatchObject({ charTotal: 4000, fitsQuota: true, queuedTasks: 3, estimatedSeconds: 17 });
+      expect(mockTranslationQueue.add).not.toHaveBeenCalled();
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
     });
   });
 
@@ -132,12 +774,1084 @@ describe('TranslationService', () => {
 
       expect(mockEntityManager.persistAndFlush).toHaveBeenCalled();
       expect(mockTask.isTranslated).toBe(true);
+      expect(mockUsageRollupService.publish).toHaveBeenCalledWith(
+        expect.objectContaining({ taskId, userId: 'user123', characters: 100 }),
+      );
+      expect(mockQualityEstimationService.schedule).toHaveBeenCalledWith(taskId, 100);
+      expect(mockTranslationCheckpointService.clear).toHaveBeenCalledWith(mockUserData);
     });
 
-    it('当任务不存在时应该抛出错误', async () => {
-      mockEntityManager.findOne.mockResolvedValue(null);
+    it('配置了 webhook 时应把结果推送加入持久化队列', async () => {
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ id: 'task123', userId: 'user123', charTotal: 10 })
+        .mockResolvedValueOnce({ id: 'task123', originJson: '{}', fromLang: 'en', toLang: 'zh' });
+      mockTranslationUtils.translateJson.mockResolvedValue('{}');
+      mockWebhookService.resolveDeliveryConfig.mockResolvedValue({ id: 'hook1', webhookUrl: 'https://example.com' });
 
-      await expect(service.handleTranslationTask('nonexistent')).rejects.toThrow('Translation task not found');
+      await service.handleTranslationTask('task123');
+
+      expect(mockWebhookQueue.add).toHaveBeenCalledWith(
+        'deliver-translation-result',
+        { userId: 'user123', tenantId: undefined, taskId: 'task123' },
+        expect.objectContaining({ jobId: 'deliver-translation-result:task123', attempts: 3 }),
+      );
+    });
+
+    it('创建时指定 suppressWebhook 的任务不应推送结果', async () => {
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ id: 'task123', userId: 'user123', charTotal: 10, suppressWebhook: true })
+        .mockResolvedValueOnce({ id: 'task123', originJson: '{}', fromLang: 'en', toLang: 'zh' });
+      mockTranslationUtils.translateJson.mockResolvedValue('{}');
+      mockWebhookService.resolveDeliveryConfig.mockResolvedValue({ id: 'hook1', webhookUrl: 'https://example.com' });
+
+      await service.handleTranslationTask('task123');
+
+      expect(mockWebhookQueue.add).not.toHaveBeenCalled();
+      expect(mockNotificationChannelService.publish).not.toHaveBeenCalled();
+    });
+
+    it('完成时应通知订阅了 translation.completed 的通知渠道', async () => {
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ id: 'task123', userId: 'user123', tenantId: 't1', charTotal: 10 })
+        .mockResolvedValueOnce({ id: 'task123', originJson: '{}', fromLang: 'en', toLang: 'zh' });
+      mockTranslationUtils.translateJson.mockResolvedValue('{}');
+
+      await service.handleTranslationTask('task123');
+
+      expect(mockNotificationChannelService.publish).toHaveBeenCalledWith(
+        'user123',
+        't1',
+        'translation.completed',
+        expect.objectContaining({ taskId: 'task123', fromLang: 'en', toLang: 'zh', partial: false }),
+      );
+    });
+
+    it('译文违反 blocking 检查规则时应暂停结果推送', async () => {
+      const mockUserData: any = { id: 'task123', originJson: '{}', fromLang: 'en', toLang: 'ja' };
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ id: 'task123', userId: 'user123', charTotal: 10 })
+        .mockResolvedValueOnce(mockUserData);
+      mockTranslationUtils.translateJson.mockResolvedValue('{}');
+      mockWebhookService.resolveDeliveryConfig.mockResolvedValue({ id: 'hook1', webhookUrl: 'https://example.com' });
+      mockTranslationLintService.lint.mockImplementationOnce(async (document) => {
+        document.lintReport = { passed: false, blocking: true };
+      });
+
+      await service.handleTranslationTask('task123');
+
+      expect(mockTranslationLintService.lint).toHaveBeenCalledWith(mockUserData);
+      expect(mockWebhookQueue.add).not.toHaveBeenCalled();
+    });
+
+    it('周期任务重新翻译时应保留锁定键的人工译文', async () => {
+      const mockUserData = {
+        id: 'task123',
+        originJson: '{"title":"Hello","nav":{"home":"Home"}}',
+        fromLang: 'en',
+        toLang: 'zh',
+        translatedJson: '{"title":"您好","nav":{"home":"首页"}}',
+        lockedKeys: ['nav.home', 'removed'],
+      };
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ id: 'task123', userId: 'user123', cron: '0 * * * *', charTotal: 10 })
+        .mockResolvedValueOnce(mockUserData);
+      mockTranslationUtils.translateJson.mockResolvedValue('{"title":"你好","nav":{"home":"主页"}}');
+
+      await service.handleTranslationTask('task123');
+
+      expect(JSON.parse(mockUserData.translatedJson)).toEqual({ title: '你好', nav: { home: '首页' } });
+      expect(mockTranslationReviewService.resetAfterTranslation).toHaveBeenCalledWith(mockUserData);
+    });
+
+    it('应按文档声明的占位符语法翻译，并记录译文中丢失的占位符', async () => {
+      const mockUserData: any = {
+        id: 'task123',
+        originJson: '{"greeting":"Hi %s","count":"%d items"}',
+        fromLang: 'en',
+        toLang: 'de',
+        placeholderStyles: ['printf'],
+      };
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ id: 'task123', userId: 'user123', charTotal: 10 })
+        .mockResolvedValueOnce(mockUserData);
+      mockTranslationUtils.translateJson.mockResolvedValue('{"greeting":"Hallo %s","count":"Artikel"}');
+
+      await service.handleTranslationTask('task123');
+
+      expect(mockTranslationUtils.translateJson).toHaveBeenCalledWith(
+        expect.any(String),
+        'en',
+        'de',
+        '',
+        expect.objectContaining({ placeholderStyles: ['printf'] }),
+      );
+      expect(mockUserData.placeholderIssues).toEqual({ count: ['%d'] });
+    });
+
+    it('部分键重试用尽时应记录未翻译的键', async () => {
+      const mockTask = { id: 'task123', userId: 'user123', status: 'pending', charTotal: 10 };
+      const mockUserData: any = { id: 'task123', originJson: '{"a":"Hi","b":"Bye"}', fromLang: 'en', toLang: 'fr' };
+      mockEntityManager.findOne.mockResolvedValueOnce(mockTask).mockResolvedValueOnce(mockUserData);
+      mockTranslationUtils.translateJson.mockImplementationOnce(async (_json, _from, _to, _ignored, options) => {
+        options.untranslatedKeys.push('b');
+        options.failedKeys.b = { reason: 'provider_unavailable', message: 'connect failed' };
+        return '{"a":"Salut","b":"Bye"}';
+      });
+
+      await service.handleTranslationTask('task123');
+
+      expect(mockUserData.untranslatedKeys).toEqual(['b']);
+      expect(mockUserData.failedKeys).toEqual({ b: { reason: 'provider_unavailable', message: 'connect failed' } });
+      expect(mockTask).toMatchObject({ isTranslated: true, status: 'partial' });
+    });
+
+    it('超大文档应切分为分片子任务，而不是整体翻译', async () => {
+      const mockTask = { id: 'task123', userId: 'user123', status: 'pending', priority: 'low', charTotal: 90000 };
+      mockEntityManager.findOne
+        .mockResolvedValueOnce(mockTask)
+        .mockResolvedValueOnce({ id: 'task123', originJson: '{}', fromLang: 'en', toLang: 'zh' });
+      mockTranslationChunkService.shouldChunk.mockReturnValueOnce(true);
+      mockTranslationChunkService.split.mockResolvedValueOnce(4);
+
+      await service.handleTranslationTask('task123');
+
+      expect(mockTranslationChunkService.split).toHaveBeenCalledWith(mockTask, expect.anything(), 5);
+      expect(mockTranslationUtils.translateJson).not.toHaveBeenCalled();
+      expect(mockUsageRollupService.publish).not.toHaveBeenCalled();
+    });
+
+    it('最后一个分片完成时应保存合并后的译文并记账', async () => {
+      const mockTask = { id: 'task123', userId: 'user123', status: 'pending', charTotal: 90000 };
+      const mockUserData: any = { id: 'task123', originJson: '{}', fromLang: 'en', toLang: 'zh' };
+      mockEntityManager.findOne.mockResolvedValueOnce(mockTask).mockResolvedValueOnce(mockUserData);
+      mockTranslationChunkService.translateChunk.mockResolvedValueOnce('{"a":"你好"}');
+
+      await service.handleTranslationChunk({ taskId: 'task123', chunkId: 'chunk1' });
+
+      expect(mockUserData.translatedJson).toBe('{"a":"你好"}');
+      expect(mockUsageRollupService.publish).toHaveBeenCalledWith(expect.objectContaining({ characters: 90000 }));
+      expect(mockTranslationChunkService.translateChunk).toHaveBeenCalledWith(
+        'chunk1',
+        mockUserData,
+        expect.objectContaining({ completed: {} }),
+      );
+      expect(mockTranslationCheckpointService.clear).toHaveBeenCalledWith(mockUserData);
+    });
+
+    it('完成时把准入预留随用量事件结算，并清除任务上的预留', async () => {
+      const reservedAt = new Date('2026-10-16T08:00:00.000Z');
+      const mockTask: any = {
+        id: 'task123',
+        userId: 'user123',
+        status: 'pending',
+        charTotal: 90000,
+        quotaReserved: 90000,
+        quotaReservedAt: reservedAt,
+      };
+      const mockUserData: any = { id: 'task123', originJson: '{}', fromLang: 'en', toLang: 'zh' };
+      mockEntityManager.findOne.mockResolvedValueOnce(mockTask).mockResolvedValueOnce(mockUserData);
+      mockTranslationChunkService.translateChunk.mockResolvedValueOnce('{"a":"你好"}');
+
+      await service.handleTranslationChunk({ taskId: 'task123', chunkId: 'chunk1' });
+
+      expect(mockUsageRollupService.publish).toHaveBeenCalledWith(
+        expect.objectContaining({ characters: 90000, reserved: 90000, reservedAt: reservedAt.toISOString() }),
+      );
+      expect(mockTask.quotaReserved).toBeUndefined();
+      expect(mockQuotaService.release).not.toHaveBeenCalled();
+    });
+
+    it('账号锁定时应暂停任务而不调用翻译', async () => {
+      const mockTask = { id: 'task123', userId: 'user123', status: 'pending', charTotal: 10 };
+      mockEntityManager.findOne
+        .mockResolvedValueOnce(mockTask)
+        .mockResolvedValueOnce({ id: 'task123', originJson: '{}', fromLang: 'en', toLang: 'zh' });
+      mockAccountLockdownService.isLocked.mockResolvedValueOnce(true);
+
+      await service.handleTranslationTask('task123');
+
+      expect(mockTask.status).toBe('paused');
+      expect(mockTranslationUtils.translateJson).not.toHaveBeenCalled();
+    });
+
+    it('当任务不存在时应该抛出错误', async () => {
+      mockEntityManager.findOne.mockResolvedValue(null);
+
+      await expect(service.handleTranslationTask('nonexistent')).rejects.toThrow('Translation task not found');
+    });
+  });
+
+  describe('handleTaskFailure', () => {
+    it('应标记任务失败并发送带重试建议的 translation.failed 事件', async () => {
+      const task = { id: 'task1', userId: 'user123', tenantId: 't1', status: 'pending' };
+      mockEntityManager.findOne.mockResolvedValueOnce(task);
+
+      await service.handleTaskFailure('task1', Object.assign(new Error('connect'), { code: 'ECONNREFUSED' }), 3, 'c1');
+
+      expect(task).toMatchObject({ status: 'failed', failureReason: 'provider_unavailable' });
+      expect(mockWebhookService.dispatchEvent).toHaveBeenCalledWith(
+        'user123',
+        't1',
+        'translation.failed',
+        expect.objectContaining({
+          taskId: 'task1',
+          reason: 'provider_unavailable',
+          attempts: 3,
+          retry: expect.objectContaining({
+            retryable: true,
+            endpoint: '/api/v1/translation/task/task1/chunks/retry',
+          }),
+        }),
+      );
+    });
+
+    it('suppressWebhook 的任务只标记失败，不发送事件', async () => {
+      const task = { id: 'task1', userId: 'user123', status: 'pending', suppressWebhook: true };
+      mockEntityManager.findOne.mockResolvedValueOnce(task);
+
+      await service.handleTaskFailure('task1', new Error('boom'), 3);
+
+      expect(task.status).toBe('failed');
+      expect(mockWebhookService.dispatchEvent).not.toHaveBeenCalled();
+    });
+
+    it('失败时释放准入预留的额度', async () => {
+      const reservedAt = new Date('2026-10-16T08:00:00.000Z');
+      const task: any = {
+        id: 'task1',
+        userId: 'user123',
+        status: 'pending',
+        quotaReserved: 40,
+        quotaReservedAt: reservedAt,
+      };
+      mockEntityManager.findOne.mockResolvedValueOnce(task);
+
+      await service.handleTaskFailure('task1', new Error('boom'), 3);
+
+      expect(mockQuotaService.release).toHaveBeenCalledWith('user123', 40, reservedAt);
+      expect(task.quotaReserved).toBeUndefined();
+      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith(task);
+    });
+
+    it('已标记失败的任务不应重复通知', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce({ id: 'task1', userId: 'user123', status: 'failed' });
+
+      await service.handleTaskFailure('task1', new Error('boom'), 3);
+
+      expect(mockWebhookService.dispatchEvent).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('cancelScheduledTask', () => {
+    it('应移除延迟任务并标记为已取消', async () => {
+      const task = { id: 'task1', userId: 'user123', status: 'scheduled', scheduledAt: new Date() };
+      const job = { remove: jest.fn() };
+      mockEntityManager.findOne.mockResolvedValueOnce(task);
+      mockTranslationQueue.getJob.mockResolvedValueOnce(job);
+
+      await service.cancelScheduledTask('user123', 'task1');
+
+      expect(job.remove).toHaveBeenCalled();
+      expect(task.status).toBe('canceled');
+    });
+
+    it('取消时释放准入预留的额度', async () => {
+      const reservedAt = new Date('2026-10-16T08:00:00.000Z');
+      const task: any = {
+        id: 'task1',
+        userId: 'user123',
+        status: 'scheduled',
+        scheduledAt: new Date(),
+        quotaReserved: 40,
+        quotaReservedAt: reservedAt,
+      };
+      mockEntityManager.findOne.mockResolvedValueOnce(task);
+      mockTranslationQueue.getJob.mockResolvedValueOnce({ remove: jest.fn() });
+
+      await service.cancelScheduledTask('user123', 'task1');
+
+      expect(mockQuotaService.release).toHaveBeenCalledWith('user123', 40, reservedAt);
+      expect(task.quotaReserved).toBeUndefined();
+    });
+
+    it('应移除周期任务的重复规则', async () => {
+      const task = { id: 'task1', userId: 'user123', status: 'scheduled', cron: '0 2 * * *', timezone: 'UTC' };
+      mockEntityManager.findOne.mockResolvedValueOnce(task);
+
+      await service.cancelScheduledTask('user123', 'task1');
+
+      expect(mockTranslationQueue.removeRepeatable).toHaveBeenCalledWith('translate-json', {
+        cron: '0 2 * * *',
+        tz: 'UTC',
+        jobId: 'task1',
+      });
+    });
+
+    it('定时任务不存在时应返回 404', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce(null);
+
+      await expect(service.cancelScheduledTask('user123', 'missing')).rejects.toThrow('Scheduled translation not found');
+    });
+  });
+
+  describe('rerankQueuedTasks', () => {
+    const queuedJob = (taskId: string, state: string, opts: Record<string, any> = {}, timestamp = Date.now()) => ({
+      name: 'translate-json',
+      data: { taskId },
+      opts,
+      timestamp,
+      getState: jest.fn().mockResolvedValue(state),
+      remove: jest.fn(),
+    });
+
+    it('升级后应按新优先级重新加入排队中的任务，保留原任务参数', async () => {
+      const upgraded = { id: 't1', userId: 'user123', charTotal: 10, priority: 'default' };
+      const lowered = { id: 't2', userId: 'user123', charTotal: 10, priority: 'low', requestedPriority: 'low' };
+      mockEntityManager.find.mockResolvedValueOnce([upgraded, lowered]);
+      mockQueuePriorityService.resolve
+        .mockResolvedValueOnce({ priority: 'critical', tier: 'standard', queuePriority: 1 })
+        .mockResolvedValueOnce({ priority: 'low', tier: 'standard', queuePriority: 10 });
+      mockQueuePriorityService.weightOf.mockReturnValueOnce(1);
+      const waiting = queuedJob('t1', 'waiting', { attempts: 3 });
+      const active = queuedJob('t1', 'active');
+      const other = queuedJob('t2', 'waiting');
+      mockTranslationQueue.getJobs.mockResolvedValueOnce([waiting, active, other]);
+      const order: string[] = [];
+      mockTranslationQueue.add.mockImplementationOnce(async () => {
+        order.push('add');
+        return { remove: jest.fn() };
+      });
+      waiting.remove.mockImplementationOnce(async () => {
+        order.push('remove');
+      });
+
+      const result = await service.rerankQueuedTasks('user123');
+
+      expect(mockQueuePriorityService.resolve).toHaveBeenCalledWith('user123', 10, 'low');
+      expect(upgraded.priority).toBe('critical');
+      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith([upgraded]);
+      expect(active.remove).not.toHaveBeenCalled();
+      expect(other.remove).not.toHaveBeenCalled();
+      expect(mockTranslationQueue.add).toHaveBeenCalledTimes(1);
+      expect(mockTranslationQueue.add).toHaveBeenCalledWith(
+        'translate-json',
+        { taskId: 't1' },
+        { attempts: 3, delay: 0, priority: 1 },
+      );
+      // 先加入新任务再移除旧任务
+      expect(order).toEqual(['add', 'remove']);
+      expect(result).toEqual({ reranked: 1 });
+    });
+
+    it('定时任务保留剩余的延迟，并换用交替的任务 ID', async () => {
+      const task = { id: 't1', userId: 'user123', charTotal: 10, priority: 'default' };
+      mockEntityManager.find.mockResolvedValueOnce([task]);
+      mockQueuePriorityService.resolve.mockResolvedValueOnce({ priority: 'critical' });
+      mockQueuePriorityService.weightOf.mockReturnValueOnce(1).mockReturnValueOnce(1);
+      const scheduled = queuedJob('t1', 'delayed', { jobId: 't1', delay: 60_000 }, Date.now() - 20_000);
+      mockTranslationQueue.getJobs.mockResolvedValueOnce([scheduled]);
+      mockTranslationQueue.add.mockResolvedValueOnce({ remove: jest.fn() });
+
+      await service.rerankQueuedTasks('user123');
+
+      const [, , options] = mockTranslationQueue.add.mock.calls[0];
+      expect(options.jobId).toBe('t1:reranked');
+      expect(options.delay).toBeGreaterThan(39_000);
+      expect(options.delay).toBeLessThanOrEqual(40_000);
+      expect(scheduled.remove).toHaveBeenCalled();
+
+      // 再次重排时换回原 ID
+      task.priority = 'default';
+      mockEntityManager.find.mockResolvedValueOnce([task]);
+      mockQueuePriorityService.resolve.mockResolvedValueOnce({ priority: 'critical' });
+      mockTranslationQueue.getJobs.mockResolvedValueOnce([
+        queuedJob('t1', 'delayed', { ...options, delay: 1_000 }, Date.now() - 5_000),
+      ]);
+      mockTranslationQueue.add.mockResolvedValueOnce({ remove: jest.fn() });
+
+      await service.rerankQueuedTasks('user123');
+
+      expect(mockTranslationQueue.add.mock.calls[1][2]).toMatchObject({ jobId: 't1', delay: 0 });
+    });
+
+    it('旧任务移除失败时撤回新加入的任务', async () => {
+      mockEntityManager.find.mockResolvedValueOnce([{ id: 't1', charTotal: 10, priority: 'default' }]);
+      mockQueuePriorityService.resolve.mockResolvedValueOnce({ priority: 'critical' });
+      const waiting = queuedJob('t1', 'waiting');
+      waiting.remove.mockRejectedValueOnce(new Error('Job is locked'));
+      mockTranslationQueue.getJobs.mockResolvedValueOnce([waiting]);
+      const replacement = { remove: jest.fn().mockResolvedValue(undefined) };
+      mockTranslationQueue.add.mockResolvedValueOnce(replacement);
+
+      await expect(service.rerankQueuedTasks('user123')).resolves.toEqual({ reranked: 0 });
+      expect(replacement.remove).toHaveBeenCalled();
+    });
+
+    it('优先级没有变化时不扫描队列', async () => {
+      mockEntityManager.find.mockResolvedValueOnce([{ id: 't1', charTotal: 10, priority: 'default' }]);
+
+      await expect(service.rerankQueuedTasks('user123')).resolves.toEqual({ reranked: 0 });
+      expect(mockTranslationQueue.getJobs).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('retryFailedKeys', () => {
+    const partial = () => ({
+      task: { id: 'task1', userId: 'user1', status: 'partial', isTranslated: true, priority: 'default' } as any,
+      userData: {
+        id: 'task1',
+        userId: 'user1',
+        fromLang: 'en',
+        toLang: 'fr',
+        originJson: '{"a":"Hi","b":"Bye","c":"See you"}',
+        translatedJson: '{"a":"Salut","b":"Bye","c":"See you"}',
+        untranslatedKeys: ['b', 'c'],
+        failedKeys: {
+          b: { reason: 'provider_unavailable', message: 'connect failed' },
+          c: { reason: 'provider_unavailable', message: 'connect failed' },
+        },
+      } as any,
+    });
+
+    it('应只提交未锁定的失败键', async () => {
+      const { task, userData } = partial();
+      userData.lockedKeys = ['c'];
+      mockEntityManager.findOne.mockResolvedValueOnce(task).mockResolvedValueOnce(userData);
+
+      const result = await service.retryFailedKeys('user1', 'task1');
+
+      expect(result).toEqual({ retried: 1 });
+      expect(mockTranslationQueue.add).toHaveBeenCalledWith(
+        'retry-failed-keys',
+        { taskId: 'task1' },
+        expect.objectContaining({ jobId: 'task1:retry-keys' }),
+      );
+    });
+
+    it('没有失败的键时应返回 409', async () => {
+      const { task, userData } = partial();
+      userData.untranslatedKeys = undefined;
+      mockEntityManager.findOne.mockResolvedValueOnce(task).mockResolvedValueOnce(userData);
+
+      await expect(service.retryFailedKeys('user1', 'task1')).rejects.toThrow('Translation has no failed keys');
+      expect(mockTranslationQueue.add).not.toHaveBeenCalled();
+    });
+
+    it('补译成功的键写回译文，全部成功后任务变为 completed', async () => {
+      const { task, userData } = partial();
+      mockEntityManager.findOne.mockResolvedValueOnce(task).mockResolvedValueOnce(userData);
+      mockTranslationUtils.retranslateKeys.mockImplementationOnce(async (_source, translated, keys) => {
+        translated.b = 'Au revoir';
+        translated.c = 'À bientôt';
+        return keys;
+      });
+
+      await service.handleFailedKeysRetry('task1');
+
+      expect(mockTranslationUtils.retranslateKeys).toHaveBeenCalledWith(
+        expect.anything(),
+        expect.anything(),
+        ['b', 'c'],
+        'en',
+        'fr',
+        expect.anything(),
+      );
+      expect(JSON.parse(userData.translatedJson)).toEqual({ a: 'Salut', b: 'Au revoir', c: 'À bientôt' });
+      expect(userData.untranslatedKeys).toBeUndefined();
+      expect(userData.failedKeys).toBeUndefined();
+      expect(task.status).toBe('completed');
+      expect(mockQualityEstimationService.forgetKeys).toHaveBeenCalledWith(userData, ['b', 'c']);
+    });
+
+    it('仍有失败的键时保持 partial 并更新失败原因', async () => {
+      const { task, userData } = partial();
+      mockEntityManager.findOne.mockResolvedValueOnce(task).mockResolvedValueOnce(userData);
+      mockTranslationUtils.retranslateKeys.mockImplementationOnce(async (...args) => {
+        const [, translated, , , , options] = args;
+        translated.b = 'Au revoir';
+        options.untranslatedKeys.push('c');
+        options.failedKeys.c = { reason: 'invalid_input', message: 'Invalid text' };
+        return ['b'];
+      });
+
+      await service.handleFailedKeysRetry('task1');
+
+      expect(userData.untranslatedKeys).toEqual(['c']);
+      expect(userData.failedKeys).toEqual({ c: { reason: 'invalid_input', message: 'Invalid text' } });
+      expect(task.status).toBe('partial');
+    });
+
+    it('文档正在人工编辑时补译延后到锁过期之后', async () => {
+      const { task, userData } = partial();
+      mockEntityManager.findOne.mockResolvedValueOnce(task).mockResolvedValueOnce(userData);
+      const expiresAt = new Date(Date.now() + 60000).toISOString();
+      mockDocumentLockService.get.mockResolvedValueOnce({ documentId: 'task1', token: 't', expiresAt });
+
+      await service.handleFailedKeysRetry('task1');
+
+      expect(mockTranslationUtils.retranslateKeys).not.toHaveBeenCalled();
+      expect(mockTranslationQueue.add).toHaveBeenCalledWith(
+        'retry-failed-keys',
+        { taskId: 'task1' },
+        expect.objectContaining({
+          jobId: `task1:after-lock:retry-failed-keys:${new Date(expiresAt).getTime()}`,
+          delay: expect.any(Number),
+        }),
+      );
+    });
+  });
+
+  describe('document lock', () => {
+    it('解锁后应立即执行编辑期间延后的重新翻译', async () => {
+      const expiresAt = '2026-10-16T08:15:00.000Z';
+      mockEntityManager.findOne.mockResolvedValueOnce({ id: 'task1', userId: 'user1', isTranslated: true });
+      mockDocumentLockService.get.mockResolvedValueOnce({ documentId: 'task1', token: 't', expiresAt });
+      const deferred = { isDelayed: jest.fn().mockResolvedValue(true), promote: jest.fn() };
+      mockTranslationQueue.getJob.mockResolvedValueOnce(deferred).mockResolvedValueOnce(null);
+
+      await service.unlockDocument('user1', 'task1', 't');
+
+      expect(mockDocumentLockService.release).toHaveBeenCalledWith('task1', 't', false);
+      expect(mockTranslationQueue.getJob).toHaveBeenCalledWith(
+        `task1:after-lock:translate-json:${new Date(expiresAt).getTime()}`,
+      );
+      expect(deferred.promote).toHaveBeenCalled();
+    });
+
+    it('查询锁时不返回 token', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce({ id: 'task1', userId: 'user1' });
+      mockDocumentLockService.get.mockResolvedValueOnce({ documentId: 'task1', token: 't', owner: 'alice' });
+
+      const lock = await service.getDocumentLock('user1', 'task1');
+
+      expect(lock).toMatchObject({ documentId: 'task1', owner: 'alice' });
+      expect(lock.token).toBeUndefined();
+    });
+
+    it('未完成的翻译不能加锁', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce({ id: 'task1', userId: 'user1', isTranslated: false });
+
+      await expect(service.lockDocument('user1', 'task1', {})).rejects.toThrow('Translation has not finished yet');
+      expect(mockDocumentLockService.acquire).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('getTaskResult', () => {
+    it('已归档的文档应从归档取回并标记 archived', async () => {
+      const archivedAt = new Date('2026-01-01');
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ id: 'task1', userId: 'user1', status: 'pending', isTranslated: true })
+        .mockResolvedValueOnce({ id: 'task1', fromLang: 'en', toLang: 'zh', originJson: '', archivedAt });
+      mockDocumentArchiveService.load.mockResolvedValueOnce({
+        originJson: '{"a":"hi"}',
+        translatedJson: '{"a":"你好"}',
+        archived: true,
+      });
+
+      const result = await service.getTaskResult('user1', 'task1');
+
+      expect(result).toMatchObject({ translatedJson: '{"a":"你好"}', archived: true, archivedAt });
+    });
+
+    it('应按输出键形态转换译文，查询参数可覆盖创建时的设置', async () => {
+      const userData = { id: 'task1', fromLang: 'en', toLang: 'de', outputKeyFormat: 'suffix' };
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ id: 'task1', userId: 'user1', status: 'pending', isTranslated: true })
+        .mockResolvedValueOnce(userData)
+        .mockResolvedValueOnce({ id: 'task1', userId: 'user1', status: 'pending', isTranslated: true })
+        .mockResolvedValueOnce(userData);
+      const content = {
+        originJson: '{"nav":{"home":"Home"}}',
+        translatedJson: '{"nav":{"home":"Startseite"}}',
+        archived: false,
+      };
+      mockDocumentArchiveService.load.mockResolvedValueOnce(content).mockResolvedValueOnce(content);
+
+      const stored = await service.getTaskResult('user1', 'task1');
+      const merged = await service.getTaskResult('user1', 'task1', { keyFormat: OutputKeyFormat.MERGED });
+
+      expect(JSON.parse(stored.translatedJson)).toEqual({ nav: { home_de: 'Startseite' } });
+      expect(JSON.parse(merged.translatedJson)).toEqual({ nav: { home: { en: 'Home', de: 'Startseite' } } });
+      expect(merged.outputKeyFormat).toBe(OutputKeyFormat.MERGED);
+    });
+
+    it('validate 时应重新校验译文并按键标注问题', async () => {
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ id: 'task1', userId: 'user1', status: 'pending', isTranslated: true })
+        .mockResolvedValueOnce({
+          id: 'task1',
+          fromLang: 'en',
+          toLang: 'de',
+          originJson: '{"a":"Hi {name}","b":"Bye"}',
+          translatedJson: '{"a":"Hallo"}',
+        });
+
+      const result = await service.getTaskResult('user1', 'task1', { validate: true });
+
+      expect(result.validation.valid).toBe(false);
+      expect(Object.keys(result.validation.issues)).toEqual(['a', 'b']);
+      expect(result.validation.issues.a[0].type).toBe('missing_placeholder');
+    });
+
+    it('绑定项目的密钥不能读取其他项目的任务', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce(null);
+      const apiKey = { id: 'key1', userId: 'user1', delegated: true, project: 'web', defaults: {} };
+
+      await expect(service.getTaskResult('user1', 'task1', {}, apiKey)).rejects.toThrow(NotFoundException);
+      expect(mockEntityManager.findOne).toHaveBeenCalledWith(TranslationTask, {
+        id: 'task1',
+        userId: 'user1',
+        project: 'web',
+      });
+    });
+
+    it('试运行密钥只能读取自己创建的任务', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce(null);
+      const apiKey = { id: 'key1', userId: 'demo', delegated: false, defaults: {}, playground: true };
+
+      await expect(service.getTaskResult('demo', 'task1', {}, apiKey)).rejects.toThrow(NotFoundException);
+      expect(mockEntityManager.findOne).toHaveBeenCalledWith(
+        TranslationTask,
+        expect.objectContaining({ id: 'task1', userId: 'demo', apiKeyId: 'key1' }),
+      );
+    });
+  });
+
+  describe('getValidationReport', () => {
+    it('没有保存的报告时应补做校验并保存', async () => {
+      const userData: any = {
+        id: 'task1',
+        userId: 'user1',
+        originJson: '{"a":"<b>Hello</b> world","b":"Bye"}',
+        translatedJson: '{"a":"<b>Hallo Welt","b":""}',
+      };
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ id: 'task1', userId: 'user1', isTranslated: true })
+        .mockResolvedValueOnce(userData);
+
+      const report = await service.getValidationReport('user1', 'task1');
+
+      expect(report.valid).toBe(false);
+      expect(report.issues.a.map((issue) => issue.type)).toEqual(['missing_placeholder', 'unbalanced_html']);
+      expect(report.issues.b.map((issue) => issue.type)).toEqual(['empty_target']);
+      expect(report.summary).toEqual({ missing_placeholder: 1, unbalanced_html: 1, empty_target: 1 });
+      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith(userData);
+    });
+
+    it('翻译未完成时应返回 409', async () => {
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ id: 'task1', userId: 'user1', isTranslated: false })
+        .mockResolvedValueOnce({ id: 'task1' });
+
+      await expect(service.getValidationReport('user1', 'task1')).rejects.toThrow('Translation has not finished yet');
+    });
+  });
+
+  describe('getKeyUsage', () => {
+    it('没有统计记录时按任务内容补算并保存，depth 不超过统计时的层级', async () => {
+      const userData: any = { id: 'task1', userId: 'user1', fromLang: 'en', toLang: 'zh', charTotal: 12 };
+      mockEntityManager.findOne
+        .mockResolvedValueOnce({ id: 'task1', userId: 'user1', content: '{"nav":{"home":"Home","about":"About us"}}' })
+        .mockResolvedValueOnce(userData);
+      mockTranslationUtils.countJsonChars.mockImplementationOnce((_json, config) => {
+        recordKeyUsage(config.keyUsage, ['nav', 'home'], 4);
+        recordKeyUsage(config.keyUsage, ['nav', 'about'], 8);
+        return 12;
+      });
+
+      const report = await service.getKeyUsage('user1', 'task1', 3);
+
+      expect(report).toEqual({
+        id: 'task1',
+        charTotal: 12,
+        depth: 1,
+        keys: [{ key: 'nav', path: ['nav'], characters: 12, percent: 100 }],
+      });
+      expect(userData).toMatchObject({ keyUsage: { '["nav"]': 12 }, keyUsageDepth: 1 });
+      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith(userData);
+    });
+  });
+
+  describe('updateTranslationKeys', () => {
+    const translated = () => ({
+      task: { id: 'task1', userId: 'user1', status: 'pending', isTranslated: true },
+      userData: { id: 'task1', userId: 'user1', translatedJson: '{"nav":{"home":"主页","about":"关于"}}' } as any,
+    });
+
+    it('修改译文时默认锁定该键，可单独解锁', async () => {
+      const { task, userData } = translated();
+      userData.lockedKeys = ['nav.about'];
+      mockEntityManager.findOne.mockResolvedValueOnce(task).mockResolvedValueOnce(userData);
+
+      const result = await service.updateTranslationKeys('user1', 'task1', {
+        keys: [
+          { key: 'nav.home', value: '首页' },
+          { key: 'nav.about', locked: false },
+        ],
+      });
+
+      expect(JSON.parse(result.translatedJson)).toEqual({ nav: { home: '首页', about: '关于' } });
+      expect(result.lockedKeys).toEqual(['nav.home']);
+      expect(mockTranslationReviewService.reopenAfterEdit).toHaveBeenCalledWith(userData, ['nav.home']);
+      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith(userData);
+    });
+
+    it('文档被锁定时应把持有的 token 交给锁校验', async () => {
+      const { task, userData } = translated();
+      mockEntityManager.findOne.mockResolvedValueOnce(task).mockResolvedValueOnce(userData);
+      mockDocumentLockService.assertEditable.mockRejectedValueOnce(new Error('Document is locked for editing'));
+
+      await expect(
+        service.updateTranslationKeys('user1', 'task1', { keys: [{ key: 'nav.home', value: '首页' }] }, 'stale'),
+      ).rejects.toThrow('Document is locked for editing');
+      expect(mockDocumentLockService.assertEditable).toHaveBeenCalledWith('task1', 'stale');
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+    });
+
+    it('修改后不再违反 blocking 检查规则时应恢复暂停的结果推送', async () => {
+      const { task, userData } = translated();
+      userData.lintReport = { passed: false, blocking: true };
+      mockEntityManager.findOne.mockResolvedValueOnce(task).mockResolvedValueOnce(userData);
+      mockTranslationLintService.lint.mockImplementationOnce(async (document) => {
+        document.lintReport = { passed: true, blocking: false };
+      });
+      mockWebhookService.resolveDeliveryConfig.mockResolvedValue({ id: 'hook1', webhookUrl: 'https://example.com' });
+
+      const result = await service.updateTranslationKeys('user1', 'task1', {
+        keys: [{ key: 'nav.home', value: '首页' }],
+      });
+
+      expect(result.lint).toEqual({ passed: true, blocking: false });
+      expect(mockWebhookQueue.add).toHaveBeenCalledWith(
+        'deliver-translation-result',
+        { userId: 'user1', tenantId: undefined, taskId: 'task1' },
+        expect.objectContaining({ jobId: 'deliver-translation-result:task1' }),
+      );
+    });
+
+    it('键不存在或指向对象时应拒绝', async () => {
+      const { task, userData } = translated();
+      mockEntityManager.findOne.mockResolvedValueOnce(task).mockResolvedValueOnce(userData);
+
+      await expect(
+        service.updateTranslationKeys('user1', 'task1', { keys: [{ key: 'nav', value: 'x' }] }),
+      ).rejects.toThrow('does not exist or is not a translated value');
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('listDocuments', () => {
+    it('应按标签和名称中的每个词过滤，并转义通配符', async () => {
+      mockEntityManager.findAndCount.mockResolvedValueOnce([
+        [{ id: 'doc1', name: 'checkout-page-v2', tags: ['web'], fromLang: 'en', toLang: 'zh' }],
+        1,
+      ]);
+
+      const result = await service.listDocuments('user1', { tag: 'web, mobile,web', q: ' checkout 100%_off ' });
+
+      // 标签和名称条件的 SQL 由 UserJsonDataRepository 按驱动生成，这里只检查传给查询的条件
+      const [, where, options] = mockEntityManager.findAndCount.mock.calls[0];
+      expect(where).toEqual({ userId: 'user1', $and: expect.any(Array) });
+      expect(where.$and).toHaveLength(3);
+      expect(options).toEqual(expect.objectContaining({ limit: 20, offset: 0 }));
+      expect(result.total).toBe(1);
+      expect(result.documents[0]).toMatchObject({ id: 'doc1', name: 'checkout-page-v2', tags: ['web'] });
+    });
+
+    it('绑定项目的密钥只列出该项目的文档', async () => {
+      mockEntityManager.findAndCount.mockResolvedValueOnce([[], 0]);
+      const apiKey = { id: 'key1', userId: 'user1', delegated: true, project: 'web', defaults: {} };
+
+      await service.listDocuments('user1', {}, 1, 20, {}, apiKey);
+
+      const [, where] = mockEntityManager.findAndCount.mock.calls[0];
+      expect(where).toEqual({ userId: 'user1', $and: [expect.any(Object)] });
+    });
+  });
+
+  describe('listDocuments with cursor', () => {
+    const doc = (id: string, charTotal: number) => ({
+      id,
+      charTotal,
+      fromLang: 'en',
+      toLang: 'zh',
+      createdAt: new Date('2026-10-01T00:00:00Z'),
+      updatedAt: new Date('2026-10-01T00:00:00Z'),
+    });
+
+    it('应按游标取下一页，并在还有更多时返回 nextCursor', async () => {
+      mockEntityManager.findAndCount.mockResolvedValueOnce([[doc('doc1', 900)], 3]);
+      const first = await service.listDocuments('user1', {}, 1, 1, { sort: DocumentSortField.CHAR_TOTAL });
+      expect(mockEntityManager.findAndCount).toHaveBeenCalledWith(
+        UserJsonData,
+        { userId: 'user1' },
+        expect.objectContaining({ orderBy: { charTotal: 'desc', id: 'desc' } }),
+      );
+      expect(first.nextCursor).toEqual(expect.any(String));
+
+      mockEntityManager.find.mockResolvedValueOnce([doc('doc2', 500), doc('doc3', 100)]);
+      mockEntityManager.count.mockResolvedValueOnce(3);
+      const second = await service.listDocuments('user1', {}, 1, 1, {
+        sort: DocumentSortField.CHAR_TOTAL,
+        cursor: first.nextCursor,
+      });
+
+      expect(mockEntityManager.find).toHaveBeenCalledWith(
+        UserJsonData,
+        {
+          userId: 'user1',
+          $and: [{ $or: [{ charTotal: { $lt: 900 } }, { charTotal: 900, id: { $lt: 'doc1' } }] }],
+        },
+        expect.objectContaining({ limit: 2 }),
+      );
+      expect(second.documents.map((document) => document.id)).toEqual(['doc2']);
+      expect(second.total).toBe(3);
+      expect(second.nextCursor).toEqual(expect.any(String));
+    });
+
+    it('游标与排序不一致时应返回 400', async () => {
+      mockEntityManager.findAndCount.mockResolvedValueOnce([[doc('doc1', 900)], 3]);
+      const { nextCursor } = await service.listDocuments('user1', {}, 1, 1);
+
+      await expect(
+        service.listDocuments('user1', {}, 1, 1, { sort: DocumentSortField.CHAR_TOTAL, cursor: nextCursor }),
+      ).rejects.toThrow(BadRequestException);
+    });
+  });
+
+  describe('updateDocumentMetadata', () => {
+    it('应只修改提供的字段，空名称表示清除', async () => {
+      const userData: any = { id: 'task1', userId: 'user1', name: 'old', tags: ['a'] };
+      mockEntityManager.findOne.mockResolvedValueOnce(userData);
+
+      const result = await service.updateDocumentMetadata('user1', 'task1', { name: ' ' });
+
+      expect(result).toEqual({ id: 'task1', name: null, tags: ['a'] });
+      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith(userData);
+    });
+
+    it('应去掉重复的标签', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce({ id: 'task1', userId: 'user1' });
+
+      const result = await service.updateDocumentMetadata('user1', 'task1', { tags: ['b', 'a', 'b'] });
+
+      expect(result.tags).toEqual(['b', 'a']);
+    });
+
+    it('绑定项目的密钥不能修改其他项目的文档', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce(null);
+      const apiKey = { id: 'key1', userId: 'user1', delegated: true, project: 'web', defaults: {} };
+
+      await expect(service.updateDocumentMetadata('user1', 'task1', { name: 'x' }, apiKey)).rejects.toThrow(
+        NotFoundException,
+      );
+      expect(mockEntityManager.persistAndFlush).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('deleteDocument', () => {
+    it('应删除已完成的任务及其原文和译文', async () => {
+      const task = { id: 'task1', userId: 'user1', status: 'pending', isTranslated: true };
+      const userData = { id: 'task1', userId: 'user1' };
+      mockEntityManager.findOne.mockResolvedValueOnce(task).mockResolvedValueOnce(userData);
+
+      await service.deleteDocument('user1', 'task1');
+
+      expect(mockDocumentArchiveService.discard).toHaveBeenCalledWith(userData);
+      expect(mockEntityManager.removeAndFlush).toHaveBeenCalledWith(userData);
+      expect(mockEntityManager.removeAndFlush).toHaveBeenCalledWith(task);
+    });
+
+    it('仍在处理中的任务不能删除', async () => {
+      mockEntityManager.findOne.mockResolvedValueOnce({ id: 'task1', status: 'pending', isTranslated: false });
+
+      await expect(service.deleteDocument('user1', 'task1')).rejects.toThrow('Translation is still in progress');
+      expect(mockEntityManager.removeAndFlush).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('resumeLockedWork', () => {
+    it('解锁后应重新入队暂停的任务和推迟的推送', async () => {
+      const paused = { id: 'task1', userId: 'user123', status: 'paused', priority: 'low' };
+      mockEntityManager.find.mockResolvedValueOnce([paused]);
+      mockRedisService.client.smembers.mockResolvedValueOnce([JSON.stringify({ userId: 'user123', taskId: 'task2' })]);
+
+      const result = await service.resumeLockedWork('user123');
+
+      expect(result).toEqual({ tasks: 1, deliveries: 1 });
+      expect(paused.status).toBe('pending');
+      expect(mockTranslationQueue.add).toHaveBeenCalledWith(
+        'translate-json',
+        { taskId: 'task1' },
+        expect.objectContaining({ priority: 5, attempts: 3 }),
+      );
+      expect(mockWebhookQueue.add).toHaveBeenCalledWith(
+        'deliver-translation-result',
+        { userId: 'user123', taskId: 'task2' },
+        expect.objectContaining({ jobId: expect.stringMatching(/^deliver-translation-result:task2:resumed:/) }),
+      );
+      expect(mockRedisService.del).toHaveBeenCalledWith('account_lockdown:deferred_deliveries:user123');
+    });
+  });
+
+  describe('deliverTranslationResult', () => {
+    const job = { userId: 'user123', taskId: 'task123' };
+
+    beforeEach(() => {
+      mockWebhookService.resolveDeliveryConfig.mockResolvedValue({ id: 'hook1', webhookUrl: 'https://example.com' });
+      mockEntityManager.findOne.mockResolvedValue({ id: 'task123', translatedJson: '{"text":"你好"}' });
+      mockEntityManager.create.mockImplementation((_entity, data) => data);
+    });
+
+    afterEach(() => {
+      mockEntityManager.create.mockReset();
+      mockHttpService.post.mockReset();
+    });
+
+    it('推送成功时应记录发送结果', async () => {
+      mockHttpService.post.mockReturnValue(of({ status: 200 }));
+      mockWebhookService.deliveryHeaders.mockReturnValueOnce({ Authorization: 'Bearer secret' });
+
+      await service.deliverTranslationResult(job, 1, 3);
+
+      expect(mockHttpService.post).toHaveBeenCalledWith(
+        'https://example.com',
+        { code: 200, msg: 'Success', data: '{"text":"你好"}' },
+        expect.objectContaining({ timeout: 10000, headers: { Authorization: 'Bearer secret' } }),
+      );
+      expect(mockEntityManager.create).toHaveBeenCalledWith(expect.anything(), expect.objectContaining({ status: 'success' }));
+    });
+
+    it('envelope 格式应推送带版本号和文档元信息的事件', async () => {
+      const createdAt = new Date('2026-10-16T08:00:00.000Z');
+      const updatedAt = new Date('2026-10-16T08:00:05.000Z');
+      mockWebhookService.resolveDeliveryConfig.mockResolvedValue({
+        id: 'hook1',
+        webhookUrl: 'https://example.com',
+        payloadFormat: 'envelope',
+      });
+      mockEntityManager.findOne.mockResolvedValue({
+        id: 'task123',
+        fromLang: 'en',
+        toLang: 'zh',
+        translatedJson: '{"text":"你好"}',
+        status: 'completed',
+        charTotal: 5,
+        createdAt,
+        updatedAt,
+      });
+      mockHttpService.post.mockReturnValue(of({ status: 200 }));
+
+      await service.deliverTranslationResult(job, 1, 3);
+
+      const [, payload] = mockHttpService.post.mock.calls[0];
+      expect(payload).toMatchObject({
+        version: 2,
+        id: `task123:${updatedAt.getTime()}`,
+        event: 'translation.completed',
+        data: {
+          documentId: 'task123',
+          taskId: 'task123',
+          fromLang: 'en',
+          toLang: 'zh',
+          charTotal: 5,
+          status: 'completed',
+          partial: false,
+          completedAt: updatedAt.toISOString(),
+          links: { result: '/api/v1/translation/task/task123/result' },
+          translatedJson: '{"text":"你好"}',
+        },
+      });
+    });
+
+    it('开启 includeDiff 时附带与上一次推送的差异，并记录本次推送的译文', async () => {
+      const updatedAt = new Date('2026-10-16T08:00:05.000Z');
+      const deliveredAt = new Date('2026-10-15T08:00:00.000Z');
+      mockWebhookService.resolveDeliveryConfig.mockResolvedValue({
+        id: 'hook1',
+        webhookUrl: 'https://example.com',
+        payloadFormat: 'envelope',
+        includeDiff: true,
+      });
+      const document: any = {
+        id: 'task123',
+        translatedJson: '{"title":"你好","nav":{"home":"主页","about":"关于"}}',
+        deliveredJson: '{"title":"你好","nav":{"home":"首页","contact":"联系"}}',
+        deliveredAt,
+        createdAt: updatedAt,
+        updatedAt,
+      };
+      mockEntityManager.findOne.mockResolvedValue(document);
+      mockHttpService.post.mockReturnValue(of({ status: 200 }));
+
+      await service.deliverTranslationResult(job, 1, 3);
+
+      const [, payload] = mockHttpService.post.mock.calls[0];
+      expect(payload.data.diff).toEqual({
+        since: deliveredAt.toISOString(),
+        changed: { nav: { home: '主页', about: '关于' } },
+        removed: [['nav', 'contact']],
+      });
+      expect(document.deliveredJson).toBe(payload.data.translatedJson);
+      expect(mockEntityManager.persistAndFlush).toHaveBeenCalledWith(document);
+
+      // 第一次推送没有基准
+      mockHttpService.post.mockClear();
+      mockEntityManager.findOne.mockResolvedValue({ ...document, deliveredJson: undefined, deliveredAt: undefined });
+      await service.deliverTranslationResult(job, 1, 3);
+      expect(mockHttpService.post.mock.calls[0][1].data.diff).toBeNull();
+    });
+
+    it('账号锁定时应推迟推送而不发送请求', async () => {
+      mockAccountLockdownService.isLocked.mockResolvedValueOnce(true);
+
+      await service.deliverTranslationResult(job, 1, 3);
+
+      expect(mockRedisService.client.sadd).toHaveBeenCalledWith(
+        'account_lockdown:deferred_deliveries:user123',
+        JSON.stringify(job),
+      );
+      expect(mockHttpService.post).not.toHaveBeenCalled();
+    });
+
+    it('超出 webhook 推送限制时应延后重新入队而不发送', async () => {
+      mockWebhookRateLimiter.acquire.mockResolvedValueOnce({ acquired: false, retryAfterMs: 1500, release: jest.fn() });
+
+      await service.deliverTranslationResult(job, 1, 3);
+
+      expect(mockHttpService.post).not.toHaveBeenCalled();
+      expect(mockWebhookQueue.add).toHaveBeenCalledWith(
+        'deliver-translation-result',
+        job,
+        expect.objectContaining({ delay: 1500, jobId: expect.stringContaining(':throttled:') }),
+      );
+    });
+
+    it('限流等待时间为 0 时仍使用新的 jobId 重新入队，并至少延后 1ms', async () => {
+      mockWebhookRateLimiter.acquire.mockResolvedValueOnce({ acquired: false, retryAfterMs: 0, release: jest.fn() });
+
+      await service.deliverTranslationResult(job, 1, 3);
+
+      expect(mockHttpService.post).not.toHaveBeenCalled();
+      expect(mockWebhookQueue.add).toHaveBeenCalledWith(
+        'deliver-translation-result',
+        job,
+        expect.objectContaining({ delay: 1, jobId: expect.stringContaining(':throttled:') }),
+      );
+    });
+
+    it('推送失败时应抛出异常交给队列重试，最后一次失败时上报', async () => {
+      mockHttpService.post.mockImplementation(() => {
+        throw new Error('connect ECONNREFUSED');
+      });
+
+      await expect(service.deliverTranslationResult(job, 1, 3)).rejects.toThrow('ECONNREFUSED');
+      expect(mockErrorReporter.captureException).not.toHaveBeenCalled();
+
+      await expect(service.deliverTranslationResult(job, 3, 3)).rejects.toThrow('ECONNREFUSED');
+      expect(mockErrorReporter.captureException).toHaveBeenCalledTimes(1);
+      // 只上报 webhook ID，地址中可能带有令牌
+      expect(mockErrorReporter.captureException.mock.calls[0][1].extra).toEqual({ webhookId: 'hook1' });
     });
   });
 
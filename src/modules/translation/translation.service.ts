@@ -1,35 +1,167 @@
-import { Injectable, Logger } from '@nestjs/common';
+import {
+  BadRequestException,
+  ConflictException,
+  ForbiddenException,
+  HttpStatus,
+  Injectable,
+  Logger,
+  NotFoundException,
+} from '@nestjs/common';
 import { ConfigService } from '@nestjs/config';
-import { EntityManager } from '@mikro-orm/core';
 import { TranslateGeneralRequest, GetDetectLanguageRequest } from '@alicloud/alimt20181012';
 import { RuntimeOptions } from '@alicloud/tea-util';
 import Alimt from '@alicloud/alimt20181012';
 import { Translation } from './entities/translation.entity';
-import { TranslationTask, UserJsonData } from './entities/translation-task.entity';
-import { SendRetry } from './entities/send-retry.entity';
+import { ReviewStatus, TranslationTask, UserJsonData } from './entities/translation-task.entity';
 import { v4 as uuidv4 } from 'uuid';
 import { HttpService } from '@nestjs/axios';
 import { firstValueFrom } from 'rxjs';
-import { TranslationUtils, TranslationConfig } from './utils/translation.utils';
+import {
+  TranslationUtils,
+  TranslationConfig,
+  TranslateJsonOptions,
+  UntranslatedKeyFailure,
+} from './utils/translation.utils';
+import { assertCronSchedule, nextCronRun } from './utils/schedule.utils';
+import { compactDiff, getPath, isPlainObject, setPath } from './utils/json-diff';
+import { applyLockedKeys, parseKeyPath } from './utils/locked-keys';
+import { compactKeyUsage, KeyUsageCounter, summarizeKeyUsage } from './utils/key-usage';
+import { sampleStrings } from './utils/language-sample';
+import { formatOutputKeys, OutputKeyFormat } from './utils/output-keys';
+import { DownloadFormat, renderDownload, TranslationDownload } from './utils/translation-download';
+import {
+  cursorFilter,
+  DocumentSortField,
+  encodeCursor,
+  InvalidCursorError,
+  SORT_PROPERTIES,
+  SortDirection,
+} from './utils/list-cursor';
+import { findPlaceholderIssues, parsePlaceholderStyles, PlaceholderStyle } from './utils/placeholders';
+import { classifyTaskFailure } from './utils/task-failure';
+import { compilePiiMatcher, findPiiSpans, MaskedSpan } from './utils/pii-masking';
 import { WebhookService } from '../webhook/webhook.service';
+import { WebhookRateLimiterService } from '../webhook/services/webhook-rate-limiter.service';
+import { NotificationChannelService } from '../webhook/services/notification-channel.service';
 import { InjectQueue } from '@nestjs/bull';
-import { Queue } from 'bull';
-import { CharacterUsageLog, CharacterUsageLogDaily, WebhookConfig } from './entities/translation-task.entity';
-import { WebhookResponse } from './dto/translation-task.dto';
+import { JobOptions, Queue } from 'bull';
+import {
+  TranslationPayload,
+  TranslationEstimate,
+  WebhookResponse,
+  ScheduledTranslation,
+  DocumentMetadataDto,
+} from './dto/translation-task.dto';
+import { UpdateTranslationKeysDto } from './dto/translation-keys.dto';
+import { QuotaService, QuotaCheckResult } from './services/quota.service';
+import { UsageRollupService } from './services/usage-rollup.service';
+import { QueuePriorityService, TaskPriority } from './services/queue-priority.service';
+import { DocumentLock, DocumentLockService } from './services/document-lock.service';
+import { BackpressureStatus, QueueBackpressureService } from './services/queue-backpressure.service';
+import { StorageLimitService } from './services/storage-limit.service';
+import { DocumentArchiveService } from './services/document-archive.service';
+import { reviewStatusOf, TranslationReviewService } from './services/translation-review.service';
+import { TranslateChunkJob, TranslationChunkService } from './services/translation-chunk.service';
+import { QualityEstimationService } from './services/quality-estimation.service';
+import { TranslationValidationService } from './services/translation-validation.service';
+import { TranslationLintService } from './services/translation-lint.service';
+import { TranslationCheckpointService } from './services/translation-checkpoint.service';
+import { IgnoreProfileService } from './services/ignore-profile.service';
+import { DuplicateSubmissionService } from './services/duplicate-submission.service';
+import { CustomMtEngineService } from './services/custom-mt-engine.service';
+import { IncidentService } from './services/incident.service';
+import { JsonLimitsService } from './services/json-limits.service';
+import { IgnoreMatcher, IgnoreRules } from './utils/ignore-rules';
+import { ProviderThrottleService } from './services/provider-throttle.service';
+import { TranslationRepository } from './translation.repository';
+import { TranslationTaskRepository, UserJsonDataRepository } from './repositories/translation-task.repository';
+import { SendRetryRepository } from '../webhook/repositories/send-retry.repository';
+import { ApiKeyContext } from '../api-key/interfaces/api-key-context.interface';
+import { TaskScope, isRestrictedScope, taskScope } from './utils/task-scope';
+import { TranslationRequestContext } from './interfaces/translation-context.interface';
+import {
+  TRANSLATION_COMPLETED_EVENT,
+  TRANSLATION_RESULT_PAYLOAD_VERSION,
+  TranslationResultDiff,
+  TranslationResultEnvelope,
+  WEBHOOK_DELIVERY_JOB,
+  WebhookDeliveryJob,
+} from './interfaces/webhook-delivery-job.interface';
+import { WebhookPayloadFormat } from '../webhook/entities/webhook-config.entity';
+import { measurePhase } from '../../common/utils/request-timing';
+import { ErrorReporterService } from '../../common/services/error-reporter.service';
+import { RedisService } from '../../common/services/redis.service';
+import { AccountLockdownService } from '../api-key/account-lockdown.service';
+import { ApiKeyService } from '../api-key/api-key.service';
+import { buildTlsOptions, ServiceTlsOptions } from '../../config/tls.config';
+import { DEFAULT_TRANSLATION_PROVIDER, TranslationProvider } from '../../config/providers';
+
+export const AUTO_DETECT_LANGUAGE = 'auto';
+export const TRANSLATION_FAILED_EVENT = 'translation.failed';
+export const RETRY_FAILED_KEYS_JOB = 'retry-failed-keys';
+
+export interface LanguageDetection {
+  language: string;
+  /** 检测结果一致的样本按长度计的占比（0-1） */
+  confidence: number;
+}
 
 @Injectable()
 export class TranslationService {
   private readonly logger = new Logger(TranslationService.name);
   private readonly translateClient: Alimt;
-  private readonly sendQueue: Array<{ userId: string; translationResult: string; taskId: string }> = [];
+  private readonly providerTls?: ServiceTlsOptions;
+  private readonly charsPerSecond: number;
+  private readonly deliveryAttempts: number;
+  private readonly deliveryBackoffMs: number;
+  private readonly deliveryTimeoutMs: number;
+  private readonly avgQueuedTaskSeconds: number;
+  private readonly scheduleMaxDays: number;
+  private readonly cronMinIntervalMinutes: number;
+  private readonly taskAttempts: number;
+  private readonly detectionSamples: number;
+  private readonly detectionMinConfidence: number;
+  private readonly taskBackoffMs: number;
+  private readonly publicApiUrl: string;
+  private readonly keyUsageDepth: number;
+  private readonly keyUsageMaxKeys: number;
 
   constructor(
     private readonly configService: ConfigService,
-    private readonly em: EntityManager,
     private readonly httpService: HttpService,
     private readonly webhookService: WebhookService,
+    private readonly webhookRateLimiter: WebhookRateLimiterService,
+    private readonly notificationChannelService: NotificationChannelService,
     @InjectQueue('translation') private readonly translationQueue: Queue,
+    @InjectQueue('webhook') private readonly webhookQueue: Queue,
     private readonly translationUtils: TranslationUtils,
+    private readonly quotaService: QuotaService,
+    private readonly queuePriorityService: QueuePriorityService,
+    private readonly queueBackpressureService: QueueBackpressureService,
+    private readonly storageLimitService: StorageLimitService,
+    private readonly documentArchiveService: DocumentArchiveService,
+    private readonly translationReviewService: TranslationReviewService,
+    private readonly translationChunkService: TranslationChunkService,
+    private readonly qualityEstimationService: QualityEstimationService,
+    private readonly translationValidationService: TranslationValidationService,
+    private readonly translationLintService: TranslationLintService,
+    private readonly translationCheckpointService: TranslationCheckpointService,
+    private readonly providerThrottleService: ProviderThrottleService,
+    private readonly usageRollupService: UsageRollupService,
+    private readonly translationRepository: TranslationRepository,
+    private readonly taskRepository: TranslationTaskRepository,
+    private readonly userJsonDataRepository: UserJsonDataRepository,
+    private readonly sendRetryRepository: SendRetryRepository,
+    private readonly errorReporter: ErrorReporterService,
+    private readonly accountLockdownService: AccountLockdownService,
+    private readonly apiKeyService: ApiKeyService,
+    private readonly redisService: RedisService,
+    private readonly documentLockService: DocumentLockService,
+    private readonly ignoreProfileService: IgnoreProfileService,
+    private readonly duplicateSubmissionService: DuplicateSubmissionService,
+    private readonly customMtEngineService: CustomMtEngineService,
+    private readonly incidentService: IncidentService,
+    private readonly jsonLimitsService: JsonLimitsService,
   ) {
     this.translateClient = new Alimt({
       accessKeyId: this.configService.get('ALIYUN_ACCESS_KEY_ID'),
@@ -41,78 +173,1402 @@ export class TranslationService {
         endpoint: 'mt.aliyuncs.com'
       })
     });
-    this.startSendQueueProcessor();
+    this.providerTls = buildTlsOptions('PROVIDER');
+    this.charsPerSecond = Math.max(Number(this.configService.get('TRANSLATION_CHARS_PER_SECOND', 2000)), 1);
+    this.avgQueuedTaskSeconds = Number(this.configService.get('TRANSLATION_AVG_TASK_SECONDS', 5));
+    this.deliveryAttempts = Math.max(Number(this.configService.get('WEBHOOK_DELIVERY_ATTEMPTS', 3)), 1);
+    this.deliveryBackoffMs = Number(this.configService.get('WEBHOOK_DELIVERY_BACKOFF_MS', 2000));
+    this.deliveryTimeoutMs = Number(this.configService.get('WEBHOOK_DELIVERY_TIMEOUT_MS', 10000));
+    this.scheduleMaxDays = Number(this.configService.get('SCHEDULE_MAX_DAYS', 90));
+    this.cronMinIntervalMinutes = Number(this.configService.get('SCHEDULE_CRON_MIN_INTERVAL_MINUTES', 60));
+    this.taskAttempts = Math.max(Number(this.configService.get('TRANSLATION_JOB_ATTEMPTS', 3)), 1);
+    this.taskBackoffMs = Number(this.configService.get('TRANSLATION_JOB_BACKOFF_MS', 5000));
+    this.detectionSamples = Math.max(Number(this.configService.get('LANGUAGE_DETECTION_SAMPLES', 5)), 1);
+    this.detectionMinConfidence = Number(this.configService.get('LANGUAGE_DETECTION_MIN_CONFIDENCE', 0.6));
+    this.publicApiUrl = String(this.configService.get('PUBLIC_API_URL', '')).replace(/\/+$/, '');
+    this.keyUsageDepth = Math.max(Number(this.configService.get('KEY_USAGE_DEPTH', 1)), 1);
+    this.keyUsageMaxKeys = Math.max(Number(this.configService.get('KEY_USAGE_MAX_KEYS', 500)), 1);
   }
 
-  private startSendQueueProcessor() {
-    setInterval(() => {
-      if (this.sendQueue.length > 0) {
-        const task = this.sendQueue.shift();
-        if (task) {
-          this.retrySendTranslationResult(task.userId, task.translationResult, task.taskId, 3);
-        }
+  async createTranslationTask(
+    userId: string,
+    payload: TranslationPayload,
+    context: TranslationRequestContext = {},
+  ): Promise<{
+    task: TranslationTask;
+    quota: QuotaCheckResult;
+    detection?: LanguageDetection;
+    backpressure?: BackpressureStatus;
+  }> {
+    const { apiKey, tenantId } = context;
+    await this.accountLockdownService.assertUnlocked(userId);
+    payload = this.applyKeyDefaults(payload, apiKey);
+    const project = this.resolveProject(payload.project, apiKey);
+    const schedule = this.resolveSchedule(payload);
+    const ignoreRules = await this.resolveIgnoreRules(userId, payload);
+    if (payload.provider === TranslationProvider.CUSTOM) {
+      await this.customMtEngineService.assertConfigured(userId);
+    }
+
+    // 在解析之前检查文档大小、嵌套深度和键数量，超限的文档不会进入 JSON.parse 和翻译
+    this.jsonLimitsService.assert(payload.jsonContentRaw);
+    const keyUsage: KeyUsageCounter = { depth: this.keyUsageDepth, totals: {} };
+    let charTotal: number;
+    try {
+      charTotal = await this.countJsonChars(
+        payload.jsonContentRaw,
+        payload.fromLang,
+        payload.toLang,
+        payload.ignoredFields,
+        ignoreRules,
+        keyUsage,
+      );
+    } catch (error) {
+      throw new BadRequestException('Invalid JSON content');
+    }
+    const piiReport = this.buildPiiReport(payload);
+
+    const detection =
+      payload.fromLang === AUTO_DETECT_LANGUAGE ? await this.detectSourceLanguage(payload, ignoreRules) : undefined;
+
+    await this.storageLimitService.assertCanStore(userId, Buffer.byteLength(payload.jsonContentRaw));
+    const quota = context.quotaReservation
+      ? await this.quotaService.check(userId, 0)
+      : await this.quotaService.assertWithinQuota(userId, charTotal, tenantId);
+    const reservation = context.quotaReservation ?? { characters: charTotal, reservedAt: new Date() };
+
+    // 预留额度之后的任何失败都释放自己预留的额度和占用的指纹、退回密钥字符数，已保存但未入队的任务一并删除
+    const id = uuidv4();
+    let fingerprint: string | undefined;
+    let keyCharactersConsumed = false;
+    let saved: [TranslationTask, UserJsonData] | undefined;
+    try {
+      const { priority, tier, queuePriority } = await this.queuePriorityService.resolve(
+        userId,
+        charTotal,
+        payload.priority,
+      );
+      // 定时任务不受当前积压影响
+      const backpressure = schedule ? undefined : await this.queueBackpressureService.assertAccepting(userId, tier);
+
+      if (!payload.force) {
+        const candidate = this.duplicateSubmissionService.fingerprint(payload);
+        await this.assertNotDuplicate(userId, candidate, id);
+        fingerprint = candidate;
+      }
+      if (apiKey) {
+        await this.apiKeyService.consumeKeyCharacters(apiKey, charTotal);
+        keyCharactersConsumed = true;
+      }
+
+      const task = this.taskRepository.build({
+        id,
+        userId,
+        content: payload.jsonContentRaw,
+        status: schedule ? 'scheduled' : 'pending',
+        charTotal,
+        project,
+        apiKeyId: apiKey?.id,
+        tenantId,
+        priority,
+        requestedPriority: payload.priority,
+        suppressWebhook: !!payload.suppressWebhook,
+        quotaReserved: reservation.characters,
+        quotaReservedAt: reservation.reservedAt,
+        ...schedule,
+      });
+      const userData = this.userJsonDataRepository.build({
+        id,
+        userId,
+        originJson: payload.jsonContentRaw,
+        fromLang: detection?.language ?? payload.fromLang,
+        toLang: payload.toLang,
+        name: payload.name?.trim() || undefined,
+        tags: normalizeTags(payload.tags),
+        charTotal,
+        keyUsage: compactKeyUsage(keyUsage.totals, this.keyUsageMaxKeys),
+        keyUsageDepth: keyUsage.depth,
+        detectionConfidence: detection?.confidence,
+        ignoredFields: payload.ignoredFields,
+        ignoreRules,
+        provider: payload.provider ?? DEFAULT_TRANSLATION_PROVIDER,
+        placeholderStyles: payload.placeholderStyles?.length ? payload.placeholderStyles : undefined,
+        outputKeyFormat: payload.outputKeyFormat,
+        preserveFormatting: payload.preserveFormatting || undefined,
+        maskPii: payload.maskPii || undefined,
+        piiPatterns: payload.maskPii && payload.piiPatterns?.length ? payload.piiPatterns : undefined,
+        piiReport,
+      });
+      await this.taskRepository.save([task, userData]);
+      saved = [task, userData];
+
+      await measurePhase('enqueue', () =>
+        this.translationQueue.add(
+          'translate-json',
+          { taskId: id },
+          { priority: queuePriority, ...this.retryJobOptions(), ...this.scheduleJobOptions(task) },
+        ),
+      );
+      return { task, quota, ...(detection && { detection }), ...(backpressure && { backpressure }) };
+    } catch (error) {
+      if (!context.quotaReservation) {
+        await this.quotaService.release(userId, charTotal, reservation.reservedAt);
+      }
+      if (fingerprint) {
+        await this.duplicateSubmissionService.release(userId, fingerprint, id);
+      }
+      if (keyCharactersConsumed) {
+        await this.apiKeyService.refundKeyCharacters(apiKey!, charTotal);
+      }
+      if (saved) {
+        await this.discardUnqueuedTask(...saved);
       }
-    }, 1000);
+      throw error;
+    }
   }
 
-  async createTranslationTask(userId: string, content: string): Promise<TranslationTask> {
-    const task = this.em.create(TranslationTask, {
-      id: uuidv4(),
-      userId,
-      content,
-      status: 'pending',
+  /**
+   * 释放任务在准入时预留的额度（失败、取消或删除），由调用方保存任务
+   */
+  private async releaseQuotaReservation(task: TranslationTask): Promise<void> {
+    if (!task.quotaReserved) {
+      return;
+    }
+    await this.quotaService.release(task.userId, task.quotaReserved, task.quotaReservedAt);
+    task.quotaReserved = undefined;
+    task.quotaReservedAt = undefined;
+  }
+
+  /**
+   * 删除已保存但没有入队的任务，删除失败只记录日志，保留原始错误
+   */
+  private async discardUnqueuedTask(task: TranslationTask, userData: UserJsonData): Promise<void> {
+    try {
+      await this.userJsonDataRepository.delete(userData);
+      await this.taskRepository.delete(task);
+    } catch (error) {
+      this.logger.error(`Failed to discard unqueued task ${task.id}: ${error.message}`);
+    }
+  }
+
+  /**
+   * 窗口期内提交过相同的文档和翻译选项时拒绝，响应中带上已有文档的 ID；已有文档被删除后允许重新提交
+   */
+  private async assertNotDuplicate(userId: string, fingerprint: string, id: string): Promise<void> {
+    const existing = await this.duplicateSubmissionService.claim(userId, fingerprint, id);
+    if (!existing) {
+      return;
+    }
+    if (!(await this.userJsonDataRepository.get({ id: existing, userId }))) {
+      await this.duplicateSubmissionService.claim(userId, fingerprint, id, true);
+      return;
+    }
+    throw new ConflictException({
+      statusCode: HttpStatus.CONFLICT,
+      message:
+        `Duplicate of translation ${existing} submitted in the last ` +
+        `${this.duplicateSubmissionService.windowSeconds} seconds; pass force=true to translate it again`,
+      error: 'Conflict',
+      documentId: existing,
     });
-    await this.em.persistAndFlush(task);
-    return task;
   }
 
-  async handleTranslationTask(taskId: string): Promise<void> {
-    const task = await this.em.findOne(TranslationTask, { id: taskId });
+  /**
+   * fromLang 为 auto 时抽样几段字符串分别检测，按长度加权投票；多数语言的权重占比即置信度，过低时拒绝
+   */
+  private async detectSourceLanguage(
+    payload: TranslationPayload,
+    ignoreRules?: IgnoreRules,
+  ): Promise<LanguageDetection> {
+    const detection = await this.detectDocumentLanguage(
+      JSON.parse(payload.jsonContentRaw),
+      payload.ignoredFields,
+      ignoreRules,
+    );
+    if (!detection) {
+      throw new BadRequestException('Source language could not be detected: no translatable text found');
+    }
+    const { language, confidence } = detection;
+    if (!language || confidence < this.detectionMinConfidence) {
+      throw new BadRequestException(
+        `Source language could not be detected reliably (confidence ${confidence}); specify fromLang explicitly`,
+      );
+    }
+    return detection;
+  }
+
+  /**
+   * 检测已解析文档的语言（不做置信度判断）；没有可翻译的字符串时返回 null，所有样本都检测失败时 language 为空
+   */
+  async detectDocumentLanguage(
+    document: any,
+    ignoredFields?: string,
+    ignoreRules?: IgnoreRules,
+  ): Promise<LanguageDetection | null> {
+    const samples = sampleStrings(
+      document,
+      this.translationUtils.getIgnoredFields(ignoredFields),
+      this.detectionSamples,
+      3,
+      IgnoreMatcher.compile(ignoreRules),
+    );
+    if (samples.length === 0) {
+      return null;
+    }
+
+    const votes = new Map<string, number>();
+    let total = 0;
+    for (const sample of samples) {
+      const language = await this.detectLanguage(sample);
+      total += sample.length;
+      if (language) {
+        votes.set(language, (votes.get(language) ?? 0) + sample.length);
+      }
+    }
+    const [language, weight] = [...votes.entries()].sort(([, a], [, b]) => b - a)[0] ?? ['', 0];
+    return { language, confidence: Math.round((weight / total) * 100) / 100 };
+  }
+
+  /**
+   * 解析定时参数：processAt 为一次性定时，cron 为周期执行，两者互斥
+   */
+  private resolveSchedule(
+    payload: TranslationPayload,
+  ): Pick<TranslationTask, 'scheduledAt' | 'cron' | 'timezone'> | undefined {
+    if (payload.processAt && payload.cron) {
+      throw new BadRequestException('processAt and cron cannot be used together');
+    }
+
+    if (payload.processAt) {
+      const scheduledAt = new Date(payload.processAt);
+      if (scheduledAt.getTime() > Date.now() + this.scheduleMaxDays * 24 * 60 * 60 * 1000) {
+        throw new BadRequestException(`processAt cannot be more than ${this.scheduleMaxDays} days in the future`);
+      }
+      // 已过去的时间视为立即执行
+      return scheduledAt.getTime() > Date.now() ? { scheduledAt } : undefined;
+    }
+
+    if (payload.cron) {
+      assertCronSchedule(payload.cron, payload.timezone, this.cronMinIntervalMinutes);
+      return { cron: payload.cron, timezone: payload.timezone };
+    }
+
+    return undefined;
+  }
+
+  /**
+   * 翻译失败（含 worker 崩溃后的重试）按退避重试，重试从断点继续
+   */
+  private retryJobOptions(): JobOptions {
+    return { attempts: this.taskAttempts, backoff: { type: 'exponential', delay: this.taskBackoffMs } };
+  }
+
+  /**
+   * 定时任务以任务 ID 作为 jobId，便于按 ID 取消
+   */
+  private scheduleJobOptions(task: TranslationTask): JobOptions {
+    if (task.cron) {
+      return { jobId: task.id, repeat: { cron: task.cron, tz: task.timezone } };
+    }
+    if (task.scheduledAt) {
+      return { jobId: task.id, delay: Math.max(task.scheduledAt.getTime() - Date.now(), 0) };
+    }
+    return {};
+  }
+
+  async listScheduledTasks(userId: string, apiKey?: ApiKeyContext): Promise<ScheduledTranslation[]> {
+    const tasks = await this.taskRepository.list(
+      { ...taskScope(userId, apiKey), status: 'scheduled' },
+      { orderBy: { createdAt: 'DESC' } },
+    );
+    return tasks.map((task) => ({
+      id: task.id,
+      scheduledAt: task.scheduledAt,
+      cron: task.cron,
+      timezone: task.timezone,
+      nextRunAt: task.cron ? nextCronRun(task.cron, task.timezone) : task.scheduledAt,
+      charTotal: task.charTotal,
+      project: task.project,
+      createdAt: task.createdAt,
+    }));
+  }
+
+  /**
+   * 取消定时任务：移除队列中的延迟 / 周期任务，并把任务标记为 canceled
+   */
+  async cancelScheduledTask(userId: string, taskId: string, apiKey?: ApiKeyContext): Promise<void> {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey), status: 'scheduled' });
     if (!task) {
-      throw new Error('Translation task not found');
+      throw new NotFoundException('Scheduled translation not found');
     }
 
-    const userData = await this.em.findOne(UserJsonData, { id: task.id });
+    if (task.cron) {
+      await this.translationQueue.removeRepeatable('translate-json', {
+        cron: task.cron,
+        tz: task.timezone,
+        jobId: task.id,
+      });
+    } else {
+      for (const jobId of [task.id, alternateJobId(task.id)]) {
+        await (await this.translationQueue.getJob(jobId))?.remove();
+      }
+    }
+
+    task.status = 'canceled';
+    await this.releaseQuotaReservation(task);
+    await this.taskRepository.save(task);
+    this.logger.log(`Scheduled translation ${task.id} canceled by user ${userId}`);
+  }
+
+  /**
+   * 获取任务的原文和译文；已归档的文档从对象存储取回，响应中 archived 为 true
+   * keyFormat 覆盖创建任务时指定的输出键形态；validate 时重新校验结构和占位符（适用于早于校验功能翻译的文档）
+   */
+  async getTaskResult(
+    userId: string,
+    taskId: string,
+    options: { keyFormat?: OutputKeyFormat; validate?: boolean } = {},
+    apiKey?: ApiKeyContext,
+  ) {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    const userData = task ? await this.userJsonDataRepository.get({ id: taskId, userId }) : null;
+    if (!userData) {
+      throw new NotFoundException('Translation not found');
+    }
+
+    const content = await this.documentArchiveService.load(userData);
+    const outputKeyFormat = options.keyFormat ?? this.outputKeyFormatOf(userData);
+    return {
+      id: task.id,
+      status: task.status,
+      isTranslated: task.isTranslated,
+      fromLang: userData.fromLang,
+      toLang: userData.toLang,
+      originJson: content.originJson,
+      translatedJson: content.translatedJson
+        ? this.formatTranslatedJson(userData, content.originJson, content.translatedJson, outputKeyFormat)
+        : null,
+      outputKeyFormat,
+      archived: content.archived,
+      archivedAt: userData.archivedAt ?? null,
+      quality: this.qualityEstimationService.summarize(userData),
+      partial: !!userData.untranslatedKeys?.length,
+      untranslatedKeys: userData.untranslatedKeys ?? [],
+      failedKeys: userData.failedKeys ?? {},
+      placeholderIssues: userData.placeholderIssues ?? {},
+      piiReport: userData.maskPii ? (userData.piiReport ?? {}) : null,
+      lint: userData.lintReport ?? null,
+      incidents: await this.incidentService.forTask(task),
+      ...(options.validate &&
+        content.translatedJson && {
+          validation: this.translationValidationService.validate(userData, content.originJson, content.translatedJson),
+        }),
+      createdAt: task.createdAt,
+    };
+  }
+
+  /**
+   * 译文文件下载：直接返回 JSON 文件内容，调用方不必从结果信封中取出并反转义 translatedJson
+   */
+  async getTaskDownload(
+    userId: string,
+    taskId: string,
+    format: DownloadFormat,
+    apiKey?: ApiKeyContext,
+  ): Promise<TranslationDownload> {
+    await this.assertTaskInScope(taskId, taskScope(userId, apiKey));
+    const userData = await this.userJsonDataRepository.get({ id: taskId, userId });
     if (!userData) {
-      throw new Error('User JSON data not found');
+      throw new NotFoundException('Translation not found');
+    }
+    const content = await this.documentArchiveService.load(userData);
+    if (!content.translatedJson) {
+      throw new ConflictException('Translation has not finished yet');
+    }
+    return renderDownload(JSON.parse(content.originJson), JSON.parse(content.translatedJson), format, {
+      id: taskId,
+      fromLang: userData.fromLang,
+      toLang: userData.toLang,
+    });
+  }
+
+  private outputKeyFormatOf(userData: UserJsonData): OutputKeyFormat {
+    return (userData.outputKeyFormat as OutputKeyFormat) ?? OutputKeyFormat.NESTED;
+  }
+
+  /**
+   * 按输出键形态转换译文；存储的译文始终保持原结构，锁定键、审校和增量翻译都基于原结构
+   */
+  private formatTranslatedJson(
+    userData: UserJsonData,
+    originJson: string,
+    translatedJson: string,
+    format: OutputKeyFormat,
+  ): string {
+    if (format === OutputKeyFormat.NESTED) {
+      return translatedJson;
+    }
+    return JSON.stringify(
+      formatOutputKeys(JSON.parse(translatedJson), {
+        format,
+        fromLang: userData.fromLang,
+        toLang: userData.toLang,
+        source: format === OutputKeyFormat.MERGED ? JSON.parse(originJson) : undefined,
+      }),
+    );
+  }
+
+  /**
+   * 任务状态；分片翻译的任务附带每个分片的进度，排队或执行期间有已声明的故障时附带故障说明
+   */
+  async getTaskStatus(userId: string, taskId: string, apiKey?: ApiKeyContext) {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    if (!task) {
+      throw new NotFoundException('Translation not found');
+    }
+    return {
+      id: task.id,
+      status: task.status,
+      failureReason: task.failureReason,
+      isTranslated: task.isTranslated,
+      charTotal: task.charTotal,
+      suppressWebhook: task.suppressWebhook,
+      chunks: await this.translationChunkService.getProgress(task.id),
+      incidents: await this.incidentService.forTask(task),
+      createdAt: task.createdAt,
+      updatedAt: task.updatedAt,
+    };
+  }
+
+  /**
+   * 计划升降级后按新等级重新计算用户排队中任务的优先级（仍不高于请求中指定的级别），
+   * 等待中和延迟中的队列任务按新的 Bull 优先级重新加入，保留原定的执行时间（剩余的延迟）；
+   * 先加入新任务再移除旧任务，中途失败时任务不会丢失。已开始执行的任务和周期任务不受影响
+   */
+  async rerankQueuedTasks(userId: string): Promise<{ reranked: number }> {
+    const tasks = await this.taskRepository.list({ userId, status: { $in: ['pending', 'scheduled'] }, cron: null });
+    const changed = new Map<string, TranslationTask>();
+    for (const task of tasks) {
+      const { priority } = await this.queuePriorityService.resolve(
+        userId,
+        task.charTotal,
+        task.requestedPriority as TaskPriority,
+      );
+      if (priority !== task.priority) {
+        task.priority = priority;
+        changed.set(task.id, task);
+      }
+    }
+    if (!changed.size) {
+      return { reranked: 0 };
+    }
+    await this.taskRepository.save([...changed.values()]);
+
+    let reranked = 0;
+    for (const job of await this.translationQueue.getJobs(['waiting', 'delayed'])) {
+      const task = changed.get(job?.data?.taskId);
+      if (!task) {
+        continue;
+      }
+      if (!['waiting', 'delayed'].includes(await job.getState())) {
+        continue;
+      }
+      // 指定了 ID 的任务（定时、锁定后延后、补译）新旧交替使用两个 ID，新任务加入时旧任务还在队列中
+      const replacement = await this.translationQueue.add(job.name, job.data, {
+        ...job.opts,
+        ...(job.opts.jobId && { jobId: alternateJobId(String(job.opts.jobId)) }),
+        delay: Math.max(job.timestamp + (job.opts.delay ?? 0) - Date.now(), 0),
+        priority: this.queuePriorityService.weightOf(task.priority),
+      });
+      try {
+        await job.remove();
+      } catch {
+        // 期间已被 worker 取走的任务保持原样，撤回刚加入的副本
+        await replacement.remove().catch(() => undefined);
+        continue;
+      }
+      reranked++;
+    }
+    this.logger.log(`Re-ranked ${reranked} queued job(s) of ${changed.size} task(s) for user ${userId}`);
+    return { reranked };
+  }
+
+  /**
+   * 重新提交分片翻译中重试用尽仍失败的分片
+   */
+  async retryFailedChunks(userId: string, taskId: string, apiKey?: ApiKeyContext): Promise<{ retried: number }> {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    if (!task) {
+      throw new NotFoundException('Translation not found');
+    }
+    if (task.status === 'canceled') {
+      throw new ConflictException('Translation task has been canceled');
+    }
+    const retried = await this.translationChunkService.retryFailed(
+      task.id,
+      this.queuePriorityService.weightOf(task.priority),
+    );
+    if (retried === 0) {
+      throw new ConflictException('Translation has no failed chunks');
+    }
+    if (task.status === 'failed') {
+      task.status = 'pending';
+      task.failureReason = undefined;
+      await this.taskRepository.save(task);
+    }
+    return { retried };
+  }
+
+  /**
+   * 部分翻译的文档只重新翻译失败的键（由 translation 队列异步执行），已翻译的键和用量不受影响
+   */
+  async retryFailedKeys(userId: string, taskId: string, apiKey?: ApiKeyContext): Promise<{ retried: number }> {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    const userData = task ? await this.userJsonDataRepository.get({ id: taskId, userId }) : null;
+    if (!userData) {
+      throw new NotFoundException('Translation not found');
+    }
+    if (task.status === 'canceled') {
+      throw new ConflictException('Translation task has been canceled');
+    }
+    if (userData.archivedAt) {
+      throw new ConflictException('Archived translations cannot be edited');
+    }
+    const keys = this.retryableKeys(userData);
+    if (!task.isTranslated || keys.length === 0) {
+      throw new ConflictException('Translation has no failed keys');
+    }
+    await this.documentLockService.assertEditable(task.id);
+
+    // 同一任务的补译在队列中只保留一个
+    await this.translationQueue.add(
+      RETRY_FAILED_KEYS_JOB,
+      { taskId: task.id },
+      {
+        jobId: `${task.id}:retry-keys`,
+        priority: this.queuePriorityService.weightOf(task.priority),
+        removeOnComplete: true,
+        removeOnFail: true,
+        ...this.retryJobOptions(),
+      },
+    );
+    return { retried: keys.length };
+  }
+
+  /**
+   * 锁定的键保留人工译文，不参与补译
+   */
+  private retryableKeys(userData: UserJsonData): string[] {
+    const locked = new Set(userData.lockedKeys ?? []);
+    return (userData.untranslatedKeys ?? []).filter((key) => !locked.has(key));
+  }
+
+  /**
+   * 重新翻译部分翻译文档中失败的键（由 translation 队列消费者调用），全部成功后任务变为 completed
+   */
+  async handleFailedKeysRetry(taskId: string): Promise<void> {
+    const task = await this.taskRepository.getOrFail({ id: taskId }, 'Translation task not found');
+    const userData = await this.userJsonDataRepository.getOrFail({ id: task.id }, 'User JSON data not found');
+    const keys = this.retryableKeys(userData);
+    if (task.status === 'canceled' || !userData.translatedJson || keys.length === 0) {
+      return;
+    }
+    if (await this.deferWhileLocked(task, RETRY_FAILED_KEYS_JOB)) {
+      return;
+    }
+
+    const translated = JSON.parse(userData.translatedJson);
+    const untranslatedKeys: string[] = [];
+    const failedKeys: Record<string, UntranslatedKeyFailure> = {};
+    const retranslated = await this.translationUtils.retranslateKeys(
+      JSON.parse(userData.originJson),
+      translated,
+      keys,
+      userData.fromLang,
+      userData.toLang,
+      {
+        provider: userData.provider,
+        userId: task.userId,
+        placeholderStyles: placeholderStylesOf(userData),
+        preserveFormatting: userData.preserveFormatting,
+        maskPii: userData.maskPii,
+        piiPatterns: userData.piiPatterns,
+        untranslatedKeys,
+        failedKeys,
+      },
+    );
+
+    userData.translatedJson = JSON.stringify(translated, null, 2);
+    userData.untranslatedKeys = untranslatedKeys.length > 0 ? untranslatedKeys : undefined;
+    userData.failedKeys = untranslatedKeys.length > 0 ? failedKeys : undefined;
+    this.qualityEstimationService.forgetKeys(userData, retranslated);
+    this.checkPlaceholders(task, userData);
+    this.translationValidationService.refresh(userData);
+    const wasBlocked = this.translationLintService.isBlocked(userData);
+    await this.translationLintService.lint(userData);
+    this.updateCompletionStatus(task, userData);
+    await this.taskRepository.save([userData, task]);
+    this.logger.log(
+      `Translation ${task.id}: ${retranslated.length} key(s) retranslated, ${untranslatedKeys.length} still failing`,
+    );
+
+    if (retranslated.length > 0 || wasBlocked) {
+      await this.deliverUnlessBlocked(task, userData);
+    }
+  }
+
+  /**
+   * 已保存文档列表（不含内容），可按审校状态、语言、标签和名称过滤
+   * tag 逗号分隔时匹配带有其中任一标签的文档；q 按空白拆分，名称需包含每个词（不区分大小写）。
+   * 传 cursor 时按游标分页（忽略 page），nextCursor 为空表示没有更多
+   */
+  async listDocuments(
+    userId: string,
+    filter: { reviewStatus?: ReviewStatus; fromLang?: string; toLang?: string; tag?: string; q?: string } = {},
+    page = 1,
+    limit = 20,
+    order: { sort?: DocumentSortField; direction?: SortDirection; cursor?: string } = {},
+    apiKey?: ApiKeyContext,
+  ) {
+    const query: Record<string, any> = { userId };
+    const scope = taskScope(userId, apiKey);
+    const tags = normalizeTags(filter.tag?.split(','));
+    const words = filter.q?.trim().split(/\s+/).filter(Boolean) ?? [];
+    if (filter.reviewStatus) {
+      query.reviewStatus = filter.reviewStatus;
+    }
+    if (filter.fromLang) {
+      query.fromLang = filter.fromLang;
+    }
+    if (filter.toLang) {
+      query.toLang = filter.toLang;
+    }
+
+    const sort = order.sort ?? DocumentSortField.CREATE_TIME;
+    const direction = order.direction ?? SortDirection.DESC;
+    const orderBy = { [SORT_PROPERTIES[sort]]: direction, id: direction };
+    // 标签、名称和密钥范围条件含原生 SQL 片段，每次查询重新生成
+    const where = (...extra: Record<string, any>[]) => {
+      const conditions = [
+        ...this.userJsonDataRepository.searchConditions(tags, words),
+        ...this.userJsonDataRepository.scopeConditions(scope),
+        ...extra,
+      ];
+      return conditions.length > 0 ? { ...query, $and: conditions } : query;
+    };
+    let documents: UserJsonData[];
+    let total: number;
+    let hasMore: boolean;
+    if (order.cursor) {
+      let after: Record<string, any>;
+      try {
+        after = cursorFilter(order.cursor, sort, direction);
+      } catch (error) {
+        if (error instanceof InvalidCursorError) {
+          throw new BadRequestException(error.message);
+        }
+        throw error;
+      }
+      // 多取一条判断是否还有下一页；total 为不含游标条件的总数
+      const [rows, count] = await Promise.all([
+        this.userJsonDataRepository.list(where(after), { limit: limit + 1, orderBy }),
+        this.userJsonDataRepository.count(where()),
+      ]);
+      documents = rows.slice(0, limit);
+      total = count;
+      hasMore = rows.length > limit;
+    } else {
+      [documents, total] = await this.userJsonDataRepository.listAndCount(where(), {
+        limit,
+        offset: (page - 1) * limit,
+        orderBy,
+      });
+      hasMore = (page - 1) * limit + documents.length < total;
+    }
+    const last = documents[documents.length - 1];
+    const incidents = await this.incidentService.forDocuments(documents);
+    return {
+      documents: documents.map((document) => ({
+        id: document.id,
+        name: document.name ?? null,
+        tags: document.tags ?? [],
+        fromLang: document.fromLang,
+        toLang: document.toLang,
+        reviewStatus: document.translatedJson || document.archivedAt ? reviewStatusOf(document) : null,
+        lockedKeys: document.lockedKeys ?? [],
+        qualityScore: document.qualityScore ?? null,
+        partial: !!document.untranslatedKeys?.length,
+        archived: !!document.archivedAt,
+        charTotal: document.charTotal,
+        incidents: incidents.get(document.id) ?? [],
+        createdAt: document.createdAt,
+        updatedAt: document.updatedAt,
+      })),
+      total,
+      nextCursor: hasMore && last ? encodeCursor(sort, direction, last) : null,
+    };
+  }
+
+  /**
+   * 修改文档名称和标签；不影响译文和任务状态，处理中的文档也可以修改
+   */
+  async updateDocumentMetadata(userId: string, taskId: string, dto: DocumentMetadataDto, apiKey?: ApiKeyContext) {
+    await this.assertTaskInScope(taskId, taskScope(userId, apiKey));
+    const userData = await this.userJsonDataRepository.getOrFail({ id: taskId, userId }, 'Translation not found');
+    if (dto.name !== undefined) {
+      userData.name = dto.name.trim() || undefined;
+    }
+    if (dto.tags !== undefined) {
+      userData.tags = normalizeTags(dto.tags);
+    }
+    await this.userJsonDataRepository.save(userData);
+    return { id: userData.id, name: userData.name ?? null, tags: userData.tags ?? [] };
+  }
+
+  /**
+   * 翻译完成时生成的校验报告；早于该功能的文档首次读取时补做校验
+   */
+  async getValidationReport(userId: string, taskId: string, apiKey?: ApiKeyContext) {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    const userData = task ? await this.userJsonDataRepository.get({ id: taskId, userId }) : null;
+    if (!userData) {
+      throw new NotFoundException('Translation not found');
+    }
+    if (!task.isTranslated) {
+      throw new ConflictException('Translation has not finished yet');
+    }
+
+    if (!userData.validationReport) {
+      const content = await this.documentArchiveService.load(userData);
+      if (!content.translatedJson) {
+        throw new ConflictException('Translation has not finished yet');
+      }
+      userData.validationReport = this.translationValidationService.validate(
+        userData,
+        content.originJson,
+        content.translatedJson,
+      );
+      await this.userJsonDataRepository.save(userData);
+    }
+    return { id: taskId, ...userData.validationReport };
+  }
+
+  /**
+   * 计费字符数按键的分布，depth 为汇总的层级（不超过统计时的层级）；
+   * 早于该功能的文档在第一次查询时按计费时的内容补算并保存
+   */
+  async getKeyUsage(userId: string, taskId: string, depth?: number, apiKey?: ApiKeyContext) {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    const userData = task ? await this.userJsonDataRepository.get({ id: taskId, userId }) : null;
+    if (!userData) {
+      throw new NotFoundException('Translation not found');
+    }
+
+    if (!userData.keyUsage) {
+      // 任务内容是实际计费的部分（增量翻译只包含变化的键）
+      const billed = task.content || (await this.documentArchiveService.load(userData)).originJson;
+      const keyUsage: KeyUsageCounter = { depth: this.keyUsageDepth, totals: {} };
+      await this.countJsonChars(
+        billed,
+        userData.fromLang,
+        userData.toLang,
+        userData.ignoredFields,
+        userData.ignoreRules,
+        keyUsage,
+      );
+      userData.keyUsage = compactKeyUsage(keyUsage.totals, this.keyUsageMaxKeys);
+      userData.keyUsageDepth = keyUsage.depth;
+      await this.userJsonDataRepository.save(userData);
+    }
+
+    const reportDepth = Math.max(Math.min(depth ?? userData.keyUsageDepth, userData.keyUsageDepth), 1);
+    return {
+      id: taskId,
+      charTotal: userData.charTotal,
+      depth: reportDepth,
+      keys: summarizeKeyUsage(userData.keyUsage, reportDepth),
+    };
+  }
+
+  /**
+   * 按当前的检查规则重新检查译文（修改或删除规则后使用）；之前因违规暂停的推送在通过后恢复
+   */
+  async lintDocument(userId: string, taskId: string, apiKey?: ApiKeyContext) {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    const userData = task ? await this.userJsonDataRepository.get({ id: taskId, userId }) : null;
+    if (!userData) {
+      throw new NotFoundException('Translation not found');
+    }
+    const content = task.isTranslated ? await this.documentArchiveService.load(userData) : null;
+    if (!content?.translatedJson) {
+      throw new ConflictException('Translation has not finished yet');
+    }
+
+    const wasBlocked = this.translationLintService.isBlocked(userData);
+    const report = await this.translationLintService.lint(userData, content.translatedJson);
+    await this.userJsonDataRepository.save(userData);
+    if (wasBlocked && !report.blocking) {
+      await this.deliverUnlessBlocked(task, userData);
+    }
+    return { id: taskId, ...report };
+  }
+
+  /**
+   * 人工修改单个键的译文并锁定 / 解锁，锁定的键在周期任务重新翻译时保留当前译文
+   */
+  async updateTranslationKeys(
+    userId: string,
+    taskId: string,
+    dto: UpdateTranslationKeysDto,
+    lockToken?: string,
+    apiKey?: ApiKeyContext,
+  ) {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    const userData = task ? await this.userJsonDataRepository.get({ id: taskId, userId }) : null;
+    if (!userData) {
+      throw new NotFoundException('Translation not found');
+    }
+    if (!task.isTranslated || !userData.translatedJson) {
+      throw new ConflictException('Translation has not finished yet');
+    }
+    if (userData.archivedAt) {
+      throw new ConflictException('Archived translations cannot be edited');
+    }
+    await this.documentLockService.assertEditable(task.id, lockToken);
+
+    const translated = JSON.parse(userData.translatedJson);
+    const locked = new Set(userData.lockedKeys ?? []);
+    const edited: string[] = [];
+    for (const update of dto.keys) {
+      const path = parseKeyPath(update.key);
+      const current = getPath(translated, path);
+      if (current === undefined || isPlainObject(current)) {
+        throw new BadRequestException(`Key "${update.key}" does not exist or is not a translated value`);
+      }
+      if (update.value !== undefined && update.value !== current) {
+        setPath(translated, path, update.value);
+        edited.push(update.key);
+      }
+      if (update.locked ?? (update.value !== undefined)) {
+        locked.add(update.key);
+      } else if (update.locked === false) {
+        locked.delete(update.key);
+      }
+    }
+
+    userData.translatedJson = JSON.stringify(translated);
+    userData.lockedKeys = locked.size > 0 ? [...locked] : null;
+    const wasBlocked = this.translationLintService.isBlocked(userData);
+    if (edited.length > 0) {
+      this.translationReviewService.reopenAfterEdit(userData, edited);
+      this.qualityEstimationService.forgetKeys(userData, edited);
+      this.translationValidationService.refresh(userData);
+      await this.translationLintService.lint(userData);
     }
+    await this.userJsonDataRepository.save(userData);
+    if (wasBlocked && !this.translationLintService.isBlocked(userData)) {
+      await this.deliverUnlessBlocked(task, userData);
+    }
+    return {
+      id: taskId,
+      translatedJson: userData.translatedJson,
+      lockedKeys: userData.lockedKeys ?? [],
+      lint: userData.lintReport ?? null,
+    };
+  }
+
+  /**
+   * 人工编辑前锁定文档；传入当前持有的 token 时续期
+   */
+  async lockDocument(
+    userId: string,
+    taskId: string,
+    options: { owner?: string; ttlSeconds?: number; token?: string },
+    apiKey?: ApiKeyContext,
+  ): Promise<DocumentLock> {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    if (!task) {
+      throw new NotFoundException('Translation not found');
+    }
+    if (!task.isTranslated) {
+      throw new ConflictException('Translation has not finished yet');
+    }
+    return this.documentLockService.acquire(task.id, options);
+  }
+
+  /**
+   * 解锁文档，并让编辑期间延后的重新翻译立即执行；force 为 true 时由所有者强制解锁
+   */
+  async unlockDocument(
+    userId: string,
+    taskId: string,
+    token?: string,
+    force = false,
+    apiKey?: ApiKeyContext,
+  ): Promise<void> {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    if (!task) {
+      throw new NotFoundException('Translation not found');
+    }
+    const lock = await this.documentLockService.get(task.id);
+    if (!lock || !(await this.documentLockService.release(task.id, token, force))) {
+      return;
+    }
+    for (const name of ['translate-json', RETRY_FAILED_KEYS_JOB]) {
+      const jobId = deferredJobId(task.id, name, lock);
+      for (const id of [jobId, alternateJobId(jobId)]) {
+        const job = await this.translationQueue.getJob(id);
+        if (job && (await job.isDelayed())) {
+          await job.promote();
+        }
+      }
+    }
+  }
+
+  async getDocumentLock(userId: string, taskId: string, apiKey?: ApiKeyContext): Promise<DocumentLock | null> {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    if (!task) {
+      throw new NotFoundException('Translation not found');
+    }
+    const lock = await this.documentLockService.get(task.id);
+    return lock ? { ...lock, token: undefined } : null;
+  }
+
+  /**
+   * 文档被锁定时把重新翻译延后到锁过期之后（提前解锁时立即执行），返回是否已延后；
+   * 到期时若锁已续期会再次延后
+   */
+  private async deferWhileLocked(task: TranslationTask, jobName: string): Promise<boolean> {
+    const lock = await this.documentLockService.get(task.id);
+    if (!lock) {
+      return false;
+    }
+    const delay = Math.max(new Date(lock.expiresAt).getTime() - Date.now(), 0) + 1000;
+    await this.translationQueue.add(
+      jobName,
+      { taskId: task.id },
+      {
+        jobId: deferredJobId(task.id, jobName, lock),
+        delay,
+        priority: this.queuePriorityService.weightOf(task.priority),
+        removeOnComplete: true,
+        removeOnFail: true,
+        ...this.retryJobOptions(),
+      },
+    );
+    this.logger.log(`Translation ${task.id} is locked for editing, ${jobName} deferred by ${delay}ms`);
+    return true;
+  }
+
+  /**
+   * 删除已保存的文档（任务及原文 / 译文），释放存储额度；已记账的用量不受影响
+   */
+  async deleteDocument(userId: string, taskId: string, apiKey?: ApiKeyContext): Promise<void> {
+    const task = await this.taskRepository.get({ id: taskId, ...taskScope(userId, apiKey) });
+    if (!task) {
+      throw new NotFoundException('Translation not found');
+    }
+    if (task.status === 'scheduled') {
+      throw new ConflictException('Cancel the scheduled translation before deleting it');
+    }
+    if (!task.isTranslated && task.status === 'pending') {
+      throw new ConflictException('Translation is still in progress');
+    }
+    await this.documentLockService.assertEditable(task.id);
 
+    const userData = await this.userJsonDataRepository.get({ id: taskId, userId });
+    if (userData) {
+      await this.documentArchiveService.discard(userData);
+      await this.userJsonDataRepository.delete(userData);
+    }
+    await this.translationChunkService.discard(taskId);
+    await this.releaseQuotaReservation(task);
+    await this.taskRepository.delete(task);
+    this.logger.log(`Translation ${taskId} deleted by user ${userId}`);
+  }
+
+  /**
+   * 试算：统计字符数、检查是否在剩余额度内并估算完成时间，不创建记录也不入队
+   */
+  async estimateTranslation(
+    userId: string,
+    payload: TranslationPayload,
+    apiKey?: ApiKeyContext,
+  ): Promise<TranslationEstimate> {
+    payload = this.applyKeyDefaults(payload, apiKey);
+    const ignoreRules = await this.resolveIgnoreRules(userId, payload);
+    this.jsonLimitsService.assert(payload.jsonContentRaw);
+    let charTotal: number;
     try {
+      charTotal = await this.countJsonChars(
+        payload.jsonContentRaw,
+        payload.fromLang,
+        payload.toLang,
+        payload.ignoredFields,
+        ignoreRules,
+      );
+    } catch (error) {
+      throw new BadRequestException('Invalid JSON content');
+    }
+
+    const [quota, counts] = await Promise.all([
+      this.quotaService.check(userId, charTotal),
+      this.translationQueue.getJobCounts(),
+    ]);
+    const queuedTasks = (counts.waiting ?? 0) + (counts.active ?? 0) + (counts.delayed ?? 0);
+    const estimatedSeconds = Math.ceil(queuedTasks * this.avgQueuedTaskSeconds + charTotal / this.charsPerSecond);
+
+    return {
+      charTotal,
+      fitsQuota: quota.used + charTotal <= quota.limit,
+      allowed: quota.allowed,
+      quota: {
+        mode: quota.mode,
+        used: quota.used,
+        limit: quota.limit,
+        remaining: quota.remaining,
+      },
+      warnings: quota.warnings,
+      queuedTasks,
+      estimatedSeconds,
+      estimatedCompletionAt: new Date(Date.now() + estimatedSeconds * 1000).toISOString(),
+    };
+  }
+
+  /**
+   * 请求体未指定的字段使用 API Key 上配置的默认值
+   */
+  private applyKeyDefaults(payload: TranslationPayload, apiKey?: ApiKeyContext): TranslationPayload {
+    const defaults = apiKey?.defaults;
+    if (!defaults) {
+      return payload;
+    }
+    return {
+      ...payload,
+      ignoredFields: payload.ignoredFields ?? defaults.ignoredFields,
+      provider: payload.provider ?? defaults.provider,
+      project: payload.project ?? defaults.project,
+      placeholderStyles: payload.placeholderStyles ?? defaults.placeholderStyles,
+    };
+  }
+
+  /**
+   * 开启个人信息屏蔽时校验调用方的正则表达式，并生成原文的屏蔽报告（只记录类型和位置）
+   */
+  private buildPiiReport(payload: TranslationPayload): Record<string, MaskedSpan[]> | undefined {
+    if (!payload.maskPii) {
+      return undefined;
+    }
+    for (const pattern of payload.piiPatterns ?? []) {
+      try {
+        new RegExp(pattern);
+      } catch (error) {
+        throw new BadRequestException(`Invalid pattern ${pattern}: ${error.message}`);
+      }
+    }
+    const report = findPiiSpans(JSON.parse(payload.jsonContentRaw), compilePiiMatcher(payload.piiPatterns));
+    return Object.keys(report).length > 0 ? report : undefined;
+  }
+
+  /**
+   * 请求引用的忽略配置的规则快照，配置不属于该用户时返回 404
+   */
+  private async resolveIgnoreRules(userId: string, payload: TranslationPayload): Promise<IgnoreRules | undefined> {
+    return payload.profileId ? this.ignoreProfileService.resolve(userId, payload.profileId) : undefined;
+  }
+
+  /**
+   * 只查询文档的接口：密钥受限时先确认对应的任务在范围内
+   */
+  private async assertTaskInScope(taskId: string, scope: TaskScope): Promise<void> {
+    if (isRestrictedScope(scope) && !(await this.taskRepository.get({ id: taskId, ...scope }))) {
+      throw new NotFoundException('Translation not found');
+    }
+  }
+
+  /**
+   * 委托密钥只能在其绑定的项目下创建任务
+   */
+  private resolveProject(requested: string | undefined, apiKey?: ApiKeyContext): string | undefined {
+    if (!apiKey?.project) {
+      return requested;
+    }
+    if (requested && requested !== apiKey.project) {
+      throw new ForbiddenException(`API key is restricted to project "${apiKey.project}"`);
+    }
+    return apiKey.project;
+  }
+
+  async handleTranslationTask(taskId: string): Promise<void> {
+    const task = await this.taskRepository.getOrFail({ id: taskId }, 'Translation task not found');
+    const userData = await this.userJsonDataRepository.getOrFail({ id: task.id }, 'User JSON data not found');
+
+    if (task.status === 'canceled') {
+      this.logger.log(`Skipping canceled translation task ${task.id}`);
+      return;
+    }
+
+    // 账号锁定期间暂停，解锁后由 resumeLockedWork 重新入队；周期任务只跳过本次触发
+    if (await this.accountLockdownService.isLocked(task.userId)) {
+      if (!task.cron) {
+        task.status = 'paused';
+        await this.taskRepository.save(task);
+      }
+      this.logger.warn(`Translation task ${task.id} paused: account ${task.userId} is locked`);
+      return;
+    }
+
+    // 已有译文的文档（周期任务）在人工编辑期间不重新翻译
+    if (task.isTranslated && (await this.deferWhileLocked(task, 'translate-json'))) {
+      return;
+    }
+
+    if (task.cron) {
+      // 周期任务每次触发都要计费并预留额度，创建时的预留只覆盖第一次
+      if (!task.quotaReserved) {
+        await this.quotaService.assertWithinQuota(task.userId, task.charTotal, task.tenantId);
+        task.quotaReserved = task.charTotal;
+        task.quotaReservedAt = new Date();
+        await this.taskRepository.save(task);
+      }
+    } else if (task.status === 'scheduled') {
+      task.status = 'pending';
+    }
+
+    if (this.translationChunkService.shouldChunk(task)) {
+      const chunks = await this.translationChunkService.split(
+        task,
+        userData,
+        this.queuePriorityService.weightOf(task.priority),
+      );
+      if (chunks > 0) {
+        // 分片由各 worker 并行翻译，最后一片完成时合并
+        task.isTranslated = false;
+        await this.taskRepository.save(task);
+        return;
+      }
+    }
+
+    try {
+      const checkpoint = await this.translationCheckpointService.open(userData);
+      const untranslatedKeys: string[] = [];
+      const failedKeys: Record<string, UntranslatedKeyFailure> = {};
       const translatedJson = await this.translateJson(
         userData.originJson,
         userData.fromLang,
         userData.toLang,
         userData.ignoredFields,
+        {
+          provider: userData.provider,
+          userId: task.userId,
+          ignoreRules: userData.ignoreRules,
+          checkpoint,
+          placeholderStyles: placeholderStylesOf(userData),
+          preserveFormatting: userData.preserveFormatting,
+          maskPii: userData.maskPii,
+          piiPatterns: userData.piiPatterns,
+          untranslatedKeys,
+          failedKeys,
+        },
       );
+      userData.untranslatedKeys = untranslatedKeys.length > 0 ? untranslatedKeys : undefined;
+      userData.failedKeys = untranslatedKeys.length > 0 ? failedKeys : undefined;
+      await this.completeTranslation(task, userData, translatedJson);
+      await this.translationCheckpointService.clear(userData);
+    } catch (error) {
+      this.logger.error(`Translation failed: ${error.message}`);
+      task.isTranslated = false;
+      await this.taskRepository.save(task);
+      throw error;
+    }
+  }
 
-      userData.translatedJson = translatedJson;
-      task.isTranslated = true;
-      await this.em.persistAndFlush([userData, task]);
-
-      await this.addCharacterUsageLog(task.id, task.userId, task.charTotal);
-      await this.updateUserCharacterUsage(task.userId, task.charTotal);
+  /**
+   * 翻译一个分片（由 translation 队列消费者调用），最后一片完成时合并并完成任务
+   */
+  async handleTranslationChunk(job: TranslateChunkJob): Promise<void> {
+    const task = await this.taskRepository.getOrFail({ id: job.taskId }, 'Translation task not found');
+    if (task.status === 'canceled') {
+      return;
+    }
+    const userData = await this.userJsonDataRepository.getOrFail({ id: task.id }, 'User JSON data not found');
 
-      const webhookConfigs = await this.em.find(WebhookConfig, { userId: task.userId });
-      if (webhookConfigs.length > 0) {
-        this.sendQueue.push({
-          userId: task.userId,
-          translationResult: translatedJson,
-          taskId: task.id,
-        });
+    try {
+      const checkpoint = await this.translationCheckpointService.open(userData);
+      const translatedJson = await this.translationChunkService.translateChunk(job.chunkId, userData, checkpoint);
+      if (translatedJson !== null) {
+        await this.completeTranslation(task, userData, translatedJson);
+        await this.translationCheckpointService.clear(userData);
       }
     } catch (error) {
-      this.logger.error(`Translation failed: ${error.message}`);
+      this.logger.error(`Translation of chunk ${job.chunkId} failed: ${error.message}`);
       task.isTranslated = false;
-      await this.em.persistAndFlush(task);
+      await this.taskRepository.save(task);
       throw error;
     }
   }
 
+  /**
+   * 任务或分片的队列重试次数用尽后调用：标记任务失败并发送 translation.failed webhook，
+   * 附带失败原因分类和重试建议；同一任务只通知一次（多个分片失败时不重复推送）
+   */
+  async handleTaskFailure(taskId: string, error: Error, attempts: number, chunkId?: string): Promise<void> {
+    const task = await this.taskRepository.get({ id: taskId });
+    if (!task || task.status === 'canceled' || task.status === 'failed') {
+      return;
+    }
+
+    const failure = classifyTaskFailure(error);
+    // 周期任务下次触发仍会执行，不改变状态，只释放本次触发的预留
+    if (!task.cron) {
+      task.status = 'failed';
+      task.failureReason = failure.reason;
+    }
+    await this.releaseQuotaReservation(task);
+    await this.taskRepository.save(task);
+
+    if (!task.suppressWebhook) {
+      await this.webhookService
+        .dispatchEvent(task.userId, task.tenantId, TRANSLATION_FAILED_EVENT, {
+          taskId: task.id,
+          chunkId,
+          reason: failure.reason,
+          message: failure.message,
+          attempts,
+          retry: {
+            retryable: failure.retryable,
+            retryAfterSeconds: failure.retryAfterSeconds,
+            guidance: failure.guidance,
+            endpoint: failure.retryable && chunkId ? `/api/v1/translation/task/${task.id}/chunks/retry` : undefined,
+          },
+          failedAt: new Date().toISOString(),
+        })
+        .catch((deliveryError) =>
+          this.logger.error(`Failed to deliver translation.failed webhook for ${task.id}: ${deliveryError.message}`),
+        );
+    }
+    this.logger.warn(`Translation task ${task.id} failed after ${attempts} attempt(s): ${failure.reason}`);
+  }
+
+  /**
+   * 保存译文（保留锁定键）、记账并推送结果
+   */
+  private async completeTranslation(
+    task: TranslationTask,
+    userData: UserJsonData,
+    translatedJson: string,
+  ): Promise<void> {
+    userData.translatedJson =
+      userData.lockedKeys?.length && userData.translatedJson
+        ? JSON.stringify(
+            applyLockedKeys(JSON.parse(translatedJson), JSON.parse(userData.translatedJson), userData.lockedKeys),
+          )
+        : translatedJson;
+    this.translationReviewService.resetAfterTranslation(userData);
+    this.checkPlaceholders(task, userData);
+    this.translationValidationService.refresh(userData);
+    await this.translationLintService.lint(userData);
+    if (userData.untranslatedKeys?.length) {
+      this.logger.warn(`Translation ${task.id} is partial: ${userData.untranslatedKeys.length} key(s) untranslated`);
+    }
+    task.isTranslated = true;
+    this.updateCompletionStatus(task, userData);
+    const { quotaReserved, quotaReservedAt } = task;
+    task.quotaReserved = undefined;
+    task.quotaReservedAt = undefined;
+    await this.taskRepository.save([userData, task]);
+
+    // 用量记账交给 worker 批量汇总，不阻塞结果推送；发布时把准入时的预留结算为实际用量
+    await this.usageRollupService.publish({
+      taskId: task.id,
+      userId: task.userId,
+      apiKeyId: task.apiKeyId,
+      tenantId: task.tenantId,
+      characters: task.charTotal,
+      occurredAt: new Date().toISOString(),
+      reserved: quotaReserved,
+      reservedAt: quotaReservedAt?.toISOString(),
+    });
+
+    await this.qualityEstimationService
+      .schedule(task.id, task.charTotal)
+      .catch((error) => this.logger.error(`Failed to schedule quality estimation: ${error.message}`));
+
+    await this.deliverUnlessBlocked(task, userData);
+  }
+
+  /**
+   * 推送结果并通知订阅了 translation.completed 的邮件 / Slack 渠道；
+   * 译文有 blocking 检查规则的违规时暂停推送，违规解决（修改译文、补译或重新检查）后再推送
+   */
+  private async deliverUnlessBlocked(task: TranslationTask, userData: UserJsonData): Promise<void> {
+    if (this.translationLintService.isBlocked(userData)) {
+      this.logger.warn(`Translation ${task.id}: result delivery held until blocking lint violations are resolved`);
+      return;
+    }
+    if (!task.suppressWebhook) {
+      await this.notificationChannelService
+        .publish(task.userId, task.tenantId, TRANSLATION_COMPLETED_EVENT, {
+          taskId: task.id,
+          fromLang: userData.fromLang,
+          toLang: userData.toLang,
+          charTotal: task.charTotal,
+          status: task.status,
+          partial: !!userData.untranslatedKeys?.length,
+          untranslatedKeys: userData.untranslatedKeys ?? [],
+          links: { result: `${this.publicApiUrl}/api/v1/translation/task/${task.id}/result` },
+        })
+        .catch((error) => this.logger.error(`Failed to queue completion notifications: ${error.message}`));
+    }
+    if (await this.shouldDeliverResult(task)) {
+      await this.enqueueResultDelivery({ userId: task.userId, tenantId: task.tenantId, taskId: task.id });
+    }
+  }
+
+  /**
+   * 创建时指定 suppressWebhook 的任务（如批量回填历史文档）不推送结果和失败事件
+   */
+  private async shouldDeliverResult(task: TranslationTask): Promise<boolean> {
+    if (task.suppressWebhook) {
+      return false;
+    }
+    return !!(await this.webhookService.resolveDeliveryConfig(task.userId, task.tenantId));
+  }
+
+  /**
+   * 有未翻译的键时任务为 partial，否则为 completed；周期任务保持 scheduled 状态
+   */
+  private updateCompletionStatus(task: TranslationTask, userData: UserJsonData): void {
+    if (!task.cron) {
+      task.status = userData.untranslatedKeys?.length ? 'partial' : 'completed';
+    }
+  }
+
+  /**
+   * 校验译文保留了原文的全部占位符，丢失的键记录在 placeholderIssues 中供结果接口返回
+   */
+  private checkPlaceholders(task: TranslationTask, userData: UserJsonData): void {
+    if (!userData.originJson || !userData.translatedJson) {
+      return;
+    }
+    const issues = findPlaceholderIssues(
+      JSON.parse(userData.originJson),
+      JSON.parse(userData.translatedJson),
+      placeholderStylesOf(userData),
+    );
+    const keys = Object.keys(issues).length;
+    userData.placeholderIssues = keys > 0 ? issues : undefined;
+    if (keys > 0) {
+      this.logger.warn(`Translation ${task.id}: placeholders lost in ${keys} key(s)`);
+    }
+  }
+
   private async translateJson(
     jsonContent: string,
     fromLang: string,
     toLang: string,
     ignoredFields?: string,
+    options: TranslateJsonOptions = {},
   ): Promise<string> {
     try {
       return await this.translationUtils.translateJson(
@@ -120,6 +1576,7 @@ export class TranslationService {
         fromLang,
         toLang,
         ignoredFields || '',
+        options,
       );
     } catch (error) {
       this.logger.error(`Translation failed: ${error.message}`);
@@ -127,42 +1584,202 @@ export class TranslationService {
     }
   }
 
-  private async retrySendTranslationResult(
-    userId: string,
-    translationResult: string,
-    taskId: string,
-    maxRetries: number,
-  ): Promise<void> {
-    const webhookConfigs = await this.em.find(WebhookConfig, { userId });
-    if (webhookConfigs.length === 0) {
+  /**
+   * 把结果推送加入持久化的 webhook 队列，重启不会丢失，失败由队列按退避策略重试
+   * 以任务 ID 作为 jobId，同一任务不会重复入队；因推送限制延后（传入 retryAfterMs）的任务总是使用新的 jobId，
+   * 即使等待时间为 0 也不会与正在执行的原任务重复而被队列丢弃
+   */
+  private async enqueueResultDelivery(job: WebhookDeliveryJob, retryAfterMs?: number): Promise<void> {
+    const throttled = retryAfterMs !== undefined;
+    await this.webhookQueue.add(WEBHOOK_DELIVERY_JOB, job, {
+      jobId: throttled
+        ? `${WEBHOOK_DELIVERY_JOB}:${job.taskId}:throttled:${Date.now()}`
+        : `${WEBHOOK_DELIVERY_JOB}:${job.taskId}`,
+      attempts: this.deliveryAttempts,
+      backoff: { type: 'exponential', delay: this.deliveryBackoffMs },
+      delay: throttled ? Math.max(retryAfterMs, 1) : undefined,
+      removeOnComplete: true,
+    });
+  }
+
+  /**
+   * 执行一次结果推送（由 webhook 队列消费者调用），失败时抛出异常交给队列重试；
+   * 最后一次尝试仍失败时上报错误追踪
+   */
+  async deliverTranslationResult(job: WebhookDeliveryJob, attempt: number, maxAttempts: number): Promise<void> {
+    const { userId, tenantId, taskId } = job;
+    if (await this.accountLockdownService.isLocked(userId)) {
+      await this.redisService.client.sadd(deferredDeliveriesKey(userId), JSON.stringify(job));
+      this.logger.warn(`Webhook delivery for task ${taskId} deferred: account ${userId} is locked`);
+      return;
+    }
+    const webhookConfig = await this.webhookService.resolveDeliveryConfig(userId, tenantId);
+    if (!webhookConfig) {
+      return;
+    }
+    const userData = await this.userJsonDataRepository.get({ id: taskId });
+    const content = userData && !userData.purgedAt ? await this.documentArchiveService.load(userData) : null;
+    if (!content?.translatedJson) {
+      this.logger.warn(`No translation result to deliver for task ${taskId}`);
       return;
     }
 
-    const payload: WebhookResponse = {
-      code: 200,
-      msg: 'Success',
-      data: translationResult,
-    };
+    const translatedJson = this.formatTranslatedJson(
+      userData,
+      content.originJson,
+      content.translatedJson,
+      this.outputKeyFormatOf(userData),
+    );
+    const envelope = webhookConfig.payloadFormat === WebhookPayloadFormat.ENVELOPE;
+    const trackDiff = envelope && !!webhookConfig.includeDiff;
+    const payload = envelope
+      ? await this.buildResultEnvelope(userData, translatedJson, trackDiff)
+      : ({ code: 200, msg: 'Success', data: translatedJson } as WebhookResponse);
 
-    for (let attempt = 1; attempt <= maxRetries; attempt++) {
-      try {
-        const response = await firstValueFrom(
-          this.httpService.post(webhookConfigs[0].webhookUrl, payload),
-        );
+    // 超出该 webhook 的并发或速率上限时延后重新入队，不计入失败重试次数
+    const slot = await this.webhookRateLimiter.acquire(webhookConfig);
+    if (!slot.acquired) {
+      await this.enqueueResultDelivery(job, slot.retryAfterMs);
+      this.logger.log(`Webhook delivery for task ${taskId} throttled, retrying in ${slot.retryAfterMs}ms`);
+      return;
+    }
 
-        if (response.status === 200) {
-          await this.recordSendRetry(webhookConfigs[0].id, taskId, 'success', attempt, payload);
-          this.logger.log(`Successfully sent translation result for user: ${userId}`);
-          return;
-        }
-      } catch (error) {
-        await this.recordSendRetry(webhookConfigs[0].id, taskId, 'failed', attempt, payload);
-        this.logger.error(`Attempt ${attempt}/${maxRetries} failed: ${error.message}`);
-        await new Promise(resolve => setTimeout(resolve, 2000));
+    try {
+      await firstValueFrom(
+        this.httpService.post(webhookConfig.webhookUrl, payload, {
+          timeout: this.deliveryTimeoutMs,
+          headers: this.webhookService.deliveryHeaders(webhookConfig),
+          httpsAgent: this.webhookService.deliveryAgent(webhookConfig),
+        }),
+      );
+      await this.recordSendRetry(webhookConfig.id, taskId, 'success', attempt, payload);
+      if (trackDiff) {
+        await this.recordDelivered(userData, translatedJson);
+      }
+      this.logger.log(`Successfully sent translation result for user: ${userId}`);
+    } catch (error) {
+      await this.recordSendRetry(webhookConfig.id, taskId, 'failed', attempt, payload);
+      this.logger.error(`Attempt ${attempt}/${maxAttempts} failed: ${error.message}`);
+
+      if (attempt >= maxAttempts) {
+        this.errorReporter.captureException(
+          new Error(`Webhook delivery failed after ${maxAttempts} attempts`),
+          {
+            source: 'webhook',
+            userId,
+            tenantId,
+            documentId: taskId,
+            extra: { webhookId: webhookConfig.id },
+          },
+        );
       }
+      throw error;
+    } finally {
+      await slot.release();
     }
   }
 
+  /**
+   * 版本化的结果推送载荷：附带文档元信息和获取结果的链接；
+   * 事件 ID 由任务 ID 和完成时间生成，队列重试时保持不变，补译后重新推送时变化
+   */
+  private async buildResultEnvelope(
+    userData: UserJsonData,
+    translatedJson: string,
+    includeDiff = false,
+  ): Promise<TranslationResultEnvelope> {
+    const task = await this.taskRepository.getOrFail({ id: userData.id }, 'Translation task not found');
+    const taskUrl = `${this.publicApiUrl}/api/v1/translation/task/${task.id}`;
+    return {
+      version: TRANSLATION_RESULT_PAYLOAD_VERSION,
+      id: `${task.id}:${task.updatedAt.getTime()}`,
+      event: TRANSLATION_COMPLETED_EVENT,
+      createdAt: new Date().toISOString(),
+      data: {
+        documentId: userData.id,
+        taskId: task.id,
+        fromLang: userData.fromLang,
+        toLang: userData.toLang,
+        charTotal: task.charTotal,
+        status: task.status,
+        partial: !!userData.untranslatedKeys?.length,
+        untranslatedKeys: userData.untranslatedKeys ?? [],
+        outputKeyFormat: this.outputKeyFormatOf(userData),
+        createdAt: task.createdAt.toISOString(),
+        completedAt: task.updatedAt.toISOString(),
+        links: { result: `${taskUrl}/result`, status: `${taskUrl}/status` },
+        translatedJson,
+        ...(includeDiff && { diff: this.diffSinceDelivered(userData, translatedJson) }),
+      },
+    };
+  }
+
+  /**
+   * 与上一次成功推送的译文比较；没有记录或记录无法解析时返回 null，接收方使用完整译文
+   */
+  private diffSinceDelivered(userData: UserJsonData, translatedJson: string): TranslationResultDiff | null {
+    if (!userData.deliveredJson || !userData.deliveredAt) {
+      return null;
+    }
+    try {
+      const { changed, removed } = compactDiff(JSON.parse(userData.deliveredJson), JSON.parse(translatedJson));
+      return { since: userData.deliveredAt.toISOString(), changed, removed };
+    } catch (error) {
+      this.logger.warn(`Cannot diff translation ${userData.id} against the delivered version: ${error.message}`);
+      return null;
+    }
+  }
+
+  /**
+   * 记录本次推送的译文，作为下一次推送计算差异的基准；记录失败不影响已成功的推送
+   */
+  private async recordDelivered(userData: UserJsonData, translatedJson: string): Promise<void> {
+    userData.deliveredJson = translatedJson;
+    userData.deliveredAt = new Date();
+    await this.userJsonDataRepository
+      .save(userData)
+      .catch((error) => this.logger.error(`Failed to record delivered translation ${userData.id}: ${error.message}`));
+  }
+
+  /**
+   * 账号解锁后恢复锁定期间暂停的翻译任务和推迟的 webhook 推送
+   */
+  async resumeLockedWork(userId: string): Promise<{ tasks: number; deliveries: number }> {
+    const paused = await this.taskRepository.list({ userId, status: 'paused' });
+    for (const task of paused) {
+      task.status = 'pending';
+    }
+    if (paused.length > 0) {
+      await this.taskRepository.save(paused);
+    }
+    for (const task of paused) {
+      await this.translationQueue.add(
+        'translate-json',
+        { taskId: task.id },
+        { priority: this.queuePriorityService.weightOf(task.priority), ...this.retryJobOptions() },
+      );
+    }
+
+    const key = deferredDeliveriesKey(userId);
+    const deferred = await this.redisService.client.smembers(key);
+    for (const raw of deferred) {
+      const job = JSON.parse(raw) as WebhookDeliveryJob;
+      // 原推送任务已以 taskId 作为 jobId 完成，这里使用新的 jobId 重新入队
+      await this.webhookQueue.add(WEBHOOK_DELIVERY_JOB, job, {
+        jobId: `${WEBHOOK_DELIVERY_JOB}:${job.taskId}:resumed:${Date.now()}`,
+        attempts: this.deliveryAttempts,
+        backoff: { type: 'exponential', delay: this.deliveryBackoffMs },
+        removeOnComplete: true,
+      });
+    }
+    if (deferred.length > 0) {
+      await this.redisService.del(key);
+    }
+
+    this.logger.log(`Resumed ${paused.length} task(s) and ${deferred.length} webhook delivery(ies) for user ${userId}`);
+    return { tasks: paused.length, deliveries: deferred.length };
+  }
+
   private async recordSendRetry(
     webhookId: string,
     taskId: string,
@@ -170,7 +1787,7 @@ export class TranslationService {
     attempt: number,
     payload: any,
   ): Promise<void> {
-    const retry = this.em.create(SendRetry, {
+    await this.sendRetryRepository.insert({
       id: uuidv4(),
       webhookId,
       taskId,
@@ -178,44 +1795,21 @@ export class TranslationService {
       status,
       payload: JSON.stringify(payload),
     });
-
-    await this.em.persistAndFlush(retry);
   }
 
-  private async addCharacterUsageLog(
-    jsonId: string,
-    userId: string,
-    totalCharacters: number,
-  ): Promise<void> {
-    const log = this.em.create(CharacterUsageLog, {
-      id: uuidv4(),
-      jsonId,
-      userId,
-      totalCharacters,
-    });
-
-    await this.em.persistAndFlush(log);
-  }
-
-  private async updateUserCharacterUsage(userId: string, charCount: number): Promise<void> {
-    const currentDate = new Date().toISOString().split('T')[0];
-    const dailyUsage = await this.em.findOne(CharacterUsageLogDaily, {
-      userId,
-      usageDate: currentDate,
-    });
-
-    if (dailyUsage) {
-      dailyUsage.totalCharacters += charCount;
-      await this.em.persistAndFlush(dailyUsage);
-    } else {
-      const newDailyUsage = this.em.create(CharacterUsageLogDaily, {
-        id: uuidv4(),
-        userId,
-        totalCharacters: charCount,
-        usageDate: currentDate,
-      });
-      await this.em.persistAndFlush(newDailyUsage);
+  /**
+   * 翻译服务商请求的运行时参数，配置了 PROVIDER_TLS_* 时附带自定义 CA 和客户端证书
+   */
+  private buildRuntimeOptions(): RuntimeOptions {
+    if (!this.providerTls) {
+      return new RuntimeOptions({});
     }
+    return new RuntimeOptions({
+      ca: this.providerTls.ca,
+      cert: this.providerTls.cert,
+      key: this.providerTls.key,
+      ignoreSSL: !this.providerTls.rejectUnauthorized,
+    });
   }
 
   async translate(
@@ -230,8 +1824,10 @@ export class TranslationService {
       formatType: 'text',
     });
 
-    const runtime = new RuntimeOptions({});
-    const response = await this.translateClient.translateGeneralWithOptions(request, runtime);
+    const runtime = this.buildRuntimeOptions();
+    const response = await this.providerThrottleService.execute(DEFAULT_TRANSLATION_PROVIDER, () =>
+      this.translateClient.translateGeneralWithOptions(request, runtime),
+    );
     return response.body.data.translated.split('\n');
   }
 
@@ -240,17 +1836,14 @@ export class TranslationService {
     status: string,
     targetText?: string,
   ) {
-    const translation = await this.em.findOne(Translation, { id: translationId });
-    if (!translation) {
-      throw new Error('Translation not found');
-    }
+    const translation = await this.translationRepository.getOrFail({ id: translationId }, 'Translation not found');
 
     translation.status = status;
     if (targetText) {
       translation.targetText = targetText;
     }
 
-    await this.em.persistAndFlush(translation);
+    await this.translationRepository.save(translation);
 
     if (status === 'completed' && targetText) {
       await this.webhookService.notifyTranslationComplete(
@@ -264,11 +1857,11 @@ export class TranslationService {
   }
 
   async getTranslation(taskId: string): Promise<Translation | null> {
-    return this.em.findOne(Translation, { id: taskId });
+    return this.translationRepository.findById(taskId);
   }
 
   async getTranslationsByUser(userId: string): Promise<Translation[]> {
-    return this.em.find(Translation, { userId });
+    return this.translationRepository.forUser(userId).list();
   }
 
   async translateText(
@@ -285,8 +1878,10 @@ export class TranslationService {
         scene: 'general',
       });
 
-      const runtime = new RuntimeOptions({});
-      const response = await this.translateClient.translateGeneralWithOptions(request, runtime);
+      const runtime = this.buildRuntimeOptions();
+      const response = await this.providerThrottleService.execute(DEFAULT_TRANSLATION_PROVIDER, () =>
+        this.translateClient.translateGeneralWithOptions(request, runtime),
+      );
 
       if (response.statusCode === 200) {
         return response.body.data.translated;
@@ -306,8 +1901,10 @@ export class TranslationService {
         sourceText: text,
       });
 
-      const runtime = new RuntimeOptions({});
-      const response = await this.translateClient.getDetectLanguageWithOptions(request, runtime);
+      const runtime = this.buildRuntimeOptions();
+      const response = await this.providerThrottleService.execute(DEFAULT_TRANSLATION_PROVIDER, () =>
+        this.translateClient.getDetectLanguageWithOptions(request, runtime),
+      );
 
       if (response.statusCode === 200) {
         return response.body.detectedLanguage;
@@ -321,14 +1918,55 @@ export class TranslationService {
     }
   }
 
-  async countJsonChars(jsonData: string, fromLang: string, toLang: string, ignoredFields?: string): Promise<number> {
+  async countJsonChars(
+    jsonData: string,
+    fromLang: string,
+    toLang: string,
+    ignoredFields?: string,
+    ignoreRules?: IgnoreRules,
+    keyUsage?: KeyUsageCounter,
+  ): Promise<number> {
     const config: TranslationConfig = {
       sourceData: JSON.parse(jsonData),
       sourceLang: fromLang,
       targetLang: toLang,
       ignoredFields: this.translationUtils.getIgnoredFields(ignoredFields || ''),
+      ignore: IgnoreMatcher.compile(ignoreRules),
+      keyUsage,
     };
 
     return this.translationUtils.countJsonChars(jsonData, config);
   }
 }
+
+/**
+ * 每次加锁或续期对应不同的 ID：锁续期后到期的延后任务需要再次延后，不能与正在执行的自己重名
+ */
+function deferredJobId(taskId: string, jobName: string, lock: DocumentLock): string {
+  return `${taskId}:after-lock:${jobName}:${new Date(lock.expiresAt).getTime()}`;
+}
+
+const RERANKED_JOB_SUFFIX = ':reranked';
+
+/**
+ * 重排优先级时新队列任务使用的 ID：在原 ID 和加了后缀的 ID 之间交替，避免与还没移除的旧任务重复
+ */
+function alternateJobId(jobId: string): string {
+  return jobId.endsWith(RERANKED_JOB_SUFFIX)
+    ? jobId.slice(0, -RERANKED_JOB_SUFFIX.length)
+    : `${jobId}${RERANKED_JOB_SUFFIX}`;
+}
+
+function deferredDeliveriesKey(userId: string): string {
+  return `account_lockdown:deferred_deliveries:${userId}`;
+}
+
+function placeholderStylesOf(userData: UserJsonData): PlaceholderStyle[] {
+  return parsePlaceholderStyles(userData.placeholderStyles ?? []);
+}
+
+/** 去掉首尾空白和重复的标签，没有标签时返回 undefined */
+function normalizeTags(tags?: string[]): string[] | undefined {
+  const unique = [...new Set((tags ?? []).map((tag) => tag.trim()).filter(Boolean))];
+  return unique.length > 0 ? unique : undefined;
+}
@@ -0,0 +1,22 @@
+import { Body, Controller, Post, Req, UseGuards } from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiResponse, ApiTags } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../auth/guards/jwt-auth.guard';
+import { AdminGuard } from '../auth/guards/admin.guard';
+import { UsageImportService } from './services/usage-import.service';
+import { UsageImportDto } from './dto/usage-import.dto';
+
+@ApiTags('admin')
+@Controller('admin/usage')
+@ApiBearerAuth()
+@UseGuards(JwtAuthGuard, AdminGuard)
+export class UsageImportController {
+  constructor(private readonly usageImportService: UsageImportService) {}
+
+  @Post('import')
+  @ApiOperation({ summary: '从旧系统导入历史用量和文档记录（支持 JSON / CSV 和试运行）' })
+  @ApiResponse({ status: 201, description: '导入报告；存在校验错误时整批不写入' })
+  @ApiResponse({ status: 400, description: '数据格式无效或超出单次导入上限' })
+  async import(@Req() req: any, @Body() dto: UsageImportDto) {
+    return this.usageImportService.import(dto, req.user.id);
+  }
+}
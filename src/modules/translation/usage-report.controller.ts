@@ -0,0 +1,64 @@
+import {
+  Body,
+  Controller,
+  Delete,
+  Get,
+  HttpCode,
+  HttpStatus,
+  ParseEnumPipe,
+  Put,
+  Query,
+  Req,
+  UseGuards,
+} from '@nestjs/common';
+import { ApiBearerAuth, ApiOperation, ApiQuery, ApiResponse, ApiSecurity, ApiTags } from '@nestjs/swagger';
+import { JwtOrApiKeyGuard } from '../auth/guards/jwt-or-api-key.guard';
+import { TenantService } from '../tenant/services/tenant.service';
+import { UsageReportService } from './services/usage-report.service';
+import { UsageReportSubscriptionDto } from './dto/usage-report.dto';
+import { UsageReportFrequency } from './entities/usage-report.entity';
+
+@ApiTags('user')
+@Controller('user/usage_reports')
+@ApiBearerAuth()
+@ApiSecurity('api-key')
+@UseGuards(JwtOrApiKeyGuard)
+export class UsageReportController {
+  constructor(
+    private readonly usageReportService: UsageReportService,
+    private readonly tenantService: TenantService,
+  ) {}
+
+  @Get()
+  @ApiOperation({ summary: '获取用量报告订阅' })
+  @ApiResponse({ status: 404, description: '未订阅' })
+  async get(@Req() req: any) {
+    return this.usageReportService.get(req.user.id);
+  }
+
+  @Put()
+  @ApiOperation({ summary: '订阅或修改每周 / 每月用量报告（邮件或 webhook）' })
+  @ApiResponse({ status: 200, description: '第一份报告在下一个周期结束后发送' })
+  async subscribe(@Req() req: any, @Body() dto: UsageReportSubscriptionDto) {
+    const tenant = await this.tenantService.resolveFromRequest(req);
+    return this.usageReportService.subscribe(req.user.id, dto, tenant?.id);
+  }
+
+  @Delete()
+  @HttpCode(HttpStatus.NO_CONTENT)
+  @ApiOperation({ summary: '取消用量报告' })
+  @ApiResponse({ status: 404, description: '未订阅' })
+  async unsubscribe(@Req() req: any) {
+    await this.usageReportService.unsubscribe(req.user.id);
+  }
+
+  @Get('preview')
+  @ApiOperation({ summary: '预览上一个周期的用量报告（不发送）' })
+  @ApiQuery({ name: 'frequency', required: false, enum: UsageReportFrequency, description: '默认使用订阅的周期' })
+  async preview(
+    @Req() req: any,
+    @Query('frequency', new ParseEnumPipe(UsageReportFrequency, { optional: true })) frequency?: UsageReportFrequency,
+  ) {
+    return this.usageReportService.preview(req.user.id, frequency);
+  }
+}
@@ -0,0 +1,62 @@
+import { suggestIgnoredFields, SuggestionReason } from './field-suggestions';
+
+describe('suggestIgnoredFields', () => {
+  const document = {
+    title: 'Welcome to our store',
+    products: [
+      {
+        id: '3f2b8c1e-5a4d-4f6b-9c7e-1d2a3b4c5d6e',
+        name: 'Red running shoes',
+        url: 'https://example.com/p/red-shoes',
+        status: 'active',
+        releasedAt: '2026-01-15T08:00:00Z',
+        slug: 'red-running-shoes',
+      },
+      {
+        id: 'a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c5d',
+        name: 'Blue sandals',
+        url: 'https://example.com/p/blue-sandals',
+        status: 'active',
+        releasedAt: '2026-02-01T08:00:00Z',
+        slug: 'blue-sandals',
+      },
+      {
+        id: '9e8d7c6b-5a4f-4e3d-8c2b-1a0f9e8d7c6b',
+        name: 'Green boots',
+        url: 'https://example.com/p/green-boots',
+        status: 'active',
+        releasedAt: '2026-03-01T08:00:00Z',
+        slug: 'green-boots',
+      },
+    ],
+    support: { email: 'help@example.com' },
+  };
+
+  it('按路径归并数组元素并给出原因和置信度', () => {
+    const suggestions = suggestIgnoredFields(document);
+    const byPath = new Map(suggestions.map((suggestion) => [suggestion.path, suggestion]));
+
+    expect(byPath.get('products.*.id')).toMatchObject({ field: 'id', reason: SuggestionReason.ID, confidence: 1 });
+    expect(byPath.get('products.*.url')).toMatchObject({ reason: SuggestionReason.URL, confidence: 1, occurrences: 3 });
+    expect(byPath.get('products.*.releasedAt')?.reason).toBe(SuggestionReason.DATE);
+    expect(byPath.get('products.*.slug')?.reason).toBe(SuggestionReason.SLUG);
+    expect(byPath.get('products.*.status')?.reason).toBe(SuggestionReason.ENUM);
+    expect(byPath.get('support.email')?.reason).toBe(SuggestionReason.EMAIL);
+    expect(byPath.has('title')).toBe(false);
+    expect(byPath.has('products.*.name')).toBe(false);
+  });
+
+  it('按置信度从高到低排列，并支持最低置信度和数量限制', () => {
+    const suggestions = suggestIgnoredFields(document, { minConfidence: 0.9, limit: 2 });
+
+    expect(suggestions).toHaveLength(2);
+    expect(suggestions.every((suggestion) => suggestion.confidence >= 0.9)).toBe(true);
+    expect(suggestions[0].confidence).toBeGreaterThanOrEqual(suggestions[1].confidence);
+  });
+
+  it('重复出现的短文案只给出较低的枚举置信度', () => {
+    const [suggestion] = suggestIgnoredFields({ items: [{ cta: 'Buy' }, { cta: 'Buy' }, { cta: 'Buy' }] });
+
+    expect(suggestion).toMatchObject({ path: 'items.*.cta', reason: SuggestionReason.ENUM, confidence: 0.6 });
+  });
+});
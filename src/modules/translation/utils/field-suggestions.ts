@@ -0,0 +1,162 @@
+import { isPlainObject } from './json-diff';
+
+/** 建议不翻译某个字段的原因 */
+export enum SuggestionReason {
+  ID = 'id',
+  URL = 'url',
+  EMAIL = 'email',
+  DATE = 'date',
+  SLUG = 'slug',
+  ENUM = 'enum',
+  NUMERIC = 'numeric',
+  COLOR = 'color',
+}
+
+export interface FieldSuggestion {
+  /** 点号路径，数组下标归并为 *，可直接用作忽略配置的 paths */
+  path: string;
+  /** 键名，可用于 ignoredFields / 忽略配置的 fields */
+  field: string;
+  reason: SuggestionReason;
+  /** 0 ~ 1 */
+  confidence: number;
+  /** 该路径下字符串值的数量 */
+  occurrences: number;
+  samples: string[];
+}
+
+export interface FieldSuggestionOptions {
+  /** 低于该置信度的建议不返回 */
+  minConfidence?: number;
+  /** 最多返回的建议数 */
+  limit?: number;
+}
+
+const VALUE_PATTERNS: [SuggestionReason, RegExp][] = [
+  [SuggestionReason.URL, /^(https?:\/\/|www\.)\S+$|^\/[\w\-./]*(\?\S*)?$/i],
+  [SuggestionReason.EMAIL, /^[^\s@]+@[^\s@]+\.[^\s@]+$/],
+  [SuggestionReason.DATE, /^\d{4}-\d{2}-\d{2}([T ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?)?$/],
+  [
+    SuggestionReason.ID,
+    /^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$|^[0-9a-f]{24,64}$|^[A-Za-z]{2,5}[-_]?\d{3,}$/i,
+  ],
+  [SuggestionReason.COLOR, /^#(?:[0-9a-f]{3}){1,2}$|^rgba?\([\d\s.,%]+\)$/i],
+  [SuggestionReason.NUMERIC, /^(?=.*\d)[-+\d\s.,:/%]+$/],
+  [SuggestionReason.SLUG, /^[a-z0-9]+(?:-[a-z0-9]+)+$/],
+  // SCREAMING_SNAKE_CASE、snake_case 常量
+  [SuggestionReason.ENUM, /^[A-Z][A-Z0-9]*(?:_[A-Z0-9]+)+$|^[a-z][a-z0-9]*(?:_[a-z0-9]+)+$/],
+];
+
+/** 键名本身暗示值不需要翻译 */
+const KEY_HINTS: [SuggestionReason, RegExp][] = [
+  [SuggestionReason.ID, /^(id|uuid|guid|sku|key|code)$|(_id|Id|ID|_key|Key|_code|Code)$/],
+  [SuggestionReason.URL, /(url|uri|href|link|src|path|image|icon)$/i],
+  [SuggestionReason.EMAIL, /e-?mail$/i],
+  [SuggestionReason.DATE, /(date|_at|At|time|timestamp)$/],
+  [SuggestionReason.SLUG, /slug$/i],
+  [SuggestionReason.ENUM, /^(type|status|kind|state|variant|mode|locale|lang|currency)$|(Type|Status|_type|_status)$/],
+  [SuggestionReason.COLOR, /colou?r$/i],
+];
+
+/** 只有一个词且不含空格的值才可能是枚举 */
+const ENUM_VALUE = /^[\w-]{1,32}$/;
+
+interface FieldStats {
+  field: string;
+  values: string[];
+}
+
+/**
+ * 分析样例文档，找出很可能不应翻译的字段（ID、链接、枚举、日期、slug 等）
+ * 同一路径（数组下标归并）下的字符串值按规则匹配的比例打分，键名命中提示时加分；
+ * 多个数组元素中反复出现的少量单词值视为枚举
+ */
+export function suggestIgnoredFields(document: any, options: FieldSuggestionOptions = {}): FieldSuggestion[] {
+  const minConfidence = options.minConfidence ?? 0.5;
+  const stats = new Map<string, FieldStats>();
+  collect(document, [], stats);
+
+  const suggestions: FieldSuggestion[] = [];
+  for (const [path, { field, values }] of stats) {
+    const scored = score(field, values);
+    if (!scored || scored.confidence < minConfidence) {
+      continue;
+    }
+    suggestions.push({
+      path,
+      field,
+      reason: scored.reason,
+      confidence: scored.confidence,
+      occurrences: values.length,
+      samples: [...new Set(values)].slice(0, 3),
+    });
+  }
+  suggestions.sort((a, b) => b.confidence - a.confidence || a.path.localeCompare(b.path));
+  return options.limit ? suggestions.slice(0, options.limit) : suggestions;
+}
+
+function collect(value: any, path: string[], stats: Map<string, FieldStats>): void {
+  if (Array.isArray(value)) {
+    value.forEach((item) => collect(item, [...path, '*'], stats));
+    return;
+  }
+  if (isPlainObject(value)) {
+    for (const [key, child] of Object.entries(value)) {
+      collect(child, [...path, key], stats);
+    }
+    return;
+  }
+  if (typeof value !== 'string' || value.trim() === '') {
+    return;
+  }
+  const field = [...path].reverse().find((segment) => segment !== '*');
+  if (!field) {
+    return;
+  }
+  const key = path.join('.');
+  const entry = stats.get(key) ?? { field, values: [] };
+  entry.values.push(value.trim());
+  stats.set(key, entry);
+}
+
+interface ScoredField {
+  reason: SuggestionReason;
+  confidence: number;
+}
+
+function score(field: string, values: string[]): ScoredField | undefined {
+  const hint = KEY_HINTS.find(([, pattern]) => pattern.test(field))?.[0];
+  const candidates: ScoredField[] = [];
+  // 值的匹配比例决定基础分，键名提示同一原因时加 0.2
+  const consider = (reason: SuggestionReason, ratio: number, weight = 0.8) => {
+    const confidence = Math.min(1, ratio * weight + (hint === reason ? 0.2 : 0));
+    candidates.push({ reason, confidence: Math.round(confidence * 100) / 100 });
+  };
+
+  for (const [reason, pattern] of VALUE_PATTERNS) {
+    const ratio = values.filter((value) => pattern.test(value)).length / values.length;
+    if (ratio > 0) {
+      consider(reason, ratio);
+    }
+  }
+
+  const distinct = new Set(values);
+  if (values.length >= 3 && distinct.size <= Math.max(1, values.length / 3)) {
+    const ratio = values.filter((value) => ENUM_VALUE.test(value)).length / values.length;
+    if (ratio > 0) {
+      // 重复出现的单词也可能是按钮文案等需要翻译的短语，权重较低
+      consider(SuggestionReason.ENUM, ratio, 0.6);
+    }
+  }
+
+  // 同分时取先匹配的规则（日期优先于数字）
+  const best = candidates.reduce<ScoredField | undefined>(
+    (top, candidate) => (!top || candidate.confidence > top.confidence ? candidate : top),
+    undefined,
+  );
+  if (hint && (!best || best.confidence < 0.5) && values.every((value) => !/\s/.test(value))) {
+    // 键名提示但值没有规律（例如自定义格式的编号），值中不含空格时仍给出低置信度的建议
+    return { reason: hint, confidence: 0.5 };
+  }
+  return best;
+}
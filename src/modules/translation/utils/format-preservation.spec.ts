@@ -0,0 +1,41 @@
+import { formattingIssues, FormatIssueType, repairFormatting } from './format-preservation';
+import { PlaceholderStyle } from './placeholders';
+
+describe('format-preservation', () => {
+  describe('repairFormatting', () => {
+    it('按原文恢复首尾空白和换行符风格', () => {
+      expect(repairFormatting('  Hello\r\nworld ', 'Hallo\nWelt')).toEqual({
+        text: '  Hallo\r\nWelt ',
+        repaired: [FormatIssueType.LINE_BREAKS, FormatIssueType.WHITESPACE],
+      });
+    });
+
+    it('恢复只改了大小写的占位符', () => {
+      const { text, repaired } = repairFormatting(
+        'Hi {{name}}, you have {{count}}',
+        'Hallo {{Name}}, Sie haben {{count}}',
+        [PlaceholderStyle.I18NEXT],
+      );
+
+      expect(text).toBe('Hallo {{name}}, Sie haben {{count}}');
+      expect(repaired).toEqual([FormatIssueType.PLACEHOLDER_CASE]);
+    });
+
+    it('格式一致时原样返回', () => {
+      expect(repairFormatting('Save', 'Speichern')).toEqual({ text: 'Speichern', repaired: [] });
+    });
+  });
+
+  describe('formattingIssues', () => {
+    it('标记换行数量不一致，以及未修复的空白和占位符大小写', () => {
+      const issues = formattingIssues(' Line one\nLine two', 'Zeile eins Zeile {Two}', []);
+
+      expect(issues.map((issue) => issue.type)).toEqual([FormatIssueType.WHITESPACE, FormatIssueType.LINE_BREAKS]);
+      expect(issues[1].message).toBe('Expected 1 line break(s), got 0');
+      expect(formattingIssues('Hi {name}', 'Hallo {Name}')[0]).toEqual({
+        type: FormatIssueType.PLACEHOLDER_CASE,
+        message: 'Placeholder case changed: {Name} → {name}',
+      });
+    });
+  });
+});
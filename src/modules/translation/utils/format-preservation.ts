@@ -0,0 +1,132 @@
+import { extractPlaceholders, PlaceholderStyle } from './placeholders';
+
+/**
+ * 格式保留检查：首尾空白、占位符大小写和换行
+ * 翻译服务常会去掉首尾空格、统一换行符或改写占位符的大小写（{name} → {Name}），
+ * 这些差异可以按原文直接修复；换行数量不一致无法判断该在哪里断行，只标记
+ */
+export enum FormatIssueType {
+  WHITESPACE = 'whitespace',
+  PLACEHOLDER_CASE = 'placeholder_case',
+  LINE_BREAKS = 'line_breaks',
+}
+
+export interface FormatIssue {
+  type: FormatIssueType;
+  message: string;
+}
+
+export interface FormatRepair {
+  text: string;
+  /** 本次修复的差异类型 */
+  repaired: FormatIssueType[];
+}
+
+const LEADING = /^\s*/;
+const TRAILING = /\s*$/;
+
+/**
+ * 按原文修复译文中的简单格式差异：换行符风格、占位符大小写、首尾空白
+ */
+export function repairFormatting(source: string, translated: string, styles: PlaceholderStyle[] = []): FormatRepair {
+  const repaired = new Set<FormatIssueType>();
+  let text = translated;
+
+  const lineBreak = lineBreakStyle(source);
+  if (lineBreak) {
+    const normalized = text.replace(/\r?\n/g, lineBreak);
+    if (normalized !== text) {
+      text = normalized;
+      repaired.add(FormatIssueType.LINE_BREAKS);
+    }
+  }
+
+  for (const [found, expected] of miscasedPlaceholders(source, text, styles)) {
+    text = text.split(found).join(expected);
+    repaired.add(FormatIssueType.PLACEHOLDER_CASE);
+  }
+
+  // 全是空白的原文不翻译，不需要处理
+  if (source.trim()) {
+    const leading = source.match(LEADING)[0];
+    const trailing = source.match(TRAILING)[0];
+    const trimmed = text.trim();
+    if (`${leading}${trimmed}${trailing}` !== text) {
+      text = `${leading}${trimmed}${trailing}`;
+      repaired.add(FormatIssueType.WHITESPACE);
+    }
+  }
+
+  return { text, repaired: [...repaired] };
+}
+
+/**
+ * 译文与原文的格式差异，用于校验报告（包括人工修改的译文）
+ */
+export function formattingIssues(source: string, translated: string, styles: PlaceholderStyle[] = []): FormatIssue[] {
+  const issues: FormatIssue[] = [];
+  if (source.trim() && translated.trim()) {
+    if (source.match(LEADING)[0] !== translated.match(LEADING)[0]) {
+      issues.push({ type: FormatIssueType.WHITESPACE, message: 'Leading whitespace differs from the source' });
+    }
+    if (source.match(TRAILING)[0] !== translated.match(TRAILING)[0]) {
+      issues.push({ type: FormatIssueType.WHITESPACE, message: 'Trailing whitespace differs from the source' });
+    }
+  }
+
+  const miscased = miscasedPlaceholders(source, translated, styles);
+  if (miscased.length > 0) {
+    issues.push({
+      type: FormatIssueType.PLACEHOLDER_CASE,
+      message: `Placeholder case changed: ${miscased.map(([found, expected]) => `${found} → ${expected}`).join(', ')}`,
+    });
+  }
+
+  const expected = innerLineBreaks(source);
+  const actual = innerLineBreaks(translated);
+  if (expected !== actual) {
+    issues.push({
+      type: FormatIssueType.LINE_BREAKS,
+      message: `Expected ${expected} line break(s), got ${actual}`,
+    });
+  }
+  return issues;
+}
+
+/**
+ * 原文只使用一种换行符时返回该换行符；没有换行或混用时返回 undefined
+ */
+function lineBreakStyle(text: string): string | undefined {
+  const crlf = (text.match(/\r\n/g) ?? []).length;
+  const lf = (text.match(/\n/g) ?? []).length;
+  if (lf === 0) {
+    return undefined;
+  }
+  if (crlf === lf) {
+    return '\r\n';
+  }
+  return crlf === 0 ? '\n' : undefined;
+}
+
+/** 首尾空白之外的换行数量 */
+function innerLineBreaks(text: string): number {
+  return (text.trim().match(/\n/g) ?? []).length;
+}
+
+/**
+ * 译文中只有大小写与原文占位符不同的占位符：[译文中的写法, 原文中的写法]
+ */
+function miscasedPlaceholders(source: string, translated: string, styles: PlaceholderStyle[]): [string, string][] {
+  const expected = new Set(extractPlaceholders(source, styles));
+  const result = new Map<string, string>();
+  for (const found of extractPlaceholders(translated, styles)) {
+    if (expected.has(found) || result.has(found)) {
+      continue;
+    }
+    const match = [...expected].find((placeholder) => placeholder.toLowerCase() === found.toLowerCase());
+    if (match) {
+      result.set(found, match);
+    }
+  }
+  return [...result.entries()];
+}
@@ -0,0 +1,52 @@
+import { isIcuChoiceMessage, parseIcuMessage, translateIcuMessage } from './icu-message';
+
+describe('icu-message', () => {
+  const dictionary: Record<string, string> = {
+    'You have': 'Sie haben',
+    'no messages': 'keine Nachrichten',
+    '# message': '# Nachricht',
+    '# messages': '# Nachrichten',
+    'from {name}.': 'von {name}.',
+    'He replied': 'Er hat geantwortet',
+    'She replied': 'Sie hat geantwortet',
+    'They replied': 'Sie haben geantwortet',
+  };
+  const translate = jest.fn(async (text: string) => dictionary[text] ?? text);
+
+  afterEach(() => translate.mockClear());
+
+  it('只翻译分支内的文字，保留参数、关键字和 #', async () => {
+    const message =
+      'You have {count, plural, =0 {no messages} one {# message} other {# messages}} from {name}.';
+
+    await expect(translateIcuMessage(message, translate)).resolves.toBe(
+      'Sie haben {count, plural, =0 {keine Nachrichten} one {# Nachricht} other {# Nachrichten}} von {name}.',
+    );
+    expect(translate).not.toHaveBeenCalledWith(expect.stringContaining('plural'));
+  });
+
+  it('应支持 select、offset 和嵌套结构', async () => {
+    const message =
+      '{gender, select, male {He replied} female {She replied} other {{n, plural, offset:1 one {They replied} other {They replied}}}}';
+
+    await expect(translateIcuMessage(message, translate)).resolves.toBe(
+      '{gender, select, male {Er hat geantwortet} female {Sie hat geantwortet} other ' +
+        '{{n, plural, offset:1 one {Sie haben geantwortet} other {Sie haben geantwortet}}}}',
+    );
+  });
+
+  it('普通字符串和格式不完整的消息不按 ICU 处理', () => {
+    expect(isIcuChoiceMessage('Hello {name}')).toBe(false);
+    expect(isIcuChoiceMessage('{count, plural, one {# item}')).toBe(false);
+    expect(isIcuChoiceMessage('{count, plural, one {# item}}')).toBe(false);
+    expect(isIcuChoiceMessage("{count, plural, one {# item} other {# items, it''s '{'ok'}'}}")).toBe(true);
+  });
+
+  it('其他类型的参数原样保留', () => {
+    expect(parseIcuMessage('{d, date, ::yyyyMMdd} {n, number}')).toEqual([
+      { type: 'argument', raw: '{d, date, ::yyyyMMdd}' },
+      { type: 'text', value: ' ' },
+      { type: 'argument', raw: '{n, number}' },
+    ]);
+  });
+});
@@ -0,0 +1,255 @@
+/**
+ * ICU MessageFormat 的 plural / select 结构
+ * 翻译时只把各分支内的文字交给翻译服务，参数名、分支关键字、offset 和 # 保持原样，避免机器翻译破坏语法
+ */
+export type IcuNode =
+  | { type: 'text'; value: string }
+  | { type: 'pound' }
+  | { type: 'argument'; raw: string }
+  | { type: 'choice'; name: string; kind: string; offset?: string; options: IcuOption[] };
+
+export interface IcuOption {
+  selector: string;
+  message: IcuNode[];
+}
+
+const CHOICE_KINDS = new Set(['plural', 'select', 'selectordinal']);
+const CHOICE_PATTERN = /\{\s*[\w.]+\s*,\s*(plural|select|selectordinal)\s*,/;
+const LETTER_PATTERN = /\p{L}/u;
+
+/**
+ * 是否包含可解析的 plural / select 结构
+ */
+export function isIcuChoiceMessage(text: string): boolean {
+  if (!CHOICE_PATTERN.test(text)) {
+    return false;
+  }
+  try {
+    return parseIcuMessage(text).some((node) => node.type === 'choice');
+  } catch {
+    return false;
+  }
+}
+
+export function parseIcuMessage(text: string): IcuNode[] {
+  const parser = new IcuParser(text);
+  const nodes = parser.parseMessage(false, false);
+  if (!parser.done()) {
+    throw new Error(`Unexpected "}" at ${parser.position}`);
+  }
+  return nodes;
+}
+
+export function printIcuMessage(nodes: IcuNode[]): string {
+  return nodes
+    .map((node) => {
+      switch (node.type) {
+        case 'text':
+          return node.value;
+        case 'pound':
+          return '#';
+        case 'argument':
+          return node.raw;
+        case 'choice': {
+          const offset = node.offset ? ` offset:${node.offset}` : '';
+          const options = node.options.map((option) => `${option.selector} {${printIcuMessage(option.message)}}`);
+          return `{${node.name}, ${node.kind},${offset} ${options.join(' ')}}`;
+        }
+      }
+    })
+    .join('');
+}
+
+/**
+ * 逐段翻译：相邻的文字、简单参数和 # 作为一段整体翻译（保留语境），分支递归处理
+ */
+export async function translateIcuMessage(text: string, translate: (text: string) => Promise<string>): Promise<string> {
+  return printIcuMessage(await translateNodes(parseIcuMessage(text), translate));
+}
+
+async function translateNodes(nodes: IcuNode[], translate: (text: string) => Promise<string>): Promise<IcuNode[]> {
+  const result: IcuNode[] = [];
+  let run = '';
+  const flush = async () => {
+    if (run) {
+      result.push({ type: 'text', value: LETTER_PATTERN.test(run) ? await translateRun(run, translate) : run });
+      run = '';
+    }
+  };
+
+  for (const node of nodes) {
+    if (node.type === 'choice') {
+      await flush();
+      const options: IcuOption[] = [];
+      for (const option of node.options) {
+        options.push({ selector: option.selector, message: await translateNodes(option.message, translate) });
+      }
+      result.push({ ...node, options });
+    } else {
+      run += node.type === 'text' ? node.value : node.type === 'pound' ? '#' : node.raw;
+    }
+  }
+  await flush();
+  return result;
+}
+
+/**
+ * 翻译服务通常会去掉首尾空白，这里把它们留在原位
+ */
+async function translateRun(run: string, translate: (text: string) => Promise<string>): Promise<string> {
+  const [, leading, body, trailing] = run.match(/^(\s*)([\s\S]*?)(\s*)$/);
+  return `${leading}${(await translate(body)).trim()}${trailing}`;
+}
+
+class IcuParser {
+  position = 0;
+
+  constructor(private readonly text: string) {}
+
+  done(): boolean {
+    return this.position >= this.text.length;
+  }
+
+  parseMessage(nested: boolean, inPlural: boolean): IcuNode[] {
+    const nodes: IcuNode[] = [];
+    let text = '';
+    const pushText = () => {
+      if (text) {
+        nodes.push({ type: 'text', value: text });
+        text = '';
+      }
+    };
+
+    while (!this.done()) {
+      const char = this.text[this.position];
+      if (char === '}') {
+        if (!nested) {
+          break;
+        }
+        pushText();
+        return nodes;
+      }
+      if (char === '{') {
+        pushText();
+        nodes.push(this.parseArgument(inPlural));
+      } else if (char === '#' && inPlural) {
+        pushText();
+        nodes.push({ type: 'pound' });
+        this.position++;
+      } else if (char === "'") {
+        text += this.readQuoted(inPlural);
+      } else {
+        text += char;
+        this.position++;
+      }
+    }
+
+    if (nested) {
+      throw new Error('Unterminated ICU message');
+    }
+    pushText();
+    return nodes;
+  }
+
+  /**
+   * '' 表示单引号，'{…}' 等引起来的语法字符按原样保留为文字
+   */
+  private readQuoted(inPlural: boolean): string {
+    const next = this.text[this.position + 1];
+    if (next === "'") {
+      this.position += 2;
+      return "''";
+    }
+    if (next !== '{' && next !== '}' && !(next === '#' && inPlural)) {
+      this.position++;
+      return "'";
+    }
+    const end = this.text.indexOf("'", this.position + 1);
+    const stop = end === -1 ? this.text.length : end + 1;
+    const quoted = this.text.slice(this.position, stop);
+    this.position = stop;
+    return quoted;
+  }
+
+  private parseArgument(inPlural: boolean): IcuNode {
+    const start = this.position;
+    this.position++;
+    const name = this.readUntil(/[,}]/).trim();
+    if (this.text[this.position] === '}') {
+      this.position++;
+      return { type: 'argument', raw: this.text.slice(start, this.position) };
+    }
+
+    this.position++;
+    const kind = this.readUntil(/[,}]/).trim();
+    if (!CHOICE_KINDS.has(kind)) {
+      this.skipBalanced();
+      return { type: 'argument', raw: this.text.slice(start, this.position) };
+    }
+    if (this.text[this.position] !== ',') {
+      throw new Error(`Missing options for ${kind} argument "${name}"`);
+    }
+    this.position++;
+
+    let offset: string | undefined;
+    this.skipWhitespace();
+    const offsetMatch = this.text.slice(this.position).match(/^offset:\s*(\d+)/);
+    if (offsetMatch) {
+      offset = offsetMatch[1];
+      this.position += offsetMatch[0].length;
+    }
+
+    const options: IcuOption[] = [];
+    for (;;) {
+      this.skipWhitespace();
+      if (this.text[this.position] === '}') {
+        this.position++;
+        break;
+      }
+      const selector = this.readUntil(/[\s{}]/);
+      this.skipWhitespace();
+      if (!selector || this.text[this.position] !== '{') {
+        throw new Error(`Invalid ${kind} option at ${this.position}`);
+      }
+      this.position++;
+      const message = this.parseMessage(true, inPlural || kind !== 'select');
+      this.position++;
+      options.push({ selector, message });
+    }
+    if (!options.some((option) => option.selector === 'other')) {
+      throw new Error(`${kind} argument "${name}" has no "other" option`);
+    }
+    return { type: 'choice', name, kind, offset, options };
+  }
+
+  private readUntil(pattern: RegExp): string {
+    const start = this.position;
+    while (!this.done() && !pattern.test(this.text[this.position])) {
+      this.position++;
+    }
+    if (this.done()) {
+      throw new Error('Unterminated ICU argument');
+    }
+    return this.text.slice(start, this.position);
+  }
+
+  private skipWhitespace(): void {
+    while (!this.done() && /\s/.test(this.text[this.position])) {
+      this.position++;
+    }
+  }
+
+  /**
+   * 跳过 {d, date, ::yyyy} 之类的参数余下部分（可能包含嵌套花括号）
+   */
+  private skipBalanced(): void {
+    let depth = 1;
+    while (!this.done() && depth > 0) {
+      const char = this.text[this.position++];
+      depth += char === '{' ? 1 : char === '}' ? -1 : 0;
+    }
+    if (depth > 0) {
+      throw new Error('Unterminated ICU argument');
+    }
+  }
+}
@@ -0,0 +1,54 @@
+import { IgnoreMatcher, SkipHeuristic } from './ignore-rules';
+
+describe('IgnoreMatcher', () => {
+  it('没有规则时不需要匹配', () => {
+    expect(IgnoreMatcher.compile(undefined)).toBeUndefined();
+    expect(IgnoreMatcher.compile({ paths: [], skip: [] })).toBeUndefined();
+  });
+
+  it('应按键名、路径通配符和正则忽略节点', () => {
+    const matcher = IgnoreMatcher.compile({
+      fields: ['id'],
+      paths: ['meta.**', 'items.*.sku'],
+      patterns: ['(^|\\.)_'],
+    });
+
+    expect(matcher.skipsNode(['user', 'id'])).toBe(true);
+    expect(matcher.skipsNode(['meta', 'seo', 'title'])).toBe(true);
+    expect(matcher.skipsNode(['items', '3', 'sku'])).toBe(true);
+    expect(matcher.skipsNode(['config', '_internal'])).toBe(true);
+    expect(matcher.skipsNode(['items', '3', 'label'])).toBe(false);
+  });
+
+  it('设置 include 时只翻译这些路径下的字符串', () => {
+    const matcher = IgnoreMatcher.compile({ include: ['ui'] });
+
+    expect(matcher.skipsLeaf(['ui', 'buttons', 'ok'], 'OK')).toBe(false);
+    expect(matcher.skipsLeaf(['errors', 'notFound'], 'Not found')).toBe(true);
+  });
+
+  it('应按值跳过链接、邮箱、数字、UUID、颜色和常量名', () => {
+    const matcher = IgnoreMatcher.compile({ skip: Object.values(SkipHeuristic) });
+    const skipped = [
+      'https://example.com/a',
+      'ops@example.com',
+      '1,299.00',
+      '2026-10-16',
+      '3f2b8c1e-1d2a-4b5c-9e8f-0a1b2c3d4e5f',
+      '#ff8800',
+      'ORDER_STATUS_PAID',
+    ];
+
+    for (const value of skipped) {
+      expect(matcher.skipsLeaf(['value'], value)).toBe(true);
+    }
+    expect(matcher.skipsLeaf(['value'], 'Version 2')).toBe(false);
+  });
+
+  it('扁平化遍历时检查祖先节点', () => {
+    const matcher = IgnoreMatcher.compile({ paths: ['meta'] });
+
+    expect(matcher.excludes(['meta', 'a', 'b'], 'text')).toBe(true);
+    expect(matcher.excludes(['body'], 'text')).toBe(false);
+  });
+});
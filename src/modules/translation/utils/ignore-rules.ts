@@ -0,0 +1,106 @@
+import { JsonPath } from './json-diff';
+import { compileKeyPattern } from './translation-lint';
+
+/** 按值跳过的规则：命中的字符串保留原文，不计费 */
+export enum SkipHeuristic {
+  /** http(s):// 或 www. 开头的链接 */
+  URLS = 'urls',
+  EMAILS = 'emails',
+  /** 只包含数字和常见分隔符的值（价格、日期、版本号等） */
+  NUMERIC = 'numeric',
+  UUIDS = 'uuids',
+  /** #fff / #ffffff 形式的颜色值 */
+  COLORS = 'colors',
+  /** SCREAMING_SNAKE_CASE 形式的常量名 */
+  CONSTANTS = 'constants',
+}
+
+const HEURISTICS: Record<SkipHeuristic, RegExp> = {
+  [SkipHeuristic.URLS]: /^(https?:\/\/|www\.)\S+$/i,
+  [SkipHeuristic.EMAILS]: /^[^\s@]+@[^\s@]+\.[^\s@]+$/,
+  [SkipHeuristic.NUMERIC]: /^(?=.*\d)[-+\d\s.,:/%]+$/,
+  [SkipHeuristic.UUIDS]: /^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$/i,
+  [SkipHeuristic.COLORS]: /^#(?:[0-9a-f]{3}){1,2}$/i,
+  [SkipHeuristic.CONSTANTS]: /^[A-Z][A-Z0-9]*(?:_[A-Z0-9]+)+$/,
+};
+
+/**
+ * 忽略规则（来自忽略配置，创建任务时保存快照到文档上）
+ */
+export interface IgnoreRules {
+  /** 忽略的键名，任意层级匹配（与 ignoredFields 相同） */
+  fields?: string[];
+  /** 忽略的路径（点号路径，* 匹配一段，** 匹配任意多段），匹配的节点整体保留原文 */
+  paths?: string[];
+  /** 匹配完整点号路径的正则表达式 */
+  patterns?: string[];
+  /** 只翻译这些路径下的字符串（写法同 paths），为空表示全部 */
+  include?: string[];
+  skip?: SkipHeuristic[];
+}
+
+/**
+ * 编译后的忽略规则
+ * 节点规则（键名、路径、正则）命中时整棵子树保留原文；include 和跳过规则只作用于字符串叶子
+ */
+export class IgnoreMatcher {
+  private readonly fields: Set<string>;
+  private readonly paths: RegExp[];
+  private readonly include: RegExp[];
+  private readonly heuristics: RegExp[];
+
+  private constructor(rules: IgnoreRules) {
+    this.fields = new Set(rules.fields ?? []);
+    this.paths = [
+      ...(rules.paths ?? []).map((pattern) => compileKeyPattern(pattern)),
+      ...(rules.patterns ?? []).map((pattern) => new RegExp(pattern)),
+    ];
+    this.include = (rules.include ?? []).map((pattern) => compileKeyPattern(pattern));
+    this.heuristics = (rules.skip ?? []).map((heuristic) => HEURISTICS[heuristic]).filter(Boolean);
+  }
+
+  /**
+   * 没有规则时返回 undefined，调用方不必做任何额外检查
+   */
+  static compile(rules?: IgnoreRules | null): IgnoreMatcher | undefined {
+    if (!rules || !Object.values(rules).some((values) => Array.isArray(values) && values.length > 0)) {
+      return undefined;
+    }
+    return new IgnoreMatcher(rules);
+  }
+
+  /** 对象键或数组元素被忽略，整个子树保留原文 */
+  skipsNode(path: JsonPath): boolean {
+    if (path.length === 0) {
+      return false;
+    }
+    if (this.fields.has(path[path.length - 1])) {
+      return true;
+    }
+    const key = path.join('.');
+    return this.paths.some((pattern) => pattern.test(key));
+  }
+
+  /** 字符串叶子不在 include 范围内，或命中按值跳过的规则 */
+  skipsLeaf(path: JsonPath, text: string): boolean {
+    if (this.include.length > 0) {
+      const included = path.some((_, index) => {
+        const prefix = path.slice(0, index + 1).join('.');
+        return this.include.some((pattern) => pattern.test(prefix));
+      });
+      if (!included) {
+        return true;
+      }
+    }
+    const value = text.trim();
+    return this.heuristics.some((pattern) => pattern.test(value));
+  }
+
+  /** 扁平化遍历时判断一个叶子是否被排除（检查每一级祖先节点） */
+  excludes(path: JsonPath, value: unknown): boolean {
+    if (path.some((_, index) => this.skipsNode(path.slice(0, index + 1)))) {
+      return true;
+    }
+    return typeof value === 'string' && this.skipsLeaf(path, value);
+  }
+}
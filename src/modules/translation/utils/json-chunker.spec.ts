@@ -0,0 +1,16 @@
+import { chunkJsonPaths } from './json-chunker';
+
+describe('json-chunker', () => {
+  it('应按文档顺序切分叶子，每片不超过目标字符数', () => {
+    const source = { a: '1234', b: { c: '12345', d: '12' }, e: '123456789' };
+
+    const chunks = chunkJsonPaths(source, 10);
+
+    expect(chunks.map((chunk) => chunk.paths)).toEqual([[['a'], ['b', 'c']], [['b', 'd']], [['e']]]);
+    expect(chunks.map((chunk) => chunk.chars)).toEqual([9, 2, 9]);
+  });
+
+  it('超过目标字符数的单个叶子独占一片', () => {
+    expect(chunkJsonPaths({ a: 'x'.repeat(50), b: 'y' }, 10).map((chunk) => chunk.chars)).toEqual([50, 1]);
+  });
+});
@@ -0,0 +1,35 @@
+import { flattenJson, JsonPath } from './json-diff';
+
+export interface JsonChunk {
+  paths: JsonPath[];
+  chars: number;
+}
+
+function leafChars(value: any): number {
+  if (typeof value === 'string') {
+    return value.length;
+  }
+  return JSON.stringify(value)?.length ?? 0;
+}
+
+/**
+ * 按文档顺序把叶子切成若干分片，每片的字符数尽量不超过 targetChars（单个叶子超出时独占一片）
+ */
+export function chunkJsonPaths(source: any, targetChars: number): JsonChunk[] {
+  const chunks: JsonChunk[] = [];
+  let current: JsonChunk = { paths: [], chars: 0 };
+
+  for (const [key, value] of flattenJson(source)) {
+    const chars = leafChars(value);
+    if (current.paths.length > 0 && current.chars + chars > targetChars) {
+      chunks.push(current);
+      current = { paths: [], chars: 0 };
+    }
+    current.paths.push(JSON.parse(key));
+    current.chars += chars;
+  }
+  if (current.paths.length > 0) {
+    chunks.push(current);
+  }
+  return chunks;
+}
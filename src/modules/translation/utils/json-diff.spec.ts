@@ -0,0 +1,39 @@
+import { diffJson, mergeTranslation, pathKey, pickPaths } from './json-diff';
+
+describe('json-diff', () => {
+  const previous = { title: 'Hello', nav: { home: 'Home', about: 'About' }, tags: ['a', 'b'] };
+
+  it('应找出新增、修改和删除的叶子', () => {
+    const next = { title: 'Hello!', nav: { home: 'Home', contact: 'Contact' }, tags: ['a', 'b'] };
+
+    expect(diffJson(previous, next)).toEqual({
+      changed: [['title'], ['nav', 'contact']],
+      removed: [['nav', 'about']],
+    });
+  });
+
+  it('键顺序变化不算差异，数组任一元素变化时整体视为变化', () => {
+    const reordered = { tags: ['a', 'b'], nav: { about: 'About', home: 'Home' }, title: 'Hello' };
+    expect(diffJson(previous, reordered)).toEqual({ changed: [], removed: [] });
+
+    expect(diffJson(previous, { ...previous, tags: ['a', 'c'] }).changed).toEqual([['tags']]);
+  });
+
+  it('只挑出变化的叶子并保持层级', () => {
+    const next = { title: 'Hello', nav: { home: 'Start', about: 'About' } };
+
+    expect(pickPaths(next, [['nav', 'home']])).toEqual({ nav: { home: 'Start' } });
+  });
+
+  it('合并时变化的键取新译文，其余沿用旧译文，删除的键丢弃', () => {
+    const next = { title: 'Hello', nav: { home: 'Start', contact: 'Contact' } };
+    const previousTranslation = { title: 'Bonjour', nav: { home: 'Accueil', about: 'À propos' } };
+    const delta = { nav: { home: 'Démarrer', contact: 'Contact FR' } };
+    const changed = new Set([pathKey(['nav', 'home']), pathKey(['nav', 'contact'])]);
+
+    expect(mergeTranslation(next, previousTranslation, delta, changed)).toEqual({
+      title: 'Bonjour',
+      nav: { home: 'Démarrer', contact: 'Contact FR' },
+    });
+  });
+});
@@ -0,0 +1,109 @@
+/**
+ * JSON 文档的叶子级差异
+ * 只有普通对象会被展开，字符串、数字、数组等都视为叶子（数组任一元素变化时整体重译）
+ */
+export type JsonPath = string[];
+
+export interface JsonDiff {
+  /** 新增或值发生变化的叶子 */
+  changed: JsonPath[];
+  /** 已从新文档中删除的叶子 */
+  removed: JsonPath[];
+}
+
+export function isPlainObject(value: unknown): value is Record<string, any> {
+  return value !== null && typeof value === 'object' && !Array.isArray(value);
+}
+
+export function pathKey(path: JsonPath): string {
+  return JSON.stringify(path);
+}
+
+export function flattenJson(value: any, prefix: JsonPath = [], result = new Map<string, any>()): Map<string, any> {
+  if (isPlainObject(value)) {
+    for (const [key, item] of Object.entries(value)) {
+      flattenJson(item, [...prefix, key], result);
+    }
+  } else if (prefix.length > 0) {
+    result.set(pathKey(prefix), value);
+  }
+  return result;
+}
+
+export function diffJson(previous: any, next: any): JsonDiff {
+  const before = flattenJson(previous);
+  const after = flattenJson(next);
+  const changed: JsonPath[] = [];
+  const removed: JsonPath[] = [];
+
+  for (const [key, value] of after) {
+    if (!before.has(key) || JSON.stringify(before.get(key)) !== JSON.stringify(value)) {
+      changed.push(JSON.parse(key));
+    }
+  }
+  for (const key of before.keys()) {
+    if (!after.has(key)) {
+      removed.push(JSON.parse(key));
+    }
+  }
+  return { changed, removed };
+}
+
+export function getPath(source: any, path: JsonPath): any {
+  return path.reduce((node, key) => (isPlainObject(node) ? node[key] : undefined), source);
+}
+
+export function setPath(target: Record<string, any>, path: JsonPath, value: any): void {
+  let node = target;
+  for (const key of path.slice(0, -1)) {
+    if (!isPlainObject(node[key])) {
+      node[key] = {};
+    }
+    node = node[key];
+  }
+  node[path[path.length - 1]] = value;
+}
+
+/**
+ * 只保留指定叶子的子文档，保持原有层级结构
+ */
+export function pickPaths(source: any, paths: JsonPath[]): Record<string, any> {
+  const result: Record<string, any> = {};
+  for (const path of paths) {
+    setPath(result, path, getPath(source, path));
+  }
+  return result;
+}
+
+/**
+ * 以新源文档的结构为准合并译文：变化的叶子取本次译文，其余沿用上一次的译文，已删除的键自然丢弃
+ */
+export function mergeTranslation(
+  source: any,
+  previousTranslation: any,
+  deltaTranslation: any,
+  changed: Set<string>,
+  prefix: JsonPath = [],
+): any {
+  if (isPlainObject(source)) {
+    const result: Record<string, any> = {};
+    for (const [key, item] of Object.entries(source)) {
+      result[key] = mergeTranslation(item, previousTranslation, deltaTranslation, changed, [...prefix, key]);
+    }
+    return result;
+  }
+
+  if (changed.has(pathKey(prefix))) {
+    return getPath(deltaTranslation, prefix);
+  }
+  const previous = getPath(previousTranslation, prefix);
+  return previous === undefined ? source : previous;
+}
+
+/**
+ * 紧凑差异：变化的叶子按原有层级取新值，删除的叶子只给出路径
+ */
+export function compactDiff(previous: any, next: any): { changed: Record<string, any>; removed: JsonPath[] } {
+  const { changed, removed } = diffJson(previous, next);
+  return { changed: pickPaths(next, changed), removed };
+}
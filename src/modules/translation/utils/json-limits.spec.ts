@@ -0,0 +1,31 @@
+import { assertJsonWithinLimits, JsonLimitError, JsonLimitScanner } from './json-limits';
+
+describe('json-limits', () => {
+  const limits = { maxBytes: 1000, maxDepth: 3, maxKeys: 4 };
+
+  it('未超限时通过', () => {
+    expect(() => assertJsonWithinLimits('{"a":{"b":["x","y"]},"c":"{\\"d\\":1}"}', limits)).not.toThrow();
+  });
+
+  it('应按嵌套深度拒绝', () => {
+    expect(() => assertJsonWithinLimits('{"a":{"b":{"c":{}}}}', limits)).toThrow('JSON nesting exceeds 3 levels');
+  });
+
+  it('只统计对象的键，字符串值和数组元素不计', () => {
+    expect(() => assertJsonWithinLimits('{"a":"b","c":["d","e","f","g"],"h":"i"}', limits)).not.toThrow();
+    expect(() => assertJsonWithinLimits('{"a":1,"b":2,"c":3,"d":4,"e":5}', limits)).toThrow(JsonLimitError);
+  });
+
+  it('应按 UTF-8 字节数拒绝', () => {
+    expect(() => assertJsonWithinLimits(`{"a":"${'你'.repeat(400)}"}`, limits)).toThrow('JSON content exceeds 1000 bytes');
+  });
+
+  it('键被拆到不同分块时仍能正确识别', () => {
+    const scanner = new JsonLimitScanner({ ...limits, maxKeys: 1 });
+    scanner.write('{"fir');
+    scanner.write('st":"x\\');
+    scanner.write('"", ');
+
+    expect(() => scanner.write('"second":1}')).toThrow('JSON content has more than 1 keys');
+  });
+});
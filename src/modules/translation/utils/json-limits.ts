@@ -0,0 +1,101 @@
+/**
+ * JSON 文档的大小、嵌套深度和键数量限制
+ * 用逐字符扫描的状态机检查，不构建对象也不递归，可以分块写入；
+ * 超限时立即停止，避免超大或深度嵌套的文档在 JSON.parse / 字符统计 / 翻译阶段耗尽内存或栈
+ */
+export interface JsonLimits {
+  maxBytes: number;
+  maxDepth: number;
+  maxKeys: number;
+}
+
+export type JsonLimitKind = 'bytes' | 'depth' | 'keys';
+
+export class JsonLimitError extends Error {
+  constructor(
+    readonly limit: JsonLimitKind,
+    readonly max: number,
+  ) {
+    super(
+      limit === 'bytes'
+        ? `JSON content exceeds ${max} bytes`
+        : limit === 'depth'
+          ? `JSON nesting exceeds ${max} levels`
+          : `JSON content has more than ${max} keys`,
+    );
+  }
+}
+
+export class JsonLimitScanner {
+  private bytes = 0;
+  private keys = 0;
+  private readonly containers: string[] = [];
+  private inString = false;
+  private escaped = false;
+  private stringIsKey = false;
+  private expectingKey = false;
+
+  constructor(private readonly limits: JsonLimits) {}
+
+  write(chunk: string): void {
+    this.bytes += Buffer.byteLength(chunk);
+    if (this.bytes > this.limits.maxBytes) {
+      throw new JsonLimitError('bytes', this.limits.maxBytes);
+    }
+
+    for (let i = 0; i < chunk.length; i++) {
+      const char = chunk[i];
+      if (this.inString) {
+        if (this.escaped) {
+          this.escaped = false;
+        } else if (char === '\\') {
+          this.escaped = true;
+        } else if (char === '"') {
+          this.inString = false;
+          if (this.stringIsKey && ++this.keys > this.limits.maxKeys) {
+            throw new JsonLimitError('keys', this.limits.maxKeys);
+          }
+        }
+        continue;
+      }
+
+      switch (char) {
+        case '"':
+          this.inString = true;
+          this.stringIsKey = this.expectingKey;
+          this.expectingKey = false;
+          break;
+        case '{':
+        case '[':
+          this.containers.push(char);
+          if (this.containers.length > this.limits.maxDepth) {
+            throw new JsonLimitError('depth', this.limits.maxDepth);
+          }
+          this.expectingKey = char === '{';
+          break;
+        case '}':
+        case ']':
+          this.containers.pop();
+          this.expectingKey = false;
+          break;
+        case ',':
+          this.expectingKey = this.containers[this.containers.length - 1] === '{';
+          break;
+      }
+    }
+  }
+}
+
+/**
+ * 分块扫描整段文本，超限时抛出 JsonLimitError；语法错误留给之后的 JSON.parse 报告
+ */
+export function assertJsonWithinLimits(raw: string, limits: JsonLimits, chunkSize = 64 * 1024): void {
+  // 按 UTF-16 长度粗判：每个码元至少 1 字节，超过上限时不必扫描
+  if (raw.length > limits.maxBytes) {
+    throw new JsonLimitError('bytes', limits.maxBytes);
+  }
+  const scanner = new JsonLimitScanner(limits);
+  for (let offset = 0; offset < raw.length; offset += chunkSize) {
+    scanner.write(raw.slice(offset, offset + chunkSize));
+  }
+}
@@ -0,0 +1,53 @@
+import { compactKeyUsage, KeyUsageCounter, OTHER_KEYS, summarizeKeyUsage } from './key-usage';
+import { TranslationUtils } from './translation.utils';
+import { IgnoreMatcher } from './ignore-rules';
+
+describe('key-usage', () => {
+  const source = {
+    nav: { home: 'Home', about: 'About us' },
+    footer: { legal: { terms: 'Terms' } },
+    items: [{ sku: 'A-1', label: 'Shoes' }],
+  };
+
+  const count = (depth: number) => {
+    const keyUsage: KeyUsageCounter = { depth, totals: {} };
+    const total = new TranslationUtils().countJsonChars(JSON.stringify(source), {
+      sourceData: source,
+      sourceLang: 'en',
+      targetLang: 'fr',
+      ignoredFields: [],
+      ignore: IgnoreMatcher.compile({ paths: ['items.*.sku'] }),
+      keyUsage,
+    });
+    return { total, totals: keyUsage.totals };
+  };
+
+  it('统计字符数时按前 depth 段键累计，跳过的叶子不计入', () => {
+    const { total, totals } = count(2);
+
+    expect(totals).toEqual({
+      '["nav","home"]': 4,
+      '["nav","about"]': 8,
+      '["footer","legal"]': 5,
+      '["items","0"]': 5,
+    });
+    expect(Object.values(totals).reduce((sum, characters) => sum + characters, 0)).toBe(total);
+  });
+
+  it('报告可以汇总到更浅的层级，按字符数从多到少排列', () => {
+    const { totals } = count(2);
+
+    expect(summarizeKeyUsage(totals, 1)).toEqual([
+      { key: 'nav', path: ['nav'], characters: 12, percent: 54.55 },
+      { key: 'footer', path: ['footer'], characters: 5, percent: 22.73 },
+      { key: 'items', path: ['items'], characters: 5, percent: 22.73 },
+    ]);
+  });
+
+  it('超出保存上限的键合并为一项', () => {
+    const compacted = compactKeyUsage({ '["a"]': 10, '["b"]': 3, '["c"]': 2 }, 1);
+
+    expect(compacted).toEqual({ '["a"]': 10, [OTHER_KEYS]: 5 });
+    expect(summarizeKeyUsage(compacted, 1)[1]).toEqual({ key: null, path: null, characters: 5, percent: 33.33 });
+  });
+});
@@ -0,0 +1,66 @@
+import { JsonPath, pathKey } from './json-diff';
+
+/**
+ * 计费字符数的按键归属
+ * 统计字符数时按叶子路径的前 depth 段累计（键为 pathKey），报告时可以再汇总到更浅的层级
+ */
+export interface KeyUsageCounter {
+  depth: number;
+  totals: Record<string, number>;
+}
+
+/** 超出保存上限的键合并到这一项；pathKey 总是 JSON 数组，不会与它冲突 */
+export const OTHER_KEYS = '*';
+
+export interface KeyUsageEntry {
+  /** 点号连接的路径，合并项为 null */
+  key: string | null;
+  path: JsonPath | null;
+  characters: number;
+  /** 占文档计费字符数的百分比 */
+  percent: number;
+}
+
+export function recordKeyUsage(counter: KeyUsageCounter, path: JsonPath, characters: number): void {
+  if (characters <= 0) {
+    return;
+  }
+  const key = pathKey(path.slice(0, counter.depth));
+  counter.totals[key] = (counter.totals[key] ?? 0) + characters;
+}
+
+/**
+ * 只保留字符数最多的 maxKeys 个键，其余合并到 OTHER_KEYS，避免键很多的扁平文档占用过多存储
+ */
+export function compactKeyUsage(totals: Record<string, number>, maxKeys: number): Record<string, number> {
+  const entries = Object.entries(totals).sort((a, b) => b[1] - a[1]);
+  if (entries.length <= maxKeys) {
+    return totals;
+  }
+  const kept = Object.fromEntries(entries.slice(0, maxKeys));
+  kept[OTHER_KEYS] = entries.slice(maxKeys).reduce((sum, [, characters]) => sum + characters, 0);
+  return kept;
+}
+
+/**
+ * 汇总到前 depth 段，按字符数从多到少排列
+ */
+export function summarizeKeyUsage(totals: Record<string, number>, depth: number): KeyUsageEntry[] {
+  const rolledUp = new Map<string, number>();
+  for (const [key, characters] of Object.entries(totals)) {
+    const target = key === OTHER_KEYS ? key : pathKey(JSON.parse(key).slice(0, depth));
+    rolledUp.set(target, (rolledUp.get(target) ?? 0) + characters);
+  }
+  const sum = [...rolledUp.values()].reduce((total, characters) => total + characters, 0);
+  return [...rolledUp.entries()]
+    .sort((a, b) => b[1] - a[1])
+    .map(([key, characters]) => {
+      const path: JsonPath | null = key === OTHER_KEYS ? null : JSON.parse(key);
+      return {
+        key: path ? path.join('.') : null,
+        path,
+        characters,
+        percent: sum > 0 ? Math.round((characters / sum) * 10000) / 100 : 0,
+      };
+    });
+}
@@ -0,0 +1,33 @@
+import { flattenJson } from './json-diff';
+import { IgnoreMatcher } from './ignore-rules';
+
+/** 占位符和标记不参与语言检测 */
+const PLACEHOLDER_PATTERN = /\{[^}]*\}|#\{[^}]*\}|<[^>]*>|%\w/g;
+
+/**
+ * 挑选用于语言检测的字符串：跳过忽略字段（及忽略配置排除的值）和过短的值，按长度从长到短取前 count 个（去重）
+ */
+export function sampleStrings(
+  source: any,
+  ignoredFields: string[],
+  count: number,
+  minLength = 3,
+  ignore?: IgnoreMatcher,
+): string[] {
+  const candidates = new Set<string>();
+  for (const [key, value] of flattenJson(source)) {
+    const path: string[] = JSON.parse(key);
+    if (
+      typeof value !== 'string' ||
+      path.some((segment) => ignoredFields.includes(segment)) ||
+      ignore?.excludes(path, value)
+    ) {
+      continue;
+    }
+    const text = value.replace(PLACEHOLDER_PATTERN, ' ').replace(/\s+/g, ' ').trim();
+    if (text.length >= minLength && /\p{L}/u.test(text)) {
+      candidates.add(text);
+    }
+  }
+  return [...candidates].sort((a, b) => b.length - a.length).slice(0, count);
+}
@@ -0,0 +1,40 @@
+import { cursorFilter, DocumentSortField, encodeCursor, InvalidCursorError, SortDirection } from './list-cursor';
+
+describe('list cursor', () => {
+  const document = {
+    id: 'doc2',
+    createdAt: new Date('2026-10-01T08:00:00Z'),
+    updatedAt: new Date('2026-10-02T08:00:00Z'),
+    charTotal: 1200,
+  };
+
+  it('降序游标应取排在上一页最后一条之后的记录（同值按 id）', () => {
+    const cursor = encodeCursor(DocumentSortField.CREATE_TIME, SortDirection.DESC, document);
+
+    expect(cursorFilter(cursor, DocumentSortField.CREATE_TIME, SortDirection.DESC)).toEqual({
+      $or: [
+        { createdAt: { $lt: document.createdAt } },
+        { createdAt: document.createdAt, id: { $lt: 'doc2' } },
+      ],
+    });
+  });
+
+  it('按字符数升序时比较数值', () => {
+    const cursor = encodeCursor(DocumentSortField.CHAR_TOTAL, SortDirection.ASC, document);
+
+    expect(cursorFilter(cursor, DocumentSortField.CHAR_TOTAL, SortDirection.ASC)).toEqual({
+      $or: [{ charTotal: { $gt: 1200 } }, { charTotal: 1200, id: { $gt: 'doc2' } }],
+    });
+  });
+
+  it('游标无效或排序不一致时应报错', () => {
+    const cursor = encodeCursor(DocumentSortField.CREATE_TIME, SortDirection.DESC, document);
+
+    expect(() => cursorFilter('not-a-cursor', DocumentSortField.CREATE_TIME, SortDirection.DESC)).toThrow(
+      InvalidCursorError,
+    );
+    expect(() => cursorFilter(cursor, DocumentSortField.UPDATE_TIME, SortDirection.DESC)).toThrow(
+      'Cursor was issued for a different sort order',
+    );
+  });
+});
@@ -0,0 +1,77 @@
+/** 文档列表的排序字段 */
+export enum DocumentSortField {
+  CREATE_TIME = 'create_time',
+  UPDATE_TIME = 'update_time',
+  CHAR_TOTAL = 'char_total',
+}
+
+export enum SortDirection {
+  ASC = 'asc',
+  DESC = 'desc',
+}
+
+/** 排序字段对应的实体属性 */
+export const SORT_PROPERTIES: Record<DocumentSortField, 'createdAt' | 'updatedAt' | 'charTotal'> = {
+  [DocumentSortField.CREATE_TIME]: 'createdAt',
+  [DocumentSortField.UPDATE_TIME]: 'updatedAt',
+  [DocumentSortField.CHAR_TOTAL]: 'charTotal',
+};
+
+export class InvalidCursorError extends Error {}
+
+interface CursorPayload {
+  /** 排序字段和方向，换了排序的游标不能继续使用 */
+  s: DocumentSortField;
+  d: SortDirection;
+  /** 上一页最后一条的排序值（时间为 ISO 字符串）和 ID */
+  v: string | number;
+  id: string;
+}
+
+/**
+ * 游标分页（keyset）
+ * 按 (排序字段, id) 记住上一页的最后一条，下一页只取排在它之后的记录；不受翻页期间新增文档的影响，
+ * 也不需要像 offset 那样扫描并跳过前面的所有行
+ */
+export function encodeCursor(
+  sort: DocumentSortField,
+  direction: SortDirection,
+  document: { id: string; createdAt: Date; updatedAt: Date; charTotal: number },
+): string {
+  const value = document[SORT_PROPERTIES[sort]];
+  const payload: CursorPayload = {
+    s: sort,
+    d: direction,
+    v: value instanceof Date ? value.toISOString() : value,
+    id: document.id,
+  };
+  return Buffer.from(JSON.stringify(payload)).toString('base64url');
+}
+
+/**
+ * 解析游标并生成“排在游标之后”的查询条件
+ */
+export function cursorFilter(cursor: string, sort: DocumentSortField, direction: SortDirection): Record<string, any> {
+  let payload: CursorPayload;
+  try {
+    payload = JSON.parse(Buffer.from(cursor, 'base64url').toString('utf8'));
+  } catch {
+    throw new InvalidCursorError('Invalid cursor');
+  }
+  if (!payload || typeof payload.id !== 'string' || payload.v === undefined) {
+    throw new InvalidCursorError('Invalid cursor');
+  }
+  if (payload.s !== sort || payload.d !== direction) {
+    throw new InvalidCursorError('Cursor was issued for a different sort order');
+  }
+
+  const property = SORT_PROPERTIES[sort];
+  const value = sort === DocumentSortField.CHAR_TOTAL ? Number(payload.v) : new Date(payload.v);
+  if (value instanceof Date ? Number.isNaN(value.getTime()) : !Number.isFinite(value)) {
+    throw new InvalidCursorError('Invalid cursor');
+  }
+  const after = direction === SortDirection.ASC ? '$gt' : '$lt';
+  return {
+    $or: [{ [property]: { [after]: value } }, { [property]: value, id: { [after]: payload.id } }],
+  };
+}
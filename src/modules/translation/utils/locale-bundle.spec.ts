@@ -0,0 +1,37 @@
+import { alignToSource, implicitFallbacks } from './locale-bundle';
+
+describe('locale-bundle', () => {
+  const source = { title: 'Hello', nav: { home: 'Home', about: 'About' } };
+
+  it('缺失的键用原文补齐，多出的键丢弃', () => {
+    const result = alignToSource(source, { title: 'Hallo', nav: { home: 'Startseite' }, legacy: 'x' });
+
+    expect(result.document).toEqual({ title: 'Hallo', nav: { home: 'Startseite', about: 'About' } });
+    expect(result.missingKeys).toEqual(['nav.about']);
+    expect(result.extraKeys).toEqual(['legacy']);
+  });
+
+  it('关闭补齐时省略缺失的键', () => {
+    const result = alignToSource(source, { title: 'Hallo' }, { fillMissing: false });
+
+    expect(result.document).toEqual({ title: 'Hallo', nav: {} });
+    expect(result.missingKeys).toEqual(['nav.home', 'nav.about']);
+  });
+
+  it('应按回退链补齐并标记每个值来自哪个语言', () => {
+    const result = alignToSource(
+      source,
+      { title: 'Bonjour' },
+      { fallbacks: [{ locale: 'fr', document: { nav: { home: 'Accueil' } } }], sourceLocale: 'en' },
+    );
+
+    expect(result.document).toEqual({ title: 'Bonjour', nav: { home: 'Accueil', about: 'About' } });
+    expect(result.fallbackKeys).toEqual({ 'nav.home': 'fr', 'nav.about': 'en' });
+  });
+
+  it('应按语言代码推导回退链', () => {
+    expect(implicitFallbacks('fr-CA', ['en', 'fr', 'fr-CA'])).toEqual(['fr']);
+    expect(implicitFallbacks('zh-Hant-TW', ['zh', 'zh-Hant'])).toEqual(['zh-Hant', 'zh']);
+    expect(implicitFallbacks('de', ['en'])).toEqual([]);
+  });
+});
@@ -0,0 +1,90 @@
+import { getPath, isPlainObject, JsonPath } from './json-diff';
+
+export interface LocaleFallback {
+  locale: string;
+  document: any;
+}
+
+export interface AlignOptions {
+  /** 缺失的键是否补齐（默认 true），false 时省略 */
+  fillMissing?: boolean;
+  /** 依次查找的回退语言（不含源语言） */
+  fallbacks?: LocaleFallback[];
+  /** 源语言，回退链的最后一环 */
+  sourceLocale?: string;
+}
+
+export interface LocaleMergeResult {
+  /** 以源文档结构为准的译文 */
+  document: Record<string, any>;
+  /** 译文中缺失、由回退语言或原文补齐（或留空）的键 */
+  missingKeys: string[];
+  /** 源文档中不存在、被丢弃的键 */
+  extraKeys: string[];
+  /** 补齐的键取自哪个语言（点号路径 → 语言） */
+  fallbackKeys: Record<string, string>;
+}
+
+/**
+ * 按语言代码推导回退链：fr-CA → fr，zh-Hant-TW → zh-Hant → zh；只保留可用的语言
+ */
+export function implicitFallbacks(locale: string, available: string[]): string[] {
+  const parts = locale.split(/[-_]/);
+  const chain: string[] = [];
+  for (let length = parts.length - 1; length > 0; length--) {
+    const prefix = parts.slice(0, length).join('-').toLowerCase();
+    const parent = available.find((candidate) => candidate.replace(/_/g, '-').toLowerCase() === prefix);
+    if (parent && parent !== locale) {
+      chain.push(parent);
+    }
+  }
+  return chain;
+}
+
+/**
+ * 把一份译文对齐到源文档的结构：缺失的叶子依次从回退语言、原文补齐，多出的键丢弃
+ */
+export function alignToSource(source: any, translation: any, options: AlignOptions = {}): LocaleMergeResult {
+  const result: LocaleMergeResult = { document: {}, missingKeys: [], extraKeys: [], fallbackKeys: {} };
+  result.document = align(source, translation, [], options, result) ?? {};
+  return result;
+}
+
+function align(source: any, translation: any, path: JsonPath, options: AlignOptions, result: LocaleMergeResult): any {
+  if (!isPlainObject(source)) {
+    if (translation !== undefined && !isPlainObject(translation)) {
+      return translation;
+    }
+    const key = path.join('.');
+    result.missingKeys.push(key);
+    if (options.fillMissing === false) {
+      return undefined;
+    }
+    for (const fallback of options.fallbacks ?? []) {
+      const value = getPath(fallback.document, path);
+      if (value !== undefined && !isPlainObject(value)) {
+        result.fallbackKeys[key] = fallback.locale;
+        return value;
+      }
+    }
+    if (options.sourceLocale) {
+      result.fallbackKeys[key] = options.sourceLocale;
+    }
+    return source;
+  }
+
+  const node = isPlainObject(translation) ? translation : {};
+  const aligned: Record<string, any> = {};
+  for (const [key, item] of Object.entries(source)) {
+    const value = align(item, node[key], [...path, key], options, result);
+    if (value !== undefined) {
+      aligned[key] = value;
+    }
+  }
+  for (const key of Object.keys(node)) {
+    if (!(key in source)) {
+      result.extraKeys.push([...path, key].join('.'));
+    }
+  }
+  return aligned;
+}
@@ -0,0 +1,41 @@
+import { getPath, JsonPath, setPath } from './json-diff';
+
+/**
+ * 人工修改并锁定的键
+ * 键以点号分隔的路径表示，重新翻译时用上一次的译文覆盖这些叶子；
+ * 键名本身含有点号或反斜杠时用反斜杠转义（如 `errors.a\.b`），与 formatKeyPath 互为逆运算
+ */
+export function parseKeyPath(key: string): JsonPath {
+  const path: JsonPath = [];
+  let part = '';
+  for (let index = 0; index < key.length; index++) {
+    if (key[index] === '\\' && index + 1 < key.length) {
+      part += key[++index];
+    } else if (key[index] === '.') {
+      path.push(part);
+      part = '';
+    } else {
+      part += key[index];
+    }
+  }
+  path.push(part);
+  return path;
+}
+
+export function formatKeyPath(path: JsonPath): string {
+  return path.map((part) => part.replace(/[\\.]/g, '\\$&')).join('.');
+}
+
+/**
+ * 把锁定键的旧译文写回新译文；源文档中已删除的键不会被加回
+ */
+export function applyLockedKeys(translated: any, previousTranslation: any, lockedKeys: string[]): any {
+  for (const key of lockedKeys) {
+    const path = parseKeyPath(key);
+    const previous = getPath(previousTranslation, path);
+    if (previous !== undefined && getPath(translated, path) !== undefined) {
+      setPath(translated, path, previous);
+    }
+  }
+  return translated;
+}
@@ -0,0 +1,35 @@
+import { formatOutputKeys, OutputKeyFormat } from './output-keys';
+
+describe('output-keys', () => {
+  const source = { title: 'Hello', nav: { home: 'Home' }, tags: ['a', 'b'] };
+  const translated = { title: 'Hallo', nav: { home: 'Startseite' }, tags: ['a', 'b'] };
+  const options = { fromLang: 'en', toLang: 'de', source };
+
+  it('nested 保持原有结构', () => {
+    expect(formatOutputKeys(translated, { ...options, format: OutputKeyFormat.NESTED })).toBe(translated);
+  });
+
+  it('suffix 只给叶子键追加目标语言后缀', () => {
+    expect(formatOutputKeys(translated, { ...options, format: OutputKeyFormat.SUFFIX })).toEqual({
+      title_de: 'Hallo',
+      nav: { home_de: 'Startseite' },
+      tags_de: ['a', 'b'],
+    });
+  });
+
+  it('merged 把原文和译文合并到同一个叶子下', () => {
+    expect(formatOutputKeys(translated, { ...options, format: OutputKeyFormat.MERGED })).toEqual({
+      title: { en: 'Hello', de: 'Hallo' },
+      nav: { home: { en: 'Home', de: 'Startseite' } },
+      tags: { en: ['a', 'b'], de: ['a', 'b'] },
+    });
+  });
+
+  it('flat 展开为点号路径', () => {
+    expect(formatOutputKeys(translated, { ...options, format: OutputKeyFormat.FLAT })).toEqual({
+      title: 'Hallo',
+      'nav.home': 'Startseite',
+      tags: ['a', 'b'],
+    });
+  });
+});
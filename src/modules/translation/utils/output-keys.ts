@@ -0,0 +1,70 @@
+import { isPlainObject } from './json-diff';
+
+/**
+ * 译文输出的键形态，适配不同 CMS 对多语言 JSON 的要求
+ * - nested：与原文结构一致（默认）
+ * - suffix：叶子键追加目标语言后缀，例如 title → title_de
+ * - merged：叶子合并为 { 源语言: 原文, 目标语言: 译文 }
+ * - flat：展开为点号路径的一层对象，例如 { "nav.home": "Startseite" }
+ */
+export enum OutputKeyFormat {
+  NESTED = 'nested',
+  SUFFIX = 'suffix',
+  MERGED = 'merged',
+  FLAT = 'flat',
+}
+
+export interface OutputKeyOptions {
+  format: OutputKeyFormat;
+  fromLang: string;
+  toLang: string;
+  /** merged 形态需要原文 */
+  source?: any;
+}
+
+export function formatOutputKeys(translated: any, options: OutputKeyOptions): any {
+  switch (options.format) {
+    case OutputKeyFormat.SUFFIX:
+      return suffixKeys(translated, `_${options.toLang}`);
+    case OutputKeyFormat.MERGED:
+      return mergeLanguages(options.source, translated, options.fromLang, options.toLang);
+    case OutputKeyFormat.FLAT:
+      return flattenKeys(translated);
+    default:
+      return translated;
+  }
+}
+
+function suffixKeys(node: any, suffix: string): any {
+  if (!isPlainObject(node)) {
+    return node;
+  }
+  const result: Record<string, any> = {};
+  for (const [key, value] of Object.entries(node)) {
+    result[isPlainObject(value) ? key : `${key}${suffix}`] = suffixKeys(value, suffix);
+  }
+  return result;
+}
+
+function mergeLanguages(source: any, translated: any, fromLang: string, toLang: string): any {
+  if (isPlainObject(translated)) {
+    const result: Record<string, any> = {};
+    for (const [key, value] of Object.entries(translated)) {
+      result[key] = mergeLanguages(isPlainObject(source) ? source[key] : undefined, value, fromLang, toLang);
+    }
+    return result;
+  }
+  return { [fromLang]: source ?? null, [toLang]: translated };
+}
+
+function flattenKeys(node: any, prefix = '', result: Record<string, any> = {}): Record<string, any> {
+  for (const [key, value] of Object.entries(node ?? {})) {
+    const path = prefix ? `${prefix}.${key}` : key;
+    if (isPlainObject(value) && Object.keys(value).length > 0) {
+      flattenKeys(value, path, result);
+    } else {
+      result[path] = value;
+    }
+  }
+  return result;
+}
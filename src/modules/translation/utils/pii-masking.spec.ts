@@ -0,0 +1,38 @@
+import { compilePiiMatcher, detectPii, findPiiSpans, maskPii, PiiType, unmaskPii } from './pii-masking';
+
+describe('pii-masking', () => {
+  const matcher = compilePiiMatcher(['EMP-\\d{6}']);
+
+  it('应识别邮箱、电话号码、信用卡号和自定义规则', () => {
+    const text = 'Mail ann@example.com, call +1 (415) 555-0100, card 4111 1111 1111 1111, id EMP-123456';
+
+    expect(detectPii(text, matcher).map((span) => [span.type, text.slice(span.start, span.end)])).toEqual([
+      [PiiType.EMAIL, 'ann@example.com'],
+      [PiiType.PHONE, '+1 (415) 555-0100'],
+      [PiiType.CREDIT_CARD, '4111 1111 1111 1111'],
+      [PiiType.CUSTOM, 'EMP-123456'],
+    ]);
+  });
+
+  it('未通过校验位的长数字不视为信用卡号，短数字不视为电话号码', () => {
+    expect(detectPii('Order 4111 1111 1111 1112 shipped in 2026', matcher)).toEqual([]);
+  });
+
+  it('屏蔽后翻译服务只看到令牌，译文中换回原文', () => {
+    const masked = maskPii('Contact ann@example.com today', matcher);
+
+    expect(masked.text).toBe('Contact __PII_0__ today');
+    expect(masked.values).toEqual(['ann@example.com']);
+    expect(unmaskPii('Kontakt __PII_0__ heute', masked.values)).toBe('Kontakt ann@example.com heute');
+  });
+
+  it('译文丢失令牌时抛出错误', () => {
+    expect(() => unmaskPii('Kontakt heute', ['ann@example.com'])).toThrow('__PII_0__');
+  });
+
+  it('按点号路径生成屏蔽报告，不包含原文', () => {
+    const report = findPiiSpans({ footer: { contacts: ['Write to ann@example.com'] }, title: 'Hello' }, matcher);
+
+    expect(report).toEqual({ 'footer.contacts.0': [{ type: PiiType.EMAIL, start: 9, end: 24 }] });
+  });
+});
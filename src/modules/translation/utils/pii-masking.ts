@@ -0,0 +1,154 @@
+import { isPlainObject } from './json-diff';
+
+/** 屏蔽的个人信息类型，custom 为调用方传入的正则表达式 */
+export enum PiiType {
+  EMAIL = 'email',
+  CREDIT_CARD = 'credit_card',
+  PHONE = 'phone',
+  CUSTOM = 'custom',
+}
+
+/** 字符串中被屏蔽的一段（[start, end) 为原文中的字符下标），不包含原文内容 */
+export interface MaskedSpan {
+  type: PiiType;
+  start: number;
+  end: number;
+}
+
+export interface PiiMatcher {
+  patterns: { type: PiiType; regex: RegExp; validate?: (value: string) => boolean }[];
+}
+
+export interface MaskedText {
+  text: string;
+  /** 按令牌编号排列的原文 */
+  values: string[];
+  spans: MaskedSpan[];
+}
+
+/** 单个文档最多记录的屏蔽键数 */
+export const MAX_PII_REPORT_KEYS = 1000;
+
+const BUILTIN_PATTERNS: PiiMatcher['patterns'] = [
+  { type: PiiType.EMAIL, regex: /[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}/g },
+  { type: PiiType.CREDIT_CARD, regex: /(?<![\d-])\d(?:[ -]?\d){12,18}(?![\d-])/g, validate: passesLuhn },
+  // 前后不能紧接数字（含一个分隔符），避免把更长的数字串（如校验失败的卡号）截成电话号码
+  {
+    type: PiiType.PHONE,
+    regex: /(?<![\w+]|\d[\s().-])\+?\d(?:[\s().-]{0,2}\d){7,14}(?!\w|[\s().-]\d)/g,
+  },
+];
+
+/**
+ * 内置规则（邮箱、信用卡号、电话号码）加上调用方的正则表达式；正则无效时抛出 SyntaxError
+ */
+export function compilePiiMatcher(customPatterns: string[] = []): PiiMatcher {
+  return {
+    patterns: [
+      ...BUILTIN_PATTERNS,
+      ...customPatterns.map((pattern) => ({ type: PiiType.CUSTOM, regex: new RegExp(pattern, 'g') })),
+    ],
+  };
+}
+
+/**
+ * 找出字符串中的个人信息；多条规则重叠时取先开始且更长的匹配，长度相同时按规则顺序
+ */
+export function detectPii(text: string, matcher: PiiMatcher): MaskedSpan[] {
+  const matches: MaskedSpan[] = [];
+  for (const { type, regex, validate } of matcher.patterns) {
+    for (const match of text.matchAll(new RegExp(regex.source, regex.flags))) {
+      if (match[0].length > 0 && (!validate || validate(match[0]))) {
+        matches.push({ type, start: match.index, end: match.index + match[0].length });
+      }
+    }
+  }
+
+  matches.sort((a, b) => a.start - b.start || b.end - a.end);
+  const spans: MaskedSpan[] = [];
+  let covered = 0;
+  for (const match of matches) {
+    if (match.start >= covered) {
+      spans.push(match);
+      covered = match.end;
+    }
+  }
+  return spans;
+}
+
+function tokenOf(index: number): string {
+  return `__PII_${index}__`;
+}
+
+/**
+ * 把个人信息替换为 __PII_n__ 令牌，翻译服务只看到令牌
+ */
+export function maskPii(text: string, matcher: PiiMatcher): MaskedText {
+  const spans = detectPii(text, matcher);
+  const values: string[] = [];
+  let masked = '';
+  let cursor = 0;
+  for (const span of spans) {
+    masked += text.slice(cursor, span.start) + tokenOf(values.length);
+    values.push(text.slice(span.start, span.end));
+    cursor = span.end;
+  }
+  return { text: masked + text.slice(cursor), values, spans };
+}
+
+/**
+ * 把译文中的令牌换回原文；翻译服务丢掉或改写了令牌时抛出错误，由调用方把该键保留原文
+ */
+export function unmaskPii(translated: string, values: string[]): string {
+  let restored = translated;
+  for (const [index, value] of values.entries()) {
+    const token = tokenOf(index);
+    if (!restored.includes(token)) {
+      throw new Error(`Masked value ${token} is missing from the translation`);
+    }
+    restored = restored.split(token).join(value);
+  }
+  return restored;
+}
+
+/**
+ * 逐个字符串叶子检测个人信息，返回点号路径 → 屏蔽的片段；最多记录 MAX_PII_REPORT_KEYS 个键
+ */
+export function findPiiSpans(document: any, matcher: PiiMatcher): Record<string, MaskedSpan[]> {
+  const report: Record<string, MaskedSpan[]> = {};
+  const visit = (node: any, path: string[]) => {
+    if (Object.keys(report).length >= MAX_PII_REPORT_KEYS) {
+      return;
+    }
+    if (typeof node === 'string') {
+      const spans = detectPii(node, matcher);
+      if (spans.length > 0) {
+        report[path.join('.')] = spans;
+      }
+    } else if (Array.isArray(node)) {
+      node.forEach((item, index) => visit(item, [...path, String(index)]));
+    } else if (isPlainObject(node)) {
+      for (const [key, value] of Object.entries(node)) {
+        visit(value, [...path, key]);
+      }
+    }
+  };
+  visit(document, []);
+  return report;
+}
+
+function passesLuhn(value: string): boolean {
+  const digits = value.replace(/\D/g, '');
+  let sum = 0;
+  for (let i = 0; i < digits.length; i++) {
+    let digit = Number(digits[digits.length - 1 - i]);
+    if (i % 2 === 1) {
+      digit *= 2;
+      if (digit > 9) {
+        digit -= 9;
+      }
+    }
+    sum += digit;
+  }
+  return sum % 10 === 0;
+}
@@ -0,0 +1,57 @@
+import {
+  extractPlaceholders,
+  findPlaceholderIssues,
+  missingPlaceholders,
+  parsePlaceholderStyles,
+  PlaceholderStyle,
+} from './placeholders';
+
+describe('placeholders', () => {
+  it('未指定语法时沿用内置分隔符', () => {
+    expect(extractPlaceholders('Hi {name}, see #{link} or <b>[x]</b>')).toEqual(['{name}', '#{link}', '<b>', '[x]', '</b>']);
+  });
+
+  it('应按声明的语法提取占位符', () => {
+    expect(extractPlaceholders('%1$s has %d items (%.2f%%)', [PlaceholderStyle.PRINTF])).toEqual([
+      '%1$s',
+      '%d',
+      '%.2f',
+      '%%',
+    ]);
+    expect(extractPlaceholders('Hello {0}, you have {count, number} points', [PlaceholderStyle.ICU])).toEqual([
+      '{0}',
+      '{count, number}',
+    ]);
+    expect(extractPlaceholders('{{- if .Admin }}Hi {{.Name}}{{ end }}', [PlaceholderStyle.GO_TEMPLATE])).toEqual([
+      '{{- if .Admin }}',
+      '{{.Name}}',
+      '{{ end }}',
+    ]);
+    expect(extractPlaceholders('Total {{amount, currency}} $t(common.ok)', [PlaceholderStyle.I18NEXT])).toEqual([
+      '{{amount, currency}}',
+      '$t(common.ok)',
+    ]);
+  });
+
+  it('双花括号优先于单花括号', () => {
+    expect(extractPlaceholders('Hi {{ user }} {id}', [PlaceholderStyle.ICU, PlaceholderStyle.VUE])).toEqual([
+      '{{ user }}',
+      '{id}',
+    ]);
+  });
+
+  it('应找出译文中丢失的占位符', () => {
+    expect(missingPlaceholders('%s of %s', 'de %s', [PlaceholderStyle.PRINTF])).toEqual(['%s']);
+    expect(
+      findPlaceholderIssues(
+        { a: 'Hi {{name}}', b: { c: 'Bye {{name}}' } },
+        { a: 'Hallo {{name}}', b: { c: 'Tschüss' } },
+        [PlaceholderStyle.VUE],
+      ),
+    ).toEqual({ 'b.c': ['{{name}}'] });
+  });
+
+  it('应忽略未知的语法名称', () => {
+    expect(parsePlaceholderStyles('icu, printf,unknown,icu')).toEqual([PlaceholderStyle.ICU, PlaceholderStyle.PRINTF]);
+  });
+});
@@ -0,0 +1,104 @@
+import { isPlainObject } from './json-diff';
+
+/**
+ * 占位符语法
+ * - legacy：未指定时使用的内置分隔符（{x}、#{x}、[x]、<x>）
+ * - icu：{0}、{name}、{count, number}
+ * - printf：%s、%d、%1$s、%.2f
+ * - go_template：{{.Name}}、{{- if .X }}
+ * - vue：{{ var }}
+ * - i18next：{{var}}、{{var, format}}、$t(key)
+ */
+export enum PlaceholderStyle {
+  LEGACY = 'legacy',
+  ICU = 'icu',
+  PRINTF = 'printf',
+  GO_TEMPLATE = 'go_template',
+  VUE = 'vue',
+  I18NEXT = 'i18next',
+}
+
+const STYLE_PATTERNS: Record<PlaceholderStyle, RegExp[]> = {
+  [PlaceholderStyle.LEGACY]: [/#\{.+?\}/g, /\{.+?\}/g, /\[.+?\]/g, /<.+?\/>/g, /<.+?>/g],
+  [PlaceholderStyle.ICU]: [/\{\s*[\w.]+\s*(?:,\s*[\w]+\s*(?:,\s*[^{}]*)?)?\}/g],
+  [PlaceholderStyle.PRINTF]: [/%(?:\d+\$)?[-+ 0#]*(?:\d+|\*)?(?:\.\d+)?(?:hh|h|ll|l|L|z|j|t)?[sdifuxXoeEgGcp@%]/g],
+  [PlaceholderStyle.GO_TEMPLATE]: [/\{\{-?[^{}]*?-?\}\}/g],
+  [PlaceholderStyle.VUE]: [/\{\{\s*[^{}]+?\s*\}\}/g],
+  [PlaceholderStyle.I18NEXT]: [/\{\{-?\s*[^{}]+?\s*\}\}/g, /\$t\([^()]*\)/g],
+};
+
+export function parsePlaceholderStyles(value?: string | string[]): PlaceholderStyle[] {
+  const styles = Array.isArray(value) ? value : (value ?? '').split(',');
+  const known = new Set<string>(Object.values(PlaceholderStyle));
+  return [...new Set(styles.map((style) => style.trim()).filter((style) => known.has(style)))] as PlaceholderStyle[];
+}
+
+/**
+ * 按出现顺序提取占位符；多种语法重叠时取先开始且更长的匹配
+ */
+export function extractPlaceholders(text: string, styles: PlaceholderStyle[] = []): string[] {
+  const patterns = (styles.length > 0 ? styles : [PlaceholderStyle.LEGACY]).flatMap((style) => STYLE_PATTERNS[style]);
+  const matches: { start: number; end: number; value: string }[] = [];
+  for (const pattern of patterns) {
+    for (const match of text.matchAll(new RegExp(pattern.source, 'g'))) {
+      matches.push({ start: match.index, end: match.index + match[0].length, value: match[0] });
+    }
+  }
+
+  matches.sort((a, b) => a.start - b.start || b.end - a.end);
+  const placeholders: string[] = [];
+  let covered = 0;
+  for (const match of matches) {
+    if (match.start >= covered) {
+      placeholders.push(match.value);
+      covered = match.end;
+    }
+  }
+  return placeholders;
+}
+
+/**
+ * 原文中有、译文中缺失的占位符（按出现次数比较）
+ */
+export function missingPlaceholders(source: string, translated: string, styles: PlaceholderStyle[] = []): string[] {
+  const remaining = extractPlaceholders(translated, styles);
+  const missing: string[] = [];
+  for (const placeholder of extractPlaceholders(source, styles)) {
+    const index = remaining.indexOf(placeholder);
+    if (index === -1) {
+      missing.push(placeholder);
+    } else {
+      remaining.splice(index, 1);
+    }
+  }
+  return missing;
+}
+
+/**
+ * 逐个字符串叶子比较原文和译文，返回占位符丢失的键（点号路径 → 缺失的占位符）
+ */
+export function findPlaceholderIssues(
+  source: any,
+  translated: any,
+  styles: PlaceholderStyle[] = [],
+  prefix: string[] = [],
+  issues: Record<string, string[]> = {},
+): Record<string, string[]> {
+  if (isPlainObject(source)) {
+    for (const [key, value] of Object.entries(source)) {
+      const target = isPlainObject(translated) ? translated[key] : undefined;
+      findPlaceholderIssues(value, target, styles, [...prefix, key], issues);
+    }
+  } else if (Array.isArray(source)) {
+    source.forEach((item, index) => {
+      const target = Array.isArray(translated) ? translated[index] : undefined;
+      findPlaceholderIssues(item, target, styles, [...prefix, String(index)], issues);
+    });
+  } else if (typeof source === 'string' && typeof translated === 'string') {
+    const missing = missingPlaceholders(source, translated, styles);
+    if (missing.length > 0) {
+      issues[prefix.join('.')] = missing;
+    }
+  }
+  return issues;
+}
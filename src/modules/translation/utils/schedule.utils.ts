@@ -0,0 +1,24 @@
+import { BadRequestException } from '@nestjs/common';
+import { parseExpression } from 'cron-parser';
+
+/**
+ * 校验 cron 表达式和时区，并限制最小触发间隔，避免高频周期任务占满队列
+ */
+export function assertCronSchedule(cron: string, timezone: string | undefined, minIntervalMinutes: number): void {
+  let first: Date;
+  let second: Date;
+  try {
+    const interval = parseExpression(cron, { tz: timezone });
+    first = interval.next().toDate();
+    second = interval.next().toDate();
+  } catch (error) {
+    throw new BadRequestException(`Invalid cron expression or timezone: ${error.message}`);
+  }
+  if (second.getTime() - first.getTime() < minIntervalMinutes * 60 * 1000) {
+    throw new BadRequestException(`Recurring jobs cannot run more often than every ${minIntervalMinutes} minutes`);
+  }
+}
+
+export function nextCronRun(cron: string, timezone?: string): Date {
+  return parseExpression(cron, { tz: timezone }).next().toDate();
+}
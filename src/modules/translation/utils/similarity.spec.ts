@@ -0,0 +1,12 @@
+import { stringSimilarity } from './similarity';
+
+describe('stringSimilarity', () => {
+  it('忽略大小写和多余空白', () => {
+    expect(stringSimilarity('Hello  World ', 'hello world')).toBe(1);
+  });
+
+  it('按编辑距离计算相似度', () => {
+    expect(stringSimilarity('kitten', 'sitting')).toBeCloseTo(1 - 3 / 7);
+    expect(stringSimilarity('abc', 'xyz')).toBe(0);
+  });
+});
@@ -0,0 +1,29 @@
+/**
+ * 基于编辑距离的字符串相似度（0-1），比较前统一小写并压缩空白
+ */
+export function stringSimilarity(a: string, b: string): number {
+  const left = normalize(a);
+  const right = normalize(b);
+  if (left === right) {
+    return 1;
+  }
+  const longest = Math.max(left.length, right.length);
+  return longest === 0 ? 1 : 1 - levenshtein(left, right) / longest;
+}
+
+function normalize(text: string): string {
+  return text.toLowerCase().replace(/\s+/g, ' ').trim();
+}
+
+function levenshtein(a: string, b: string): number {
+  let previous = Array.from({ length: b.length + 1 }, (_, index) => index);
+  for (let i = 1; i <= a.length; i++) {
+    const current = [i];
+    for (let j = 1; j <= b.length; j++) {
+      const cost = a[i - 1] === b[j - 1] ? 0 : 1;
+      current[j] = Math.min(previous[j] + 1, current[j - 1] + 1, previous[j - 1] + cost);
+    }
+    previous = current;
+  }
+  return previous[b.length];
+}
@@ -0,0 +1,55 @@
+import { BadRequestException, HttpException, HttpStatus } from '@nestjs/common';
+import { classifyTaskFailure, providerAccountFailure, TaskFailureReason } from './task-failure';
+
+describe('classifyTaskFailure', () => {
+  it('无效 JSON 不应建议重试', () => {
+    const failure = classifyTaskFailure(new BadRequestException('Invalid JSON content'));
+
+    expect(failure.reason).toBe(TaskFailureReason.INVALID_INPUT);
+    expect(failure.retryable).toBe(false);
+  });
+
+  it('额度用尽应归类为 quota_exceeded', () => {
+    const failure = classifyTaskFailure(
+      new HttpException('Monthly character quota exceeded', HttpStatus.TOO_MANY_REQUESTS),
+    );
+
+    expect(failure.reason).toBe(TaskFailureReason.QUOTA_EXCEEDED);
+    expect(failure.retryable).toBe(false);
+  });
+
+  it('服务商凭证被拒和账号余额不足应与本服务的额度区分', () => {
+    const credentials = classifyTaskFailure(
+      Object.assign(new Error('Specified access key is disabled'), { code: 'Forbidden.AccessKeyDisabled' }),
+    );
+    const balance = classifyTaskFailure(Object.assign(new Error('Account is in arrears'), { code: 'Arrearage' }));
+
+    expect(credentials.reason).toBe(TaskFailureReason.PROVIDER_CREDENTIALS_REJECTED);
+    expect(balance.reason).toBe(TaskFailureReason.PROVIDER_QUOTA_EXHAUSTED);
+    expect(balance).toMatchObject({ retryable: true, retryAfterSeconds: 1800 });
+    expect(providerAccountFailure(new HttpException('Monthly character quota exceeded', 429))).toBeNull();
+  });
+
+  it('服务商限流应给出重试间隔', () => {
+    const failure = classifyTaskFailure(Object.assign(new Error('Request was denied'), { code: 'Throttling.User' }));
+
+    expect(failure.reason).toBe(TaskFailureReason.PROVIDER_RATE_LIMITED);
+    expect(failure).toMatchObject({ retryable: true, retryAfterSeconds: 300 });
+  });
+
+  it('超时和网络错误应区分', () => {
+    expect(classifyTaskFailure(Object.assign(new Error('socket hang up'), { code: 'ETIMEDOUT' })).reason).toBe(
+      TaskFailureReason.TIMEOUT,
+    );
+    expect(classifyTaskFailure(Object.assign(new Error('connect failed'), { code: 'ECONNREFUSED' })).reason).toBe(
+      TaskFailureReason.PROVIDER_UNAVAILABLE,
+    );
+  });
+
+  it('未知错误归类为 internal_error 并截断消息', () => {
+    const failure = classifyTaskFailure(new Error('x'.repeat(1000)));
+
+    expect(failure.reason).toBe(TaskFailureReason.INTERNAL_ERROR);
+    expect(failure.message).toHaveLength(500);
+  });
+});
@@ -0,0 +1,158 @@
+/**
+ * 翻译任务失败原因分类，用于 translation.failed webhook 和任务状态
+ */
+export enum TaskFailureReason {
+  INVALID_INPUT = 'invalid_input',
+  QUOTA_EXCEEDED = 'quota_exceeded',
+  PROVIDER_RATE_LIMITED = 'provider_rate_limited',
+  PROVIDER_UNAVAILABLE = 'provider_unavailable',
+  /** 服务商账号的额度或余额用尽 */
+  PROVIDER_QUOTA_EXHAUSTED = 'provider_quota_exhausted',
+  /** 服务商拒绝凭证（密钥无效、停用或无权限） */
+  PROVIDER_CREDENTIALS_REJECTED = 'provider_credentials_rejected',
+  TIMEOUT = 'timeout',
+  INTERNAL_ERROR = 'internal_error',
+}
+
+export interface TaskFailure {
+  reason: TaskFailureReason;
+  message: string;
+  /** 原样重新提交是否有可能成功 */
+  retryable: boolean;
+  /** 建议的最短重试间隔（秒） */
+  retryAfterSeconds?: number;
+  guidance: string;
+}
+
+/**
+ * 服务商账号层面的失败：对同一服务商重试只会再次失败，应暂停该服务商并切换到备用服务商
+ */
+export const PROVIDER_ACCOUNT_FAILURES = new Set([
+  TaskFailureReason.PROVIDER_QUOTA_EXHAUSTED,
+  TaskFailureReason.PROVIDER_CREDENTIALS_REJECTED,
+]);
+
+/**
+ * 服务商已因账号失败暂停且没有可用的备用服务商
+ */
+export class ProviderPausedError extends Error {
+  constructor(
+    readonly provider: string,
+    readonly reason: TaskFailureReason,
+    message: string,
+  ) {
+    super(message);
+    this.name = 'ProviderPausedError';
+  }
+}
+
+const MAX_MESSAGE_LENGTH = 500;
+
+const NETWORK_ERROR_CODES = new Set(['ECONNREFUSED', 'ECONNRESET', 'ENOTFOUND', 'EAI_AGAIN', 'EHOSTUNREACH', 'EPIPE']);
+const TIMEOUT_ERROR_CODES = new Set(['ETIMEDOUT', 'ECONNABORTED', 'ESOCKETTIMEDOUT']);
+
+const PROVIDER_CREDENTIAL_CODES =
+  /^(InvalidAccessKeyId|InvalidSecurityToken|SignatureDoesNotMatch|IncompleteSignature|Forbidden\.(AccessKey|RAM))/i;
+const PROVIDER_QUOTA_PATTERN =
+  /arrear|overdue|insufficient.?balance|balance.?(is )?insufficient|quota.?(exhausted|used up|depleted)|out of credit/i;
+
+function statusOf(error: any): number | undefined {
+  if (typeof error?.getStatus === 'function') {
+    return error.getStatus();
+  }
+  return error?.response?.status ?? error?.statusCode ?? error?.status;
+}
+
+/**
+ * 识别服务商账号层面的失败（凭证被拒、额度或余额用尽），其他错误返回 null。
+ * 本服务自己的额度错误（Monthly character quota exceeded）不在此列
+ */
+export function providerAccountFailure(error: any): TaskFailureReason | null {
+  if (error instanceof ProviderPausedError) {
+    return error.reason;
+  }
+  const code = String(error?.code ?? '');
+  if (PROVIDER_CREDENTIAL_CODES.test(code) || statusOf(error) === 401) {
+    return TaskFailureReason.PROVIDER_CREDENTIALS_REJECTED;
+  }
+  if (PROVIDER_QUOTA_PATTERN.test(`${code} ${error?.message ?? ''}`)) {
+    return TaskFailureReason.PROVIDER_QUOTA_EXHAUSTED;
+  }
+  return null;
+}
+
+export function classifyTaskFailure(error: any): TaskFailure {
+  const message = String(error?.message ?? error ?? 'Unknown error').slice(0, MAX_MESSAGE_LENGTH);
+  const status = statusOf(error);
+  const code = String(error?.code ?? '');
+
+  const accountFailure = providerAccountFailure(error);
+  if (accountFailure === TaskFailureReason.PROVIDER_CREDENTIALS_REJECTED) {
+    return {
+      reason: accountFailure,
+      message,
+      retryable: true,
+      retryAfterSeconds: 1800,
+      guidance: 'The translation provider rejected the service credentials; resubmit once the provider is restored',
+    };
+  }
+  if (accountFailure === TaskFailureReason.PROVIDER_QUOTA_EXHAUSTED) {
+    return {
+      reason: accountFailure,
+      message,
+      retryable: true,
+      retryAfterSeconds: 1800,
+      guidance: 'The translation provider account is out of quota; resubmit once the provider is restored',
+    };
+  }
+  if (/quota/i.test(message) || status === 402) {
+    return {
+      reason: TaskFailureReason.QUOTA_EXCEEDED,
+      message,
+      retryable: false,
+      guidance: 'Upgrade the plan or wait for the quota to reset, then resubmit the task',
+    };
+  }
+  if (error instanceof SyntaxError || status === 400 || /invalid json/i.test(message)) {
+    return {
+      reason: TaskFailureReason.INVALID_INPUT,
+      message,
+      retryable: false,
+      guidance: 'Fix the source document and submit a new task; retrying the same input will fail again',
+    };
+  }
+  if (status === 429 || /throttl|rate limit|too many requests/i.test(`${code} ${message}`)) {
+    return {
+      reason: TaskFailureReason.PROVIDER_RATE_LIMITED,
+      message,
+      retryable: true,
+      retryAfterSeconds: 300,
+      guidance: 'The translation provider is rate limiting requests; resubmit after a few minutes',
+    };
+  }
+  if (TIMEOUT_ERROR_CODES.has(code) || /timed? ?out/i.test(message)) {
+    return {
+      reason: TaskFailureReason.TIMEOUT,
+      message,
+      retryable: true,
+      retryAfterSeconds: 60,
+      guidance: 'The provider did not respond in time; resubmit, or split very large documents',
+    };
+  }
+  if (NETWORK_ERROR_CODES.has(code) || (status !== undefined && status >= 500)) {
+    return {
+      reason: TaskFailureReason.PROVIDER_UNAVAILABLE,
+      message,
+      retryable: true,
+      retryAfterSeconds: 120,
+      guidance: 'The translation provider is unavailable; resubmit once it recovers',
+    };
+  }
+  return {
+    reason: TaskFailureReason.INTERNAL_ERROR,
+    message,
+    retryable: true,
+    retryAfterSeconds: 600,
+    guidance: 'Resubmit the task; contact support with the task ID if the failure persists',
+  };
+}
@@ -0,0 +1,31 @@
+import { ApiKeyContext } from '../../api-key/interfaces/api-key-context.interface';
+
+/**
+ * 密钥可访问的任务范围（文档与翻译任务同 ID，项目和创建密钥记录在任务上）
+ */
+export interface TaskScope {
+  userId: string;
+  project?: string;
+  apiKeyId?: string;
+}
+
+/**
+ * 绑定项目的密钥（委托密钥等）只能访问该项目的任务；试运行密钥都属于演示账号，只能访问自己创建的任务
+ */
+export function taskScope(userId: string, apiKey?: ApiKeyContext): TaskScope {
+  const scope: TaskScope = { userId };
+  if (apiKey?.project) {
+    scope.project = apiKey.project;
+  }
+  if (apiKey?.playground) {
+    scope.apiKeyId = apiKey.id;
+  }
+  return scope;
+}
+
+/**
+ * 范围是否比所有者本人更窄
+ */
+export function isRestrictedScope(scope: TaskScope): boolean {
+  return !!scope.project || !!scope.apiKeyId;
+}
@@ -0,0 +1,33 @@
+import { DownloadFormat, renderDownload } from './translation-download';
+
+describe('renderDownload', () => {
+  const origin = { nav: { home: 'Home', about: 'About' }, title: 'Hello' };
+  const translated = { nav: { home: 'Startseite', about: 'Über uns' }, title: 'Hallo' };
+  const context = { id: 'task1', fromLang: 'en', toLang: 'de' };
+
+  it('raw 返回紧凑 JSON，pretty 返回缩进的 JSON', () => {
+    expect(renderDownload(origin, translated, DownloadFormat.RAW, context)).toEqual({
+      fileName: 'task1.de.json',
+      content: JSON.stringify(translated),
+    });
+    expect(renderDownload(origin, translated, DownloadFormat.PRETTY, context).content).toBe(
+      JSON.stringify(translated, null, 2),
+    );
+  });
+
+  it('flat 展开为点号路径，side_by_side 并列原文和译文', () => {
+    const flat = renderDownload(origin, translated, DownloadFormat.FLAT, context);
+    expect(flat.fileName).toBe('task1.de.flat.json');
+    expect(JSON.parse(flat.content)).toEqual({ 'nav.home': 'Startseite', 'nav.about': 'Über uns', title: 'Hallo' });
+
+    const sideBySide = renderDownload(origin, translated, DownloadFormat.SIDE_BY_SIDE, context);
+    expect(sideBySide.fileName).toBe('task1.en-de.json');
+    expect(JSON.parse(sideBySide.content)['nav.home']).toEqual({ en: 'Home', de: 'Startseite' });
+  });
+
+  it('文件名只保留安全字符', () => {
+    const { fileName } = renderDownload(origin, translated, DownloadFormat.RAW, { ...context, toLang: 'de"\r\nx' });
+
+    expect(fileName).toBe('task1.de___x.json');
+  });
+});
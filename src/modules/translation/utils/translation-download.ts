@@ -0,0 +1,60 @@
+import { formatOutputKeys, OutputKeyFormat } from './output-keys';
+
+/**
+ * 译文文件下载的格式
+ * - raw：紧凑的 JSON
+ * - pretty：缩进两个空格的 JSON
+ * - flat：点号路径的一层键值对象，例如 { "nav.home": "Startseite" }
+ * - side_by_side：按点号路径并列原文和译文，例如 { "nav.home": { "en": "Home", "de": "Startseite" } }
+ */
+export enum DownloadFormat {
+  RAW = 'raw',
+  PRETTY = 'pretty',
+  FLAT = 'flat',
+  SIDE_BY_SIDE = 'side_by_side',
+}
+
+export interface TranslationDownload {
+  fileName: string;
+  content: string;
+}
+
+export function renderDownload(
+  origin: any,
+  translated: any,
+  format: DownloadFormat,
+  context: { id: string; fromLang: string; toLang: string },
+): TranslationDownload {
+  const { fromLang, toLang } = context;
+  // 文件名写入 Content-Disposition，只保留安全字符
+  const id = safeName(context.id);
+  const [from, to] = [safeName(fromLang), safeName(toLang)];
+  switch (format) {
+    case DownloadFormat.RAW:
+      return { fileName: `${id}.${to}.json`, content: JSON.stringify(translated) };
+    case DownloadFormat.FLAT:
+      return {
+        fileName: `${id}.${to}.flat.json`,
+        content: JSON.stringify(flatten(translated, toLang), null, 2),
+      };
+    case DownloadFormat.SIDE_BY_SIDE: {
+      const source = flatten(origin, fromLang);
+      const target = flatten(translated, toLang);
+      const rows: Record<string, Record<string, any>> = {};
+      for (const key of new Set([...Object.keys(source), ...Object.keys(target)])) {
+        rows[key] = { [fromLang]: source[key] ?? null, [toLang]: target[key] ?? null };
+      }
+      return { fileName: `${id}.${from}-${to}.json`, content: JSON.stringify(rows, null, 2) };
+    }
+    default:
+      return { fileName: `${id}.${to}.json`, content: JSON.stringify(translated, null, 2) };
+  }
+}
+
+function flatten(document: any, lang: string): Record<string, any> {
+  return formatOutputKeys(document, { format: OutputKeyFormat.FLAT, fromLang: lang, toLang: lang });
+}
+
+function safeName(value: string): string {
+  return String(value).replace(/[^\w.-]/g, '_');
+}
@@ -0,0 +1,58 @@
+import { compileKeyPattern, lintTranslation, LintRuleDefinition, LintRuleType } from './translation-lint';
+
+describe('translation-lint', () => {
+  const rule = (data: Partial<LintRuleDefinition>): LintRuleDefinition => ({
+    id: 'r1',
+    type: LintRuleType.FORBIDDEN_TERM,
+    ...data,
+  });
+
+  it('应按键标注禁用词，默认不区分大小写', () => {
+    const violations = lintTranslation(
+      { title: 'Better than ACME', items: ['acme inside', 'ok'] },
+      [rule({ terms: ['Acme'], blocking: true })],
+      'en',
+    );
+
+    expect(Object.keys(violations)).toEqual(['title', 'items.0']);
+    expect(violations.title).toEqual([
+      { ruleId: 'r1', type: LintRuleType.FORBIDDEN_TERM, message: 'Contains forbidden term(s): Acme', blocking: true },
+    ]);
+  });
+
+  it('caseSensitive 时只匹配大小写一致的词', () => {
+    expect(lintTranslation({ a: 'acme' }, [rule({ terms: ['Acme'], caseSensitive: true })], 'en')).toEqual({});
+  });
+
+  it('应检查必须出现的敬语、长度上限和禁用字符', () => {
+    const violations = lintTranslation(
+      { greeting: '田中さん、こんにちは', cta: '今すぐ購入™' },
+      [
+        rule({ id: 'honorific', type: LintRuleType.REQUIRED_TERM, terms: ['様'], keyPattern: 'greeting' }),
+        rule({ id: 'length', type: LintRuleType.MAX_LENGTH, maxLength: 5 }),
+        rule({ id: 'chars', type: LintRuleType.BANNED_CHARACTERS, characters: '™®' }),
+      ],
+      'ja',
+    );
+
+    expect(violations.greeting.map((violation) => violation.ruleId)).toEqual(['honorific', 'length']);
+    expect(violations.cta.map((violation) => violation.message)).toEqual([
+      'Length 6 exceeds the limit of 5',
+      'Contains banned character(s): ™',
+    ]);
+  });
+
+  it('只对规则指定的目标语言生效，主语言同时匹配地区变体', () => {
+    const rules = [rule({ terms: ['x'], languages: ['ja'] })];
+
+    expect(lintTranslation({ a: 'x' }, rules, 'ja-JP')).toHaveProperty('a');
+    expect(lintTranslation({ a: 'x' }, rules, 'zh')).toEqual({});
+  });
+
+  it('键模式中 * 匹配一段，** 匹配任意多段', () => {
+    expect(compileKeyPattern('nav.*').test('nav.home')).toBe(true);
+    expect(compileKeyPattern('nav.*').test('nav.menu.home')).toBe(false);
+    expect(compileKeyPattern('marketing.**').test('marketing.hero.title')).toBe(true);
+    expect(compileKeyPattern('btn_*').test('btn_save')).toBe(true);
+  });
+});
@@ -0,0 +1,131 @@
+import { isPlainObject } from './json-diff';
+
+/**
+ * 译文内容规则检查：按用户配置的规则（各市场的禁用词、必须出现的敬语、长度上限、禁用字符）
+ * 检查译文中的每个字符串，按键（点号路径，数组下标同样作为一段）返回违规项
+ */
+export enum LintRuleType {
+  FORBIDDEN_TERM = 'forbidden_term',
+  REQUIRED_TERM = 'required_term',
+  MAX_LENGTH = 'max_length',
+  BANNED_CHARACTERS = 'banned_characters',
+}
+
+export interface LintRuleDefinition {
+  id: string;
+  name?: string;
+  type: LintRuleType | string;
+  /** 只对这些目标语言生效（ja 同时匹配 ja-JP），为空表示全部 */
+  languages?: string[];
+  /** 只检查匹配的键：* 匹配一段，** 匹配任意多段；为空表示全部 */
+  keyPattern?: string;
+  /** forbidden_term：出现任一即违规；required_term：至少出现其中一个 */
+  terms?: string[];
+  caseSensitive?: boolean;
+  /** max_length：字符数上限（按 Unicode 码点计） */
+  maxLength?: number;
+  /** banned_characters：不允许出现的字符 */
+  characters?: string;
+  /** 违规时暂停结果推送，直到问题解决 */
+  blocking?: boolean;
+}
+
+export interface LintViolation {
+  ruleId: string;
+  type: string;
+  message: string;
+  blocking: boolean;
+}
+
+export type LintViolations = Record<string, LintViolation[]>;
+
+export function lintTranslation(translated: any, rules: LintRuleDefinition[], toLang: string): LintViolations {
+  const applicable = rules
+    .filter((rule) => appliesToLanguage(rule, toLang))
+    .map((rule) => ({ rule, keyMatcher: rule.keyPattern ? compileKeyPattern(rule.keyPattern) : null }));
+  const violations: LintViolations = {};
+  if (applicable.length === 0) {
+    return violations;
+  }
+
+  walkStrings(translated, [], (key, text) => {
+    for (const { rule, keyMatcher } of applicable) {
+      if (keyMatcher && !keyMatcher.test(key)) {
+        continue;
+      }
+      const message = checkRule(rule, text);
+      if (message) {
+        (violations[key] ??= []).push({ ruleId: rule.id, type: rule.type, message, blocking: !!rule.blocking });
+      }
+    }
+  });
+  return violations;
+}
+
+function appliesToLanguage(rule: LintRuleDefinition, toLang: string): boolean {
+  if (!rule.languages?.length) {
+    return true;
+  }
+  const target = toLang.toLowerCase();
+  return rule.languages.some((lang) => {
+    const language = lang.toLowerCase();
+    return target === language || target.startsWith(`${language}-`);
+  });
+}
+
+export function compileKeyPattern(pattern: string): RegExp {
+  const source = pattern
+    .split('.')
+    .map((segment) => {
+      if (segment === '**') {
+        return '.*';
+      }
+      return segment
+        .split('*')
+        .map((part) => part.replace(/[.*+?^${}()|[\]\\]/g, '\\$&'))
+        .join('[^.]*');
+    })
+    .join('\\.');
+  return new RegExp(`^${source}$`);
+}
+
+function walkStrings(value: any, path: string[], visit: (key: string, text: string) => void): void {
+  if (typeof value === 'string') {
+    visit(path.join('.') || '$', value);
+  } else if (Array.isArray(value)) {
+    value.forEach((item, index) => walkStrings(item, [...path, String(index)], visit));
+  } else if (isPlainObject(value)) {
+    for (const [key, item] of Object.entries(value)) {
+      walkStrings(item, [...path, key], visit);
+    }
+  }
+}
+
+function checkRule(rule: LintRuleDefinition, text: string): string | null {
+  const fold = (value: string) => (rule.caseSensitive ? value : value.toLocaleLowerCase());
+  switch (rule.type) {
+    case LintRuleType.FORBIDDEN_TERM: {
+      const found = (rule.terms ?? []).filter((term) => fold(text).includes(fold(term)));
+      return found.length > 0 ? `Contains forbidden term(s): ${found.join(', ')}` : null;
+    }
+    case LintRuleType.REQUIRED_TERM: {
+      const terms = rule.terms ?? [];
+      return terms.length > 0 && !terms.some((term) => fold(text).includes(fold(term)))
+        ? `Missing required term (one of: ${terms.join(', ')})`
+        : null;
+    }
+    case LintRuleType.MAX_LENGTH: {
+      const length = [...text].length;
+      return rule.maxLength !== undefined && length > rule.maxLength
+        ? `Length ${length} exceeds the limit of ${rule.maxLength}`
+        : null;
+    }
+    case LintRuleType.BANNED_CHARACTERS: {
+      const banned = new Set([...(rule.characters ?? '')]);
+      const found = [...new Set([...text].filter((char) => banned.has(char)))];
+      return found.length > 0 ? `Contains banned character(s): ${found.join(' ')}` : null;
+    }
+    default:
+      return null;
+  }
+}
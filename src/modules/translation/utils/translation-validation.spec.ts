@@ -0,0 +1,60 @@
+import { validateTranslation, validateTranslationJson, ValidationIssueType } from './translation-validation';
+import { PlaceholderStyle } from './placeholders';
+
+describe('translation-validation', () => {
+  it('结构一致且占位符完整时没有问题', () => {
+    expect(validateTranslation({ a: 'Hi {name}', b: ['x', 1] }, { a: 'Hallo {name}', b: ['y', 1] })).toEqual({});
+  });
+
+  it('应按键标注缺失、多出和类型不一致的问题', () => {
+    const issues = validateTranslation(
+      { title: 'Hello', nav: { home: 'Home', about: 'About' }, tags: ['a', 'b'] },
+      { title: { text: 'Hallo' }, nav: { home: 'Start', legacy: 'x' }, tags: ['a'] },
+    );
+
+    expect(issues.title[0].type).toBe(ValidationIssueType.TYPE_MISMATCH);
+    expect(issues['nav.about'][0].type).toBe(ValidationIssueType.MISSING_KEY);
+    expect(issues['nav.legacy'][0].type).toBe(ValidationIssueType.EXTRA_KEY);
+    expect(issues.tags[0].message).toBe('Expected 2 item(s), got 1');
+  });
+
+  it('应按声明的语法检查占位符', () => {
+    const issues = validateTranslation(
+      { a: '%s of %d' },
+      { a: '%s von' },
+      { placeholderStyles: [PlaceholderStyle.PRINTF] },
+    );
+
+    expect(issues).toEqual({
+      a: [{ type: ValidationIssueType.MISSING_PLACEHOLDER, message: 'Missing placeholder(s): %d' }],
+    });
+  });
+
+  it('应标记空译文、长度比例异常和不配对的 HTML 标签', () => {
+    const issues = validateTranslation(
+      { a: 'Save', b: 'Click <a href="/x">here</a> to continue', c: 'Please confirm your email address' },
+      { a: ' ', b: 'Klicken Sie <a href="/x">hier, um fortzufahren', c: 'Bitte' },
+      { minLengthRatio: 0.3, maxLengthRatio: 3, minLengthForRatio: 20 },
+    );
+
+    expect(issues.a.map((issue) => issue.type)).toEqual([ValidationIssueType.EMPTY_TARGET]);
+    expect(issues.b.map((issue) => issue.type)).toContain(ValidationIssueType.UNBALANCED_HTML);
+    expect(issues.c.map((issue) => issue.type)).toEqual([ValidationIssueType.LENGTH_RATIO]);
+  });
+
+  it('开启格式检查时标记首尾空白和换行差异', () => {
+    const source = { a: 'Hello ', b: 'One\nTwo' };
+    const translated = { a: 'Hallo', b: 'Eins Zwei' };
+
+    expect(validateTranslation(source, translated)).toEqual({});
+    const issues = validateTranslation(source, translated, { checkFormatting: true });
+    expect(issues.a.map((issue) => issue.type)).toEqual([ValidationIssueType.WHITESPACE]);
+    expect(issues.b.map((issue) => issue.type)).toEqual([ValidationIssueType.LINE_BREAKS]);
+  });
+
+  it('译文不是合法 JSON 时只报告 invalid_json', () => {
+    expect(validateTranslationJson('{"a":"x"}', '{"a":')).toEqual({
+      $: [expect.objectContaining({ type: ValidationIssueType.INVALID_JSON })],
+    });
+  });
+});
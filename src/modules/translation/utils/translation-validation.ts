@@ -0,0 +1,194 @@
+import { isPlainObject } from './json-diff';
+import { missingPlaceholders, PlaceholderStyle } from './placeholders';
+import { formattingIssues } from './format-preservation';
+
+/**
+ * 译文校验：对照原文检查结构、占位符、空译文、长度比例和 HTML 标签配对，按键（点号路径）返回问题
+ */
+export enum ValidationIssueType {
+  INVALID_JSON = 'invalid_json',
+  MISSING_KEY = 'missing_key',
+  EXTRA_KEY = 'extra_key',
+  TYPE_MISMATCH = 'type_mismatch',
+  MISSING_PLACEHOLDER = 'missing_placeholder',
+  EMPTY_TARGET = 'empty_target',
+  LENGTH_RATIO = 'length_ratio',
+  UNBALANCED_HTML = 'unbalanced_html',
+  WHITESPACE = 'whitespace',
+  PLACEHOLDER_CASE = 'placeholder_case',
+  LINE_BREAKS = 'line_breaks',
+}
+
+export interface ValidationIssue {
+  type: ValidationIssueType;
+  message: string;
+}
+
+export interface ValidationOptions {
+  placeholderStyles?: PlaceholderStyle[];
+  /** 译文与原文的长度比例范围，超出时标记；原文短于 minLengthForRatio 的字符串不检查 */
+  minLengthRatio?: number;
+  maxLengthRatio?: number;
+  minLengthForRatio?: number;
+  /** 检查首尾空白、占位符大小写和换行是否与原文一致（任务开启 preserveFormatting 时） */
+  checkFormatting?: boolean;
+}
+
+export type ValidationIssues = Record<string, ValidationIssue[]>;
+
+export function validateTranslation(source: any, translated: any, options: ValidationOptions = {}): ValidationIssues {
+  const issues: ValidationIssues = {};
+  walk(source, translated, [], options, issues);
+  return issues;
+}
+
+function addIssue(issues: ValidationIssues, path: string[], type: ValidationIssueType, message: string): void {
+  const key = path.join('.') || '$';
+  (issues[key] ??= []).push({ type, message });
+}
+
+function kindOf(value: any): string {
+  if (value === null) {
+    return 'null';
+  }
+  return Array.isArray(value) ? 'array' : typeof value;
+}
+
+function walk(
+  source: any,
+  translated: any,
+  path: string[],
+  options: ValidationOptions,
+  issues: ValidationIssues,
+): void {
+  const expected = kindOf(source);
+  const actual = kindOf(translated);
+  if (expected !== actual) {
+    addIssue(issues, path, ValidationIssueType.TYPE_MISMATCH, `Expected ${expected}, got ${actual}`);
+    return;
+  }
+
+  if (isPlainObject(source)) {
+    for (const [key, value] of Object.entries(source)) {
+      if (!(key in translated)) {
+        addIssue(issues, [...path, key], ValidationIssueType.MISSING_KEY, 'Key is missing from the translation');
+      } else {
+        walk(value, translated[key], [...path, key], options, issues);
+      }
+    }
+    for (const key of Object.keys(translated)) {
+      if (!(key in source)) {
+        addIssue(issues, [...path, key], ValidationIssueType.EXTRA_KEY, 'Key does not exist in the source');
+      }
+    }
+  } else if (Array.isArray(source)) {
+    if (source.length !== translated.length) {
+      addIssue(
+        issues,
+        path,
+        ValidationIssueType.TYPE_MISMATCH,
+        `Expected ${source.length} item(s), got ${translated.length}`,
+      );
+    }
+    source.slice(0, translated.length).forEach((item, index) => {
+      walk(item, translated[index], [...path, String(index)], options, issues);
+    });
+  } else if (typeof source === 'string') {
+    checkString(source, translated, path, options, issues);
+  }
+}
+
+function checkString(
+  source: string,
+  translated: string,
+  path: string[],
+  options: ValidationOptions,
+  issues: ValidationIssues,
+): void {
+  const missing = missingPlaceholders(source, translated, options.placeholderStyles);
+  if (missing.length > 0) {
+    addIssue(issues, path, ValidationIssueType.MISSING_PLACEHOLDER, `Missing placeholder(s): ${missing.join(', ')}`);
+  }
+
+  if (source.trim() && !translated.trim()) {
+    addIssue(issues, path, ValidationIssueType.EMPTY_TARGET, 'Translation is empty');
+    return;
+  }
+
+  if (options.checkFormatting) {
+    for (const issue of formattingIssues(source, translated, options.placeholderStyles)) {
+      addIssue(issues, path, issue.type as string as ValidationIssueType, issue.message);
+    }
+  }
+
+  const sourceLength = source.trim().length;
+  if (options.minLengthRatio !== undefined && sourceLength >= (options.minLengthForRatio ?? 0)) {
+    const ratio = translated.trim().length / sourceLength;
+    if (ratio < options.minLengthRatio || ratio > (options.maxLengthRatio ?? Infinity)) {
+      addIssue(issues, path, ValidationIssueType.LENGTH_RATIO, `Length ratio ${ratio.toFixed(2)} is out of range`);
+    }
+  }
+
+  // 原文本身不配对时不追究译文
+  if (unbalancedTags(source).length === 0) {
+    const unbalanced = unbalancedTags(translated);
+    if (unbalanced.length > 0) {
+      addIssue(issues, path, ValidationIssueType.UNBALANCED_HTML, `Unbalanced HTML tag(s): ${unbalanced.join(', ')}`);
+    }
+  }
+}
+
+const VOID_TAGS = new Set([
+  'area',
+  'base',
+  'br',
+  'col',
+  'embed',
+  'hr',
+  'img',
+  'input',
+  'link',
+  'meta',
+  'source',
+  'wbr',
+]);
+
+/**
+ * 未闭合或多余的 HTML 标签（自闭合和空元素除外）
+ */
+export function unbalancedTags(text: string): string[] {
+  const open: string[] = [];
+  const unbalanced: string[] = [];
+  for (const [, closing, name, selfClosing] of text.matchAll(/<(\/?)([a-zA-Z][\w-]*)\b[^<>]*?(\/?)>/g)) {
+    const tag = name.toLowerCase();
+    if (selfClosing || VOID_TAGS.has(tag)) {
+      continue;
+    }
+    if (!closing) {
+      open.push(tag);
+    } else if (open[open.length - 1] === tag) {
+      open.pop();
+    } else {
+      unbalanced.push(`</${tag}>`);
+    }
+  }
+  return [...unbalanced, ...open.map((tag) => `<${tag}>`)];
+}
+
+/**
+ * 对原始 JSON 文本做完整校验；译文无法解析时只返回 invalid_json
+ */
+export function validateTranslationJson(
+  originJson: string,
+  translatedJson: string,
+  options: ValidationOptions = {},
+): ValidationIssues {
+  let translated: any;
+  try {
+    translated = JSON.parse(translatedJson);
+  } catch (error) {
+    const message = `Translation is not valid JSON: ${error.message}`;
+    return { $: [{ type: ValidationIssueType.INVALID_JSON, message }] };
+  }
+  return validateTranslation(JSON.parse(originJson), translated, options);
+}
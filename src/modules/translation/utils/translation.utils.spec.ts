@@ -0,0 +1,198 @@
+import { TranslationUtils } from './translation.utils';
+import { IgnoreMatcher, SkipHeuristic } from './ignore-rules';
+
+describe('TranslationUtils', () => {
+  const retryConfig: any = { getProviderConfig: () => ({ maxAttempts: 3, delay: 0 }) };
+  const unavailable = () => Object.assign(new Error('connect failed'), { code: 'ECONNREFUSED' });
+
+  let utils: TranslationUtils;
+  let callProvider: jest.SpyInstance;
+
+  beforeEach(() => {
+    utils = new TranslationUtils(undefined, undefined, retryConfig);
+    callProvider = jest.spyOn(utils as any, 'callProvider');
+  });
+
+  it('可恢复的错误按重试策略重试', async () => {
+    callProvider.mockRejectedValueOnce(unavailable()).mockResolvedValueOnce('Bonjour');
+
+    const result = await utils.translateJson('{"greeting":"Hello"}', 'en', 'fr', '');
+
+    expect(JSON.parse(result)).toEqual({ greeting: 'Bonjour' });
+    expect(callProvider).toHaveBeenCalledTimes(2);
+  });
+
+  it('重试用尽的键保留原文并返回路径，其余键照常翻译', async () => {
+    callProvider.mockImplementation(async (text: string) => {
+      if (text === 'About') {
+        throw unavailable();
+      }
+      return `fr:${text}`;
+    });
+    const untranslatedKeys: string[] = [];
+
+    const result = await utils.translateJson('{"nav":{"home":"Home","about":"About"}}', 'en', 'fr', '', {
+      untranslatedKeys,
+    });
+
+    expect(JSON.parse(result)).toEqual({ nav: { home: 'fr:Home', about: 'About' } });
+    expect(untranslatedKeys).toEqual(['nav.about']);
+    expect(callProvider).toHaveBeenCalledTimes(4);
+  });
+
+  it('只把成功的翻译服务调用计入账户的计费字符', async () => {
+    const providerUsage: any = { record: jest.fn() };
+    utils = new TranslationUtils(undefined, undefined, retryConfig, providerUsage);
+    jest.spyOn(utils as any, 'callProvider').mockImplementation(async (text: string) => {
+      if (text === 'Bad') {
+        throw Object.assign(new Error('Invalid text'), { status: 400 });
+      }
+      return `fr:${text}`;
+    });
+
+    await utils.translateJson('{"a":"Bad","b":"Hello"}', 'en', 'fr', '', { userId: 'u1', untranslatedKeys: [] });
+
+    expect(providerUsage.record).toHaveBeenCalledTimes(1);
+    expect(providerUsage.record).toHaveBeenCalledWith('aliyun', 5, 'u1');
+  });
+
+  it('忽略配置命中的节点和值保留原文，且不计入字符数', async () => {
+    callProvider.mockImplementation(async (text: string) => `fr:${text}`);
+    const source = {
+      ui: { title: 'Hello', link: 'https://example.com' },
+      meta: { note: 'internal' },
+      items: [{ sku: 'A-1', label: 'Shoes' }],
+    };
+    const ignoreRules = { paths: ['meta', 'items.*.sku'], skip: [SkipHeuristic.URLS] };
+
+    const result = await utils.translateJson(JSON.stringify(source), 'en', 'fr', '', { ignoreRules });
+    const chars = utils.countJsonChars(JSON.stringify(source), {
+      sourceData: source,
+      sourceLang: 'en',
+      targetLang: 'fr',
+      ignoredFields: [],
+      ignore: IgnoreMatcher.compile(ignoreRules),
+    });
+
+    expect(JSON.parse(result)).toEqual({
+      ui: { title: 'fr:Hello', link: 'https://example.com' },
+      meta: { note: 'internal' },
+      items: [{ sku: 'A-1', label: 'fr:Shoes' }],
+    });
+    expect(chars).toBe('Hello'.length + 'Shoes'.length);
+  });
+
+  it('不可重试的错误不重试', async () => {
+    callProvider.mockImplementation(async (text: string) => {
+      if (text === 'Bad') {
+        throw Object.assign(new Error('Invalid text'), { status: 400 });
+      }
+      return text;
+    });
+    const untranslatedKeys: string[] = [];
+
+    await utils.translateJson('{"a":"Bad","b":"Good"}', 'en', 'fr', '', { untranslatedKeys });
+
+    expect(untranslatedKeys).toEqual(['a']);
+    expect(callProvider).toHaveBeenCalledTimes(2);
+  });
+
+  it('服务商额度用尽时暂停并改用备用服务商，不重试', async () => {
+    const paused = new Set<string>();
+    const providerHealth: any = {
+      route: jest.fn(async (provider: string) => (paused.has(provider) ? 'backup' : provider)),
+      report: jest.fn(async (provider: string) => paused.add(provider)),
+    };
+    utils = new TranslationUtils(undefined, undefined, retryConfig, undefined, undefined, providerHealth);
+    callProvider = jest.spyOn(utils as any, 'callProvider').mockImplementation(async (text: string, config: any) => {
+      if (config.provider === 'aliyun') {
+        throw Object.assign(new Error('Insufficient balance'), { code: 'InsufficientBalance' });
+      }
+      return `${config.provider}:${text}`;
+    });
+
+    const result = await utils.translateJson('{"a":"Hi","b":"Bye"}', 'en', 'fr', '');
+
+    expect(JSON.parse(result)).toEqual({ a: 'backup:Hi', b: 'backup:Bye' });
+    expect(callProvider).toHaveBeenCalledTimes(3);
+    expect(providerHealth.report).toHaveBeenCalledTimes(1);
+  });
+
+  it('开启个人信息屏蔽时翻译服务只收到令牌', async () => {
+    callProvider.mockImplementation(async (text: string) => text.replace('Write to', 'Schreiben an'));
+
+    const result = await utils.translateJson('{"a":"Write to ann@example.com"}', 'en', 'de', '', { maskPii: true });
+
+    expect(callProvider).toHaveBeenCalledWith('Write to __PII_0__', expect.anything());
+    expect(JSON.parse(result)).toEqual({ a: 'Schreiben an ann@example.com' });
+  });
+
+  it('应按键记录失败原因', async () => {
+    callProvider.mockImplementation(async (text: string) => {
+      if (text === 'Bad') {
+        throw Object.assign(new Error('Invalid text'), { status: 400 });
+      }
+      return text;
+    });
+    const failedKeys = {};
+
+    await utils.translateJson('{"a":"Bad","b":"Good"}', 'en', 'fr', '', { failedKeys });
+
+    expect(failedKeys).toEqual({ a: { reason: 'invalid_input', message: 'Invalid text' } });
+  });
+
+  it('补译时只翻译指定的键并写回译文，支持数组下标', async () => {
+    callProvider.mockImplementation(async (text: string) => {
+      if (text === 'Two') {
+        throw unavailable();
+      }
+      return `fr:${text}`;
+    });
+    const source = { nav: { about: 'About' }, items: ['One', 'Two'], title: 'Title' };
+    const translated = { nav: { about: 'About' }, items: ['One', 'Two'], title: 'fr:Title' };
+    const untranslatedKeys: string[] = [];
+
+    const retranslated = await utils.retranslateKeys(
+      source,
+      translated,
+      ['nav.about', 'items.0', 'items.1', 'removed'],
+      'en',
+      'fr',
+      { untranslatedKeys },
+    );
+
+    expect(retranslated).toEqual(['nav.about', 'items.0']);
+    expect(translated).toEqual({ nav: { about: 'fr:About' }, items: ['fr:One', 'Two'], title: 'fr:Title' });
+    expect(untranslatedKeys).toEqual(['items.1']);
+  });
+
+  it('键名含点号或反斜杠时记录转义后的路径，补译时按同样的规则解析', async () => {
+    let available = false;
+    callProvider.mockImplementation(async (text: string) => {
+      if (!available && text !== 'Yes') {
+        throw unavailable();
+      }
+      return `fr:${text}`;
+    });
+    const source = { errors: { 'a.b': 'Oops', 'c\\d': 'Fail' }, ok: 'Yes' };
+    const untranslatedKeys: string[] = [];
+
+    const result = await utils.translateJson(JSON.stringify(source), 'en', 'fr', '', { untranslatedKeys });
+
+    expect(untranslatedKeys).toEqual(['errors.a\\.b', 'errors.c\\\\d']);
+    available = true;
+    const translated = JSON.parse(result);
+    const retranslated = await utils.retranslateKeys(source, translated, untranslatedKeys, 'en', 'fr');
+
+    expect(retranslated).toEqual(untranslatedKeys);
+    expect(translated).toEqual({ errors: { 'a.b': 'fr:Oops', 'c\\d': 'fr:Fail' }, ok: 'fr:Yes' });
+  });
+
+  it('一个键都没有翻译成功时抛出翻译服务的错误', async () => {
+    callProvider.mockRejectedValue(unavailable());
+
+    await expect(utils.translateJson('{"a":"One","b":"Two"}', 'en', 'fr', '')).rejects.toMatchObject({
+      code: 'ECONNREFUSED',
+    });
+  });
+});
@@ -1,21 +1,99 @@
-import { Injectable } from '@nestjs/common';
+import { Injectable, Logger, Optional } from '@nestjs/common';
+import { ProviderCacheService } from '../services/provider-cache.service';
+import { ProviderThrottleService } from '../services/provider-throttle.service';
+import { ProviderUsageService } from '../services/provider-usage.service';
+import { ProviderHealthService } from '../services/provider-health.service';
+import { CustomMtEngineService } from '../services/custom-mt-engine.service';
+import { DEFAULT_TRANSLATION_PROVIDER, TranslationProvider } from '../../../config/providers';
+import { extractPlaceholders, PlaceholderStyle } from './placeholders';
+import { isIcuChoiceMessage, translateIcuMessage } from './icu-message';
+import { JsonPath, pathKey } from './json-diff';
+import { formatKeyPath, parseKeyPath } from './locked-keys';
+import { KeyUsageCounter, recordKeyUsage } from './key-usage';
+import { compilePiiMatcher, maskPii, PiiMatcher, unmaskPii } from './pii-masking';
+import { classifyTaskFailure, PROVIDER_ACCOUNT_FAILURES, TaskFailureReason } from './task-failure';
+import { IgnoreMatcher, IgnoreRules } from './ignore-rules';
+import { repairFormatting } from './format-preservation';
+import { RetryConfigService } from '../../../common/services/retry-config.service';
+import { RetryConfig } from '../../../common/interfaces/retry-config.interface';
 
 export interface TranslationConfig {
   sourceData: any;
   sourceLang: string;
   targetLang: string;
   ignoredFields: string[];
+  /** 忽略配置中的路径、正则、include 和按值跳过的规则 */
+  ignore?: IgnoreMatcher;
+  provider?: string;
+  /** 翻译服务计费字符记在哪个账户下 */
+  userId?: string;
+  /** 占位符语法，为空时使用内置分隔符 */
+  placeholderStyles?: PlaceholderStyle[];
+  /** 译文按原文修复首尾空白、占位符大小写和换行符 */
+  preserveFormatting?: boolean;
+  /** 发给翻译服务前屏蔽的个人信息规则，为空时不屏蔽 */
+  pii?: PiiMatcher;
+  checkpoint?: TranslationCheckpoint;
+  /** 统计字符数时同时按键累计（countJsonChars） */
+  keyUsage?: KeyUsageCounter;
+  /** 本次翻译的叶子统计，重试用尽的叶子保留原文并记录路径和原因 */
+  progress?: {
+    translated: number;
+    untranslated: string[];
+    failures: Record<string, UntranslatedKeyFailure>;
+    lastError?: Error;
+  };
 }
 
+/** 未翻译的键失败的原因 */
+export interface UntranslatedKeyFailure {
+  reason: TaskFailureReason;
+  message: string;
+}
+
+/**
+ * 断点续译：以字符串叶子为粒度，completed 中按路径（pathKey）记录的译文直接复用，
+ * 其余叶子翻译成功后立即通过 save 记录，worker 崩溃后从最后一个已翻译的键继续
+ */
+export interface TranslationCheckpoint {
+  completed: Record<string, any>;
+  save(key: string, value: any): Promise<void>;
+}
+
+export interface TranslateJsonOptions {
+  /** 翻译服务，参与响应缓存的键 */
+  provider?: string;
+  /** 翻译服务计费字符记在哪个账户下，用于与服务商账单对账 */
+  userId?: string;
+  checkpoint?: TranslationCheckpoint;
+  placeholderStyles?: PlaceholderStyle[];
+  preserveFormatting?: boolean;
+  /** 发给翻译服务前屏蔽个人信息，译文中再换回原文 */
+  maskPii?: boolean;
+  /** 除内置规则外需要屏蔽的正则表达式 */
+  piiPatterns?: string[];
+  /** 文档保存的忽略配置快照 */
+  ignoreRules?: IgnoreRules;
+  /** 重试用尽仍未翻译、保留原文的键（点号路径）写入此数组 */
+  untranslatedKeys?: string[];
+  /** 未翻译的键（点号路径）→ 失败原因 */
+  failedKeys?: Record<string, UntranslatedKeyFailure>;
+}
+
+const DEFAULT_PROVIDER_RETRY: RetryConfig = { maxAttempts: 3, delay: 500, backoff: true, backoffFactor: 2 };
+
 @Injectable()
 export class TranslationUtils {
-  private readonly delimiters = [
-    ['{', '}'],
-    ['#{', '}'],
-    ['[', ']'],
-    ['<', '>'],
-    ['<', '/>'],
-  ];
+  private readonly logger = new Logger(TranslationUtils.name);
+
+  constructor(
+    @Optional() private readonly providerCache?: ProviderCacheService,
+    @Optional() private readonly providerThrottle?: ProviderThrottleService,
+    @Optional() private readonly retryConfigService?: RetryConfigService,
+    @Optional() private readonly providerUsage?: ProviderUsageService,
+    @Optional() private readonly customMtEngine?: CustomMtEngineService,
+    @Optional() private readonly providerHealth?: ProviderHealthService,
+  ) {}
 
   getIgnoredFields(ignoredFieldsStr: string): string[] {
     if (!ignoredFieldsStr) {
@@ -33,7 +111,10 @@ export class TranslationUtils {
     fromLang: string,
     toLang: string,
     ignoredFields: string,
+    options: TranslateJsonOptions = {},
   ): Promise<string> {
+    const progress: TranslationConfig['progress'] = { translated: 0, untranslated: [], failures: {} };
+    let translatedData: any;
     try {
       const result = JSON.parse(jsonData);
       const config: TranslationConfig = {
@@ -41,13 +122,92 @@ export class TranslationUtils {
         sourceLang: fromLang,
         targetLang: toLang,
         ignoredFields: this.getIgnoredFields(ignoredFields),
+        ignore: IgnoreMatcher.compile(options.ignoreRules),
+        provider: options.provider,
+        userId: options.userId,
+        placeholderStyles: options.placeholderStyles,
+        preserveFormatting: options.preserveFormatting,
+        pii: options.maskPii ? compilePiiMatcher(options.piiPatterns) : undefined,
+        checkpoint: options.checkpoint,
+        progress,
       };
 
-      const translatedData = await this.translateJSON(config);
-      return JSON.stringify(translatedData, null, 2);
+      translatedData = await this.translateJSON(config);
     } catch (error) {
       throw new Error(`Failed to translate JSON: ${error.message}`);
     }
+
+    // 一个叶子都没有翻译成功时视为整体失败，原样抛出翻译服务的错误交给任务级重试和失败分类
+    if (progress.untranslated.length > 0 && progress.translated === 0) {
+      throw progress.lastError ?? new Error('Failed to translate JSON: no keys could be translated');
+    }
+    this.collectUntranslated(progress, options);
+    return JSON.stringify(translatedData, null, 2);
+  }
+
+  /**
+   * 只重新翻译指定的叶子（点号路径，可包含数组下标），用于补译部分翻译文档中失败的键
+   * 译文直接写入 translated；源文档中已不存在或不再是字符串的键忽略，仍然失败的键写入 options
+   * 返回本次翻译成功的键
+   */
+  async retranslateKeys(
+    source: any,
+    translated: any,
+    keys: string[],
+    fromLang: string,
+    toLang: string,
+    options: TranslateJsonOptions = {},
+  ): Promise<string[]> {
+    const progress: TranslationConfig['progress'] = { translated: 0, untranslated: [], failures: {} };
+    const config: TranslationConfig = {
+      sourceData: source,
+      sourceLang: fromLang,
+      targetLang: toLang,
+      ignoredFields: [],
+      provider: options.provider,
+      userId: options.userId,
+      placeholderStyles: options.placeholderStyles,
+      preserveFormatting: options.preserveFormatting,
+      pii: options.maskPii ? compilePiiMatcher(options.piiPatterns) : undefined,
+      progress,
+    };
+
+    const succeeded: string[] = [];
+    for (const key of keys) {
+      const path = parseKeyPath(key);
+      const text = this.valueAt(source, path);
+      const parent = this.valueAt(translated, path.slice(0, -1));
+      if (typeof text !== 'string' || parent === null || typeof parent !== 'object') {
+        continue;
+      }
+
+      const value = await this.translateLeaf(text, config, path);
+      if (!progress.failures[key]) {
+        parent[path[path.length - 1]] = value;
+        succeeded.push(key);
+      }
+    }
+
+    this.collectUntranslated(progress, options);
+    return succeeded;
+  }
+
+  private valueAt(root: any, path: JsonPath): any {
+    return path.reduce((node, part) => (node !== null && typeof node === 'object' ? node[part] : undefined), root);
+  }
+
+  private collectUntranslated(progress: TranslationConfig['progress'], options: TranslateJsonOptions): void {
+    options.untranslatedKeys?.push(...progress.untranslated);
+    if (options.failedKeys) {
+      Object.assign(options.failedKeys, progress.failures);
+    }
+  }
+
+  private recordUntranslated(config: TranslationConfig, key: string, error: Error): void {
+    const failure = classifyTaskFailure(error);
+    config.progress.untranslated.push(key);
+    config.progress.failures[key] = { reason: failure.reason, message: failure.message };
+    config.progress.lastError = error;
   }
 
   private async translateJSON(config: TranslationConfig): Promise<any> {
@@ -57,15 +217,18 @@ export class TranslationUtils {
     for (const key of keys) {
       const value = config.sourceData[key];
 
-      if (this.isIgnored(key, config.ignoredFields)) {
+      if (this.skipsNode([key], config)) {
         translatedData[key] = value;
         continue;
       }
 
       try {
-        translatedData[key] = await this.translateElement(value, config);
+        translatedData[key] = await this.translateElement(value, config, [key]);
       } catch (error) {
-        console.error(`Error translating key ${key}:`, error);
+        this.logger.error(`Error translating key ${key}: ${error.message}`);
+        if (config.progress) {
+          this.recordUntranslated(config, key, error);
+        }
         translatedData[key] = value;
       }
     }
@@ -76,29 +239,86 @@ export class TranslationUtils {
   private async translateElement(
     element: any,
     config: TranslationConfig,
+    path: JsonPath = [],
   ): Promise<any> {
     if (element === null || element === undefined) {
       return element;
     }
 
     if (typeof element === 'object' && !Array.isArray(element)) {
-      return this.translateNestedJSON(element, config);
+      return this.translateNestedJSON(element, config, path);
     }
 
     if (Array.isArray(element)) {
-      return this.translateArray(element, config);
+      return this.translateArray(element, config, path);
     }
 
     if (typeof element === 'string') {
-      return this.translateString(element, config);
+      return config.ignore?.skipsLeaf(path, element) ? element : this.translateLeaf(element, config, path);
     }
 
     return element;
   }
 
+  /**
+   * ignoredFields 中的键名，或忽略配置中匹配的路径，整个子树保留原文
+   */
+  private skipsNode(path: JsonPath, config: TranslationConfig): boolean {
+    return this.isIgnored(path[path.length - 1], config.ignoredFields) || !!config.ignore?.skipsNode(path);
+  }
+
+  /**
+   * 翻译一个字符串叶子，命中断点时直接复用上次的译文
+   */
+  private async translateLeaf(text: string, config: TranslationConfig, path: JsonPath): Promise<string> {
+    const checkpoint = config.checkpoint;
+    const field = pathKey(path);
+    if (checkpoint && field in checkpoint.completed) {
+      this.countTranslated(config);
+      return checkpoint.completed[field];
+    }
+
+    let translated: string;
+    try {
+      translated = await this.translateMasked(text, config);
+      if (config.preserveFormatting) {
+        translated = repairFormatting(text, translated, config.placeholderStyles).text;
+      }
+    } catch (error) {
+      // 重试用尽或不可重试：保留原文并记录，不影响其他键
+      if (!config.progress) {
+        throw error;
+      }
+      this.recordUntranslated(config, formatKeyPath(path), error);
+      this.logger.warn(`Leaving ${formatKeyPath(path)} untranslated: ${error.message}`);
+      return text;
+    }
+    this.countTranslated(config);
+    await checkpoint?.save(field, translated);
+    return translated;
+  }
+
+  /**
+   * 开启个人信息屏蔽时翻译服务只收到令牌，译文中的令牌换回原文；令牌丢失时抛出错误，该键保留原文
+   */
+  private async translateMasked(text: string, config: TranslationConfig): Promise<string> {
+    const masked = config.pii ? maskPii(text, config.pii) : null;
+    if (!masked?.values.length) {
+      return this.translateString(text, config);
+    }
+    return unmaskPii(await this.translateString(masked.text, config), masked.values);
+  }
+
+  private countTranslated(config: TranslationConfig): void {
+    if (config.progress) {
+      config.progress.translated += 1;
+    }
+  }
+
   private async translateNestedJSON(
     data: any,
     config: TranslationConfig,
+    path: JsonPath = [],
   ): Promise<any> {
     const translatedData = {};
     const keys = Object.keys(data);
@@ -106,13 +326,13 @@ export class TranslationUtils {
     for (const key of keys) {
       const value = data[key];
 
-      if (this.isIgnored(key, config.ignoredFields)) {
+      if (this.skipsNode([...path, key], config)) {
         translatedData[key] = value;
         continue;
       }
 
       try {
-        translatedData[key] = await this.translateElement(value, config);
+        translatedData[key] = await this.translateElement(value, config, [...path, key]);
       } catch (error) {
         throw new Error(`Error translating key ${key}: ${error.message}`);
       }
@@ -124,23 +344,40 @@ export class TranslationUtils {
   private async translateArray(
     array: any[],
     config: TranslationConfig,
+    path: JsonPath = [],
   ): Promise<any[]> {
     const translatedArray = [];
-    for (const item of array) {
-      translatedArray.push(await this.translateElement(item, config));
+    for (const [index, item] of array.entries()) {
+      const itemPath = [...path, String(index)];
+      translatedArray.push(
+        config.ignore?.skipsNode(itemPath) ? item : await this.translateElement(item, config, itemPath),
+      );
     }
     return translatedArray;
   }
 
+  /**
+   * ICU plural / select 消息只翻译各分支的文字，其余字符串整体翻译
+   */
   private async translateString(
     text: string,
     config: TranslationConfig,
   ): Promise<string> {
-    const variables = this.extractVariables(text);
+    if (isIcuChoiceMessage(text)) {
+      return translateIcuMessage(text, (part) => this.translatePlainString(part, config));
+    }
+    return this.translatePlainString(text, config);
+  }
+
+  private async translatePlainString(
+    text: string,
+    config: TranslationConfig,
+  ): Promise<string> {
+    const variables = extractPlaceholders(text, config.placeholderStyles);
     let translatedText = await this.translateText(text, config);
 
     if (variables.length > 0) {
-      const translatedVariables = this.extractVariables(translatedText);
+      const translatedVariables = extractPlaceholders(translatedText, config.placeholderStyles);
       if (translatedVariables.length === variables.length) {
         for (let i = 0; i < variables.length; i++) {
           translatedText = translatedText.replace(
@@ -158,91 +395,138 @@ export class TranslationUtils {
     text: string,
     config: TranslationConfig,
   ): Promise<string> {
-    // TODO: 实现实际的翻译逻辑，调用翻译 API
-    // 这里只是一个示例实现
-    return text;
-  }
-
-  private extractVariables(text: string): string[] {
-    const variables: string[] = [];
-    const processedIndexes = new Set<number>();
+    // 用户自己的引擎：响应缓存按服务商共享，不能混用；也不计入服务商用量和限流
+    if (config.provider === TranslationProvider.CUSTOM && this.customMtEngine) {
+      return this.withRetry(() =>
+        this.customMtEngine.translate(config.userId, text, config.sourceLang, config.targetLang),
+      );
+    }
 
-    for (const [startDelimiter, endDelimiter] of this.delimiters) {
-      const pattern = `\\${startDelimiter}(.+?)\\${endDelimiter}`;
-      const regex = new RegExp(pattern, 'g');
-      let match;
+    const requested = config.provider || DEFAULT_TRANSLATION_PROVIDER;
+    // 服务商因额度用尽或凭证失效暂停时换用备用服务商，调用中发现账号失败时暂停并立即换下一个
+    for (;;) {
+      const provider = this.providerHealth ? await this.providerHealth.route(requested) : requested;
+      try {
+        return await this.translateWithProvider(text, config, provider);
+      } catch (error) {
+        if (!(await this.providerHealth?.report(provider, error))) {
+          throw error;
+        }
+      }
+    }
+  }
 
-      while ((match = regex.exec(text)) !== null) {
-        const start = match.index;
-        const end = start + match[0].length;
-        let isOverlapping = false;
+  private async translateWithProvider(text: string, config: TranslationConfig, provider: string): Promise<string> {
+    // 备用服务商的译文按实际服务商缓存，不混入请求的服务商的缓存
+    const rerouted = provider !== (config.provider || DEFAULT_TRANSLATION_PROVIDER);
+    const segment = {
+      text,
+      from: config.sourceLang,
+      to: config.targetLang,
+      provider: rerouted ? provider : config.provider,
+    };
+    const cached = await this.providerCache?.get(segment);
+    if (cached !== null && cached !== undefined) {
+      return cached;
+    }
 
-        for (let i = start; i < end; i++) {
-          if (processedIndexes.has(i)) {
-            isOverlapping = true;
-            break;
-          }
-        }
+    const routed = { ...config, provider };
+    const translated = await this.withRetry(() =>
+      this.providerThrottle
+        ? this.providerThrottle.execute(provider, () => this.callProvider(text, routed))
+        : this.callProvider(text, routed),
+    );
+    await this.providerUsage?.record(provider, text.length, config.userId);
+    await this.providerCache?.set(segment, translated);
+    return translated;
+  }
 
-        if (!isOverlapping) {
-          variables.push(match[0]);
-          for (let i = start; i < end; i++) {
-            processedIndexes.add(i);
-          }
+  /**
+   * 按 PROVIDER_RETRY_* 策略重试翻译服务调用，只重试可恢复的错误（限流、超时、服务不可用等）；
+   * 服务商账号层面的失败（额度用尽、凭证被拒）重试也不会成功，直接抛出
+   */
+  private async withRetry<T>(fn: () => Promise<T>): Promise<T> {
+    const policy = this.retryConfigService?.getProviderConfig() ?? DEFAULT_PROVIDER_RETRY;
+    for (let attempt = 1; ; attempt++) {
+      try {
+        return await fn();
+      } catch (error) {
+        const failure = classifyTaskFailure(error);
+        if (attempt >= policy.maxAttempts || !failure.retryable || PROVIDER_ACCOUNT_FAILURES.has(failure.reason)) {
+          throw error;
         }
+        const delay = policy.backoff ? policy.delay * Math.pow(policy.backoffFactor || 2, attempt - 1) : policy.delay;
+        await new Promise((resolve) => setTimeout(resolve, delay));
       }
     }
+  }
 
-    return variables;
+  private async callProvider(
+    text: string,
+    config: TranslationConfig,
+  ): Promise<string> {
+    // TODO: 实现实际的翻译逻辑，调用翻译 API
+    // 这里只是一个示例实现
+    return text;
   }
 
   countJsonChars(jsonData: string, config: TranslationConfig): number {
     try {
       const data = JSON.parse(jsonData);
-      return this.countElement(data, config);
+      return this.countElement(data, config, []);
     } catch (error) {
       throw new Error(`Failed to count JSON characters: ${error.message}`);
     }
   }
 
-  private countElement(element: any, config: TranslationConfig): number {
+  private countElement(element: any, config: TranslationConfig, path: JsonPath): number {
     if (element === null || element === undefined) {
       return 0;
     }
 
     if (typeof element === 'object' && !Array.isArray(element)) {
-      return this.countObject(element, config);
+      return this.countObject(element, config, path);
     }
 
     if (Array.isArray(element)) {
-      return this.countArray(element, config);
+      return this.countArray(element, config, path);
     }
 
     if (typeof element === 'string') {
-      return this.countString(element);
+      if (config.ignore?.skipsLeaf(path, element)) {
+        return 0;
+      }
+      const characters = this.countString(element);
+      if (config.keyUsage) {
+        recordKeyUsage(config.keyUsage, path, characters);
+      }
+      return characters;
     }
 
     return 0;
   }
 
-  private countObject(obj: any, config: TranslationConfig): number {
+  private countObject(obj: any, config: TranslationConfig, path: JsonPath): number {
     let totalCount = 0;
     const keys = Object.keys(obj);
 
     for (const key of keys) {
-      if (this.isIgnored(key, config.ignoredFields)) {
+      if (this.skipsNode([...path, key], config)) {
         continue;
       }
-      totalCount += this.countElement(obj[key], config);
+      totalCount += this.countElement(obj[key], config, [...path, key]);
     }
 
     return totalCount;
   }
 
-  private countArray(array: any[], config: TranslationConfig): number {
+  private countArray(array: any[], config: TranslationConfig, path: JsonPath): number {
     let totalCount = 0;
-    for (const item of array) {
-      totalCount += this.countElement(item, config);
+    for (const [index, item] of array.entries()) {
+      const itemPath = [...path, String(index)];
+      if (!config.ignore?.skipsNode(itemPath)) {
+        totalCount += this.countElement(item, config, itemPath);
+      }
     }
     return totalCount;
   }
@@ -5,6 +5,11 @@ import { UserSubscription } from '../../subscription/entities/user-subscription.
 import { ApiKey } from './api-key.entity';
 import { UsageLog } from './usage-log.entity';
 
+export enum UserRole {
+  USER = 'user',
+  ADMIN = 'admin',
+}
+
 export enum AuthProvider {
   LOCAL = 'local',
   GOOGLE = 'google',
@@ -43,9 +48,22 @@ export class User extends BaseEntity {
   @Enum(() => AuthProvider)
   provider: AuthProvider = AuthProvider.LOCAL;
 
+  @Enum(() => UserRole)
+  role: UserRole = UserRole.USER;
+
   @Property({ nullable: true })
   providerId?: string;
 
+  /** 账号级 API 锁定（疑似密钥泄露时的应急开关）：锁定期间拒绝创建任务，暂停翻译和 webhook 推送 */
+  @Property({ nullable: true })
+  apiLockedAt?: Date;
+
+  @Property({ type: 'text', nullable: true })
+  apiLockReason?: string;
+
+  @Property({ nullable: true })
+  apiLockedBy?: string;
+
   @ManyToOne(() => SubscriptionPlan)
   subscriptionPlan!: SubscriptionPlan;
 
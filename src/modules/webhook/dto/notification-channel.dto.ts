@@ -0,0 +1,49 @@
+import { ApiProperty } from '@nestjs/swagger';
+import {
+  ArrayMaxSize,
+  ArrayNotEmpty,
+  IsArray,
+  IsEmail,
+  IsEnum,
+  IsIn,
+  IsOptional,
+  IsString,
+  MaxLength,
+} from 'class-validator';
+import { NotificationChannelType } from '../entities/notification-channel.entity';
+import { NOTIFICATION_EVENTS } from '../utils/notification-message';
+
+export class CreateNotificationChannelDto {
+  @ApiProperty({ description: '渠道类型', enum: NotificationChannelType })
+  @IsEnum(NotificationChannelType)
+  type: NotificationChannelType;
+
+  @ApiProperty({ description: '名称', required: false, example: 'Localization team' })
+  @IsOptional()
+  @IsString()
+  @MaxLength(255)
+  name?: string;
+
+  @ApiProperty({ description: '订阅的事件类型', enum: [...NOTIFICATION_EVENTS], isArray: true })
+  @IsArray()
+  @ArrayNotEmpty()
+  @IsIn([...NOTIFICATION_EVENTS], { each: true })
+  events: string[];
+
+  @ApiProperty({ description: 'email：收件人，最多 10 个', type: [String], required: false })
+  @IsOptional()
+  @IsArray()
+  @ArrayNotEmpty()
+  @ArrayMaxSize(10)
+  @IsEmail({}, { each: true })
+  recipients?: string[];
+
+  @ApiProperty({
+    description: 'slack：incoming webhook 地址（https://hooks.slack.com/services/...），加密保存',
+    required: false,
+  })
+  @IsOptional()
+  @IsString()
+  @MaxLength(512)
+  slackWebhookUrl?: string;
+}
@@ -0,0 +1,35 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { Type } from 'class-transformer';
+import { IsObject, IsOptional, IsString, MaxLength, ValidateNested } from 'class-validator';
+
+export class WebhookBasicAuthDto {
+  @ApiProperty({ description: '用户名', example: 'translator' })
+  @IsString()
+  @MaxLength(256)
+  username: string;
+
+  @ApiProperty({ description: '密码' })
+  @IsString()
+  @MaxLength(256)
+  password: string;
+}
+
+/**
+ * webhook 推送时附带的认证信息，整体替换已有配置；两者都不传时清除
+ */
+export class WebhookAuthDto {
+  @ApiProperty({
+    description: '静态请求头（名称 → 取值），最多 10 个',
+    required: false,
+    example: { Authorization: 'Bearer <token>' },
+  })
+  @IsOptional()
+  @IsObject()
+  headers?: Record<string, string>;
+
+  @ApiProperty({ description: 'Basic 认证，转换为 Authorization 请求头', required: false, type: WebhookBasicAuthDto })
+  @IsOptional()
+  @ValidateNested()
+  @Type(() => WebhookBasicAuthDto)
+  basicAuth?: WebhookBasicAuthDto;
+}
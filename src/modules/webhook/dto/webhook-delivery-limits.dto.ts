@@ -0,0 +1,23 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsInt, IsOptional, Max, Min, ValidateIf } from 'class-validator';
+
+/**
+ * webhook 推送限制，传 null 取消对应限制，不传则保持不变
+ */
+export class WebhookDeliveryLimitsDto {
+  @ApiProperty({ description: '同时进行中的推送上限', required: false, nullable: true, example: 2 })
+  @IsOptional()
+  @ValidateIf((_, value) => value !== null)
+  @IsInt()
+  @Min(1)
+  @Max(100)
+  maxConcurrency?: number | null;
+
+  @ApiProperty({ description: '每分钟推送次数上限', required: false, nullable: true, example: 30 })
+  @IsOptional()
+  @ValidateIf((_, value) => value !== null)
+  @IsInt()
+  @Min(1)
+  @Max(10000)
+  maxPerMinute?: number | null;
+}
@@ -0,0 +1,21 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsBoolean, IsEnum, IsOptional } from 'class-validator';
+import { WebhookPayloadFormat } from '../entities/webhook-config.entity';
+
+export class WebhookPayloadFormatDto {
+  @ApiProperty({
+    description: '翻译结果推送的载荷格式：envelope 为带版本号的事件信封，legacy 为旧格式 { code, msg, data }',
+    enum: WebhookPayloadFormat,
+    example: WebhookPayloadFormat.ENVELOPE,
+  })
+  @IsEnum(WebhookPayloadFormat)
+  payloadFormat: WebhookPayloadFormat;
+
+  @ApiProperty({
+    description: '事件信封中附带与上一次推送的译文之间的差异（data.diff），不传时保持当前设置',
+    required: false,
+  })
+  @IsOptional()
+  @IsBoolean()
+  includeDiff?: boolean;
+}
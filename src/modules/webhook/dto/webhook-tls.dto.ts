@@ -0,0 +1,34 @@
+import { ApiProperty } from '@nestjs/swagger';
+import { IsOptional, IsString, MaxLength } from 'class-validator';
+
+/**
+ * webhook 推送的 TLS 设置（自定义 CA、客户端证书），整体替换已有配置；都不传时清除
+ */
+export class WebhookTlsDto {
+  @ApiProperty({
+    description: '信任的 CA 证书（PEM，可包含多个证书）；设置后只信任这些 CA，不再使用系统 CA',
+    required: false,
+  })
+  @IsOptional()
+  @IsString()
+  @MaxLength(65536)
+  ca?: string;
+
+  @ApiProperty({ description: '客户端证书（PEM，可附带中间证书），用于双向 TLS', required: false })
+  @IsOptional()
+  @IsString()
+  @MaxLength(65536)
+  clientCert?: string;
+
+  @ApiProperty({ description: '客户端证书的私钥（PEM），加密保存，不会在接口中返回', required: false })
+  @IsOptional()
+  @IsString()
+  @MaxLength(16384)
+  clientKey?: string;
+
+  @ApiProperty({ description: '私钥口令', required: false })
+  @IsOptional()
+  @IsString()
+  @MaxLength(1024)
+  passphrase?: string;
+}
@@ -0,0 +1,50 @@
+import { Entity, Enum, PrimaryKey, Property } from '@mikro-orm/core';
+
+export enum NotificationChannelType {
+  EMAIL = 'email',
+  SLACK = 'slack',
+}
+
+/**
+ * webhook 之外的通知渠道（邮件、Slack incoming webhook）
+ * 按事件类型订阅，投递走 webhook 队列的重试，每次尝试记录到发送历史
+ */
+@Entity()
+export class NotificationChannel {
+  @PrimaryKey()
+  id!: string;
+
+  @Property()
+  userId!: string;
+
+  /** 只接收该租户的事件，为空表示接收账户下所有事件 */
+  @Property({ nullable: true })
+  tenantId?: string;
+
+  @Enum({ items: () => NotificationChannelType })
+  type!: NotificationChannelType;
+
+  @Property({ nullable: true })
+  name?: string;
+
+  /** 订阅的事件类型 */
+  @Property({ type: 'json' })
+  events!: string[];
+
+  /** email：收件人 */
+  @Property({ type: 'json', nullable: true })
+  recipients?: string[];
+
+  /** slack：incoming webhook 地址，SecretBoxService 加密保存 */
+  @Property({ type: 'text', nullable: true, hidden: true })
+  slackWebhookUrl?: string;
+
+  @Property()
+  isActive: boolean = true;
+
+  @Property()
+  createdAt: Date = new Date();
+
+  @Property({ onUpdate: () => new Date() })
+  updatedAt: Date = new Date();
+}
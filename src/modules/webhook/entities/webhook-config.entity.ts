@@ -1,4 +1,12 @@
-import { Entity, PrimaryKey, Property } from '@mikro-orm/core';
+import { Entity, Enum, PrimaryKey, Property } from '@mikro-orm/core';
+
+/** 翻译结果推送的载荷格式 */
+export enum WebhookPayloadFormat {
+  /** 旧格式 { code, msg, data }，data 只有译文 */
+  LEGACY = 'legacy',
+  /** 带版本号的事件信封，包含文档元信息和获取链接 */
+  ENVELOPE = 'envelope',
+}
 
 @Entity()
 export class WebhookConfig {
@@ -11,6 +19,50 @@ export class WebhookConfig {
   @Property()
   webhookUrl!: string;
 
+  /** 租户专属的 webhook，为空表示账户默认配置 */
+  @Property({ nullable: true })
+  tenantId?: string;
+
+  /** 同时进行中的推送上限，为空表示不限制 */
+  @Property({ nullable: true })
+  maxConcurrency?: number;
+
+  /** 每分钟推送次数上限，为空表示不限制 */
+  @Property({ nullable: true })
+  maxPerMinute?: number;
+
+  /** 已有配置迁移为 legacy 保持兼容，新建配置默认使用事件信封 */
+  @Enum({ items: () => WebhookPayloadFormat })
+  payloadFormat: WebhookPayloadFormat = WebhookPayloadFormat.ENVELOPE;
+
+  /** envelope 格式的结果推送附带与上一次推送的译文之间的差异 */
+  @Property()
+  includeDiff: boolean = false;
+
+  /** 推送时附带的请求头（含 Basic 认证生成的 Authorization），SecretBoxService 加密后的 JSON */
+  @Property({ type: 'text', nullable: true, hidden: true })
+  authHeaders?: string;
+
+  /** 已配置的请求头名称，用于展示，不含取值 */
+  @Property({ type: 'json', nullable: true })
+  authHeaderNames?: string[];
+
+  /** 信任的 CA 证书（PEM），设置后推送只信任这些 CA */
+  @Property({ type: 'text', nullable: true, hidden: true })
+  tlsCa?: string;
+
+  /** 双向 TLS 的客户端证书（PEM） */
+  @Property({ type: 'text', nullable: true, hidden: true })
+  tlsClientCert?: string;
+
+  /** 客户端证书的私钥和口令，SecretBoxService 加密后的 JSON */
+  @Property({ type: 'text', nullable: true, hidden: true })
+  tlsClientKey?: string;
+
+  /** 已配置证书的主题、签发者、到期时间和指纹，用于展示 */
+  @Property({ type: 'json', nullable: true })
+  tlsInfo?: Record<string, any>;
+
   @Property()
   createdAt: Date = new Date();
 
@@ -0,0 +1,97 @@
+import {
+  Body,
+  Controller,
+  DefaultValuePipe,
+  Delete,
+  ForbiddenException,
+  Get,
+  HttpCode,
+  HttpStatus,
+  Param,
+  ParseIntPipe,
+  Post,
+  Query,
+  Req,
+  UseGuards,
+} from '@nestjs/common';
+import { ApiOperation, ApiParam, ApiQuery, ApiResponse, ApiTags } from '@nestjs/swagger';
+import { JwtAuthGuard } from '../auth/guards/jwt-auth.guard';
+import { PlanLimitsService } from '../subscription/services/plan-limits.service';
+import { TenantService } from '../tenant/services/tenant.service';
+import { NotificationChannelService } from './services/notification-channel.service';
+import { CreateNotificationChannelDto } from './dto/notification-channel.dto';
+
+@ApiTags('webhook')
+@Controller('webhook/channels')
+@UseGuards(JwtAuthGuard)
+export class NotificationChannelController {
+  constructor(
+    private readonly notificationChannelService: NotificationChannelService,
+    private readonly planLimitsService: PlanLimitsService,
+    private readonly tenantService: TenantService,
+  ) {}
+
+  private async ensureWebhookAccess(userId: string): Promise<void> {
+    const allowed = await this.planLimitsService.hasFeature(userId, 'webhooks');
+    if (!allowed) {
+      throw new ForbiddenException('Webhook functionality is not available for free users');
+    }
+  }
+
+  @Post()
+  @ApiOperation({ summary: '添加邮件或 Slack 通知渠道，按事件类型订阅' })
+  @ApiResponse({ status: 201, description: '已添加；Slack 地址加密保存，不会在接口中返回' })
+  @ApiResponse({ status: 400, description: '缺少收件人或 Slack 地址无效、未配置邮件发送、超出数量限制' })
+  @ApiResponse({ status: 403, description: '免费用户无法使用 webhook 功能' })
+  async create(@Req() req: any, @Body() dto: CreateNotificationChannelDto) {
+    await this.ensureWebhookAccess(req.user.id);
+    const tenant = await this.tenantService.resolveFromRequest(req);
+    return this.notificationChannelService.create(req.user.id, dto, tenant?.id);
+  }
+
+  @Get()
+  @ApiOperation({ summary: '获取通知渠道列表' })
+  async list(@Req() req: any) {
+    await this.ensureWebhookAccess(req.user.id);
+    return this.notificationChannelService.list(req.user.id);
+  }
+
+  @Post(':id/test')
+  @HttpCode(HttpStatus.ACCEPTED)
+  @ApiOperation({ summary: '发送一条测试通知，结果见发送历史' })
+  @ApiParam({ name: 'id', description: '通知渠道 ID' })
+  @ApiResponse({ status: 202, description: '已加入队列' })
+  async sendTest(@Req() req: any, @Param('id') id: string) {
+    await this.ensureWebhookAccess(req.user.id);
+    return this.notificationChannelService.sendTest(req.user.id, id);
+  }
+
+  @Get(':id/history')
+  @ApiOperation({ summary: '获取通知渠道的发送历史' })
+  @ApiParam({ name: 'id', description: '通知渠道 ID' })
+  @ApiQuery({ name: 'page', required: false, type: Number })
+  @ApiQuery({ name: 'limit', required: false, type: Number, description: '每页数量，最大 100' })
+  async getHistory(
+    @Req() req: any,
+    @Param('id') id: string,
+    @Query('page', new DefaultValuePipe(1), ParseIntPipe) page: number,
+    @Query('limit', new DefaultValuePipe(20), ParseIntPipe) limit: number,
+  ) {
+    await this.ensureWebhookAccess(req.user.id);
+    return this.notificationChannelService.getHistory(
+      req.user.id,
+      id,
+      Math.max(page, 1),
+      Math.min(Math.max(limit, 1), 100),
+    );
+  }
+
+  @Delete(':id')
+  @HttpCode(HttpStatus.NO_CONTENT)
+  @ApiOperation({ summary: '删除通知渠道' })
+  @ApiParam({ name: 'id', description: '通知渠道 ID' })
+  async remove(@Req() req: any, @Param('id') id: string) {
+    await this.ensureWebhookAccess(req.user.id);
+    await this.notificationChannelService.remove(req.user.id, id);
+  }
+}
@@ -0,0 +1,11 @@
+import { Injectable } from '@nestjs/common';
+import { EntityManager } from '@mikro-orm/core';
+import { DataRepository } from '../../../common/repositories/data.repository';
+import { NotificationChannel } from '../entities/notification-channel.entity';
+
+@Injectable()
+export class NotificationChannelRepository extends DataRepository<NotificationChannel> {
+  constructor(em: EntityManager) {
+    super(em, NotificationChannel, 'userId');
+  }
+}
@@ -0,0 +1,11 @@
+import { Injectable } from '@nestjs/common';
+import { EntityManager } from '@mikro-orm/core';
+import { DataRepository } from '../../../common/repositories/data.repository';
+import { SendRetry } from '../../translation/entities/send-retry.entity';
+
+@Injectable()
+export class SendRetryRepository extends DataRepository<SendRetry> {
+  constructor(em: EntityManager) {
+    super(em, SendRetry);
+  }
+}
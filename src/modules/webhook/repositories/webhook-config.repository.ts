@@ -0,0 +1,11 @@
+import { Injectable } from '@nestjs/common';
+import { EntityManager } from '@mikro-orm/core';
+import { DataRepository } from '../../../common/repositories/data.repository';
+import { WebhookConfig } from '../entities/webhook-config.entity';
+
+@Injectable()
+export class WebhookConfigRepository extends DataRepository<WebhookConfig> {
+  constructor(em: EntityManager) {
+    super(em, WebhookConfig, 'userId');
+  }
+}
@@ -0,0 +1,187 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { getQueueToken } from '@nestjs/bull';
+import { ConfigService } from '@nestjs/config';
+import { HttpService } from '@nestjs/axios';
+import { BadRequestException } from '@nestjs/common';
+import { of, throwError } from 'rxjs';
+import { NOTIFICATION_DELIVERY_JOB, NotificationChannelService } from './notification-channel.service';
+import { NotificationChannelType } from '../entities/notification-channel.entity';
+import { NotificationChannelRepository } from '../repositories/notification-channel.repository';
+import { SendRetryRepository } from '../repositories/send-retry.repository';
+import { SecretBoxService } from '../../../common/services/secret-box.service';
+import { MailService } from '../../../common/services/mail.service';
+import { ErrorReporterService } from '../../../common/services/error-reporter.service';
+import { TenantMailService } from '../../tenant/services/tenant-mail.service';
+
+describe('NotificationChannelService', () => {
+  let service: NotificationChannelService;
+
+  const SLACK_URL = 'https://hooks.slack.com/services/T000/B000/XXXX';
+  const scopedChannelRepository = {
+    count: jest.fn(),
+    insert: jest.fn(async (entity) => entity),
+    getOrFail: jest.fn(),
+  };
+  const mockChannelRepository = {
+    forUser: jest.fn(() => scopedChannelRepository),
+    list: jest.fn(),
+    get: jest.fn(),
+    save: jest.fn(),
+  };
+  const mockSendRetryRepository = { insert: jest.fn(), listAndCount: jest.fn() };
+  const mockWebhookQueue = { add: jest.fn() };
+  const mockHttpService = { post: jest.fn() };
+  const mockMailService = { enabled: true };
+  const mockTenantMailService = { send: jest.fn() };
+  const mockErrorReporter = { captureException: jest.fn() };
+  const secretBox = new SecretBoxService({ get: jest.fn(() => Buffer.alloc(32, 1).toString('base64')) } as any);
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        NotificationChannelService,
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, def?: any) => def) } },
+        { provide: HttpService, useValue: mockHttpService },
+        { provide: getQueueToken('webhook'), useValue: mockWebhookQueue },
+        { provide: NotificationChannelRepository, useValue: mockChannelRepository },
+        { provide: SendRetryRepository, useValue: mockSendRetryRepository },
+        { provide: SecretBoxService, useValue: secretBox },
+        { provide: MailService, useValue: mockMailService },
+        { provide: TenantMailService, useValue: mockTenantMailService },
+        { provide: ErrorReporterService, useValue: mockErrorReporter },
+      ],
+    }).compile();
+
+    service = module.get<NotificationChannelService>(NotificationChannelService);
+    scopedChannelRepository.count.mockResolvedValue(0);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  describe('create', () => {
+    it('Slack 渠道应加密保存 webhook URL', async () => {
+      const channel = await service.create('u1', {
+        type: NotificationChannelType.SLACK,
+        events: ['translation.failed', 'translation.failed'],
+        slackWebhookUrl: SLACK_URL,
+      });
+
+      expect(channel.events).toEqual(['translation.failed']);
+      expect(channel.slackWebhookUrl).not.toBe(SLACK_URL);
+      expect(secretBox.decrypt(channel.slackWebhookUrl)).toBe(SLACK_URL);
+    });
+
+    it('应拒绝非 Slack 地址、没有收件人的邮件渠道和超出数量上限', async () => {
+      await expect(
+        service.create('u1', {
+          type: NotificationChannelType.SLACK,
+          events: ['translation.failed'],
+          slackWebhookUrl: 'https://example.com/hook',
+        }),
+      ).rejects.toThrow(BadRequestException);
+      await expect(
+        service.create('u1', { type: NotificationChannelType.EMAIL, events: ['translation.failed'] }),
+      ).rejects.toThrow('Email channels require recipients');
+
+      scopedChannelRepository.count.mockResolvedValue(10);
+      await expect(
+        service.create('u1', {
+          type: NotificationChannelType.EMAIL,
+          events: ['translation.failed'],
+          recipients: ['ops@example.com'],
+        }),
+      ).rejects.toThrow('At most 10 notification channels are allowed per account');
+      expect(scopedChannelRepository.insert).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('publish', () => {
+    it('应只把事件加入订阅了该事件的渠道，队列中只保存摘要', async () => {
+      mockChannelRepository.list.mockResolvedValue([
+        { id: 'c1', events: ['translation.completed'] },
+        { id: 'c2', events: ['translation.failed'] },
+      ]);
+
+      await service.publish('u1', undefined, 'translation.completed', {
+        taskId: 'task1',
+        fromLang: 'en',
+        toLang: 'ja',
+        charTotal: 42,
+        translatedJson: '{"secret":"value"}',
+      });
+
+      expect(mockChannelRepository.list).toHaveBeenCalledWith({ userId: 'u1', isActive: true, tenantId: null });
+      expect(mockWebhookQueue.add).toHaveBeenCalledTimes(1);
+      const [name, job, options] = mockWebhookQueue.add.mock.calls[0];
+      expect(name).toBe(NOTIFICATION_DELIVERY_JOB);
+      expect(job).toMatchObject({ channelId: 'c1', event: 'translation.completed' });
+      expect(job.message.title).toBe('Translation task1 completed');
+      expect(JSON.stringify(job)).not.toContain('secret');
+      expect(options).toMatchObject({ jobId: `${NOTIFICATION_DELIVERY_JOB}:c1:${job.eventId}`, attempts: 3 });
+    });
+  });
+
+  describe('deliver', () => {
+    const job = {
+      channelId: 'c1',
+      eventId: 'e1',
+      event: 'translation.failed',
+      message: { title: 'Translation task1 failed', lines: ['Reason: provider_error'] },
+    };
+
+    it('邮件发送成功时应记录发送历史', async () => {
+      mockChannelRepository.get.mockResolvedValue({
+        id: 'c1',
+        userId: 'u1',
+        tenantId: 't1',
+        type: NotificationChannelType.EMAIL,
+        recipients: ['ops@example.com'],
+      });
+      mockTenantMailService.send.mockResolvedValue(true);
+
+      await service.deliver(job, 1, 3);
+
+      expect(mockTenantMailService.send).toHaveBeenCalledWith(
+        't1',
+        expect.objectContaining({ to: ['ops@example.com'], subject: 'Translation task1 failed' }),
+      );
+      expect(mockSendRetryRepository.insert).toHaveBeenCalledWith(
+        expect.objectContaining({ webhookId: 'c1', taskId: 'e1', attempt: 1, status: 'success' }),
+      );
+    });
+
+    it('Slack 推送失败时应记录失败并抛出交给队列重试，最后一次失败时上报', async () => {
+      mockChannelRepository.get.mockResolvedValue({
+        id: 'c1',
+        userId: 'u1',
+        type: NotificationChannelType.SLACK,
+        slackWebhookUrl: secretBox.encrypt(SLACK_URL),
+      });
+      mockHttpService.post.mockReturnValue(throwError(() => new Error('connect ECONNREFUSED')));
+
+      await expect(service.deliver(job, 3, 3)).rejects.toThrow('connect ECONNREFUSED');
+
+      expect(mockHttpService.post).toHaveBeenCalledWith(
+        SLACK_URL,
+        { text: '*Translation task1 failed*\nReason: provider_error' },
+        expect.objectContaining({ maxRedirects: 0 }),
+      );
+      expect(mockSendRetryRepository.insert).toHaveBeenCalledWith(
+        expect.objectContaining({ webhookId: 'c1', attempt: 3, status: 'failed' }),
+      );
+      expect(mockErrorReporter.captureException).toHaveBeenCalled();
+    });
+
+    it('渠道已删除时跳过', async () => {
+      mockChannelRepository.get.mockResolvedValue(null);
+      mockHttpService.post.mockReturnValue(of({ status: 200 }));
+
+      await service.deliver(job, 1, 3);
+
+      expect(mockHttpService.post).not.toHaveBeenCalled();
+      expect(mockSendRetryRepository.insert).not.toHaveBeenCalled();
+    });
+  });
+});
@@ -0,0 +1,222 @@
+import { BadRequestException, Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { HttpService } from '@nestjs/axios';
+import { InjectQueue } from '@nestjs/bull';
+import { Queue } from 'bull';
+import { firstValueFrom } from 'rxjs';
+import { v4 as uuidv4 } from 'uuid';
+import { NotificationChannel, NotificationChannelType } from '../entities/notification-channel.entity';
+import { CreateNotificationChannelDto } from '../dto/notification-channel.dto';
+import { NotificationChannelRepository } from '../repositories/notification-channel.repository';
+import { SendRetryRepository } from '../repositories/send-retry.repository';
+import {
+  formatNotification,
+  NOTIFICATION_TEST_EVENT,
+  NotificationMessage,
+  toEmailBody,
+  toSlackMessage,
+} from '../utils/notification-message';
+import { SecretBoxService } from '../../../common/services/secret-box.service';
+import { MailService } from '../../../common/services/mail.service';
+import { ErrorReporterService } from '../../../common/services/error-reporter.service';
+import { TenantMailService } from '../../tenant/services/tenant-mail.service';
+
+export const NOTIFICATION_DELIVERY_JOB = 'deliver-notification';
+
+export interface NotificationDeliveryJob {
+  channelId: string;
+  eventId: string;
+  event: string;
+  /** 入队时生成的摘要，队列中不保存事件的完整载荷（如译文） */
+  message: NotificationMessage;
+}
+
+const SLACK_WEBHOOK_PATTERN = /^https:\/\/hooks\.slack\.com\/services\/[A-Za-z0-9/_-]+$/;
+
+/**
+ * 邮件和 Slack 通知渠道
+ * 没有 webhook 接收端的小团队也能收到完成、失败等提醒；事件发生时按渠道订阅的事件类型入队，
+ * 投递与结果推送共用 webhook 队列的退避重试，每次尝试记录到发送历史（webhookId 为渠道 ID）
+ */
+@Injectable()
+export class NotificationChannelService {
+  private readonly logger = new Logger(NotificationChannelService.name);
+  private readonly maxPerUser: number;
+  private readonly deliveryAttempts: number;
+  private readonly deliveryBackoffMs: number;
+  private readonly deliveryTimeoutMs: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly httpService: HttpService,
+    @InjectQueue('webhook') private readonly webhookQueue: Queue,
+    private readonly channelRepository: NotificationChannelRepository,
+    private readonly sendRetryRepository: SendRetryRepository,
+    private readonly secretBox: SecretBoxService,
+    private readonly mailService: MailService,
+    private readonly tenantMailService: TenantMailService,
+    private readonly errorReporter: ErrorReporterService,
+  ) {
+    this.maxPerUser = Number(this.configService.get('NOTIFICATION_CHANNELS_MAX_PER_USER', 10));
+    this.deliveryAttempts = Math.max(Number(this.configService.get('WEBHOOK_DELIVERY_ATTEMPTS', 3)), 1);
+    this.deliveryBackoffMs = Number(this.configService.get('WEBHOOK_DELIVERY_BACKOFF_MS', 2000));
+    this.deliveryTimeoutMs = Number(this.configService.get('WEBHOOK_DELIVERY_TIMEOUT_MS', 10000));
+  }
+
+  async create(userId: string, dto: CreateNotificationChannelDto, tenantId?: string): Promise<NotificationChannel> {
+    const channels = this.channelRepository.forUser(userId);
+    if ((await channels.count({ isActive: true })) >= this.maxPerUser) {
+      throw new BadRequestException(`At most ${this.maxPerUser} notification channels are allowed per account`);
+    }
+
+    const channel: Partial<NotificationChannel> = {
+      id: uuidv4(),
+      userId,
+      tenantId,
+      type: dto.type,
+      name: dto.name,
+      events: [...new Set(dto.events)],
+    };
+    if (dto.type === NotificationChannelType.EMAIL) {
+      if (!dto.recipients?.length) {
+        throw new BadRequestException('Email channels require recipients');
+      }
+      if (!this.mailService.enabled) {
+        throw new BadRequestException('Email delivery is not configured');
+      }
+      channel.recipients = [...new Set(dto.recipients.map((recipient) => recipient.toLowerCase()))];
+    } else {
+      if (!dto.slackWebhookUrl || !SLACK_WEBHOOK_PATTERN.test(dto.slackWebhookUrl)) {
+        throw new BadRequestException('Slack channels require a https://hooks.slack.com/services/ webhook URL');
+      }
+      channel.slackWebhookUrl = this.secretBox.encrypt(dto.slackWebhookUrl);
+    }
+    return channels.insert(channel as NotificationChannel);
+  }
+
+  async list(userId: string): Promise<NotificationChannel[]> {
+    return this.channelRepository.forUser(userId).list({ isActive: true }, { orderBy: { createdAt: 'ASC' } });
+  }
+
+  async remove(userId: string, id: string): Promise<void> {
+    const channel = await this.getOwned(userId, id);
+    channel.isActive = false;
+    await this.channelRepository.save(channel);
+  }
+
+  /**
+   * 向渠道发送一条测试通知（同样经过队列，结果见发送历史）
+   */
+  async sendTest(userId: string, id: string): Promise<{ eventId: string }> {
+    const channel = await this.getOwned(userId, id);
+    const eventId = uuidv4();
+    await this.enqueue(channel, eventId, NOTIFICATION_TEST_EVENT, { events: channel.events });
+    return { eventId };
+  }
+
+  async getHistory(userId: string, id: string, page = 1, limit = 20) {
+    const channel = await this.getOwned(userId, id);
+    const [history, total] = await this.sendRetryRepository.listAndCount(
+      { webhookId: channel.id },
+      { limit, offset: (page - 1) * limit, orderBy: { createdAt: 'DESC', id: 'DESC' } },
+    );
+    return { history, total, page, limit };
+  }
+
+  /**
+   * 把事件加入订阅了该事件的渠道的投递队列；租户的事件同时发给账户级渠道
+   */
+  async publish(userId: string, tenantId: string | undefined, event: string, data: Record<string, any>) {
+    const channels = await this.channelRepository.list({
+      userId,
+      isActive: true,
+      ...(tenantId ? { $or: [{ tenantId }, { tenantId: null }] } : { tenantId: null }),
+    });
+    const subscribed = channels.filter((channel) => channel.events.includes(event));
+    if (subscribed.length === 0) {
+      return;
+    }
+    const eventId = uuidv4();
+    for (const channel of subscribed) {
+      await this.enqueue(channel, eventId, event, data);
+    }
+  }
+
+  /**
+   * 执行一次投递（由 webhook 队列消费者调用），失败时抛出异常交给队列重试
+   */
+  async deliver(job: NotificationDeliveryJob, attempt: number, maxAttempts: number): Promise<void> {
+    const channel = await this.channelRepository.get({ id: job.channelId, isActive: true });
+    if (!channel) {
+      return;
+    }
+
+    const record = { event: job.event, type: channel.type, title: job.message.title };
+    try {
+      await this.send(channel, job.message);
+      await this.recordDelivery(channel.id, job.eventId, 'success', attempt, record);
+    } catch (error) {
+      await this.recordDelivery(channel.id, job.eventId, 'failed', attempt, record);
+      this.logger.error(`Notification ${job.event} to ${channel.type} channel ${channel.id} failed: ${error.message}`);
+      if (attempt >= maxAttempts) {
+        this.errorReporter.captureException(new Error(`Notification delivery failed after ${maxAttempts} attempts`), {
+          source: 'webhook',
+          userId: channel.userId,
+          tenantId: channel.tenantId,
+          tags: { event: job.event, channel: channel.type },
+          extra: { channelId: channel.id, eventId: job.eventId },
+        });
+      }
+      throw error;
+    }
+  }
+
+  private async send(channel: NotificationChannel, message: NotificationMessage): Promise<void> {
+    if (channel.type === NotificationChannelType.SLACK) {
+      await firstValueFrom(
+        this.httpService.post(this.secretBox.decrypt(channel.slackWebhookUrl), toSlackMessage(message), {
+          timeout: this.deliveryTimeoutMs,
+          maxRedirects: 0,
+        }),
+      );
+      return;
+    }
+
+    const sent = await this.tenantMailService.send(channel.tenantId, {
+      to: channel.recipients,
+      subject: message.title,
+      ...toEmailBody(message),
+    });
+    if (!sent) {
+      throw new Error('Email could not be sent');
+    }
+  }
+
+  private async enqueue(channel: NotificationChannel, eventId: string, event: string, data: Record<string, any>) {
+    const message = formatNotification(event, data);
+    const job: NotificationDeliveryJob = { channelId: channel.id, eventId, event, message };
+    await this.webhookQueue.add(NOTIFICATION_DELIVERY_JOB, job, {
+      jobId: `${NOTIFICATION_DELIVERY_JOB}:${channel.id}:${eventId}`,
+      attempts: this.deliveryAttempts,
+      backoff: { type: 'exponential', delay: this.deliveryBackoffMs },
+      removeOnComplete: true,
+    });
+  }
+
+  private async recordDelivery(channelId: string, eventId: string, status: string, attempt: number, payload: any) {
+    await this.sendRetryRepository.insert({
+      id: uuidv4(),
+      webhookId: channelId,
+      taskId: eventId,
+      attempt,
+      status,
+      payload: JSON.stringify(payload),
+    });
+  }
+
+  private async getOwned(userId: string, id: string): Promise<NotificationChannel> {
+    return this.channelRepository
+      .forUser(userId)
+      .getOrFail({ id, isActive: true }, 'Notification channel not found');
+  }
+}
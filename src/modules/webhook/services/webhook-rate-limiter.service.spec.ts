@@ -0,0 +1,68 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { ConfigService } from '@nestjs/config';
+import { WebhookRateLimiterService } from './webhook-rate-limiter.service';
+import { RedisService } from '../../../common/services/redis.service';
+
+describe('WebhookRateLimiterService', () => {
+  let service: WebhookRateLimiterService;
+
+  const mockRedisService = {
+    client: { eval: jest.fn(), decr: jest.fn() },
+    increment: jest.fn(),
+  };
+
+  const config = (limits: Record<string, number> = {}): any => ({
+    id: 'hook1',
+    userId: 'u1',
+    webhookUrl: 'https://example.com',
+    ...limits,
+  });
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        WebhookRateLimiterService,
+        { provide: RedisService, useValue: mockRedisService },
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, def?: any) => def) } },
+      ],
+    }).compile();
+
+    service = module.get<WebhookRateLimiterService>(WebhookRateLimiterService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  it('未配置限制时直接放行', async () => {
+    const slot = await service.acquire(config());
+
+    expect(slot.acquired).toBe(true);
+    expect(mockRedisService.client.eval).not.toHaveBeenCalled();
+    expect(mockRedisService.increment).not.toHaveBeenCalled();
+  });
+
+  it('并发已满时拒绝，释放时归还名额', async () => {
+    mockRedisService.client.eval.mockResolvedValueOnce(0).mockResolvedValueOnce(1);
+
+    const rejected = await service.acquire(config({ maxConcurrency: 2 }));
+    expect(rejected).toMatchObject({ acquired: false, retryAfterMs: 1000 });
+
+    const slot = await service.acquire(config({ maxConcurrency: 2 }));
+    expect(slot.acquired).toBe(true);
+    await slot.release();
+    expect(mockRedisService.client.decr).toHaveBeenCalledWith('webhook_inflight:hook1');
+  });
+
+  it('超出每分钟次数时延后到下一个窗口并归还并发名额', async () => {
+    mockRedisService.client.eval.mockResolvedValueOnce(1);
+    mockRedisService.increment.mockResolvedValueOnce(31);
+
+    const slot = await service.acquire(config({ maxConcurrency: 2, maxPerMinute: 30 }));
+
+    expect(slot.acquired).toBe(false);
+    expect(slot.retryAfterMs).toBeGreaterThan(0);
+    expect(slot.retryAfterMs).toBeLessThanOrEqual(60000);
+    expect(mockRedisService.client.decr).toHaveBeenCalledWith('webhook_inflight:hook1');
+  });
+});
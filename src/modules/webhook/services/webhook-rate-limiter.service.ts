@@ -0,0 +1,92 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { ConfigService } from '@nestjs/config';
+import { WebhookConfig } from '../entities/webhook-config.entity';
+import { RedisService } from '../../../common/services/redis.service';
+
+/**
+ * 进行中的推送数未达上限时加一并刷新过期时间，返回是否成功
+ * 过期时间保证 worker 崩溃没有释放名额时计数能自动恢复
+ */
+const ACQUIRE_SLOT_SCRIPT = `
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if current >= tonumber(ARGV[1]) then
+  return 0
+end
+redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`;
+
+export interface WebhookDeliverySlot {
+  acquired: boolean;
+  /** 未取得时建议的延迟（毫秒） */
+  retryAfterMs?: number;
+  release(): Promise<void>;
+}
+
+const noop = async () => undefined;
+
+/**
+ * 按 webhook 配置限制推送的并发数和每分钟次数
+ * 计数存在 Redis 中，所有推送 worker 共享；Redis 不可用时不限制
+ */
+@Injectable()
+export class WebhookRateLimiterService {
+  private readonly logger = new Logger(WebhookRateLimiterService.name);
+  private readonly retryDelayMs: number;
+  private readonly slotTtlMs: number;
+
+  constructor(
+    private readonly configService: ConfigService,
+    private readonly redisService: RedisService,
+  ) {
+    this.retryDelayMs = Number(this.configService.get('WEBHOOK_THROTTLE_RETRY_MS', 1000));
+    this.slotTtlMs = Number(this.configService.get('WEBHOOK_DELIVERY_TIMEOUT_MS', 10000)) * 2;
+  }
+
+  async acquire(config: WebhookConfig): Promise<WebhookDeliverySlot> {
+    const release = config.maxConcurrency ? await this.acquireSlot(config) : noop;
+    if (!release) {
+      return { acquired: false, retryAfterMs: this.retryDelayMs, release: noop };
+    }
+
+    if (config.maxPerMinute) {
+      const now = Date.now();
+      const window = Math.floor(now / 60000);
+      const sent = await this.redisService.increment(`webhook_rate:${config.id}:${window}`, 1, 60);
+      if (sent !== null && sent > config.maxPerMinute) {
+        await release();
+        return { acquired: false, retryAfterMs: (window + 1) * 60000 - now, release: noop };
+      }
+    }
+
+    return { acquired: true, release };
+  }
+
+  private async acquireSlot(config: WebhookConfig): Promise<(() => Promise<void>) | null> {
+    const key = `webhook_inflight:${config.id}`;
+    try {
+      const acquired = await this.redisService.client.eval(
+        ACQUIRE_SLOT_SCRIPT,
+        1,
+        key,
+        config.maxConcurrency,
+        this.slotTtlMs,
+      );
+      if (Number(acquired) !== 1) {
+        return null;
+      }
+    } catch (error) {
+      this.logger.error(`Webhook concurrency limiter unavailable: ${error.message}`);
+      return noop;
+    }
+
+    return async () => {
+      try {
+        await this.redisService.client.decr(key);
+      } catch (error) {
+        this.logger.error(`Failed to release webhook delivery slot ${key}: ${error.message}`);
+      }
+    };
+  }
+}
@@ -0,0 +1,135 @@
+import { BadRequestException, Injectable } from '@nestjs/common';
+import { Agent } from 'https';
+import { createPrivateKey, KeyObject, X509Certificate } from 'crypto';
+import { WebhookConfig } from '../entities/webhook-config.entity';
+import { WebhookTlsDto } from '../dto/webhook-tls.dto';
+import { SecretBoxService } from '../../../common/services/secret-box.service';
+
+const CERTIFICATE_PATTERN = /-----BEGIN CERTIFICATE-----[\s\S]+?-----END CERTIFICATE-----/g;
+
+export interface WebhookCertificateInfo {
+  subject: string;
+  issuer: string;
+  validTo: string;
+  fingerprint256: string;
+}
+
+/** 保存在配置上、可以展示的证书信息 */
+export interface WebhookTlsInfo {
+  ca?: WebhookCertificateInfo[];
+  clientCert?: WebhookCertificateInfo;
+}
+
+export interface WebhookTlsSettings {
+  ca?: string;
+  clientCert?: string;
+  /** SecretBoxService 加密后的 { key, passphrase } */
+  clientKey?: string;
+  info?: WebhookTlsInfo;
+}
+
+/**
+ * webhook 推送的双向 TLS 和自定义 CA
+ * 企业用户的内网接收端可以只信任自己的 CA、要求客户端证书，代替共享密钥请求头；
+ * 私钥加密保存，推送时按配置创建 https.Agent 并缓存，配置更新后重建
+ */
+@Injectable()
+export class WebhookTlsService {
+  private readonly agents = new Map<string, { version: number; agent: Agent }>();
+
+  constructor(private readonly secretBox: SecretBoxService) {}
+
+  /**
+   * 校验证书和私钥（PEM 格式、有效期、私钥与证书匹配）并生成要保存的字段；都不传时返回空设置
+   */
+  prepare(dto: WebhookTlsDto): WebhookTlsSettings {
+    if (!!dto.clientCert !== !!dto.clientKey) {
+      throw new BadRequestException('clientCert and clientKey must be set together');
+    }
+    const settings: WebhookTlsSettings = {};
+    const info: WebhookTlsInfo = {};
+
+    if (dto.ca) {
+      info.ca = this.parseCertificates(dto.ca, 'ca').map((certificate) => this.describe(certificate));
+      settings.ca = dto.ca;
+    }
+    if (dto.clientCert) {
+      const [certificate] = this.parseCertificates(dto.clientCert, 'clientCert');
+      if (!certificate.checkPrivateKey(this.parsePrivateKey(dto.clientKey, dto.passphrase))) {
+        throw new BadRequestException('clientKey does not match clientCert');
+      }
+      info.clientCert = this.describe(certificate);
+      settings.clientCert = dto.clientCert;
+      settings.clientKey = this.secretBox.encryptJson({ key: dto.clientKey, passphrase: dto.passphrase });
+    }
+    if (info.ca || info.clientCert) {
+      settings.info = info;
+    }
+    return settings;
+  }
+
+  /**
+   * 推送使用的 https.Agent，没有 TLS 设置时返回 undefined（使用默认 Agent）
+   */
+  agentFor(config: WebhookConfig): Agent | undefined {
+    if (!config.tlsCa && !config.tlsClientCert) {
+      this.agents.delete(config.id);
+      return undefined;
+    }
+    const version = config.updatedAt?.getTime() ?? 0;
+    const cached = this.agents.get(config.id);
+    if (cached?.version === version) {
+      return cached.agent;
+    }
+
+    const clientKey = config.tlsClientKey
+      ? this.secretBox.decryptJson<{ key: string; passphrase?: string }>(config.tlsClientKey)
+      : undefined;
+    const agent = new Agent({
+      keepAlive: true,
+      ca: config.tlsCa || undefined,
+      cert: config.tlsClientCert || undefined,
+      key: clientKey?.key,
+      passphrase: clientKey?.passphrase,
+    });
+    cached?.agent.destroy();
+    this.agents.set(config.id, { version, agent });
+    return agent;
+  }
+
+  private parseCertificates(pem: string, field: string): X509Certificate[] {
+    const blocks = pem.match(CERTIFICATE_PATTERN) ?? [];
+    if (blocks.length === 0) {
+      throw new BadRequestException(`${field} is not a valid PEM certificate`);
+    }
+    return blocks.map((block) => {
+      let certificate: X509Certificate;
+      try {
+        certificate = new X509Certificate(block);
+      } catch {
+        throw new BadRequestException(`${field} is not a valid PEM certificate`);
+      }
+      if (new Date(certificate.validTo).getTime() < Date.now()) {
+        throw new BadRequestException(`${field} contains an expired certificate`);
+      }
+      return certificate;
+    });
+  }
+
+  private parsePrivateKey(pem: string, passphrase?: string): KeyObject {
+    try {
+      return createPrivateKey({ key: pem, passphrase });
+    } catch {
+      throw new BadRequestException('clientKey is not a valid PEM private key or the passphrase is wrong');
+    }
+  }
+
+  private describe(certificate: X509Certificate): WebhookCertificateInfo {
+    return {
+      subject: certificate.subject,
+      issuer: certificate.issuer,
+      validTo: new Date(certificate.validTo).toISOString(),
+      fingerprint256: certificate.fingerprint256,
+    };
+  }
+}
@@ -0,0 +1,107 @@
+/** 通知渠道可以订阅的事件 */
+export const NOTIFICATION_EVENTS = [
+  'translation.completed',
+  'translation.failed',
+  'quota.warning',
+  'source_sync.updated',
+  'usage.report',
+  'api_key.expiring',
+] as const;
+
+/** 发送测试通知时使用的事件，不需要订阅 */
+export const NOTIFICATION_TEST_EVENT = 'notification.test';
+
+export interface NotificationMessage {
+  title: string;
+  lines: string[];
+  link?: string;
+}
+
+/**
+ * 把事件载荷转换成给人看的通知（邮件、Slack 共用），只保留摘要，不包含译文内容
+ */
+export function formatNotification(event: string, data: Record<string, any>): NotificationMessage {
+  switch (event) {
+    case 'translation.completed':
+      return {
+        title: `Translation ${data.taskId} ${data.partial ? 'partially completed' : 'completed'}`,
+        lines: [
+          `${data.fromLang} → ${data.toLang}, ${data.charTotal} characters`,
+          ...(data.partial ? [`${data.untranslatedKeys?.length ?? 0} key(s) could not be translated`] : []),
+        ],
+        link: data.links?.result,
+      };
+    case 'translation.failed':
+      return {
+        title: `Translation ${data.taskId} failed`,
+        lines: [`Reason: ${data.reason}`, data.message, data.retry?.guidance].filter(Boolean),
+      };
+    case 'quota.warning':
+      return {
+        title: `Monthly character quota at ${data.percent}%`,
+        lines: [`${data.used} of ${data.limit} characters used in ${data.period}`],
+      };
+    case 'source_sync.updated':
+      return {
+        title: `Source ${data.name ?? data.syncId} updated`,
+        lines: [
+          `${data.changedKeys?.length ?? 0} changed and ${data.removedKeys?.length ?? 0} removed key(s)`,
+          `Languages: ${Object.keys(data.translations ?? {}).join(', ')}`,
+        ],
+      };
+    case 'usage.report':
+      return {
+        title: `Usage report for ${data.period?.label}`,
+        lines: [
+          `${data.totalCharacters} characters across ${data.documents} document(s) ` +
+            `from ${data.period?.from} to ${data.period?.to}`,
+          ...(data.topDocuments ?? []).map(
+            (document: any) => `${document.name ?? document.id}: ${document.characters} characters`,
+          ),
+          data.forecast?.limit
+            ? `Projected ${data.forecast.projected} of ${data.forecast.limit} characters ` +
+              `(${data.forecast.projectedPercent}%) in ${data.forecast.month}` +
+              (data.forecast.exhaustsOn ? `; quota runs out around ${data.forecast.exhaustsOn}` : '')
+            : `Projected ${data.forecast?.projected} characters in ${data.forecast?.month}`,
+        ],
+      };
+    case 'api_key.expiring':
+      return {
+        title: `API key ${data.name} expires in ${data.daysLeft} day(s)`,
+        lines: [
+          `The key stops working at ${data.expiresAt}.`,
+          'Create a replacement or extend its expiry before then to avoid failed requests.',
+        ],
+      };
+    case NOTIFICATION_TEST_EVENT:
+      return {
+        title: 'Test notification',
+        lines: [`This channel receives: ${(data.events ?? []).join(', ')}`],
+      };
+    default:
+      return { title: event, lines: [] };
+  }
+}
+
+/**
+ * Slack incoming webhook 的消息体（mrkdwn，转义 Slack 的控制字符）
+ */
+export function toSlackMessage(message: NotificationMessage): { text: string } {
+  const escape = (value: string) => value.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;');
+  const lines = [`*${escape(message.title)}*`, ...message.lines.map(escape)];
+  if (message.link) {
+    lines.push(`<${message.link}|View result>`);
+  }
+  return { text: lines.join('\n') };
+}
+
+export function toEmailBody(message: NotificationMessage): { text: string; html: string } {
+  const escape = (value: string) =>
+    value.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/"/g, '&quot;');
+  const lines = message.link ? [...message.lines, `View result: ${message.link}`] : message.lines;
+  const paragraphs = message.lines.map((line) => `<p>${escape(line)}</p>`);
+  if (message.link) {
+    paragraphs.push(`<p><a href="${escape(message.link)}">View result</a></p>`);
+  }
+  return { text: lines.join('\n\n'), html: paragraphs.join('') };
+}
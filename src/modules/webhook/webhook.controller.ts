@@ -1,18 +1,46 @@
-import { Controller, Post, Get, Delete, Patch, Body, UseGuards, Req, Param, Query } from '@nestjs/common';
+import {
+  Controller,
+  Post,
+  Get,
+  Delete,
+  Patch,
+  Put,
+  Body,
+  UseGuards,
+  Req,
+  Param,
+  Query,
+  DefaultValuePipe,
+  ParseIntPipe,
+  ParseEnumPipe,
+} from '@nestjs/common';
 import { ApiTags, ApiOperation, ApiResponse, ApiParam, ApiQuery } from '@nestjs/swagger';
-import { WebhookService } from './webhook.service';
+import { WebhookService, WEBHOOK_DELIVERY_STATUSES, WebhookDeliveryStatus } from './webhook.service';
 import { JwtAuthGuard } from '../auth/guards/jwt-auth.guard';
-import { SubscriptionService } from '../subscription/subscription.service';
+import { PlanLimitsService } from '../subscription/services/plan-limits.service';
+import { TenantService } from '../tenant/services/tenant.service';
 import { ForbiddenException } from '@nestjs/common';
+import { WebhookDeliveryLimitsDto } from './dto/webhook-delivery-limits.dto';
+import { WebhookPayloadFormatDto } from './dto/webhook-payload-format.dto';
+import { WebhookAuthDto } from './dto/webhook-auth.dto';
+import { WebhookTlsDto } from './dto/webhook-tls.dto';
 
 @ApiTags('webhook')
 @Controller('webhook')
 export class WebhookController {
   constructor(
     private readonly webhookService: WebhookService,
-    private readonly subscriptionService: SubscriptionService,
+    private readonly planLimitsService: PlanLimitsService,
+    private readonly tenantService: TenantService,
   ) {}
 
+  private async ensureWebhookAccess(userId: string): Promise<void> {
+    const allowed = await this.planLimitsService.hasFeature(userId, 'webhooks');
+    if (!allowed) {
+      throw new ForbiddenException('Webhook functionality is not available for free users');
+    }
+  }
+
   @Post('config')
   @UseGuards(JwtAuthGuard)
   @ApiOperation({ summary: '创建 webhook 配置' })
@@ -22,11 +50,9 @@ export class WebhookController {
     @Req() req: any,
     @Body('webhookUrl') webhookUrl: string,
   ) {
-    const subscription = await this.subscriptionService.getCurrentPlan(req.user.id);
-    if (subscription.tier === 'free') {
-      throw new ForbiddenException('Webhook functionality is not available for free users');
-    }
-    return this.webhookService.createWebhookConfig(req.user.id, webhookUrl);
+    await this.ensureWebhookAccess(req.user.id);
+    const tenant = await this.tenantService.resolveFromRequest(req);
+    return this.webhookService.createWebhookConfig(req.user.id, webhookUrl, tenant?.id);
   }
 
   @Get('config')
@@ -34,11 +60,9 @@ export class WebhookController {
   @ApiOperation({ summary: '获取 webhook 配置' })
   @ApiResponse({ status: 200, description: '返回用户的 webhook 配置' })
   async getWebhookConfig(@Req() req: any) {
-    const subscription = await this.subscriptionService.getCurrentPlan(req.user.id);
-    if (subscription.tier === 'free') {
-      throw new ForbiddenException('Webhook functionality is not available for free users');
-    }
-    return this.webhookService.getWebhookConfig(req.user.id);
+    await this.ensureWebhookAccess(req.user.id);
+    const tenant = await this.tenantService.resolveFromRequest(req);
+    return this.webhookService.getWebhookConfig(req.user.id, tenant?.id);
   }
 
   @Patch('config/:id')
@@ -52,13 +76,72 @@ export class WebhookController {
     @Param('id') id: string,
     @Body('webhookUrl') webhookUrl: string,
   ) {
-    const subscription = await this.subscriptionService.getCurrentPlan(req.user.id);
-    if (subscription.tier === 'free') {
-      throw new ForbiddenException('Webhook functionality is not available for free users');
-    }
+    await this.ensureWebhookAccess(req.user.id);
     return this.webhookService.updateWebhookConfig(req.user.id, id, webhookUrl);
   }
 
+  @Put('config/:id/limits')
+  @UseGuards(JwtAuthGuard)
+  @ApiOperation({ summary: '设置 webhook 推送的并发数和每分钟次数上限' })
+  @ApiParam({ name: 'id', description: 'Webhook 配置 ID' })
+  @ApiResponse({ status: 200, description: '返回更新后的 webhook 配置' })
+  @ApiResponse({ status: 403, description: '免费用户无法使用 webhook 功能' })
+  async updateDeliveryLimits(
+    @Req() req: any,
+    @Param('id') id: string,
+    @Body() dto: WebhookDeliveryLimitsDto,
+  ) {
+    await this.ensureWebhookAccess(req.user.id);
+    return this.webhookService.updateDeliveryLimits(req.user.id, id, dto);
+  }
+
+  @Put('config/:id/payload-format')
+  @UseGuards(JwtAuthGuard)
+  @ApiOperation({ summary: '设置翻译结果推送的载荷格式（事件信封或旧格式），以及是否附带与上一次推送的差异' })
+  @ApiParam({ name: 'id', description: 'Webhook 配置 ID' })
+  @ApiResponse({ status: 200, description: '返回更新后的 webhook 配置' })
+  @ApiResponse({ status: 403, description: '免费用户无法使用 webhook 功能' })
+  async updatePayloadFormat(
+    @Req() req: any,
+    @Param('id') id: string,
+    @Body() dto: WebhookPayloadFormatDto,
+  ) {
+    await this.ensureWebhookAccess(req.user.id);
+    return this.webhookService.updatePayloadFormat(req.user.id, id, dto.payloadFormat, dto.includeDiff);
+  }
+
+  @Put('config/:id/auth')
+  @UseGuards(JwtAuthGuard)
+  @ApiOperation({ summary: '设置推送时附带的请求头或 Basic 认证（加密保存，不会在接口中返回取值）' })
+  @ApiParam({ name: 'id', description: 'Webhook 配置 ID' })
+  @ApiResponse({ status: 200, description: '返回更新后的 webhook 配置，authHeaderNames 为已配置的请求头名称' })
+  @ApiResponse({ status: 400, description: '请求头名称或取值无效' })
+  @ApiResponse({ status: 403, description: '免费用户无法使用 webhook 功能' })
+  async updateAuth(
+    @Req() req: any,
+    @Param('id') id: string,
+    @Body() dto: WebhookAuthDto,
+  ) {
+    await this.ensureWebhookAccess(req.user.id);
+    return this.webhookService.updateAuth(req.user.id, id, dto);
+  }
+
+  @Put('config/:id/tls')
+  @UseGuards(JwtAuthGuard)
+  @ApiOperation({ summary: '设置推送使用的自定义 CA 和客户端证书（双向 TLS），私钥加密保存' })
+  @ApiParam({ name: 'id', description: 'Webhook 配置 ID' })
+  @ApiResponse({ status: 200, description: '返回更新后的 webhook 配置，tlsInfo 为证书的主题、到期时间和指纹' })
+  @ApiResponse({ status: 400, description: '证书或私钥无效、已过期、不匹配，或 webhook 地址不是 https' })
+  @ApiResponse({ status: 403, description: '当前计划不支持自定义 TLS 设置' })
+  async updateTls(
+    @Req() req: any,
+    @Param('id') id: string,
+    @Body() dto: WebhookTlsDto,
+  ) {
+    await this.ensureWebhookAccess(req.user.id);
+    return this.webhookService.updateTls(req.user.id, id, dto);
+  }
+
   @Delete('config/:id')
   @UseGuards(JwtAuthGuard)
   @ApiOperation({ summary: '删除 webhook 配置' })
@@ -69,10 +152,7 @@ export class WebhookController {
     @Req() req: any,
     @Param('id') id: string,
   ) {
-    const subscription = await this.subscriptionService.getCurrentPlan(req.user.id);
-    if (subscription.tier === 'free') {
-      throw new ForbiddenException('Webhook functionality is not available for free users');
-    }
+    await this.ensureWebhookAccess(req.user.id);
     return this.webhookService.deleteWebhookConfig(req.user.id, id);
   }
 
@@ -80,28 +160,35 @@ export class WebhookController {
   @UseGuards(JwtAuthGuard)
   @ApiOperation({ summary: '获取 webhook 历史记录' })
   @ApiQuery({ name: 'page', required: false, description: '页码' })
-  @ApiQuery({ name: 'limit', required: false, description: '每页数量' })
+  @ApiQuery({ name: 'limit', required: false, description: '每页数量（最大 100）' })
   @ApiQuery({ name: 'create_time_min', required: false, description: '开始时间' })
   @ApiQuery({ name: 'create_time_max', required: false, description: '结束时间' })
-  @ApiResponse({ status: 200, description: '返回 webhook 历史记录' })
+  @ApiQuery({ name: 'status', required: false, enum: WEBHOOK_DELIVERY_STATUSES, description: '投递结果' })
+  @ApiQuery({ name: 'webhook_id', required: false, description: 'Webhook 配置 ID，默认为当前配置' })
+  @ApiResponse({ status: 200, description: '返回 webhook 历史记录，按时间倒序' })
   @ApiResponse({ status: 403, description: '免费用户无法使用 webhook 功能' })
   async getWebhookHistory(
     @Req() req: any,
-    @Query('page') page?: number,
-    @Query('limit') limit?: number,
+    @Query('page', new DefaultValuePipe(1), ParseIntPipe) page?: number,
+    @Query('limit', new DefaultValuePipe(20), ParseIntPipe) limit?: number,
     @Query('create_time_min') createTimeMin?: string,
     @Query('create_time_max') createTimeMax?: string,
+    @Query('status', new ParseEnumPipe(WEBHOOK_DELIVERY_STATUSES, { optional: true })) status?: WebhookDeliveryStatus,
+    @Query('webhook_id') webhookId?: string,
   ) {
-    const subscription = await this.subscriptionService.getCurrentPlan(req.user.id);
-    if (subscription.tier === 'free') {
-      throw new ForbiddenException('Webhook functionality is not available for free users');
-    }
+    await this.ensureWebhookAccess(req.user.id);
+    const tenant = await this.tenantService.resolveFromRequest(req);
     return this.webhookService.getWebhookHistory(
       req.user.id,
-      page,
-      limit,
-      createTimeMin,
-      createTimeMax,
+      {
+        page: Math.max(page, 1),
+        limit: Math.min(Math.max(limit, 1), 100),
+        createTimeMin,
+        createTimeMax,
+        status,
+        webhookId,
+      },
+      tenant?.id,
     );
   }
 
@@ -115,10 +202,7 @@ export class WebhookController {
     @Req() req: any,
     @Param('id') id: string,
   ) {
-    const subscription = await this.subscriptionService.getCurrentPlan(req.user.id);
-    if (subscription.tier === 'free') {
-      throw new ForbiddenException('Webhook functionality is not available for free users');
-    }
+    await this.ensureWebhookAccess(req.user.id);
     return this.webhookService.getWebhookDetails(req.user.id, id);
   }
 
@@ -132,10 +216,7 @@ export class WebhookController {
     @Req() req: any,
     @Param('id') id: string,
   ) {
-    const subscription = await this.subscriptionService.getCurrentPlan(req.user.id);
-    if (subscription.tier === 'free') {
-      throw new ForbiddenException('Webhook functionality is not available for free users');
-    }
+    await this.ensureWebhookAccess(req.user.id);
     return this.webhookService.getWebhookStatus(req.user.id, id);
   }
 } 
\ No newline at end of file
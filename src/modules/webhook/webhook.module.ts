@@ -1,12 +1,43 @@
 import { Module } from '@nestjs/common';
+import { BullModule } from '@nestjs/bull';
+import { HttpModule } from '@nestjs/axios';
 import { WebhookController } from './webhook.controller';
+import { NotificationChannelController } from './notification-channel.controller';
 import { WebhookService } from './webhook.service';
+import { WebhookConfigRepository } from './repositories/webhook-config.repository';
+import { SendRetryRepository } from './repositories/send-retry.repository';
+import { WebhookRateLimiterService } from './services/webhook-rate-limiter.service';
+import { WebhookTlsService } from './services/webhook-tls.service';
+import { NotificationChannelService } from './services/notification-channel.service';
+import { NotificationChannelRepository } from './repositories/notification-channel.repository';
 import { SubscriptionModule } from '../subscription/subscription.module';
+import { TenantModule } from '../tenant/tenant.module';
+import { CommonModule } from '../../common/common.module';
 
 @Module({
-  imports: [SubscriptionModule],
-  controllers: [WebhookController],
-  providers: [WebhookService],
-  exports: [WebhookService],
+  imports: [
+    BullModule.registerQueue({ name: 'webhook' }),
+    HttpModule,
+    SubscriptionModule,
+    TenantModule,
+    CommonModule,
+  ],
+  controllers: [WebhookController, NotificationChannelController],
+  providers: [
+    WebhookService,
+    WebhookRateLimiterService,
+    WebhookTlsService,
+    NotificationChannelService,
+    WebhookConfigRepository,
+    NotificationChannelRepository,
+    SendRetryRepository,
+  ],
+  exports: [
+    WebhookService,
+    WebhookRateLimiterService,
+    NotificationChannelService,
+    WebhookConfigRepository,
+    SendRetryRepository,
+  ],
 })
-export class WebhookModule {} 
\ No newline at end of file
+export class WebhookModule {}
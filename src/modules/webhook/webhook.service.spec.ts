@@ -0,0 +1,200 @@
+import { Test, TestingModule } from '@nestjs/testing';
+import { getQueueToken } from '@nestjs/bull';
+import { ConfigService } from '@nestjs/config';
+import { HttpService } from '@nestjs/axios';
+import { BadRequestException, ForbiddenException, NotFoundException } from '@nestjs/common';
+import { WebhookService } from './webhook.service';
+import { WebhookConfigRepository } from './repositories/webhook-config.repository';
+import { SendRetryRepository } from './repositories/send-retry.repository';
+import { PlanLimitsService } from '../subscription/services/plan-limits.service';
+import { ErrorReporterService } from '../../common/services/error-reporter.service';
+import { SecretBoxService } from '../../common/services/secret-box.service';
+import { WebhookTlsService } from './services/webhook-tls.service';
+import { NotificationChannelService } from './services/notification-channel.service';
+
+describe('WebhookService', () => {
+  let service: WebhookService;
+
+  const scopedConfigRepository = { get: jest.fn(), getOrFail: jest.fn() };
+  const mockWebhookConfigRepository = {
+    forUser: jest.fn(() => scopedConfigRepository),
+    update: jest.fn((entity, changes) => Object.assign(entity, changes)),
+  };
+  const secretBox = new SecretBoxService({ get: jest.fn(() => Buffer.alloc(32, 1).toString('base64')) } as any);
+  const mockSendRetryRepository = { listAndCount: jest.fn(), list: jest.fn(), insert: jest.fn() };
+  const mockPlanLimitsService = { hasFeature: jest.fn() };
+  const mockWebhookTlsService = { prepare: jest.fn(), agentFor: jest.fn() };
+
+  beforeEach(async () => {
+    const module: TestingModule = await Test.createTestingModule({
+      providers: [
+        WebhookService,
+        { provide: getQueueToken('webhook'), useValue: { add: jest.fn() } },
+        { provide: WebhookConfigRepository, useValue: mockWebhookConfigRepository },
+        { provide: SendRetryRepository, useValue: mockSendRetryRepository },
+        { provide: PlanLimitsService, useValue: mockPlanLimitsService },
+        { provide: ConfigService, useValue: { get: jest.fn((key: string, def?: any) => def) } },
+        { provide: HttpService, useValue: { post: jest.fn() } },
+        { provide: ErrorReporterService, useValue: { captureException: jest.fn() } },
+        { provide: SecretBoxService, useValue: secretBox },
+        { provide: WebhookTlsService, useValue: mockWebhookTlsService },
+        { provide: NotificationChannelService, useValue: { publish: jest.fn().mockResolvedValue(undefined) } },
+      ],
+    }).compile();
+
+    service = module.get<WebhookService>(WebhookService);
+  });
+
+  afterEach(() => {
+    jest.clearAllMocks();
+  });
+
+  describe('getWebhookHistory', () => {
+    it('应把过滤、分页和稳定排序交给数据库查询', async () => {
+      scopedConfigRepository.getOrFail.mockResolvedValue({ id: 'hook2', userId: 'u1' });
+      mockSendRetryRepository.listAndCount.mockResolvedValue([[{ id: 'r1' }], 41]);
+
+      const result = await service.getWebhookHistory('u1', {
+        page: 3,
+        limit: 20,
+        status: 'failed',
+        webhookId: 'hook2',
+        createTimeMin: '2026-10-01T00:00:00Z',
+        createTimeMax: '2026-10-16T00:00:00Z',
+      });
+
+      expect(mockWebhookConfigRepository.forUser).toHaveBeenCalledWith('u1');
+      expect(scopedConfigRepository.getOrFail).toHaveBeenCalledWith({ id: 'hook2' }, 'Webhook config not found');
+      expect(mockSendRetryRepository.listAndCount).toHaveBeenCalledWith(
+        {
+          webhookId: 'hook2',
+          status: 'failed',
+          createdAt: { $gte: new Date('2026-10-01T00:00:00Z'), $lte: new Date('2026-10-16T00:00:00Z') },
+        },
+        { limit: 20, offset: 40, orderBy: { createdAt: 'DESC', id: 'DESC' } },
+      );
+      expect(result).toEqual({ history: [{ id: 'r1' }], total: 41, page: 3, limit: 20 });
+    });
+
+    it('未指定 webhookId 时使用当前配置，没有配置时返回空列表', async () => {
+      scopedConfigRepository.get.mockResolvedValue(null);
+
+      const result = await service.getWebhookHistory('u1', {}, 't1');
+
+      expect(scopedConfigRepository.get).toHaveBeenCalledWith({ tenantId: 't1' });
+      expect(mockSendRetryRepository.listAndCount).not.toHaveBeenCalled();
+      expect(result).toEqual({ history: [], total: 0, page: 1, limit: 20 });
+    });
+
+    it('不属于当前用户的 webhookId 应返回 404', async () => {
+      scopedConfigRepository.getOrFail.mockRejectedValue(new NotFoundException('Webhook config not found'));
+
+      await expect(service.getWebhookHistory('u1', { webhookId: 'other' })).rejects.toThrow(NotFoundException);
+      expect(mockSendRetryRepository.listAndCount).not.toHaveBeenCalled();
+    });
+
+    it('时间参数无效或区间颠倒时应拒绝', async () => {
+      scopedConfigRepository.get.mockResolvedValue({ id: 'hook1' });
+
+      await expect(service.getWebhookHistory('u1', { createTimeMin: 'yesterday' })).rejects.toThrow(
+        new BadRequestException('Invalid create_time_min timestamp'),
+      );
+      await expect(
+        service.getWebhookHistory('u1', { createTimeMin: '2026-10-16', createTimeMax: '2026-10-01' }),
+      ).rejects.toThrow(BadRequestException);
+    });
+  });
+
+  describe('updateAuth', () => {
+    it('应加密保存请求头，只公开请求头名称，推送时解密附带', async () => {
+      const config: any = { id: 'hook1', userId: 'u1' };
+      scopedConfigRepository.getOrFail.mockResolvedValue(config);
+
+      await service.updateAuth('u1', 'hook1', { headers: { 'X-Api-Key': 'secret' } });
+
+      expect(config.authHeaderNames).toEqual(['X-Api-Key']);
+      expect(config.authHeaders).not.toContain('secret');
+      expect(service.deliveryHeaders(config)).toEqual({ 'X-Api-Key': 'secret' });
+    });
+
+    it('Basic 认证应转换为 Authorization 请求头，都不传时清除', async () => {
+      const config: any = { id: 'hook1', userId: 'u1' };
+      scopedConfigRepository.getOrFail.mockResolvedValue(config);
+
+      await service.updateAuth('u1', 'hook1', { basicAuth: { username: 'user', password: 'pass' } });
+      const credentials = Buffer.from('user:pass').toString('base64');
+      expect(service.deliveryHeaders(config)).toEqual({ Authorization: `Basic ${credentials}` });
+
+      await service.updateAuth('u1', 'hook1', {});
+      expect(config).toMatchObject({ authHeaders: null, authHeaderNames: null });
+      expect(service.deliveryHeaders(config)).toEqual({});
+    });
+
+    it('应拒绝无效、保留或冲突的请求头', async () => {
+      scopedConfigRepository.getOrFail.mockResolvedValue({ id: 'hook1' });
+
+      await expect(service.updateAuth('u1', 'hook1', { headers: { 'X-Bad': 'a\r\nHost: evil' } })).rejects.toThrow(
+        'Invalid webhook header X-Bad',
+      );
+      await expect(service.updateAuth('u1', 'hook1', { headers: { 'Content-Type': 'text/plain' } })).rejects.toThrow(
+        'Webhook header Content-Type is set by the delivery worker',
+      );
+      await expect(
+        service.updateAuth('u1', 'hook1', {
+          headers: { authorization: 'Bearer x' },
+          basicAuth: { username: 'user', password: 'pass' },
+        }),
+      ).rejects.toThrow(BadRequestException);
+    });
+  });
+
+  describe('updateTls', () => {
+    const settings = {
+      ca: 'ca-pem',
+      clientCert: 'cert-pem',
+      clientKey: 'encrypted-key',
+      info: { clientCert: { subject: 'CN=client' } },
+    };
+
+    it('应保存证书、加密后的私钥和证书信息', async () => {
+      const config: any = { id: 'hook1', webhookUrl: 'https://hooks.internal.example.com' };
+      scopedConfigRepository.getOrFail.mockResolvedValue(config);
+      mockWebhookTlsService.prepare.mockReturnValue(settings);
+      mockPlanLimitsService.hasFeature.mockResolvedValue(true);
+
+      await service.updateTls('u1', 'hook1', { ca: 'ca-pem', clientCert: 'cert-pem', clientKey: 'key-pem' });
+
+      expect(mockPlanLimitsService.hasFeature).toHaveBeenCalledWith('u1', 'customIntegrations');
+      expect(config).toMatchObject({
+        tlsCa: 'ca-pem',
+        tlsClientCert: 'cert-pem',
+        tlsClientKey: 'encrypted-key',
+        tlsInfo: settings.info,
+      });
+    });
+
+    it('计划不支持或 webhook 地址不是 https 时应拒绝', async () => {
+      scopedConfigRepository.getOrFail.mockResolvedValue({ id: 'hook1', webhookUrl: 'http://hooks.example.com' });
+      mockWebhookTlsService.prepare.mockReturnValue(settings);
+
+      mockPlanLimitsService.hasFeature.mockResolvedValueOnce(false);
+      await expect(service.updateTls('u1', 'hook1', { ca: 'ca-pem' })).rejects.toThrow(ForbiddenException);
+
+      mockPlanLimitsService.hasFeature.mockResolvedValueOnce(true);
+      await expect(service.updateTls('u1', 'hook1', { ca: 'ca-pem' })).rejects.toThrow(
+        'Webhook TLS settings require an https webhook URL',
+      );
+      expect(mockWebhookConfigRepository.update).not.toHaveBeenCalled();
+    });
+
+    it('都不传时清除 TLS 设置', async () => {
+      const config: any = { id: 'hook1', webhookUrl: 'http://hooks.example.com', tlsCa: 'ca-pem' };
+      scopedConfigRepository.getOrFail.mockResolvedValue(config);
+      mockWebhookTlsService.prepare.mockReturnValue({});
+
+      await service.updateTls('u1', 'hook1', {});
+
+      expect(config).toMatchObject({ tlsCa: null, tlsClientCert: null, tlsClientKey: null, tlsInfo: null });
+    });
+  });
+});
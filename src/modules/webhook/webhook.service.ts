@@ -1,15 +1,43 @@
-import { Injectable, ForbiddenException } from '@nestjs/common';
+import { Injectable, ForbiddenException, BadRequestException } from '@nestjs/common';
 import { InjectQueue } from '@nestjs/bull';
 import { Queue } from 'bull';
 import { Logger } from '@nestjs/common';
-import { EntityManager } from '@mikro-orm/core';
-import { WebhookConfig } from './entities/webhook-config.entity';
-import { SubscriptionService } from '../subscription/subscription.service';
+import { WebhookConfig, WebhookPayloadFormat } from './entities/webhook-config.entity';
+import { WebhookConfigRepository } from './repositories/webhook-config.repository';
+import { SendRetryRepository } from './repositories/send-retry.repository';
+import { PlanLimitsService } from '../subscription/services/plan-limits.service';
 import { v4 as uuidv4 } from 'uuid';
-import { SendRetry } from '../translation/entities/send-retry.entity';
 import { ConfigService } from '@nestjs/config';
 import { HttpService } from '@nestjs/axios';
 import { firstValueFrom } from 'rxjs';
+import { ErrorReporterService } from '../../common/services/error-reporter.service';
+import { WebhookDeliveryLimitsDto } from './dto/webhook-delivery-limits.dto';
+import { WebhookAuthDto } from './dto/webhook-auth.dto';
+import { SecretBoxService } from '../../common/services/secret-box.service';
+import { WebhookTlsDto } from './dto/webhook-tls.dto';
+import { WebhookTlsService } from './services/webhook-tls.service';
+import { NotificationChannelService } from './services/notification-channel.service';
+import { Agent } from 'https';
+
+/** 发送历史的投递结果 */
+export const WEBHOOK_DELIVERY_STATUSES = ['success', 'failed'] as const;
+export type WebhookDeliveryStatus = (typeof WEBHOOK_DELIVERY_STATUSES)[number];
+
+/** 每个配置最多附带的请求头数量 */
+export const MAX_WEBHOOK_AUTH_HEADERS = 10;
+/** 由推送 worker 自己设置、不允许覆盖的请求头 */
+const RESERVED_WEBHOOK_HEADERS = ['host', 'content-length', 'content-type', 'transfer-encoding', 'connection'];
+const HEADER_NAME_PATTERN = /^[!#$%&'*+.^_`|~0-9A-Za-z-]{1,64}$/;
+
+export interface WebhookHistoryFilter {
+  page?: number;
+  limit?: number;
+  createTimeMin?: string;
+  createTimeMax?: string;
+  status?: WebhookDeliveryStatus;
+  /** 指定配置（须属于当前用户），为空时使用账户或租户当前的配置 */
+  webhookId?: string;
+}
 
 @Injectable()
 export class WebhookService {
@@ -17,26 +45,30 @@ export class WebhookService {
 
   constructor(
     @InjectQueue('webhook') private readonly webhookQueue: Queue,
-    private readonly em: EntityManager,
-    private readonly subscriptionService: SubscriptionService,
+    private readonly webhookConfigRepository: WebhookConfigRepository,
+    private readonly sendRetryRepository: SendRetryRepository,
+    private readonly planLimitsService: PlanLimitsService,
     private readonly configService: ConfigService,
     private readonly httpService: HttpService,
+    private readonly errorReporter: ErrorReporterService,
+    private readonly secretBox: SecretBoxService,
+    private readonly webhookTlsService: WebhookTlsService,
+    private readonly notificationChannelService: NotificationChannelService,
   ) {}
 
-  async createWebhookConfig(userId: string, webhookUrl: string): Promise<WebhookConfig> {
+  async createWebhookConfig(userId: string, webhookUrl: string, tenantId?: string): Promise<WebhookConfig> {
     // 检查用户是否有权限使用 webhook
-    const canUseWebhook = await this.subscriptionService.canUseWebhook(userId);
+    const canUseWebhook = await this.planLimitsService.hasFeature(userId, 'webhooks');
     if (!canUseWebhook) {
       throw new ForbiddenException('Webhook functionality is only available for paid users');
     }
 
-    const config = this.em.create(WebhookConfig, {
+    return this.webhookConfigRepository.forUser(userId).insert({
       id: uuidv4(),
       userId,
       webhookUrl,
+      tenantId,
     });
-    await this.em.persistAndFlush(config);
-    return config;
   }
 
   async notifyTranslationComplete(
@@ -62,65 +94,264 @@ export class WebhookService {
     }
   }
 
-  async getWebhookConfig(userId: string) {
-    return this.em.findOne(WebhookConfig, { userId });
+  /**
+   * 向账户（或租户）的 webhook 投递事件，失败时按固定间隔重试，每次尝试都记录到发送历史；
+   * 订阅了该事件的邮件 / Slack 渠道同时入队。没有配置 webhook 时返回 false
+   */
+  async dispatchEvent(
+    userId: string,
+    tenantId: string | undefined,
+    event: string,
+    data: Record<string, any>,
+    maxRetries = 3,
+  ): Promise<boolean> {
+    await this.notificationChannelService
+      .publish(userId, tenantId, event, data)
+      .catch((error) => this.logger.error(`Failed to queue ${event} notifications: ${error.message}`));
+
+    const webhookConfig = await this.resolveDeliveryConfig(userId, tenantId);
+    if (!webhookConfig) {
+      return false;
+    }
+
+    const eventId = uuidv4();
+    const payload = { id: eventId, event, code: 200, msg: 'Success', data, createdAt: new Date().toISOString() };
+
+    for (let attempt = 1; attempt <= maxRetries; attempt++) {
+      try {
+        const response = await firstValueFrom(
+          this.httpService.post(webhookConfig.webhookUrl, payload, {
+            headers: this.deliveryHeaders(webhookConfig),
+            httpsAgent: this.deliveryAgent(webhookConfig),
+          }),
+        );
+        if (response.status >= 200 && response.status < 300) {
+          await this.recordDelivery(webhookConfig.id, eventId, 'success', attempt, payload);
+          return true;
+        }
+      } catch (error) {
+        this.logger.error(`Event ${event} delivery attempt ${attempt}/${maxRetries} failed: ${error.message}`);
+      }
+      await this.recordDelivery(webhookConfig.id, eventId, 'failed', attempt, payload);
+      if (attempt < maxRetries) {
+        await new Promise(resolve => setTimeout(resolve, 2000));
+      }
+    }
+
+    this.errorReporter.captureException(new Error(`Webhook event ${event} failed after ${maxRetries} attempts`), {
+      source: 'webhook',
+      userId,
+      tenantId,
+      tags: { event },
+      extra: { eventId, webhookId: webhookConfig.id },
+    });
+    return false;
+  }
+
+  private async recordDelivery(webhookId: string, eventId: string, status: string, attempt: number, payload: any) {
+    await this.sendRetryRepository.insert({
+      id: uuidv4(),
+      webhookId,
+      taskId: eventId,
+      attempt,
+      status,
+      payload: JSON.stringify(payload),
+    });
+  }
+
+  /**
+   * 获取账户（或指定租户）的 webhook 配置
+   */
+  async getWebhookConfig(userId: string, tenantId?: string): Promise<WebhookConfig | null> {
+    return this.webhookConfigRepository.forUser(userId).get({ tenantId: tenantId ?? null });
+  }
+
+  /**
+   * 投递时使用的配置：优先租户专属配置，没有时回退到账户默认配置
+   */
+  async resolveDeliveryConfig(userId: string, tenantId?: string): Promise<WebhookConfig | null> {
+    if (tenantId) {
+      const tenantConfig = await this.getWebhookConfig(userId, tenantId);
+      if (tenantConfig) {
+        return tenantConfig;
+      }
+    }
+    return this.getWebhookConfig(userId);
   }
 
   async updateWebhookConfig(userId: string, id: string, webhookUrl: string) {
-    const webhookConfig = await this.em.findOne(WebhookConfig, { id, userId });
-    if (!webhookConfig) {
-      throw new Error('Webhook config not found');
+    const webhookConfig = await this.getOwnedConfig(userId, id);
+    return this.webhookConfigRepository.update(webhookConfig, { webhookUrl });
+  }
+
+  /**
+   * 设置推送并发和速率上限，由推送 worker 执行
+   */
+  async updateDeliveryLimits(userId: string, id: string, dto: WebhookDeliveryLimitsDto): Promise<WebhookConfig> {
+    const webhookConfig = await this.getOwnedConfig(userId, id);
+    const changes: Partial<WebhookConfig> = {};
+    if (dto.maxConcurrency !== undefined) {
+      changes.maxConcurrency = dto.maxConcurrency ?? null;
     }
-    webhookConfig.webhookUrl = webhookUrl;
-    await this.em.persistAndFlush(webhookConfig);
-    return webhookConfig;
+    if (dto.maxPerMinute !== undefined) {
+      changes.maxPerMinute = dto.maxPerMinute ?? null;
+    }
+    return this.webhookConfigRepository.update(webhookConfig, changes);
   }
 
-  async deleteWebhookConfig(userId: string, id: string) {
-    const webhookConfig = await this.em.findOne(WebhookConfig, { id, userId });
-    if (!webhookConfig) {
-      throw new Error('Webhook config not found');
+  /**
+   * 切换翻译结果推送的载荷格式（旧格式只为兼容保留），以及事件信封是否附带差异
+   */
+  async updatePayloadFormat(
+    userId: string,
+    id: string,
+    payloadFormat: WebhookPayloadFormat,
+    includeDiff?: boolean,
+  ): Promise<WebhookConfig> {
+    const webhookConfig = await this.getOwnedConfig(userId, id);
+    return this.webhookConfigRepository.update(webhookConfig, {
+      payloadFormat,
+      ...(includeDiff !== undefined && { includeDiff }),
+    });
+  }
+
+  /**
+   * 设置推送时附带的静态请求头或 Basic 认证，加密保存；整体替换已有配置，都不传时清除
+   */
+  async updateAuth(userId: string, id: string, dto: WebhookAuthDto): Promise<WebhookConfig> {
+    const webhookConfig = await this.getOwnedConfig(userId, id);
+    const headers = this.buildAuthHeaders(dto);
+    const names = Object.keys(headers);
+    return this.webhookConfigRepository.update(webhookConfig, {
+      authHeaders: names.length ? this.secretBox.encryptJson(headers) : null,
+      authHeaderNames: names.length ? names : null,
+    });
+  }
+
+  /**
+   * 推送时附带的认证请求头，解密失败时抛出异常（按推送失败处理，不会不带认证发出）
+   */
+  deliveryHeaders(webhookConfig: WebhookConfig): Record<string, string> {
+    return webhookConfig.authHeaders ? this.secretBox.decryptJson(webhookConfig.authHeaders) : {};
+  }
+
+  /**
+   * 设置推送使用的自定义 CA 和客户端证书（双向 TLS），整体替换已有配置，都不传时清除；
+   * 需要包含 customIntegrations 的计划，且 webhook 地址必须是 https
+   */
+  async updateTls(userId: string, id: string, dto: WebhookTlsDto): Promise<WebhookConfig> {
+    const webhookConfig = await this.getOwnedConfig(userId, id);
+    const settings = this.webhookTlsService.prepare(dto);
+    if (settings.info) {
+      if (!(await this.planLimitsService.hasFeature(userId, 'customIntegrations'))) {
+        throw new ForbiddenException('Webhook TLS settings require a plan with custom integrations');
+      }
+      if (!webhookConfig.webhookUrl.startsWith('https://')) {
+        throw new BadRequestException('Webhook TLS settings require an https webhook URL');
+      }
+    }
+    return this.webhookConfigRepository.update(webhookConfig, {
+      tlsCa: settings.ca ?? null,
+      tlsClientCert: settings.clientCert ?? null,
+      tlsClientKey: settings.clientKey ?? null,
+      tlsInfo: settings.info ?? null,
+    });
+  }
+
+  /**
+   * 推送使用的 https.Agent（自定义 CA / 客户端证书），没有 TLS 设置时为 undefined
+   */
+  deliveryAgent(webhookConfig: WebhookConfig): Agent | undefined {
+    return this.webhookTlsService.agentFor(webhookConfig);
+  }
+
+  private buildAuthHeaders(dto: WebhookAuthDto): Record<string, string> {
+    const headers: Record<string, string> = {};
+    for (const [name, value] of Object.entries(dto.headers ?? {})) {
+      const validValue = typeof value === 'string' && value.length <= 1024 && !/[\r\n]/.test(value);
+      if (!HEADER_NAME_PATTERN.test(name) || !validValue) {
+        throw new BadRequestException(`Invalid webhook header ${name}`);
+      }
+      if (RESERVED_WEBHOOK_HEADERS.includes(name.toLowerCase())) {
+        throw new BadRequestException(`Webhook header ${name} is set by the delivery worker`);
+      }
+      headers[name] = value;
+    }
+    if (dto.basicAuth) {
+      if (Object.keys(headers).some((name) => name.toLowerCase() === 'authorization')) {
+        throw new BadRequestException('Use either basicAuth or an Authorization header, not both');
+      }
+      const { username, password } = dto.basicAuth;
+      headers.Authorization = `Basic ${Buffer.from(`${username}:${password}`).toString('base64')}`;
     }
-    await this.em.removeAndFlush(webhookConfig);
+    if (Object.keys(headers).length > MAX_WEBHOOK_AUTH_HEADERS) {
+      throw new BadRequestException(`At most ${MAX_WEBHOOK_AUTH_HEADERS} webhook headers are allowed`);
+    }
+    return headers;
+  }
+
+  async deleteWebhookConfig(userId: string, id: string) {
+    const webhookConfig = await this.getOwnedConfig(userId, id);
+    await this.webhookConfigRepository.delete(webhookConfig);
     return { success: true };
   }
 
-  async getWebhookHistory(
-    userId: string,
-    page = 1,
-    limit = 20,
-    createTimeMin?: string,
-    createTimeMax?: string,
-  ) {
-    const webhookConfig = await this.em.findOne(WebhookConfig, { userId });
+  /**
+   * 分页查询发送历史：过滤、排序和分页都在数据库中完成
+   * 未指定 webhookId 时查询账户（或租户）当前的配置；同一时间的记录按 id 排序，翻页结果稳定
+   */
+  async getWebhookHistory(userId: string, filter: WebhookHistoryFilter = {}, tenantId?: string) {
+    const { page = 1, limit = 20 } = filter;
+    const webhookConfig = filter.webhookId
+      ? await this.getOwnedConfig(userId, filter.webhookId)
+      : await this.getWebhookConfig(userId, tenantId);
     if (!webhookConfig) {
-      return { history: [], total: 0 };
+      return { history: [], total: 0, page, limit };
     }
 
     const query: any = { webhookId: webhookConfig.id };
-    if (createTimeMin) {
-      query.createdAt = { $gte: new Date(createTimeMin) };
+    if (filter.status) {
+      query.status = filter.status;
     }
-    if (createTimeMax) {
-      query.createdAt = { ...query.createdAt, $lte: new Date(createTimeMax) };
+    const createdAt = this.parseTimeRange(filter.createTimeMin, filter.createTimeMax);
+    if (createdAt) {
+      query.createdAt = createdAt;
     }
 
-    const [history, total] = await this.em.findAndCount(SendRetry, query, {
+    const [history, total] = await this.sendRetryRepository.listAndCount(query, {
       limit,
       offset: (page - 1) * limit,
-      orderBy: { createdAt: 'DESC' },
+      orderBy: { createdAt: 'DESC', id: 'DESC' },
     });
 
-    return { history, total };
+    return { history, total, page, limit };
   }
 
-  async getWebhookDetails(userId: string, id: string) {
-    const webhookConfig = await this.em.findOne(WebhookConfig, { id, userId });
-    if (!webhookConfig) {
-      throw new Error('Webhook config not found');
+  private parseTimeRange(min?: string, max?: string): { $gte?: Date; $lte?: Date } | undefined {
+    const range: { $gte?: Date; $lte?: Date } = {};
+    for (const [field, value, operator] of [
+      ['create_time_min', min, '$gte'],
+      ['create_time_max', max, '$lte'],
+    ] as const) {
+      if (!value) {
+        continue;
+      }
+      const date = new Date(value);
+      if (Number.isNaN(date.getTime())) {
+        throw new BadRequestException(`Invalid ${field} timestamp`);
+      }
+      range[operator] = date;
+    }
+    if (range.$gte && range.$lte && range.$gte > range.$lte) {
+      throw new BadRequestException('create_time_min must not be after create_time_max');
     }
+    return range.$gte || range.$lte ? range : undefined;
+  }
+
+  async getWebhookDetails(userId: string, id: string) {
+    const webhookConfig = await this.getOwnedConfig(userId, id);
 
-    const retries = await this.em.find(SendRetry, { webhookId: webhookConfig.id }, {
+    const retries = await this.sendRetryRepository.list({ webhookId: webhookConfig.id }, {
       orderBy: { createdAt: 'DESC' },
     });
 
@@ -131,12 +362,9 @@ export class WebhookService {
   }
 
   async getWebhookStatus(userId: string, id: string) {
-    const webhookConfig = await this.em.findOne(WebhookConfig, { id, userId });
-    if (!webhookConfig) {
-      throw new Error('Webhook config not found');
-    }
+    const webhookConfig = await this.getOwnedConfig(userId, id);
 
-    const retries = await this.em.find(SendRetry, { webhookId: webhookConfig.id }, {
+    const retries = await this.sendRetryRepository.list({ webhookId: webhookConfig.id }, {
       orderBy: { createdAt: 'DESC' },
       limit: 10,
     });
@@ -147,4 +375,8 @@ export class WebhookService {
       createdAt: retry.createdAt,
     }));
   }
+
+  private async getOwnedConfig(userId: string, id: string): Promise<WebhookConfig> {
+    return this.webhookConfigRepository.forUser(userId).getOrFail({ id }, 'Webhook config not found');
+  }
 }
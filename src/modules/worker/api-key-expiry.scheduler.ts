@@ -0,0 +1,29 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { Cron, CronExpression } from '@nestjs/schedule';
+import { ApiKeyExpiryService } from '../api-key/api-key-expiry.service';
+
+/**
+ * 定时发送 API Key 到期提醒，只在 worker 角色中注册
+ */
+@Injectable()
+export class ApiKeyExpiryScheduler {
+  private readonly logger = new Logger(ApiKeyExpiryScheduler.name);
+  private running = false;
+
+  constructor(private readonly apiKeyExpiryService: ApiKeyExpiryService) {}
+
+  @Cron(CronExpression.EVERY_HOUR)
+  async remind(): Promise<void> {
+    if (this.running) {
+      return;
+    }
+    this.running = true;
+    try {
+      await this.apiKeyExpiryService.remindExpiring();
+    } catch (error) {
+      this.logger.error(`API key expiry reminders failed, will retry: ${error.message}`);
+    } finally {
+      this.running = false;
+    }
+  }
+}
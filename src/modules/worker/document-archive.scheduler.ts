@@ -0,0 +1,33 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { Cron, CronExpression } from '@nestjs/schedule';
+import { DocumentArchiveService } from '../translation/services/document-archive.service';
+
+/**
+ * 定时把到期的已完成文档归档到对象存储，只在 worker 角色中注册
+ */
+@Injectable()
+export class DocumentArchiveScheduler {
+  private readonly logger = new Logger(DocumentArchiveScheduler.name);
+  private running = false;
+
+  constructor(private readonly documentArchiveService: DocumentArchiveService) {}
+
+  @Cron(CronExpression.EVERY_HOUR)
+  async archive(): Promise<void> {
+    if (this.running) {
+      return;
+    }
+    this.running = true;
+    try {
+      let cursor: Date | undefined;
+      let scanned: number;
+      do {
+        ({ scanned, cursor } = await this.documentArchiveService.archiveBatch(cursor));
+      } while (scanned > 0);
+    } catch (error) {
+      this.logger.error(`Document archiving failed, will retry: ${error.message}`);
+    } finally {
+      this.running = false;
+    }
+  }
+}
@@ -0,0 +1,29 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { Cron, CronExpression } from '@nestjs/schedule';
+import { DocumentRetentionService } from '../translation/services/document-retention.service';
+
+/**
+ * 定时按保留策略清除到期文档的内容，只在 worker 角色中注册
+ */
+@Injectable()
+export class DocumentRetentionScheduler {
+  private readonly logger = new Logger(DocumentRetentionScheduler.name);
+  private running = false;
+
+  constructor(private readonly documentRetentionService: DocumentRetentionService) {}
+
+  @Cron(CronExpression.EVERY_HOUR)
+  async purge(): Promise<void> {
+    if (this.running) {
+      return;
+    }
+    this.running = true;
+    try {
+      await this.documentRetentionService.purgeDue();
+    } catch (error) {
+      this.logger.error(`Retention purge failed, will retry: ${error.message}`);
+    } finally {
+      this.running = false;
+    }
+  }
+}
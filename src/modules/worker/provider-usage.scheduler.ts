@@ -0,0 +1,29 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { Cron, CronExpression } from '@nestjs/schedule';
+import { ProviderUsageService } from '../translation/services/provider-usage.service';
+
+/**
+ * 定时把翻译服务计费字符的 Redis 计数同步到数据库，只在 worker 角色中注册
+ */
+@Injectable()
+export class ProviderUsageScheduler {
+  private readonly logger = new Logger(ProviderUsageScheduler.name);
+  private running = false;
+
+  constructor(private readonly providerUsageService: ProviderUsageService) {}
+
+  @Cron(CronExpression.EVERY_HOUR)
+  async sync(): Promise<void> {
+    if (this.running) {
+      return;
+    }
+    this.running = true;
+    try {
+      await this.providerUsageService.flush();
+    } catch (error) {
+      this.logger.error(`Provider usage sync failed, will retry: ${error.message}`);
+    } finally {
+      this.running = false;
+    }
+  }
+}
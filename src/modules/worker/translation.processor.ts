@@ -1,15 +1,58 @@
-import { Injectable, Logger } from '@nestjs/common';
-import { Process, Processor } from '@nestjs/bull';
-import { Job } from 'bull';
-import { TranslationService } from '../translation/translation.service';
+import { BadRequestException, Injectable, Logger } from '@nestjs/common';
+import { InjectQueue, OnQueueFailed, Process, Processor } from '@nestjs/bull';
+import { Job, JobOptions, Queue } from 'bull';
+import { RETRY_FAILED_KEYS_JOB, TranslationService } from '../translation/translation.service';
 import { TranslationRequest } from '../../models/models';
+import { TranslationTaskRepository } from '../translation/repositories/translation-task.repository';
+import { TranslationTask } from '../translation/entities/translation-task.entity';
+import { SourceSyncService, SOURCE_SYNC_JOB } from '../translation/services/source-sync.service';
+import { TranslateChunkJob, TRANSLATE_CHUNK_JOB } from '../translation/services/translation-chunk.service';
+import {
+  QualityEstimationService,
+  QUALITY_ESTIMATION_JOB,
+} from '../translation/services/quality-estimation.service';
+import {
+  GithubIntegrationService,
+  GithubSyncJob,
+  GITHUB_SYNC_JOB,
+} from '../github/services/github-integration.service';
+import {
+  RerankQueuedTasksJob,
+  RERANK_QUEUED_TASKS_JOB,
+} from '../subscription/services/billing-sync.service';
+import {
+  LanguageBackfillJob,
+  LanguageBackfillService,
+  LANGUAGE_BACKFILL_JOB,
+} from '../translation/services/language-backfill.service';
+import { UserConcurrencyService } from '../translation/services/user-concurrency.service';
+import { WorkerMemoryService } from '../translation/services/worker-memory.service';
+import { ErrorReporterService } from '../../common/services/error-reporter.service';
+import { providerAccountFailure } from '../translation/utils/task-failure';
+
+/**
+ * 同时处理的文档翻译和分片任务数（各自计算），装饰器在模块加载时求值，因此直接读取环境变量；
+ * 与 WEBHOOK_DELIVERY_CONCURRENCY 相互独立
+ */
+const TRANSLATION_WORKER_CONCURRENCY = Number(process.env.TRANSLATION_WORKER_CONCURRENCY || 1);
 
 @Injectable()
 @Processor('translation')
 export class TranslationProcessor {
   private readonly logger = new Logger(TranslationProcessor.name);
 
-  constructor(private readonly translationService: TranslationService) {}
+  constructor(
+    private readonly translationService: TranslationService,
+    private readonly taskRepository: TranslationTaskRepository,
+    private readonly errorReporter: ErrorReporterService,
+    private readonly sourceSyncService: SourceSyncService,
+    private readonly githubIntegrationService: GithubIntegrationService,
+    private readonly qualityEstimationService: QualityEstimationService,
+    private readonly userConcurrencyService: UserConcurrencyService,
+    private readonly workerMemoryService: WorkerMemoryService,
+    private readonly languageBackfillService: LanguageBackfillService,
+    @InjectQueue('translation') private readonly translationQueue: Queue,
+  ) {}
 
   @Process('translate')
   async handleTranslation(job: Job<TranslationRequest>) {
@@ -29,4 +72,156 @@ export class TranslationProcessor {
       throw error;
     }
   }
+
+  @Process({ name: 'translate-json', concurrency: TRANSLATION_WORKER_CONCURRENCY })
+  async handleJsonTranslation(job: Job<{ taskId: string }>) {
+    await this.withUserSlot(job, async () => {
+      try {
+        this.logger.log(`Processing JSON translation task ${job.data.taskId}`);
+        await this.translationService.handleTranslationTask(job.data.taskId);
+        this.logger.log(`JSON translation task ${job.data.taskId} completed`);
+      } catch (error) {
+        this.logger.error(`Failed to process JSON translation task ${job.data.taskId}: ${error.message}`);
+        throw error;
+      }
+    });
+  }
+
+  @Process({ name: TRANSLATE_CHUNK_JOB, concurrency: TRANSLATION_WORKER_CONCURRENCY })
+  async handleChunk(job: Job<TranslateChunkJob>) {
+    await this.withUserSlot(job, () => this.translationService.handleTranslationChunk(job.data));
+  }
+
+  @Process(RETRY_FAILED_KEYS_JOB)
+  async handleFailedKeysRetry(job: Job<{ taskId: string }>) {
+    const task = await this.taskRepository.get({ id: job.data.taskId });
+    const memory = task ? await this.reserveMemory(job, task) : undefined;
+    if (memory && !memory.admitted) {
+      return;
+    }
+    try {
+      await this.translationService.handleFailedKeysRetry(job.data.taskId);
+    } finally {
+      memory?.release();
+    }
+  }
+
+  @Process(QUALITY_ESTIMATION_JOB)
+  async handleQualityEstimation(job: Job<{ taskId: string }>) {
+    await this.qualityEstimationService.estimate(job.data.taskId);
+  }
+
+  @Process(SOURCE_SYNC_JOB)
+  async handleSourceSync(job: Job<{ syncId: string }>) {
+    const result = await this.sourceSyncService.run(job.data.syncId);
+    if (result?.changed) {
+      this.logger.log(`Source sync ${job.data.syncId} translated ${result.changedKeys} changed key(s)`);
+    }
+    return result;
+  }
+
+  @Process(GITHUB_SYNC_JOB)
+  async handleGithubSync(job: Job<GithubSyncJob>) {
+    return this.githubIntegrationService.sync(job.data);
+  }
+
+  @Process(RERANK_QUEUED_TASKS_JOB)
+  async handleRerank(job: Job<RerankQueuedTasksJob>) {
+    return this.translationService.rerankQueuedTasks(job.data.userId);
+  }
+
+  @Process(LANGUAGE_BACKFILL_JOB)
+  async handleLanguageBackfill(job: Job<LanguageBackfillJob>) {
+    return this.languageBackfillService.run(job);
+  }
+
+  /**
+   * 预留内存并占用任务所属用户的执行名额后再处理；进程内存预算不足或用户同时执行的任务已达上限时
+   * 把任务放回队列稍后再试，放回的任务排在同一优先级中已等待的任务之后，当前 worker 转而处理其他任务。放回不计入重试次数
+   */
+  private async withUserSlot(job: Job<{ taskId: string; chunkId?: string }>, work: () => Promise<void>): Promise<void> {
+    const task = await this.taskRepository.get({ id: job.data.taskId });
+    if (!task) {
+      return work();
+    }
+
+    const memory = await this.reserveMemory(job, task);
+    if (!memory.admitted) {
+      return;
+    }
+    const slot = await this.userConcurrencyService.acquire(task.userId);
+    if (!slot.acquired) {
+      memory.release();
+      await this.requeue(job, slot.retryAfterMs);
+      this.logger.debug(
+        `User ${task.userId} is at the concurrent task limit, ${job.name} ${job.data.taskId} requeued`,
+      );
+      return;
+    }
+
+    try {
+      await work();
+    } catch (error) {
+      // 服务商额度用尽或凭证失效（且没有可用的备用服务商）时队列重试只会再次失败，直接记为失败
+      if (!providerAccountFailure(error)) {
+        throw error;
+      }
+      this.logger.warn(`${job.name} ${job.data.taskId} failed without retry: ${error.message}`);
+      await this.translationService.handleTaskFailure(task.id, error, job.attemptsMade + 1, job.data.chunkId);
+    } finally {
+      await slot.release();
+      memory.release();
+    }
+  }
+
+  /**
+   * 按文档大小预留 worker 内存；预算不足时放回队列，超过单个任务上限时任务直接失败（不重试）
+   */
+  private async reserveMemory(job: Job<{ taskId: string; chunkId?: string }>, task: TranslationTask) {
+    const memory = this.workerMemoryService.reserve(Buffer.byteLength(task.content ?? ''));
+    if (memory.rejection === 'busy') {
+      await this.requeue(job, memory.retryAfterMs);
+      this.logger.debug(`Worker memory budget exhausted, ${job.name} ${job.data.taskId} requeued`);
+    } else if (memory.rejection === 'too_large') {
+      const error = new BadRequestException(this.workerMemoryService.tooLargeMessage(memory.estimatedBytes));
+      this.logger.warn(`Rejecting ${job.name} ${job.data.taskId}: ${error.message}`);
+      await this.translationService.handleTaskFailure(task.id, error, job.attemptsMade + 1, job.data.chunkId);
+    }
+    return memory;
+  }
+
+  /**
+   * 周期任务本次触发的实例只需要延后执行一次，不能再注册一个重复任务
+   */
+  private async requeue(job: Job, delay: number): Promise<void> {
+    const { jobId, repeat, delay: _delay, ...options } = job.opts;
+    await this.translationQueue.add(job.name, job.data, { ...options, delay } as JobOptions);
+  }
+
+  /**
+   * 重试次数用尽后上报错误追踪，附带任务归属信息；翻译任务和分片同时发送 translation.failed webhook
+   */
+  @OnQueueFailed()
+  async onFailed(job: Job, error: Error) {
+    if (job.attemptsMade < (job.opts.attempts ?? 1)) {
+      return;
+    }
+
+    const taskId: string | undefined = job.data?.taskId;
+    const task = taskId ? await this.taskRepository.get({ id: taskId }).catch(() => null) : null;
+    this.errorReporter.captureException(error, {
+      source: 'worker',
+      userId: task?.userId,
+      tenantId: task?.tenantId,
+      documentId: taskId,
+      tags: { queue: 'translation', job: job.name },
+      extra: { jobId: job.id, attempts: job.attemptsMade, project: task?.project },
+    });
+
+    if (task && (job.name === 'translate-json' || job.name === TRANSLATE_CHUNK_JOB)) {
+      await this.translationService
+        .handleTaskFailure(task.id, error, job.attemptsMade, job.data?.chunkId)
+        .catch((failure) => this.logger.error(`Failed to record failure of task ${task.id}: ${failure.message}`));
+    }
+  }
 } 
\ No newline at end of file
@@ -0,0 +1,32 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { Cron, CronExpression } from '@nestjs/schedule';
+import { UsageReportService } from '../translation/services/usage-report.service';
+
+/**
+ * 定时发送到期的每周 / 每月用量报告，只在 worker 角色中注册
+ */
+@Injectable()
+export class UsageReportScheduler {
+  private readonly logger = new Logger(UsageReportScheduler.name);
+  private running = false;
+
+  constructor(private readonly usageReportService: UsageReportService) {}
+
+  @Cron(CronExpression.EVERY_HOUR)
+  async send(): Promise<void> {
+    if (this.running) {
+      return;
+    }
+    this.running = true;
+    try {
+      const sent = await this.usageReportService.sendDue();
+      if (sent > 0) {
+        this.logger.log(`Sent ${sent} usage report(s)`);
+      }
+    } catch (error) {
+      this.logger.error(`Usage report run failed, will retry: ${error.message}`);
+    } finally {
+      this.running = false;
+    }
+  }
+}
@@ -0,0 +1,37 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { Interval } from '@nestjs/schedule';
+import { UsageRollupService } from '../translation/services/usage-rollup.service';
+
+/** 汇总间隔，装饰器在模块加载时求值，因此直接读取环境变量 */
+const USAGE_ROLLUP_INTERVAL_MS = Number(process.env.USAGE_ROLLUP_INTERVAL_MS || 5000);
+
+/**
+ * 定时批量汇总字符用量事件，只在 worker 角色中注册
+ * 事件持久化在 Redis 中，停止时未汇总的事件由下一个 worker 继续处理
+ */
+@Injectable()
+export class UsageRollupScheduler {
+  private readonly logger = new Logger(UsageRollupScheduler.name);
+  private running = false;
+
+  constructor(private readonly usageRollupService: UsageRollupService) {}
+
+  @Interval(USAGE_ROLLUP_INTERVAL_MS)
+  async rollup(): Promise<void> {
+    if (this.running) {
+      return;
+    }
+    this.running = true;
+    try {
+      // 每次取一批，积压时连续处理直到清空
+      let claimed: number;
+      do {
+        ({ claimed } = await this.usageRollupService.flush());
+      } while (claimed > 0);
+    } catch (error) {
+      this.logger.error(`Usage rollup failed, will retry: ${error.message}`);
+    } finally {
+      this.running = false;
+    }
+  }
+}
@@ -0,0 +1,30 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { Cron, CronExpression } from '@nestjs/schedule';
+import { WarehouseExportService } from '../translation/services/warehouse-export.service';
+
+/**
+ * 每天把前一天的推送记录和用量事件导出到对象存储，只在 worker 角色中注册
+ * 每小时检查一次，当天已导出时不会重复执行，停机错过的日期在下次检查时补导
+ */
+@Injectable()
+export class WarehouseExportScheduler {
+  private readonly logger = new Logger(WarehouseExportScheduler.name);
+  private running = false;
+
+  constructor(private readonly warehouseExportService: WarehouseExportService) {}
+
+  @Cron(CronExpression.EVERY_HOUR)
+  async export(): Promise<void> {
+    if (this.running || !this.warehouseExportService.enabled) {
+      return;
+    }
+    this.running = true;
+    try {
+      await this.warehouseExportService.exportPending();
+    } catch (error) {
+      this.logger.error(`Warehouse export failed, will retry: ${error.message}`);
+    } finally {
+      this.running = false;
+    }
+  }
+}
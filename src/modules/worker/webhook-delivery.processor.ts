@@ -0,0 +1,45 @@
+import { Injectable, Logger } from '@nestjs/common';
+import { Process, Processor } from '@nestjs/bull';
+import { Job } from 'bull';
+import { TranslationService } from '../translation/translation.service';
+import {
+  WEBHOOK_DELIVERY_JOB,
+  WebhookDeliveryJob,
+} from '../translation/interfaces/webhook-delivery-job.interface';
+import {
+  NOTIFICATION_DELIVERY_JOB,
+  NotificationChannelService,
+  NotificationDeliveryJob,
+} from '../webhook/services/notification-channel.service';
+
+/** 同时进行的推送数，装饰器在模块加载时求值，因此直接读取环境变量 */
+const WEBHOOK_DELIVERY_CONCURRENCY = Number(process.env.WEBHOOK_DELIVERY_CONCURRENCY || 5);
+
+/**
+ * 翻译结果 webhook 推送和邮件 / Slack 通知的消费者
+ * 任务持久化在 Redis 中，失败按指数退避重试，次数由入队时的 attempts 决定
+ */
+@Injectable()
+@Processor('webhook')
+export class WebhookDeliveryProcessor {
+  private readonly logger = new Logger(WebhookDeliveryProcessor.name);
+
+  constructor(
+    private readonly translationService: TranslationService,
+    private readonly notificationChannelService: NotificationChannelService,
+  ) {}
+
+  @Process({ name: WEBHOOK_DELIVERY_JOB, concurrency: WEBHOOK_DELIVERY_CONCURRENCY })
+  async handleDelivery(job: Job<WebhookDeliveryJob>) {
+    const attempt = job.attemptsMade + 1;
+    this.logger.log(`Delivering translation result for task ${job.data.taskId} (attempt ${attempt})`);
+    await this.translationService.deliverTranslationResult(job.data, attempt, job.opts.attempts ?? 1);
+  }
+
+  @Process({ name: NOTIFICATION_DELIVERY_JOB, concurrency: WEBHOOK_DELIVERY_CONCURRENCY })
+  async handleNotification(job: Job<NotificationDeliveryJob>) {
+    const attempt = job.attemptsMade + 1;
+    this.logger.log(`Delivering ${job.data.event} notification to channel ${job.data.channelId} (attempt ${attempt})`);
+    await this.notificationChannelService.deliver(job.data, attempt, job.opts.attempts ?? 1);
+  }
+}
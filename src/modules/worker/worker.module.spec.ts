@@ -0,0 +1,81 @@
+import { MODULE_METADATA } from '@nestjs/common/constants';
+import { TRANSLATE_CHUNK_JOB } from '../translation/services/translation-chunk.service';
+import { WEBHOOK_DELIVERY_JOB } from '../translation/interfaces/webhook-delivery-job.interface';
+import { NOTIFICATION_DELIVERY_JOB } from '../webhook/services/notification-channel.service';
+
+// @Process 装饰器写入的元数据，值为 { name, concurrency }
+const PROCESS_METADATA = 'bull:module_queue_process';
+
+describe('WorkerModule', () => {
+  const originalEnv = { ...process.env };
+
+  // 队列选择和并发数在模块加载时读取，每个用例用新的模块注册表按指定环境变量重新加载
+  const load = <T>(path: string, env: Record<string, string>): T => {
+    Object.assign(process.env, env);
+    let loaded: T;
+    jest.isolateModules(() => {
+      loaded = require(path);
+    });
+    return loaded;
+  };
+
+  const consumers = (env: Record<string, string>) => {
+    const { WorkerModule } = load<any>('./worker.module', env);
+    const providers: { name: string }[] = Reflect.getMetadata(MODULE_METADATA.PROVIDERS, WorkerModule);
+    return providers.map((provider) => provider.name).filter((name) => name.endsWith('Processor'));
+  };
+
+  // 按任务名返回 @Process 声明的并发数
+  const concurrencyOf = (target: object): Record<string, number | undefined> =>
+    Object.fromEntries(
+      Object.getOwnPropertyNames(target)
+        .map((method) => Reflect.getMetadata(PROCESS_METADATA, target[method]))
+        .filter(Boolean)
+        .map(({ name, concurrency }) => [name, concurrency]),
+    );
+
+  afterEach(() => {
+    process.env = { ...originalEnv };
+  });
+
+  it('未设置 WORKER_QUEUES 时同时消费翻译和推送队列', () => {
+    expect(consumers({})).toEqual(['TranslationProcessor', 'WebhookProcessor', 'WebhookDeliveryProcessor']);
+  });
+
+  it('只消费翻译队列的 worker 不注册推送消费者', () => {
+    expect(consumers({ WORKER_QUEUES: 'translation' })).toEqual(['TranslationProcessor']);
+  });
+
+  it('只消费推送队列的 worker 不注册翻译消费者', () => {
+    expect(consumers({ WORKER_QUEUES: 'webhook' })).toEqual(['WebhookProcessor', 'WebhookDeliveryProcessor']);
+  });
+
+  it('翻译和推送使用各自的并发数', () => {
+    const env = { TRANSLATION_WORKER_CONCURRENCY: '2', WEBHOOK_DELIVERY_CONCURRENCY: '50' };
+    const { TranslationProcessor } = load<any>('./translation.processor', env);
+    const { WebhookDeliveryProcessor } = load<any>('./webhook-delivery.processor', env);
+
+    expect(concurrencyOf(TranslationProcessor.prototype)).toMatchObject({
+      'translate-json': 2,
+      [TRANSLATE_CHUNK_JOB]: 2,
+    });
+    expect(concurrencyOf(WebhookDeliveryProcessor.prototype)).toEqual({
+      [WEBHOOK_DELIVERY_JOB]: 50,
+      [NOTIFICATION_DELIVERY_JOB]: 50,
+    });
+  });
+
+  it('未配置时翻译并发为 1，推送并发为 5', () => {
+    const { TranslationProcessor } = load<any>('./translation.processor', {});
+    const { WebhookDeliveryProcessor } = load<any>('./webhook-delivery.processor', {});
+
+    expect(concurrencyOf(TranslationProcessor.prototype)).toMatchObject({
+      'translate-json': 1,
+      [TRANSLATE_CHUNK_JOB]: 1,
+    });
+    expect(concurrencyOf(WebhookDeliveryProcessor.prototype)).toEqual({
+      [WEBHOOK_DELIVERY_JOB]: 5,
+      [NOTIFICATION_DELIVERY_JOB]: 5,
+    });
+  });
+});
@@ -1,19 +1,32 @@
 import { Module } from '@nestjs/common';
 import { BullModule } from '@nestjs/bull';
-import { ConfigModule, ConfigService } from '@nestjs/config';
+import { ConfigModule } from '@nestjs/config';
 import { TranslationProcessor } from './translation.processor';
 import { WebhookProcessor } from '../webhook/webhook.processor';
+import { WebhookDeliveryProcessor } from './webhook-delivery.processor';
+import { UsageRollupScheduler } from './usage-rollup.scheduler';
+import { DocumentArchiveScheduler } from './document-archive.scheduler';
+import { ProviderUsageScheduler } from './provider-usage.scheduler';
+import { WarehouseExportScheduler } from './warehouse-export.scheduler';
+import { UsageReportScheduler } from './usage-report.scheduler';
+import { DocumentRetentionScheduler } from './document-retention.scheduler';
+import { ApiKeyExpiryScheduler } from './api-key-expiry.scheduler';
+import { TranslationModule } from '../translation/translation.module';
+import { WebhookModule } from '../webhook/webhook.module';
+import { GithubModule } from '../github/github.module';
+import { ApiKeyModule } from '../api-key/api-key.module';
+import { CommonModule } from '../../common/common.module';
+import { buildRedisOptions } from '../../config/redis.config';
+import { resolveWorkerQueues, WorkerQueue } from '../../config/process-role';
+
+const consumedQueues = resolveWorkerQueues(process.env.WORKER_QUEUES);
 
 @Module({
   imports: [
     BullModule.forRootAsync({
       imports: [ConfigModule],
-      useFactory: async (configService: ConfigService) => ({
-        redis: {
-          host: configService.get('REDIS_HOST'),
-          port: configService.get('REDIS_PORT'),
-          password: configService.get('REDIS_PASSWORD'),
-        },
+      useFactory: async () => ({
+        redis: buildRedisOptions(),
         defaultJobOptions: {
           attempts: 3,
           backoff: {
@@ -22,7 +35,6 @@ import { WebhookProcessor } from '../webhook/webhook.processor';
           },
         },
       }),
-      inject: [ConfigService],
     }),
     BullModule.registerQueue(
       {
@@ -46,8 +58,24 @@ import { WebhookProcessor } from '../webhook/webhook.processor';
         },
       },
     ),
+    TranslationModule,
+    WebhookModule,
+    GithubModule,
+    ApiKeyModule,
+    CommonModule,
+  ],
+  providers: [
+    // 只注册 WORKER_QUEUES 中队列的消费者；两个队列仍都注册生产者，翻译 worker 可以继续投递推送任务
+    ...(consumedQueues.includes(WorkerQueue.TRANSLATION) ? [TranslationProcessor] : []),
+    ...(consumedQueues.includes(WorkerQueue.WEBHOOK) ? [WebhookProcessor, WebhookDeliveryProcessor] : []),
+    UsageRollupScheduler,
+    DocumentArchiveScheduler,
+    ProviderUsageScheduler,
+    WarehouseExportScheduler,
+    UsageReportScheduler,
+    DocumentRetentionScheduler,
+    ApiKeyExpiryScheduler,
   ],
-  providers: [TranslationProcessor, WebhookProcessor],
   exports: [BullModule],
 })
 export class WorkerModule {} 
\ No newline at end of file